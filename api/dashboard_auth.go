@@ -0,0 +1,88 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+)
+
+// DashboardTokenHeader carries the dashboard token on API requests that only
+// a human may make, such as resolving an approval or rewinding a workflow.
+// The dashboard sends it as the dashboardCookie instead.
+const DashboardTokenHeader = "X-Stratus-Dashboard-Token"
+
+// dashboardCookie holds the dashboard token in the browser after the user
+// opened the dashboard link printed by `stratus serve`.
+const dashboardCookie = "stratus_dashboard"
+
+// NewDashboardToken returns a random token for SetDashboardToken. It is kept
+// in memory only, so agents running in the project cannot read it from disk.
+func NewDashboardToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("dashboard token: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// SetDashboardToken sets the token that human-only endpoints require. Until
+// it is set those endpoints refuse every request.
+func (s *Server) SetDashboardToken(token string) {
+	s.dashboardToken = token
+}
+
+// fromDashboard reports whether r carries the dashboard token, in the
+// dashboard cookie or the DashboardTokenHeader.
+func (s *Server) fromDashboard(r *http.Request) bool {
+	if s.dashboardToken == "" {
+		return false
+	}
+	got := r.Header.Get(DashboardTokenHeader)
+	if got == "" {
+		if c, err := r.Cookie(dashboardCookie); err == nil {
+			got = c.Value
+		}
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(s.dashboardToken)) == 1
+}
+
+// requireDashboard writes 403 and returns false unless r comes from the
+// dashboard.
+func (s *Server) requireDashboard(w http.ResponseWriter, r *http.Request) bool {
+	if s.fromDashboard(r) {
+		return true
+	}
+	jsonErr(w, http.StatusForbidden, "only a human can do this: open the dashboard link printed by `stratus serve`")
+	return false
+}
+
+// dashboardLogin stores the ?token= of a dashboard link in the dashboard
+// cookie and redirects to the same page without it, so the token does not
+// linger in the address bar or history.
+func (s *Server) dashboardLogin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" || r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if s.dashboardToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.dashboardToken)) == 1 {
+			http.SetCookie(w, &http.Cookie{
+				Name:     dashboardCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+		}
+		target := *r.URL
+		q := target.Query()
+		q.Del("token")
+		target.RawQuery = q.Encode()
+		if s.cfg != nil && s.cfg.DevMode {
+			target.Scheme, target.Host = "http", "localhost:5173"
+		}
+		http.Redirect(w, r, target.String(), http.StatusFound)
+	})
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/orchestration"
+)
+
+func TestDashboardLogin_CookieAuthorizesRewind(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+	coord := orchestration.NewCoordinator(database)
+	if _, err := coord.Start("bug-rewind", orchestration.WorkflowBug, orchestration.ComplexitySimple, "Rewind"); err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{db: database, coordinator: coord, hub: NewHub(), dashboardToken: "secret"}
+	h := server.Handler()
+
+	rewind := func(cookie *http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/workflows/bug-rewind/rewind", strings.NewReader(`{"phase":"analyze","reason":"redo"}`))
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	if w := rewind(nil); w.Code != http.StatusForbidden {
+		t.Fatalf("rewind without the dashboard cookie: expected 403, got %d", w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/workflows?token=wrong", nil))
+	if w.Code != http.StatusFound || len(w.Result().Cookies()) != 0 {
+		t.Fatalf("wrong token: expected a redirect without cookie, got %d %v", w.Code, w.Result().Cookies())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/workflows?token=secret", nil))
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/workflows" {
+		t.Fatalf("login: expected a redirect to /workflows, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != dashboardCookie || !cookies[0].HttpOnly {
+		t.Fatalf("cookies = %v", cookies)
+	}
+	// The workflow has not left analyze, so the rewind passes the guard and
+	// is refused by the coordinator instead.
+	if w := rewind(cookies[0]); w.Code != http.StatusBadRequest {
+		t.Fatalf("rewind with the dashboard cookie: expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
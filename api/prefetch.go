@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/MartinNevlaha/stratus-v2/config"
 	"github.com/MartinNevlaha/stratus-v2/db"
 	"github.com/MartinNevlaha/stratus-v2/events"
 )
@@ -26,7 +27,8 @@ type prefetchQuery struct {
 
 // prefetcher listens for phase-transition events and pre-fetches relevant
 // context (code + governance + wiki) so the next delegated agent can read it
-// from a single memory event instead of re-querying from scratch.
+// from a single memory event instead of re-querying from scratch. The event
+// text is a citation-tagged block packed to Retrieval.PrefetchMaxTokens.
 type prefetcher struct {
 	server  *Server
 	mu      sync.Mutex
@@ -91,12 +93,19 @@ func (p *prefetcher) runPrefetch(ctx context.Context, wfID, wfType, toPhase, tit
 		return
 	}
 
+	budget := p.server.retrievalConfig().PrefetchMaxTokens
+	if budget <= 0 {
+		budget = config.Default().Retrieval.PrefetchMaxTokens
+	}
+	packed := p.server.packRetrieved(title, allResults, true, budget)
+
 	refs := map[string]any{
 		"workflow_id":   wfID,
 		"workflow_type": wfType,
 		"phase":         toPhase,
 		"queries":       queries,
-		"results":       allResults,
+		"citations":     packed.Citations,
+		"tokens":        packed.Tokens,
 	}
 
 	_, err := p.server.db.SaveEvent(db.SaveEventInput{
@@ -104,7 +113,7 @@ func (p *prefetcher) runPrefetch(ctx context.Context, wfID, wfType, toPhase, tit
 		Scope:      "workflow",
 		Type:       "context_prefetch",
 		Title:      fmt.Sprintf("Phase prefetch: %s/%s", wfID, toPhase),
-		Text:       summarizePrefetch(allResults),
+		Text:       packed.Text,
 		Tags:       []string{"context_prefetch", wfType, toPhase},
 		Refs:       refs,
 		Importance: 0.5,
@@ -155,26 +164,3 @@ func buildPrefetchQueries(wfType, toPhase, title string) []prefetchQuery {
 	}
 	return nil
 }
-
-func summarizePrefetch(results []retrieveResult) string {
-	if len(results) == 0 {
-		return "no results"
-	}
-	var sb strings.Builder
-	fmt.Fprintf(&sb, "%d prefetch results", len(results))
-	for i, r := range results {
-		if i >= 3 {
-			break
-		}
-		sb.WriteString("\n- ")
-		switch {
-		case r.Title != "":
-			sb.WriteString(r.Title)
-		case r.FilePath != "":
-			sb.WriteString(r.FilePath)
-		default:
-			sb.WriteString(r.Source)
-		}
-	}
-	return sb.String()
-}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -229,6 +230,13 @@ func TestPrefetcher_SavesEventWithGovernance(t *testing.T) {
 	if count := countPrefetchEvents(t, database); count != 1 {
 		t.Fatalf("expected 1 prefetch event saved, got %d", count)
 	}
+	evs, _ := database.SearchEvents(db.SearchEventsInput{Type: "context_prefetch", Limit: 1})
+	if !strings.HasPrefix(evs[0].Text, "[1] governance .claude/rules/testing.md (rule)") {
+		t.Errorf("prefetch text should be a packed context block, got %q", evs[0].Text)
+	}
+	if _, ok := evs[0].Refs["citations"]; !ok {
+		t.Errorf("prefetch refs should carry citations, got %v", evs[0].Refs)
+	}
 
 	// Verify dedup map was populated.
 	p.mu.Lock()
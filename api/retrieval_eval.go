@@ -0,0 +1,438 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+	"github.com/MartinNevlaha/stratus-v2/vexor"
+)
+
+// goldenFile is the repo-checked golden set: a JSON array of
+// {"query", "corpus", "expected"} objects. It is read on every run and
+// takes precedence over a stored case with the same query and corpus.
+const goldenFile = ".stratus/retrieval-golden.json"
+
+// followUpWindow is how long after a retrieve call an edit or open of one of
+// its results still counts as the agent following up on it.
+const followUpWindow = 30 * time.Minute
+
+// evalRegressionDelta is the drop in a corpus metric, against the previous
+// run with the same k and mode, that is reported as a regression.
+const evalRegressionDelta = 0.05
+
+// RetrievalEvalOptions configure an evaluation run. Offline skips code
+// search and reranking so the run needs nothing but the FTS indexes.
+type RetrievalEvalOptions struct {
+	K       int  `json:"k"`
+	Offline bool `json:"offline"`
+	Save    bool `json:"save"`
+}
+
+// RunRetrievalEvalOffline scores the golden set against the governance and
+// wiki FTS indexes in database without a running server.
+func RunRetrievalEvalOffline(database *db.DB, cfg *config.Config, projectRoot string, k int, save bool) (*db.RetrievalEvalRun, error) {
+	s := &Server{db: database, cfg: cfg, projectRoot: projectRoot, vexor: offlineCodeSearch{}}
+	return s.runRetrievalEval(RetrievalEvalOptions{K: k, Offline: true, Save: save})
+}
+
+// offlineCodeSearch stands in for the code backend in offline runs.
+type offlineCodeSearch struct{}
+
+func (offlineCodeSearch) Available() bool { return false }
+func (offlineCodeSearch) Search(string, int, string) ([]vexor.Result, error) {
+	return nil, errors.New("code search is disabled offline")
+}
+func (offlineCodeSearch) Index([]string) error { return nil }
+
+// runRetrievalEval runs every golden case through retrieve and scores the
+// top k. Cases whose corpus cannot be served are reported as skipped.
+func (s *Server) runRetrievalEval(opts RetrievalEvalOptions) (*db.RetrievalEvalRun, error) {
+	if opts.K <= 0 {
+		opts.K = 10
+	}
+	cases, err := s.goldenCases()
+	if err != nil {
+		return nil, err
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("golden set is empty: add cases, seed them from the query log or write %s", goldenFile)
+	}
+
+	run := &db.RetrievalEvalRun{K: opts.K, Offline: opts.Offline, Metrics: map[string]db.RetrievalMetrics{}}
+	sums := map[string]*db.RetrievalMetrics{}
+	add := func(key string, c db.RetrievalCaseResult) {
+		m := sums[key]
+		if m == nil {
+			m = &db.RetrievalMetrics{}
+			sums[key] = m
+		}
+		m.Cases++
+		m.Recall += c.Recall
+		m.MRR += c.RR
+		m.NDCG += c.NDCG
+	}
+	for _, gc := range cases {
+		res := db.RetrievalCaseResult{Query: gc.Query, Corpus: gc.Corpus, Expected: gc.Expected, Hits: []string{}}
+		if reason := s.evalSkipReason(gc.Corpus, opts.Offline); reason != "" {
+			res.Skipped = reason
+			run.Skipped++
+			run.Results = append(run.Results, res)
+			continue
+		}
+		hits, _ := s.retrieve(gc.Query, gc.Corpus, opts.K, false)
+		res.Hits = s.evalTargets(hits)
+		res.Recall, res.RR, res.NDCG = scoreRetrieval(res.Hits, gc.Expected, opts.K)
+		run.Cases++
+		run.Results = append(run.Results, res)
+		add(corpusLabel(gc.Corpus), res)
+		add("all", res)
+	}
+	for key, m := range sums {
+		n := float64(m.Cases)
+		run.Metrics[key] = db.RetrievalMetrics{Cases: m.Cases, Recall: m.Recall / n, MRR: m.MRR / n, NDCG: m.NDCG / n}
+	}
+
+	prev, err := s.db.LastRetrievalEvalRun(opts.K, opts.Offline)
+	if err != nil {
+		return nil, err
+	}
+	run.Regressions = evalRegressions(prev, run)
+	if opts.Save {
+		if err := s.db.SaveRetrievalEvalRun(run); err != nil {
+			return nil, err
+		}
+	}
+	return run, nil
+}
+
+func (s *Server) evalSkipReason(corpus string, offline bool) string {
+	switch {
+	case offline && (corpus == "code" || symbolCorpora[corpus]):
+		return "needs the code index; not available offline"
+	case corpus == "code" && !s.vexor.Available():
+		return "code search backend unavailable"
+	case symbolCorpora[corpus] && s.symbols == nil:
+		return "symbol index disabled"
+	}
+	return ""
+}
+
+// goldenCases merges the repo's golden file with the stored cases.
+func (s *Server) goldenCases() ([]db.RetrievalGoldenCase, error) {
+	fileCases, err := loadGoldenFile(s.projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.db.ListRetrievalGoldenCases()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	out := make([]db.RetrievalGoldenCase, 0, len(fileCases)+len(stored))
+	for _, c := range append(fileCases, stored...) {
+		key := c.Corpus + "\x00" + c.Query
+		if seen[key] || len(c.Expected) == 0 {
+			continue
+		}
+		seen[key] = true
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func loadGoldenFile(projectRoot string) ([]db.RetrievalGoldenCase, error) {
+	if projectRoot == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(projectRoot, goldenFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cases []db.RetrievalGoldenCase
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", goldenFile, err)
+	}
+	valid := cases[:0]
+	for _, c := range cases {
+		if strings.TrimSpace(c.Query) == "" {
+			continue
+		}
+		c.ID, c.Source = 0, "file"
+		valid = append(valid, c)
+	}
+	return valid, nil
+}
+
+// evalTargets maps hits to the identifiers golden cases use, keeping the
+// first occurrence of each: project-relative paths, or "wiki:<title>".
+func (s *Server) evalTargets(hits []retrieveResult) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, h := range hits {
+		t := s.evalTarget(h)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+func (s *Server) evalTarget(h retrieveResult) string {
+	if h.FilePath != "" {
+		return s.relProjectPath(h.FilePath)
+	}
+	if h.Source == "wiki" && h.Title != "" {
+		return "wiki:" + h.Title
+	}
+	return ""
+}
+
+// targetMatches reports whether a hit satisfies an expected entry. An entry
+// may name the full relative path or a trailing part of it ("errors.md"
+// matches ".claude/rules/errors.md"); wiki titles compare case-insensitively.
+func targetMatches(hit, expected string) bool {
+	expected = strings.TrimPrefix(filepath.ToSlash(expected), "./")
+	if hit == expected || strings.HasSuffix(hit, "/"+expected) {
+		return true
+	}
+	return strings.HasPrefix(hit, "wiki:") && strings.EqualFold(hit, expected)
+}
+
+// scoreRetrieval computes recall@k, the reciprocal rank of the first
+// relevant hit and binary-relevance nDCG@k. Each expected entry counts once,
+// however many hits match it.
+func scoreRetrieval(hits, expected []string, k int) (recall, rr, ndcg float64) {
+	if len(expected) == 0 {
+		return 0, 0, 0
+	}
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	found := make([]bool, len(expected))
+	var dcg float64
+	for i, h := range hits {
+		for j, e := range expected {
+			if found[j] || !targetMatches(h, e) {
+				continue
+			}
+			found[j] = true
+			dcg += 1 / math.Log2(float64(i+2))
+			if rr == 0 {
+				rr = 1 / float64(i+1)
+			}
+			break
+		}
+	}
+	var matched int
+	for _, f := range found {
+		if f {
+			matched++
+		}
+	}
+	var idcg float64
+	for i := 0; i < len(expected) && i < k; i++ {
+		idcg += 1 / math.Log2(float64(i+2))
+	}
+	return float64(matched) / float64(len(expected)), rr, dcg / idcg
+}
+
+func corpusLabel(corpus string) string {
+	if corpus == "" {
+		return "auto"
+	}
+	return corpus
+}
+
+// evalRegressions lists the corpus metrics that dropped by more than
+// evalRegressionDelta since prev.
+func evalRegressions(prev, cur *db.RetrievalEvalRun) []string {
+	out := []string{}
+	if prev == nil {
+		return out
+	}
+	keys := make([]string, 0, len(cur.Metrics))
+	for key := range cur.Metrics {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		before, ok := prev.Metrics[key]
+		if !ok {
+			continue
+		}
+		after := cur.Metrics[key]
+		for _, m := range []struct {
+			name          string
+			before, after float64
+		}{
+			{fmt.Sprintf("recall@%d", cur.K), before.Recall, after.Recall},
+			{"mrr", before.MRR, after.MRR},
+			{fmt.Sprintf("ndcg@%d", cur.K), before.NDCG, after.NDCG},
+		} {
+			if m.before-m.after > evalRegressionDelta {
+				out = append(out, fmt.Sprintf("%s %s %.3f → %.3f", key, m.name, m.before, m.after))
+			}
+		}
+	}
+	return out
+}
+
+// logRetrieval records a served retrieve call for golden-set seeding.
+func (s *Server) logRetrieval(query, corpus string, results []retrieveResult) {
+	if _, err := s.db.LogRetrievalQuery(query, corpus, s.evalTargets(results)); err != nil {
+		log.Printf("[retrieve log] %v", err)
+	}
+}
+
+// recordFollowUps marks recent retrieve calls that returned one of paths as
+// followed up. Paths may be absolute or project-relative.
+func (s *Server) recordFollowUps(paths []string) {
+	targets := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if filepath.IsAbs(p) {
+			p = s.relProjectPath(p)
+		}
+		targets = append(targets, filepath.ToSlash(filepath.Clean(p)))
+	}
+	if _, err := s.db.MarkRetrievalFollowUps(targets, followUpWindow); err != nil {
+		log.Printf("[retrieve log] follow-ups: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Handlers
+// ---------------------------------------------------------------------------
+
+func (s *Server) handleListRetrievalGolden(w http.ResponseWriter, r *http.Request) {
+	cases, err := s.goldenCases()
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	json200(w, map[string]any{"cases": cases, "count": len(cases), "file": goldenFile})
+}
+
+func (s *Server) handleSaveRetrievalGolden(w http.ResponseWriter, r *http.Request) {
+	var body db.RetrievalGoldenCase
+	if err := decodeBody(r, &body); err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	body.Query = strings.TrimSpace(body.Query)
+	if body.Query == "" || len(body.Expected) == 0 {
+		jsonErr(w, http.StatusBadRequest, "query and expected are required")
+		return
+	}
+	if body.Corpus != "" && body.Corpus != "code" && body.Corpus != "governance" && body.Corpus != "wiki" && !symbolCorpora[body.Corpus] {
+		jsonErr(w, http.StatusBadRequest, "invalid corpus value, must be: code, governance, wiki, symbols, callers, callees, or empty")
+		return
+	}
+	body.Source = "manual"
+	id, err := s.db.SaveRetrievalGoldenCase(&body)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	json200(w, map[string]any{"id": id})
+}
+
+func (s *Server) handleDeleteRetrievalGolden(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(pathParam(r, "id"), 10, 64)
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	ok, err := s.db.DeleteRetrievalGoldenCase(id)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !ok {
+		jsonErr(w, http.StatusNotFound, "golden case not found")
+		return
+	}
+	json200(w, map[string]any{"deleted": id})
+}
+
+// handleSeedRetrievalGolden adds golden cases from retrieve calls whose
+// results were later edited or opened.
+func (s *Server) handleSeedRetrievalGolden(w http.ResponseWriter, r *http.Request) {
+	added, err := s.db.SeedRetrievalGolden(queryInt(r, "limit", 100))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	json200(w, map[string]any{"added": added})
+}
+
+func (s *Server) handleRunRetrievalEval(w http.ResponseWriter, r *http.Request) {
+	opts := RetrievalEvalOptions{K: 10, Save: true}
+	if r.ContentLength != 0 {
+		if err := decodeBody(r, &opts); err != nil {
+			jsonErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	target := s
+	if opts.Offline {
+		target = &Server{db: s.db, cfg: s.cfg, projectRoot: s.projectRoot, vexor: offlineCodeSearch{}}
+	}
+	run, err := target.runRetrievalEval(opts)
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if opts.Save {
+		s.hub.BroadcastJSON("retrieval_eval_completed", map[string]any{
+			"id": run.ID, "metrics": run.Metrics, "regressions": run.Regressions,
+		})
+	}
+	json200(w, run)
+}
+
+func (s *Server) handleListRetrievalEvalRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := s.db.ListRetrievalEvalRuns(queryInt(r, "limit", 20))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if runs == nil {
+		runs = []db.RetrievalEvalRun{}
+	}
+	json200(w, map[string]any{"runs": runs, "count": len(runs)})
+}
+
+func (s *Server) handleGetRetrievalEvalRun(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(pathParam(r, "id"), 10, 64)
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	run, err := s.db.GetRetrievalEvalRun(id)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if run == nil {
+		jsonErr(w, http.StatusNotFound, "eval run not found")
+		return
+	}
+	json200(w, run)
+}
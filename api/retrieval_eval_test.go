@@ -0,0 +1,161 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+func TestScoreRetrieval(t *testing.T) {
+	hits := []string{"README.md", ".claude/rules/errors.md", "docs/decisions/001-db.md"}
+	recall, rr, ndcg := scoreRetrieval(hits, []string{"errors.md", "docs/decisions/001-db.md"}, 10)
+	if recall != 1 || rr != 0.5 {
+		t.Fatalf("recall=%v rr=%v", recall, rr)
+	}
+	want := (1/math.Log2(3) + 1/math.Log2(4)) / (1 + 1/math.Log2(3))
+	if math.Abs(ndcg-want) > 1e-9 {
+		t.Fatalf("ndcg=%v want %v", ndcg, want)
+	}
+	// Only the top k count.
+	if recall, rr, _ := scoreRetrieval(hits, []string{"docs/decisions/001-db.md"}, 2); recall != 0 || rr != 0 {
+		t.Fatalf("k=2: recall=%v rr=%v", recall, rr)
+	}
+	if !targetMatches("wiki:Deploy Guide", "wiki:deploy guide") || targetMatches("a/errors.md.bak", "errors.md") {
+		t.Fatal("targetMatches")
+	}
+}
+
+func TestRetrievalEval_OfflineRunAndRegression(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+	root := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		p := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".claude/rules/errors.md", "# Errors\n\nWrap errors with context using fmt.Errorf and %w.")
+	write("docs/decisions/001-db.md", "# ADR 1\n\nWe use SQLite with WAL mode for the local database.")
+	write(goldenFile, `[
+  {"query": "wrap errors", "corpus": "governance", "expected": ["errors.md"]},
+  {"query": "semantic search", "corpus": "code", "expected": ["vexor/vexor.go"]}
+]`)
+	if err := database.IndexGovernance(root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.SaveRetrievalGoldenCase(&db.RetrievalGoldenCase{
+		Query: "database WAL", Corpus: "governance", Expected: []string{"docs/decisions/001-db.md"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	server := newRetrievalServer(t, database)
+	server.projectRoot = root
+	server.hub = NewHub()
+
+	post := func(body string) db.RetrievalEvalRun {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.handleRunRetrievalEval(w, httptest.NewRequest("POST", "/api/retrieval/eval", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("eval: %d %s", w.Code, w.Body.String())
+		}
+		var run db.RetrievalEvalRun
+		if err := json.NewDecoder(w.Body).Decode(&run); err != nil {
+			t.Fatal(err)
+		}
+		return run
+	}
+
+	run := post(`{"k": 5, "offline": true, "save": true}`)
+	if run.Cases != 2 || run.Skipped != 1 || run.ID == 0 {
+		t.Fatalf("run = %+v", run)
+	}
+	if m := run.Metrics["governance"]; m.Cases != 2 || m.Recall != 1 || m.MRR != 1 {
+		t.Fatalf("governance metrics = %+v", m)
+	}
+	if len(run.Regressions) != 0 {
+		t.Fatalf("first run regressions = %v", run.Regressions)
+	}
+
+	// Pointing a stored case at a file that is never returned shows up as a
+	// regression against the previous run.
+	if _, err := database.SaveRetrievalGoldenCase(&db.RetrievalGoldenCase{
+		Query: "database WAL", Corpus: "governance", Expected: []string{"docs/decisions/999-missing.md"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	run = post(`{"k": 5, "offline": true, "save": true}`)
+	if m := run.Metrics["governance"]; m.Recall != 0.5 {
+		t.Fatalf("governance metrics after edit = %+v", m)
+	}
+	if len(run.Regressions) == 0 || !strings.HasPrefix(run.Regressions[0], "all recall@5") {
+		t.Fatalf("regressions = %v", run.Regressions)
+	}
+
+	w := httptest.NewRecorder()
+	server.handleListRetrievalEvalRuns(w, httptest.NewRequest("GET", "/api/retrieval/eval/runs", nil))
+	var list struct {
+		Runs []db.RetrievalEvalRun `json:"runs"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil || len(list.Runs) != 2 {
+		t.Fatalf("history = %+v %v", list, err)
+	}
+}
+
+func TestRetrievalEval_SeedsFromRetrieveFollowUps(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+	root := t.TempDir()
+	rule := filepath.Join(root, ".claude", "rules", "testing.md")
+	if err := os.MkdirAll(filepath.Dir(rule), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rule, []byte("# Testing\n\nTable-driven tests live next to the code."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.IndexGovernance(root); err != nil {
+		t.Fatal(err)
+	}
+	server := newRetrievalServer(t, database)
+	server.projectRoot = root
+	server.dirtyFiles = map[string]struct{}{}
+	server.dirtyCh = make(chan struct{}, 1)
+
+	w := httptest.NewRecorder()
+	server.handleRetrieve(w, httptest.NewRequest("GET", "/api/retrieve?q=table-driven+tests&corpus=governance", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("retrieve: %d %s", w.Code, w.Body.String())
+	}
+
+	// The agent then edits the file it was pointed at.
+	body, _ := json.Marshal(map[string]any{"paths": []string{rule}})
+	w = httptest.NewRecorder()
+	server.handleMarkDirty(w, httptest.NewRequest("POST", "/api/retrieve/dirty", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("dirty: %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleSeedRetrievalGolden(w, httptest.NewRequest("POST", "/api/retrieval/golden/seed", nil))
+	if !strings.Contains(w.Body.String(), `"added":1`) {
+		t.Fatalf("seed: %s", w.Body.String())
+	}
+	cases, _ := database.ListRetrievalGoldenCases()
+	if len(cases) != 1 || cases[0].Query != "table-driven tests" || cases[0].Expected[0] != ".claude/rules/testing.md" {
+		t.Fatalf("seeded cases = %+v", cases)
+	}
+}
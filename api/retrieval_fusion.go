@@ -0,0 +1,261 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+)
+
+// retrieveExplain records why a hit ranks where it does; it is only filled
+// in when the caller asks for explain=true.
+type retrieveExplain struct {
+	// Ranks and RawScores hold each source's 1-based rank and native score
+	// for this hit (vexor similarity, bm25, wiki position score).
+	Ranks       map[string]int     `json:"ranks"`
+	RawScores   map[string]float64 `json:"raw_scores"`
+	Fused       float64            `json:"fused"`
+	RerankScore *float64           `json:"rerank_score,omitempty"`
+	Merged      []string           `json:"merged,omitempty"`
+	Reason      string             `json:"reason"`
+}
+
+// droppedHit is a candidate that did not make the final list.
+type droppedHit struct {
+	Source   string `json:"source"`
+	FilePath string `json:"file_path,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Rank     int    `json:"rank"`
+	Reason   string `json:"reason"`
+}
+
+// rankedList is one source's hits, best first.
+type rankedList struct {
+	source string
+	hits   []retrieveResult
+}
+
+// fusedHit accumulates a candidate's contributions during fusion.
+type fusedHit struct {
+	result    retrieveResult
+	fused     float64
+	ranks     map[string]int
+	rawScores map[string]float64
+	merged    []string
+	rerank    *float64
+}
+
+// retrievalConfig returns the live retrieval settings, or the defaults when
+// the server runs without a config (tests).
+func (s *Server) retrievalConfig() config.RetrievalConfig {
+	if s.cfg == nil {
+		return config.Default().Retrieval
+	}
+	return s.cfg.Retrieval
+}
+
+// fuseResults merges per-source lists into one ranking. With "rrf" a hit at
+// rank r in a source contributes w·(k+1)/(k+r), so every source's top hit is
+// worth its weight; with "score" each source's native scores are min-max
+// normalised between its best and worst hit. Overlapping chunks of the same
+// file are folded into the better-ranked one (summing contributions when the
+// duplicate comes from another source) and at most maxPerFile hits per file
+// are kept.
+func fuseResults(lists []rankedList, cfg config.RetrievalConfig) ([]*fusedHit, []droppedHit) {
+	k := float64(cfg.RRFK)
+	if k <= 0 {
+		k = 60
+	}
+	maxPerFile := cfg.MaxPerFile
+	if maxPerFile <= 0 {
+		maxPerFile = 2
+	}
+
+	type candidate struct {
+		source string
+		rank   int
+		score  float64
+		result retrieveResult
+	}
+	var candidates []candidate
+	for _, l := range lists {
+		w := 1.0
+		if v, ok := cfg.Weights[l.source]; ok {
+			w = v
+		}
+		if len(l.hits) == 0 {
+			continue
+		}
+		best, worst := l.hits[0].Score, l.hits[len(l.hits)-1].Score
+		for i, h := range l.hits {
+			var contrib float64
+			if cfg.Fusion == "score" {
+				norm := 1.0
+				if best != worst {
+					norm = (h.Score - worst) / (best - worst)
+				}
+				contrib = w * norm
+			} else {
+				contrib = w * (k + 1) / (k + float64(i+1))
+			}
+			candidates = append(candidates, candidate{l.source, i + 1, contrib, h})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	var kept []*fusedHit
+	var dropped []droppedHit
+	perFile := map[string]int{}
+	for _, c := range candidates {
+		if dup := findDuplicate(kept, c.result); dup != nil {
+			if _, seen := dup.ranks[c.source]; !seen {
+				dup.fused += c.score
+				dup.ranks[c.source] = c.rank
+				dup.rawScores[c.source] = c.result.Score
+			}
+			dup.merged = append(dup.merged, fmt.Sprintf("%s rank %d", c.source, c.rank))
+			dropped = append(dropped, droppedHit{c.source, c.result.FilePath, c.result.Title, c.rank,
+				"duplicate of " + describeHit(dup.result)})
+			continue
+		}
+		if path := c.result.FilePath; path != "" && perFile[path] >= maxPerFile {
+			dropped = append(dropped, droppedHit{c.source, path, c.result.Title, c.rank,
+				fmt.Sprintf("already %d hits from this file", maxPerFile)})
+			continue
+		}
+		if c.result.FilePath != "" {
+			perFile[c.result.FilePath]++
+		}
+		kept = append(kept, &fusedHit{
+			result:    c.result,
+			fused:     c.score,
+			ranks:     map[string]int{c.source: c.rank},
+			rawScores: map[string]float64{c.source: c.result.Score},
+		})
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].fused > kept[j].fused })
+	return kept, dropped
+}
+
+// findDuplicate returns the kept hit that r repeats: same file with
+// overlapping lines, or same file and identical text.
+func findDuplicate(kept []*fusedHit, r retrieveResult) *fusedHit {
+	if r.FilePath == "" {
+		return nil
+	}
+	for _, h := range kept {
+		k := h.result
+		if k.FilePath != r.FilePath {
+			continue
+		}
+		if k.LineEnd > 0 && r.LineEnd > 0 && k.LineStart <= r.LineEnd && r.LineStart <= k.LineEnd {
+			return h
+		}
+		if strings.TrimSpace(k.Excerpt) != "" && strings.TrimSpace(k.Excerpt) == strings.TrimSpace(r.Excerpt) {
+			return h
+		}
+	}
+	return nil
+}
+
+func describeHit(r retrieveResult) string {
+	if r.LineEnd > 0 {
+		return fmt.Sprintf("%s:%d-%d", r.FilePath, r.LineStart, r.LineEnd)
+	}
+	if r.FilePath != "" {
+		return r.FilePath
+	}
+	return r.Title
+}
+
+// rerankHits reorders the top cfg.TopN hits by the reranker's scores. On
+// failure the fused order stands and the error is returned for logging.
+func (s *Server) rerankHits(query string, hits []*fusedHit, cfg config.RerankConfig) error {
+	if s.reranker == nil || !cfg.Enabled || len(hits) < 2 {
+		return nil
+	}
+	n := cfg.TopN
+	if n <= 0 {
+		n = 20
+	}
+	n = min(n, len(hits))
+	timeout := time.Duration(cfg.TimeoutSec) * time.Second
+	if timeout <= 0 {
+		timeout = 20 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	docs := make([]string, n)
+	for i, h := range hits[:n] {
+		docs[i] = strings.TrimSpace(describeHit(h.result) + "\n" + h.result.Title + "\n" + h.result.Excerpt)
+	}
+	scores, err := s.reranker.Rerank(ctx, query, docs)
+	if err != nil {
+		return err
+	}
+	if len(scores) != n {
+		return fmt.Errorf("reranker returned %d scores for %d hits", len(scores), n)
+	}
+	for i, h := range hits[:n] {
+		score := scores[i]
+		h.rerank = &score
+	}
+	sort.SliceStable(hits[:n], func(i, j int) bool { return *hits[i].rerank > *hits[j].rerank })
+	return nil
+}
+
+// finalizeResults cuts the fused list to topK and, when explain is set,
+// attaches per-hit explanations and reports what was cut.
+func finalizeResults(hits []*fusedHit, dropped []droppedHit, topK int, explain bool) ([]retrieveResult, []droppedHit) {
+	if topK > 0 && len(hits) > topK {
+		for _, h := range hits[topK:] {
+			dropped = append(dropped, droppedHit{h.result.Source, h.result.FilePath, h.result.Title,
+				h.ranks[h.result.Source], fmt.Sprintf("below top_k=%d", topK)})
+		}
+		hits = hits[:topK]
+	}
+	results := make([]retrieveResult, len(hits))
+	for i, h := range hits {
+		r := h.result
+		r.Score = h.fused
+		if explain {
+			r.Explain = &retrieveExplain{
+				Ranks:       h.ranks,
+				RawScores:   h.rawScores,
+				Fused:       h.fused,
+				RerankScore: h.rerank,
+				Merged:      h.merged,
+				Reason:      explainReason(h, i+1),
+			}
+		}
+		results[i] = r
+	}
+	if !explain {
+		dropped = nil
+	}
+	return results, dropped
+}
+
+func explainReason(h *fusedHit, position int) string {
+	sources := make([]string, 0, len(h.ranks))
+	for src := range h.ranks {
+		sources = append(sources, src)
+	}
+	sort.Strings(sources)
+	parts := make([]string, len(sources))
+	for i, src := range sources {
+		parts[i] = fmt.Sprintf("rank %d in %s", h.ranks[src], src)
+	}
+	reason := strings.Join(parts, ", ") + fmt.Sprintf("; fused score %.3f", h.fused)
+	if len(sources) > 1 {
+		reason += " (found by several sources)"
+	}
+	if h.rerank != nil {
+		reason += fmt.Sprintf("; reranker scored %.2f, placed #%d", *h.rerank, position)
+	}
+	return reason
+}
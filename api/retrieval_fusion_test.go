@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+func TestFuseResults_RRFMergesAndDedupes(t *testing.T) {
+	code := rankedList{source: "code", hits: []retrieveResult{
+		{Source: "code", FilePath: "api/auth.go", Title: "func Login", LineStart: 10, LineEnd: 40, Score: 0.91},
+		{Source: "code", FilePath: "api/auth.go", Title: "func Login (part)", LineStart: 30, LineEnd: 60, Score: 0.90},
+		{Source: "code", FilePath: "api/auth.go", Title: "func Logout", LineStart: 70, LineEnd: 90, Score: 0.80},
+		{Source: "code", FilePath: "api/auth.go", Title: "func Refresh", LineStart: 100, LineEnd: 120, Score: 0.79},
+		{Source: "code", FilePath: "docs/auth.md", Title: "section Auth", Excerpt: "Tokens expire hourly", Score: 0.5},
+	}}
+	gov := rankedList{source: "governance", hits: []retrieveResult{
+		{Source: "governance", FilePath: "docs/auth.md", Title: "Auth", Excerpt: "Tokens expire hourly", Score: 7},
+		{Source: "governance", FilePath: "docs/adr/001.md", Title: "ADR 1", Excerpt: "Use JWT", Score: 3},
+	}}
+	hits, dropped := fuseResults([]rankedList{code, gov}, config.Default().Retrieval)
+
+	var titles []string
+	for _, h := range hits {
+		titles = append(titles, h.result.Title)
+	}
+	// docs/auth.md is found by both sources and outranks every single-source hit.
+	if len(hits) != 4 || hits[0].result.FilePath != "docs/auth.md" || len(hits[0].ranks) != 2 {
+		t.Fatalf("fused = %v", titles)
+	}
+	if hits[1].result.Title != "func Login" || hits[1].fused != 1 {
+		t.Errorf("second hit = %+v", hits[1])
+	}
+	reasons := map[string]string{}
+	for _, d := range dropped {
+		reasons[d.Title] = d.Reason
+	}
+	if reasons["func Login (part)"] != "duplicate of api/auth.go:10-40" || reasons["func Refresh"] != "already 2 hits from this file" {
+		t.Errorf("dropped = %+v", dropped)
+	}
+
+	// Score fusion normalises each source between its best and worst hit.
+	cfg := config.Default().Retrieval
+	cfg.Fusion = "score"
+	hits, _ = fuseResults([]rankedList{gov}, cfg)
+	if hits[0].fused != 1 || hits[1].fused != 0 {
+		t.Errorf("score fusion = %v, %v", hits[0].fused, hits[1].fused)
+	}
+}
+
+// reverseReranker prefers later candidates.
+type reverseReranker struct{ calls int }
+
+func (r *reverseReranker) Rerank(_ context.Context, _ string, docs []string) ([]float64, error) {
+	r.calls++
+	scores := make([]float64, len(docs))
+	for i := range docs {
+		scores[i] = float64(i) / float64(len(docs))
+	}
+	return scores, nil
+}
+
+func TestHandleRetrieve_ExplainAndRerank(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+	for _, p := range []*db.WikiPage{
+		newRetrievalWikiPage("w1", "summary", "Caching strategy", "caching caching layer redis", 0),
+		newRetrievalWikiPage("w2", "summary", "Cache invalidation", "caching invalidation events", 0),
+	} {
+		if err := database.SaveWikiPage(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := config.Default()
+	cfg.Retrieval.Rerank.Enabled = true
+	server := newRetrievalServer(t, database)
+	server.cfg = &cfg
+	rr := &reverseReranker{}
+	server.SetReranker(rr)
+
+	w := httptest.NewRecorder()
+	server.handleRetrieve(w, httptest.NewRequest(http.MethodGet, "/api/retrieve?q=caching&corpus=wiki&explain=true", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("retrieve: %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Results  []retrieveResult `json:"results"`
+		Dropped  []droppedHit     `json:"dropped"`
+		Fusion   string           `json:"fusion"`
+		Reranked bool             `json:"reranked"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 2 || rr.calls != 1 || !resp.Reranked || resp.Fusion != "rrf" || resp.Dropped == nil {
+		t.Fatalf("resp = %+v", resp)
+	}
+	first := resp.Results[0].Explain
+	if first == nil || first.Ranks["wiki"] != 2 || first.RerankScore == nil || first.Reason == "" {
+		t.Errorf("reranked head explain = %+v", first)
+	}
+
+	// Without explain the response carries no diagnostics.
+	w = httptest.NewRecorder()
+	server.handleRetrieve(w, httptest.NewRequest(http.MethodGet, "/api/retrieve?q=caching&corpus=wiki", nil))
+	var plain map[string]any
+	_ = json.Unmarshal(w.Body.Bytes(), &plain)
+	if _, ok := plain["dropped"]; ok {
+		t.Errorf("dropped returned without explain: %v", plain)
+	}
+}
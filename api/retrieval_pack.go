@@ -0,0 +1,386 @@
+package api
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+const (
+	// packCharsPerToken is the rough chars-per-token ratio used to estimate
+	// budgets without a tokenizer; it errs on the generous side for code.
+	packCharsPerToken = 4
+	// packMinBodyTokens is the smallest trimmed excerpt worth emitting; below
+	// it a hit is dropped rather than cut to a stub.
+	packMinBodyTokens = 24
+	// packAdjacentLines is the largest gap between two chunks of one file
+	// that still merges them into a single excerpt.
+	packAdjacentLines = 3
+	packMemoryLimit   = 5
+	// packMinImportance filters memories that were never flagged as worth
+	// keeping; routine observations default to 0.5.
+	packMinImportance = 0.6
+)
+
+// inactiveADR matches a status line marking a decision as no longer in force.
+var inactiveADR = regexp.MustCompile(`(?im)^\s*(?:\*\*)?status(?:\*\*)?\s*:?\s*(?:\*\*)?\s*(superseded|deprecated|rejected)`)
+
+// packedCitation identifies one entry of a packed context block. Ref is the
+// tag used in the block text, e.g. "[2]".
+type packedCitation struct {
+	Ref       string `json:"ref"`
+	Source    string `json:"source"`
+	FilePath  string `json:"file_path,omitempty"`
+	Title     string `json:"title,omitempty"`
+	LineStart int    `json:"line_start,omitempty"`
+	LineEnd   int    `json:"line_end,omitempty"`
+	MemoryID  int64  `json:"memory_id,omitempty"`
+	Tokens    int    `json:"tokens"`
+	Trimmed   bool   `json:"trimmed,omitempty"`
+}
+
+// packedContext is a token-budgeted rendering of retrieval hits, ready to be
+// pasted into an agent prompt.
+type packedContext struct {
+	Text      string           `json:"text"`
+	Citations []packedCitation `json:"citations"`
+	Tokens    int              `json:"tokens"`
+	Budget    int              `json:"budget"`
+	// Merged counts hits folded into a neighbouring chunk or dropped as
+	// duplicates; Omitted counts hits that did not fit the budget.
+	Merged  int `json:"merged"`
+	Omitted int `json:"omitted"`
+}
+
+// packItem is a candidate for the context block.
+type packItem struct {
+	source    string
+	filePath  string
+	title     string
+	label     string // doc/page/event type shown next to the citation
+	body      string
+	lineStart int
+	lineEnd   int
+	memoryID  int64
+	priority  float64
+}
+
+// estimateTokens approximates the token count of s.
+func estimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + packCharsPerToken - 1) / packCharsPerToken
+}
+
+// packContext fits results and memories into maxTokens. Hits are merged per
+// file when their line ranges touch, duplicates are dropped, and the rest is
+// admitted greedily by priority: accepted rules and ADRs and high-importance
+// memories first, then everything else in retrieval order. A hit that does
+// not fit whole is trimmed at a paragraph, line or sentence boundary when
+// enough budget remains, otherwise skipped in favour of smaller ones.
+func packContext(results []retrieveResult, memories []db.Event, maxTokens int) packedContext {
+	out := packedContext{Budget: maxTokens, Citations: []packedCitation{}}
+	items, merged := mergePackItems(results)
+	out.Merged = merged
+	for _, e := range memories {
+		items = append(items, packItem{
+			source:   "memory",
+			title:    e.Title,
+			label:    e.Type,
+			body:     e.Text,
+			memoryID: e.ID,
+			priority: 1.5 * e.Importance,
+		})
+	}
+	items, dupes := dropDuplicateBodies(items)
+	out.Merged += dupes
+	sort.SliceStable(items, func(i, j int) bool { return items[i].priority > items[j].priority })
+
+	var sb strings.Builder
+	remaining := maxTokens
+	for _, it := range items {
+		ref := fmt.Sprintf("[%d]", len(out.Citations)+1)
+		header := ref + " " + packHeader(it) + "\n"
+		cost := estimateTokens(header)
+		if len(out.Citations) > 0 {
+			cost++ // blank line between entries
+		}
+		body := compactText(it.body)
+		avail := remaining - cost
+		trimmed := false
+		if estimateTokens(body) > avail {
+			if avail < packMinBodyTokens {
+				out.Omitted++
+				continue
+			}
+			body = trimExcerpt(body, avail*packCharsPerToken)
+			trimmed = true
+		}
+		used := cost + estimateTokens(body)
+		if len(out.Citations) > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(header)
+		sb.WriteString(body)
+		sb.WriteString("\n")
+		remaining -= used
+		out.Citations = append(out.Citations, packedCitation{
+			Ref:       ref,
+			Source:    it.source,
+			FilePath:  it.filePath,
+			Title:     it.title,
+			LineStart: it.lineStart,
+			LineEnd:   it.lineEnd,
+			MemoryID:  it.memoryID,
+			Tokens:    used,
+			Trimmed:   trimmed,
+		})
+	}
+	out.Text = sb.String()
+	out.Tokens = maxTokens - remaining
+	return out
+}
+
+// mergePackItems converts results to pack items, folding chunks of the same
+// file whose line ranges overlap or sit within packAdjacentLines of each
+// other. Hits without line ranges merge only when they are the same chunk.
+// It returns the items in retrieval order and how many hits were folded.
+func mergePackItems(results []retrieveResult) ([]packItem, int) {
+	var items []packItem
+	merged := 0
+	n := len(results)
+	for i, r := range results {
+		// Retrieval order is the baseline: 1.0 for the top hit down to 0.5.
+		priority := 1.0
+		if n > 1 {
+			priority -= 0.5 * float64(i) / float64(n-1)
+		}
+		priority *= packBoost(r)
+		label := r.DocType
+		if label == "" {
+			label = r.PageType
+		}
+		it := packItem{
+			source:    r.Source,
+			filePath:  r.FilePath,
+			title:     r.Title,
+			label:     label,
+			body:      r.Excerpt,
+			lineStart: r.LineStart,
+			lineEnd:   r.LineEnd,
+			priority:  priority,
+		}
+		folded := false
+		for j := range items {
+			if mergeAdjacent(&items[j], it) {
+				folded = true
+				break
+			}
+		}
+		if folded {
+			merged++
+			continue
+		}
+		items = append(items, it)
+	}
+	return items, merged
+}
+
+// packBoost weights a hit by how authoritative its source is.
+func packBoost(r retrieveResult) float64 {
+	switch {
+	case r.Source == "governance" && r.DocType == "rule":
+		return 1.5
+	case r.Source == "governance" && r.DocType == "adr":
+		if inactiveADR.MatchString(r.Excerpt) {
+			return 0.5
+		}
+		return 1.3
+	case r.Source == "wiki" && r.StalenessScore > 0.7:
+		return 0.7
+	}
+	return 1
+}
+
+// mergeAdjacent folds b into a when both are chunks of the same file whose
+// line ranges overlap or nearly touch, reporting whether it did. Overlapping
+// lines are emitted once when the excerpts line up with their ranges;
+// otherwise the excerpts are joined with an elision marker.
+func mergeAdjacent(a *packItem, b packItem) bool {
+	if a.filePath == "" || a.filePath != b.filePath || a.source != b.source {
+		return false
+	}
+	if a.lineStart == 0 || b.lineStart == 0 {
+		if a.title == b.title && a.lineStart == b.lineStart {
+			if b.priority > a.priority {
+				a.priority = b.priority
+			}
+			return true
+		}
+		return false
+	}
+	if b.lineStart > a.lineEnd+packAdjacentLines || a.lineStart > b.lineEnd+packAdjacentLines {
+		return false
+	}
+	first, second := *a, b
+	if second.lineStart < first.lineStart {
+		first, second = second, first
+	}
+	aLines := strings.Split(first.body, "\n")
+	bLines := strings.Split(second.body, "\n")
+	switch {
+	case second.lineEnd <= first.lineEnd && len(aLines) == first.lineEnd-first.lineStart+1:
+		// second is contained in first.
+	case len(aLines) == first.lineEnd-first.lineStart+1 && len(bLines) == second.lineEnd-second.lineStart+1:
+		skip := first.lineEnd - second.lineStart + 1
+		if skip < 0 {
+			// A few lines apart: mark the gap rather than invent its content.
+			aLines = append(aLines, "…")
+			skip = 0
+		}
+		first.body = strings.Join(append(aLines, bLines[skip:]...), "\n")
+	default:
+		first.body = first.body + "\n…\n" + second.body
+	}
+	if second.lineEnd > first.lineEnd {
+		first.lineEnd = second.lineEnd
+	}
+	if first.title == "" {
+		first.title = second.title
+	}
+	if b.priority > a.priority {
+		first.priority = b.priority
+	} else {
+		first.priority = a.priority
+	}
+	*a = first
+	return true
+}
+
+// dropDuplicateBodies removes items whose text repeats an earlier, higher
+// ranked one, e.g. a rule indexed both locally and from a team peer.
+func dropDuplicateBodies(items []packItem) ([]packItem, int) {
+	seen := make(map[string]bool, len(items))
+	out := items[:0]
+	dropped := 0
+	for _, it := range items {
+		key := strings.Join(strings.Fields(strings.ToLower(it.body)), " ")
+		if key != "" && seen[key] {
+			dropped++
+			continue
+		}
+		seen[key] = true
+		out = append(out, it)
+	}
+	return out, dropped
+}
+
+// packHeader renders the citation line for an item, e.g.
+// "governance .claude/rules/errors.md (rule) — Errors".
+func packHeader(it packItem) string {
+	var sb strings.Builder
+	sb.WriteString(it.source)
+	switch {
+	case it.memoryID > 0:
+		fmt.Fprintf(&sb, " #%d", it.memoryID)
+	case it.filePath != "":
+		sb.WriteString(" " + it.filePath)
+		if it.lineStart > 0 {
+			fmt.Fprintf(&sb, ":L%d-%d", it.lineStart, it.lineEnd)
+		}
+	}
+	if it.label != "" {
+		sb.WriteString(" (" + it.label + ")")
+	}
+	if it.title != "" && it.title != it.filePath {
+		sb.WriteString(" — " + it.title)
+	}
+	return sb.String()
+}
+
+// compactText strips trailing whitespace and collapses runs of blank lines,
+// which cost tokens without carrying meaning.
+func compactText(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	out := lines[:0]
+	blank := false
+	for _, l := range lines {
+		l = strings.TrimRight(l, " \t\r")
+		if l == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		out = append(out, l)
+	}
+	return strings.Join(out, "\n")
+}
+
+// trimExcerpt cuts s to at most maxChars runes, preferring to end at a
+// paragraph break, then a line break, then a sentence end, then a word
+// boundary — whichever comes latest in the second half of the window.
+func trimExcerpt(s string, maxChars int) string {
+	runes := []rune(s)
+	if len(runes) <= maxChars {
+		return s
+	}
+	if maxChars <= 1 {
+		return "…"
+	}
+	cut := string(runes[:maxChars-1])
+	floor := len(cut) / 2
+	for _, sep := range []string{"\n\n", "\n", ". ", " "} {
+		if i := strings.LastIndex(cut, sep); i >= floor {
+			if sep == ". " {
+				i++ // keep the full stop
+			}
+			return strings.TrimRight(cut[:i], " \n") + " …"
+		}
+	}
+	return cut + "…"
+}
+
+// packRetrieved packs results for an agent prompt, citing indexed files
+// relative to the project root. With memories, important memories matching
+// query compete for the budget too.
+func (s *Server) packRetrieved(query string, results []retrieveResult, memories bool, maxTokens int) packedContext {
+	rel := make([]retrieveResult, len(results))
+	for i, r := range results {
+		if filepath.IsAbs(r.FilePath) {
+			r.FilePath = s.relProjectPath(r.FilePath)
+		}
+		rel[i] = r
+	}
+	var events []db.Event
+	if memories {
+		events = s.packMemories(query)
+	}
+	return packContext(rel, events, maxTokens)
+}
+
+// packMemories returns memories relevant to query that are important enough
+// to compete for context budget. Prefetch events are skipped: they are
+// themselves packed retrieval output.
+func (s *Server) packMemories(query string) []db.Event {
+	events, err := s.db.SearchEvents(db.SearchEventsInput{Query: query, Limit: packMemoryLimit * 3})
+	if err != nil {
+		return nil
+	}
+	var out []db.Event
+	for _, e := range events {
+		if e.Type == "context_prefetch" || e.Importance < packMinImportance {
+			continue
+		}
+		out = append(out, e)
+		if len(out) == packMemoryLimit {
+			break
+		}
+	}
+	return out
+}
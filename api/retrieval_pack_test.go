@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+func TestPackContext_MergesAdjacentChunksAndPrefersRules(t *testing.T) {
+	results := []retrieveResult{
+		{Source: "code", FilePath: "api/server.go", Title: "Handler", Excerpt: "l10\nl11\nl12", LineStart: 10, LineEnd: 12},
+		{Source: "governance", FilePath: ".claude/rules/errors.md", Title: "Errors", Excerpt: "Wrap errors with %w.", DocType: "rule"},
+		{Source: "wiki", Title: "Deploy", Excerpt: "Deploy with make release.", PageType: "guide"},
+		{Source: "code", FilePath: "api/server.go", Title: "Handler", Excerpt: "l12\nl13", LineStart: 12, LineEnd: 13},
+		{Source: "governance", FilePath: "docs/decisions/003-queue.md", Title: "Queue", Excerpt: "Status: Superseded\n\nUse NATS.", DocType: "adr"},
+	}
+	memories := []db.Event{{ID: 7, Type: "decision", Title: "Use SQLite", Text: "We picked SQLite for local state.", Importance: 0.8}}
+
+	p := packContext(results, memories, 1000)
+	if p.Merged != 1 || p.Omitted != 0 || len(p.Citations) != 5 {
+		t.Fatalf("packed = %+v", p)
+	}
+	order := []string{}
+	for _, c := range p.Citations {
+		order = append(order, c.Source)
+	}
+	// The rule (boosted 1.5×) and the 0.8 memory outrank the top code hit;
+	// the superseded ADR drops to the end.
+	if got := strings.Join(order, ","); got != "governance,memory,code,wiki,governance" {
+		t.Fatalf("order = %s", got)
+	}
+	code := p.Citations[2]
+	if code.LineStart != 10 || code.LineEnd != 13 {
+		t.Fatalf("merged range = %d-%d", code.LineStart, code.LineEnd)
+	}
+	if !strings.Contains(p.Text, "[3] code api/server.go:L10-13 — Handler\nl10\nl11\nl12\nl13\n") {
+		t.Fatalf("merged block:\n%s", p.Text)
+	}
+	if !strings.HasPrefix(p.Text, "[1] governance .claude/rules/errors.md (rule) — Errors\n") {
+		t.Fatalf("block:\n%s", p.Text)
+	}
+	if !strings.Contains(p.Text, "[2] memory #7 (decision) — Use SQLite") {
+		t.Fatalf("memory citation missing:\n%s", p.Text)
+	}
+}
+
+func TestPackContext_RespectsBudget(t *testing.T) {
+	long := strings.Repeat("First sentence of a long paragraph. ", 20) + "\n\n" + strings.Repeat("tail ", 200)
+	results := []retrieveResult{
+		{Source: "governance", FilePath: "README.md", Title: "Readme", Excerpt: long, DocType: "project"},
+		{Source: "governance", FilePath: "CONTRIBUTING.md", Title: "Contributing", Excerpt: long, DocType: "contributing"},
+		{Source: "wiki", Title: "Short", Excerpt: "Small page."},
+	}
+	p := packContext(results, nil, 250)
+	if p.Tokens > 250 || estimateTokens(p.Text) > 250 {
+		t.Fatalf("over budget: tokens=%d estimate=%d", p.Tokens, estimateTokens(p.Text))
+	}
+	// The first doc is trimmed at the paragraph break, the duplicate body is
+	// dropped and the short page still fits.
+	if len(p.Citations) != 2 || !p.Citations[0].Trimmed || p.Merged != 1 {
+		t.Fatalf("packed = %+v", p)
+	}
+	if strings.Contains(p.Text, "tail") || !strings.Contains(p.Text, "paragraph. …") {
+		t.Fatalf("trim point:\n%s", p.Text)
+	}
+
+	// Too little room to trim the docs to anything useful; the short page
+	// still fits whole.
+	if p := packContext(results, nil, 20); len(p.Citations) != 1 || p.Citations[0].Source != "wiki" || p.Omitted != 1 {
+		t.Fatalf("tiny budget = %+v", p)
+	}
+}
+
+func TestTrimExcerpt(t *testing.T) {
+	if got := trimExcerpt("short", 10); got != "short" {
+		t.Fatalf("got %q", got)
+	}
+	if got := trimExcerpt("alpha beta gamma delta", 14); got != "alpha beta …" {
+		t.Fatalf("word boundary: %q", got)
+	}
+	if got := trimExcerpt("line one\nline two\nline three", 22); got != "line one\nline two …" {
+		t.Fatalf("line boundary: %q", got)
+	}
+}
+
+func TestHandleRetrieve_MaxTokens(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+	root := t.TempDir()
+	rule := filepath.Join(root, ".claude", "rules", "logging.md")
+	if err := os.MkdirAll(filepath.Dir(rule), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rule, []byte("# Logging\n\nUse structured logging with slog."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.IndexGovernance(root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.SaveEvent(db.SaveEventInput{
+		Actor: "agent", Type: "decision", Title: "Logging choice",
+		Text: "Structured logging via slog everywhere.", Importance: 0.8,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	server := newRetrievalServer(t, database)
+	server.projectRoot = root
+
+	w := httptest.NewRecorder()
+	server.handleRetrieve(w, httptest.NewRequest("GET", "/api/retrieve?q=structured+logging&max_tokens=300", nil))
+	var resp struct {
+		Context packedContext `json:"context"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Context.Budget != 300 || len(resp.Context.Citations) != 2 {
+		t.Fatalf("context = %+v", resp.Context)
+	}
+	if resp.Context.Citations[0].Source != "governance" || resp.Context.Citations[1].Source != "memory" {
+		t.Fatalf("citations = %+v", resp.Context.Citations)
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/MartinNevlaha/stratus-v2/codeindex"
+)
+
+// symbolCorpora are the retrieve corpora answered by the symbol index
+// rather than by search.
+var symbolCorpora = map[string]bool{"symbols": true, "callers": true, "callees": true}
+
+// retrieveSymbols answers the symbol corpora: "symbols" finds definitions,
+// "callers" the call sites of a symbol and "callees" what a function calls.
+// Hits come back in the index's order (type-resolved matches before
+// name-only ones); there is nothing to fuse.
+func (s *Server) retrieveSymbols(query, corpus string, topK int, explain bool) []retrieveResult {
+	if s.symbols == nil {
+		return nil
+	}
+	var hits []codeindex.SymbolHit
+	var err error
+	switch corpus {
+	case "symbols":
+		hits, err = s.symbols.Definitions(query, topK)
+	case "callers":
+		hits, err = s.symbols.Callers(query, topK)
+	case "callees":
+		hits, err = s.symbols.Callees(query, topK)
+	}
+	if err != nil {
+		log.Printf("[symbol search] corpus=%s query=%q error=%v", corpus, query, err)
+		return nil
+	}
+	log.Printf("[symbol search] corpus=%s query=%q results=%d", corpus, query, len(hits))
+
+	results := make([]retrieveResult, len(hits))
+	for i, h := range hits {
+		r := symbolResult(corpus, h)
+		r.Score = float64(len(hits)-i) / float64(len(hits))
+		if explain {
+			r.Explain = &retrieveExplain{
+				Ranks:     map[string]int{corpus: i + 1},
+				RawScores: map[string]float64{},
+				Fused:     r.Score,
+				Reason:    symbolReason(corpus, h),
+			}
+		}
+		results[i] = r
+	}
+	return results
+}
+
+func symbolResult(corpus string, h codeindex.SymbolHit) retrieveResult {
+	sym := h.Symbol
+	r := retrieveResult{
+		Source:    corpus,
+		FilePath:  sym.FilePath,
+		Title:     strings.TrimSpace(sym.Kind + " " + sym.DisplayName()),
+		Excerpt:   sym.Signature,
+		LineStart: sym.LineStart,
+		LineEnd:   sym.LineEnd,
+	}
+	switch {
+	case corpus == "callers" && h.Ref != nil:
+		verb := "calls"
+		if h.Ref.Kind != "call" {
+			verb = "references"
+		}
+		r.FilePath = h.Ref.FilePath
+		r.Title = fmt.Sprintf("%s %s %s", sym.DisplayName(), verb, h.Of)
+		r.Excerpt = h.Ref.Context
+		r.LineStart, r.LineEnd = h.Ref.Line, h.Ref.Line
+	case corpus == "callees" && h.Ref != nil:
+		if !h.Resolved {
+			// Not defined in the project: point at the call site.
+			r.FilePath = h.Ref.FilePath
+			r.Title = sym.QName
+			if r.Title == "" {
+				r.Title = sym.Name
+			}
+			r.Excerpt = h.Ref.Context
+			r.LineStart, r.LineEnd = h.Ref.Line, h.Ref.Line
+		} else if h.Ref.Kind != "call" {
+			r.Title += " (referenced)"
+		}
+	}
+	return r
+}
+
+func symbolReason(corpus string, h codeindex.SymbolHit) string {
+	switch corpus {
+	case "symbols":
+		return "definition of " + h.Symbol.QName
+	case "callees":
+		if !h.Resolved {
+			return fmt.Sprintf("called from %s at line %d; no definition in the index", h.Of, h.Ref.Line)
+		}
+		return fmt.Sprintf("called from %s at line %d", h.Of, h.Ref.Line)
+	}
+	if h.Resolved {
+		return "reference resolved to " + h.Ref.ToQName
+	}
+	return "matched by name only (" + h.Ref.ToName + "); the target could not be resolved"
+}
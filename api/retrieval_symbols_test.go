@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/codeindex"
+	"github.com/MartinNevlaha/stratus-v2/config"
+)
+
+func TestHandleRetrieve_SymbolCorpora(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module example.com/app\n",
+		"db/db.go":     "package db\n\ntype DB struct{}\n\nfunc (d *DB) SaveEvent() error { return nil }\n",
+		"api/route.go": "package api\n\nimport \"example.com/app/db\"\n\nfunc handleSave(d *db.DB) {\n\t_ = d.SaveEvent()\n}\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	si := codeindex.NewSymbolIndex(database, root, config.CodeIndexConfig{})
+	if _, err := si.IndexAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	server := newRetrievalServer(t, database)
+	server.SetSymbolIndex(si)
+
+	get := func(query string) []retrieveResult {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.handleRetrieve(w, httptest.NewRequest("GET", "/api/retrieve?"+query, nil))
+		if w.Code != 200 {
+			t.Fatalf("%s: status %d: %s", query, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Results []retrieveResult `json:"results"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Results
+	}
+
+	defs := get("q=SaveEvent&corpus=symbols")
+	if len(defs) != 1 || defs[0].FilePath != "db/db.go" || defs[0].LineStart != 5 || defs[0].Title != "method DB.SaveEvent" {
+		t.Fatalf("symbols = %+v", defs)
+	}
+
+	callers := get("q=DB.SaveEvent&corpus=callers&explain=true")
+	if len(callers) != 1 || callers[0].FilePath != "api/route.go" || callers[0].LineStart != 6 ||
+		callers[0].Title != "handleSave calls DB.SaveEvent" || callers[0].Explain == nil {
+		t.Fatalf("callers = %+v", callers)
+	}
+
+	callees := get("q=handleSave&corpus=callees")
+	if len(callees) == 0 || callees[0].Title != "method DB.SaveEvent" || callees[0].FilePath != "db/db.go" {
+		t.Fatalf("callees = %+v", callees)
+	}
+}
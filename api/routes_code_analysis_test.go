@@ -407,7 +407,7 @@ func TestHandleUpdateCodeAnalysisConfig_Valid(t *testing.T) {
 	defer database.Close()
 
 	cfg := config.Default()
-	server := &Server{db: database, cfg: &cfg}
+	server := &Server{db: database, cfg: &cfg, projectRoot: t.TempDir()}
 
 	updated := config.CodeAnalysisConfig{
 		Enabled:             true,
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MartinNevlaha/stratus-v2/orchestration"
+)
+
+// handleReportUsage records token spend from the statusline (a session's
+// cumulative cost) or the agent_usage hook (one delegated agent) against the
+// workflow that owns the session.
+func (s *Server) handleReportUsage(w http.ResponseWriter, r *http.Request) {
+	var body orchestration.UsageReport
+	if err := decodeBody(r, &body); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+	res, err := s.coordinator.RecordUsage(body)
+	if err != nil {
+		switch {
+		case errors.Is(err, orchestration.ErrInvalidUsage):
+			jsonErr(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, orchestration.ErrWorkflowNotFound):
+			jsonErr(w, http.StatusNotFound, err.Error())
+		default:
+			jsonErr(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if res.Entry != nil {
+		s.hub.BroadcastJSON("workflow_cost_updated", res.Summary)
+	}
+	if res.BudgetExceeded {
+		s.hub.BroadcastJSON("workflow_budget_exceeded", map[string]any{
+			"workflow_id": res.Summary.WorkflowID,
+			"spent_usd":   res.Summary.Total.CostUSD,
+			"budget_usd":  res.Summary.BudgetUSD,
+		})
+	}
+	json200(w, res)
+}
+
+// handleWorkflowCosts returns a workflow's spend and its latest ledger
+// entries.
+func (s *Server) handleWorkflowCosts(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	summary, err := s.coordinator.Costs(id)
+	if err != nil {
+		if errors.Is(err, orchestration.ErrWorkflowNotFound) {
+			jsonErr(w, http.StatusNotFound, err.Error())
+			return
+		}
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	entries, err := s.db.ListWorkflowCosts(id, queryInt(r, "limit", 50))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	json200(w, map[string]any{"summary": summary, "entries": entries})
+}
+
+// handleSetWorkflowBudget sets a workflow's cost cap; 0 falls back to the
+// workflow definition's budget.
+func (s *Server) handleSetWorkflowBudget(w http.ResponseWriter, r *http.Request) {
+	if !s.requireDashboard(w, r) {
+		return
+	}
+	id := r.PathValue("id")
+	var body struct {
+		BudgetUSD float64 `json:"budget_usd"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+	state, err := s.coordinator.SetBudget(id, body.BudgetUSD)
+	if err != nil {
+		switch {
+		case errors.Is(err, orchestration.ErrInvalidUsage):
+			jsonErr(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, orchestration.ErrWorkflowNotFound):
+			jsonErr(w, http.StatusNotFound, err.Error())
+		default:
+			jsonErr(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	s.hub.BroadcastJSON("workflow_updated", state)
+	if summary, err := s.coordinator.Costs(id); err == nil {
+		s.hub.BroadcastJSON("workflow_cost_updated", summary)
+	}
+	json200(w, state)
+}
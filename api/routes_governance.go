@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// GET /api/governance/docs
+// Lists indexed governance files with project-relative paths.
+func (s *Server) handleListGovernanceDocs(w http.ResponseWriter, r *http.Request) {
+	files, err := s.db.ListGovernanceFiles(s.projectRoot)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for i := range files {
+		files[i].FilePath = s.relProjectPath(files[i].FilePath)
+	}
+	json200(w, map[string]any{"docs": nilSlice(files), "count": len(files)})
+}
+
+// GET /api/governance/doc?path=<project-relative path>
+// Returns the full content of one indexed governance file.
+func (s *Server) handleGetGovernanceDoc(w http.ResponseWriter, r *http.Request) {
+	rel := queryStr(r, "path")
+	if rel == "" {
+		jsonErr(w, http.StatusBadRequest, "path is required")
+		return
+	}
+	clean := filepath.Clean(filepath.FromSlash(rel))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		jsonErr(w, http.StatusBadRequest, "path must be relative to the project root")
+		return
+	}
+
+	file, err := s.db.GetGovernanceFile(filepath.Join(s.projectRoot, clean))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if file == nil {
+		jsonErr(w, http.StatusNotFound, "governance doc not found")
+		return
+	}
+	file.FilePath = filepath.ToSlash(clean)
+	s.recordFollowUps([]string{file.FilePath})
+	json200(w, file)
+}
+
+// relProjectPath converts an absolute indexed path into a slash-separated
+// path relative to the project root. Paths outside the root are returned as-is.
+func (s *Server) relProjectPath(path string) string {
+	rel, err := filepath.Rel(s.projectRoot, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
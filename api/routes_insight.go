@@ -613,6 +613,62 @@ func (s *Server) handleGetWorkflowScorecardByType(w http.ResponseWriter, r *http
 	json200(w, card)
 }
 
+func (s *Server) handleGetToolScorecards(w http.ResponseWriter, r *http.Request) {
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "7d"
+	}
+
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			if parsed > 0 && parsed <= 200 {
+				limit = parsed
+			}
+		}
+	}
+
+	cards, err := s.db.ListToolScorecards(window, r.URL.Query().Get("sortBy"), r.URL.Query().Get("sortDirection"), limit)
+	if err != nil {
+		slog.Error("failed to list tool scorecards", "error", err)
+		jsonErr(w, http.StatusInternalServerError, "failed to list tool scorecards")
+		return
+	}
+
+	json200(w, map[string]any{
+		"scorecards": cards,
+		"window":     window,
+		"count":      len(cards),
+	})
+}
+
+func (s *Server) handleGetToolScorecardByName(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == "" {
+		jsonErr(w, http.StatusBadRequest, "missing tool name")
+		return
+	}
+
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "7d"
+	}
+
+	card, err := s.db.GetToolScorecardByName(name, window)
+	if err != nil {
+		slog.Error("failed to get tool scorecard", "name", name, "error", err)
+		jsonErr(w, http.StatusInternalServerError, "failed to get tool scorecard")
+		return
+	}
+
+	if card == nil {
+		jsonErr(w, http.StatusNotFound, "tool scorecard not found")
+		return
+	}
+
+	json200(w, card)
+}
+
 func (s *Server) handleTriggerScorecardComputation(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		jsonErr(w, http.StatusMethodNotAllowed, "method not allowed")
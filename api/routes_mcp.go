@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/events"
+	"github.com/MartinNevlaha/stratus-v2/mcp"
+)
+
+// mcpInProcessBase is the placeholder origin used by MCP tools served from
+// this process. Requests never leave the process, so the host is irrelevant.
+const mcpInProcessBase = "http://stratus.internal"
+
+// mcpToolTimeout bounds a single MCP tool call dispatched in-process.
+const mcpToolTimeout = 60 * time.Second
+
+// mcpEndpoint returns the /mcp handler for a mux built by Handler. The MCP
+// server behind it is built on the first call; later calls only redirect its
+// tool calls to the newer mux.
+func (s *Server) mcpEndpoint(mux *http.ServeMux) http.Handler {
+	s.mcpAPI.Store(mux)
+	s.mcpOnce.Do(func() {
+		s.mcpHandler = s.newMCPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.mcpAPI.Load().ServeHTTP(w, r)
+		}))
+	})
+	return s.mcpHandler
+}
+
+// newMCPHandler builds the Streamable HTTP MCP endpoint. It registers the same
+// tools, resources and prompts as `stratus mcp-serve`, but calls are
+// dispatched straight into apiHandler instead of a loopback HTTP connection,
+// and tool calls land in the same audit log.
+//
+// Hub broadcasts and event bus events drive resource change notifications.
+func (s *Server) newMCPHandler(apiHandler http.Handler) http.Handler {
+	client := &http.Client{
+		Transport: inProcessTransport{handler: apiHandler},
+		Timeout:   mcpToolTimeout,
+	}
+	srv := mcp.New()
+	mcp.RegisterTools(srv, mcpInProcessBase, client)
+	mcp.RegisterResources(srv, mcpInProcessBase, client)
+	mcp.RegisterPrompts(srv, mcpInProcessBase, client)
+	mcp.RecordCalls(srv, mcpInProcessBase, client)
+
+	if s.hub != nil {
+		s.hub.Listen(func(msg Message) { srv.HandleChange(msg.Type, msg.Payload) })
+	}
+	if s.eventBus != nil {
+		s.eventBus.Subscribe(func(_ context.Context, evt events.Event) {
+			srv.HandleChange(string(evt.Type), evt.Payload)
+		})
+	}
+	var origins []string
+	if s.cfg != nil {
+		origins = s.cfg.MCP.AllowedOrigins
+	}
+	return srv.HTTPHandler(origins...)
+}
+
+// inProcessTransport is an http.RoundTripper that serves requests with a local
+// handler. It lets the MCP tool registry (a thin HTTP proxy by design) run
+// inside `stratus serve` without knowing the port it listens on.
+type inProcessTransport struct {
+	handler http.Handler
+}
+
+func (t inProcessTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	// A panicking handler would otherwise take the whole MCP request down
+	// with it; report it as a failed call like a dropped connection would be.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s %s: handler panic: %v", req.Method, req.URL.Path, r)
+		}
+	}()
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, req)
+	resp = rec.Result()
+	resp.Request = req
+	return resp, nil
+}
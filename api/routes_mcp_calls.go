@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+// mcpErrorMaxLen caps the stored error text of a tool call.
+const mcpErrorMaxLen = 2000
+
+// handleRecordMCPCall appends one tool call to the audit log. MCP servers
+// post here after every tools/call; arguments arrive already redacted.
+func (s *Server) handleRecordMCPCall(w http.ResponseWriter, r *http.Request) {
+	var body db.MCPToolCall
+	if err := decodeBody(r, &body); err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	body.Tool = strings.TrimSpace(body.Tool)
+	if body.Tool == "" {
+		jsonErr(w, http.StatusBadRequest, "tool is required")
+		return
+	}
+	if body.DurationMs < 0 {
+		body.DurationMs = 0
+	}
+	if len(body.Error) > mcpErrorMaxLen {
+		body.Error = body.Error[:mcpErrorMaxLen]
+	}
+	id, err := s.db.SaveMCPToolCall(&body)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	json200(w, map[string]any{"id": id})
+}
+
+// handleListMCPCalls returns audit log entries, newest first. Filters:
+// tool, workflow_id, session_id, errors=true, since (timestamp), limit, offset.
+func (s *Server) handleListMCPCalls(w http.ResponseWriter, r *http.Request) {
+	limit := queryInt(r, "limit", 50)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	calls, err := s.db.ListMCPToolCalls(db.MCPToolCallFilter{
+		Tool:       queryStr(r, "tool"),
+		WorkflowID: queryStr(r, "workflow_id"),
+		SessionID:  queryStr(r, "session_id"),
+		ErrorsOnly: queryStr(r, "errors") == "true",
+		Since:      queryStr(r, "since"),
+		Limit:      limit,
+		Offset:     queryInt(r, "offset", 0),
+	})
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	json200(w, map[string]any{"calls": calls, "count": len(calls)})
+}
+
+// handleMCPMetrics returns the per-tool daily rollup for the last `days`
+// days (default 7), refreshing it from the audit log first. `tool` narrows
+// the result to one tool; tool=all is the daily summary.
+func (s *Server) handleMCPMetrics(w http.ResponseWriter, r *http.Request) {
+	days := queryInt(r, "days", 7)
+	if days <= 0 || days > 90 {
+		days = 7
+	}
+	if err := s.db.CollectMCPToolMetrics(days); err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	metrics, err := s.db.GetMCPToolMetrics(days, queryStr(r, "tool"))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	json200(w, map[string]any{"metrics": metrics, "days": days})
+}
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+	"github.com/MartinNevlaha/stratus-v2/orchestration"
+)
+
+// doMCPPost posts one JSON-RPC message (or batch) to the /mcp endpoint of h.
+func doMCPPost(t *testing.T, h http.Handler, sessionID, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if sessionID != "" {
+		r.Header.Set("Mcp-Session-Id", sessionID)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// doMCPAPIRequest sends a REST request to the /api/mcp routes of h.
+func doMCPAPIRequest(t *testing.T, h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// startMCPSession initializes an MCP session on h and returns its ID.
+func startMCPSession(t *testing.T, h http.Handler) string {
+	t.Helper()
+	w := doMCPPost(t, h, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("initialize: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	sid := w.Header().Get("Mcp-Session-Id")
+	if sid == "" {
+		t.Fatal("expected Mcp-Session-Id header")
+	}
+	return sid
+}
+
+// decodeMCPResponse decodes the JSON-RPC response recorded in w.
+func decodeMCPResponse(t *testing.T, w *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", w.Body.String(), err)
+	}
+	return resp
+}
+
+func TestMCPEndpoint_DispatchesToolsInProcess(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	if _, err := database.SaveEvent(db.SaveEventInput{Text: "the build uses sqlite fts5", Title: "fts note"}); err != nil {
+		t.Fatalf("SaveEvent: %v", err)
+	}
+
+	server := &Server{db: database}
+	h := server.Handler()
+
+	sid := startMCPSession(t, h)
+
+	w := doMCPPost(t, h, sid, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"search","arguments":{"query":"sqlite"}}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("tools/call: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Result struct {
+			IsError bool `json:"isError"`
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Result.IsError {
+		t.Fatalf("tool returned error: %s", w.Body.String())
+	}
+	if len(resp.Result.Content) == 0 || !strings.Contains(resp.Result.Content[0].Text, "fts note") {
+		t.Errorf("expected search hit in tool output, got %s", w.Body.String())
+	}
+}
+
+func TestMCPEndpoint_BuiltOncePerServer(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	hub := NewHub()
+	server := &Server{db: database, hub: hub}
+	first := server.Handler()
+	second := server.Handler()
+	if n := len(hub.listeners); n != 1 {
+		t.Fatalf("expected one hub listener after two Handler calls, got %d", n)
+	}
+
+	sid := startMCPSession(t, first)
+	w := doMCPPost(t, second, sid, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"search","arguments":{"query":"sqlite"}}}`)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), `"isError":true`) {
+		t.Fatalf("expected the session to work across handlers, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMCPEndpoint_ReadsWorkflowAndGovernanceResources(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "README.md"), []byte("# Project\n\nUse table-driven tests."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.IndexGovernance(root); err != nil {
+		t.Fatalf("IndexGovernance: %v", err)
+	}
+
+	coord := orchestration.NewCoordinator(database)
+	if _, err := coord.Start("spec-login", orchestration.WorkflowSpec, orchestration.ComplexitySimple, "Login"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := coord.SetPlanContent("spec-login", "## Plan\n\n1. add form"); err != nil {
+		t.Fatalf("SetPlanContent: %v", err)
+	}
+
+	server := &Server{db: database, coordinator: coord, projectRoot: root}
+	h := server.Handler()
+
+	sid := startMCPSession(t, h)
+
+	list := decodeMCPResponse(t, doMCPPost(t, h, sid, `{"jsonrpc":"2.0","id":2,"method":"resources/list"}`))
+	raw, _ := json.Marshal(list)
+	for _, uri := range []string{"stratus://workflow/spec-login/plan", "stratus://workflow/spec-login", "stratus://governance/README.md"} {
+		if !strings.Contains(string(raw), uri) {
+			t.Errorf("resources/list missing %s: %s", uri, raw)
+		}
+	}
+
+	plan := decodeMCPResponse(t, doMCPPost(t, h, sid, `{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"stratus://workflow/spec-login/plan"}}`))
+	raw, _ = json.Marshal(plan)
+	if !strings.Contains(string(raw), "add form") {
+		t.Errorf("plan resource missing content: %s", raw)
+	}
+
+	gov := decodeMCPResponse(t, doMCPPost(t, h, sid, `{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"stratus://governance/README.md"}}`))
+	raw, _ = json.Marshal(gov)
+	if !strings.Contains(string(raw), "table-driven tests") {
+		t.Errorf("governance resource missing content: %s", raw)
+	}
+
+	missing := decodeMCPResponse(t, doMCPPost(t, h, sid, `{"jsonrpc":"2.0","id":5,"method":"resources/read","params":{"uri":"stratus://workflow/nope/plan"}}`))
+	if errObj, _ := missing["error"].(map[string]any); errObj == nil || errObj["code"] != float64(-32002) {
+		t.Errorf("expected -32002 for missing workflow, got %v", missing)
+	}
+}
+
+func TestMCPEndpoint_PushesWorkflowUpdatesToSubscribers(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	coord := orchestration.NewCoordinator(database)
+	if _, err := coord.Start("bug-crash", orchestration.WorkflowBug, orchestration.ComplexitySimple, "Crash"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	server := &Server{db: database, coordinator: coord, hub: NewHub()}
+	h := server.Handler()
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	sid := startMCPSession(t, h)
+	doMCPPost(t, h, sid, `{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"stratus://workflow/bug-crash"}}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/mcp", nil)
+	r.Header.Set("Accept", "text/event-stream")
+	r.Header.Set("Mcp-Session-Id", sid)
+	stream, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer stream.Body.Close()
+
+	req, _ := http.NewRequest(http.MethodPut, ts.URL+"/api/workflows/bug-crash/phase", strings.NewReader(`{"phase":"fix"}`))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("transition: %v %v", err, resp)
+	}
+	resp.Body.Close()
+
+	scanner := bufio.NewScanner(stream.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data: ") {
+			if !strings.Contains(line, "notifications/resources/updated") || !strings.Contains(line, "stratus://workflow/bug-crash") {
+				t.Errorf("unexpected notification %q", line)
+			}
+			return
+		}
+	}
+	t.Fatalf("stream ended without notification: %v", scanner.Err())
+}
+
+func TestMCPEndpoint_RecordsToolCalls(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	server := &Server{db: database}
+	h := server.Handler()
+
+	sid := startMCPSession(t, h)
+	doMCPPost(t, h, sid, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"search","arguments":{"query":"sqlite","token":"s3cret"}}}`)
+	doMCPPost(t, h, sid, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"get_workflow","arguments":{"workflow_id":"spec-missing"}}}`)
+
+	// Calls are recorded asynchronously.
+	var calls []db.MCPToolCall
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		var err error
+		if calls, err = database.ListMCPToolCalls(db.MCPToolCallFilter{}); err != nil {
+			t.Fatal(err)
+		}
+		if len(calls) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 recorded calls, got %+v", calls)
+	}
+
+	w := doMCPAPIRequest(t, h, http.MethodGet, "/api/mcp/calls?workflow_id=spec-missing&errors=true", "")
+	var list struct {
+		Calls []db.MCPToolCall `json:"calls"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode calls: %v", err)
+	}
+	if len(list.Calls) != 1 || list.Calls[0].Tool != "get_workflow" || list.Calls[0].SessionID != sid {
+		t.Errorf("filtered calls = %s", w.Body.String())
+	}
+
+	w = doMCPAPIRequest(t, h, http.MethodGet, "/api/mcp/calls?tool=search", "")
+	if strings.Contains(w.Body.String(), "s3cret") {
+		t.Errorf("secret argument stored unredacted: %s", w.Body.String())
+	}
+
+	w = doMCPAPIRequest(t, h, http.MethodGet, "/api/mcp/metrics?tool=all", "")
+	var metrics struct {
+		Metrics []db.MCPToolMetric `json:"metrics"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &metrics); err != nil {
+		t.Fatalf("decode metrics: %v", err)
+	}
+	if len(metrics.Metrics) != 1 || metrics.Metrics[0].Calls != 2 || metrics.Metrics[0].Errors != 1 {
+		t.Errorf("metrics = %s", w.Body.String())
+	}
+
+	if w := doMCPAPIRequest(t, h, http.MethodPost, "/api/mcp/calls", `{"duration_ms":3}`); w.Code != http.StatusBadRequest {
+		t.Errorf("POST without tool: expected 400, got %d", w.Code)
+	}
+}
@@ -1,12 +1,21 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/MartinNevlaha/stratus-v2/db"
+	"github.com/MartinNevlaha/stratus-v2/memory"
 )
 
+// embedOnSaveTimeout bounds the background embedding of a freshly saved event.
+const embedOnSaveTimeout = 30 * time.Second
+
 func (s *Server) handleSaveEvent(w http.ResponseWriter, r *http.Request) {
 	var in db.SaveEventInput
 	if err := decodeBody(r, &in); err != nil {
@@ -19,6 +28,16 @@ func (s *Server) handleSaveEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	s.hub.BroadcastJSON("event_saved", map[string]any{"id": id})
+	if ix := s.memoryIndex; ix != nil {
+		// Embedding needs a model round-trip; don't hold up the save.
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), embedOnSaveTimeout)
+			defer cancel()
+			if err := ix.EmbedEvent(ctx, id); err != nil {
+				log.Printf("memory: embed event %d: %v (backfill will retry)", id, err)
+			}
+		}()
+	}
 	json200(w, map[string]any{"id": id})
 }
 
@@ -33,7 +52,16 @@ func (s *Server) handleSearchEvents(w http.ResponseWriter, r *http.Request) {
 		Limit:     queryInt(r, "limit", 20),
 		Offset:    queryInt(r, "offset", 0),
 	}
-	events, err := s.db.SearchEvents(in)
+	// mode=keyword forces plain FTS ranking even when embeddings are enabled.
+	mode := "keyword"
+	var events []db.Event
+	var err error
+	if s.memoryIndex != nil && in.Query != "" && queryStr(r, "mode") != "keyword" {
+		mode = "hybrid"
+		events, err = s.memoryIndex.Search(r.Context(), in)
+	} else {
+		events, err = s.db.SearchEvents(in)
+	}
 	if err != nil {
 		jsonErr(w, http.StatusInternalServerError, err.Error())
 		return
@@ -41,7 +69,43 @@ func (s *Server) handleSearchEvents(w http.ResponseWriter, r *http.Request) {
 	if events == nil {
 		events = []db.Event{}
 	}
-	json200(w, map[string]any{"results": events, "count": len(events)})
+	json200(w, map[string]any{"results": events, "count": len(events), "mode": mode})
+}
+
+// handleEmbeddingStatus reports whether semantic search is enabled and how
+// many events still lack an embedding.
+func (s *Server) handleEmbeddingStatus(w http.ResponseWriter, r *http.Request) {
+	if s.memoryIndex == nil {
+		json200(w, map[string]any{"enabled": false})
+		return
+	}
+	cov, err := s.memoryIndex.Coverage()
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	json200(w, map[string]any{"enabled": true, "coverage": cov})
+}
+
+// handleEmbeddingBackfill embeds up to `limit` events (default 500) that have
+// no embedding from the current model.
+func (s *Server) handleEmbeddingBackfill(w http.ResponseWriter, r *http.Request) {
+	if s.memoryIndex == nil {
+		jsonErr(w, http.StatusConflict, "embeddings are disabled (set embeddings.enabled in .stratus.json)")
+		return
+	}
+	limit := queryInt(r, "limit", 500)
+	n, err := s.memoryIndex.Backfill(r.Context(), limit)
+	if err != nil {
+		jsonErr(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	cov, err := s.memoryIndex.Coverage()
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	json200(w, map[string]any{"embedded": n, "coverage": cov})
 }
 
 func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
@@ -82,6 +146,111 @@ func (s *Server) handleBatchEvents(w http.ResponseWriter, r *http.Request) {
 	json200(w, map[string]any{"results": events})
 }
 
+// handleConsolidationReport returns a dry run of the memory consolidation
+// pass: which events would be purged, merged and decayed. Nothing changes.
+func (s *Server) handleConsolidationReport(w http.ResponseWriter, r *http.Request) {
+	s.runConsolidation(w, r, true)
+}
+
+// handleConsolidationRun applies a consolidation pass immediately.
+func (s *Server) handleConsolidationRun(w http.ResponseWriter, r *http.Request) {
+	s.runConsolidation(w, r, false)
+}
+
+func (s *Server) runConsolidation(w http.ResponseWriter, r *http.Request, dryRun bool) {
+	if s.consolidator == nil {
+		jsonErr(w, http.StatusServiceUnavailable, "memory consolidation not available")
+		return
+	}
+	run := s.consolidator.Apply
+	if dryRun {
+		run = s.consolidator.Plan
+	}
+	report, err := run(r.Context())
+	if errors.Is(err, memory.ErrRunning) {
+		jsonErr(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !dryRun {
+		s.hub.BroadcastJSON("memory_consolidated", report.Summary)
+	}
+	json200(w, report)
+}
+
+// maxImportBytes caps the size of a memory import upload.
+const maxImportBytes = 256 << 20
+
+// memoryFilter reads the export/import filter from query parameters.
+func memoryFilter(r *http.Request) memory.Filter {
+	return memory.Filter{
+		Project:         queryStr(r, "project"),
+		Scope:           queryStr(r, "scope"),
+		Type:            queryStr(r, "type"),
+		Since:           queryStr(r, "since"),
+		Until:           queryStr(r, "until"),
+		IncludeArchived: queryStr(r, "include_archived") == "true",
+	}
+}
+
+// handleMemoryExport streams memory events and their sessions as JSONL.
+// Filters: project, scope, type, since, until, include_archived=true.
+func (s *Server) handleMemoryExport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="stratus-memory-%s.jsonl"`, time.Now().Format("2006-01-02")))
+	if _, err := memory.Export(s.db, w, memoryFilter(r)); err != nil {
+		// Headers are already sent; the truncated stream is all we can do.
+		log.Printf("memory export: %v", err)
+	}
+}
+
+// handleMemoryImport loads a JSONL export from the request body. The same
+// filters as export apply; dry_run=true reports without writing.
+func (s *Server) handleMemoryImport(w http.ResponseWriter, r *http.Request) {
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	report, err := memory.Import(s.db, body, memory.ImportOptions{
+		Filter: memoryFilter(r),
+		DryRun: queryStr(r, "dry_run") == "true",
+	})
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !report.DryRun && report.Events.Imported > 0 {
+		s.hub.BroadcastJSON("memory_imported", report.Events)
+	}
+	json200(w, report)
+}
+
+// handleMemoryRedactions reports how many stored events and workflow logs
+// had secrets redacted.
+func (s *Server) handleMemoryRedactions(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.db.GetRedactionStats()
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	json200(w, stats)
+}
+
+// handleMemoryScrub redacts secrets in rows stored before redaction ran on
+// save and rebuilds the search index; dry_run=true only reports.
+func (s *Server) handleMemoryScrub(w http.ResponseWriter, r *http.Request) {
+	report, err := s.db.ScrubSecrets(queryStr(r, "dry_run") == "true")
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !report.DryRun && report.Events.Rows+report.WorkflowLogs.Rows > 0 {
+		s.hub.BroadcastJSON("memory_scrubbed", report)
+	}
+	json200(w, report)
+}
+
 func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 	var body struct {
 		ContentSessionID string  `json:"content_session_id"`
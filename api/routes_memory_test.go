@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+	"github.com/MartinNevlaha/stratus-v2/embeddings"
+	"github.com/MartinNevlaha/stratus-v2/memory"
+)
+
+// stubEmbedder gives every text the same direction so any embedded event
+// matches any query.
+type stubEmbedder struct{}
+
+func (stubEmbedder) Model() string { return "stub" }
+
+func (stubEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		out[i] = []float32{1, 1}
+	}
+	return out, nil
+}
+
+func TestSearchEvents_HybridMode(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+	if _, err := database.SaveEvent(db.SaveEventInput{Text: "connection pool exhausted under load"}); err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{db: database, hub: NewHub()}
+	handler := server.Handler()
+
+	get := func(path string) map[string]any {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: %d %s", path, rec.Code, rec.Body.String())
+		}
+		var out map[string]any
+		_ = json.Unmarshal(rec.Body.Bytes(), &out)
+		return out
+	}
+
+	if out := get("/api/events/embeddings/status"); out["enabled"] != false {
+		t.Errorf("status without index = %v", out)
+	}
+	if out := get("/api/events/search?q=database+saturation"); out["mode"] != "keyword" || out["count"].(float64) != 0 {
+		t.Errorf("keyword search = %v", out)
+	}
+
+	server.SetMemoryIndex(embeddings.NewIndexer(database, stubEmbedder{}, 8))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/events/embeddings/backfill", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"embedded":1`) {
+		t.Fatalf("backfill: %d %s", rec.Code, rec.Body.String())
+	}
+
+	out := get("/api/events/search?q=database+saturation")
+	if out["mode"] != "hybrid" || out["count"].(float64) != 1 {
+		t.Errorf("hybrid search = %v", out)
+	}
+	if out := get("/api/events/search?q=database+saturation&mode=keyword"); out["mode"] != "keyword" {
+		t.Errorf("mode=keyword = %v", out)
+	}
+	status := get("/api/events/embeddings/status")
+	cov, _ := status["coverage"].(map[string]any)
+	if status["enabled"] != true || cov["pending"].(float64) != 0 {
+		t.Errorf("status = %v", status)
+	}
+}
+
+func TestConsolidationEndpoints(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+	past := "2000-01-01"
+	if _, err := database.SaveEvent(db.SaveEventInput{Text: "scratch note", TTL: &past}); err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{db: database, hub: NewHub()}
+	handler := server.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/memory/consolidation/report", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("report without consolidator: %d", rec.Code)
+	}
+
+	cfg := config.Default().Memory.Consolidation
+	server.SetConsolidator(memory.NewConsolidator(database, func() config.MemoryConsolidationConfig { return cfg }))
+	for _, tc := range []struct {
+		method, path string
+		dryRun       bool
+		remaining    int
+	}{
+		{http.MethodGet, "/api/memory/consolidation/report", true, 1},
+		{http.MethodPost, "/api/memory/consolidation/run", false, 0},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s: %d %s", tc.method, tc.path, rec.Code, rec.Body.String())
+		}
+		var report memory.Report
+		_ = json.Unmarshal(rec.Body.Bytes(), &report)
+		if report.DryRun != tc.dryRun || report.Summary.Expired != 1 {
+			t.Errorf("%s: report = %+v", tc.path, report)
+		}
+		if events, _ := database.SearchEvents(db.SearchEventsInput{}); len(events) != tc.remaining {
+			t.Errorf("%s: %d events remain, want %d", tc.path, len(events), tc.remaining)
+		}
+	}
+}
+
+func TestMemoryExportImportEndpoints(t *testing.T) {
+	src := setupTestDB(t)
+	defer src.Close()
+	key := "shared-1"
+	if _, err := src.SaveEvent(db.SaveEventInput{Scope: "global", Text: "CI runs on every push", DedupeKey: &key}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.SaveEvent(db.SaveEventInput{Scope: "repo", Text: "local only"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	(&Server{db: src, hub: NewHub()}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/memory/export?scope=global", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("export: %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	export := rec.Body.String()
+	if strings.Count(export, "\n") != 2 {
+		t.Fatalf("export = %s", export)
+	}
+
+	dst := setupTestDB(t)
+	defer dst.Close()
+	handler := (&Server{db: dst, hub: NewHub()}).Handler()
+	for _, tc := range []struct {
+		query    string
+		imported int
+	}{
+		{"?dry_run=true", 1},
+		{"", 1},
+		{"", 0},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/memory/import"+tc.query, strings.NewReader(export)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("import%s: %d %s", tc.query, rec.Code, rec.Body.String())
+		}
+		var report memory.ImportReport
+		_ = json.Unmarshal(rec.Body.Bytes(), &report)
+		if report.Events.Imported != tc.imported {
+			t.Errorf("import%s: imported %d, want %d", tc.query, report.Events.Imported, tc.imported)
+		}
+	}
+	if e, _ := dst.FindEventByDedupeKey(key); e == nil {
+		t.Error("event not imported")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/memory/import", strings.NewReader("garbage")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("garbage import: %d", rec.Code)
+	}
+}
+
+func TestMemoryScrubEndpoints(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+	if _, err := database.SaveWorkflowLog("wf", "s", "Bash", "go test ./..."); err != nil {
+		t.Fatal(err)
+	}
+	handler := (&Server{db: database, hub: NewHub()}).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/memory/redactions", nil))
+	var stats db.RedactionStats
+	_ = json.Unmarshal(rec.Body.Bytes(), &stats)
+	if rec.Code != http.StatusOK || stats.WorkflowLogsRedacted != 0 {
+		t.Fatalf("redactions: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/memory/scrub?dry_run=true", nil))
+	var report db.ScrubReport
+	_ = json.Unmarshal(rec.Body.Bytes(), &report)
+	if rec.Code != http.StatusOK || !report.DryRun || report.WorkflowLogs.Scanned != 1 {
+		t.Errorf("scrub: %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"sort"
 	"strconv"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/MartinNevlaha/stratus-v2/events"
 	insightllm "github.com/MartinNevlaha/stratus-v2/internal/insight/llm"
+	"github.com/MartinNevlaha/stratus-v2/mcp"
 	"github.com/MartinNevlaha/stratus-v2/orchestration"
 )
 
@@ -295,10 +298,14 @@ func (s *Server) handleAnalyzeWorkflow(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleStartWorkflow(w http.ResponseWriter, r *http.Request) {
 	var body struct {
 		ID         string `json:"id"`
-		Type       string `json:"type"`       // "spec" | "bug" | "e2e"
+		Type       string `json:"type"`       // "spec" | "bug" | "e2e" | a custom definition
 		Complexity string `json:"complexity"` // "simple" | "complex"
 		Title      string `json:"title"`
 		SessionID  string `json:"session_id"` // Claude Code session — optional
+		Template   string `json:"template"`   // workflow template — optional
+		ParentID   string `json:"parent_id"`  // spawning workflow — optional
+
+		BudgetUSD float64 `json:"budget_usd"` // cost cap — optional
 	}
 	if err := decodeBody(r, &body); err != nil {
 		jsonErr(w, http.StatusBadRequest, "invalid body: "+err.Error())
@@ -309,18 +316,37 @@ func (s *Server) handleStartWorkflow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	wtype := orchestration.WorkflowSpec
-	if body.Type == "bug" {
-		wtype = orchestration.WorkflowBug
-	} else if body.Type == "e2e" {
-		wtype = orchestration.WorkflowE2E
+	if body.Type != "" {
+		wtype = orchestration.WorkflowType(strings.ToLower(strings.TrimSpace(body.Type)))
+	} else if t, ok := orchestration.Template(body.Template); ok {
+		wtype = t.Type
+	}
+	if _, ok := orchestration.Definition(wtype); !ok {
+		jsonErr(w, http.StatusBadRequest, fmt.Sprintf("unknown workflow type %q; defined types: %v", body.Type, orchestration.WorkflowTypes()))
+		return
 	}
-	complexity := orchestration.ComplexitySimple
-	if body.Complexity == "complex" {
+	// An empty complexity lets the template choose.
+	var complexity orchestration.Complexity
+	switch body.Complexity {
+	case "complex":
 		complexity = orchestration.ComplexityComplex
+	case "simple":
+		complexity = orchestration.ComplexitySimple
 	}
-	state, err := s.coordinator.Start(body.ID, wtype, complexity, body.Title)
+	state, err := s.coordinator.StartWith(body.ID, wtype, complexity, body.Title, orchestration.StartOptions{
+		Template:  body.Template,
+		ParentID:  body.ParentID,
+		BudgetUSD: body.BudgetUSD,
+	})
 	if err != nil {
-		jsonErr(w, http.StatusInternalServerError, err.Error())
+		switch {
+		case errors.Is(err, orchestration.ErrInvalidStart):
+			jsonErr(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, orchestration.ErrWorkflowNotFound):
+			jsonErr(w, http.StatusNotFound, err.Error())
+		default:
+			jsonErr(w, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 	if body.SessionID != "" {
@@ -340,10 +366,50 @@ func (s *Server) handleStartWorkflow(w http.ResponseWriter, r *http.Request) {
 		"type":        body.Type,
 		"complexity":  body.Complexity,
 		"title":       body.Title,
+		"parent_id":   state.ParentID,
 	})
+	if state.ParentID != "" {
+		if parent, err := s.coordinator.Get(state.ParentID); err == nil {
+			s.hub.BroadcastJSON("workflow_updated", parent)
+		}
+	}
 	json200(w, state)
 }
 
+// handleListWorkflowTemplates returns the templates loaded from
+// .stratus/templates. Invalid files are reported by the definitions list.
+func (s *Server) handleListWorkflowTemplates(w http.ResponseWriter, r *http.Request) {
+	json200(w, map[string]any{"templates": orchestration.Templates()})
+}
+
+// handleListWorkflowDefinitions returns the registered workflow types along
+// with any definition files that failed validation.
+func (s *Server) handleListWorkflowDefinitions(w http.ResponseWriter, r *http.Request) {
+	errs := orchestration.DefinitionErrors()
+	if errs == nil {
+		errs = []string{}
+	}
+	json200(w, map[string]any{
+		"definitions": orchestration.Definitions(),
+		"errors":      errs,
+	})
+}
+
+// handleReloadWorkflowDefinitions re-reads the project's workflow
+// definition files. Workflows already running keep their type; transitions
+// follow the reloaded definition.
+func (s *Server) handleReloadWorkflowDefinitions(w http.ResponseWriter, r *http.Request) {
+	if err := orchestration.LoadWorkflowDefinitions(s.projectRoot); err != nil {
+		log.Printf("workflow definitions: %v", err)
+	}
+	// MCP servers restrict workflow types and phases in their tool schemas.
+	s.hub.BroadcastJSON(mcp.WorkflowDefinitionsReloaded, map[string]any{
+		"types":  orchestration.WorkflowTypes(),
+		"phases": orchestration.KnownPhases(),
+	})
+	s.handleListWorkflowDefinitions(w, r)
+}
+
 func (s *Server) handleGetWorkflow(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	state, err := s.coordinator.Get(id)
@@ -368,14 +434,41 @@ func (s *Server) handleTransitionPhase(w http.ResponseWriter, r *http.Request) {
 	// matches the state machine instead of failing with "invalid transition".
 	phase := orchestration.Phase(strings.ToLower(strings.TrimSpace(body.Phase)))
 	state, err := s.coordinator.Transition(id, phase)
+	var checkErr *orchestration.CheckFailedError
+	if errors.As(err, &checkErr) {
+		s.hub.BroadcastJSON("phase_checks_failed", map[string]any{
+			"workflow_id": id,
+			"from":        checkErr.From,
+			"to":          checkErr.To,
+			"checks":      checkErr.Results,
+		})
+		jsonStatus(w, http.StatusConflict, map[string]any{"error": err.Error(), "checks": checkErr.Results})
+		return
+	}
+	var approvalErr *orchestration.ApprovalPendingError
+	if errors.As(err, &approvalErr) {
+		s.writeAwaitingApproval(w, approvalErr)
+		return
+	}
+	if errors.Is(err, orchestration.ErrConcurrentUpdate) {
+		jsonErr(w, http.StatusConflict, err.Error())
+		return
+	}
 	if err != nil {
 		jsonErr(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	s.announceTransition(state)
+	json200(w, state)
+}
+
+// announceTransition notifies the dashboard and event bus that a workflow
+// entered a new phase.
+func (s *Server) announceTransition(state *orchestration.WorkflowState) {
 	s.hub.BroadcastJSON("workflow_updated", state)
 	s.hub.BroadcastJSON("phase_changed", map[string]any{
-		"workflow_id": id,
-		"phase":       body.Phase,
+		"workflow_id": state.ID,
+		"phase":       state.Phase,
 	})
 	s.emitEvent(events.EventPhaseTransition, "orchestration", map[string]any{
 		"workflow_id":   state.ID,
@@ -383,13 +476,137 @@ func (s *Server) handleTransitionPhase(w http.ResponseWriter, r *http.Request) {
 		"to_phase":      string(state.Phase),
 		"title":         state.Title,
 	})
-	if body.Phase == "complete" {
+	if state.Phase == orchestration.PhaseComplete {
 		s.hub.BroadcastJSON("workflow_completed", map[string]any{
-			"workflow_id": id,
+			"workflow_id": state.ID,
 			"success":     true,
 		})
-		go s.generateChangeSummary(id, state.BaseCommit)
+		go s.generateChangeSummary(state.ID, state.BaseCommit)
 	}
+}
+
+// writeAwaitingApproval answers a transition held for human approval with
+// 202 and the workflow state, marked with status "awaiting_approval" so
+// agents can tell it apart from a completed transition.
+func (s *Server) writeAwaitingApproval(w http.ResponseWriter, e *orchestration.ApprovalPendingError) {
+	s.hub.BroadcastJSON("workflow_updated", e.State)
+	s.hub.BroadcastJSON("approval_requested", map[string]any{
+		"workflow_id": e.WorkflowID,
+		"title":       e.State.Title,
+		"approval":    e.Approval,
+	})
+	body := map[string]any{}
+	if data, err := json.Marshal(e.State); err == nil {
+		_ = json.Unmarshal(data, &body)
+	}
+	body["status"] = "awaiting_approval"
+	body["message"] = e.Error()
+	jsonStatus(w, http.StatusAccepted, body)
+}
+
+// handleResolveApproval records a human decision on a workflow's pending
+// approval. Only the dashboard may do so; see requireDashboard.
+func (s *Server) handleResolveApproval(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !s.requireDashboard(w, r) {
+		return
+	}
+	var body struct {
+		Decision string `json:"decision"`
+		Actor    string `json:"actor"`
+		Comment  string `json:"comment"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+	state, transitioned, err := s.coordinator.ResolveApproval(id, body.Decision, body.Actor, body.Comment)
+	if err != nil {
+		if errors.Is(err, orchestration.ErrWorkflowNotFound) {
+			jsonErr(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, orchestration.ErrConcurrentUpdate) {
+			jsonErr(w, http.StatusConflict, err.Error())
+			return
+		}
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.hub.BroadcastJSON("approval_resolved", map[string]any{
+		"workflow_id": id,
+		"decision":    body.Decision,
+		"actor":       body.Actor,
+		"approval":    state.Approval,
+	})
+	if transitioned {
+		s.announceTransition(state)
+	} else {
+		s.hub.BroadcastJSON("workflow_updated", state)
+	}
+	json200(w, state)
+}
+
+func (s *Server) handleListWorkflowEvidence(w http.ResponseWriter, r *http.Request) {
+	evidence, err := s.db.ListWorkflowEvidence(r.PathValue("id"))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	json200(w, map[string]any{"evidence": evidence})
+}
+
+func (s *Server) handleWorkflowHistory(w http.ResponseWriter, r *http.Request) {
+	h, err := s.coordinator.History(r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, orchestration.ErrWorkflowNotFound) {
+			jsonErr(w, http.StatusNotFound, err.Error())
+			return
+		}
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	json200(w, h)
+}
+
+func (s *Server) handleRewindWorkflow(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !s.requireDashboard(w, r) {
+		return
+	}
+	var body struct {
+		Phase  string `json:"phase"`
+		Reason string `json:"reason"`
+		Actor  string `json:"actor"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+	if strings.TrimSpace(body.Reason) == "" {
+		jsonErr(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+	phase := orchestration.Phase(strings.ToLower(strings.TrimSpace(body.Phase)))
+	state, err := s.coordinator.Rewind(id, phase, body.Reason, body.Actor)
+	if err != nil {
+		if errors.Is(err, orchestration.ErrWorkflowNotFound) {
+			jsonErr(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, orchestration.ErrConcurrentUpdate) {
+			jsonErr(w, http.StatusConflict, err.Error())
+			return
+		}
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.hub.BroadcastJSON("workflow_updated", state)
+	s.hub.BroadcastJSON("phase_changed", map[string]any{
+		"workflow_id": id,
+		"phase":       state.Phase,
+		"rewind":      true,
+	})
 	json200(w, state)
 }
 
@@ -403,6 +620,15 @@ func (s *Server) handleRecordDelegation(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	state, err := s.coordinator.RecordDelegation(id, body.AgentID)
+	var budgetErr *orchestration.BudgetExceededError
+	if errors.As(err, &budgetErr) {
+		jsonStatus(w, http.StatusConflict, map[string]any{
+			"error":      err.Error(),
+			"spent_usd":  budgetErr.SpentUSD,
+			"budget_usd": budgetErr.BudgetUSD,
+		})
+		return
+	}
 	if err != nil {
 		jsonErr(w, http.StatusBadRequest, err.Error())
 		return
@@ -583,10 +809,20 @@ func (s *Server) handleDispatch(w http.ResponseWriter, r *http.Request) {
 	if delegated == nil {
 		delegated = []string{}
 	}
+	nextPhases := []orchestration.Phase{}
+	var allowedAgents []string
+	if def, ok := orchestration.Definition(state.Type); ok {
+		if p, ok := def.Phase(state.Phase); ok {
+			nextPhases = append(nextPhases, p.Next...)
+			allowedAgents = p.Agents
+		}
+	}
 	json200(w, map[string]any{
 		"workflow_id":      id,
 		"type":             state.Type,
 		"phase":            phase,
+		"next_phases":      nextPhases,
+		"allowed_agents":   allowedAgents,
 		"delegated_agents": delegated,
 		"total_tasks":      state.TotalTasks,
 		"current_task":     state.CurrentTask,
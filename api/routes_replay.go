@@ -0,0 +1,64 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/MartinNevlaha/stratus-v2/orchestration"
+)
+
+// handleWorkflowReplay returns a workflow's recorded timeline. With ?step=N
+// it also returns the workflow state as it was after entry N.
+func (s *Server) handleWorkflowReplay(w http.ResponseWriter, r *http.Request) {
+	replay, err := s.coordinator.Replay(r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, orchestration.ErrWorkflowNotFound) {
+			jsonErr(w, http.StatusNotFound, err.Error())
+			return
+		}
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := struct {
+		*orchestration.Replay
+		State *orchestration.WorkflowState `json:"state,omitempty"`
+	}{Replay: replay}
+	if v := r.URL.Query().Get("step"); v != "" {
+		step, err := strconv.Atoi(v)
+		if err != nil {
+			jsonErr(w, http.StatusBadRequest, "step must be a number")
+			return
+		}
+		if resp.State, err = replay.StateAt(step); err != nil {
+			jsonErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	json200(w, resp)
+}
+
+// handleSimulateWorkflow replays a workflow against a modified definition
+// and reports the steps it would judge differently from the registered one.
+func (s *Server) handleSimulateWorkflow(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Definition orchestration.WorkflowDefinition `json:"definition"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+	sim, err := s.coordinator.Simulate(r.PathValue("id"), body.Definition)
+	if err != nil {
+		switch {
+		case errors.Is(err, orchestration.ErrInvalidSimulation):
+			jsonErr(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, orchestration.ErrWorkflowNotFound):
+			jsonErr(w, http.StatusNotFound, err.Error())
+		default:
+			jsonErr(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	json200(w, sim)
+}
@@ -4,27 +4,46 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"sort"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
 )
 
 // retrieveResult is a single hit returned by runRetrieve, shared between the
 // HTTP handler and internal callers (e.g. the phase-transition prefetcher).
+// Score is the fused score, comparable across sources.
 type retrieveResult struct {
-	Source         string  `json:"source"`
-	FilePath       string  `json:"file_path"`
-	Title          string  `json:"title"`
-	Excerpt        string  `json:"excerpt"`
-	Score          float64 `json:"score"`
-	DocType        string  `json:"doc_type,omitempty"`
-	PageType       string  `json:"page_type,omitempty"`
-	StalenessScore float64 `json:"staleness_score,omitempty"`
+	Source         string           `json:"source"`
+	FilePath       string           `json:"file_path"`
+	Title          string           `json:"title"`
+	Excerpt        string           `json:"excerpt"`
+	Score          float64          `json:"score"`
+	LineStart      int              `json:"line_start,omitempty"`
+	LineEnd        int              `json:"line_end,omitempty"`
+	DocType        string           `json:"doc_type,omitempty"`
+	PageType       string           `json:"page_type,omitempty"`
+	StalenessScore float64          `json:"staleness_score,omitempty"`
+	Explain        *retrieveExplain `json:"explain,omitempty"`
 }
 
 // runRetrieve is the core search logic shared by handleRetrieve and internal
-// callers. corpus is one of "code" | "governance" | "wiki" | "" (auto = all).
-// In auto mode, wiki results are capped at topK/3 so they don't drown out code
-// and governance hits.
+// callers. corpus is one of "code" | "governance" | "wiki" | "" (auto = all)
+// or one of the symbol corpora ("symbols" | "callers" | "callees").
 func (s *Server) runRetrieve(query, corpus string, topK int) []retrieveResult {
-	var results []retrieveResult
+	results, _ := s.retrieve(query, corpus, topK, false)
+	return results
+}
+
+// retrieve searches each selected corpus, fuses the ranked lists (see
+// fuseResults), optionally reranks the head and cuts to topK. In auto mode,
+// wiki candidates are capped at topK/3 so they don't drown out code and
+// governance hits. With explain, hits carry their per-source ranks and the
+// dropped candidates are returned too.
+func (s *Server) retrieve(query, corpus string, topK int, explain bool) ([]retrieveResult, []droppedHit) {
+	if symbolCorpora[corpus] {
+		return s.retrieveSymbols(query, corpus, topK, explain), nil
+	}
+	var lists []rankedList
 
 	useCode := corpus == "" || corpus == "code"
 	useGov := corpus == "" || corpus == "governance"
@@ -33,18 +52,22 @@ func (s *Server) runRetrieve(query, corpus string, topK int) []retrieveResult {
 	if useCode && s.vexor.Available() {
 		hits, err := s.vexor.Search(query, topK, "auto")
 		if err == nil {
-			log.Printf("[vexor search] query=%q results=%d", query, len(hits))
+			log.Printf("[code search] query=%q results=%d", query, len(hits))
+			list := rankedList{source: "code"}
 			for _, h := range hits {
-				results = append(results, retrieveResult{
-					Source:   "code",
-					FilePath: h.FilePath,
-					Title:    h.Heading,
-					Excerpt:  h.Excerpt,
-					Score:    h.Score,
+				list.hits = append(list.hits, retrieveResult{
+					Source:    "code",
+					FilePath:  h.FilePath,
+					Title:     h.Heading,
+					Excerpt:   h.Excerpt,
+					Score:     h.Score,
+					LineStart: h.LineStart,
+					LineEnd:   h.LineEnd,
 				})
 			}
+			lists = append(lists, list)
 		} else {
-			log.Printf("[vexor search] query=%q error=%v", query, err)
+			log.Printf("[code search] query=%q error=%v", query, err)
 		}
 	}
 
@@ -52,16 +75,20 @@ func (s *Server) runRetrieve(query, corpus string, topK int) []retrieveResult {
 		docs, err := s.db.SearchDocs(query, "", s.projectRoot, topK)
 		if err == nil {
 			log.Printf("[governance search] query=%q results=%d", query, len(docs))
+			list := rankedList{source: "governance"}
 			for _, d := range docs {
-				results = append(results, retrieveResult{
+				list.hits = append(list.hits, retrieveResult{
 					Source:   "governance",
 					FilePath: d.FilePath,
 					Title:    d.Title,
 					Excerpt:  truncate(d.Content, 500),
-					Score:    d.Score,
-					DocType:  d.DocType,
+					// bm25: lower is better; negate so higher is better like
+					// the other sources.
+					Score:   -d.Score,
+					DocType: d.DocType,
 				})
 			}
+			lists = append(lists, list)
 		} else {
 			log.Printf("[governance search] query=%q error=%v", query, err)
 		}
@@ -79,6 +106,7 @@ func (s *Server) runRetrieve(query, corpus string, topK int) []retrieveResult {
 		wikiPages, err := s.db.SearchWikiPages(query, "", wikiLimit)
 		if err == nil {
 			log.Printf("[wiki search] query=%q results=%d", query, len(wikiPages))
+			list := rankedList{source: "wiki"}
 			for i, p := range wikiPages {
 				score := 1.0 - float64(i)*0.1
 				if score < 0.1 {
@@ -92,7 +120,7 @@ func (s *Server) runRetrieve(query, corpus string, topK int) []retrieveResult {
 				if p.GeneratedBy == "evolution" {
 					score *= 1.2
 				}
-				results = append(results, retrieveResult{
+				list.hits = append(list.hits, retrieveResult{
 					Source:         "wiki",
 					Title:          p.Title,
 					Excerpt:        truncate(p.Content, 500),
@@ -101,17 +129,26 @@ func (s *Server) runRetrieve(query, corpus string, topK int) []retrieveResult {
 					StalenessScore: p.StalenessScore,
 				})
 			}
+			// The staleness penalty and evolution boost reorder pages, so the
+			// wiki's rank for fusion follows the adjusted score.
+			sort.SliceStable(list.hits, func(i, j int) bool { return list.hits[i].Score > list.hits[j].Score })
+			lists = append(lists, list)
 		} else {
 			log.Printf("[wiki search] query=%q error=%v", query, err)
 		}
 	}
 
-	return results
+	cfg := s.retrievalConfig()
+	fused, dropped := fuseResults(lists, cfg)
+	if err := s.rerankHits(query, fused, cfg.Rerank); err != nil {
+		log.Printf("[retrieve rerank] query=%q error=%v", query, err)
+	}
+	return finalizeResults(fused, dropped, topK, explain)
 }
 
 func (s *Server) handleRetrieve(w http.ResponseWriter, r *http.Request) {
 	query := queryStr(r, "q")
-	corpus := queryStr(r, "corpus") // "code" | "governance" | "wiki" | "" (auto)
+	corpus := queryStr(r, "corpus") // "code" | "governance" | "wiki" | "" (auto) | "symbols" | "callers" | "callees"
 	topK := queryInt(r, "top_k", 10)
 
 	if query == "" {
@@ -119,38 +156,69 @@ func (s *Server) handleRetrieve(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if corpus != "" && corpus != "code" && corpus != "governance" && corpus != "wiki" {
-		jsonErr(w, http.StatusBadRequest, "invalid corpus value, must be: code, governance, wiki, or empty")
+	if corpus != "" && corpus != "code" && corpus != "governance" && corpus != "wiki" && !symbolCorpora[corpus] {
+		jsonErr(w, http.StatusBadRequest, "invalid corpus value, must be: code, governance, wiki, symbols, callers, callees, or empty")
 		return
 	}
 
-	results := s.runRetrieve(query, corpus, topK)
+	explain := queryStr(r, "explain") == "true"
+	results, dropped := s.retrieve(query, corpus, topK, explain)
 	if results == nil {
 		results = []retrieveResult{}
 	}
-	json200(w, map[string]any{
+	s.logRetrieval(query, corpus, results)
+	resp := map[string]any{
 		"results": results,
 		"count":   len(results),
 		"query":   query,
 		"corpus":  corpus,
-	})
+	}
+	if explain {
+		cfg := s.retrievalConfig()
+		if dropped == nil {
+			dropped = []droppedHit{}
+		}
+		resp["dropped"] = dropped
+		resp["fusion"] = cfg.Fusion
+		resp["reranked"] = s.reranker != nil && cfg.Rerank.Enabled
+	}
+	if maxTokens := queryInt(r, "max_tokens", 0); maxTokens > 0 {
+		resp["context"] = s.packRetrieved(query, results, corpus == "", maxTokens)
+	}
+	json200(w, resp)
 }
 
 func (s *Server) handleRetrieveStatus(w http.ResponseWriter, r *http.Request) {
 	stats, _ := s.db.GovernanceStats()
 	wikiCount, _ := s.db.WikiPageCount()
-	json200(w, map[string]any{
+	resp := map[string]any{
 		"vexor_available":      s.vexor.Available(),
+		"code_backend":         "vexor",
 		"governance_available": true,
 		"wiki_available":       wikiCount > 0,
 		"wiki_page_count":      wikiCount,
 		"governance_stats":     stats,
-	})
+		"symbols_available":    s.symbols != nil,
+	}
+	if s.symbols != nil {
+		if symStats, err := s.symbols.Stats(); err == nil {
+			resp["symbol_index"] = symStats
+		}
+	}
+	if ix, ok := s.vexor.(interface {
+		Stats() (db.CodeIndexStats, error)
+	}); ok {
+		resp["code_backend"] = "native"
+		if codeStats, err := ix.Stats(); err == nil {
+			resp["code_index"] = codeStats
+		}
+	}
+	json200(w, resp)
 }
 
 func (s *Server) handleReIndex(w http.ResponseWriter, r *http.Request) {
 	go func() {
-		_ = s.db.IndexGovernance(s.projectRoot)
+		_, _ = s.db.IndexGovernanceWith(s.projectRoot, s.governanceOptions())
 		stats, _ := s.db.GovernanceStats()
 		s.hub.BroadcastJSON("governance_indexed", stats)
 	}()
@@ -166,6 +234,7 @@ func (s *Server) handleMarkDirty(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	s.markDirty(body.Paths)
+	s.recordFollowUps(body.Paths)
 	json200(w, map[string]any{"status": "queued", "count": len(body.Paths)})
 }
 
@@ -388,6 +388,9 @@ func (s *Server) handleSwarmDispatch(w http.ResponseWriter, r *http.Request) {
 	if assignments == nil {
 		assignments = []swarm.Assignment{}
 	}
+	if len(assignments) > 0 {
+		s.hub.BroadcastJSON("tickets_dispatched", map[string]any{"mission_id": missionID, "assignments": assignments})
+	}
 	json200(w, map[string]any{"assignments": assignments})
 }
 
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MartinNevlaha/stratus-v2/memory"
+)
+
+// maxSyncPushBytes caps one batch pushed by a peer.
+const maxSyncPushBytes = 64 << 20
+
+// handleSyncStatus reports this instance's sync identity, journal counts and
+// the last sync round.
+func (s *Server) handleSyncStatus(w http.ResponseWriter, r *http.Request) {
+	if s.syncer == nil {
+		json200(w, map[string]any{"enabled": false})
+		return
+	}
+	status, err := s.syncer.Status()
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	json200(w, map[string]any{"enabled": true, "status": status})
+}
+
+// handleSyncRun runs a sync round immediately.
+func (s *Server) handleSyncRun(w http.ResponseWriter, r *http.Request) {
+	if s.syncer == nil {
+		jsonErr(w, http.StatusConflict, "team sync is disabled")
+		return
+	}
+	report, err := s.syncer.Sync(r.Context())
+	if errors.Is(err, memory.ErrSyncRunning) {
+		jsonErr(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if report.Merge.Applied > 0 {
+		s.hub.BroadcastJSON("sync_completed", report)
+	}
+	json200(w, report)
+}
+
+// syncPeer checks that this instance serves as a hub and that the caller
+// presented the configured token.
+func (s *Server) syncPeer(w http.ResponseWriter, r *http.Request) bool {
+	if s.syncer == nil || !s.syncer.Serving() {
+		jsonErr(w, http.StatusNotFound, "this instance is not a sync hub")
+		return false
+	}
+	if !s.syncer.Authorized(r.Header.Get("Authorization")) {
+		jsonErr(w, http.StatusUnauthorized, "invalid sync token")
+		return false
+	}
+	return true
+}
+
+// handleSyncChanges serves journal entries after ?since= to pulling peers.
+func (s *Server) handleSyncChanges(w http.ResponseWriter, r *http.Request) {
+	if !s.syncPeer(w, r) {
+		return
+	}
+	items, next, err := s.syncer.Changes(int64(queryInt(r, "since", 0)), queryInt(r, "limit", 500))
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if items == nil {
+		items = []memory.SyncItem{}
+	}
+	json200(w, memory.HubChanges{Items: items, Next: next})
+}
+
+// handleSyncPush merges entries pushed by a peer into this instance.
+func (s *Server) handleSyncPush(w http.ResponseWriter, r *http.Request) {
+	if !s.syncPeer(w, r) {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxSyncPushBytes)
+	var req memory.HubPushRequest
+	if err := decodeBody(r, &req); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.Instance == "" {
+		jsonErr(w, http.StatusBadRequest, "instance is required")
+		return
+	}
+	result := s.syncer.Merge(req.Items)
+	if result.Applied > 0 {
+		s.hub.BroadcastJSON("sync_completed", map[string]any{"from": req.Instance, "merge": result})
+	}
+	json200(w, result)
+}
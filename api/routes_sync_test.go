@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+	"github.com/MartinNevlaha/stratus-v2/memory"
+)
+
+func newSyncer(t *testing.T, database *db.DB, cfg config.TeamSyncConfig) *memory.Syncer {
+	t.Helper()
+	s, err := memory.NewSyncer(database, func() config.TeamSyncConfig { return cfg })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestTeamSync_ThroughHub(t *testing.T) {
+	cfg := config.Default().TeamSync
+	cfg.Enabled = true
+
+	hubDB := setupTestDB(t)
+	defer hubDB.Close()
+	hubCfg := cfg
+	hubCfg.Serve, hubCfg.Token = true, "s3cret"
+	hubServer := &Server{db: hubDB, hub: NewHub()}
+	hubServer.SetSyncer(newSyncer(t, hubDB, hubCfg))
+	hub := httptest.NewServer(hubServer.Handler())
+	defer hub.Close()
+
+	resp, err := http.Get(hub.URL + "/api/sync/changes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("changes without token: %d", resp.StatusCode)
+	}
+
+	client := func() (*memory.Syncer, *db.DB) {
+		database := setupTestDB(t)
+		t.Cleanup(func() { database.Close() })
+		s := newSyncer(t, database, cfg)
+		s.SetTransport(memory.NewHubTransport(hub.URL, "s3cret"))
+		return s, database
+	}
+	a, dbA := client()
+	b, dbB := client()
+
+	if _, err := dbA.SaveEvent(db.SaveEventInput{Scope: "global", Type: "decision", Text: "Releases are cut on Tuesdays"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []*memory.Syncer{a, b} {
+		report, err := s.Sync(context.Background())
+		if err != nil || len(report.Errors) > 0 {
+			t.Fatalf("sync: %v %v", err, report)
+		}
+	}
+	for name, database := range map[string]*db.DB{"hub": hubDB, "B": dbB} {
+		events, _ := database.SearchEvents(db.SearchEventsInput{Query: "Tuesdays"})
+		if len(events) != 1 {
+			t.Errorf("%s has %d matching events", name, len(events))
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	hubServer.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/sync/status", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Instances without a syncer report sync as disabled and are not hubs.
+	plain := (&Server{db: dbA, hub: NewHub()}).Handler()
+	for _, tc := range []struct {
+		method, path string
+		code         int
+	}{
+		{http.MethodGet, "/api/sync/status", http.StatusOK},
+		{http.MethodPost, "/api/sync/run", http.StatusConflict},
+		{http.MethodGet, "/api/sync/changes", http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		plain.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		if rec.Code != tc.code {
+			t.Errorf("%s %s: %d, want %d", tc.method, tc.path, rec.Code, tc.code)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -51,3 +53,154 @@ func TestHandleTransitionPhase_CaseInsensitive(t *testing.T) {
 		t.Errorf("phase = %v, want plan", resp["phase"])
 	}
 }
+
+func TestHandleStartWorkflow_RejectsUndefinedType(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+	server := &Server{db: database, coordinator: orchestration.NewCoordinator(database), hub: NewHub()}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/workflows", strings.NewReader(`{"id":"release-1","type":"release","title":"Release"}`))
+	w := httptest.NewRecorder()
+	server.handleStartWorkflow(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "spec") {
+		t.Fatalf("expected 400 listing defined types, got %d (body: %s)", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	server.handleListWorkflowDefinitions(w, httptest.NewRequest(http.MethodGet, "/api/workflow-definitions", nil))
+	var resp struct {
+		Definitions []orchestration.WorkflowDefinition `json:"definitions"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Definitions) != 3 {
+		t.Fatalf("definitions = %+v", resp.Definitions)
+	}
+}
+
+func TestHandleStartWorkflow_TemplateAndParentErrors(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+	server := &Server{db: database, coordinator: orchestration.NewCoordinator(database), hub: NewHub()}
+
+	for body, want := range map[string]int{
+		`{"id":"spec-1","type":"spec","title":"T","template":"missing"}`: http.StatusBadRequest,
+		`{"id":"bug-1","type":"bug","title":"T","parent_id":"missing"}`:  http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		server.handleStartWorkflow(w, httptest.NewRequest(http.MethodPost, "/api/workflows", strings.NewReader(body)))
+		if w.Code != want {
+			t.Errorf("%s: got %d want %d (body: %s)", body, w.Code, want, w.Body.String())
+		}
+	}
+}
+
+func TestHandleTransitionPhase_FailingCheckReturnsConflict(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, orchestration.WorkflowDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	def := `{"type": "hotfix", "phases": [
+	  {"name": "fix", "next": ["complete"], "checks": [{"name": "test", "command": ["sh", "-c", "echo 'FAIL: TestLogin'; exit 1"]}]},
+	  {"name": "complete"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "hotfix.json"), []byte(def), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := orchestration.LoadWorkflowDefinitions(root); err != nil {
+		t.Fatal(err)
+	}
+	empty := t.TempDir()
+	t.Cleanup(func() { _ = orchestration.LoadWorkflowDefinitions(empty) })
+
+	database := setupTestDB(t)
+	defer database.Close()
+	coord := orchestration.NewCoordinator(database)
+	coord.SetProjectRoot(root)
+	server := &Server{db: database, coordinator: coord, hub: NewHub()}
+	if _, err := coord.Start("hotfix-1", "hotfix", orchestration.ComplexitySimple, "Login fix"); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/workflows/hotfix-1/phase", strings.NewReader(`{"phase":"complete"}`))
+	req.SetPathValue("id", "hotfix-1")
+	w := httptest.NewRecorder()
+	server.handleTransitionPhase(w, req)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Error  string                      `json:"error"`
+		Checks []orchestration.CheckResult `json:"checks"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp.Error, "FAIL: TestLogin") || len(resp.Checks) != 1 || resp.Checks[0].EvidenceID == 0 {
+		t.Fatalf("response = %+v", resp)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/workflows/hotfix-1/evidence", nil)
+	req.SetPathValue("id", "hotfix-1")
+	w = httptest.NewRecorder()
+	server.handleListWorkflowEvidence(w, req)
+	if !strings.Contains(w.Body.String(), `"verdict":"fail"`) {
+		t.Fatalf("evidence = %s", w.Body.String())
+	}
+}
+
+func TestHandleTransitionPhase_ApprovalGate(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+	coord := orchestration.NewCoordinator(database)
+	server := &Server{db: database, coordinator: coord, hub: NewHub(), dashboardToken: "secret"}
+
+	const id = "spec-approval-test"
+	if _, err := coord.Start(id, orchestration.WorkflowSpec, orchestration.ComplexityComplex, "Approval"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := coord.Transition(id, orchestration.PhaseAccept); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/workflows/"+id+"/phase", strings.NewReader(`{"phase":"implement"}`))
+	req.SetPathValue("id", id)
+	w := httptest.NewRecorder()
+	server.handleTransitionPhase(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	var resp map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp["status"] != "awaiting_approval" || resp["phase"] != "accept" {
+		t.Fatalf("response = %v", resp)
+	}
+
+	resolve := func(body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/workflows/"+id+"/approval", strings.NewReader(body))
+		req.SetPathValue("id", id)
+		if token != "" {
+			req.Header.Set(DashboardTokenHeader, token)
+		}
+		w := httptest.NewRecorder()
+		server.handleResolveApproval(w, req)
+		return w
+	}
+	if w := resolve(`{"decision":"approve","actor":"alice"}`, ""); w.Code != http.StatusForbidden {
+		t.Fatalf("request without the dashboard token: expected 403, got %d", w.Code)
+	}
+	if w := resolve(`{"decision":"approve","actor":"alice"}`, "guess"); w.Code != http.StatusForbidden {
+		t.Fatalf("request with a wrong token: expected 403, got %d", w.Code)
+	}
+	w = resolve(`{"decision":"approve","actor":"alice","comment":"ship it"}`, "secret")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	state, err := coord.Get(id)
+	if err != nil || state.Phase != orchestration.PhaseImplement || state.Approval.Status != orchestration.ApprovalApproved {
+		t.Fatalf("state = %+v, %v", state, err)
+	}
+}
@@ -9,10 +9,14 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/MartinNevlaha/stratus-v2/codeindex"
 	"github.com/MartinNevlaha/stratus-v2/config"
 	"github.com/MartinNevlaha/stratus-v2/db"
+	"github.com/MartinNevlaha/stratus-v2/embeddings"
+	"github.com/MartinNevlaha/stratus-v2/governance"
 	"github.com/MartinNevlaha/stratus-v2/guardian"
 	"github.com/MartinNevlaha/stratus-v2/insight"
 	"github.com/MartinNevlaha/stratus-v2/events"
@@ -21,7 +25,9 @@ import (
 	"github.com/MartinNevlaha/stratus-v2/internal/insight/onboarding"
 	"github.com/MartinNevlaha/stratus-v2/internal/insight/product_intelligence"
 	wiki_engine "github.com/MartinNevlaha/stratus-v2/internal/insight/wiki_engine"
+	"github.com/MartinNevlaha/stratus-v2/memory"
 	"github.com/MartinNevlaha/stratus-v2/orchestration"
+	"github.com/MartinNevlaha/stratus-v2/rerank"
 	"github.com/MartinNevlaha/stratus-v2/swarm"
 	"github.com/MartinNevlaha/stratus-v2/terminal"
 	"github.com/MartinNevlaha/stratus-v2/vexor"
@@ -29,10 +35,19 @@ import (
 
 const emitEventTimeout = 5 * time.Second
 
+// CodeSearcher is a semantic code search backend. *vexor.Client and the
+// native *codeindex.Indexer both implement it.
+type CodeSearcher interface {
+	Available() bool
+	Search(query string, topK int, mode string) ([]vexor.Result, error)
+	// Index reindexes paths; an empty slice reindexes the whole project.
+	Index(paths []string) error
+}
+
 type Server struct {
 	db                   *db.DB
 	coordinator          *orchestration.Coordinator
-	vexor                *vexor.Client
+	vexor                CodeSearcher // code search backend: vexor CLI or native index
 	hub                  *Hub
 	terminal             *terminal.Manager
 	projectRoot          string
@@ -68,13 +83,38 @@ type Server struct {
 	// to start a background code analysis run. Wire this up after the code
 	// analysis engine is initialised.
 	codeAnalysisTrigger CodeAnalysisTriggerFn
+
+	// dashboardToken authorizes human-only requests; see fromDashboard.
+	dashboardToken string
+
+	// memoryIndex, when set, embeds saved events and serves hybrid
+	// (keyword + semantic) memory search.
+	memoryIndex *embeddings.Indexer
+	// consolidator runs memory consolidation passes on demand.
+	consolidator *memory.Consolidator
+	// syncer shares memory with other instances and, when serving, acts as
+	// their hub.
+	syncer *memory.Syncer
+	// reranker, when set and retrieval.rerank.enabled, reorders the top
+	// fused retrieval hits.
+	reranker rerank.Reranker
+	// symbols, when set, serves the symbols/callers/callees corpora and is
+	// kept current from the dirty-file queue.
+	symbols *codeindex.SymbolIndex
+
+	// The /mcp endpoint is built once per server, so its hub and event bus
+	// listeners are too; mcpAPI holds the mux of the latest Handler call,
+	// which its tool calls are dispatched to.
+	mcpOnce    sync.Once
+	mcpHandler http.Handler
+	mcpAPI     atomic.Pointer[http.ServeMux]
 }
 
 // NewServer creates the HTTP server with all routes wired up.
 func NewServer(
 	database *db.DB,
 	coord *orchestration.Coordinator,
-	vexorClient *vexor.Client,
+	codeSearch CodeSearcher,
 	hub *Hub,
 	termMgr *terminal.Manager,
 	projectRoot string,
@@ -92,7 +132,7 @@ func NewServer(
 	s := &Server{
 		db:                   database,
 		coordinator:          coord,
-		vexor:                vexorClient,
+		vexor:                codeSearch,
 		hub:                  hub,
 		terminal:             termMgr,
 		projectRoot:          projectRoot,
@@ -164,6 +204,35 @@ func (s *Server) SetGuardianLLM(c insightllm.Client) {
 	s.guardianLLM = c
 }
 
+// SetMemoryIndex enables semantic memory search. Pass nil to fall back to
+// keyword-only search.
+func (s *Server) SetMemoryIndex(ix *embeddings.Indexer) {
+	s.memoryIndex = ix
+}
+
+// SetConsolidator attaches the memory consolidator so routes can report on
+// and trigger consolidation passes.
+func (s *Server) SetConsolidator(c *memory.Consolidator) {
+	s.consolidator = c
+}
+
+// SetReranker enables reranking of retrieval results. Pass nil to keep the
+// fused order.
+func (s *Server) SetReranker(r rerank.Reranker) {
+	s.reranker = r
+}
+
+// SetSymbolIndex enables symbol-aware retrieval.
+func (s *Server) SetSymbolIndex(si *codeindex.SymbolIndex) {
+	s.symbols = si
+}
+
+// SetSyncer attaches team sync so routes can report on and trigger sync
+// rounds and serve peers.
+func (s *Server) SetSyncer(sy *memory.Syncer) {
+	s.syncer = sy
+}
+
 // markDirty adds file paths to the dirty set and signals the index worker.
 func (s *Server) markDirty(paths []string) {
 	s.dirtyMu.Lock()
@@ -177,6 +246,30 @@ func (s *Server) markDirty(paths []string) {
 	}
 }
 
+// governanceOptions returns the configured governance sources, or the
+// built-in ones when the server runs without a config (tests).
+func (s *Server) governanceOptions() db.GovernanceOptions {
+	if s.cfg == nil {
+		return db.GovernanceOptions{}
+	}
+	return governance.Options(s.cfg.Governance)
+}
+
+// reindexGovernance updates the governance index for dirty files that are
+// governance docs, so a rule edited through a tool call is searchable
+// without waiting for the file watcher or a full reindex.
+func (s *Server) reindexGovernance(files []string) {
+	report, err := s.db.IndexGovernancePaths(s.projectRoot, s.governanceOptions(), files)
+	if err != nil {
+		log.Printf("governance reindex: %v", err)
+		return
+	}
+	if report.Indexed > 0 || report.Removed > 0 {
+		stats, _ := s.db.GovernanceStats()
+		s.hub.BroadcastJSON("governance_indexed", stats)
+	}
+}
+
 func (s *Server) emitEvent(eventType events.EventType, source string, payload map[string]any) {
 	if s.eventBus == nil {
 		return
@@ -213,7 +306,7 @@ func (s *Server) indexWorker() {
 			if backoff > maxBackoff {
 				backoff = maxBackoff
 			}
-			log.Printf("vexor reindex: backing off %v after %d consecutive errors", backoff, consecutiveErrors)
+			log.Printf("code reindex: backing off %v after %d consecutive errors", backoff, consecutiveErrors)
 			select {
 			case <-time.After(backoff):
 			case <-s.dirtyCh:
@@ -242,18 +335,27 @@ func (s *Server) indexWorker() {
 		s.dirtyFiles = make(map[string]struct{})
 		s.dirtyMu.Unlock()
 
-		if len(files) == 0 || !s.vexor.Available() {
+		if len(files) == 0 {
 			continue
 		}
+		s.reindexGovernance(files)
 		if len(files) > maxBatch {
 			files = nil
 		}
+		if s.symbols != nil {
+			if err := s.symbols.Index(files); err != nil {
+				log.Printf("symbol reindex: %v", err)
+			}
+		}
+		if !s.vexor.Available() {
+			continue
+		}
 		if err := s.vexor.Index(files); err != nil {
 			consecutiveErrors++
-			log.Printf("vexor reindex: %v (error %d/%d)", err, consecutiveErrors, 5)
+			log.Printf("code reindex: %v (error %d/%d)", err, consecutiveErrors, 5)
 		} else {
 			if consecutiveErrors > 0 {
-				log.Printf("vexor reindex: recovered after %d errors", consecutiveErrors)
+				log.Printf("code reindex: recovered after %d errors", consecutiveErrors)
 			}
 			consecutiveErrors = 0
 		}
@@ -274,6 +376,20 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("GET /api/events/search", s.handleSearchEvents)
 	mux.HandleFunc("GET /api/events/{id}/timeline", s.handleTimeline)
 	mux.HandleFunc("POST /api/events/batch", s.handleBatchEvents)
+	mux.HandleFunc("GET /api/events/embeddings/status", s.handleEmbeddingStatus)
+	mux.HandleFunc("POST /api/events/embeddings/backfill", s.handleEmbeddingBackfill)
+	mux.HandleFunc("GET /api/memory/consolidation/report", s.handleConsolidationReport)
+	mux.HandleFunc("POST /api/memory/consolidation/run", s.handleConsolidationRun)
+	mux.HandleFunc("GET /api/memory/export", s.handleMemoryExport)
+	mux.HandleFunc("POST /api/memory/import", s.handleMemoryImport)
+	mux.HandleFunc("GET /api/memory/redactions", s.handleMemoryRedactions)
+	mux.HandleFunc("POST /api/memory/scrub", s.handleMemoryScrub)
+
+	// Team sync
+	mux.HandleFunc("GET /api/sync/status", s.handleSyncStatus)
+	mux.HandleFunc("POST /api/sync/run", s.handleSyncRun)
+	mux.HandleFunc("GET /api/sync/changes", s.handleSyncChanges)
+	mux.HandleFunc("POST /api/sync/push", s.handleSyncPush)
 
 	// Sessions
 	mux.HandleFunc("POST /api/sessions", s.handleCreateSession)
@@ -284,15 +400,40 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("GET /api/retrieve/status", s.handleRetrieveStatus)
 	mux.HandleFunc("POST /api/retrieve/index", s.handleReIndex)
 	mux.HandleFunc("POST /api/retrieve/dirty", s.handleMarkDirty)
+	mux.HandleFunc("GET /api/retrieval/golden", s.handleListRetrievalGolden)
+	mux.HandleFunc("POST /api/retrieval/golden", s.handleSaveRetrievalGolden)
+	mux.HandleFunc("POST /api/retrieval/golden/seed", s.handleSeedRetrievalGolden)
+	mux.HandleFunc("DELETE /api/retrieval/golden/{id}", s.handleDeleteRetrievalGolden)
+	mux.HandleFunc("POST /api/retrieval/eval", s.handleRunRetrievalEval)
+	mux.HandleFunc("GET /api/retrieval/eval/runs", s.handleListRetrievalEvalRuns)
+	mux.HandleFunc("GET /api/retrieval/eval/runs/{id}", s.handleGetRetrievalEvalRun)
+
+	// Governance docs
+	mux.HandleFunc("GET /api/governance/docs", s.handleListGovernanceDocs)
+	mux.HandleFunc("GET /api/governance/doc", s.handleGetGovernanceDoc)
 
 	// Orchestration
 	mux.HandleFunc("GET /api/past", s.handleListPast)
 	mux.HandleFunc("POST /api/workflows/analyze", s.handleAnalyzeWorkflow)
 	mux.HandleFunc("GET /api/workflows", s.handleListWorkflows)
+	mux.HandleFunc("GET /api/workflow-templates", s.handleListWorkflowTemplates)
+	mux.HandleFunc("GET /api/workflow-definitions", s.handleListWorkflowDefinitions)
+	mux.HandleFunc("POST /api/workflow-definitions/reload", s.handleReloadWorkflowDefinitions)
 	mux.HandleFunc("POST /api/workflows", s.handleStartWorkflow)
 	mux.HandleFunc("GET /api/workflows/{id}", s.handleGetWorkflow)
 	mux.HandleFunc("PUT /api/workflows/{id}/phase", s.handleTransitionPhase)
+	mux.HandleFunc("GET /api/workflows/{id}/evidence", s.handleListWorkflowEvidence)
+	mux.HandleFunc("GET /api/workflows/{id}/history", s.handleWorkflowHistory)
+	mux.HandleFunc("GET /api/workflows/{id}/replay", s.handleWorkflowReplay)
+	mux.HandleFunc("POST /api/workflows/{id}/simulate", s.handleSimulateWorkflow)
+	mux.HandleFunc("POST /api/workflows/{id}/rewind", s.handleRewindWorkflow)
+	mux.HandleFunc("POST /api/workflows/{id}/approval", s.handleResolveApproval)
 	mux.HandleFunc("POST /api/workflows/{id}/delegate", s.handleRecordDelegation)
+	mux.HandleFunc("GET /api/workflows/{id}/costs", s.handleWorkflowCosts)
+	mux.HandleFunc("PUT /api/workflows/{id}/budget", s.handleSetWorkflowBudget)
+	mux.HandleFunc("POST /api/usage", s.handleReportUsage)
+	mux.HandleFunc("POST /api/workflow_logs", s.handleSaveWorkflowLog)
+	mux.HandleFunc("GET /api/workflow_logs", s.handleGetWorkflowLogs)
 	mux.HandleFunc("POST /api/workflows/{id}/tasks", s.handleSetTasks)
 	mux.HandleFunc("POST /api/workflows/{id}/tasks/{index}/start", s.handleStartTask)
 	mux.HandleFunc("POST /api/workflows/{id}/tasks/{index}/complete", s.handleCompleteTask)
@@ -371,6 +512,8 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("GET /api/insight/scorecards/agents/{name}", s.handleGetAgentScorecardByName)
 	mux.HandleFunc("GET /api/insight/scorecards/workflows", s.handleGetWorkflowScorecards)
 	mux.HandleFunc("GET /api/insight/scorecards/workflows/{type}", s.handleGetWorkflowScorecardByType)
+	mux.HandleFunc("GET /api/insight/scorecards/tools", s.handleGetToolScorecards)
+	mux.HandleFunc("GET /api/insight/scorecards/tools/{name}", s.handleGetToolScorecardByName)
 	mux.HandleFunc("POST /api/insight/scorecards/compute", s.handleTriggerScorecardComputation)
 	mux.HandleFunc("GET /api/insight/scorecards/highlights", s.handleGetScorecardHighlights)
 
@@ -508,12 +651,20 @@ func (s *Server) Handler() http.Handler {
 	// Terminal
 	mux.HandleFunc("POST /api/terminal/upload-image", s.handleTerminalUploadImage)
 
+	// MCP tool-call audit log and per-tool metrics
+	mux.HandleFunc("POST /api/mcp/calls", s.handleRecordMCPCall)
+	mux.HandleFunc("GET /api/mcp/calls", s.handleListMCPCalls)
+	mux.HandleFunc("GET /api/mcp/metrics", s.handleMCPMetrics)
+
+	// MCP Streamable HTTP transport (same tools as `stratus mcp-serve`)
+	mux.Handle("/mcp", s.mcpEndpoint(mux))
+
 	// WebSocket
 	mux.HandleFunc("/api/ws", s.hub.ServeWS)
 	mux.HandleFunc("/api/terminal/ws", s.terminal.ServeWS)
 
 	// Static files (embedded Svelte SPA) with SPA fallback to index.html
-	mux.Handle("/", s.spaHandler())
+	mux.Handle("/", s.dashboardLogin(s.spaHandler()))
 
 	return corsMiddleware(mux)
 }
@@ -559,7 +710,8 @@ func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Mcp-Session-Id, Mcp-Protocol-Version")
+		w.Header().Set("Access-Control-Expose-Headers", "Mcp-Session-Id")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
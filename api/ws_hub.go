@@ -19,8 +19,10 @@ type Message struct {
 
 // Hub manages WebSocket connections and broadcasts.
 type Hub struct {
-	mu      sync.RWMutex
-	clients map[*wsClient]struct{}
+	mu        sync.RWMutex
+	clients   map[*wsClient]struct{}
+	listeners map[int]func(Message)
+	nextID    int
 }
 
 type wsClient struct {
@@ -30,13 +32,35 @@ type wsClient struct {
 
 // NewHub creates a new WebSocket hub.
 func NewHub() *Hub {
-	return &Hub{clients: make(map[*wsClient]struct{})}
+	return &Hub{
+		clients:   make(map[*wsClient]struct{}),
+		listeners: make(map[int]func(Message)),
+	}
+}
+
+// Listen registers an in-process receiver for every broadcast message. fn is
+// called synchronously from Broadcast and must not block. The returned
+// function removes the listener.
+func (h *Hub) Listen(fn func(Message)) (cancel func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.nextID++
+	id := h.nextID
+	h.listeners[id] = fn
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.listeners, id)
+	}
 }
 
-// Broadcast sends a message to all connected clients.
+// Broadcast sends a message to all connected clients and listeners.
 func (h *Hub) Broadcast(msg Message) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
+	for _, fn := range h.listeners {
+		fn(msg)
+	}
 	for client := range h.clients {
 		select {
 		case client.send <- msg:
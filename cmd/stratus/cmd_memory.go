@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+const memoryUsage = `usage:
+  stratus memory export [-o file] [filters]
+  stratus memory import <file|-> [--dry-run] [filters]
+  stratus memory scrub [--dry-run]
+
+filters: --project NAME --scope repo|global|user --type TYPE
+         --since DATE --until DATE --include-archived (export only)`
+
+// cmdMemory implements `stratus memory export|import|scrub`. All talk to the
+// running Stratus API server so the live database is never opened twice.
+func cmdMemory() {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, memoryUsage)
+		os.Exit(2)
+	}
+	switch os.Args[2] {
+	case "export":
+		cmdMemoryExport(os.Args[3:])
+	case "import":
+		cmdMemoryImport(os.Args[3:])
+	case "scrub":
+		cmdMemoryScrub(os.Args[3:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown memory command: %s\n%s\n", os.Args[2], memoryUsage)
+		os.Exit(2)
+	}
+}
+
+// parseMemoryFlags reads the shared filter flags into query parameters and
+// returns the arguments it did not consume.
+func parseMemoryFlags(args []string, params neturl.Values) []string {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		flag := args[i]
+		switch flag {
+		case "--project", "--scope", "--type", "--since", "--until":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "%s requires a value\n", flag)
+				os.Exit(2)
+			}
+			i++
+			params.Set(flag[2:], args[i])
+		case "--include-archived":
+			params.Set("include_archived", "true")
+		case "--dry-run":
+			params.Set("dry_run", "true")
+		default:
+			rest = append(rest, flag)
+		}
+	}
+	return rest
+}
+
+func memoryAPIURL(path string, params neturl.Values) string {
+	cfg := config.Load()
+	port := cfg.Port
+	if port == 0 {
+		port = 41777
+	}
+	u := fmt.Sprintf("http://localhost:%d%s", port, path)
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	return u
+}
+
+func cmdMemoryExport(args []string) {
+	params := neturl.Values{}
+	rest := parseMemoryFlags(args, params)
+	output := ""
+	for i := 0; i < len(rest); i++ {
+		switch rest[i] {
+		case "-o", "--output":
+			if i+1 >= len(rest) {
+				fmt.Fprintln(os.Stderr, "-o requires a file name")
+				os.Exit(2)
+			}
+			i++
+			output = rest[i]
+		default:
+			fmt.Fprintf(os.Stderr, "unknown flag: %s\n%s\n", rest[i], memoryUsage)
+			os.Exit(2)
+		}
+	}
+
+	url := memoryAPIURL("/api/memory/export", params)
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Get(url)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "GET %s: %v\n(is `stratus serve` running?)\n", url, err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Fprintf(os.Stderr, "export failed: HTTP %d\n%s\n", resp.StatusCode, string(body))
+		os.Exit(1)
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "create %s: %v\n", output, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		os.Exit(1)
+	}
+	if output != "" {
+		fmt.Fprintf(os.Stderr, "wrote %d bytes to %s\n", n, output)
+	}
+}
+
+func cmdMemoryImport(args []string) {
+	params := neturl.Values{}
+	rest := parseMemoryFlags(args, params)
+	if len(rest) != 1 {
+		fmt.Fprintln(os.Stderr, memoryUsage)
+		os.Exit(2)
+	}
+
+	var in io.Reader = os.Stdin
+	if rest[0] != "-" {
+		f, err := os.Open(rest[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "open %s: %v\n", rest[0], err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	url := memoryAPIURL("/api/memory/import", params)
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Post(url, "application/x-ndjson", in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "POST %s: %v\n(is `stratus serve` running?)\n", url, err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "import failed: HTTP %d\n%s\n", resp.StatusCode, string(respBody))
+		os.Exit(1)
+	}
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, respBody, "", "  ") == nil {
+		fmt.Println(pretty.String())
+	} else {
+		fmt.Println(string(respBody))
+	}
+}
+
+// cmdMemoryScrub redacts secrets in events and workflow logs stored before
+// redaction on save existed, then rebuilds the memory search index.
+func cmdMemoryScrub(args []string) {
+	params := neturl.Values{}
+	for _, a := range args {
+		if a != "--dry-run" {
+			fmt.Fprintf(os.Stderr, "unknown flag: %s\n%s\n", a, memoryUsage)
+			os.Exit(2)
+		}
+		params.Set("dry_run", "true")
+	}
+
+	url := memoryAPIURL("/api/memory/scrub", params)
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Post(url, "application/json", nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "POST %s: %v\n(is `stratus serve` running?)\n", url, err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "scrub failed: HTTP %d\n%s\n", resp.StatusCode, string(respBody))
+		os.Exit(1)
+	}
+	var report db.ScrubReport
+	if err := json.Unmarshal(respBody, &report); err != nil {
+		fmt.Println(string(respBody))
+		return
+	}
+	verb := "scrubbed"
+	if report.DryRun {
+		verb = "would scrub"
+	}
+	fmt.Printf("%s %d of %d events (%d secrets)\n", verb, report.Events.Rows, report.Events.Scanned, report.Events.Redactions)
+	fmt.Printf("%s %d of %d workflow logs (%d secrets)\n", verb, report.WorkflowLogs.Rows, report.WorkflowLogs.Scanned, report.WorkflowLogs.Redactions)
+	kinds := make([]string, 0, len(report.Kinds))
+	for kind := range report.Kinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		fmt.Printf("  %-18s %d\n", kind, report.Kinds[kind])
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/hooks"
+	"github.com/MartinNevlaha/stratus-v2/orchestration"
+)
+
+const policyUsage = `usage:
+  stratus policy check [--policy file]
+  stratus policy test [--policy file] [--all] [events.jsonl]
+
+check validates the hook policy (.stratus/policy.json); run stratus refresh
+after adding or removing it so the policy_guard hook is installed. test
+evaluates recorded hook events against it: those policy_guard logged to
+policy-events.jsonl in the project's data directory while the policy sets
+"record": true, or a hand-written file in the same format. It lists the
+events the policy now decides differently (--all lists every event) and
+exits 1 when an event's "expect" outcome is not met.`
+
+// cmdPolicy implements `stratus policy check|test`.
+func cmdPolicy() {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, policyUsage)
+		os.Exit(2)
+	}
+	cfg := config.Load()
+	policyFile := filepath.Join(cfg.ProjectRoot, hooks.PolicyFile)
+	eventsFile := filepath.Join(cfg.ProjectDataDir(), hooks.PolicyEventLog)
+	all := false
+	args := os.Args[3:]
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--policy":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "--policy requires a value")
+				os.Exit(2)
+			}
+			i++
+			policyFile = args[i]
+		case args[i] == "--all":
+			all = true
+		case os.Args[2] == "test" && len(args[i]) > 0 && args[i][0] != '-':
+			eventsFile = args[i]
+		default:
+			fmt.Fprintf(os.Stderr, "unknown flag: %s\n%s\n", args[i], policyUsage)
+			os.Exit(2)
+		}
+	}
+
+	// Read-only phase conditions follow the project's workflow definitions.
+	if err := orchestration.LoadWorkflowDefinitions(cfg.ProjectRoot); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: workflow definitions: %v\n", err)
+	}
+	policy, err := hooks.ReadPolicy(policyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "policy: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch os.Args[2] {
+	case "check":
+		fmt.Printf("%s: %d rules\n", policyFile, len(policy.Rules))
+		for _, r := range policy.Rules {
+			fmt.Printf("  %-16s %s\n", r.Outcome, r.Name)
+		}
+	case "test":
+		cmdPolicyTest(policy, eventsFile, all)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown policy command: %s\n%s\n", os.Args[2], policyUsage)
+		os.Exit(2)
+	}
+}
+
+func cmdPolicyTest(policy *hooks.Policy, eventsFile string, all bool) {
+	f, err := os.Open(eventsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "events: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	results, err := hooks.ReplayPolicy(policy, f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "events: %v\n", err)
+		os.Exit(1)
+	}
+
+	outcome := func(o hooks.PolicyOutcome) string {
+		if o == "" {
+			return "-"
+		}
+		return string(o)
+	}
+	changed, failed := 0, 0
+	for _, r := range results {
+		if r.Changed {
+			changed++
+		}
+		if r.Failed {
+			failed++
+		}
+		if !all && !r.Changed && !r.Failed {
+			continue
+		}
+		var got hooks.PolicyOutcome
+		rule := ""
+		if r.Result != nil {
+			got, rule = r.Result.Outcome, r.Result.Rule
+		}
+		mark := " "
+		switch {
+		case r.Failed:
+			mark = "✗"
+		case r.Changed:
+			mark = "~"
+		}
+		line := fmt.Sprintf("%s %4d  %-16s %s", mark, r.Line, outcome(got), r.Summary)
+		if rule != "" {
+			line += "  [" + rule + "]"
+		}
+		switch {
+		case r.Failed:
+			line += fmt.Sprintf("  (expected %s)", r.Record.Expect)
+		case r.Changed:
+			line += fmt.Sprintf("  (was %s)", outcome(r.Record.Outcome))
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("%d events, %d decided differently, %d expectations failed\n", len(results), changed, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
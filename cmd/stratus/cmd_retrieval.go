@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/api"
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+	"github.com/MartinNevlaha/stratus-v2/governance"
+)
+
+const retrievalUsage = `usage:
+  stratus retrieval eval [--k N] [--offline] [--no-save] [--fail-on-regression]
+  stratus retrieval seed [--limit N]
+  stratus retrieval history [--limit N]
+
+eval scores the golden set (.stratus/retrieval-golden.json plus stored
+cases) and reports recall@k, MRR and nDCG@k per corpus. --offline runs
+in-process against the governance and wiki FTS indexes, without a server,
+code search or reranking.`
+
+// cmdRetrieval implements `stratus retrieval eval|seed|history`.
+func cmdRetrieval() {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, retrievalUsage)
+		os.Exit(2)
+	}
+	switch os.Args[2] {
+	case "eval":
+		cmdRetrievalEval(os.Args[3:])
+	case "seed":
+		limit := parseLimitFlag(os.Args[3:], 100)
+		body := retrievalAPI(http.MethodPost, "/api/retrieval/golden/seed", neturl.Values{"limit": {strconv.Itoa(limit)}}, nil)
+		var resp struct {
+			Added int `json:"added"`
+		}
+		_ = json.Unmarshal(body, &resp)
+		fmt.Printf("added %d golden cases from followed-up queries\n", resp.Added)
+	case "history":
+		limit := parseLimitFlag(os.Args[3:], 20)
+		body := retrievalAPI(http.MethodGet, "/api/retrieval/eval/runs", neturl.Values{"limit": {strconv.Itoa(limit)}}, nil)
+		var resp struct {
+			Runs []db.RetrievalEvalRun `json:"runs"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			fmt.Println(string(body))
+			return
+		}
+		for _, run := range resp.Runs {
+			all := run.Metrics["all"]
+			mode := "online"
+			if run.Offline {
+				mode = "offline"
+			}
+			fmt.Printf("#%-4d %s  k=%-3d %-7s cases=%-4d recall=%.3f mrr=%.3f ndcg=%.3f regressions=%d\n",
+				run.ID, run.CreatedAt, run.K, mode, run.Cases, all.Recall, all.MRR, all.NDCG, len(run.Regressions))
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown retrieval command: %s\n%s\n", os.Args[2], retrievalUsage)
+		os.Exit(2)
+	}
+}
+
+func cmdRetrievalEval(args []string) {
+	opts := api.RetrievalEvalOptions{K: 10, Save: true}
+	failOnRegression := false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--k":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "--k requires a value")
+				os.Exit(2)
+			}
+			i++
+			k, err := strconv.Atoi(args[i])
+			if err != nil || k <= 0 {
+				fmt.Fprintf(os.Stderr, "invalid --k: %s\n", args[i])
+				os.Exit(2)
+			}
+			opts.K = k
+		case "--offline":
+			opts.Offline = true
+		case "--no-save":
+			opts.Save = false
+		case "--fail-on-regression":
+			failOnRegression = true
+		default:
+			fmt.Fprintf(os.Stderr, "unknown flag: %s\n%s\n", args[i], retrievalUsage)
+			os.Exit(2)
+		}
+	}
+
+	var run *db.RetrievalEvalRun
+	if opts.Offline {
+		// Offline runs open the database directly, refreshing the
+		// governance index first so results reflect the working tree.
+		cfg := config.Load()
+		database := mustOpenDB(cfg)
+		defer database.Close()
+		if _, err := database.IndexGovernanceWith(cfg.ProjectRoot, governance.Options(cfg.Governance)); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: governance index: %v\n", err)
+		}
+		// Per-query search logging is server chatter; keep the report clean.
+		log.SetOutput(io.Discard)
+		var err error
+		run, err = api.RunRetrievalEvalOffline(database, &cfg, cfg.ProjectRoot, opts.K, opts.Save)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	} else {
+		payload, _ := json.Marshal(opts)
+		body := retrievalAPI(http.MethodPost, "/api/retrieval/eval", nil, payload)
+		run = &db.RetrievalEvalRun{}
+		if err := json.Unmarshal(body, run); err != nil {
+			fmt.Println(string(body))
+			return
+		}
+	}
+
+	printEvalRun(run)
+	if failOnRegression && len(run.Regressions) > 0 {
+		os.Exit(1)
+	}
+}
+
+func printEvalRun(run *db.RetrievalEvalRun) {
+	fmt.Printf("%d cases scored at k=%d, %d skipped\n\n", run.Cases, run.K, run.Skipped)
+	keys := make([]string, 0, len(run.Metrics))
+	for key := range run.Metrics {
+		if key != "all" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	keys = append(keys, "all")
+	fmt.Printf("  %-12s %6s %9s %7s %8s\n", "corpus", "cases", "recall@k", "mrr", "ndcg@k")
+	for _, key := range keys {
+		m, ok := run.Metrics[key]
+		if !ok {
+			continue
+		}
+		fmt.Printf("  %-12s %6d %9.3f %7.3f %8.3f\n", key, m.Cases, m.Recall, m.MRR, m.NDCG)
+	}
+	for _, r := range run.Results {
+		switch {
+		case r.Skipped != "":
+			fmt.Printf("\n  skipped %q (%s): %s", r.Query, r.Corpus, r.Skipped)
+		case r.Recall < 1:
+			fmt.Printf("\n  miss    %q: recall %.2f, expected %v, got %v", r.Query, r.Recall, r.Expected, r.Hits)
+		}
+	}
+	fmt.Println()
+	if len(run.Regressions) > 0 {
+		fmt.Println("\nregressions since the previous run:")
+		for _, r := range run.Regressions {
+			fmt.Println("  " + r)
+		}
+	}
+}
+
+func parseLimitFlag(args []string, def int) int {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--limit" && i+1 < len(args) {
+			if n, err := strconv.Atoi(args[i+1]); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return def
+}
+
+// retrievalAPI calls the running server and returns the response body,
+// exiting on failure.
+func retrievalAPI(method, path string, params neturl.Values, payload []byte) []byte {
+	url := memoryAPIURL(path, params)
+	req, err := http.NewRequest(method, url, bytes.NewReader(payload))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n(is `stratus serve` running? use --offline to evaluate without it)\n", method, url, err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "%s %s failed: HTTP %d\n%s\n", method, path, resp.StatusCode, string(body))
+		os.Exit(1)
+	}
+	return body
+}
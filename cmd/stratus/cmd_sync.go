@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+const syncUsage = `usage:
+  stratus sync status   show instance ID, journal counts and the last round
+  stratus sync run      publish, pull and push now`
+
+// cmdSync implements `stratus sync status|run` against the running server.
+func cmdSync() {
+	sub := "status"
+	if len(os.Args) >= 3 {
+		sub = os.Args[2]
+	}
+	var req *http.Request
+	var err error
+	switch sub {
+	case "status":
+		req, err = http.NewRequest(http.MethodGet, memoryAPIURL("/api/sync/status", nil), nil)
+	case "run":
+		req, err = http.NewRequest(http.MethodPost, memoryAPIURL("/api/sync/run", nil), nil)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown sync command: %s\n%s\n", sub, syncUsage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n(is `stratus serve` running?)\n", req.Method, req.URL, err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "sync %s failed: HTTP %d\n%s\n", sub, resp.StatusCode, string(body))
+		os.Exit(1)
+	}
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, body, "", "  ") == nil {
+		fmt.Println(pretty.String())
+	} else {
+		fmt.Println(string(body))
+	}
+}
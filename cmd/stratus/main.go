@@ -22,9 +22,12 @@ import (
 	"time"
 
 	"github.com/MartinNevlaha/stratus-v2/api"
+	"github.com/MartinNevlaha/stratus-v2/codeindex"
 	"github.com/MartinNevlaha/stratus-v2/config"
 	"github.com/MartinNevlaha/stratus-v2/db"
+	"github.com/MartinNevlaha/stratus-v2/embeddings"
 	"github.com/MartinNevlaha/stratus-v2/events"
+	"github.com/MartinNevlaha/stratus-v2/governance"
 	"github.com/MartinNevlaha/stratus-v2/guardian"
 	"github.com/MartinNevlaha/stratus-v2/hooks"
 	"github.com/MartinNevlaha/stratus-v2/insight"
@@ -34,7 +37,9 @@ import (
 	"github.com/MartinNevlaha/stratus-v2/internal/insight/prompts"
 	wiki_engine "github.com/MartinNevlaha/stratus-v2/internal/insight/wiki_engine"
 	"github.com/MartinNevlaha/stratus-v2/mcp"
+	"github.com/MartinNevlaha/stratus-v2/memory"
 	"github.com/MartinNevlaha/stratus-v2/orchestration"
+	"github.com/MartinNevlaha/stratus-v2/rerank"
 	"github.com/MartinNevlaha/stratus-v2/swarm"
 	"github.com/MartinNevlaha/stratus-v2/terminal"
 	"github.com/MartinNevlaha/stratus-v2/vexor"
@@ -101,6 +106,14 @@ func main() {
 		cmdOnboard()
 	case "ingest":
 		cmdIngest()
+	case "memory":
+		cmdMemory()
+	case "sync":
+		cmdSync()
+	case "retrieval":
+		cmdRetrieval()
+	case "policy":
+		cmdPolicy()
 	default:
 		fmt.Fprintf(os.Stderr, "unknown command: %s\n", os.Args[1])
 		printUsage()
@@ -125,7 +138,17 @@ Commands:
   port        Print the configured API port (reads .stratus.json / STRATUS_PORT env)
   onboard     Auto-generate project documentation wiki pages
   ingest      Ingest a PDF/URL/YouTube/markdown/text source into the wiki
-              Flags: --tags a,b,c --title "..." --no-synth --skip-links`)
+              Flags: --tags a,b,c --title "..." --no-synth --skip-links
+  memory      Export, import or scrub memory events
+              export [-o file] | import <file|-> [--dry-run] | scrub [--dry-run]
+              Filters: --project --scope --type --since --until
+  sync        Show team sync status or run a sync round now
+              status | run
+  retrieval   Evaluate retrieval quality against the golden set
+              eval [--k N] [--offline] [--no-save] [--fail-on-regression]
+              | seed [--limit N] | history [--limit N]
+  policy      Check the hook policy or test it against recorded hook events
+              test [--policy file] [--all] [events.jsonl] | check [--policy file]`)
 }
 
 // llmAutodocEnricher calls an LLM to rewrite the base autodoc markdown into a
@@ -217,21 +240,45 @@ func cmdServe() {
 	database := mustOpenDB(cfg)
 	defer database.Close()
 
+	orchestration.SetBuiltinGoChecks(cfg.Workflows.GoChecks)
+	if err := orchestration.LoadWorkflowDefinitions(cfg.ProjectRoot); err != nil {
+		log.Printf("workflow definitions: %v", err)
+	}
+
 	// Index governance docs on startup (best-effort)
 	go func() {
-		if err := database.IndexGovernance(cfg.ProjectRoot); err != nil {
+		if _, err := database.IndexGovernanceWith(cfg.ProjectRoot, governance.Options(cfg.Governance)); err != nil {
 			log.Printf("governance index warning: %v", err)
 		}
 	}()
 
 	coord := orchestration.NewCoordinator(database)
 	coord.SetWikiStore(database)
+	coord.SetProjectRoot(cfg.ProjectRoot)
 	// The event bus is always created: Guardian uses it regardless of the
 	// Insight toggle, and no-op cost is negligible when nobody subscribes.
 	eventBus := events.NewInMemoryBus(1000)
 	coord.SetEventBus(eventBus)
-	vexorClient := vexor.New(cfg.Vexor.BinaryPath, cfg.Vexor.Model, cfg.Vexor.TimeoutSec)
+	var codeSearch api.CodeSearcher = vexor.New(cfg.Vexor.BinaryPath, cfg.Vexor.Model, cfg.Vexor.TimeoutSec)
+	// Native code index: chunk and embed source files in-process and
+	// reindex only changed files. Falls back to the vexor CLI when the
+	// embedding provider cannot be set up.
+	if cfg.CodeIndex.Backend == "native" {
+		embCfg := cfg.Embeddings
+		if cfg.CodeIndex.Model != "" {
+			embCfg.Model = cfg.CodeIndex.Model
+		}
+		if embedder, err := embeddings.New(embCfg); err == nil {
+			ix := codeindex.New(database, embedder, cfg.ProjectRoot, embCfg.BatchSize, cfg.CodeIndex)
+			codeSearch = ix
+			go ix.Run(context.Background())
+			log.Printf("code index: native backend (provider=%s, model=%s)", embCfg.Provider, embCfg.Model)
+		} else {
+			log.Printf("code index: native backend unavailable, using vexor: %v", err)
+		}
+	}
 	hub := api.NewHub()
+	coord.SetBroadcaster(hub)
 	termMgr := terminal.NewManager()
 
 	// Strip the "static/" prefix so the FS root is the build output directory.
@@ -252,6 +299,8 @@ func cmdServe() {
 	cfg.Insight.LLM = config.ResolveLLMConfig(cfg.LLM, cfg.Insight.LLM)
 	cfg.Guardian.LLM = config.ResolveLLMConfig(cfg.LLM, cfg.Guardian.LLM)
 	cfg.CodeAnalysis.LLM = config.ResolveLLMConfig(cfg.LLM, cfg.CodeAnalysis.LLM)
+	cfg.Memory.Consolidation.LLM = config.ResolveLLMConfig(cfg.LLM, cfg.Memory.Consolidation.LLM)
+	cfg.Retrieval.Rerank.LLM = config.ResolveLLMConfig(cfg.LLM, cfg.Retrieval.Rerank.LLM)
 	cfg.STT.Model = normalizeSTTModel(cfg.STT.Model)
 
 	// Initialize Insight engine
@@ -276,13 +325,17 @@ func cmdServe() {
 	opencodeAgentsDir := filepath.Join(cfg.ProjectRoot, ".opencode", "agents")
 	agentEvolutionEngine := agent_evolution.NewEngine(database, agent_evolution.DefaultConfig(), claudeAgentsDir, opencodeAgentsDir, logger)
 
-	srv := api.NewServer(database, coord, vexorClient, hub, termMgr, cfg.ProjectRoot, cfg.STT.Endpoint, cfg.STT.Model, staticFS, Version, syncedVersion, skippedFiles, swarmStore, insightEngine, agentEvolutionEngine, &cfg)
+	srv := api.NewServer(database, coord, codeSearch, hub, termMgr, cfg.ProjectRoot, cfg.STT.Endpoint, cfg.STT.Model, staticFS, Version, syncedVersion, skippedFiles, swarmStore, insightEngine, agentEvolutionEngine, &cfg)
 	if eventBus != nil {
 		srv.SetEventBus(eventBus)
 	}
 	if insightEngine != nil {
 		srv.SetProductIntelligenceEngine(insightEngine.ProductIntelligence())
 	}
+	// Approvals and rewinds need this token, which only reaches the browser
+	// through the link logged below.
+	dashboardToken := api.NewDashboardToken()
+	srv.SetDashboardToken(dashboardToken)
 
 	// Wire code analysis trigger so the API can start a background run.
 	if insightEngine != nil {
@@ -364,6 +417,126 @@ func cmdServe() {
 	}
 
 	go g.Run(guardianCtx)
+	// Workflow time budgets escalate on their own, independent of Guardian.
+	go coord.RunSLAMonitor(guardianCtx)
+
+	// Symbol index: definitions, references and imports for the symbols,
+	// callers and callees corpora. The dirty-file queue keeps it current.
+	if cfg.CodeIndex.Symbols {
+		symbolIndex := codeindex.NewSymbolIndex(database, cfg.ProjectRoot, cfg.CodeIndex)
+		srv.SetSymbolIndex(symbolIndex)
+		go symbolIndex.Run(guardianCtx)
+	}
+
+	// Governance watcher: edits to rules, ADRs and other governance files
+	// are searchable as soon as they hit the disk.
+	if cfg.Governance.Watch {
+		gw := governance.NewWatcher(database, cfg.ProjectRoot, cfg.Governance)
+		gw.OnIndexed = func(db.GovernanceReport) {
+			stats, _ := database.GovernanceStats()
+			hub.BroadcastJSON("governance_indexed", stats)
+		}
+		go func() {
+			if err := gw.Run(guardianCtx); err != nil && guardianCtx.Err() == nil {
+				log.Printf("governance watcher: %v", err)
+			}
+		}()
+	}
+
+	// Retrieval reranking: an LLM or a cross-encoder rescoring the top fused
+	// hits. Fail-open to the fused order.
+	if rc := cfg.Retrieval.Rerank; rc.Enabled {
+		switch rc.Provider {
+		case "http":
+			if rc.Endpoint == "" {
+				log.Printf("retrieval: rerank provider http needs an endpoint, reranking disabled")
+				break
+			}
+			srv.SetReranker(rerank.NewHTTP(rc.Endpoint, rc.Model, rc.APIKey, time.Duration(rc.TimeoutSec)*time.Second))
+			log.Printf("retrieval: reranking with cross-encoder at %s", rc.Endpoint)
+		default:
+			rl := rc.LLM
+			rerankCfg := llm.Config{
+				Provider:             rl.Provider,
+				Model:                rl.Model,
+				APIKey:               rl.APIKey,
+				BaseURL:              rl.BaseURL,
+				Timeout:              rl.Timeout,
+				MaxTokens:            rl.MaxTokens,
+				Temperature:          rl.Temperature,
+				MaxRetries:           rl.MaxRetries,
+				Concurrency:          rl.Concurrency,
+				MinRequestIntervalMs: rl.MinRequestIntervalMs,
+			}.WithEnv()
+			if client, err := llm.NewClient(rerankCfg); err == nil {
+				srv.SetReranker(rerank.NewLLM(client))
+				log.Printf("retrieval: reranking with LLM (provider=%s, model=%s)", rerankCfg.Provider, rerankCfg.Model)
+			} else {
+				log.Printf("retrieval: rerank LLM unavailable, using fused order: %v", err)
+			}
+		}
+	}
+
+	// Memory consolidation: the dry-run report is always available; the
+	// scheduled pass only runs when memory.consolidation.enabled is set.
+	consolidator := memory.NewConsolidator(database, func() config.MemoryConsolidationConfig {
+		return config.Load().Memory.Consolidation
+	})
+	if mc := cfg.Memory.Consolidation.LLM; mc.Provider != "" && mc.Model != "" {
+		consolidationCfg := llm.Config{
+			Provider:             mc.Provider,
+			Model:                mc.Model,
+			APIKey:               mc.APIKey,
+			BaseURL:              mc.BaseURL,
+			Timeout:              mc.Timeout,
+			MaxTokens:            mc.MaxTokens,
+			Temperature:          mc.Temperature,
+			MaxRetries:           mc.MaxRetries,
+			Concurrency:          mc.Concurrency,
+			MinRequestIntervalMs: mc.MinRequestIntervalMs,
+		}.WithEnv()
+		if client, err := llm.NewClient(consolidationCfg); err == nil {
+			consolidator.SetLLMClient(client)
+		} else {
+			log.Printf("memory: consolidation LLM unavailable, clusters will only be reported: %v", err)
+		}
+	}
+	srv.SetConsolidator(consolidator)
+
+	// Semantic memory search: embed new events on save and backfill existing
+	// ones in the background. Fail-open to keyword search.
+	if cfg.Embeddings.Enabled {
+		if embedder, err := embeddings.New(cfg.Embeddings); err == nil {
+			ix := embeddings.NewIndexer(database, embedder, cfg.Embeddings.BatchSize)
+			srv.SetMemoryIndex(ix)
+			consolidator.SetEmbeddingModel(ix.Model())
+			go ix.Run(guardianCtx, time.Duration(cfg.Embeddings.BackfillIntervalMinutes)*time.Minute)
+			log.Printf("memory: semantic search enabled (provider=%s, model=%s)", cfg.Embeddings.Provider, cfg.Embeddings.Model)
+		} else {
+			log.Printf("memory: embeddings unavailable, using keyword search: %v", err)
+		}
+	}
+	go consolidator.Run(guardianCtx)
+
+	// Team sync: share global/repo memory, governance docs and approved
+	// proposals with a hub instance or through a shared directory.
+	if cfg.TeamSync.Enabled {
+		syncer, err := memory.NewSyncer(database, func() config.TeamSyncConfig {
+			return config.Load().TeamSync
+		})
+		if err != nil {
+			log.Printf("sync: unavailable: %v", err)
+		} else {
+			if t, err := memory.NewTransport(cfg.TeamSync, syncer.InstanceID()); err != nil {
+				log.Printf("sync: %v; serving and publishing only", err)
+			} else if t != nil {
+				syncer.SetTransport(t)
+			}
+			srv.SetSyncer(syncer)
+			go syncer.Run(guardianCtx)
+			log.Printf("sync: team sync enabled (instance=%s, mode=%q, serve=%v)", syncer.InstanceID(), cfg.TeamSync.Mode, cfg.TeamSync.Serve)
+		}
+	}
 
 	// Periodic vault pull: pull external .md edits from the Obsidian vault back
 	// into the DB. Fail-open; intervals < 1 or wiki disabled skip the loop.
@@ -439,6 +612,7 @@ func cmdServe() {
 	if cfg.DevMode {
 		log.Printf("stratus running in DEV mode — open http://localhost:5173 for frontend")
 	}
+	log.Printf("stratus dashboard: http://localhost:%d/?token=%s (open this link to resolve approvals and rewind workflows)", cfg.Port, dashboardToken)
 	if err := srv.Serve(ln); err != nil {
 		log.Fatalf("server error: %v", err)
 	}
@@ -586,10 +760,22 @@ func cmdPort() {
 func cmdMCPServe() {
 	cfg := config.Load()
 	apiBase := fmt.Sprintf("http://localhost:%d", cfg.Port)
+	// Custom workflow types extend the register_workflow enum and phase list.
+	if err := orchestration.LoadWorkflowDefinitions(cfg.ProjectRoot); err != nil {
+		log.Printf("workflow definitions: %v", err)
+	}
 
 	httpClient := &http.Client{Timeout: 10 * time.Second}
 	srv := mcp.New()
 	mcp.RegisterTools(srv, apiBase, httpClient)
+	mcp.RegisterResources(srv, apiBase, httpClient)
+	mcp.RegisterPrompts(srv, apiBase, httpClient)
+	mcp.RecordCalls(srv, apiBase, httpClient)
+
+	// Push resource updates from the running server to subscribed clients.
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go mcp.WatchHub(watchCtx, srv, apiBase)
 
 	if err := srv.Serve(); err != nil {
 		log.Fatalf("mcp serve error: %v", err)
@@ -602,6 +788,13 @@ func cmdHook() {
 		os.Exit(1)
 	}
 	hookName := os.Args[2]
+	// Phase agent lists and read-only phases come from the workflow
+	// definitions; invalid project files fall back to the built-ins.
+	cfg := config.Load()
+	_ = orchestration.LoadWorkflowDefinitions(cfg.ProjectRoot)
+	// An invalid policy file disables policy_guard; `stratus policy check`
+	// reports why.
+	_ = hooks.LoadPolicy(cfg.ProjectRoot, cfg.ProjectDataDir())
 	handlers := map[string]hooks.Handler{
 		"phase_guard":              hooks.PhaseGuard,
 		"workflow_existence_guard": hooks.WorkflowExistenceGuard,
@@ -609,6 +802,9 @@ func cmdHook() {
 		"workflow_enforcer":        hooks.WorkflowEnforcer,
 		"bash_write_guard":         hooks.BashWriteGuard,
 		"watcher":                  hooks.Watcher,
+		"streamer":                 hooks.Streamer,
+		"policy_guard":             hooks.PolicyGuard,
+		"agent_usage":              hooks.AgentUsage,
 		"teammate_idle":            hooks.TeammateIdle,
 		"task_completed":           hooks.TaskCompleted,
 	}
@@ -863,6 +1059,8 @@ Prompts written to .opencode/prompts/:
   PreToolUse  workflow_existence_guard — requires session-scoped active workflow for Task delegation
   PreToolUse  delegation_guard         — applies delivery-agent delegation policy and phase-agent matching
   PreToolUse  bash_write_guard         — blocks file-modifying bash commands for delivery agents without workflow
+  PreToolUse  streamer                 — logs tool calls for the workflow replay timeline
+  PreToolUse  policy_guard             — applies the rules in .stratus/policy.json (installed only when it exists)
   PostToolUse watcher                  — queues modified files for vexor reindexing
 
 Statusline registered in .claude/settings.json — workflow status visible in Claude Code status bar`
@@ -1424,12 +1622,15 @@ func writeHooks(projectRoot string) error {
 				{"Agent|Task", "stratus hook workflow_existence_guard"},
 				{"Agent|Task", "stratus hook delegation_guard"},
 				{"Bash", "stratus hook bash_write_guard"},
+				// Feeds the tool calls of the workflow replay timeline.
+				{"Bash|Write|Edit|MultiEdit|NotebookEdit|Read|Glob|Grep|Agent|Task|WebFetch|WebSearch", "stratus hook streamer"},
 			},
 		},
 		{
 			event: "PostToolUse",
 			hooks: []hookDef{
 				{"Write|Edit|MultiEdit|NotebookEdit", "stratus hook watcher"},
+				{"Agent|Task", "stratus hook agent_usage"},
 			},
 		},
 		{
@@ -1446,12 +1647,23 @@ func writeHooks(projectRoot string) error {
 		},
 	}
 
+	// policy_guard runs on every tool call, so it is only installed while
+	// the project has a policy; refresh after adding or removing one.
+	hasPolicy := false
+	if _, err := os.Stat(filepath.Join(projectRoot, hooks.PolicyFile)); err == nil {
+		hasPolicy = true
+		defs[0].hooks = append(defs[0].hooks, hookDef{"", "stratus hook policy_guard"})
+	}
+
 	for _, d := range defs {
 		groups, _ := hooksSection[d.event].([]any)
 		if d.event == "PreToolUse" {
 			groups = removeStratusHook(groups, "stratus hook executor_routing_guard")
 			groups = removeStratusHook(groups, "stratus hook workflow_existence_guard")
 			groups = removeStratusHook(groups, "stratus hook delegation_guard")
+			if !hasPolicy {
+				groups = removeStratusHook(groups, "stratus hook policy_guard")
+			}
 		}
 		for _, h := range d.hooks {
 			if hasStratusHook(groups, h.command) {
@@ -1575,7 +1787,7 @@ func governanceIndex(projectRoot string) {
 	cfg := config.Load()
 	database := mustOpenDB(cfg)
 	defer database.Close()
-	if err := database.IndexGovernance(projectRoot); err != nil {
+	if _, err := database.IndexGovernanceWith(projectRoot, governance.Options(cfg.Governance)); err != nil {
 		fmt.Printf("warning: governance index failed: %v\n", err)
 		return
 	}
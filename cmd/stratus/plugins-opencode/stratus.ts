@@ -105,6 +105,21 @@ async function fetchWorkflowByID(id: string): Promise<Workflow | null> {
   return await res.json()
 }
 
+// workflowBudgetBlock returns why a workflow over its cost budget may not
+// delegate further, or null. Fails open: an unreadable ledger never blocks.
+async function workflowBudgetBlock(id: string): Promise<string | null> {
+  try {
+    const res = await fetch(`${BASE}/api/workflows/${encodeURIComponent(id)}/costs?limit=0`)
+    if (!res.ok) return null
+    const { summary } = await res.json()
+    if (!summary?.over_budget) return null
+    return `Workflow "${id}" has spent $${summary.total.cost_usd.toFixed(2)} of its $${summary.budget_usd.toFixed(2)} budget. ` +
+      `Ask the user to raise the budget from the dashboard before delegating further.`
+  } catch {
+    return null
+  }
+}
+
 function getTaskText(args: Record<string, unknown>): string {
   return [args["prompt"], args["command"], args["description"]]
     .filter((v): v is string => typeof v === "string")
@@ -331,6 +346,11 @@ export const Stratus: Plugin = async () => {
                   `Agent "${subagentType}" is not allowed in phase "${phase}" (workflow type: ${wtype}). Allowed agents: ${allowed.join(", ")}`,
                 )
               }
+
+              const budgetReason = await workflowBudgetBlock(wf.id)
+              if (budgetReason) {
+                throw new Error(budgetReason)
+              }
             }
           }
 
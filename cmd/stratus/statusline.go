@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -27,6 +28,7 @@ const (
 
 // slInput is the JSON Claude Code sends on stdin to the statusline command.
 type slInput struct {
+	SessionID string `json:"session_id"`
 	Workspace struct {
 		CurrentDir string `json:"current_dir"`
 	} `json:"workspace"`
@@ -79,7 +81,9 @@ func cmdStatusline() {
 	_ = json.NewDecoder(os.Stdin).Decode(&in)
 
 	cfg := config.Load()
-	state := fetchStratusState(fmt.Sprintf("http://127.0.0.1:%d", cfg.Port))
+	base := fmt.Sprintf("http://127.0.0.1:%d", cfg.Port)
+	reportSessionCost(base, in)
+	state := fetchStratusState(base)
 
 	fmt.Print(formatStatusline(in, state))
 }
@@ -100,6 +104,26 @@ func fetchStratusState(base string) *slDashboard {
 	return &s
 }
 
+// reportSessionCost sends the session's running cost to the server, which
+// charges its growth to the workflow the session owns. Best-effort: the
+// statusline must render even when the server is down.
+func reportSessionCost(base string, in slInput) {
+	if in.SessionID == "" || in.Cost.TotalCostUSD <= 0 {
+		return
+	}
+	body, _ := json.Marshal(map[string]any{
+		"session_id": in.SessionID,
+		"model":      in.Model.ID,
+		"cost_usd":   in.Cost.TotalCostUSD,
+		"cumulative": true,
+	})
+	client := &http.Client{Timeout: 300 * time.Millisecond}
+	resp, err := client.Post(base+"/api/usage", "application/json", bytes.NewReader(body))
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
 // formatStatusline assembles the full status line from all segments.
 func formatStatusline(in slInput, state *slDashboard) string {
 	cwd := statusCWD(in)
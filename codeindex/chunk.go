@@ -0,0 +1,321 @@
+package codeindex
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Chunk is a contiguous, 1-based inclusive line range of a file with the
+// declaration it holds, if any.
+type Chunk struct {
+	Kind      string
+	Name      string
+	LineStart int
+	LineEnd   int
+	Content   string
+}
+
+const (
+	// maxChunkLines splits declarations longer than this into windows so a
+	// single chunk stays within what small embedding models read.
+	maxChunkLines = 120
+	// windowLines is the window size for files without a language-aware
+	// chunker.
+	windowLines = 60
+)
+
+// languages maps file extensions to the chunker used for them. Extensions
+// missing here are not indexed.
+var languages = map[string]func(lines []string, src []byte) []Chunk{
+	".go":     chunkGo,
+	".ts":     chunkTS,
+	".tsx":    chunkTS,
+	".js":     chunkTS,
+	".jsx":    chunkTS,
+	".mjs":    chunkTS,
+	".cjs":    chunkTS,
+	".svelte": chunkTS,
+	".vue":    chunkTS,
+	".py":     chunkPython,
+	".md":     chunkMarkdown,
+	".rs":     chunkWindows,
+	".java":   chunkWindows,
+	".kt":     chunkWindows,
+	".rb":     chunkWindows,
+	".php":    chunkWindows,
+	".c":      chunkWindows,
+	".h":      chunkWindows,
+	".cpp":    chunkWindows,
+	".cs":     chunkWindows,
+	".swift":  chunkWindows,
+	".sh":     chunkWindows,
+	".sql":    chunkWindows,
+	".yaml":   chunkWindows,
+	".yml":    chunkWindows,
+	".toml":   chunkWindows,
+}
+
+// Supported reports whether files like path are indexed.
+func Supported(path string) bool {
+	_, ok := languages[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// ChunkFile splits src into chunks using the chunker for path's language:
+// top-level functions, methods and types for Go, TypeScript/JavaScript and
+// Python, sections for Markdown and fixed windows otherwise.
+func ChunkFile(path string, src []byte) []Chunk {
+	chunker, ok := languages[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil
+	}
+	text := strings.ReplaceAll(string(src), "\r\n", "\n")
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	var out []Chunk
+	for _, c := range chunker(lines, src) {
+		out = append(out, split(lines, c)...)
+	}
+	return out
+}
+
+// span builds a chunk over lines[start-1:end], trimming blank edges. It
+// returns false when nothing but whitespace is left.
+func span(lines []string, kind, name string, start, end int) (Chunk, bool) {
+	if end > len(lines) {
+		end = len(lines)
+	}
+	for start <= end && strings.TrimSpace(lines[start-1]) == "" {
+		start++
+	}
+	for end >= start && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	if start > end {
+		return Chunk{}, false
+	}
+	return Chunk{
+		Kind:      kind,
+		Name:      name,
+		LineStart: start,
+		LineEnd:   end,
+		Content:   strings.Join(lines[start-1:end], "\n"),
+	}, true
+}
+
+// split breaks chunks longer than maxChunkLines into consecutive parts that
+// keep the declaration's kind and name.
+func split(lines []string, c Chunk) []Chunk {
+	if c.LineEnd-c.LineStart+1 <= maxChunkLines {
+		return []Chunk{c}
+	}
+	var parts []Chunk
+	for start := c.LineStart; start <= c.LineEnd; start += maxChunkLines {
+		end := min(start+maxChunkLines-1, c.LineEnd)
+		if part, ok := span(lines, c.Kind, c.Name, start, end); ok {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// boundary marks the 1-based line where a declaration starts.
+type boundary struct {
+	line       int
+	kind, name string
+}
+
+// fromBoundaries turns sorted declaration starts into chunks that each run
+// up to the next declaration. Text before the first one becomes a "module"
+// chunk.
+func fromBoundaries(lines []string, bs []boundary) []Chunk {
+	if len(bs) == 0 {
+		return chunkWindows(lines, nil)
+	}
+	var out []Chunk
+	if bs[0].line > 1 {
+		if c, ok := span(lines, "module", "", 1, bs[0].line-1); ok {
+			out = append(out, c)
+		}
+	}
+	for i, b := range bs {
+		end := len(lines)
+		if i+1 < len(bs) {
+			end = bs[i+1].line - 1
+		}
+		if c, ok := span(lines, b.kind, b.name, b.line, end); ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func chunkWindows(lines []string, _ []byte) []Chunk {
+	var out []Chunk
+	for start := 1; start <= len(lines); start += windowLines {
+		if c, ok := span(lines, "text", "", start, start+windowLines-1); ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// chunkGo emits one chunk per top-level declaration, doc comment included.
+// Files that do not parse fall back to windows.
+func chunkGo(lines []string, src []byte) []Chunk {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return chunkWindows(lines, src)
+	}
+	var out []Chunk
+	if c, ok := span(lines, "module", "package "+f.Name.Name, 1, fset.Position(f.Name.End()).Line); ok {
+		out = append(out, c)
+	}
+	for _, decl := range f.Decls {
+		start, end := decl.Pos(), decl.End()
+		var kind, name string
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+			kind, name = "function", d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				kind, name = "method", receiverName(d.Recv.List[0].Type)+"."+d.Name.Name
+			}
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+			kind, name = d.Tok.String(), genDeclName(d)
+		default:
+			continue
+		}
+		if c, ok := span(lines, kind, name, fset.Position(start).Line, fset.Position(end).Line); ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+func genDeclName(d *ast.GenDecl) string {
+	var names []string
+	for _, spec := range d.Specs {
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			names = append(names, s.Name.Name)
+		case *ast.ValueSpec:
+			for _, n := range s.Names {
+				names = append(names, n.Name)
+			}
+		}
+	}
+	if len(names) > 3 {
+		names = append(names[:3], "…")
+	}
+	return strings.Join(names, ", ")
+}
+
+var (
+	tsDecl = regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?` +
+		`(function\*?|class|interface|type|enum|const|let|var)\s+([A-Za-z_$][\w$]*)`)
+	pyDecl = regexp.MustCompile(`^(?:async\s+)?(def|class)\s+([A-Za-z_]\w*)`)
+)
+
+// chunkTS splits TypeScript and JavaScript at top-level declarations, which
+// start in column 0 in formatted code. Svelte and Vue components are treated
+// the same way, so script-level functions become chunks.
+func chunkTS(lines []string, _ []byte) []Chunk {
+	var bs []boundary
+	for i, line := range lines {
+		m := tsDecl.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		kind := strings.TrimSuffix(m[1], "*")
+		switch kind {
+		case "const", "let", "var":
+			kind = "variable"
+		}
+		bs = append(bs, boundary{line: leadingComment(lines, i, "//", "/*", "*") + 1, kind: kind, name: m[2]})
+	}
+	return fromBoundaries(lines, bs)
+}
+
+// chunkPython splits at top-level def and class statements, keeping their
+// decorators and leading comments.
+func chunkPython(lines []string, _ []byte) []Chunk {
+	var bs []boundary
+	for i, line := range lines {
+		m := pyDecl.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		kind := "function"
+		if m[1] == "class" {
+			kind = "class"
+		}
+		bs = append(bs, boundary{line: leadingComment(lines, i, "#", "@") + 1, kind: kind, name: m[2]})
+	}
+	return fromBoundaries(lines, bs)
+}
+
+// chunkMarkdown splits at headings, naming each section after its heading.
+func chunkMarkdown(lines []string, _ []byte) []Chunk {
+	var bs []boundary
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+		}
+		if !inFence && strings.HasPrefix(line, "#") {
+			bs = append(bs, boundary{line: i + 1, kind: "section", name: strings.TrimSpace(strings.TrimLeft(line, "#"))})
+		}
+	}
+	return fromBoundaries(lines, bs)
+}
+
+// leadingComment returns the 0-based index of the first line of the comment
+// or decorator block directly above lines[i], or i if there is none.
+func leadingComment(lines []string, i int, prefixes ...string) int {
+	start := i
+	for j := i - 1; j >= 0; j-- {
+		trimmed := strings.TrimSpace(lines[j])
+		matched := false
+		for _, p := range prefixes {
+			if strings.HasPrefix(trimmed, p) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			break
+		}
+		start = j
+	}
+	return start
+}
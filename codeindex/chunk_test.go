@@ -0,0 +1,103 @@
+package codeindex
+
+import (
+	"strings"
+	"testing"
+)
+
+func names(chunks []Chunk) []string {
+	var out []string
+	for _, c := range chunks {
+		out = append(out, c.Kind+" "+c.Name)
+	}
+	return out
+}
+
+func TestChunkFile_Go(t *testing.T) {
+	src := `// Package shop sells things.
+package shop
+
+import "fmt"
+
+// Cart holds items.
+type Cart struct {
+	Items []string
+}
+
+const maxItems = 10
+
+// Add puts an item in the cart.
+func (c *Cart) Add(item string) {
+	c.Items = append(c.Items, item)
+}
+
+func Total(c Cart) string {
+	return fmt.Sprint(len(c.Items))
+}
+`
+	chunks := ChunkFile("shop/cart.go", []byte(src))
+	want := []string{"module package shop", "type Cart", "const maxItems", "method Cart.Add", "function Total"}
+	if got := names(chunks); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("chunks = %v, want %v", got, want)
+	}
+	add := chunks[3]
+	if add.LineStart != 13 || add.LineEnd != 16 || !strings.HasPrefix(add.Content, "// Add puts") {
+		t.Errorf("Add chunk = %+v", add)
+	}
+}
+
+func TestChunkFile_TypeScriptAndPython(t *testing.T) {
+	ts := `import { x } from './x'
+
+/** Formats a price. */
+export function formatPrice(n: number): string {
+  return n.toFixed(2)
+}
+
+export interface Order {
+  id: string
+}
+
+export const api = {
+  get: () => x,
+}
+`
+	if got := names(ChunkFile("src/lib/price.ts", []byte(ts))); strings.Join(got, "|") != "module |function formatPrice|interface Order|variable api" {
+		t.Errorf("ts chunks = %v", got)
+	}
+
+	py := `import os
+
+@cache
+def load(path):
+    return open(path).read()
+
+
+class Store:
+    def get(self):
+        return 1
+`
+	chunks := ChunkFile("store.py", []byte(py))
+	if got := names(chunks); strings.Join(got, "|") != "module |function load|class Store" {
+		t.Errorf("py chunks = %v", got)
+	}
+	if chunks[1].LineStart != 3 || chunks[1].LineEnd != 5 {
+		t.Errorf("decorator not kept: %+v", chunks[1])
+	}
+}
+
+func TestChunkFile_SplitsLongAndSkipsUnsupported(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("package big\n\nfunc Long() {\n")
+	for i := 0; i < 300; i++ {
+		b.WriteString("\tprintln()\n")
+	}
+	b.WriteString("}\n")
+	chunks := ChunkFile("big.go", []byte(b.String()))
+	if len(chunks) != 4 || chunks[1].Name != "Long" || chunks[3].Name != "Long" || chunks[3].LineEnd != 304 {
+		t.Errorf("long function split into %v", names(chunks))
+	}
+	if ChunkFile("logo.png", []byte("binary")) != nil || Supported("go.sum") {
+		t.Error("unsupported file was chunked")
+	}
+}
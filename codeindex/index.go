@@ -0,0 +1,282 @@
+// Package codeindex is the native code search backend: it chunks source files
+// by declaration, embeds the chunks with the configured embedding provider
+// and keeps the vectors in SQLite, reindexing only files whose content
+// changed. Results use vexor.Result so callers can switch backends freely.
+package codeindex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+	"github.com/MartinNevlaha/stratus-v2/embeddings"
+	"github.com/MartinNevlaha/stratus-v2/vexor"
+)
+
+const (
+	// maxEmbedRunes caps the text embedded per chunk.
+	maxEmbedRunes = 6000
+	// excerptRunes caps the chunk text returned in search results.
+	excerptRunes = 600
+	// indexTimeout bounds one Index call made without a context.
+	indexTimeout = 30 * time.Minute
+)
+
+// Indexer maintains the native code index for one project root.
+type Indexer struct {
+	tree
+	db        *db.DB
+	embedder  embeddings.Embedder
+	batchSize int
+
+	// mu serialises indexing so a full pass and an incremental batch never
+	// race on the same file.
+	mu sync.Mutex
+}
+
+// IndexReport counts what one indexing pass did.
+type IndexReport struct {
+	Indexed   int `json:"indexed"`
+	Unchanged int `json:"unchanged"`
+	Removed   int `json:"removed"`
+	Chunks    int `json:"chunks"`
+}
+
+// New creates an Indexer over root. batchSize is the number of chunks sent
+// per embedding request.
+func New(database *db.DB, embedder embeddings.Embedder, root string, batchSize int, cfg config.CodeIndexConfig) *Indexer {
+	if batchSize <= 0 {
+		batchSize = 32
+	}
+	if cfg.MaxFileKB <= 0 {
+		cfg.MaxFileKB = 256
+	}
+	return &Indexer{tree: tree{root: root, cfg: cfg}, db: database, embedder: embedder, batchSize: batchSize}
+}
+
+// Model returns the embedding model chunks are indexed with.
+func (ix *Indexer) Model() string { return ix.embedder.Model() }
+
+// Available reports whether the index can serve searches. It always can;
+// an empty index simply returns no results.
+func (ix *Indexer) Available() bool { return ix != nil && ix.embedder != nil }
+
+// Stats summarises the index.
+func (ix *Indexer) Stats() (db.CodeIndexStats, error) {
+	return ix.db.GetCodeIndexStats(ix.Model())
+}
+
+// Search embeds query and returns the topK most similar chunks. mode is
+// accepted for compatibility with the vexor CLI and ignored.
+func (ix *Indexer) Search(query string, topK int, _ string) ([]vexor.Result, error) {
+	if topK <= 0 {
+		topK = 10
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	vecs, err := ix.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("code search: %w", err)
+	}
+	if len(vecs) != 1 {
+		return nil, fmt.Errorf("code search: embedder returned %d vectors", len(vecs))
+	}
+	hits, err := ix.db.SearchCodeChunks(ix.Model(), vecs[0], topK)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]vexor.Result, len(hits))
+	for i, h := range hits {
+		heading := h.Name
+		if h.Kind != "" && h.Name != "" {
+			heading = h.Kind + " " + h.Name
+		}
+		results[i] = vexor.Result{
+			Rank:       i + 1,
+			Score:      h.Score,
+			FilePath:   h.FilePath,
+			ChunkIndex: h.ChunkIndex,
+			LineStart:  h.LineStart,
+			LineEnd:    h.LineEnd,
+			Heading:    heading,
+			Excerpt:    truncateRunes(h.Content, excerptRunes),
+		}
+	}
+	return results, nil
+}
+
+// Index reindexes paths, or the whole project when paths is empty. Only
+// files whose content hash changed are re-embedded; deleted files are
+// dropped from the index.
+func (ix *Indexer) Index(paths []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), indexTimeout)
+	defer cancel()
+	var err error
+	if len(paths) == 0 {
+		_, err = ix.IndexAll(ctx)
+	} else {
+		_, err = ix.IndexPaths(ctx, paths)
+	}
+	return err
+}
+
+// IndexPaths reindexes the given files. Paths may be absolute or relative
+// to the project root; paths outside it are ignored.
+func (ix *Indexer) IndexPaths(ctx context.Context, paths []string) (IndexReport, error) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	var report IndexReport
+	var errs []error
+	for _, p := range paths {
+		rel, ok := ix.relPath(p)
+		if !ok {
+			continue
+		}
+		if err := ix.indexFile(ctx, rel, &report); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return report, errors.Join(errs...)
+}
+
+// IndexAll walks the project, indexes new and changed files and removes
+// files that no longer exist or are now excluded.
+func (ix *Indexer) IndexAll(ctx context.Context) (IndexReport, error) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	var report IndexReport
+	seen := map[string]bool{}
+	var errs []error
+	err := ix.walk(ctx, func(rel string) {
+		if !Supported(rel) {
+			return
+		}
+		seen[rel] = true
+		if err := ix.indexFile(ctx, rel, &report); err != nil {
+			errs = append(errs, err)
+		}
+	})
+	if err != nil {
+		return report, err
+	}
+
+	indexed, err := ix.db.ListCodeFiles()
+	if err != nil {
+		return report, err
+	}
+	for _, rel := range indexed {
+		if seen[rel] {
+			continue
+		}
+		if err := ix.db.DeleteCodeFile(rel); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		report.Removed++
+	}
+	return report, errors.Join(errs...)
+}
+
+// Run indexes the whole project once in the background, logging the
+// outcome. Later changes arrive through Index.
+func (ix *Indexer) Run(ctx context.Context) {
+	start := time.Now()
+	report, err := ix.IndexAll(ctx)
+	if err != nil {
+		log.Printf("code index: %v", err)
+	}
+	log.Printf("code index: %d files indexed, %d unchanged, %d removed, %d chunks in %v",
+		report.Indexed, report.Unchanged, report.Removed, report.Chunks, time.Since(start).Round(time.Millisecond))
+}
+
+// indexFile brings one project-relative file up to date in the index.
+func (ix *Indexer) indexFile(ctx context.Context, rel string, report *IndexReport) error {
+	abs := filepath.Join(ix.root, filepath.FromSlash(rel))
+	info, err := os.Stat(abs)
+	if err != nil || info.IsDir() || !ix.wanted(rel) || info.Size() > int64(ix.cfg.MaxFileKB)*1024 {
+		hash, _, _ := ix.db.GetCodeFileHash(rel)
+		if hash == "" {
+			return nil
+		}
+		report.Removed++
+		return ix.db.DeleteCodeFile(rel)
+	}
+	src, err := os.ReadFile(abs)
+	if err != nil {
+		return fmt.Errorf("code index %s: %w", rel, err)
+	}
+	sum := sha256.Sum256(src)
+	hash := hex.EncodeToString(sum[:])
+	if oldHash, oldModel, err := ix.db.GetCodeFileHash(rel); err != nil {
+		return err
+	} else if oldHash == hash && oldModel == ix.Model() {
+		report.Unchanged++
+		return nil
+	}
+
+	chunks := ChunkFile(rel, src)
+	stored := make([]db.CodeChunk, len(chunks))
+	for start := 0; start < len(chunks); start += ix.batchSize {
+		end := min(start+ix.batchSize, len(chunks))
+		texts := make([]string, 0, end-start)
+		for _, c := range chunks[start:end] {
+			texts = append(texts, embedText(rel, c))
+		}
+		vecs, err := ix.embedder.Embed(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("code index %s: %w", rel, err)
+		}
+		if len(vecs) != len(texts) {
+			return fmt.Errorf("code index %s: embedder returned %d vectors for %d chunks", rel, len(vecs), len(texts))
+		}
+		for i, c := range chunks[start:end] {
+			stored[start+i] = db.CodeChunk{
+				FilePath:  rel,
+				Kind:      c.Kind,
+				Name:      c.Name,
+				LineStart: c.LineStart,
+				LineEnd:   c.LineEnd,
+				Content:   c.Content,
+				Vector:    vecs[i],
+			}
+		}
+	}
+	if err := ix.db.ReplaceCodeFile(rel, hash, ix.Model(), stored); err != nil {
+		return err
+	}
+	report.Indexed++
+	report.Chunks += len(stored)
+	return nil
+}
+
+// wanted reports whether a project-relative file path should be indexed.
+func (ix *Indexer) wanted(rel string) bool {
+	return Supported(rel) && !ix.skipped(rel)
+}
+
+// embedText prefixes a chunk with its location so the path and declaration
+// name contribute to similarity.
+func embedText(rel string, c Chunk) string {
+	header := rel
+	if c.Name != "" {
+		header += "\n" + c.Kind + " " + c.Name
+	}
+	return truncateRunes(header+"\n\n"+c.Content, maxEmbedRunes)
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
@@ -0,0 +1,101 @@
+package codeindex
+
+import (
+	"context"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+// wordEmbedder hashes words into buckets, so texts sharing words are
+// similar. It counts the texts it embedded.
+type wordEmbedder struct{ embedded int }
+
+func (*wordEmbedder) Model() string { return "words" }
+
+func (e *wordEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, 64)
+		for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !('a' <= r && r <= 'z')
+		}) {
+			h := fnv.New32a()
+			h.Write([]byte(w))
+			vec[h.Sum32()%64]++
+		}
+		out[i] = vec
+	}
+	e.embedded += len(texts)
+	return out, nil
+}
+
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndexer_IncrementalReindexAndSearch(t *testing.T) {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	root := t.TempDir()
+	writeFile(t, root, "billing/invoice.go", "package billing\n\n// RenderInvoice prints an invoice.\nfunc RenderInvoice() {}\n")
+	writeFile(t, root, "shipping/rates.py", "def shipping_rate(weight):\n    return weight * 2\n")
+	writeFile(t, root, "node_modules/dep/index.js", "function ignored() {}\n")
+	writeFile(t, root, ".git/config.yml", "x: 1\n")
+	writeFile(t, root, "gen/schema.gen.go", "package gen\n")
+
+	emb := &wordEmbedder{}
+	ix := New(database, emb, root, 8, config.CodeIndexConfig{Exclude: []string{"*.gen.go"}})
+	report, err := ix.IndexAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Indexed != 2 || report.Chunks != 3 {
+		t.Fatalf("first pass = %+v", report)
+	}
+
+	results, err := ix.Search("shipping rate weight", 1, "auto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].FilePath != "shipping/rates.py" || results[0].Heading != "function shipping_rate" || results[0].LineStart != 1 {
+		t.Fatalf("search = %+v", results)
+	}
+
+	// Only the changed file is re-embedded; untouched ones are skipped.
+	before := emb.embedded
+	writeFile(t, root, "billing/invoice.go", "package billing\n\n// RenderInvoice prints a PDF invoice.\nfunc RenderInvoice() {}\n")
+	report, err = ix.IndexPaths(context.Background(), []string{filepath.Join(root, "billing/invoice.go"), "shipping/rates.py", "../outside.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Indexed != 1 || report.Unchanged != 1 || emb.embedded-before != 2 {
+		t.Errorf("incremental pass = %+v, embedded %d", report, emb.embedded-before)
+	}
+
+	if err := os.Remove(filepath.Join(root, "shipping/rates.py")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Index([]string{"shipping/rates.py"}); err != nil {
+		t.Fatal(err)
+	}
+	stats, _ := ix.Stats()
+	if stats.Files != 1 || stats.Chunks != 2 {
+		t.Errorf("stats after delete = %+v", stats)
+	}
+}
@@ -0,0 +1,442 @@
+package codeindex
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+// SymbolIndex maintains definitions, references and import edges for a
+// project. Go packages are type-checked with go/types so references resolve
+// to qualified names; other languages are scanned with ctags-style patterns
+// and their calls are matched by name.
+type SymbolIndex struct {
+	tree
+	db     *db.DB
+	module string
+
+	mu sync.Mutex
+}
+
+// SymbolHit is one answer to a symbol query. For definitions Ref is nil;
+// for callers Symbol is the calling function and Ref the call site; for
+// callees Symbol is the called definition (or a stub when it is not in the
+// index) and Ref the call site inside the queried function.
+type SymbolHit struct {
+	Symbol db.Symbol
+	Ref    *db.SymbolRef
+	// Of is the display name of the symbol the query resolved to.
+	Of string
+	// Resolved is false when the reference only matched by name.
+	Resolved bool
+}
+
+// NewSymbolIndex creates a symbol index over root. The Go module path is
+// read from root/go.mod so package paths match import paths.
+func NewSymbolIndex(database *db.DB, root string, cfg config.CodeIndexConfig) *SymbolIndex {
+	if cfg.MaxFileKB <= 0 {
+		cfg.MaxFileKB = 256
+	}
+	return &SymbolIndex{tree: tree{root: root, cfg: cfg}, db: database, module: readModulePath(root)}
+}
+
+// Stats summarises the index.
+func (si *SymbolIndex) Stats() (db.SymbolIndexStats, error) {
+	return si.db.GetSymbolIndexStats()
+}
+
+// Index brings paths up to date, or the whole project when paths is empty.
+// A changed Go file reindexes its whole package, since type information is
+// shared between the package's files.
+func (si *SymbolIndex) Index(paths []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), indexTimeout)
+	defer cancel()
+	var err error
+	if len(paths) == 0 {
+		_, err = si.IndexAll(ctx)
+	} else {
+		_, err = si.IndexPaths(ctx, paths)
+	}
+	return err
+}
+
+// Run indexes the whole project once, logging the outcome.
+func (si *SymbolIndex) Run(ctx context.Context) {
+	start := time.Now()
+	report, err := si.IndexAll(ctx)
+	if err != nil {
+		log.Printf("symbol index: %v", err)
+	}
+	log.Printf("symbol index: %d files indexed, %d unchanged, %d removed in %v",
+		report.Indexed, report.Unchanged, report.Removed, time.Since(start).Round(time.Millisecond))
+}
+
+// IndexPaths reindexes the given files. Paths may be absolute or relative
+// to the project root; paths outside it are ignored.
+func (si *SymbolIndex) IndexPaths(ctx context.Context, paths []string) (IndexReport, error) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	stored, err := si.storedFiles()
+	if err != nil {
+		return IndexReport{}, err
+	}
+	var report IndexReport
+	var errs []error
+	goDirs := map[string]bool{}
+	for _, p := range paths {
+		rel, ok := si.relPath(p)
+		if !ok || ctx.Err() != nil {
+			continue
+		}
+		if strings.HasSuffix(rel, ".go") {
+			goDirs[path.Dir(rel)] = true
+			continue
+		}
+		if err := si.indexPatternFile(rel, stored, &report); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	imp := newGoImporter(si.tree, si.module)
+	for _, dir := range sortedKeys(goDirs) {
+		if err := si.indexGoDir(imp, dir, stored, &report); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return report, errors.Join(append(errs, ctx.Err())...)
+}
+
+// IndexAll walks the project, reindexes changed files and drops files that
+// no longer exist.
+func (si *SymbolIndex) IndexAll(ctx context.Context) (IndexReport, error) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	stored, err := si.storedFiles()
+	if err != nil {
+		return IndexReport{}, err
+	}
+	var report IndexReport
+	var errs []error
+	seen := map[string]bool{}
+	goDirs := map[string]bool{}
+	err = si.walk(ctx, func(rel string) {
+		switch {
+		case strings.HasSuffix(rel, ".go"):
+			goDirs[path.Dir(rel)] = true
+		case patternSupported(rel):
+			seen[rel] = true
+			if err := si.indexPatternFile(rel, stored, &report); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	})
+	if err != nil {
+		return report, err
+	}
+	imp := newGoImporter(si.tree, si.module)
+	for _, dir := range sortedKeys(goDirs) {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if err := si.indexGoDir(imp, dir, stored, &report); err != nil {
+			errs = append(errs, err)
+		}
+		for rel := range stored {
+			if path.Dir(rel) == dir && strings.HasSuffix(rel, ".go") {
+				seen[rel] = true
+			}
+		}
+	}
+	for rel := range stored {
+		if seen[rel] {
+			continue
+		}
+		if err := si.db.DeleteSymbolFile(rel); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		report.Removed++
+	}
+	return report, errors.Join(errs...)
+}
+
+// storedFiles returns the indexed paths as a set. indexGoDir and
+// indexPatternFile keep it current as they add and remove files.
+func (si *SymbolIndex) storedFiles() (map[string]bool, error) {
+	paths, err := si.db.ListSymbolFiles()
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		stored[p] = true
+	}
+	return stored, nil
+}
+
+// indexPatternFile brings one non-Go file up to date.
+func (si *SymbolIndex) indexPatternFile(rel string, stored map[string]bool, report *IndexReport) error {
+	src, ok := si.read(rel)
+	if !ok || !patternSupported(rel) {
+		if !stored[rel] {
+			return nil
+		}
+		delete(stored, rel)
+		report.Removed++
+		return si.db.DeleteSymbolFile(rel)
+	}
+	hash := hashBytes(src)
+	if old, err := si.db.GetSymbolFileHash(rel); err != nil {
+		return err
+	} else if old == hash {
+		report.Unchanged++
+		return nil
+	}
+	if err := si.db.ReplaceSymbolFile(extractPattern(rel, hash, src)); err != nil {
+		return err
+	}
+	stored[rel] = true
+	report.Indexed++
+	return nil
+}
+
+// indexGoDir reindexes the Go package in dir when any of its files was
+// added, changed or removed.
+func (si *SymbolIndex) indexGoDir(imp *goImporter, dir string, stored map[string]bool, report *IndexReport) error {
+	var files []goFile
+	hashes := map[string]string{}
+	changed := false
+	for _, f := range parseGoDir(imp.fset, filepath.Join(si.root, filepath.FromSlash(dir)), true) {
+		rel := path.Join(dir, f.name)
+		if si.skipped(rel) || len(f.src) > si.cfg.MaxFileKB*1024 {
+			continue
+		}
+		files = append(files, f)
+		hashes[rel] = hashBytes(f.src)
+		old, err := si.db.GetSymbolFileHash(rel)
+		if err != nil {
+			return err
+		}
+		if old != hashes[rel] {
+			changed = true
+		}
+	}
+	var gone []string
+	for rel := range stored {
+		if path.Dir(rel) == dir && strings.HasSuffix(rel, ".go") && hashes[rel] == "" {
+			gone = append(gone, rel)
+		}
+	}
+	if !changed && len(gone) == 0 {
+		report.Unchanged += len(files)
+		return nil
+	}
+
+	var errs []error
+	for _, rel := range gone {
+		if err := si.db.DeleteSymbolFile(rel); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delete(stored, rel)
+		report.Removed++
+	}
+	for _, sf := range extractGoDir(imp, dir, files, hashes) {
+		if err := si.db.ReplaceSymbolFile(sf); err != nil {
+			errs = append(errs, fmt.Errorf("symbol index %s: %w", sf.Path, err))
+			continue
+		}
+		stored[sf.Path] = true
+		report.Indexed++
+	}
+	return errors.Join(errs...)
+}
+
+// read returns the contents of an indexable file.
+func (si *SymbolIndex) read(rel string) ([]byte, bool) {
+	if si.skipped(rel) {
+		return nil, false
+	}
+	abs := filepath.Join(si.root, filepath.FromSlash(rel))
+	info, err := os.Stat(abs)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > int64(si.cfg.MaxFileKB)*1024 {
+		return nil, false
+	}
+	src, err := os.ReadFile(abs)
+	return src, err == nil
+}
+
+// Definitions finds symbols whose name matches query exactly, by prefix or
+// by substring, best matches first.
+func (si *SymbolIndex) Definitions(query string, limit int) ([]SymbolHit, error) {
+	syms, err := si.db.FindSymbols(query, false, limit)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]SymbolHit, len(syms))
+	for i, s := range syms {
+		hits[i] = SymbolHit{Symbol: s, Of: s.DisplayName(), Resolved: true}
+	}
+	return hits, nil
+}
+
+// Callers finds the places that call or reference the symbols named by
+// query (a qualified name, "Type.Method" or a bare name).
+func (si *SymbolIndex) Callers(query string, limit int) ([]SymbolHit, error) {
+	targets, err := si.db.FindSymbols(query, true, 10)
+	if err != nil {
+		return nil, err
+	}
+	var hits []SymbolHit
+	seen := map[string]bool{}
+	cache := map[string]*db.Symbol{}
+	for _, t := range targets {
+		refs, err := si.db.FindReferences(t, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range refs {
+			key := fmt.Sprintf("%s:%d:%s", r.FilePath, r.Line, r.FromQName)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			ref := r
+			caller := si.lookup(cache, r.FromQName)
+			if caller == nil {
+				caller = &db.Symbol{FilePath: r.FilePath, Lang: r.Lang, QName: r.FromQName, Name: r.FromQName, Kind: "file"}
+			}
+			hits = append(hits, SymbolHit{Symbol: *caller, Ref: &ref, Of: t.DisplayName(), Resolved: r.ToQName != ""})
+		}
+	}
+	sortHits(hits)
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// Callees lists what the symbols named by query call and reference, each
+// resolved to its definition where the index has one.
+func (si *SymbolIndex) Callees(query string, limit int) ([]SymbolHit, error) {
+	targets, err := si.db.FindSymbols(query, true, 10)
+	if err != nil {
+		return nil, err
+	}
+	var hits []SymbolHit
+	seen := map[string]bool{}
+	cache := map[string]*db.Symbol{}
+	for _, t := range targets {
+		refs, err := si.db.FindCallees(t.QName, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range refs {
+			key := t.QName + "|" + r.ToQName + "|" + r.ToName
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			ref := r
+			callee := si.lookup(cache, r.ToQName)
+			if callee == nil && r.ToQName == "" {
+				callee = si.lookupByName(r.ToName, r.Lang, t.FilePath)
+			}
+			resolved := callee != nil
+			if callee == nil {
+				callee = &db.Symbol{FilePath: r.FilePath, Lang: r.Lang, QName: r.ToQName, Name: r.ToName, Kind: "external"}
+			}
+			hits = append(hits, SymbolHit{Symbol: *callee, Ref: &ref, Of: t.DisplayName(), Resolved: resolved})
+		}
+	}
+	sortHits(hits)
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func (si *SymbolIndex) lookup(cache map[string]*db.Symbol, qname string) *db.Symbol {
+	if qname == "" {
+		return nil
+	}
+	if s, ok := cache[qname]; ok {
+		return s
+	}
+	s, err := si.db.GetSymbol(qname)
+	if err != nil {
+		s = nil
+	}
+	cache[qname] = s
+	return s
+}
+
+// lookupByName resolves a name-only reference, preferring a definition in
+// the same file, then one in the same language.
+func (si *SymbolIndex) lookupByName(name, lang, file string) *db.Symbol {
+	syms, err := si.db.FindSymbols(name, true, 20)
+	if err != nil {
+		return nil
+	}
+	var best *db.Symbol
+	for i, s := range syms {
+		if s.Name != name || s.Lang != lang {
+			continue
+		}
+		if s.FilePath == file {
+			return &syms[i]
+		}
+		if best == nil {
+			best = &syms[i]
+		}
+	}
+	return best
+}
+
+// sortHits puts resolved hits first, keeping their order otherwise.
+func sortHits(hits []SymbolHit) {
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Resolved && !hits[j].Resolved })
+}
+
+// readModulePath returns the module path declared in root/go.mod, or "".
+func readModulePath(root string) string {
+	f, err := os.Open(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(sc.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,190 @@
+package codeindex
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+func TestSymbolIndex_GoDefinitionsCallersCallees(t *testing.T) {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/shop\n\ngo 1.22\n")
+	writeFile(t, root, "store/store.go", `package store
+
+import "fmt"
+
+// Store keeps orders.
+type Store struct{ orders []string }
+
+// Saver saves orders.
+type Saver interface {
+	Save(order string) error
+}
+
+// Save records an order.
+func (s *Store) Save(order string) error {
+	s.orders = append(s.orders, order)
+	return validate(order)
+}
+
+func validate(order string) error {
+	if order == "" {
+		return fmt.Errorf("empty order")
+	}
+	return nil
+}
+`)
+	writeFile(t, root, "api/handler.go", `package api
+
+import "example.com/shop/store"
+
+type Handler struct{ st *store.Store }
+
+func (h *Handler) Checkout(order string) error {
+	return h.st.Save(order)
+}
+
+func viaInterface(s store.Saver) {
+	_ = s.Save("x")
+}
+`)
+	writeFile(t, root, "web/cart.ts", `import { api } from "./client"
+
+export function addToCart(id: string) {
+  return api.post(id)
+}
+
+export class Cart {
+  total(items: number[]) {
+    return addToCart("sum")
+  }
+}
+`)
+
+	si := NewSymbolIndex(database, root, config.CodeIndexConfig{})
+	report, err := si.IndexAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Indexed != 3 {
+		t.Fatalf("indexed %d files, want 3 (%+v)", report.Indexed, report)
+	}
+
+	defs, err := si.Definitions("Store.Save", 5)
+	if err != nil || len(defs) == 0 {
+		t.Fatalf("definitions: %v %v", defs, err)
+	}
+	if d := defs[0].Symbol; d.QName != "example.com/shop/store.Store.Save" || d.FilePath != "store/store.go" || d.LineStart != 14 {
+		t.Fatalf("Store.Save definition = %+v", d)
+	}
+
+	callers, err := si.Callers("Store.Save", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(callers) != 1 || callers[0].Symbol.DisplayName() != "Handler.Checkout" || !callers[0].Resolved ||
+		callers[0].Ref.FilePath != "api/handler.go" || callers[0].Ref.Line != 8 {
+		t.Fatalf("callers of Store.Save = %+v", callers)
+	}
+
+	// The call through the interface resolves to the interface method.
+	callers, err = si.Callers("Saver.Save", 10)
+	if err != nil || len(callers) != 1 || callers[0].Symbol.Name != "viaInterface" {
+		t.Fatalf("callers of Saver.Save = %+v %v", callers, err)
+	}
+
+	callees, err := si.Callees("Store.Save", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range callees {
+		names = append(names, c.Symbol.QName)
+	}
+	if !strings.Contains(strings.Join(names, " "), "example.com/shop/store.validate") {
+		t.Fatalf("callees of Store.Save = %v", names)
+	}
+
+	// Pattern-indexed languages: class members become methods and calls
+	// match by name.
+	callers, err = si.Callers("addToCart", 10)
+	if err != nil || len(callers) != 1 || callers[0].Symbol.DisplayName() != "Cart.total" || callers[0].Resolved {
+		t.Fatalf("callers of addToCart = %+v %v", callers, err)
+	}
+	importers, err := database.ListImporters("example.com/shop/store")
+	if err != nil || len(importers) != 1 || importers[0] != "api/handler.go" {
+		t.Fatalf("importers = %v %v", importers, err)
+	}
+
+	// Incremental update: rewriting the caller moves the call; an unchanged
+	// package is not reparsed into new rows.
+	writeFile(t, root, "api/handler.go", `package api
+
+import "example.com/shop/store"
+
+type Handler struct{ st *store.Store }
+
+func (h *Handler) Checkout(order string) error {
+	return nil
+}
+
+func (h *Handler) Reorder(order string) error {
+	return h.st.Save(order)
+}
+`)
+	report, err = si.IndexPaths(context.Background(), []string{"api/handler.go", "store/store.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Indexed != 1 || report.Unchanged != 1 {
+		t.Fatalf("incremental report = %+v", report)
+	}
+	callers, err = si.Callers("Store.Save", 10)
+	if err != nil || len(callers) != 1 || callers[0].Symbol.DisplayName() != "Handler.Reorder" {
+		t.Fatalf("callers after edit = %+v %v", callers, err)
+	}
+}
+
+func TestExtractPattern_PythonMethodsAndImports(t *testing.T) {
+	src := `import os
+from billing.tax import rate
+
+class Invoice:
+    def total(self):
+        return compute(self.lines)
+
+    def render(self):
+        # compute() in a comment is not a call
+        return "compute()"
+
+def compute(lines):
+    return sum(lines) * rate()
+`
+	f := extractPattern("billing/invoice.py", "h", []byte(src))
+	var defs []string
+	for _, s := range f.Symbols {
+		defs = append(defs, s.Kind+" "+s.DisplayName())
+	}
+	if got := strings.Join(defs, ", "); got != "class Invoice, method Invoice.total, method Invoice.render, function compute" {
+		t.Fatalf("symbols = %s", got)
+	}
+	if strings.Join(f.Imports, ",") != "os,billing.tax" {
+		t.Fatalf("imports = %v", f.Imports)
+	}
+	var calls []string
+	for _, r := range f.Refs {
+		calls = append(calls, r.FromQName+"->"+r.ToName)
+	}
+	want := "billing/invoice.py:Invoice.total->compute billing/invoice.py:compute->sum billing/invoice.py:compute->rate"
+	if got := strings.Join(calls, " "); got != want {
+		t.Fatalf("calls = %s, want %s", got, want)
+	}
+}
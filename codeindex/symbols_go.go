@@ -0,0 +1,464 @@
+package codeindex
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+// goImporter type-checks project packages from source on demand so
+// references into them resolve to qualified names. Imports from outside the
+// module fail; the checker carries on and selectors on those packages fall
+// back to "<import path>.<Name>".
+type goImporter struct {
+	tree
+	module  string
+	fset    *token.FileSet
+	cache   map[string]*types.Package
+	loading map[string]bool
+}
+
+func newGoImporter(t tree, module string) *goImporter {
+	return &goImporter{
+		tree:    t,
+		module:  module,
+		fset:    token.NewFileSet(),
+		cache:   map[string]*types.Package{},
+		loading: map[string]bool{},
+	}
+}
+
+var errNotProject = errors.New("not a project package")
+
+// Import implements types.Importer.
+func (g *goImporter) Import(importPath string) (*types.Package, error) {
+	if pkg, ok := g.cache[importPath]; ok {
+		if pkg == nil {
+			return nil, errNotProject
+		}
+		return pkg, nil
+	}
+	dir, ok := g.dirFor(importPath)
+	if !ok || g.loading[importPath] {
+		g.cache[importPath] = nil
+		return nil, errNotProject
+	}
+	g.loading[importPath] = true
+	defer delete(g.loading, importPath)
+
+	var files []*ast.File
+	for _, f := range parseGoDir(g.fset, filepath.Join(g.root, filepath.FromSlash(dir)), false) {
+		files = append(files, f.ast)
+	}
+	files = mainPackageFiles(files)
+	if len(files) == 0 {
+		g.cache[importPath] = nil
+		return nil, errNotProject
+	}
+	conf := types.Config{Importer: g, Error: func(error) {}, FakeImportC: true}
+	pkg, _ := conf.Check(importPath, g.fset, files, nil)
+	g.cache[importPath] = pkg
+	return pkg, nil
+}
+
+// dirFor maps an import path inside the module to its directory.
+func (g *goImporter) dirFor(importPath string) (string, bool) {
+	if g.module == "" {
+		return "", false
+	}
+	if importPath == g.module {
+		return ".", true
+	}
+	rel, ok := strings.CutPrefix(importPath, g.module+"/")
+	if !ok || g.skipped(rel) {
+		return "", false
+	}
+	return rel, true
+}
+
+// pkgPath is the import path of the package in dir.
+func (g *goImporter) pkgPath(dir string) string {
+	switch {
+	case g.module == "":
+		if dir == "." {
+			return ""
+		}
+		return dir
+	case dir == ".":
+		return g.module
+	}
+	return g.module + "/" + dir
+}
+
+// goFile is a parsed Go source file.
+type goFile struct {
+	name string
+	src  []byte
+	ast  *ast.File
+}
+
+// parseGoDir parses the .go files in dir, skipping _test.go files unless
+// withTests is set. Files that fail to parse are skipped.
+func parseGoDir(fset *token.FileSet, dir string, withTests bool) []goFile {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var out []goFile
+	for _, e := range entries {
+		name := e.Name()
+		if !e.Type().IsRegular() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		if !withTests && strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, src, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		out = append(out, goFile{name: name, src: src, ast: f})
+	}
+	return out
+}
+
+// mainPackageFiles keeps the files of the most common package clause, which
+// drops stray files such as build-tagged generators declaring package main.
+func mainPackageFiles(files []*ast.File) []*ast.File {
+	counts := map[string]int{}
+	best := ""
+	for _, f := range files {
+		n := f.Name.Name
+		counts[n]++
+		if counts[n] > counts[best] {
+			best = n
+		}
+	}
+	var out []*ast.File
+	for _, f := range files {
+		if f.Name.Name == best {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// extractGoDir extracts symbols, references and imports for every Go file
+// in the project directory dir. Files are type-checked per package clause,
+// so in-package tests and external _test packages are handled separately.
+func extractGoDir(g *goImporter, dir string, files []goFile, hashes map[string]string) []db.SymbolFile {
+	byPkg := map[string][]goFile{}
+	var names []string
+	for _, f := range files {
+		n := f.ast.Name.Name
+		if _, ok := byPkg[n]; !ok {
+			names = append(names, n)
+		}
+		byPkg[n] = append(byPkg[n], f)
+	}
+	sort.Strings(names)
+
+	var out []db.SymbolFile
+	for _, name := range names {
+		group := byPkg[name]
+		pkgPath := g.pkgPath(dir)
+		if strings.HasSuffix(name, "_test") && pkgPath != "" {
+			pkgPath += "_test"
+		}
+		asts := make([]*ast.File, len(group))
+		for i, f := range group {
+			asts[i] = f.ast
+		}
+		info := &types.Info{
+			Defs: map[*ast.Ident]types.Object{},
+			Uses: map[*ast.Ident]types.Object{},
+		}
+		conf := types.Config{Importer: g, Error: func(error) {}, FakeImportC: true}
+		_, _ = conf.Check(pkgPath, g.fset, asts, info)
+
+		for _, f := range group {
+			rel := path.Join(dir, f.name)
+			x := goExtractor{fset: g.fset, info: info, pkg: pkgPath, lines: strings.Split(string(f.src), "\n")}
+			out = append(out, x.file(rel, hashes[rel], f))
+		}
+	}
+	return out
+}
+
+// goExtractor walks one type-checked file.
+type goExtractor struct {
+	fset  *token.FileSet
+	info  *types.Info
+	pkg   string
+	lines []string
+	refs  []db.SymbolRef
+	seen  map[string]bool
+}
+
+func (x *goExtractor) file(rel, hash string, f goFile) db.SymbolFile {
+	out := db.SymbolFile{Path: rel, Hash: hash, Lang: "go"}
+	for _, spec := range f.ast.Imports {
+		if p := strings.Trim(spec.Path.Value, "`\""); p != "" {
+			out.Imports = append(out.Imports, p)
+		}
+	}
+	x.seen = map[string]bool{}
+	for _, decl := range f.ast.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			sym := x.symbol(d.Name.Name, "function", d.Pos(), d.End())
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				sym.Kind = "method"
+				sym.Container = receiverName(d.Recv.List[0].Type)
+			}
+			end := d.End()
+			if d.Body != nil {
+				end = d.Body.Lbrace
+			}
+			sym.Signature = x.source(d.Pos(), end)
+			sym.QName = qualify(x.pkg, sym.Container, sym.Name)
+			out.Symbols = append(out.Symbols, sym)
+			x.collectRefs(sym.QName, d)
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			for _, spec := range d.Specs {
+				start, end := spec.Pos(), spec.End()
+				if !d.Lparen.IsValid() {
+					start, end = d.Pos(), d.End()
+				}
+				var from string
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					sym := x.symbol(s.Name.Name, "type", start, end)
+					sym.QName = qualify(x.pkg, "", sym.Name)
+					sym.Signature = "type " + strings.TrimSpace(strings.SplitN(x.source(s.Pos(), s.End()), "{", 2)[0])
+					if _, ok := s.Type.(*ast.InterfaceType); ok {
+						sym.Kind = "interface"
+					}
+					out.Symbols = append(out.Symbols, sym)
+					out.Symbols = append(out.Symbols, x.interfaceMethods(s)...)
+					from = sym.QName
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						if n.Name == "_" {
+							continue
+						}
+						sym := x.symbol(n.Name, d.Tok.String(), n.Pos(), s.End())
+						sym.QName = qualify(x.pkg, "", n.Name)
+						sym.Signature = d.Tok.String() + " " + x.source(s.Pos(), s.End())
+						out.Symbols = append(out.Symbols, sym)
+						if from == "" {
+							from = sym.QName
+						}
+					}
+				}
+				if from != "" {
+					x.collectRefs(from, spec)
+				}
+			}
+		}
+	}
+	out.Refs = x.refs
+	return out
+}
+
+// interfaceMethods lists an interface's methods as symbols so calls made
+// through the interface have a definition to point at.
+func (x *goExtractor) interfaceMethods(s *ast.TypeSpec) []db.Symbol {
+	it, ok := s.Type.(*ast.InterfaceType)
+	if !ok || it.Methods == nil {
+		return nil
+	}
+	var out []db.Symbol
+	for _, m := range it.Methods.List {
+		if _, ok := m.Type.(*ast.FuncType); !ok {
+			continue
+		}
+		for _, n := range m.Names {
+			sym := x.symbol(n.Name, "method", m.Pos(), m.End())
+			sym.Container = s.Name.Name
+			sym.QName = qualify(x.pkg, sym.Container, n.Name)
+			sym.Signature = strings.TrimSpace(x.source(m.Pos(), m.End()))
+			out = append(out, sym)
+		}
+	}
+	return out
+}
+
+func (x *goExtractor) symbol(name, kind string, start, end token.Pos) db.Symbol {
+	return db.Symbol{
+		Lang:      "go",
+		Package:   x.pkg,
+		Name:      name,
+		Kind:      kind,
+		LineStart: x.fset.Position(start).Line,
+		LineEnd:   x.fset.Position(end).Line,
+	}
+}
+
+// collectRefs records every use of a package-level symbol inside node.
+func (x *goExtractor) collectRefs(from string, node ast.Node) {
+	calls := map[*ast.Ident]bool{}
+	pkgSel := map[*ast.Ident]string{}
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if id := calleeIdent(n.Fun); id != nil {
+				calls[id] = true
+			}
+		case *ast.SelectorExpr:
+			if pkgID, ok := n.X.(*ast.Ident); ok {
+				if pn, ok := x.info.Uses[pkgID].(*types.PkgName); ok {
+					pkgSel[n.Sel] = pn.Imported().Path()
+				}
+			}
+		case *ast.Ident:
+			kind := "ref"
+			if calls[n] {
+				kind = "call"
+			}
+			obj := x.info.Uses[n]
+			switch {
+			case obj != nil:
+				if q := objectQName(obj); q != "" {
+					x.addRef(from, q, n.Name, kind, n.Pos())
+				}
+			case pkgSel[n] != "":
+				x.addRef(from, pkgSel[n]+"."+n.Name, n.Name, kind, n.Pos())
+			case kind == "call":
+				// A call the checker could not resolve, e.g. a method on a
+				// value from a package outside the module.
+				x.addRef(from, "", n.Name, kind, n.Pos())
+			}
+		}
+		return true
+	})
+}
+
+func (x *goExtractor) addRef(from, to, name, kind string, pos token.Pos) {
+	line := x.fset.Position(pos).Line
+	key := fmt.Sprintf("%s|%s|%s|%s|%d", from, to, name, kind, line)
+	if x.seen[key] {
+		return
+	}
+	x.seen[key] = true
+	var context string
+	if line > 0 && line <= len(x.lines) {
+		context = truncateRunes(strings.TrimSpace(x.lines[line-1]), 160)
+	}
+	x.refs = append(x.refs, db.SymbolRef{
+		Lang:      "go",
+		FromQName: from,
+		ToQName:   to,
+		ToName:    name,
+		Kind:      kind,
+		Line:      line,
+		Context:   context,
+	})
+}
+
+// source returns the text between two positions with runs of whitespace
+// collapsed, capped for storage.
+func (x *goExtractor) source(start, end token.Pos) string {
+	s, e := x.fset.Position(start), x.fset.Position(end)
+	if s.Line < 1 || e.Line > len(x.lines) || s.Line > e.Line {
+		return ""
+	}
+	var parts []string
+	for i := s.Line; i <= e.Line; i++ {
+		line := x.lines[i-1]
+		if i == e.Line && e.Column-1 <= len(line) {
+			line = line[:e.Column-1]
+		}
+		if i == s.Line && s.Column-1 <= len(line) {
+			line = line[s.Column-1:]
+		}
+		parts = append(parts, strings.TrimSpace(line))
+	}
+	return truncateRunes(strings.Join(strings.Fields(strings.Join(parts, " ")), " "), 200)
+}
+
+// calleeIdent returns the identifier naming the function a call invokes:
+// f, pkg.F or x.Method, looking through parentheses and type arguments.
+func calleeIdent(fun ast.Expr) *ast.Ident {
+	for {
+		switch f := fun.(type) {
+		case *ast.ParenExpr:
+			fun = f.X
+		case *ast.IndexExpr:
+			fun = f.X
+		case *ast.IndexListExpr:
+			fun = f.X
+		case *ast.Ident:
+			return f
+		case *ast.SelectorExpr:
+			return f.Sel
+		default:
+			return nil
+		}
+	}
+}
+
+// objectQName returns the qualified name of a package-level function, type,
+// variable or constant, or of a method; other objects (locals, fields,
+// builtins, package names) yield "".
+func objectQName(obj types.Object) string {
+	if obj.Pkg() == nil {
+		return ""
+	}
+	switch o := obj.(type) {
+	case *types.Func:
+		o = o.Origin()
+		if sig, ok := o.Type().(*types.Signature); ok && sig.Recv() != nil {
+			return qualify(o.Pkg().Path(), namedTypeName(sig.Recv().Type()), o.Name())
+		}
+	case *types.Var:
+		if o.IsField() {
+			return ""
+		}
+	case *types.TypeName, *types.Const:
+	default:
+		return ""
+	}
+	if obj.Parent() != obj.Pkg().Scope() {
+		return ""
+	}
+	return qualify(obj.Pkg().Path(), "", obj.Name())
+}
+
+// namedTypeName returns the name of a method receiver's type.
+func namedTypeName(t types.Type) string {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if n, ok := t.(*types.Named); ok {
+		return n.Origin().Obj().Name()
+	}
+	return ""
+}
+
+func qualify(pkg, container, name string) string {
+	parts := make([]string, 0, 3)
+	for _, p := range []string{pkg, container, name} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ".")
+}
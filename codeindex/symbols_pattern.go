@@ -0,0 +1,348 @@
+package codeindex
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+// defRule recognises a definition line, ctags style. kindIdx and nameIdx
+// are submatch indexes; kindIdx 0 means the rule always yields kind.
+type defRule struct {
+	re      *regexp.Regexp
+	kindIdx int
+	nameIdx int
+	kind    string
+}
+
+// patternLang describes how to pull symbols out of a language without a
+// parser: definition patterns, import patterns (first submatch is the
+// imported path) and the line comment prefix.
+type patternLang struct {
+	name    string
+	defs    []defRule
+	imports []*regexp.Regexp
+	comment string
+}
+
+var (
+	jsLang = &patternLang{
+		name: "js",
+		defs: []defRule{
+			{re: tsDecl, kindIdx: 1, nameIdx: 2},
+			{re: regexp.MustCompile(`^\s+(?:(?:public|private|protected|static|async|readonly|override|abstract|get|set)\s+)*(#?[A-Za-z_$][\w$]*)\s*(?:<[^>]*>)?\s*\([^)]*\)\s*(?::\s*[^={]+)?\{\s*$`), nameIdx: 1, kind: "method"},
+		},
+		imports: []*regexp.Regexp{
+			regexp.MustCompile(`^\s*(?:import|export)\s+(?:[^'"]*?\s+from\s+)?['"]([^'"]+)['"]`),
+			regexp.MustCompile(`\brequire\(\s*['"]([^'"]+)['"]\s*\)`),
+		},
+		comment: "//",
+	}
+	pythonLang = &patternLang{
+		name: "python",
+		defs: []defRule{
+			{re: regexp.MustCompile(`^\s*(?:async\s+)?(def|class)\s+([A-Za-z_]\w*)`), kindIdx: 1, nameIdx: 2},
+		},
+		imports: []*regexp.Regexp{
+			regexp.MustCompile(`^\s*from\s+([\w.]+)\s+import\b`),
+			regexp.MustCompile(`^\s*import\s+([\w.]+)`),
+		},
+		comment: "#",
+	}
+	rustLang = &patternLang{
+		name: "rust",
+		defs: []defRule{
+			{re: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:(?:async|const|unsafe|extern(?:\s+"[^"]*")?)\s+)*(fn|struct|enum|trait|type|mod|union)\s+([A-Za-z_]\w*)`), kindIdx: 1, nameIdx: 2},
+			{re: regexp.MustCompile(`^\s*impl(?:<[^>]*>)?\s+(?:[\w:<>]+\s+for\s+)?([A-Za-z_]\w*)`), nameIdx: 1, kind: "impl"},
+			{re: regexp.MustCompile(`^\s*macro_rules!\s+([A-Za-z_]\w*)`), nameIdx: 1, kind: "macro"},
+		},
+		imports: []*regexp.Regexp{regexp.MustCompile(`^\s*(?:pub\s+)?use\s+([\w:]+)`)},
+		comment: "//",
+	}
+	jvmTypeRule = defRule{
+		re:      regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|static|final|abstract|sealed|open|data|partial|inline|annotation|enum|readonly)\s+)*(class|interface|enum|record|object|struct|protocol|extension|trait)\s+([A-Za-z_]\w*)`),
+		kindIdx: 1,
+		nameIdx: 2,
+	}
+	javaLang = &patternLang{
+		name: "java",
+		defs: []defRule{
+			jvmTypeRule,
+			{re: regexp.MustCompile(`^\s+(?:(?:public|private|protected|internal|static|final|abstract|override|virtual|async|synchronized|native|sealed|extern|unsafe)\s+)*(?:<[^>]+>\s+)?[\w<>\[\],.?]+\s+([A-Za-z_]\w*)\s*\([^;]*$`), nameIdx: 1, kind: "method"},
+		},
+		imports: []*regexp.Regexp{
+			regexp.MustCompile(`^\s*import\s+(?:static\s+)?([\w.]+)`),
+			regexp.MustCompile(`^\s*using\s+([\w.]+)\s*;`),
+		},
+		comment: "//",
+	}
+	kotlinLang = &patternLang{
+		name: "kotlin",
+		defs: []defRule{
+			jvmTypeRule,
+			{re: regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|override|open|abstract|suspend|inline|operator|infix|tailrec)\s+)*fun\s+(?:<[^>]+>\s+)?(?:[\w.]+\.)?([A-Za-z_]\w*)\s*\(`), nameIdx: 1, kind: "function"},
+		},
+		imports: []*regexp.Regexp{regexp.MustCompile(`^\s*import\s+([\w.]+)`)},
+		comment: "//",
+	}
+	swiftLang = &patternLang{
+		name: "swift",
+		defs: []defRule{
+			jvmTypeRule,
+			{re: regexp.MustCompile(`^\s*(?:(?:public|private|internal|open|fileprivate|static|class|override|mutating|final|@\w+)\s+)*func\s+([A-Za-z_]\w*)`), nameIdx: 1, kind: "function"},
+		},
+		imports: []*regexp.Regexp{regexp.MustCompile(`^\s*import\s+(\w+)`)},
+		comment: "//",
+	}
+	phpLang = &patternLang{
+		name: "php",
+		defs: []defRule{
+			jvmTypeRule,
+			{re: regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|abstract|final)\s+)*function\s+&?([A-Za-z_]\w*)\s*\(`), nameIdx: 1, kind: "function"},
+		},
+		imports: []*regexp.Regexp{
+			regexp.MustCompile(`^\s*use\s+([\w\\]+)`),
+			regexp.MustCompile(`\b(?:require|include)(?:_once)?\s*\(?\s*['"]([^'"]+)['"]`),
+		},
+		comment: "//",
+	}
+	rubyLang = &patternLang{
+		name: "ruby",
+		defs: []defRule{
+			{re: regexp.MustCompile(`^\s*(class|module)\s+([A-Z]\w*)`), kindIdx: 1, nameIdx: 2},
+			{re: regexp.MustCompile(`^\s*def\s+(?:self\.)?([A-Za-z_]\w*[?!=]?)`), nameIdx: 1, kind: "function"},
+		},
+		imports: []*regexp.Regexp{regexp.MustCompile(`^\s*require(?:_relative)?\s+['"]([^'"]+)['"]`)},
+		comment: "#",
+	}
+	cLang = &patternLang{
+		name: "c",
+		defs: []defRule{
+			{re: regexp.MustCompile(`^(?:typedef\s+)?(struct|class|union|enum)\s+([A-Za-z_]\w*)\s*(?::[^{;]*)?\{?\s*$`), kindIdx: 1, nameIdx: 2},
+			{re: regexp.MustCompile(`^(?:[\w:*&<>,]+\s+)+[*&]*([A-Za-z_]\w*(?:::[A-Za-z_~]\w*)?)\s*\([^;]*$`), nameIdx: 1, kind: "function"},
+		},
+		imports: []*regexp.Regexp{regexp.MustCompile(`^\s*#\s*include\s+[<"]([^>"]+)[>"]`)},
+		comment: "//",
+	}
+	shLang = &patternLang{
+		name: "sh",
+		defs: []defRule{
+			{re: regexp.MustCompile(`^\s*(?:function\s+)?([A-Za-z_][\w-]*)\s*\(\)`), nameIdx: 1, kind: "function"},
+			{re: regexp.MustCompile(`^\s*function\s+([A-Za-z_][\w-]*)`), nameIdx: 1, kind: "function"},
+		},
+		imports: []*regexp.Regexp{regexp.MustCompile(`^\s*(?:source|\.)\s+(\S+)`)},
+		comment: "#",
+	}
+)
+
+// patternLangs maps extensions to their pattern rules. Go has a real
+// extractor (extractGoDir) and is not listed.
+var patternLangs = map[string]*patternLang{
+	".ts": jsLang, ".tsx": jsLang, ".js": jsLang, ".jsx": jsLang, ".mjs": jsLang, ".cjs": jsLang,
+	".svelte": jsLang, ".vue": jsLang,
+	".py":    pythonLang,
+	".rs":    rustLang,
+	".java":  javaLang,
+	".cs":    javaLang,
+	".kt":    kotlinLang,
+	".swift": swiftLang,
+	".php":   phpLang,
+	".rb":    rubyLang,
+	".c":     cLang, ".h": cLang, ".cpp": cLang, ".cc": cLang, ".hpp": cLang,
+	".sh": shLang,
+}
+
+// containerKinds are definitions whose indented members become methods.
+var containerKinds = map[string]bool{
+	"class": true, "interface": true, "struct": true, "trait": true, "impl": true, "module": true,
+	"object": true, "enum": true, "record": true, "protocol": true, "extension": true,
+}
+
+// notNames are keywords the loose definition and call patterns pick up.
+var notNames = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "return": true,
+	"function": true, "def": true, "class": true, "new": true, "throw": true, "else": true,
+	"await": true, "yield": true, "case": true, "typeof": true, "sizeof": true, "super": true,
+	"with": true, "elif": true, "print": true, "echo": true, "lambda": true, "not": true,
+	"and": true, "or": true, "in": true, "fn": true, "match": true, "loop": true, "do": true,
+	"try": true, "import": true, "require": true, "assert": true, "delete": true, "void": true,
+}
+
+// statementWords start lines that look like "Type name(" declarations to
+// the loose patterns but are statements.
+var statementWords = map[string]bool{
+	"return": true, "new": true, "throw": true, "else": true, "await": true, "yield": true,
+	"case": true, "echo": true, "print": true, "delete": true, "typeof": true, "goto": true,
+}
+
+var (
+	callPattern   = regexp.MustCompile(`([A-Za-z_$][\w$]*)\s*\(`)
+	stringPattern = regexp.MustCompile("\"(?:[^\"\\\\]|\\\\.)*\"|'(?:[^'\\\\]|\\\\.)*'|`[^`]*`")
+)
+
+// patternSupported reports whether rel has pattern rules.
+func patternSupported(rel string) bool {
+	_, ok := patternLangs[strings.ToLower(filepath.Ext(rel))]
+	return ok
+}
+
+// patternDef is a definition found by a pattern, before ranges and
+// containers are known.
+type patternDef struct {
+	line   int // 1-based
+	indent int
+	kind   string
+	name   string
+}
+
+// extractPattern pulls definitions, calls and imports out of src with the
+// rules for rel's language. A definition runs until the next one that is not
+// indented deeper; functions indented inside a class-like definition become
+// its methods. Calls are matched by name only.
+func extractPattern(rel, hash string, src []byte) db.SymbolFile {
+	lang := patternLangs[strings.ToLower(filepath.Ext(rel))]
+	out := db.SymbolFile{Path: rel, Hash: hash, Lang: lang.name}
+	lines := strings.Split(strings.ReplaceAll(string(src), "\r\n", "\n"), "\n")
+
+	var defs []patternDef
+	seenImports := map[string]bool{}
+	for i, line := range lines {
+		for _, re := range lang.imports {
+			if m := re.FindStringSubmatch(line); m != nil && !seenImports[m[1]] {
+				seenImports[m[1]] = true
+				out.Imports = append(out.Imports, m[1])
+			}
+		}
+		if d, ok := matchDef(lang, line); ok {
+			d.line = i + 1
+			defs = append(defs, d)
+		}
+	}
+
+	ends := make([]int, len(defs))
+	containers := make([]string, len(defs))
+	for i, d := range defs {
+		ends[i] = len(lines)
+		for j := i + 1; j < len(defs); j++ {
+			if defs[j].indent <= d.indent {
+				ends[i] = defs[j].line - 1
+				break
+			}
+		}
+		for ends[i] > d.line && strings.TrimSpace(lines[ends[i]-1]) == "" {
+			ends[i]--
+		}
+		for j := i - 1; j >= 0; j-- {
+			if defs[j].indent < d.indent && ends[j] >= d.line && containerKinds[defs[j].kind] {
+				containers[i] = defs[j].name
+				break
+			}
+		}
+	}
+
+	qnames := make([]string, len(defs))
+	for i, d := range defs {
+		kind := d.kind
+		if containers[i] != "" && kind == "function" {
+			kind = "method"
+		}
+		name, container := d.name, containers[i]
+		if c, n, ok := strings.Cut(name, "::"); ok {
+			container, name = c, n
+		}
+		qnames[i] = rel + ":" + qualify("", container, name)
+		if kind == "impl" {
+			continue
+		}
+		out.Symbols = append(out.Symbols, db.Symbol{
+			Lang:      lang.name,
+			QName:     qnames[i],
+			Name:      name,
+			Container: container,
+			Kind:      kind,
+			LineStart: d.line,
+			LineEnd:   ends[i],
+			Signature: truncateRunes(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(lines[d.line-1]), "{")), 200),
+		})
+	}
+
+	seen := map[string]bool{}
+	for i, line := range lines {
+		code := stringPattern.ReplaceAllString(line, `""`)
+		if c := strings.Index(code, lang.comment); c >= 0 {
+			code = code[:c]
+		}
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+		from, own := rel, ""
+		for j := len(defs) - 1; j >= 0; j-- {
+			if defs[j].line <= i+1 && ends[j] >= i+1 {
+				from = qnames[j]
+				if defs[j].line == i+1 {
+					own = defs[j].name
+				}
+				break
+			}
+		}
+		for _, m := range callPattern.FindAllStringSubmatch(code, -1) {
+			name := strings.TrimPrefix(m[1], "$")
+			if name == "" || name == own || notNames[name] {
+				continue
+			}
+			key := from + "|" + name + "|" + strconv.Itoa(i)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			out.Refs = append(out.Refs, db.SymbolRef{
+				Lang:      lang.name,
+				FromQName: from,
+				ToName:    name,
+				Kind:      "call",
+				Line:      i + 1,
+				Context:   truncateRunes(strings.TrimSpace(line), 160),
+			})
+		}
+	}
+	return out
+}
+
+func matchDef(lang *patternLang, line string) (patternDef, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, lang.comment) {
+		return patternDef{}, false
+	}
+	if first, _, _ := strings.Cut(trimmed, " "); statementWords[first] {
+		return patternDef{}, false
+	}
+	for _, r := range lang.defs {
+		m := r.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name := m[r.nameIdx]
+		if notNames[name] {
+			continue
+		}
+		kind := r.kind
+		if r.kindIdx > 0 {
+			kind = normalizeKind(m[r.kindIdx])
+		}
+		return patternDef{indent: len(line) - len(strings.TrimLeft(line, " \t")), kind: kind, name: name}, true
+	}
+	return patternDef{}, false
+}
+
+func normalizeKind(raw string) string {
+	switch strings.TrimSuffix(raw, "*") {
+	case "def", "fn", "func", "fun", "function":
+		return "function"
+	case "const", "let", "var":
+		return "variable"
+	}
+	return raw
+}
@@ -0,0 +1,95 @@
+package codeindex
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+)
+
+// skipDirs are never descended into. Hidden directories are skipped too.
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	"coverage":     true,
+	"__pycache__":  true,
+	"venv":         true,
+}
+
+// tree is the part of the project both indexes cover: everything under root
+// except hidden and dependency directories and the configured excludes.
+type tree struct {
+	root string
+	cfg  config.CodeIndexConfig
+}
+
+// walk calls fn with the slash-separated relative path of every regular,
+// non-skipped file under the root.
+func (t tree) walk(ctx context.Context, fn func(rel string)) error {
+	return filepath.WalkDir(t.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		rel, err := filepath.Rel(t.root, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel != "." && (strings.HasPrefix(d.Name(), ".") || skipDirs[d.Name()] || t.excluded(rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && !t.skipped(rel) {
+			fn(rel)
+		}
+		return nil
+	})
+}
+
+// relPath converts p to a slash-separated path relative to the root,
+// reporting false for paths outside it.
+func (t tree) relPath(p string) (string, bool) {
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(t.root, p)
+	}
+	rel, err := filepath.Rel(t.root, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// skipped reports whether rel lies in a skipped directory or is excluded.
+func (t tree) skipped(rel string) bool {
+	if t.excluded(rel) {
+		return true
+	}
+	for _, part := range strings.Split(rel, "/") {
+		if strings.HasPrefix(part, ".") || skipDirs[part] {
+			return true
+		}
+	}
+	return false
+}
+
+func (t tree) excluded(rel string) bool {
+	for _, pattern := range t.cfg.Exclude {
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -299,6 +299,9 @@ type Config struct {
 	Language                 string             `json:"language"`
 	LLM                      LLMConfig          `json:"llm"`
 	Vexor                    VexorConfig        `json:"vexor"`
+	CodeIndex                CodeIndexConfig    `json:"code_index"`
+	Retrieval                RetrievalConfig    `json:"retrieval"`
+	Governance               GovernanceConfig   `json:"governance"`
 	STT                      STTConfig          `json:"stt"`
 	Guardian                 GuardianConfig     `json:"guardian"`
 	SyncState                *SyncState         `json:"sync_state,omitempty"`
@@ -308,6 +311,11 @@ type Config struct {
 	Evolution                EvolutionConfig    `json:"evolution"`
 	CodeAnalysis             CodeAnalysisConfig `json:"code_analysis"`
 	Learn                    LearnConfig        `json:"learn"`
+	Embeddings               EmbeddingsConfig   `json:"embeddings"`
+	Memory                   MemoryConfig       `json:"memory"`
+	TeamSync                 TeamSyncConfig     `json:"team_sync"`
+	MCP                      MCPConfig          `json:"mcp"`
+	Workflows                WorkflowsConfig    `json:"workflows"`
 }
 
 // ValidLanguage returns true if s is a supported UI language code.
@@ -322,6 +330,162 @@ type VexorConfig struct {
 	TimeoutSec int    `json:"timeout_sec"`
 }
 
+// CodeIndexConfig selects the semantic code search backend. "vexor" shells
+// out to the vexor CLI; "native" chunks and embeds source files in-process
+// with the provider from EmbeddingsConfig (Enabled need not be set) and
+// reindexes only changed files.
+type CodeIndexConfig struct {
+	Backend string `json:"backend"`
+	// Model overrides the embeddings model for code; empty uses
+	// embeddings.model.
+	Model string `json:"model,omitempty"`
+	// MaxFileKB skips larger files, which are mostly generated or vendored.
+	MaxFileKB int `json:"max_file_kb"`
+	// Exclude lists extra glob patterns, matched against the project-relative
+	// path and the base name, that are never indexed.
+	Exclude []string `json:"exclude,omitempty"`
+	// Symbols maintains the symbol index (definitions, references, imports)
+	// behind the symbols, callers and callees retrieve corpora. It works
+	// with either backend.
+	Symbols bool `json:"symbols"`
+}
+
+// GovernanceConfig adds project-specific sources to the governance index on
+// top of the built-in ones (rules, ADRs, CLAUDE.md, CODEOWNERS, OpenAPI
+// specs, lint configs, ...).
+type GovernanceConfig struct {
+	Include []GovernanceSource `json:"include,omitempty"`
+	// Exclude lists glob patterns, matched against the project-relative path
+	// and the base name, that are never indexed.
+	Exclude []string `json:"exclude,omitempty"`
+	// Watch reindexes governance files as soon as they change on disk
+	// instead of waiting for the next reindex.
+	Watch bool `json:"watch"`
+}
+
+// GovernanceSource indexes files matching Pattern (relative to the project
+// root; "**" spans directories) as DocType.
+type GovernanceSource struct {
+	Pattern string `json:"pattern"`
+	DocType string `json:"doc_type"`
+}
+
+// RetrievalConfig controls how /api/retrieve merges code, governance and
+// wiki hits. Fusion is "rrf" (reciprocal rank fusion, the default) or
+// "score" (per-source min-max normalised scores); either way Weights scales
+// each source's contribution.
+type RetrievalConfig struct {
+	Fusion string `json:"fusion"`
+	// RRFK is the rank offset k in 1/(k+rank); larger values flatten the
+	// advantage of top-ranked hits.
+	RRFK    int                `json:"rrf_k"`
+	Weights map[string]float64 `json:"weights,omitempty"`
+	// MaxPerFile caps how many hits from one file survive deduplication.
+	MaxPerFile int          `json:"max_per_file"`
+	Rerank     RerankConfig `json:"rerank"`
+	// PrefetchMaxTokens is the context budget for the block saved on each
+	// phase transition.
+	PrefetchMaxTokens int `json:"prefetch_max_tokens"`
+}
+
+// RerankConfig configures optional reranking of the top fused hits.
+// Provider "llm" asks an LLM (LLM falls back to the top-level llm block);
+// "http" calls a cross-encoder server exposing a TEI, Jina or Cohere style
+// /rerank endpoint.
+type RerankConfig struct {
+	Enabled    bool      `json:"enabled"`
+	Provider   string    `json:"provider"`
+	Endpoint   string    `json:"endpoint,omitempty"`
+	Model      string    `json:"model,omitempty"`
+	APIKey     string    `json:"api_key,omitempty"`
+	TopN       int       `json:"top_n"`
+	TimeoutSec int       `json:"timeout_sec"`
+	LLM        LLMConfig `json:"llm"`
+}
+
+// EmbeddingsConfig configures the embedding model used for semantic memory
+// search. Provider is "ollama" (native /api/embed) or any OpenAI-compatible
+// server ("openai", "lmstudio"); BaseURL defaults per provider like LLMConfig.
+type EmbeddingsConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	BaseURL  string `json:"base_url,omitempty"`
+	APIKey   string `json:"api_key,omitempty"`
+	Timeout  int    `json:"timeout,omitempty"` // seconds per request
+	// BatchSize is the number of texts sent per embedding request.
+	BatchSize int `json:"batch_size,omitempty"`
+	// BackfillIntervalMinutes is how often events without an embedding are
+	// embedded in the background. 0 disables the periodic backfill.
+	BackfillIntervalMinutes int `json:"backfill_interval_minutes"`
+}
+
+// MemoryConfig configures maintenance of the memory event store.
+type MemoryConfig struct {
+	Consolidation MemoryConsolidationConfig `json:"consolidation"`
+}
+
+// MemoryConsolidationConfig configures the scheduled consolidation pass that
+// merges similar events, decays stale low-importance ones and purges expired
+// TTL rows.
+type MemoryConsolidationConfig struct {
+	Enabled       bool `json:"enabled"`
+	IntervalHours int  `json:"interval_hours"`
+	// MaxEvents caps how many of the newest active events are clustered per run.
+	MaxEvents int `json:"max_events"`
+	// SimilarityThreshold is the cosine similarity at which two embedded
+	// events cluster; LexicalThreshold is the token Jaccard used when either
+	// event has no embedding.
+	SimilarityThreshold float64 `json:"similarity_threshold"`
+	LexicalThreshold    float64 `json:"lexical_threshold"`
+	MinClusterSize      int     `json:"min_cluster_size"`
+	MaxClusterSize      int     `json:"max_cluster_size"`
+	// Events below DecayMaxImportance and older than DecayGraceDays lose half
+	// their importance every DecayHalfLifeDays; once they fall below
+	// ArchiveBelow they are archived.
+	DecayHalfLifeDays  int       `json:"decay_half_life_days"`
+	DecayGraceDays     int       `json:"decay_grace_days"`
+	DecayMaxImportance float64   `json:"decay_max_importance"`
+	ArchiveBelow       float64   `json:"archive_below"`
+	LLM                LLMConfig `json:"llm"`
+}
+
+// TeamSyncConfig configures sharing memory between Stratus instances. Mode
+// "hub" pushes to and pulls from another instance at HubURL; mode "dir"
+// exchanges one JSONL journal per instance through a shared directory,
+// committing and pushing it when Git is set. Serve makes this instance a hub
+// for others, requiring Token as a bearer token when one is set.
+type TeamSyncConfig struct {
+	Enabled         bool   `json:"enabled"`
+	Mode            string `json:"mode"`
+	HubURL          string `json:"hub_url,omitempty"`
+	Dir             string `json:"dir,omitempty"`
+	Git             bool   `json:"git"`
+	Serve           bool   `json:"serve"`
+	Token           string `json:"token,omitempty"`
+	IntervalMinutes int    `json:"interval_minutes"`
+	// Scopes lists the memory event scopes that are shared.
+	Scopes    []string `json:"scopes"`
+	Docs      bool     `json:"docs"`
+	Proposals bool     `json:"proposals"`
+}
+
+// MCPConfig tunes the Streamable HTTP MCP endpoint served at /mcp.
+type MCPConfig struct {
+	// AllowedOrigins lists browser origins, besides localhost, that may call
+	// the endpoint, e.g. "https://app.example.com".
+	AllowedOrigins []string `json:"allowed_origins,omitempty"`
+}
+
+// WorkflowsConfig tunes the built-in workflow definitions.
+type WorkflowsConfig struct {
+	// GoChecks makes the built-in spec and bug workflows run `go build ./...`
+	// and `go test ./...` (in projects with a go.mod) before leaving the
+	// implement/fix and verify/review phases. The commands run synchronously
+	// on the transition request, so they are opt-in.
+	GoChecks bool `json:"go_checks"`
+}
+
 type STTConfig struct {
 	Endpoint string `json:"endpoint"`
 	Model    string `json:"model"`
@@ -346,6 +510,25 @@ func Default() Config {
 			Model:      "nomic-embed-text-v1.5",
 			TimeoutSec: 15,
 		},
+		CodeIndex: CodeIndexConfig{
+			Backend:   "vexor",
+			MaxFileKB: 256,
+			Symbols:   true,
+		},
+		Retrieval: RetrievalConfig{
+			Fusion:     "rrf",
+			RRFK:       60,
+			MaxPerFile: 2,
+			Rerank: RerankConfig{
+				Provider:   "llm",
+				TopN:       20,
+				TimeoutSec: 20,
+			},
+			PrefetchMaxTokens: 2000,
+		},
+		Governance: GovernanceConfig{
+			Watch: true,
+		},
 		STT: STTConfig{
 			Endpoint: "http://localhost:8011",
 			Model:    "matoog/whisper-large-v3-turbo-sk-ct2",
@@ -445,6 +628,40 @@ func Default() Config {
 		Learn: LearnConfig{
 			PipelineTimeoutSec: 180,
 		},
+		Embeddings: EmbeddingsConfig{
+			Enabled:                 false,
+			Provider:                "ollama",
+			Model:                   "nomic-embed-text",
+			Timeout:                 30,
+			BatchSize:               32,
+			BackfillIntervalMinutes: 10,
+		},
+		Memory: MemoryConfig{
+			Consolidation: MemoryConsolidationConfig{
+				Enabled:             false,
+				IntervalHours:       24,
+				MaxEvents:           2000,
+				SimilarityThreshold: 0.88,
+				LexicalThreshold:    0.6,
+				MinClusterSize:      2,
+				MaxClusterSize:      12,
+				DecayHalfLifeDays:   90,
+				DecayGraceDays:      14,
+				DecayMaxImportance:  0.7,
+				ArchiveBelow:        0.1,
+				LLM: LLMConfig{
+					Temperature: 0.2,
+					MaxTokens:   2048,
+				},
+			},
+		},
+		TeamSync: TeamSyncConfig{
+			Enabled:         false,
+			IntervalMinutes: 5,
+			Scopes:          []string{"global", "repo"},
+			Docs:            true,
+			Proposals:       true,
+		},
 	}
 }
 
@@ -0,0 +1,174 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// CodeChunk is one indexed piece of a source file: a function, type or
+// window of lines. Vector is only set when saving; Score only in search
+// results.
+type CodeChunk struct {
+	FilePath   string    `json:"file_path"`
+	ChunkIndex int       `json:"chunk_index"`
+	Kind       string    `json:"kind"`
+	Name       string    `json:"name"`
+	LineStart  int       `json:"line_start"`
+	LineEnd    int       `json:"line_end"`
+	Content    string    `json:"content"`
+	Vector     []float32 `json:"-"`
+	Score      float64   `json:"score,omitempty"`
+}
+
+// CodeIndexStats summarises the native code index for one model.
+type CodeIndexStats struct {
+	Model     string `json:"model"`
+	Files     int    `json:"files"`
+	Chunks    int    `json:"chunks"`
+	Stale     int    `json:"stale"`
+	IndexedAt string `json:"indexed_at,omitempty"`
+}
+
+// GetCodeFileHash returns the hash and model a file was last indexed with,
+// or empty strings if it is not indexed.
+func (d *DB) GetCodeFileHash(filePath string) (hash, model string, err error) {
+	err = d.sql.QueryRow(`SELECT file_hash, model FROM code_files WHERE file_path = ?`, filePath).Scan(&hash, &model)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("get code file hash: %w", err)
+	}
+	return hash, model, nil
+}
+
+// ReplaceCodeFile stores the chunks of filePath, replacing whatever was
+// indexed for it before. Vectors are L2-normalised on the way in.
+func (d *DB) ReplaceCodeFile(filePath, fileHash, model string, chunks []CodeChunk) error {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM code_chunks WHERE file_path = ?`, filePath); err != nil {
+		return fmt.Errorf("replace code file: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO code_files (file_path, file_hash, model, chunks, indexed_at)
+		VALUES (?, ?, ?, ?, strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+		ON CONFLICT(file_path) DO UPDATE SET
+			file_hash = excluded.file_hash, model = excluded.model,
+			chunks = excluded.chunks, indexed_at = excluded.indexed_at`,
+		filePath, fileHash, model, len(chunks)); err != nil {
+		return fmt.Errorf("replace code file: %w", err)
+	}
+	for i, c := range chunks {
+		if len(c.Vector) == 0 {
+			return fmt.Errorf("replace code file %s: chunk %d has no vector", filePath, i)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO code_chunks (file_path, chunk_index, kind, name, line_start, line_end, content, model, dims, vector)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			filePath, i, c.Kind, c.Name, c.LineStart, c.LineEnd, c.Content,
+			model, len(c.Vector), encodeVector(normalizeVector(c.Vector))); err != nil {
+			return fmt.Errorf("replace code file %s: %w", filePath, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteCodeFile removes a file and its chunks from the index.
+func (d *DB) DeleteCodeFile(filePath string) error {
+	if _, err := d.sql.Exec(`DELETE FROM code_chunks WHERE file_path = ?`, filePath); err != nil {
+		return fmt.Errorf("delete code file: %w", err)
+	}
+	if _, err := d.sql.Exec(`DELETE FROM code_files WHERE file_path = ?`, filePath); err != nil {
+		return fmt.Errorf("delete code file: %w", err)
+	}
+	return nil
+}
+
+// ListCodeFiles returns the paths of all indexed files.
+func (d *DB) ListCodeFiles() ([]string, error) {
+	rows, err := d.sql.Query(`SELECT file_path FROM code_files ORDER BY file_path`)
+	if err != nil {
+		return nil, fmt.Errorf("list code files: %w", err)
+	}
+	defer rows.Close()
+	var paths []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, rows.Err()
+}
+
+// SearchCodeChunks returns the limit chunks embedded with model that are
+// most similar to vec, best first. Chunks with non-positive similarity are
+// dropped.
+func (d *DB) SearchCodeChunks(model string, vec []float32, limit int) ([]CodeChunk, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	rows, err := d.sql.Query(`
+		SELECT file_path, chunk_index, kind, name, line_start, line_end, content, vector
+		FROM code_chunks
+		WHERE model = ? AND dims = ?`, model, len(vec))
+	if err != nil {
+		return nil, fmt.Errorf("search code chunks: %w", err)
+	}
+	defer rows.Close()
+
+	query := normalizeVector(vec)
+	var hits []CodeChunk
+	for rows.Next() {
+		var c CodeChunk
+		var blob []byte
+		if err := rows.Scan(&c.FilePath, &c.ChunkIndex, &c.Kind, &c.Name,
+			&c.LineStart, &c.LineEnd, &c.Content, &blob); err != nil {
+			return nil, fmt.Errorf("scan code chunk: %w", err)
+		}
+		v := decodeVector(blob)
+		if len(v) != len(query) {
+			continue
+		}
+		if sim := dot(query, v); sim > 0 {
+			c.Score = math.Min(sim, 1)
+			hits = append(hits, c)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// GetCodeIndexStats counts indexed files and chunks. Files indexed with a
+// model other than model are reported as stale.
+func (d *DB) GetCodeIndexStats(model string) (CodeIndexStats, error) {
+	s := CodeIndexStats{Model: model}
+	var indexedAt sql.NullString
+	err := d.sql.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM code_files WHERE model = ?),
+			(SELECT COUNT(*) FROM code_chunks WHERE model = ?),
+			(SELECT COUNT(*) FROM code_files WHERE model != ?),
+			(SELECT MAX(indexed_at) FROM code_files)`,
+		model, model, model).Scan(&s.Files, &s.Chunks, &s.Stale, &indexedAt)
+	if err != nil {
+		return s, fmt.Errorf("code index stats: %w", err)
+	}
+	s.IndexedAt = indexedAt.String
+	return s, nil
+}
@@ -299,6 +299,13 @@ SELECT id, title, content, doc_type FROM docs;
 	`ALTER TABLE wiki_pages ADD COLUMN workflow_id TEXT`,
 	`ALTER TABLE wiki_pages ADD COLUMN feature_slug TEXT`,
 	`CREATE UNIQUE INDEX IF NOT EXISTS wiki_pages_workflow_uniq ON wiki_pages(workflow_id, feature_slug) WHERE workflow_id IS NOT NULL`,
+	// memory consolidation: archived originals point at the consolidated event
+	`ALTER TABLE events ADD COLUMN archived_at TEXT`,
+	`ALTER TABLE events ADD COLUMN consolidated_into INTEGER`,
+	`ALTER TABLE events ADD COLUMN decayed_ms INTEGER`,
+	`CREATE INDEX IF NOT EXISTS idx_events_archived ON events(archived_at)`,
+	// secret redaction: number of secrets replaced before the row was written
+	`ALTER TABLE events ADD COLUMN redactions INTEGER NOT NULL DEFAULT 0`,
 }
 
 func isMigrationError(err error) bool {
@@ -0,0 +1,344 @@
+package db
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// HybridWeights controls how HybridSearchEvents blends its signals. Each
+// signal is normalised to [0, 1] before weighting.
+type HybridWeights struct {
+	Text       float64 `json:"text"`
+	Vector     float64 `json:"vector"`
+	Importance float64 `json:"importance"`
+	Recency    float64 `json:"recency"`
+}
+
+// DefaultHybridWeights favours semantic similarity, keeps keyword matches
+// strong and uses importance and recency as tie breakers.
+var DefaultHybridWeights = HybridWeights{Text: 0.35, Vector: 0.45, Importance: 0.1, Recency: 0.1}
+
+// recencyHalfLife is the age at which an event's recency signal halves.
+const recencyHalfLife = 30 * 24 * time.Hour
+
+// HybridSearchInput is the input for HybridSearchEvents.
+type HybridSearchInput struct {
+	SearchEventsInput
+	// Vector is the query embedding; nil ranks by keyword, importance and
+	// recency only.
+	Vector []float32
+	// Model selects which stored embeddings are comparable to Vector.
+	Model   string
+	Weights HybridWeights
+}
+
+// EmbeddingCoverage reports how many events have an embedding from model.
+type EmbeddingCoverage struct {
+	Model    string `json:"model"`
+	Events   int    `json:"events"`
+	Embedded int    `json:"embedded"`
+	Pending  int    `json:"pending"`
+}
+
+// SaveEventEmbedding stores the embedding of an event, replacing any earlier
+// one. The vector is L2-normalised on the way in.
+func (d *DB) SaveEventEmbedding(eventID int64, model string, vec []float32) error {
+	if len(vec) == 0 {
+		return fmt.Errorf("save event embedding %d: empty vector", eventID)
+	}
+	_, err := d.sql.Exec(`
+		INSERT INTO event_embeddings (event_id, model, dims, vector, created_at)
+		VALUES (?, ?, ?, ?, strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+		ON CONFLICT(event_id) DO UPDATE SET
+			model = excluded.model,
+			dims = excluded.dims,
+			vector = excluded.vector,
+			created_at = excluded.created_at`,
+		eventID, model, len(vec), encodeVector(normalizeVector(vec)))
+	if err != nil {
+		return fmt.Errorf("save event embedding %d: %w", eventID, err)
+	}
+	return nil
+}
+
+// EventsWithoutEmbedding returns up to limit active events after afterID
+// that have no embedding from model, oldest first, so a backfill makes steady
+// progress and can step past events it failed to embed.
+func (d *DB) EventsWithoutEmbedding(model string, afterID int64, limit int) ([]Event, error) {
+	if limit <= 0 {
+		limit = 32
+	}
+	rows, err := d.sql.Query(`
+		SELECT e.id, e.ts, e.actor, e.scope, e.type, e.text, e.title,
+		       e.tags, e.refs, e.ttl, e.importance, e.dedupe_key, e.project, e.session_id, e.created_ms
+		FROM events e
+		LEFT JOIN event_embeddings ee ON ee.event_id = e.id
+		WHERE e.id > ? AND e.archived_at IS NULL AND (ee.event_id IS NULL OR ee.model != ?)
+		ORDER BY e.id ASC
+		LIMIT ?`, afterID, model, limit)
+	if err != nil {
+		return nil, fmt.Errorf("events without embedding: %w", err)
+	}
+	defer rows.Close()
+	return scanEvents(rows)
+}
+
+// GetEmbeddingCoverage counts events and how many of them carry an embedding
+// from model.
+func (d *DB) GetEmbeddingCoverage(model string) (EmbeddingCoverage, error) {
+	c := EmbeddingCoverage{Model: model}
+	err := d.sql.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM events WHERE archived_at IS NULL),
+			(SELECT COUNT(*) FROM event_embeddings ee JOIN events e ON e.id = ee.event_id
+			 WHERE ee.model = ? AND e.archived_at IS NULL)`, model).Scan(&c.Events, &c.Embedded)
+	if err != nil {
+		return c, fmt.Errorf("embedding coverage: %w", err)
+	}
+	c.Pending = c.Events - c.Embedded
+	if c.Pending < 0 {
+		c.Pending = 0
+	}
+	return c, nil
+}
+
+// HybridSearchEvents ranks events by a weighted blend of BM25 keyword
+// relevance, cosine similarity to in.Vector, importance and recency.
+// Candidates are the union of the top keyword and top vector matches, so an
+// event can surface on meaning alone even when it shares no terms with the
+// query. Filters apply to both candidate sets.
+func (d *DB) HybridSearchEvents(in HybridSearchInput) ([]Event, error) {
+	if in.Limit <= 0 {
+		in.Limit = 20
+	}
+	if in.Weights == (HybridWeights{}) {
+		in.Weights = DefaultHybridWeights
+	}
+	candidates := (in.Limit + in.Offset) * 5
+	if candidates < 100 {
+		candidates = 100
+	}
+
+	textScores, err := d.keywordCandidates(in.SearchEventsInput, candidates)
+	if err != nil {
+		return nil, err
+	}
+	vecScores := map[int64]float64{}
+	if len(in.Vector) > 0 {
+		if vecScores, err = d.vectorCandidates(in, candidates); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]int64, 0, len(textScores)+len(vecScores))
+	for id := range textScores {
+		ids = append(ids, id)
+	}
+	for id := range vecScores {
+		if _, ok := textScores[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return []Event{}, nil
+	}
+	events, err := d.GetEventsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range events {
+		e := &events[i]
+		age := now.Sub(time.UnixMilli(e.CreatedMs))
+		if age < 0 {
+			age = 0
+		}
+		recency := math.Exp2(-float64(age) / float64(recencyHalfLife))
+		e.Score = in.Weights.Text*textScores[e.ID] +
+			in.Weights.Vector*vecScores[e.ID] +
+			in.Weights.Importance*clamp01(e.Importance) +
+			in.Weights.Recency*recency
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Score != events[j].Score {
+			return events[i].Score > events[j].Score
+		}
+		return events[i].ID > events[j].ID
+	})
+
+	if in.Offset >= len(events) {
+		return []Event{}, nil
+	}
+	events = events[in.Offset:]
+	if len(events) > in.Limit {
+		events = events[:in.Limit]
+	}
+	return events, nil
+}
+
+// keywordCandidates returns the top FTS matches with their BM25 score
+// min-max normalised to [0, 1].
+func (d *DB) keywordCandidates(in SearchEventsInput, limit int) (map[int64]float64, error) {
+	scores := map[int64]float64{}
+	ftsQuery := buildFTS5Query(in.Query)
+	if ftsQuery == "" {
+		return scores, nil
+	}
+	rows, err := d.sql.Query(`
+		SELECT e.id, bm25(events_fts)
+		FROM events_fts f
+		JOIN events e ON e.id = f.rowid
+		WHERE events_fts MATCH ?
+		  AND e.archived_at IS NULL
+		  AND (? = '' OR e.type = ?)
+		  AND (? = '' OR e.scope = ?)
+		  AND (? = '' OR e.project = ?)
+		  AND (? = '' OR e.ts >= ?)
+		  AND (? = '' OR e.ts <= ?)
+		ORDER BY bm25(events_fts)
+		LIMIT ?`,
+		ftsQuery,
+		in.Type, in.Type,
+		in.Scope, in.Scope,
+		in.Project, in.Project,
+		in.DateStart, in.DateStart,
+		in.DateEnd, in.DateEnd,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("hybrid keyword candidates: %w", err)
+	}
+	defer rows.Close()
+	// bm25() is lower-is-better; flip the sign so higher means more relevant.
+	raw := map[int64]float64{}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for rows.Next() {
+		var id int64
+		var rank float64
+		if err := rows.Scan(&id, &rank); err != nil {
+			return nil, fmt.Errorf("scan keyword candidate: %w", err)
+		}
+		raw[id] = -rank
+		lo = math.Min(lo, -rank)
+		hi = math.Max(hi, -rank)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for id, v := range raw {
+		if hi > lo {
+			scores[id] = (v - lo) / (hi - lo)
+		} else {
+			scores[id] = 1
+		}
+	}
+	return scores, nil
+}
+
+// vectorCandidates scans the stored embeddings for in.Model and returns the
+// most similar events with their cosine similarity, clamped at zero.
+func (d *DB) vectorCandidates(in HybridSearchInput, limit int) (map[int64]float64, error) {
+	rows, err := d.sql.Query(`
+		SELECT ee.event_id, ee.vector
+		FROM event_embeddings ee
+		JOIN events e ON e.id = ee.event_id
+		WHERE ee.model = ? AND ee.dims = ?
+		  AND e.archived_at IS NULL
+		  AND (? = '' OR e.type = ?)
+		  AND (? = '' OR e.scope = ?)
+		  AND (? = '' OR e.project = ?)
+		  AND (? = '' OR e.ts >= ?)
+		  AND (? = '' OR e.ts <= ?)`,
+		in.Model, len(in.Vector),
+		in.Type, in.Type,
+		in.Scope, in.Scope,
+		in.Project, in.Project,
+		in.DateStart, in.DateStart,
+		in.DateEnd, in.DateEnd,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("hybrid vector candidates: %w", err)
+	}
+	defer rows.Close()
+
+	query := normalizeVector(in.Vector)
+	type hit struct {
+		id  int64
+		sim float64
+	}
+	var hits []hit
+	for rows.Next() {
+		var id int64
+		var blob []byte
+		if err := rows.Scan(&id, &blob); err != nil {
+			return nil, fmt.Errorf("scan vector candidate: %w", err)
+		}
+		vec := decodeVector(blob)
+		if len(vec) != len(query) {
+			continue
+		}
+		if sim := dot(query, vec); sim > 0 {
+			hits = append(hits, hit{id, sim})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].sim > hits[j].sim })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	scores := make(map[int64]float64, len(hits))
+	for _, h := range hits {
+		scores[h.id] = math.Min(h.sim, 1)
+	}
+	return scores, nil
+}
+
+func encodeVector(vec []float32) []byte {
+	buf := make([]byte, 4*len(vec))
+	for i, v := range vec {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	vec := make([]float32, len(buf)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vec
+}
+
+func normalizeVector(vec []float32) []float32 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	out := make([]float32, len(vec))
+	if sum == 0 {
+		return out
+	}
+	norm := math.Sqrt(sum)
+	for i, v := range vec {
+		out[i] = float32(float64(v) / norm)
+	}
+	return out
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestHybridSearchEvents(t *testing.T) {
+	d := openTestDB(t)
+	save := func(title, text string, importance float64) int64 {
+		id, err := d.SaveEvent(SaveEventInput{Title: title, Text: text, Importance: importance})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	keyword := save("Retry policy", "HTTP client retries use exponential backoff", 0.5)
+	semantic := save("Flaky network calls", "wrap outbound requests so transient failures are attempted again", 0.9)
+	unrelated := save("Color palette", "the dashboard uses a dark theme", 0.5)
+
+	model := "test-model"
+	for id, vec := range map[int64][]float32{
+		keyword:   {0.6, 0.8, 0},
+		semantic:  {1, 0.1, 0},
+		unrelated: {0, 0, 1},
+	} {
+		if err := d.SaveEventEmbedding(id, model, vec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cov, err := d.GetEmbeddingCoverage(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cov.Events != 3 || cov.Embedded != 3 || cov.Pending != 0 {
+		t.Errorf("coverage = %+v", cov)
+	}
+
+	got, err := d.HybridSearchEvents(HybridSearchInput{
+		SearchEventsInput: SearchEventsInput{Query: "retries backoff"},
+		Vector:            []float32{1, 0, 0},
+		Model:             model,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d results, want keyword + semantic match: %+v", len(got), got)
+	}
+	ids := map[int64]bool{got[0].ID: true, got[1].ID: true}
+	if !ids[keyword] || !ids[semantic] {
+		t.Errorf("results = %d, %d; want %d and %d", got[0].ID, got[1].ID, keyword, semantic)
+	}
+	if got[0].Score <= 0 || got[0].Score < got[1].Score {
+		t.Errorf("scores not descending: %v, %v", got[0].Score, got[1].Score)
+	}
+
+	// Without a vector the keyword match alone is returned.
+	kw, err := d.HybridSearchEvents(HybridSearchInput{SearchEventsInput: SearchEventsInput{Query: "retries backoff"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kw) != 1 || kw[0].ID != keyword {
+		t.Errorf("keyword-only = %+v", kw)
+	}
+}
+
+func TestEventsWithoutEmbedding(t *testing.T) {
+	d := openTestDB(t)
+	a, _ := d.SaveEvent(SaveEventInput{Text: "first"})
+	b, _ := d.SaveEvent(SaveEventInput{Text: "second"})
+	if err := d.SaveEventEmbedding(a, "m1", []float32{1, 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := d.EventsWithoutEmbedding("m1", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ID != b {
+		t.Errorf("pending for m1 = %+v", pending)
+	}
+	// A model switch makes every event pending again.
+	pending, _ = d.EventsWithoutEmbedding("m2", 0, 10)
+	if len(pending) != 2 {
+		t.Errorf("pending for m2 = %d, want 2", len(pending))
+	}
+}
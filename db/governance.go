@@ -3,9 +3,11 @@ package db
 import (
 	"crypto/sha256"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -24,11 +26,32 @@ type Doc struct {
 	Score      float64 `json:"score,omitempty"`
 }
 
-// docGlobs maps glob patterns to doc types.
-var docGlobs = []struct {
-	pattern string
-	docType string
-}{
+// DocSource is a governance source: a glob relative to the project root
+// ("**" matches any number of directories) and the doc type its files are
+// indexed as.
+type DocSource struct {
+	Pattern string `json:"pattern"`
+	DocType string `json:"doc_type"`
+}
+
+// GovernanceOptions extends the built-in sources. Sources are tried after
+// the built-in ones, so they cannot retype a built-in file. Exclude globs
+// match the project-relative path or the base name.
+type GovernanceOptions struct {
+	Sources []DocSource
+	Exclude []string
+}
+
+// GovernanceReport counts what an indexing pass did.
+type GovernanceReport struct {
+	Indexed   int `json:"indexed"`
+	Unchanged int `json:"unchanged"`
+	Removed   int `json:"removed"`
+}
+
+// docGlobs maps glob patterns to doc types. The first matching pattern
+// decides a file's type.
+var docGlobs = []DocSource{
 	{".claude/rules/*.md", "rule"},
 	{"docs/decisions/*.md", "adr"},
 	{".claude/templates/*.md", "template"},
@@ -37,26 +60,182 @@ var docGlobs = []struct {
 	{"docs/architecture/*.md", "architecture"},
 	{"**/CLAUDE.md", "project"},
 	{"README.md", "project"},
+	{"CONTRIBUTING.md", "contributing"},
+	{".github/CONTRIBUTING.md", "contributing"},
+	{"docs/CONTRIBUTING.md", "contributing"},
+	{"CODEOWNERS", "codeowners"},
+	{".github/CODEOWNERS", "codeowners"},
+	{"docs/CODEOWNERS", "codeowners"},
+	{".editorconfig", "editorconfig"},
+	{"**/openapi.json", "api-spec"},
+	{"**/openapi.yaml", "api-spec"},
+	{"**/openapi.yml", "api-spec"},
+	{"**/swagger.json", "api-spec"},
+	{"**/swagger.yaml", "api-spec"},
+	{"**/swagger.yml", "api-spec"},
+	{".golangci.*", "lint"},
+	{".eslintrc*", "lint"},
+	{"eslint.config.*", "lint"},
+	{".prettierrc*", "lint"},
+	{".stylelintrc*", "lint"},
+	{".markdownlint*", "lint"},
+	{"biome.json", "lint"},
+	{"ruff.toml", "lint"},
+	{".flake8", "lint"},
+	{".rubocop.yml", "lint"},
+}
+
+// DefaultDocSources returns the built-in governance sources.
+func DefaultDocSources() []DocSource {
+	return append([]DocSource(nil), docGlobs...)
 }
 
-// IndexGovernance indexes governance docs from the given project root.
+// skipDocDirs are not descended into by "**" patterns.
+var skipDocDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "dist": true, "build": true,
+	"target": true, "venv": true, ".venv": true, "__pycache__": true,
+}
+
+// IndexGovernance indexes governance docs from the given project root using
+// the built-in sources.
 func (d *DB) IndexGovernance(projectRoot string) error {
-	for _, g := range docGlobs {
-		matches, err := findFiles(projectRoot, g.pattern)
+	_, err := d.IndexGovernanceWith(projectRoot, GovernanceOptions{})
+	return err
+}
+
+// IndexGovernanceWith walks every source, reindexes files whose content
+// changed and tombstones indexed files that are gone or now excluded.
+// Unreadable files are skipped; the pass is best-effort.
+func (d *DB) IndexGovernanceWith(projectRoot string, opts GovernanceOptions) (GovernanceReport, error) {
+	var report GovernanceReport
+	seen := map[string]bool{}
+	for _, g := range append(DefaultDocSources(), opts.Sources...) {
+		matches, err := findFiles(projectRoot, g.Pattern)
 		if err != nil {
 			continue
 		}
 		for _, path := range matches {
-			if err := d.indexFile(path, g.docType, projectRoot); err != nil {
-				// Best-effort: log and continue
-				_ = err
+			if seen[path] || docExcluded(projectRoot, path, opts.Exclude) {
+				continue
 			}
+			seen[path] = true
+			_ = d.indexFile(path, g.DocType, projectRoot, &report)
 		}
 	}
-	// Remove stale entries using SQLite datetime arithmetic (avoids format mismatch).
-	_, _ = d.sql.Exec(`DELETE FROM docs WHERE project = ? AND indexed_at < datetime('now', '-24 hours')`,
-		projectRoot)
-	return nil
+
+	indexed, err := d.indexedDocFiles(projectRoot)
+	if err != nil {
+		return report, err
+	}
+	for path, docType := range indexed {
+		if seen[path] {
+			continue
+		}
+		if err := d.tombstoneDoc(path, projectRoot, docType); err != nil {
+			return report, err
+		}
+		report.Removed++
+	}
+	return report, nil
+}
+
+// IndexGovernancePaths brings the given files up to date: changed files are
+// reindexed, deleted or excluded ones tombstoned and files that match no
+// source ignored. Paths may be absolute or relative to projectRoot.
+func (d *DB) IndexGovernancePaths(projectRoot string, opts GovernanceOptions, paths []string) (GovernanceReport, error) {
+	var report GovernanceReport
+	for _, p := range paths {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(projectRoot, p)
+		}
+		p = filepath.Clean(p)
+		docType, ok := GovernanceDocType(projectRoot, opts, p)
+		info, statErr := os.Stat(p)
+		if ok && statErr == nil && info.Mode().IsRegular() {
+			if err := d.indexFile(p, docType, projectRoot, &report); err != nil {
+				return report, err
+			}
+			continue
+		}
+		var existing string
+		err := d.sql.QueryRow(`SELECT doc_type FROM docs WHERE file_path = ? LIMIT 1`, p).Scan(&existing)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return report, fmt.Errorf("index governance paths: %w", err)
+		}
+		if err := d.tombstoneDoc(p, projectRoot, existing); err != nil {
+			return report, err
+		}
+		report.Removed++
+	}
+	return report, nil
+}
+
+// GovernanceDocType reports the doc type path would be indexed as, or false
+// when no source matches it or it is excluded.
+func GovernanceDocType(projectRoot string, opts GovernanceOptions, path string) (string, bool) {
+	rel, err := filepath.Rel(projectRoot, path)
+	if err != nil || strings.HasPrefix(rel, "..") || docExcluded(projectRoot, path, opts.Exclude) {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, g := range append(DefaultDocSources(), opts.Sources...) {
+		if matchDocPattern(g.Pattern, rel) {
+			return g.DocType, true
+		}
+	}
+	return "", false
+}
+
+// matchDocPattern matches a project-relative slash path against a source
+// pattern the same way findFiles expands it.
+func matchDocPattern(pattern, rel string) bool {
+	if !strings.Contains(pattern, "**") {
+		ok, _ := path.Match(pattern, rel)
+		return ok
+	}
+	parts := strings.SplitN(pattern, "**", 2)
+	base := strings.TrimSuffix(path.Clean(parts[0]), "/")
+	suffix := strings.TrimPrefix(parts[1], "/")
+	if base != "." {
+		if !strings.HasPrefix(rel, base+"/") {
+			return false
+		}
+		rel = strings.TrimPrefix(rel, base+"/")
+	}
+	for _, dir := range strings.Split(path.Dir(rel), "/") {
+		if skipDocDirs[dir] {
+			return false
+		}
+	}
+	ok, _ := path.Match(suffix, path.Base(rel))
+	return ok
+}
+
+func docExcluded(projectRoot, p string, exclude []string) bool {
+	if len(exclude) == 0 {
+		return false
+	}
+	rel, err := filepath.Rel(projectRoot, p)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range exclude {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+		// A directory pattern excludes everything below it.
+		if strings.HasPrefix(rel, strings.TrimSuffix(pattern, "/")+"/") {
+			return true
+		}
+	}
+	return false
 }
 
 // findFiles returns files matching relPattern under root.
@@ -70,10 +249,17 @@ func findFiles(root, relPattern string) ([]string, error) {
 	parts := strings.SplitN(relPattern, "**", 2)
 	baseDir := filepath.Join(root, filepath.Clean(parts[0]))
 	suffix := strings.TrimPrefix(parts[1], string(filepath.Separator))
+	suffix = strings.TrimPrefix(suffix, "/")
 
 	var matches []string
 	_ = filepath.WalkDir(baseDir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil || d.IsDir() {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != baseDir && skipDocDirs[d.Name()] {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 		matched, _ := filepath.Match(suffix, d.Name())
@@ -85,36 +271,106 @@ func findFiles(root, relPattern string) ([]string, error) {
 	return matches, nil
 }
 
-func (d *DB) indexFile(path, docType, project string) error {
+// indexFile reindexes path when its content or doc type changed since it
+// was last indexed, and clears any tombstone for it.
+func (d *DB) indexFile(path, docType, project string, report *GovernanceReport) error {
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
 	hash := fmt.Sprintf("%x", sha256.Sum256(content))
 
-	// Check if unchanged
-	var existingHash string
-	err = d.sql.QueryRow(`SELECT file_hash FROM docs WHERE file_path = ? AND chunk_index = 0 LIMIT 1`, path).Scan(&existingHash)
-	if err == nil && existingHash == hash {
-		return nil // unchanged
+	var existingHash, existingType string
+	err = d.sql.QueryRow(`SELECT file_hash, doc_type FROM docs WHERE file_path = ? AND chunk_index = 0 LIMIT 1`, path).
+		Scan(&existingHash, &existingType)
+	if err == nil && existingHash == hash && existingType == docType {
+		report.Unchanged++
+		return nil
+	}
+
+	var chunks []Doc
+	for _, c := range chunkDoc(path, string(content)) {
+		chunks = append(chunks, Doc{Title: c.title, Content: c.content})
+	}
+	if err := d.ReplaceDocChunks(path, project, docType, hash, chunks); err != nil {
+		return err
 	}
+	if _, err := d.sql.Exec(`DELETE FROM doc_tombstones WHERE file_path = ?`, path); err != nil {
+		return fmt.Errorf("clear doc tombstone: %w", err)
+	}
+	report.Indexed++
+	return nil
+}
 
-	// Delete old chunks for this file
-	_, _ = d.sql.Exec(`DELETE FROM docs WHERE file_path = ?`, path)
+// indexedDocFiles maps the locally indexed files of project to their doc
+// type. Docs received through team sync are not included.
+func (d *DB) indexedDocFiles(project string) (map[string]string, error) {
+	rows, err := d.sql.Query(`
+		SELECT file_path, MIN(doc_type) FROM docs
+		WHERE project = ? AND file_path NOT LIKE ? || '%'
+		GROUP BY file_path`, project, SyncedDocPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("list indexed docs: %w", err)
+	}
+	defer rows.Close()
+	out := map[string]string{}
+	for rows.Next() {
+		var p, t string
+		if err := rows.Scan(&p, &t); err != nil {
+			return nil, err
+		}
+		out[p] = t
+	}
+	return out, rows.Err()
+}
 
-	// Chunk by ## headers
-	chunks := chunkMarkdown(string(content))
-	for i, chunk := range chunks {
-		_, err := d.sql.Exec(`
-			INSERT OR REPLACE INTO docs (file_path, chunk_index, title, content, doc_type, file_hash, project)
-			VALUES (?, ?, ?, ?, ?, ?, ?)`,
-			path, i, chunk.title, chunk.content, docType, hash, project,
-		)
-		if err != nil {
-			return fmt.Errorf("insert doc chunk: %w", err)
+// tombstoneDoc drops a file's chunks and records that it was removed.
+func (d *DB) tombstoneDoc(path, project, docType string) error {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM docs WHERE file_path = ?`, path); err != nil {
+		return fmt.Errorf("tombstone doc: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO doc_tombstones (file_path, project, doc_type, deleted_at)
+		VALUES (?, ?, ?, strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+		ON CONFLICT(file_path) DO UPDATE SET
+			project = excluded.project, doc_type = excluded.doc_type, deleted_at = excluded.deleted_at`,
+		path, project, docType); err != nil {
+		return fmt.Errorf("tombstone doc: %w", err)
+	}
+	return tx.Commit()
+}
+
+// DocTombstone is a governance file that was indexed and then removed.
+type DocTombstone struct {
+	FilePath  string `json:"file_path"`
+	Project   string `json:"project"`
+	DocType   string `json:"doc_type"`
+	DeletedAt string `json:"deleted_at"`
+}
+
+// ListDocTombstones returns removed governance files, most recent first.
+func (d *DB) ListDocTombstones() ([]DocTombstone, error) {
+	rows, err := d.sql.Query(`
+		SELECT file_path, project, doc_type, deleted_at FROM doc_tombstones
+		ORDER BY deleted_at DESC, file_path`)
+	if err != nil {
+		return nil, fmt.Errorf("list doc tombstones: %w", err)
+	}
+	defer rows.Close()
+	var out []DocTombstone
+	for rows.Next() {
+		var t DocTombstone
+		if err := rows.Scan(&t.FilePath, &t.Project, &t.DocType, &t.DeletedAt); err != nil {
+			return nil, err
 		}
+		out = append(out, t)
 	}
-	return nil
+	return out, rows.Err()
 }
 
 type markdownChunk struct {
@@ -252,8 +508,87 @@ func (d *DB) GovernanceStats() (map[string]any, error) {
 		}
 	}
 
+	var files, deleted int
+	_ = d.sql.QueryRow(`SELECT COUNT(DISTINCT file_path) FROM docs`).Scan(&files)
+	_ = d.sql.QueryRow(`SELECT COUNT(*) FROM doc_tombstones`).Scan(&deleted)
+
 	return map[string]any{
-		"total_chunks": total,
-		"by_type":      byType,
+		"total_chunks":  total,
+		"total_files":   files,
+		"deleted_files": deleted,
+		"by_type":       byType,
 	}, nil
 }
+
+// GovernanceFile is an indexed governance document with its chunks reassembled.
+type GovernanceFile struct {
+	FilePath  string `json:"file_path"`
+	DocType   string `json:"doc_type"`
+	Title     string `json:"title"`
+	Chunks    int    `json:"chunks"`
+	Content   string `json:"content,omitempty"`
+	IndexedAt string `json:"indexed_at"`
+}
+
+// ListGovernanceFiles returns one entry per indexed governance file for the
+// project, without content. The title is taken from the first chunk.
+func (d *DB) ListGovernanceFiles(project string) ([]GovernanceFile, error) {
+	rows, err := d.sql.Query(`
+		SELECT file_path, doc_type,
+		       COALESCE((SELECT title FROM docs d2 WHERE d2.file_path = docs.file_path ORDER BY chunk_index LIMIT 1), ''),
+		       COUNT(*), MAX(indexed_at)
+		FROM docs
+		WHERE (? = '' OR project = ?)
+		GROUP BY file_path, doc_type
+		ORDER BY doc_type, file_path`,
+		project, project,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list governance files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []GovernanceFile
+	for rows.Next() {
+		var f GovernanceFile
+		if err := rows.Scan(&f.FilePath, &f.DocType, &f.Title, &f.Chunks, &f.IndexedAt); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// GetGovernanceFile reassembles all chunks of an indexed governance file in
+// chunk order. Returns nil, nil when the file is not indexed.
+func (d *DB) GetGovernanceFile(filePath string) (*GovernanceFile, error) {
+	rows, err := d.sql.Query(`
+		SELECT chunk_index, title, content, doc_type, indexed_at
+		FROM docs WHERE file_path = ? ORDER BY chunk_index`, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("get governance file: %w", err)
+	}
+	defer rows.Close()
+
+	var f *GovernanceFile
+	var parts []string
+	for rows.Next() {
+		var idx int
+		var title, content, docType, indexedAt string
+		if err := rows.Scan(&idx, &title, &content, &docType, &indexedAt); err != nil {
+			return nil, err
+		}
+		if f == nil {
+			f = &GovernanceFile{FilePath: filePath, DocType: docType, Title: title, IndexedAt: indexedAt}
+		}
+		f.Chunks++
+		parts = append(parts, content)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if f != nil {
+		f.Content = strings.Join(parts, "\n\n")
+	}
+	return f, nil
+}
@@ -0,0 +1,243 @@
+package db
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxDocChunk is the size small neighbouring sections are merged up to, so
+// a config file with dozens of one-line keys does not become dozens of rows.
+const maxDocChunk = 1500
+
+// chunkDoc splits a governance file into searchable chunks, picking the
+// splitter from the file name.
+func chunkDoc(path, content string) []markdownChunk {
+	base := filepath.Base(path)
+	lower := strings.ToLower(base)
+	ext := strings.ToLower(filepath.Ext(base))
+	name := strings.TrimSuffix(lower, ext)
+
+	switch {
+	case ext == ".md" || ext == ".markdown":
+		return chunkMarkdown(content)
+	case base == "CODEOWNERS":
+		return mergeChunks(chunkBlocks(content, "#"))
+	case base == ".editorconfig":
+		return mergeChunks(chunkINI(content))
+	case (name == "openapi" || name == "swagger") && ext == ".json":
+		if chunks := chunkOpenAPIJSON(content); len(chunks) > 0 {
+			return chunks
+		}
+	case name == "openapi" || name == "swagger":
+		return chunkOpenAPIYAML(content)
+	}
+
+	var chunks []markdownChunk
+	switch {
+	case ext == ".json" || (strings.HasSuffix(base, "rc") && strings.HasPrefix(strings.TrimSpace(content), "{")):
+		chunks = chunkJSON(content)
+	case ext == ".yml" || ext == ".yaml":
+		chunks = chunkTopLevel(content, "#")
+	case ext == ".toml" || ext == ".ini" || ext == ".cfg" || base == ".flake8":
+		chunks = chunkINI(content)
+	}
+	if len(chunks) == 0 {
+		chunks = chunkBlocks(content, "#")
+	}
+	return mergeChunks(chunks)
+}
+
+// chunkBlocks splits on blank lines. A block's title is its leading comment,
+// if any.
+func chunkBlocks(content, comment string) []markdownChunk {
+	var chunks []markdownChunk
+	for _, block := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n\n") {
+		text := strings.TrimSpace(block)
+		if text == "" {
+			continue
+		}
+		title := ""
+		if first := strings.SplitN(text, "\n", 2)[0]; strings.HasPrefix(first, comment) {
+			title = strings.TrimSpace(strings.TrimPrefix(first, comment))
+		}
+		chunks = append(chunks, markdownChunk{title: title, content: text})
+	}
+	return chunks
+}
+
+// chunkINI splits INI-style files (.editorconfig, TOML, setup.cfg) on
+// [section] headers.
+func chunkINI(content string) []markdownChunk {
+	var chunks []markdownChunk
+	title := ""
+	var lines []string
+	flush := func() {
+		if text := strings.TrimSpace(strings.Join(lines, "\n")); text != "" {
+			chunks = append(chunks, markdownChunk{title: title, content: text})
+		}
+	}
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			flush()
+			title = strings.Trim(trimmed, "[]")
+			lines = []string{line}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return chunks
+}
+
+// chunkTopLevel splits YAML on unindented keys, keeping any comment lines
+// directly above a key with it.
+func chunkTopLevel(content, comment string) []markdownChunk {
+	var chunks []markdownChunk
+	title := ""
+	var lines, pending []string
+	flush := func() {
+		if text := strings.TrimSpace(strings.Join(lines, "\n")); text != "" {
+			chunks = append(chunks, markdownChunk{title: title, content: text})
+		}
+	}
+	for _, line := range strings.Split(content, "\n") {
+		switch {
+		case strings.HasPrefix(line, comment):
+			pending = append(pending, line)
+			continue
+		case line != "" && line[0] != ' ' && line[0] != '\t' && line[0] != '-' && strings.Contains(line, ":"):
+			flush()
+			title = strings.TrimSpace(strings.SplitN(line, ":", 2)[0])
+			lines = append(pending, line)
+		default:
+			lines = append(lines, pending...)
+			lines = append(lines, line)
+		}
+		pending = nil
+	}
+	lines = append(lines, pending...)
+	flush()
+	return chunks
+}
+
+// chunkJSON makes one chunk per top-level key of a JSON object.
+func chunkJSON(content string) []markdownChunk {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &obj); err != nil {
+		return nil
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var chunks []markdownChunk
+	for _, k := range keys {
+		chunks = append(chunks, markdownChunk{title: k, content: k + ": " + indentJSON(obj[k])})
+	}
+	return chunks
+}
+
+// chunkOpenAPIJSON makes one chunk per operation ("GET /orders") plus one
+// for the document's info block.
+func chunkOpenAPIJSON(content string) []markdownChunk {
+	var spec struct {
+		Info  json.RawMessage                       `json:"info"`
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(content), &spec); err != nil || len(spec.Paths) == 0 {
+		return nil
+	}
+	var chunks []markdownChunk
+	if len(spec.Info) > 0 {
+		chunks = append(chunks, markdownChunk{title: "info", content: indentJSON(spec.Info)})
+	}
+	paths := make([]string, 0, len(spec.Paths))
+	for p := range spec.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		methods := make([]string, 0, len(spec.Paths[p]))
+		for m := range spec.Paths[p] {
+			methods = append(methods, m)
+		}
+		sort.Strings(methods)
+		for _, m := range methods {
+			title := strings.ToUpper(m) + " " + p
+			chunks = append(chunks, markdownChunk{title: title, content: title + "\n" + indentJSON(spec.Paths[p][m])})
+		}
+	}
+	return chunks
+}
+
+// chunkOpenAPIYAML makes one chunk per entry under "paths:" and one per
+// other top-level key.
+func chunkOpenAPIYAML(content string) []markdownChunk {
+	var chunks []markdownChunk
+	for _, top := range chunkTopLevel(content, "#") {
+		if top.title != "paths" {
+			chunks = append(chunks, top)
+			continue
+		}
+		title := ""
+		var lines []string
+		flush := func() {
+			if text := strings.TrimSpace(strings.Join(lines, "\n")); text != "" && title != "" {
+				chunks = append(chunks, markdownChunk{title: title, content: text})
+			}
+		}
+		indent := -1
+		inPaths := false
+		for _, line := range strings.Split(top.content, "\n") {
+			if !inPaths {
+				inPaths = strings.HasPrefix(line, "paths:")
+				continue
+			}
+			trimmed := strings.TrimLeft(line, " ")
+			n := len(line) - len(trimmed)
+			if trimmed != "" && !strings.HasPrefix(trimmed, "#") && (indent < 0 || n == indent) {
+				indent = n
+				flush()
+				title = strings.Trim(strings.TrimSuffix(strings.TrimSpace(trimmed), ":"), `"'`)
+				lines = nil
+			}
+			lines = append(lines, line)
+		}
+		flush()
+	}
+	return chunks
+}
+
+func indentJSON(raw json.RawMessage) string {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return string(raw)
+	}
+	return string(out)
+}
+
+// mergeChunks joins neighbouring chunks while the result stays under
+// maxDocChunk. The merged chunk keeps the first non-empty title.
+func mergeChunks(chunks []markdownChunk) []markdownChunk {
+	var out []markdownChunk
+	for _, c := range chunks {
+		if n := len(out); n > 0 && len(out[n-1].content)+len(c.content)+2 <= maxDocChunk {
+			last := &out[n-1]
+			last.content += "\n\n" + c.content
+			if last.title == "" {
+				last.title = c.title
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeGovFile(t *testing.T, root, rel, content string) string {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestIndexGovernance_IncrementalAndTombstones(t *testing.T) {
+	d := openTestDB(t)
+	root := t.TempDir()
+	writeGovFile(t, root, ".claude/rules/errors.md", "# Errors\n\n## Wrapping\nWrap errors with %w.\n")
+	writeGovFile(t, root, ".github/CODEOWNERS", "# Backend\n/api/ @backend-team\n\n# Frontend\n/frontend/ @web-team\n")
+	writeGovFile(t, root, ".editorconfig", "root = true\n\n[*.go]\nindent_style = tab\n")
+	writeGovFile(t, root, "api/openapi.json", `{"info":{"title":"Shop"},"paths":{"/orders":{"get":{"summary":"List orders"},"post":{"summary":"Create order"}}}}`)
+	writeGovFile(t, root, "node_modules/pkg/openapi.json", `{"paths":{"/x":{"get":{}}}}`)
+	writeGovFile(t, root, "policies/retention.txt", "Logs are kept for 30 days.")
+	writeGovFile(t, root, ".claude/rules/draft.md", "# Draft\n\nNot ready.")
+
+	opts := GovernanceOptions{
+		Sources: []DocSource{{Pattern: "policies/*.txt", DocType: "policy"}},
+		Exclude: []string{"draft.md"},
+	}
+	report, err := d.IndexGovernanceWith(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Indexed != 5 || report.Unchanged != 0 || report.Removed != 0 {
+		t.Fatalf("first pass = %+v", report)
+	}
+
+	files, err := d.ListGovernanceFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := map[string]string{}
+	for _, f := range files {
+		rel, _ := filepath.Rel(root, f.FilePath)
+		types[filepath.ToSlash(rel)] = f.DocType
+	}
+	want := map[string]string{
+		".claude/rules/errors.md": "rule",
+		".github/CODEOWNERS":      "codeowners",
+		".editorconfig":           "editorconfig",
+		"api/openapi.json":        "api-spec",
+		"policies/retention.txt":  "policy",
+	}
+	for rel, docType := range want {
+		if types[rel] != docType {
+			t.Errorf("%s indexed as %q, want %q (all: %v)", rel, types[rel], docType, types)
+		}
+	}
+	if len(types) != len(want) {
+		t.Errorf("indexed files = %v", types)
+	}
+
+	docs, err := d.SearchDocs("Create order", "api-spec", "", 5)
+	if err != nil || len(docs) == 0 || docs[0].Title != "POST /orders" {
+		t.Fatalf("openapi search = %+v %v", docs, err)
+	}
+	docs, err = d.SearchDocs("indent_style", "editorconfig", "", 5)
+	if err != nil || len(docs) != 1 {
+		t.Fatalf("editorconfig search = %+v %v", docs, err)
+	}
+
+	// Nothing changed: nothing is rewritten.
+	report, err = d.IndexGovernanceWith(root, opts)
+	if err != nil || report.Indexed != 0 || report.Unchanged != 5 {
+		t.Fatalf("second pass = %+v %v", report, err)
+	}
+
+	// An edit is picked up by path; a deleted file is tombstoned.
+	rule := writeGovFile(t, root, ".claude/rules/errors.md", "# Errors\n\n## Wrapping\nWrap errors with %w and add context.\n")
+	report, err = d.IndexGovernancePaths(root, opts, []string{rule, filepath.Join(root, "main.go")})
+	if err != nil || report.Indexed != 1 {
+		t.Fatalf("path reindex = %+v %v", report, err)
+	}
+	if err := os.Remove(filepath.Join(root, ".github", "CODEOWNERS")); err != nil {
+		t.Fatal(err)
+	}
+	report, err = d.IndexGovernanceWith(root, opts)
+	if err != nil || report.Removed != 1 || report.Unchanged != 4 {
+		t.Fatalf("pass after delete = %+v %v", report, err)
+	}
+	if docs, _ := d.SearchDocs("backend", "codeowners", "", 5); len(docs) != 0 {
+		t.Errorf("deleted CODEOWNERS still searchable: %+v", docs)
+	}
+	tombstones, err := d.ListDocTombstones()
+	if err != nil || len(tombstones) != 1 || !strings.HasSuffix(tombstones[0].FilePath, "CODEOWNERS") ||
+		tombstones[0].DocType != "codeowners" {
+		t.Fatalf("tombstones = %+v %v", tombstones, err)
+	}
+
+	// Restoring the file clears its tombstone.
+	writeGovFile(t, root, ".github/CODEOWNERS", "/api/ @backend-team\n")
+	if _, err := d.IndexGovernanceWith(root, opts); err != nil {
+		t.Fatal(err)
+	}
+	if tombstones, _ := d.ListDocTombstones(); len(tombstones) != 0 {
+		t.Errorf("tombstones after restore = %+v", tombstones)
+	}
+}
+
+func TestChunkDoc_Formats(t *testing.T) {
+	yaml := `openapi: 3.0.0
+info:
+  title: Shop
+# Endpoints
+paths:
+  /orders:
+    get:
+      summary: List orders
+  "/orders/{id}":
+    delete:
+      summary: Cancel order
+`
+	var titles []string
+	for _, c := range chunkDoc("api/openapi.yaml", yaml) {
+		titles = append(titles, c.title)
+	}
+	if got := strings.Join(titles, ","); got != "openapi,info,/orders,/orders/{id}" {
+		t.Errorf("openapi yaml chunks = %s", got)
+	}
+
+	ini := chunkDoc(".editorconfig", "root = true\n\n[*]\ncharset = utf-8\n\n[*.go]\nindent_style = tab\n")
+	if len(ini) != 1 || !strings.Contains(ini[0].content, "[*.go]") {
+		t.Errorf("small editorconfig should merge into one chunk: %+v", ini)
+	}
+
+	big := strings.Repeat("x", maxDocChunk)
+	lint := chunkDoc(".golangci.json", `{"linters":{"enable":["errcheck"]},"run":{"timeout":"`+big+`"}}`)
+	if len(lint) != 2 || lint[0].title != "linters" || lint[1].title != "run" {
+		t.Errorf("json chunks = %+v", lint)
+	}
+}
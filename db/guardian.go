@@ -56,6 +56,20 @@ func (d *DB) ListGuardianAlerts(alertType string) ([]GuardianAlert, error) {
 	return scanGuardianAlerts(rows)
 }
 
+// ListWorkflowGuardianAlerts returns every alert raised about a workflow,
+// dismissed ones included, oldest first.
+func (d *DB) ListWorkflowGuardianAlerts(workflowID string) ([]GuardianAlert, error) {
+	rows, err := d.sql.Query(`
+		SELECT id, type, severity, message, metadata, dismissed_at, created_at
+		FROM guardian_alerts WHERE json_extract(metadata, '$.workflow_id') = ?
+		ORDER BY created_at ASC, id ASC`, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanGuardianAlerts(rows)
+}
+
 // DismissGuardianAlert marks an alert as dismissed.
 func (d *DB) DismissGuardianAlert(id int64) error {
 	_, err := d.sql.Exec(`
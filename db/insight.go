@@ -1233,6 +1233,122 @@ func (d *DB) GetWorkflowScorecardByType(workflowType string, window string) (*Wo
 	return &c, nil
 }
 
+// ToolScorecard rates one MCP tool over a window from the tool-call audit log.
+type ToolScorecard struct {
+	ID              string  `json:"id"`
+	ToolName        string  `json:"tool_name"`
+	Window          string  `json:"window"`
+	WindowStart     string  `json:"window_start"`
+	WindowEnd       string  `json:"window_end"`
+	TotalCalls      int     `json:"total_calls"`
+	ErrorRate       float64 `json:"error_rate"`
+	AvgDurationMs   int64   `json:"avg_duration_ms"`
+	P95DurationMs   int64   `json:"p95_duration_ms"`
+	ConfidenceScore float64 `json:"confidence_score"`
+	Trend           string  `json:"trend"`
+	CreatedAt       string  `json:"created_at"`
+	UpdatedAt       string  `json:"updated_at"`
+}
+
+func (d *DB) SaveToolScorecard(card *ToolScorecard) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	_, err := d.sql.Exec(`
+		INSERT INTO insight_tool_scorecards
+		(id, tool_name, window, window_start, window_end, total_calls, error_rate,
+		 avg_duration_ms, p95_duration_ms, confidence_score, trend, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(tool_name, window) DO UPDATE SET
+			window_start = excluded.window_start,
+			window_end = excluded.window_end,
+			total_calls = excluded.total_calls,
+			error_rate = excluded.error_rate,
+			avg_duration_ms = excluded.avg_duration_ms,
+			p95_duration_ms = excluded.p95_duration_ms,
+			confidence_score = excluded.confidence_score,
+			trend = excluded.trend,
+			updated_at = excluded.updated_at
+	`,
+		card.ID, card.ToolName, card.Window, card.WindowStart, card.WindowEnd,
+		card.TotalCalls, card.ErrorRate, card.AvgDurationMs, card.P95DurationMs,
+		card.ConfidenceScore, card.Trend, now, now,
+	)
+
+	return err
+}
+
+func (d *DB) ListToolScorecards(window string, sortBy string, sortDir string, limit int) ([]ToolScorecard, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	allowedSort := map[string]bool{
+		"tool_name": true, "total_calls": true, "error_rate": true,
+		"avg_duration_ms": true, "p95_duration_ms": true,
+		"confidence_score": true, "trend": true,
+	}
+	if !allowedSort[sortBy] {
+		sortBy = "total_calls"
+	}
+	if sortDir != "ASC" && sortDir != "DESC" {
+		sortDir = "DESC"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, tool_name, window, window_start, window_end, total_calls, error_rate,
+		       avg_duration_ms, p95_duration_ms, confidence_score, trend, created_at, updated_at
+		FROM insight_tool_scorecards
+		WHERE window = ?
+		ORDER BY %s %s
+		LIMIT ?
+	`, sortBy, sortDir)
+
+	rows, err := d.sql.Query(query, window, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list tool scorecards: %w", err)
+	}
+	defer rows.Close()
+
+	var cards []ToolScorecard
+	for rows.Next() {
+		var c ToolScorecard
+		if err := rows.Scan(
+			&c.ID, &c.ToolName, &c.Window, &c.WindowStart, &c.WindowEnd,
+			&c.TotalCalls, &c.ErrorRate, &c.AvgDurationMs, &c.P95DurationMs,
+			&c.ConfidenceScore, &c.Trend, &c.CreatedAt, &c.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan tool scorecard: %w", err)
+		}
+		cards = append(cards, c)
+	}
+
+	return cards, rows.Err()
+}
+
+func (d *DB) GetToolScorecardByName(toolName string, window string) (*ToolScorecard, error) {
+	row := d.sql.QueryRow(`
+		SELECT id, tool_name, window, window_start, window_end, total_calls, error_rate,
+		       avg_duration_ms, p95_duration_ms, confidence_score, trend, created_at, updated_at
+		FROM insight_tool_scorecards
+		WHERE tool_name = ? AND window = ?
+	`, toolName, window)
+
+	var c ToolScorecard
+	err := row.Scan(
+		&c.ID, &c.ToolName, &c.Window, &c.WindowStart, &c.WindowEnd,
+		&c.TotalCalls, &c.ErrorRate, &c.AvgDurationMs, &c.P95DurationMs,
+		&c.ConfidenceScore, &c.Trend, &c.CreatedAt, &c.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get tool scorecard: %w", err)
+	}
+
+	return &c, nil
+}
+
 func (d *DB) GetScorecardHighlights(window string) (map[string]interface{}, error) {
 	highlights := make(map[string]interface{})
 
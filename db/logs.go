@@ -11,16 +11,20 @@ type WorkflowLog struct {
 	ToolName   string `json:"tool_name"`
 	Summary    string `json:"summary"`
 	CreatedMs  int64  `json:"created_ms"`
+	// Redactions is the number of secrets replaced in Summary.
+	Redactions int `json:"redactions,omitempty"`
 }
 
-// SaveWorkflowLog inserts a log entry and returns the saved row.
+// SaveWorkflowLog inserts a log entry and returns the saved row. Secrets in
+// the summary, such as tokens in a Bash command, are redacted first.
 func (d *DB) SaveWorkflowLog(workflowID, sessionID, toolName, summary string) (WorkflowLog, error) {
 	ts := time.Now().UTC().Format("2006-01-02T15:04:05.999Z")
 	createdMs := time.Now().UnixMilli()
+	summary, redactions := redactText(summary, nil)
 	res, err := d.sql.Exec(
-		`INSERT INTO workflow_logs (workflow_id, session_id, ts, tool_name, summary, created_ms)
-		 VALUES (?, ?, ?, ?, ?, ?)`,
-		workflowID, sessionID, ts, toolName, summary, createdMs,
+		`INSERT INTO workflow_logs (workflow_id, session_id, ts, tool_name, summary, redactions, created_ms)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		workflowID, sessionID, ts, toolName, summary, redactions, createdMs,
 	)
 	if err != nil {
 		return WorkflowLog{}, err
@@ -34,6 +38,7 @@ func (d *DB) SaveWorkflowLog(workflowID, sessionID, toolName, summary string) (W
 		ToolName:   toolName,
 		Summary:    summary,
 		CreatedMs:  createdMs,
+		Redactions: redactions,
 	}, nil
 }
 
@@ -43,7 +48,7 @@ func (d *DB) GetWorkflowLogs(workflowID string, limit int) ([]WorkflowLog, error
 		limit = 200
 	}
 	rows, err := d.sql.Query(
-		`SELECT id, workflow_id, session_id, ts, tool_name, summary, created_ms
+		`SELECT id, workflow_id, session_id, ts, tool_name, summary, created_ms, redactions
 		 FROM workflow_logs WHERE workflow_id = ?
 		 ORDER BY id DESC LIMIT ?`,
 		workflowID, limit,
@@ -61,7 +66,7 @@ func (d *DB) GetWorkflowLogsBySession(sessionID string, limit int) ([]WorkflowLo
 		limit = 200
 	}
 	rows, err := d.sql.Query(
-		`SELECT id, workflow_id, session_id, ts, tool_name, summary, created_ms
+		`SELECT id, workflow_id, session_id, ts, tool_name, summary, created_ms, redactions
 		 FROM workflow_logs WHERE session_id = ?
 		 ORDER BY id DESC LIMIT ?`,
 		sessionID, limit,
@@ -81,7 +86,7 @@ func scanWorkflowLogs(rows interface {
 	var logs []WorkflowLog
 	for rows.Next() {
 		var l WorkflowLog
-		if err := rows.Scan(&l.ID, &l.WorkflowID, &l.SessionID, &l.Ts, &l.ToolName, &l.Summary, &l.CreatedMs); err != nil {
+		if err := rows.Scan(&l.ID, &l.WorkflowID, &l.SessionID, &l.Ts, &l.ToolName, &l.Summary, &l.CreatedMs, &l.Redactions); err != nil {
 			return nil, err
 		}
 		logs = append(logs, l)
@@ -0,0 +1,293 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MCPToolCall is one entry of the MCP tool-call audit log.
+type MCPToolCall struct {
+	ID         int64          `json:"id"`
+	SessionID  string         `json:"session_id"`
+	WorkflowID string         `json:"workflow_id"`
+	Tool       string         `json:"tool"`
+	Args       map[string]any `json:"args"`
+	DurationMs int64          `json:"duration_ms"`
+	IsError    bool           `json:"is_error"`
+	Error      string         `json:"error"`
+	CreatedAt  string         `json:"created_at"`
+}
+
+// MCPToolCallFilter narrows ListMCPToolCalls. Zero values match everything.
+type MCPToolCallFilter struct {
+	Tool       string
+	WorkflowID string
+	SessionID  string
+	ErrorsOnly bool
+	Since      string
+	Limit      int
+	Offset     int
+}
+
+// MCPToolStats summarises the calls of one tool over a period.
+type MCPToolStats struct {
+	Tool          string  `json:"tool"`
+	Calls         int     `json:"calls"`
+	Errors        int     `json:"errors"`
+	ErrorRate     float64 `json:"error_rate"`
+	AvgDurationMs int64   `json:"avg_duration_ms"`
+	P95DurationMs int64   `json:"p95_duration_ms"`
+}
+
+// MCPToolMetric is a row of the mcp_tool_metrics daily rollup.
+type MCPToolMetric struct {
+	Date string `json:"date"`
+	MCPToolStats
+}
+
+// mcpTimeFormat matches the strftime default used for created_at, so window
+// bounds compare correctly as strings.
+const mcpTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// mcpCallRetention bounds how long audited tool calls are kept. It covers the
+// longest window the metrics endpoint serves.
+const mcpCallRetention = 90 * 24 * time.Hour
+
+// SaveMCPToolCall appends a call to the audit log and returns its ID. Calls
+// older than mcpCallRetention are pruned on the way.
+func (d *DB) SaveMCPToolCall(c *MCPToolCall) (int64, error) {
+	args := "{}"
+	if len(c.Args) > 0 {
+		data, err := json.Marshal(c.Args)
+		if err != nil {
+			return 0, fmt.Errorf("marshal mcp call args: %w", err)
+		}
+		args = string(data)
+	}
+	isError := 0
+	if c.IsError {
+		isError = 1
+	}
+	res, err := d.sql.Exec(`
+		INSERT INTO mcp_tool_calls (session_id, workflow_id, tool, args, duration_ms, is_error, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		c.SessionID, c.WorkflowID, c.Tool, args, c.DurationMs, isError, c.Error)
+	if err != nil {
+		return 0, fmt.Errorf("save mcp tool call: %w", err)
+	}
+	cutoff := time.Now().UTC().Add(-mcpCallRetention).Format(mcpTimeFormat)
+	if _, err := d.sql.Exec(`DELETE FROM mcp_tool_calls WHERE created_at < ?`, cutoff); err != nil {
+		return 0, fmt.Errorf("prune mcp tool calls: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// ListMCPToolCalls returns audit log entries, newest first.
+func (d *DB) ListMCPToolCalls(f MCPToolCallFilter) ([]MCPToolCall, error) {
+	if f.Limit <= 0 {
+		f.Limit = 50
+	}
+	var where []string
+	var args []any
+	if f.Tool != "" {
+		where = append(where, "tool = ?")
+		args = append(args, f.Tool)
+	}
+	if f.WorkflowID != "" {
+		where = append(where, "workflow_id = ?")
+		args = append(args, f.WorkflowID)
+	}
+	if f.SessionID != "" {
+		where = append(where, "session_id = ?")
+		args = append(args, f.SessionID)
+	}
+	if f.ErrorsOnly {
+		where = append(where, "is_error = 1")
+	}
+	if f.Since != "" {
+		where = append(where, "created_at >= ?")
+		args = append(args, f.Since)
+	}
+	query := `SELECT id, session_id, workflow_id, tool, args, duration_ms, is_error, error, created_at FROM mcp_tool_calls`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
+	args = append(args, f.Limit, f.Offset)
+
+	rows, err := d.sql.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list mcp tool calls: %w", err)
+	}
+	defer rows.Close()
+	calls := []MCPToolCall{}
+	for rows.Next() {
+		var c MCPToolCall
+		var argsJSON string
+		var isError int
+		if err := rows.Scan(&c.ID, &c.SessionID, &c.WorkflowID, &c.Tool, &argsJSON, &c.DurationMs, &isError, &c.Error, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan mcp tool call: %w", err)
+		}
+		c.IsError = isError != 0
+		_ = json.Unmarshal([]byte(argsJSON), &c.Args)
+		calls = append(calls, c)
+	}
+	return calls, rows.Err()
+}
+
+// GetMCPToolStats aggregates the calls made in [start, end) per tool, sorted
+// by tool name.
+func (d *DB) GetMCPToolStats(start, end time.Time) ([]MCPToolStats, error) {
+	rows, err := d.sql.Query(`
+		SELECT tool, duration_ms, is_error FROM mcp_tool_calls
+		WHERE created_at >= ? AND created_at < ?`,
+		start.UTC().Format(mcpTimeFormat), end.UTC().Format(mcpTimeFormat))
+	if err != nil {
+		return nil, fmt.Errorf("get mcp tool stats: %w", err)
+	}
+	defer rows.Close()
+	acc := map[string]*callAccumulator{}
+	for rows.Next() {
+		var tool string
+		var dur int64
+		var isError int
+		if err := rows.Scan(&tool, &dur, &isError); err != nil {
+			return nil, fmt.Errorf("scan mcp tool stats: %w", err)
+		}
+		if acc[tool] == nil {
+			acc[tool] = &callAccumulator{}
+		}
+		acc[tool].add(dur, isError != 0)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	stats := make([]MCPToolStats, 0, len(acc))
+	for tool, a := range acc {
+		stats = append(stats, a.stats(tool))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Tool < stats[j].Tool })
+	return stats, nil
+}
+
+// CollectMCPToolMetrics rebuilds the mcp_tool_metrics rollup for the last n
+// days from the audit log: one row per tool per day plus an "all" row.
+// Latency percentiles need the raw durations, so the aggregation happens here
+// rather than in SQL.
+func (d *DB) CollectMCPToolMetrics(days int) error {
+	rows, err := d.sql.Query(`
+		SELECT date(created_at), tool, duration_ms, is_error FROM mcp_tool_calls
+		WHERE date(created_at) >= date('now', '-' || ? || ' days')`, days)
+	if err != nil {
+		return fmt.Errorf("collect mcp tool metrics: %w", err)
+	}
+	type key struct{ date, tool string }
+	acc := map[key]*callAccumulator{}
+	for rows.Next() {
+		var date, tool string
+		var dur int64
+		var isError int
+		if err := rows.Scan(&date, &tool, &dur, &isError); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan mcp tool call: %w", err)
+		}
+		for _, k := range []key{{date, tool}, {date, "all"}} {
+			if acc[k] == nil {
+				acc[k] = &callAccumulator{}
+			}
+			acc[k].add(dur, isError != 0)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return fmt.Errorf("begin mcp tool metrics: %w", err)
+	}
+	defer tx.Rollback()
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for k, a := range acc {
+		s := a.stats(k.tool)
+		if _, err := tx.Exec(`
+			INSERT INTO mcp_tool_metrics (metric_date, tool, calls, errors, error_rate, avg_duration_ms, p95_duration_ms, computed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(metric_date, tool) DO UPDATE SET
+				calls = excluded.calls,
+				errors = excluded.errors,
+				error_rate = excluded.error_rate,
+				avg_duration_ms = excluded.avg_duration_ms,
+				p95_duration_ms = excluded.p95_duration_ms,
+				computed_at = excluded.computed_at`,
+			k.date, k.tool, s.Calls, s.Errors, s.ErrorRate, s.AvgDurationMs, s.P95DurationMs, now); err != nil {
+			return fmt.Errorf("upsert mcp tool metric: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetMCPToolMetrics returns the daily rollup for the last n days, newest
+// first. An empty tool returns every tool including the "all" rows.
+func (d *DB) GetMCPToolMetrics(days int, tool string) ([]MCPToolMetric, error) {
+	query := `
+		SELECT metric_date, tool, calls, errors, error_rate, avg_duration_ms, p95_duration_ms
+		FROM mcp_tool_metrics
+		WHERE metric_date >= date('now', '-' || ? || ' days')`
+	args := []any{days}
+	if tool != "" {
+		query += " AND tool = ?"
+		args = append(args, tool)
+	}
+	query += " ORDER BY metric_date DESC, tool ASC"
+	rows, err := d.sql.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get mcp tool metrics: %w", err)
+	}
+	defer rows.Close()
+	metrics := []MCPToolMetric{}
+	for rows.Next() {
+		var m MCPToolMetric
+		if err := rows.Scan(&m.Date, &m.Tool, &m.Calls, &m.Errors, &m.ErrorRate, &m.AvgDurationMs, &m.P95DurationMs); err != nil {
+			return nil, fmt.Errorf("scan mcp tool metric: %w", err)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, rows.Err()
+}
+
+// callAccumulator collects call outcomes for one aggregation bucket.
+type callAccumulator struct {
+	durations []int64
+	errors    int
+}
+
+func (a *callAccumulator) add(durationMs int64, isError bool) {
+	a.durations = append(a.durations, durationMs)
+	if isError {
+		a.errors++
+	}
+}
+
+func (a *callAccumulator) stats(tool string) MCPToolStats {
+	s := MCPToolStats{Tool: tool, Calls: len(a.durations), Errors: a.errors}
+	if s.Calls == 0 {
+		return s
+	}
+	s.ErrorRate = float64(a.errors) / float64(s.Calls)
+	sorted := append([]int64(nil), a.durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var sum int64
+	for _, v := range sorted {
+		sum += v
+	}
+	s.AvgDurationMs = sum / int64(len(sorted))
+	// Nearest-rank percentile.
+	rank := (95*len(sorted) + 99) / 100
+	s.P95DurationMs = sorted[rank-1]
+	return s
+}
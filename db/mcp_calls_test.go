@@ -0,0 +1,111 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMCPToolCalls_SaveAndList(t *testing.T) {
+	d := openTestDB(t)
+	calls := []MCPToolCall{
+		{SessionID: "s1", WorkflowID: "spec-a", Tool: "transition_phase", Args: map[string]any{"phase": "plan"}, DurationMs: 12},
+		{SessionID: "s1", Tool: "search", DurationMs: 40, IsError: true, Error: "HTTP 500"},
+		{SessionID: "s2", WorkflowID: "spec-a", Tool: "search", DurationMs: 8},
+	}
+	for i := range calls {
+		if _, err := d.SaveMCPToolCall(&calls[i]); err != nil {
+			t.Fatalf("SaveMCPToolCall: %v", err)
+		}
+	}
+
+	all, err := d.ListMCPToolCalls(MCPToolCallFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[0].SessionID != "s2" {
+		t.Fatalf("got %+v, want 3 calls newest first", all)
+	}
+	if got := all[2].Args["phase"]; got != "plan" {
+		t.Errorf("args not round-tripped: %+v", all[2].Args)
+	}
+
+	errs, _ := d.ListMCPToolCalls(MCPToolCallFilter{ErrorsOnly: true})
+	if len(errs) != 1 || errs[0].Error != "HTTP 500" {
+		t.Errorf("errors only: %+v", errs)
+	}
+	wf, _ := d.ListMCPToolCalls(MCPToolCallFilter{WorkflowID: "spec-a", Tool: "search"})
+	if len(wf) != 1 || wf[0].SessionID != "s2" {
+		t.Errorf("workflow+tool filter: %+v", wf)
+	}
+}
+
+func TestMCPToolCalls_PrunesOldCalls(t *testing.T) {
+	d := openTestDB(t)
+	old, err := d.SaveMCPToolCall(&MCPToolCall{Tool: "search"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := time.Now().UTC().Add(-mcpCallRetention - time.Hour).Format(mcpTimeFormat)
+	if _, err := d.sql.Exec(`UPDATE mcp_tool_calls SET created_at = ? WHERE id = ?`, stale, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.SaveMCPToolCall(&MCPToolCall{Tool: "get_workflow"}); err != nil {
+		t.Fatal(err)
+	}
+	calls, err := d.ListMCPToolCalls(MCPToolCallFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 || calls[0].Tool != "get_workflow" {
+		t.Errorf("expected only the recent call to be kept, got %+v", calls)
+	}
+}
+
+func TestCollectMCPToolMetrics(t *testing.T) {
+	d := openTestDB(t)
+	for i := 1; i <= 20; i++ {
+		c := MCPToolCall{Tool: "search", DurationMs: int64(i * 10), IsError: i%5 == 0}
+		if _, err := d.SaveMCPToolCall(&c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := d.SaveMCPToolCall(&MCPToolCall{Tool: "get_workflow", DurationMs: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.CollectMCPToolMetrics(7); err != nil {
+		t.Fatalf("CollectMCPToolMetrics: %v", err)
+	}
+	// Collecting twice must not duplicate rows.
+	if err := d.CollectMCPToolMetrics(7); err != nil {
+		t.Fatal(err)
+	}
+
+	search, err := d.GetMCPToolMetrics(7, "search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(search) != 1 {
+		t.Fatalf("got %d search rows, want 1", len(search))
+	}
+	m := search[0]
+	if m.Calls != 20 || m.Errors != 4 || m.ErrorRate != 0.2 {
+		t.Errorf("calls/errors = %d/%d (%.2f), want 20/4 (0.20)", m.Calls, m.Errors, m.ErrorRate)
+	}
+	if m.AvgDurationMs != 105 || m.P95DurationMs != 190 {
+		t.Errorf("avg/p95 = %d/%d, want 105/190", m.AvgDurationMs, m.P95DurationMs)
+	}
+
+	all, _ := d.GetMCPToolMetrics(7, "all")
+	if len(all) != 1 || all[0].Calls != 21 {
+		t.Errorf("all rollup = %+v, want 21 calls", all)
+	}
+
+	stats, err := d.GetMCPToolStats(time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats[0].Tool != "get_workflow" || stats[1].Calls != 20 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
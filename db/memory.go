@@ -25,6 +25,8 @@ type Event struct {
 	Project   *string          `json:"project,omitempty"`
 	SessionID *string          `json:"session_id,omitempty"`
 	CreatedMs int64            `json:"created_ms"`
+	// Score is the hybrid relevance score; set only by HybridSearchEvents.
+	Score     float64          `json:"score,omitempty"`
 }
 
 // SaveEventInput is the input for SaveEvent.
@@ -43,7 +45,8 @@ type SaveEventInput struct {
 	SessionID  *string        `json:"session_id,omitempty"`
 }
 
-// SaveEvent saves an event, returning the ID. Handles deduplication.
+// SaveEvent saves an event, returning the ID. Handles deduplication. Secrets
+// in the title, text, tags and refs are redacted before the insert.
 func (d *DB) SaveEvent(in SaveEventInput) (int64, error) {
 	// Dedupe by explicit key
 	if in.DedupeKey != nil && *in.DedupeKey != "" {
@@ -54,6 +57,9 @@ func (d *DB) SaveEvent(in SaveEventInput) (int64, error) {
 		}
 	}
 
+	var redactions int
+	in.Tags, in.Refs, redactions = redactEvent(&in.Title, &in.Text, in.Tags, in.Refs, nil)
+
 	tags, _ := json.Marshal(in.Tags)
 	refs, _ := json.Marshal(in.Refs)
 	if in.Tags == nil {
@@ -76,11 +82,11 @@ func (d *DB) SaveEvent(in SaveEventInput) (int64, error) {
 	}
 
 	res, err := d.sql.Exec(`
-		INSERT INTO events (actor, scope, type, text, title, tags, refs, ttl, importance, dedupe_key, project, session_id)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		INSERT INTO events (actor, scope, type, text, title, tags, refs, ttl, importance, dedupe_key, project, session_id, redactions)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		in.Actor, in.Scope, in.Type, in.Text, in.Title,
 		string(tags), string(refs), in.TTL, in.Importance,
-		in.DedupeKey, in.Project, in.SessionID,
+		in.DedupeKey, in.Project, in.SessionID, redactions,
 	)
 	if err != nil {
 		// Unique constraint on dedupe_key — return existing
@@ -158,6 +164,7 @@ func (d *DB) SearchEvents(in SearchEventsInput) ([]Event, error) {
 			FROM events_fts f
 			JOIN events e ON e.id = f.rowid
 			WHERE events_fts MATCH ?
+			  AND e.archived_at IS NULL
 			  AND (? = '' OR e.type = ?)
 			  AND (? = '' OR e.scope = ?)
 			  AND (? = '' OR e.project = ?)
@@ -178,7 +185,8 @@ func (d *DB) SearchEvents(in SearchEventsInput) ([]Event, error) {
 			SELECT id, ts, actor, scope, type, text, title,
 			       tags, refs, ttl, importance, dedupe_key, project, session_id, created_ms
 			FROM events
-			WHERE (? = '' OR type = ?)
+			WHERE archived_at IS NULL
+			  AND (? = '' OR type = ?)
 			  AND (? = '' OR scope = ?)
 			  AND (? = '' OR project = ?)
 			  AND (? = '' OR ts >= ?)
@@ -0,0 +1,207 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DecayCandidate is an active event eligible for importance decay.
+type DecayCandidate struct {
+	ID         int64   `json:"id"`
+	Title      string  `json:"title"`
+	Importance float64 `json:"importance"`
+	CreatedMs  int64   `json:"created_ms"`
+	// DecayedMs is when importance was last decayed; 0 means never.
+	DecayedMs int64 `json:"decayed_ms"`
+}
+
+// ImportanceUpdate sets the decayed importance of one event.
+type ImportanceUpdate struct {
+	ID         int64
+	Importance float64
+	Archive    bool
+}
+
+// ListActiveEvents returns up to limit events that are not archived, newest
+// first.
+func (d *DB) ListActiveEvents(limit int) ([]Event, error) {
+	if limit <= 0 {
+		limit = 1000
+	}
+	rows, err := d.sql.Query(`
+		SELECT id, ts, actor, scope, type, text, title,
+		       tags, refs, ttl, importance, dedupe_key, project, session_id, created_ms
+		FROM events
+		WHERE archived_at IS NULL
+		ORDER BY id DESC
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list active events: %w", err)
+	}
+	defer rows.Close()
+	return scanEvents(rows)
+}
+
+// ListEventsWithTTL returns every event that carries a TTL. The TTL is free
+// text (normally an ISO 8601 date), so expiry is decided by the caller.
+func (d *DB) ListEventsWithTTL() ([]Event, error) {
+	rows, err := d.sql.Query(`
+		SELECT id, ts, actor, scope, type, text, title,
+		       tags, refs, ttl, importance, dedupe_key, project, session_id, created_ms
+		FROM events
+		WHERE ttl IS NOT NULL AND ttl != ''
+		ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("list events with ttl: %w", err)
+	}
+	defer rows.Close()
+	return scanEvents(rows)
+}
+
+// DeleteEvents removes events permanently; their FTS rows and embeddings go
+// with them.
+func (d *DB) DeleteEvents(ids []int64) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	placeholders, args := idArgs(ids)
+	res, err := d.sql.Exec(`DELETE FROM events WHERE id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("delete events: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// ListDecayCandidates returns active events below maxImportance that were
+// created before cutoff.
+func (d *DB) ListDecayCandidates(maxImportance float64, cutoff time.Time) ([]DecayCandidate, error) {
+	rows, err := d.sql.Query(`
+		SELECT id, title, importance, created_ms, COALESCE(decayed_ms, 0)
+		FROM events
+		WHERE archived_at IS NULL AND importance < ? AND created_ms < ?
+		ORDER BY id`, maxImportance, cutoff.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("list decay candidates: %w", err)
+	}
+	defer rows.Close()
+	out := []DecayCandidate{}
+	for rows.Next() {
+		var c DecayCandidate
+		if err := rows.Scan(&c.ID, &c.Title, &c.Importance, &c.CreatedMs, &c.DecayedMs); err != nil {
+			return nil, fmt.Errorf("scan decay candidate: %w", err)
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// ApplyImportanceDecay writes decayed importances and archives the events
+// flagged for it, stamping decayed_ms with now.
+func (d *DB) ApplyImportanceDecay(updates []ImportanceUpdate, now time.Time) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return fmt.Errorf("begin importance decay: %w", err)
+	}
+	defer tx.Rollback()
+	archivedAt := now.UTC().Format("2006-01-02T15:04:05.000Z")
+	for _, u := range updates {
+		var archived any
+		if u.Archive {
+			archived = archivedAt
+		}
+		if _, err := tx.Exec(`
+			UPDATE events SET importance = ?, decayed_ms = ?, archived_at = COALESCE(?, archived_at)
+			WHERE id = ?`, u.Importance, now.UnixMilli(), archived, u.ID); err != nil {
+			return fmt.Errorf("decay event %d: %w", u.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ConsolidateEvents saves a consolidated memory and archives the originals
+// it replaces in one transaction. The originals stay readable by ID and point
+// at the new event through consolidated_into.
+func (d *DB) ConsolidateEvents(in SaveEventInput, originals []int64) (int64, error) {
+	if len(originals) == 0 {
+		return 0, fmt.Errorf("consolidate events: no originals")
+	}
+	var redactions int
+	in.Tags, in.Refs, redactions = redactEvent(&in.Title, &in.Text, in.Tags, in.Refs, nil)
+	tags, _ := json.Marshal(in.Tags)
+	refs, _ := json.Marshal(in.Refs)
+	if in.Tags == nil {
+		tags = []byte("[]")
+	}
+	if in.Refs == nil {
+		refs = []byte("{}")
+	}
+	if in.Actor == "" {
+		in.Actor = "system"
+	}
+
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin consolidate events: %w", err)
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(`
+		INSERT INTO events (actor, scope, type, text, title, tags, refs, importance, project, redactions)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		in.Actor, in.Scope, in.Type, in.Text, in.Title,
+		string(tags), string(refs), in.Importance, in.Project, redactions)
+	if err != nil {
+		return 0, fmt.Errorf("insert consolidated event: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	placeholders, args := idArgs(originals)
+	args = append([]any{id}, args...)
+	if _, err := tx.Exec(`
+		UPDATE events
+		SET archived_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now'), consolidated_into = ?
+		WHERE archived_at IS NULL AND id IN (`+placeholders+`)`, args...); err != nil {
+		return 0, fmt.Errorf("archive consolidated events: %w", err)
+	}
+	return id, tx.Commit()
+}
+
+// GetEventEmbeddings returns the stored vectors from model for the given
+// events. Events without one are absent from the map.
+func (d *DB) GetEventEmbeddings(ids []int64, model string) (map[int64][]float32, error) {
+	out := map[int64][]float32{}
+	if len(ids) == 0 || model == "" {
+		return out, nil
+	}
+	placeholders, args := idArgs(ids)
+	args = append(args, model)
+	rows, err := d.sql.Query(`
+		SELECT event_id, vector FROM event_embeddings
+		WHERE event_id IN (`+placeholders+`) AND model = ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get event embeddings: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var blob []byte
+		if err := rows.Scan(&id, &blob); err != nil {
+			return nil, fmt.Errorf("scan event embedding: %w", err)
+		}
+		out[id] = decodeVector(blob)
+	}
+	return out, rows.Err()
+}
+
+// idArgs builds an IN (...) placeholder list for ids.
+func idArgs(ids []int64) (string, []any) {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","), args
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EventFilter selects events for export. Zero values match everything;
+// DateStart and DateEnd compare against ts.
+type EventFilter struct {
+	Project         string
+	Scope           string
+	Type            string
+	DateStart       string
+	DateEnd         string
+	IncludeArchived bool
+}
+
+// ListEventsAfter returns up to limit events matching f with an ID greater
+// than afterID, in ID order. Callers page through the store by passing the
+// last ID they saw.
+func (d *DB) ListEventsAfter(f EventFilter, afterID int64, limit int) ([]Event, error) {
+	if limit <= 0 {
+		limit = 500
+	}
+	rows, err := d.sql.Query(`
+		SELECT id, ts, actor, scope, type, text, title,
+		       tags, refs, ttl, importance, dedupe_key, project, session_id, created_ms
+		FROM events
+		WHERE id > ?
+		  AND (? OR archived_at IS NULL)
+		  AND (? = '' OR project = ?)
+		  AND (? = '' OR scope = ?)
+		  AND (? = '' OR type = ?)
+		  AND (? = '' OR ts >= ?)
+		  AND (? = '' OR ts <= ?)
+		ORDER BY id
+		LIMIT ?`,
+		afterID, f.IncludeArchived,
+		f.Project, f.Project,
+		f.Scope, f.Scope,
+		f.Type, f.Type,
+		f.DateStart, f.DateStart,
+		f.DateEnd, f.DateEnd,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+	defer rows.Close()
+	return scanEvents(rows)
+}
+
+// FindEventByDedupeKey returns the event holding key, or nil if none does.
+func (d *DB) FindEventByDedupeKey(key string) (*Event, error) {
+	rows, err := d.sql.Query(`
+		SELECT id, ts, actor, scope, type, text, title,
+		       tags, refs, ttl, importance, dedupe_key, project, session_id, created_ms
+		FROM events WHERE dedupe_key = ?`, key)
+	if err != nil {
+		return nil, fmt.Errorf("find event by dedupe key: %w", err)
+	}
+	defer rows.Close()
+	events, err := scanEvents(rows)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return &events[0], nil
+}
+
+// FindIdenticalEvent returns the ID of an event with the same timestamp,
+// scope, type, title and text as e, or 0. Import uses it to make re-importing
+// the same file a no-op for events without a dedupe key.
+func (d *DB) FindIdenticalEvent(e Event) (int64, error) {
+	var id int64
+	err := d.sql.QueryRow(`
+		SELECT id FROM events
+		WHERE ts = ? AND scope = ? AND type = ? AND title = ? AND text = ?
+		LIMIT 1`, e.Ts, e.Scope, e.Type, e.Title, e.Text).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("find identical event: %w", err)
+	}
+	return id, nil
+}
+
+// ImportEvent inserts e as a new event, keeping its original ts and
+// created_ms. Secrets are redacted as in SaveEvent. Unlike SaveEvent it does not resolve dedupe keys; callers check
+// FindEventByDedupeKey first.
+func (d *DB) ImportEvent(e Event) (int64, error) {
+	var redactions int
+	e.Tags, e.Refs, redactions = redactEvent(&e.Title, &e.Text, e.Tags, e.Refs, nil)
+	tags, _ := json.Marshal(e.Tags)
+	refs, _ := json.Marshal(e.Refs)
+	if e.Tags == nil {
+		tags = []byte("[]")
+	}
+	if e.Refs == nil {
+		refs = []byte("{}")
+	}
+	if e.Ts == "" {
+		e.Ts = now()
+	}
+	var created any
+	if e.CreatedMs > 0 {
+		created = e.CreatedMs
+	}
+	res, err := d.sql.Exec(`
+		INSERT INTO events (ts, actor, scope, type, text, title, tags, refs, ttl, importance, dedupe_key, project, session_id, redactions, created_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+		        COALESCE(?, CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER)))`,
+		e.Ts, e.Actor, e.Scope, e.Type, e.Text, e.Title,
+		string(tags), string(refs), e.TTL, e.Importance,
+		e.DedupeKey, e.Project, e.SessionID, redactions, created,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("import event: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// GetSessionsByContentIDs returns the sessions with the given content session
+// IDs.
+func (d *DB) GetSessionsByContentIDs(ids []string) ([]Session, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	rows, err := d.sql.Query(`
+		SELECT id, content_session_id, project, initial_prompt, started_at
+		FROM sessions WHERE content_session_id IN (`+placeholders+`)
+		ORDER BY started_at`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get sessions: %w", err)
+	}
+	defer rows.Close()
+	var sessions []Session
+	for rows.Next() {
+		var s Session
+		var ip sql.NullString
+		if err := rows.Scan(&s.ID, &s.ContentSessionID, &s.Project, &ip, &s.StartedAt); err != nil {
+			return nil, err
+		}
+		if ip.Valid {
+			s.InitialPrompt = &ip.String
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// ImportSession inserts s unless a session with the same content session ID
+// exists. It reports whether a row was created.
+func (d *DB) ImportSession(s Session) (bool, error) {
+	var existing int64
+	err := d.sql.QueryRow(`SELECT id FROM sessions WHERE content_session_id = ?`, s.ContentSessionID).Scan(&existing)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("import session: %w", err)
+	}
+	if s.StartedAt == "" {
+		s.StartedAt = now()
+	}
+	if _, err := d.sql.Exec(`
+		INSERT INTO sessions (content_session_id, project, initial_prompt, started_at) VALUES (?, ?, ?, ?)`,
+		s.ContentSessionID, s.Project, s.InitialPrompt, s.StartedAt); err != nil {
+		return false, fmt.Errorf("import session: %w", err)
+	}
+	return true, nil
+}
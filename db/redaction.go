@@ -0,0 +1,254 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/MartinNevlaha/stratus-v2/internal/redact"
+)
+
+// redactText replaces secrets in s, adding what it found to kinds when
+// kinds is non-nil.
+func redactText(s string, kinds map[string]int) (string, int) {
+	r := redact.Apply(s)
+	for k, n := range r.Kinds {
+		if kinds != nil {
+			kinds[k] += n
+		}
+	}
+	return r.Text, r.Count
+}
+
+// redactEvent replaces secrets in the free-text fields of an event. Tags and
+// refs are copied before anything changes, so callers' values stay intact.
+func redactEvent(title, text *string, tags []string, refs map[string]any, kinds map[string]int) ([]string, map[string]any, int) {
+	var total, n int
+	*title, n = redactText(*title, kinds)
+	total += n
+	*text, n = redactText(*text, kinds)
+	total += n
+	var outTags []string
+	if tags != nil {
+		outTags = make([]string, len(tags))
+		for i, t := range tags {
+			outTags[i], n = redactText(t, kinds)
+			total += n
+		}
+	}
+	var outRefs map[string]any
+	if refs != nil {
+		v, n := redactRefValue(refs, kinds)
+		outRefs = v.(map[string]any)
+		total += n
+	}
+	return outTags, outRefs, total
+}
+
+func redactRefValue(v any, kinds map[string]int) (any, int) {
+	switch val := v.(type) {
+	case string:
+		return redactText(val, kinds)
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		total := 0
+		for k, item := range val {
+			var n int
+			out[k], n = redactRefValue(item, kinds)
+			total += n
+		}
+		return out, total
+	case []any:
+		out := make([]any, len(val))
+		total := 0
+		for i, item := range val {
+			var n int
+			out[i], n = redactRefValue(item, kinds)
+			total += n
+		}
+		return out, total
+	}
+	return v, 0
+}
+
+// RedactionStats counts rows that had secrets replaced before they were
+// stored.
+type RedactionStats struct {
+	EventsRedacted        int `json:"events_redacted"`
+	EventRedactions       int `json:"event_redactions"`
+	WorkflowLogsRedacted  int `json:"workflow_logs_redacted"`
+	WorkflowLogRedactions int `json:"workflow_log_redactions"`
+}
+
+// GetRedactionStats returns how many events and workflow logs were redacted
+// and how many secrets were replaced in total.
+func (d *DB) GetRedactionStats() (*RedactionStats, error) {
+	var s RedactionStats
+	if err := d.sql.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(redactions), 0) FROM events WHERE redactions > 0`,
+	).Scan(&s.EventsRedacted, &s.EventRedactions); err != nil {
+		return nil, fmt.Errorf("event redaction stats: %w", err)
+	}
+	if err := d.sql.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(redactions), 0) FROM workflow_logs WHERE redactions > 0`,
+	).Scan(&s.WorkflowLogsRedacted, &s.WorkflowLogRedactions); err != nil {
+		return nil, fmt.Errorf("workflow log redaction stats: %w", err)
+	}
+	return &s, nil
+}
+
+// ScrubCounts counts the rows one table scrub looked at and changed.
+type ScrubCounts struct {
+	Scanned    int `json:"scanned"`
+	Rows       int `json:"rows"`
+	Redactions int `json:"redactions"`
+}
+
+// ScrubReport describes a retroactive secret scrub, or what one would change
+// when DryRun.
+type ScrubReport struct {
+	DryRun       bool           `json:"dry_run"`
+	Events       ScrubCounts    `json:"events"`
+	WorkflowLogs ScrubCounts    `json:"workflow_logs"`
+	Kinds        map[string]int `json:"kinds"`
+}
+
+const scrubPageSize = 500
+
+// ScrubSecrets redacts secrets in rows written before redaction existed:
+// event titles, text, tags and refs (archived events included) and workflow
+// log summaries. Scrubbed events lose their embedding so it is recomputed
+// from the clean text, and the events FTS index is rebuilt so the old
+// tokens are no longer searchable.
+func (d *DB) ScrubSecrets(dryRun bool) (*ScrubReport, error) {
+	report := &ScrubReport{DryRun: dryRun, Kinds: map[string]int{}}
+	if err := d.scrubEvents(report); err != nil {
+		return nil, err
+	}
+	if err := d.scrubWorkflowLogs(report); err != nil {
+		return nil, err
+	}
+	if !dryRun && report.Events.Rows > 0 {
+		if _, err := d.sql.Exec(`INSERT INTO events_fts(events_fts) VALUES('rebuild')`); err != nil {
+			return nil, fmt.Errorf("rebuild events fts: %w", err)
+		}
+	}
+	return report, nil
+}
+
+func (d *DB) scrubEvents(report *ScrubReport) error {
+	var after int64
+	for {
+		rows, err := d.sql.Query(`
+			SELECT id, title, text, tags, refs FROM events
+			WHERE id > ? ORDER BY id LIMIT ?`, after, scrubPageSize)
+		if err != nil {
+			return fmt.Errorf("scrub events: %w", err)
+		}
+		type row struct {
+			id               int64
+			title, text      string
+			tagsRaw, refsRaw string
+		}
+		var page []row
+		for rows.Next() {
+			var r row
+			if err := rows.Scan(&r.id, &r.title, &r.text, &r.tagsRaw, &r.refsRaw); err != nil {
+				rows.Close()
+				return err
+			}
+			page = append(page, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, r := range page {
+			after = r.id
+			report.Events.Scanned++
+			var tags []string
+			var refs map[string]any
+			_ = json.Unmarshal([]byte(r.tagsRaw), &tags)
+			_ = json.Unmarshal([]byte(r.refsRaw), &refs)
+			tags, refs, n := redactEvent(&r.title, &r.text, tags, refs, report.Kinds)
+			if n == 0 {
+				continue
+			}
+			report.Events.Rows++
+			report.Events.Redactions += n
+			if report.DryRun {
+				continue
+			}
+			tagsJSON, _ := json.Marshal(tags)
+			refsJSON, _ := json.Marshal(refs)
+			if tags == nil {
+				tagsJSON = []byte("[]")
+			}
+			if refs == nil {
+				refsJSON = []byte("{}")
+			}
+			if _, err := d.sql.Exec(`
+				UPDATE events SET title = ?, text = ?, tags = ?, refs = ?, redactions = redactions + ?
+				WHERE id = ?`, r.title, r.text, string(tagsJSON), string(refsJSON), n, r.id); err != nil {
+				return fmt.Errorf("scrub event %d: %w", r.id, err)
+			}
+			if _, err := d.sql.Exec(`DELETE FROM event_embeddings WHERE event_id = ?`, r.id); err != nil {
+				return fmt.Errorf("scrub event %d: %w", r.id, err)
+			}
+		}
+		if len(page) < scrubPageSize {
+			return nil
+		}
+	}
+}
+
+func (d *DB) scrubWorkflowLogs(report *ScrubReport) error {
+	var after int64
+	for {
+		rows, err := d.sql.Query(`
+			SELECT id, summary FROM workflow_logs
+			WHERE id > ? ORDER BY id LIMIT ?`, after, scrubPageSize)
+		if err != nil {
+			return fmt.Errorf("scrub workflow logs: %w", err)
+		}
+		type row struct {
+			id      int64
+			summary string
+		}
+		var page []row
+		for rows.Next() {
+			var r row
+			if err := rows.Scan(&r.id, &r.summary); err != nil {
+				rows.Close()
+				return err
+			}
+			page = append(page, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, r := range page {
+			after = r.id
+			report.WorkflowLogs.Scanned++
+			summary, n := redactText(r.summary, report.Kinds)
+			if n == 0 {
+				continue
+			}
+			report.WorkflowLogs.Rows++
+			report.WorkflowLogs.Redactions += n
+			if report.DryRun {
+				continue
+			}
+			if _, err := d.sql.Exec(`
+				UPDATE workflow_logs SET summary = ?, redactions = redactions + ? WHERE id = ?`,
+				summary, n, r.id); err != nil {
+				return fmt.Errorf("scrub workflow log %d: %w", r.id, err)
+			}
+		}
+		if len(page) < scrubPageSize {
+			return nil
+		}
+	}
+}
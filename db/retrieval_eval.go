@@ -0,0 +1,375 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetrievalGoldenCase is a query with the targets a good retrieval should
+// return for it: project-relative file paths, or "wiki:<title>" for wiki
+// pages. Source is "manual", "seeded" (from the query log) or "file" (the
+// repo's golden set, never stored in the DB).
+type RetrievalGoldenCase struct {
+	ID        int64    `json:"id,omitempty"`
+	Query     string   `json:"query"`
+	Corpus    string   `json:"corpus"`
+	Expected  []string `json:"expected"`
+	Source    string   `json:"source,omitempty"`
+	CreatedAt string   `json:"created_at,omitempty"`
+}
+
+// RetrievalMetrics are mean scores over the cases of one corpus.
+type RetrievalMetrics struct {
+	Cases  int     `json:"cases"`
+	Recall float64 `json:"recall"`
+	MRR    float64 `json:"mrr"`
+	NDCG   float64 `json:"ndcg"`
+}
+
+// RetrievalCaseResult is how one golden case scored in a run.
+type RetrievalCaseResult struct {
+	Query    string   `json:"query"`
+	Corpus   string   `json:"corpus"`
+	Expected []string `json:"expected"`
+	Hits     []string `json:"hits"`
+	Recall   float64  `json:"recall"`
+	RR       float64  `json:"rr"`
+	NDCG     float64  `json:"ndcg"`
+	Skipped  string   `json:"skipped,omitempty"`
+}
+
+// RetrievalEvalRun is one scored pass over the golden set. Metrics are keyed
+// by corpus ("auto" for the fused default) plus "all".
+type RetrievalEvalRun struct {
+	ID          int64                       `json:"id"`
+	K           int                         `json:"k"`
+	Offline     bool                        `json:"offline"`
+	Cases       int                         `json:"cases"`
+	Skipped     int                         `json:"skipped"`
+	Metrics     map[string]RetrievalMetrics `json:"metrics"`
+	Regressions []string                    `json:"regressions"`
+	Results     []RetrievalCaseResult       `json:"results,omitempty"`
+	CreatedAt   string                      `json:"created_at"`
+}
+
+// retrievalLogRetention bounds how long served queries that nobody followed
+// up on are kept for seeding.
+const retrievalLogRetention = 30 * 24 * time.Hour
+
+// SaveRetrievalGoldenCase inserts c, or replaces the expected targets of the
+// case with the same query and corpus, and returns its ID.
+func (d *DB) SaveRetrievalGoldenCase(c *RetrievalGoldenCase) (int64, error) {
+	expected, err := json.Marshal(nonNilStrings(c.Expected))
+	if err != nil {
+		return 0, fmt.Errorf("marshal expected: %w", err)
+	}
+	source := c.Source
+	if source == "" {
+		source = "manual"
+	}
+	var id int64
+	err = d.sql.QueryRow(`
+		INSERT INTO retrieval_golden (query, corpus, expected, source)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(query, corpus) DO UPDATE SET expected = excluded.expected, source = excluded.source
+		RETURNING id`,
+		c.Query, c.Corpus, string(expected), source).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("save retrieval golden case: %w", err)
+	}
+	return id, nil
+}
+
+// ListRetrievalGoldenCases returns the stored golden set, oldest first.
+func (d *DB) ListRetrievalGoldenCases() ([]RetrievalGoldenCase, error) {
+	rows, err := d.sql.Query(`
+		SELECT id, query, corpus, expected, source, created_at
+		FROM retrieval_golden ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("list retrieval golden cases: %w", err)
+	}
+	defer rows.Close()
+	var out []RetrievalGoldenCase
+	for rows.Next() {
+		var c RetrievalGoldenCase
+		var expected string
+		if err := rows.Scan(&c.ID, &c.Query, &c.Corpus, &expected, &c.Source, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(expected), &c.Expected)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// DeleteRetrievalGoldenCase removes a stored case. It reports whether the
+// case existed.
+func (d *DB) DeleteRetrievalGoldenCase(id int64) (bool, error) {
+	res, err := d.sql.Exec(`DELETE FROM retrieval_golden WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("delete retrieval golden case: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// LogRetrievalQuery records the targets a retrieve call served, so later
+// follow-ups can turn it into a golden case. Old entries without follow-ups
+// are pruned on the way.
+func (d *DB) LogRetrievalQuery(query, corpus string, results []string) (int64, error) {
+	data, err := json.Marshal(nonNilStrings(results))
+	if err != nil {
+		return 0, fmt.Errorf("marshal results: %w", err)
+	}
+	res, err := d.sql.Exec(`
+		INSERT INTO retrieval_query_log (query, corpus, results) VALUES (?, ?, ?)`,
+		query, corpus, string(data))
+	if err != nil {
+		return 0, fmt.Errorf("log retrieval query: %w", err)
+	}
+	cutoff := time.Now().UTC().Add(-retrievalLogRetention).Format(mcpTimeFormat)
+	if _, err := d.sql.Exec(`
+		DELETE FROM retrieval_query_log WHERE created_at < ? AND followed = '[]'`, cutoff); err != nil {
+		return 0, fmt.Errorf("prune retrieval query log: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// MarkRetrievalFollowUps records that targets were acted on (edited or
+// opened). Every query served within window whose results contained one of
+// them counts it as followed up. It returns the number of queries updated.
+func (d *DB) MarkRetrievalFollowUps(targets []string, window time.Duration) (int, error) {
+	if len(targets) == 0 {
+		return 0, nil
+	}
+	want := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		want[t] = true
+	}
+	since := time.Now().UTC().Add(-window).Format(mcpTimeFormat)
+	rows, err := d.sql.Query(`
+		SELECT id, results, followed FROM retrieval_query_log WHERE created_at >= ?`, since)
+	if err != nil {
+		return 0, fmt.Errorf("mark retrieval follow-ups: %w", err)
+	}
+	type update struct {
+		id       int64
+		followed string
+	}
+	var updates []update
+	for rows.Next() {
+		var id int64
+		var resultsJSON, followedJSON string
+		if err := rows.Scan(&id, &resultsJSON, &followedJSON); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		var results, followed []string
+		_ = json.Unmarshal([]byte(resultsJSON), &results)
+		_ = json.Unmarshal([]byte(followedJSON), &followed)
+		seen := make(map[string]bool, len(followed))
+		for _, f := range followed {
+			seen[f] = true
+		}
+		changed := false
+		for _, r := range results {
+			if want[r] && !seen[r] {
+				followed = append(followed, r)
+				seen[r] = true
+				changed = true
+			}
+		}
+		if changed {
+			data, _ := json.Marshal(followed)
+			updates = append(updates, update{id, string(data)})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	for _, u := range updates {
+		if _, err := d.sql.Exec(`UPDATE retrieval_query_log SET followed = ? WHERE id = ?`, u.followed, u.id); err != nil {
+			return 0, fmt.Errorf("mark retrieval follow-ups: %w", err)
+		}
+	}
+	return len(updates), nil
+}
+
+// SeedRetrievalGolden turns followed-up queries from the log into golden
+// cases: the followed targets of every logged call with the same query and
+// corpus become the expected set. Existing cases are left alone. It returns
+// the number of cases added.
+func (d *DB) SeedRetrievalGolden(limit int) (int, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := d.sql.Query(`
+		SELECT query, corpus, followed FROM retrieval_query_log
+		WHERE followed != '[]'
+		  AND NOT EXISTS (
+			SELECT 1 FROM retrieval_golden g
+			WHERE g.query = retrieval_query_log.query AND g.corpus = retrieval_query_log.corpus)
+		ORDER BY id`)
+	if err != nil {
+		return 0, fmt.Errorf("seed retrieval golden: %w", err)
+	}
+	type key struct{ query, corpus string }
+	var order []key
+	expected := map[key][]string{}
+	for rows.Next() {
+		var k key
+		var followedJSON string
+		if err := rows.Scan(&k.query, &k.corpus, &followedJSON); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		var followed []string
+		_ = json.Unmarshal([]byte(followedJSON), &followed)
+		if _, ok := expected[k]; !ok {
+			order = append(order, k)
+		}
+		for _, f := range followed {
+			if !containsString(expected[k], f) {
+				expected[k] = append(expected[k], f)
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for _, k := range order {
+		if added >= limit {
+			break
+		}
+		res, err := d.sql.Exec(`
+			INSERT INTO retrieval_golden (query, corpus, expected, source)
+			VALUES (?, ?, ?, 'seeded')
+			ON CONFLICT(query, corpus) DO NOTHING`,
+			k.query, k.corpus, jsonString(expected[k]))
+		if err != nil {
+			return added, fmt.Errorf("seed retrieval golden: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			added++
+		}
+	}
+	return added, nil
+}
+
+// SaveRetrievalEvalRun stores a scored run and sets its ID and timestamp.
+func (d *DB) SaveRetrievalEvalRun(run *RetrievalEvalRun) error {
+	offline := 0
+	if run.Offline {
+		offline = 1
+	}
+	err := d.sql.QueryRow(`
+		INSERT INTO retrieval_eval_runs (k, offline, cases, skipped, metrics, regressions, results)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, created_at`,
+		run.K, offline, run.Cases, run.Skipped,
+		jsonString(run.Metrics), jsonString(nonNilStrings(run.Regressions)), jsonString(run.Results),
+	).Scan(&run.ID, &run.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("save retrieval eval run: %w", err)
+	}
+	return nil
+}
+
+// ListRetrievalEvalRuns returns the most recent runs first, without their
+// per-case results.
+func (d *DB) ListRetrievalEvalRuns(limit int) ([]RetrievalEvalRun, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := d.sql.Query(`
+		SELECT id, k, offline, cases, skipped, metrics, regressions, '[]', created_at
+		FROM retrieval_eval_runs ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list retrieval eval runs: %w", err)
+	}
+	defer rows.Close()
+	var out []RetrievalEvalRun
+	for rows.Next() {
+		run, err := scanRetrievalEvalRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *run)
+	}
+	return out, rows.Err()
+}
+
+// GetRetrievalEvalRun returns a run with its per-case results, or nil.
+func (d *DB) GetRetrievalEvalRun(id int64) (*RetrievalEvalRun, error) {
+	run, err := scanRetrievalEvalRun(d.sql.QueryRow(`
+		SELECT id, k, offline, cases, skipped, metrics, regressions, results, created_at
+		FROM retrieval_eval_runs WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return run, err
+}
+
+// LastRetrievalEvalRun returns the latest run with the same k and mode, the
+// baseline a new run is compared against, or nil.
+func (d *DB) LastRetrievalEvalRun(k int, offline bool) (*RetrievalEvalRun, error) {
+	off := 0
+	if offline {
+		off = 1
+	}
+	run, err := scanRetrievalEvalRun(d.sql.QueryRow(`
+		SELECT id, k, offline, cases, skipped, metrics, regressions, '[]', created_at
+		FROM retrieval_eval_runs WHERE k = ? AND offline = ? ORDER BY id DESC LIMIT 1`, k, off))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return run, err
+}
+
+func scanRetrievalEvalRun(row interface{ Scan(...any) error }) (*RetrievalEvalRun, error) {
+	var run RetrievalEvalRun
+	var offline int
+	var metrics, regressions, results string
+	if err := row.Scan(&run.ID, &run.K, &offline, &run.Cases, &run.Skipped,
+		&metrics, &regressions, &results, &run.CreatedAt); err != nil {
+		return nil, err
+	}
+	run.Offline = offline == 1
+	_ = json.Unmarshal([]byte(metrics), &run.Metrics)
+	_ = json.Unmarshal([]byte(regressions), &run.Regressions)
+	_ = json.Unmarshal([]byte(results), &run.Results)
+	if run.Regressions == nil {
+		run.Regressions = []string{}
+	}
+	return &run, nil
+}
+
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonString marshals values that cannot fail to encode (strings, numbers
+// and structs of them).
+func jsonString(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
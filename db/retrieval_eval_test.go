@@ -0,0 +1,91 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetrievalGolden_SeedFromFollowedUpQueries(t *testing.T) {
+	d := openTestDB(t)
+	if _, err := d.LogRetrievalQuery("error wrapping", "governance", []string{".claude/rules/errors.md", "README.md"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.LogRetrievalQuery("error wrapping", "governance", []string{"docs/decisions/002-errors.md"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.LogRetrievalQuery("nobody cared", "", []string{"main.go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := d.MarkRetrievalFollowUps([]string{".claude/rules/errors.md", "docs/decisions/002-errors.md", "unrelated.go"}, time.Hour)
+	if err != nil || n != 2 {
+		t.Fatalf("follow-ups updated %d queries, err %v", n, err)
+	}
+	// Marking the same target again changes nothing.
+	if n, _ := d.MarkRetrievalFollowUps([]string{".claude/rules/errors.md"}, time.Hour); n != 0 {
+		t.Fatalf("repeat follow-up updated %d queries", n)
+	}
+
+	added, err := d.SeedRetrievalGolden(10)
+	if err != nil || added != 1 {
+		t.Fatalf("seeded %d, err %v", added, err)
+	}
+	cases, err := d.ListRetrievalGoldenCases()
+	if err != nil || len(cases) != 1 {
+		t.Fatalf("cases = %+v %v", cases, err)
+	}
+	c := cases[0]
+	if c.Query != "error wrapping" || c.Source != "seeded" || len(c.Expected) != 2 {
+		t.Fatalf("seeded case = %+v", c)
+	}
+
+	// A manual edit wins over re-seeding.
+	c.Expected = []string{".claude/rules/errors.md"}
+	c.Source = ""
+	if _, err := d.SaveRetrievalGoldenCase(&c); err != nil {
+		t.Fatal(err)
+	}
+	if added, _ := d.SeedRetrievalGolden(10); added != 0 {
+		t.Fatalf("re-seed added %d", added)
+	}
+	cases, _ = d.ListRetrievalGoldenCases()
+	if len(cases) != 1 || cases[0].Source != "manual" || len(cases[0].Expected) != 1 {
+		t.Fatalf("after manual edit = %+v", cases)
+	}
+}
+
+func TestRetrievalEvalRuns_History(t *testing.T) {
+	d := openTestDB(t)
+	for i, recall := range []float64{0.5, 0.8} {
+		run := &RetrievalEvalRun{
+			K: 10, Offline: true, Cases: 2,
+			Metrics: map[string]RetrievalMetrics{"all": {Cases: 2, Recall: recall}},
+			Results: []RetrievalCaseResult{{Query: "q", Expected: []string{"a"}, Hits: []string{"a"}, Recall: recall}},
+		}
+		if err := d.SaveRetrievalEvalRun(run); err != nil {
+			t.Fatal(err)
+		}
+		if run.ID != int64(i+1) || run.CreatedAt == "" {
+			t.Fatalf("saved run = %+v", run)
+		}
+	}
+	if err := d.SaveRetrievalEvalRun(&RetrievalEvalRun{K: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	last, err := d.LastRetrievalEvalRun(10, true)
+	if err != nil || last == nil || last.Metrics["all"].Recall != 0.8 {
+		t.Fatalf("last offline k=10 run = %+v %v", last, err)
+	}
+	if last, _ := d.LastRetrievalEvalRun(10, false); last != nil {
+		t.Fatalf("online baseline should not exist: %+v", last)
+	}
+	runs, err := d.ListRetrievalEvalRuns(10)
+	if err != nil || len(runs) != 3 || runs[0].K != 5 || len(runs[1].Results) != 0 {
+		t.Fatalf("runs = %+v %v", runs, err)
+	}
+	full, err := d.GetRetrievalEvalRun(2)
+	if err != nil || full == nil || len(full.Results) != 1 {
+		t.Fatalf("run 2 = %+v %v", full, err)
+	}
+}
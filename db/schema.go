@@ -46,6 +46,125 @@ CREATE TRIGGER IF NOT EXISTS events_ad AFTER DELETE ON events BEGIN
     INSERT INTO events_fts(events_fts, rowid, title, text, tags) VALUES ('delete', old.id, old.title, old.text, old.tags);
 END;
 
+-- Memory event embeddings for semantic search. vector is a little-endian
+-- float32 array, L2-normalized so a dot product is the cosine similarity.
+-- One row per event; switching models re-embeds in place.
+CREATE TABLE IF NOT EXISTS event_embeddings (
+    event_id   INTEGER PRIMARY KEY REFERENCES events(id) ON DELETE CASCADE,
+    model      TEXT    NOT NULL,
+    dims       INTEGER NOT NULL,
+    vector     BLOB    NOT NULL,
+    created_at TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_embeddings_model ON event_embeddings(model);
+
+-- Native code index. code_files records the content hash each file was
+-- last indexed at so reindexing skips unchanged files; code_chunks holds one
+-- row per function, type or text window with its L2-normalised embedding.
+CREATE TABLE IF NOT EXISTS code_files (
+    file_path  TEXT PRIMARY KEY,
+    file_hash  TEXT    NOT NULL,
+    model      TEXT    NOT NULL,
+    chunks     INTEGER NOT NULL DEFAULT 0,
+    indexed_at TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS code_chunks (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    file_path   TEXT    NOT NULL REFERENCES code_files(file_path) ON DELETE CASCADE,
+    chunk_index INTEGER NOT NULL,
+    kind        TEXT    NOT NULL DEFAULT '',
+    name        TEXT    NOT NULL DEFAULT '',
+    line_start  INTEGER NOT NULL,
+    line_end    INTEGER NOT NULL,
+    content     TEXT    NOT NULL,
+    model       TEXT    NOT NULL,
+    dims        INTEGER NOT NULL,
+    vector      BLOB    NOT NULL,
+    UNIQUE(file_path, chunk_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_code_chunks_model ON code_chunks(model, dims);
+
+-- Symbol index. symbol_files tracks the content hash of every file whose
+-- symbols are stored; code_symbols holds definitions, code_refs every use of
+-- a symbol inside another one (to_qname is empty when the target could not
+-- be resolved and only its name is known) and code_imports the import edges.
+CREATE TABLE IF NOT EXISTS symbol_files (
+    file_path  TEXT PRIMARY KEY,
+    file_hash  TEXT NOT NULL,
+    lang       TEXT NOT NULL,
+    indexed_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS code_symbols (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    file_path  TEXT    NOT NULL,
+    lang       TEXT    NOT NULL,
+    package    TEXT    NOT NULL DEFAULT '',
+    qname      TEXT    NOT NULL,
+    name       TEXT    NOT NULL,
+    container  TEXT    NOT NULL DEFAULT '',
+    kind       TEXT    NOT NULL,
+    line_start INTEGER NOT NULL,
+    line_end   INTEGER NOT NULL,
+    signature  TEXT    NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_code_symbols_name  ON code_symbols(name COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS idx_code_symbols_qname ON code_symbols(qname);
+CREATE INDEX IF NOT EXISTS idx_code_symbols_file  ON code_symbols(file_path);
+
+CREATE TABLE IF NOT EXISTS code_refs (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    file_path  TEXT    NOT NULL,
+    lang       TEXT    NOT NULL,
+    from_qname TEXT    NOT NULL,
+    to_qname   TEXT    NOT NULL DEFAULT '',
+    to_name    TEXT    NOT NULL,
+    kind       TEXT    NOT NULL,
+    line       INTEGER NOT NULL,
+    context    TEXT    NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_code_refs_to   ON code_refs(to_qname);
+CREATE INDEX IF NOT EXISTS idx_code_refs_name ON code_refs(to_name);
+CREATE INDEX IF NOT EXISTS idx_code_refs_from ON code_refs(from_qname);
+CREATE INDEX IF NOT EXISTS idx_code_refs_file ON code_refs(file_path);
+
+CREATE TABLE IF NOT EXISTS code_imports (
+    file_path   TEXT NOT NULL,
+    import_path TEXT NOT NULL,
+    PRIMARY KEY (file_path, import_path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_code_imports_path ON code_imports(import_path);
+
+-- Team sync. sync_meta holds the instance ID and transport cursors.
+-- sync_journal has one row per shared item (event, doc, proposal) with its
+-- last-writer-wins version; seq is the local change order served to peers.
+CREATE TABLE IF NOT EXISTS sync_meta (
+    key   TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sync_journal (
+    kind       TEXT    NOT NULL,
+    uid        TEXT    NOT NULL,
+    seq        INTEGER NOT NULL,
+    version_ms INTEGER NOT NULL,
+    writer     TEXT    NOT NULL,
+    hash       TEXT    NOT NULL,
+    local_hash TEXT    NOT NULL DEFAULT '', -- hash of the local row when last published or applied
+    local_id   TEXT    NOT NULL DEFAULT '',
+    payload    TEXT    NOT NULL,
+    PRIMARY KEY (kind, uid)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_journal_seq ON sync_journal(seq);
+CREATE INDEX IF NOT EXISTS idx_sync_journal_local ON sync_journal(kind, local_id);
+
 -- Sessions
 CREATE TABLE IF NOT EXISTS sessions (
     id                 INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -86,6 +205,51 @@ CREATE TRIGGER IF NOT EXISTS docs_ad AFTER DELETE ON docs BEGIN
     INSERT INTO docs_fts(docs_fts, rowid, title, content, doc_type) VALUES ('delete', old.id, old.title, old.content, old.doc_type);
 END;
 
+-- Governance files that were indexed and have since been deleted or
+-- excluded. Their chunks are gone from docs; the tombstone lets team sync
+-- propagate the deletion and reports what disappeared.
+CREATE TABLE IF NOT EXISTS doc_tombstones (
+    file_path  TEXT PRIMARY KEY,
+    project    TEXT NOT NULL DEFAULT '',
+    doc_type   TEXT NOT NULL DEFAULT '',
+    deleted_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+-- Retrieval evaluation: golden query → expected target pairs, the log of
+-- served retrieve calls they can be seeded from, and scored runs.
+CREATE TABLE IF NOT EXISTS retrieval_golden (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    query      TEXT NOT NULL,
+    corpus     TEXT NOT NULL DEFAULT '',
+    expected   TEXT NOT NULL DEFAULT '[]',
+    source     TEXT NOT NULL DEFAULT 'manual',
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    UNIQUE(query, corpus)
+);
+
+CREATE TABLE IF NOT EXISTS retrieval_query_log (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    query      TEXT NOT NULL,
+    corpus     TEXT NOT NULL DEFAULT '',
+    results    TEXT NOT NULL DEFAULT '[]',
+    followed   TEXT NOT NULL DEFAULT '[]',
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_retrieval_query_log_created ON retrieval_query_log(created_at);
+
+CREATE TABLE IF NOT EXISTS retrieval_eval_runs (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    k           INTEGER NOT NULL,
+    offline     INTEGER NOT NULL DEFAULT 0,
+    cases       INTEGER NOT NULL DEFAULT 0,
+    skipped     INTEGER NOT NULL DEFAULT 0,
+    metrics     TEXT    NOT NULL DEFAULT '{}',
+    regressions TEXT    NOT NULL DEFAULT '[]',
+    results     TEXT    NOT NULL DEFAULT '[]',
+    created_at  TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
 -- Orchestration workflows (replaces spec-state.json + bug-state.json)
 CREATE TABLE IF NOT EXISTS workflows (
     id         TEXT PRIMARY KEY,
@@ -97,6 +261,84 @@ CREATE TABLE IF NOT EXISTS workflows (
     updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
 );
 
+-- Append-only workflow event log. workflows.state_json is the projection of
+-- these events; data holds the event payload and phase the phase after it.
+CREATE TABLE IF NOT EXISTS workflow_events (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    workflow_id TEXT NOT NULL,
+    type        TEXT NOT NULL,
+    phase       TEXT NOT NULL DEFAULT '',
+    actor       TEXT NOT NULL DEFAULT '',
+    data        TEXT NOT NULL DEFAULT '{}',
+    created_at  TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_events_workflow ON workflow_events(workflow_id, id);
+
+-- Agent token spend and cost attributed to workflows. source is 'session'
+-- for deltas of the statusline's session totals, 'agent' for a delegated
+-- agent's usage reported by the agent_usage hook.
+CREATE TABLE IF NOT EXISTS workflow_costs (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    workflow_id   TEXT    NOT NULL,
+    phase         TEXT    NOT NULL DEFAULT '',
+    agent         TEXT    NOT NULL DEFAULT '',
+    session_id    TEXT    NOT NULL DEFAULT '',
+    source        TEXT    NOT NULL,
+    model         TEXT    NOT NULL DEFAULT '',
+    input_tokens  INTEGER NOT NULL DEFAULT 0,
+    output_tokens INTEGER NOT NULL DEFAULT 0,
+    cost_usd      REAL    NOT NULL DEFAULT 0,
+    estimated     INTEGER NOT NULL DEFAULT 0,
+    created_at    TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_costs_workflow ON workflow_costs(workflow_id);
+
+-- Last cumulative cost the statusline reported per Claude Code session and
+-- the workflow it was charged to, so each report can be turned into a delta.
+-- The first report for a workflow is its baseline.
+CREATE TABLE IF NOT EXISTS session_usage (
+    session_id  TEXT PRIMARY KEY,
+    workflow_id TEXT NOT NULL DEFAULT '',
+    cost_usd    REAL NOT NULL DEFAULT 0,
+    updated_at  TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+-- Tool calls streamed by the Streamer hook. summary is redacted before insert;
+-- redactions counts the secrets replaced.
+CREATE TABLE IF NOT EXISTS workflow_logs (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    workflow_id TEXT    NOT NULL DEFAULT '',
+    session_id  TEXT    NOT NULL DEFAULT '',
+    ts          TEXT    NOT NULL,
+    tool_name   TEXT    NOT NULL,
+    summary     TEXT    NOT NULL DEFAULT '',
+    redactions  INTEGER NOT NULL DEFAULT 0,
+    created_ms  INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_logs_workflow ON workflow_logs(workflow_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_logs_session ON workflow_logs(session_id);
+
+-- Results of executable phase checks run on workflow transitions, in the
+-- shape of swarm_evidence. verdict is pass or fail.
+CREATE TABLE IF NOT EXISTS workflow_evidence (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    workflow_id TEXT NOT NULL,
+    phase       TEXT NOT NULL DEFAULT '',
+    to_phase    TEXT NOT NULL DEFAULT '',
+    type        TEXT NOT NULL,
+    name        TEXT NOT NULL DEFAULT '',
+    content     TEXT NOT NULL DEFAULT '',
+    agent       TEXT NOT NULL DEFAULT '',
+    verdict     TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    created_at  TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_evidence_workflow ON workflow_evidence(workflow_id);
+
 -- Swarm: Missions (groups of coordinated tickets)
 CREATE TABLE IF NOT EXISTS missions (
     id               TEXT PRIMARY KEY,
@@ -415,6 +657,26 @@ CREATE TABLE IF NOT EXISTS insight_workflow_scorecards (
 CREATE INDEX IF NOT EXISTS idx_workflow_scorecards_type ON insight_workflow_scorecards(workflow_type);
 CREATE INDEX IF NOT EXISTS idx_workflow_scorecards_window ON insight_workflow_scorecards(window);
 
+-- Insight: MCP Tool Scorecards
+CREATE TABLE IF NOT EXISTS insight_tool_scorecards (
+    id TEXT PRIMARY KEY,
+    tool_name TEXT NOT NULL,
+    window TEXT NOT NULL,
+    window_start TEXT NOT NULL,
+    window_end TEXT NOT NULL,
+    total_calls INTEGER DEFAULT 0,
+    error_rate REAL DEFAULT 0,
+    avg_duration_ms INTEGER DEFAULT 0,
+    p95_duration_ms INTEGER DEFAULT 0,
+    confidence_score REAL DEFAULT 0,
+    trend TEXT DEFAULT 'stable',
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    UNIQUE(tool_name, window)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tool_scorecards_window ON insight_tool_scorecards(window);
+
 -- Insight: Routing Recommendations
 CREATE TABLE IF NOT EXISTS insight_routing_recommendations (
     id TEXT PRIMARY KEY,
@@ -910,6 +1172,37 @@ CREATE TABLE IF NOT EXISTS llm_token_usage (
 CREATE UNIQUE INDEX IF NOT EXISTS idx_llm_usage_date_sub
     ON llm_token_usage(date, subsystem);
 
+-- MCP tool-call audit log. args holds the redacted arguments as JSON.
+CREATE TABLE IF NOT EXISTS mcp_tool_calls (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id  TEXT    NOT NULL DEFAULT '',
+    workflow_id TEXT    NOT NULL DEFAULT '',
+    tool        TEXT    NOT NULL,
+    args        TEXT    NOT NULL DEFAULT '{}',
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    is_error    INTEGER NOT NULL DEFAULT 0,
+    error       TEXT    NOT NULL DEFAULT '',
+    created_at  TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_mcp_tool_calls_created ON mcp_tool_calls(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_mcp_tool_calls_tool ON mcp_tool_calls(tool, created_at);
+CREATE INDEX IF NOT EXISTS idx_mcp_tool_calls_workflow ON mcp_tool_calls(workflow_id);
+
+-- MCP tool-call daily rollup; tool = 'all' is the summary row.
+CREATE TABLE IF NOT EXISTS mcp_tool_metrics (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    metric_date     TEXT    NOT NULL,
+    tool            TEXT    NOT NULL DEFAULT 'all',
+    calls           INTEGER NOT NULL DEFAULT 0,
+    errors          INTEGER NOT NULL DEFAULT 0,
+    error_rate      REAL    NOT NULL DEFAULT 0,
+    avg_duration_ms INTEGER NOT NULL DEFAULT 0,
+    p95_duration_ms INTEGER NOT NULL DEFAULT 0,
+    computed_at     TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    UNIQUE(metric_date, tool)
+);
+
 -- Code Analysis: Runs
 CREATE TABLE IF NOT EXISTS code_analysis_runs (
     id                 TEXT PRIMARY KEY,
@@ -0,0 +1,313 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Symbol is a definition in the symbol index. QName identifies it across
+// the project: "<package>.<Name>" or "<package>.<Type>.<Method>" for Go and
+// "<file>:<Name>" for languages indexed by pattern.
+type Symbol struct {
+	FilePath  string `json:"file_path"`
+	Lang      string `json:"lang"`
+	Package   string `json:"package,omitempty"`
+	QName     string `json:"qname"`
+	Name      string `json:"name"`
+	Container string `json:"container,omitempty"`
+	Kind      string `json:"kind"`
+	LineStart int    `json:"line_start"`
+	LineEnd   int    `json:"line_end"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// DisplayName is the name with its receiver or class, e.g. "DB.SaveEvent".
+func (s Symbol) DisplayName() string {
+	if s.Container != "" {
+		return s.Container + "." + s.Name
+	}
+	return s.Name
+}
+
+// SymbolRef is a use of a symbol inside another one. ToQName is empty when
+// the target could not be resolved and only its name is known. Kind is
+// "call" or "ref".
+type SymbolRef struct {
+	FilePath  string `json:"file_path"`
+	Lang      string `json:"lang"`
+	FromQName string `json:"from_qname"`
+	ToQName   string `json:"to_qname,omitempty"`
+	ToName    string `json:"to_name"`
+	Kind      string `json:"kind"`
+	Line      int    `json:"line"`
+	Context   string `json:"context,omitempty"`
+}
+
+// SymbolFile is everything the symbol index stores for one source file.
+type SymbolFile struct {
+	Path    string
+	Hash    string
+	Lang    string
+	Symbols []Symbol
+	Refs    []SymbolRef
+	Imports []string
+}
+
+// SymbolIndexStats summarises the symbol index.
+type SymbolIndexStats struct {
+	Files     int    `json:"files"`
+	Symbols   int    `json:"symbols"`
+	Refs      int    `json:"refs"`
+	Imports   int    `json:"imports"`
+	IndexedAt string `json:"indexed_at,omitempty"`
+}
+
+// GetSymbolFileHash returns the hash a file's symbols were last extracted
+// from, or "" if the file is not indexed.
+func (d *DB) GetSymbolFileHash(filePath string) (string, error) {
+	var hash string
+	err := d.sql.QueryRow(`SELECT file_hash FROM symbol_files WHERE file_path = ?`, filePath).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get symbol file hash: %w", err)
+	}
+	return hash, nil
+}
+
+// ReplaceSymbolFile stores the symbols, references and imports of one file,
+// replacing whatever was stored for it before.
+func (d *DB) ReplaceSymbolFile(f SymbolFile) error {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"code_symbols", "code_refs", "code_imports"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE file_path = ?`, f.Path); err != nil {
+			return fmt.Errorf("replace symbol file: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO symbol_files (file_path, file_hash, lang, indexed_at)
+		VALUES (?, ?, ?, strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+		ON CONFLICT(file_path) DO UPDATE SET
+			file_hash = excluded.file_hash, lang = excluded.lang, indexed_at = excluded.indexed_at`,
+		f.Path, f.Hash, f.Lang); err != nil {
+		return fmt.Errorf("replace symbol file: %w", err)
+	}
+	for _, s := range f.Symbols {
+		if _, err := tx.Exec(`
+			INSERT INTO code_symbols (file_path, lang, package, qname, name, container, kind, line_start, line_end, signature)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			f.Path, f.Lang, s.Package, s.QName, s.Name, s.Container, s.Kind, s.LineStart, s.LineEnd, s.Signature); err != nil {
+			return fmt.Errorf("replace symbol file %s: %w", f.Path, err)
+		}
+	}
+	for _, r := range f.Refs {
+		if _, err := tx.Exec(`
+			INSERT INTO code_refs (file_path, lang, from_qname, to_qname, to_name, kind, line, context)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			f.Path, f.Lang, r.FromQName, r.ToQName, r.ToName, r.Kind, r.Line, r.Context); err != nil {
+			return fmt.Errorf("replace symbol file %s: %w", f.Path, err)
+		}
+	}
+	for _, imp := range f.Imports {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO code_imports (file_path, import_path) VALUES (?, ?)`, f.Path, imp); err != nil {
+			return fmt.Errorf("replace symbol file %s: %w", f.Path, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteSymbolFile drops a file from the symbol index.
+func (d *DB) DeleteSymbolFile(filePath string) error {
+	for _, table := range []string{"code_symbols", "code_refs", "code_imports", "symbol_files"} {
+		if _, err := d.sql.Exec(`DELETE FROM `+table+` WHERE file_path = ?`, filePath); err != nil {
+			return fmt.Errorf("delete symbol file: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListSymbolFiles returns the paths of all files in the symbol index.
+func (d *DB) ListSymbolFiles() ([]string, error) {
+	rows, err := d.sql.Query(`SELECT file_path FROM symbol_files ORDER BY file_path`)
+	if err != nil {
+		return nil, fmt.Errorf("list symbol files: %w", err)
+	}
+	defer rows.Close()
+	var paths []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, rows.Err()
+}
+
+const symbolColumns = `file_path, lang, package, qname, name, container, kind, line_start, line_end, signature`
+
+func scanSymbols(rows *sql.Rows) ([]Symbol, error) {
+	defer rows.Close()
+	var out []Symbol
+	for rows.Next() {
+		var s Symbol
+		if err := rows.Scan(&s.FilePath, &s.Lang, &s.Package, &s.QName, &s.Name, &s.Container,
+			&s.Kind, &s.LineStart, &s.LineEnd, &s.Signature); err != nil {
+			return nil, fmt.Errorf("scan symbol: %w", err)
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// FindSymbols looks up definitions by qualified name, "Type.Name" or bare
+// name, case-insensitively. Unless exact is set, names starting with or
+// containing query match too. Exact matches come first.
+func (d *DB) FindSymbols(query string, exact bool, limit int) ([]Symbol, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	like := "%" + escapeLike(query) + "%"
+	prefix := escapeLike(query) + "%"
+	cond := `qname = ?1 OR name = ?2 COLLATE NOCASE OR (container || '.' || name) = ?2 COLLATE NOCASE`
+	if !exact {
+		cond += ` OR name LIKE ?4 ESCAPE '\' OR (container || '.' || name) LIKE ?4 ESCAPE '\'`
+	}
+	rows, err := d.sql.Query(`
+		SELECT `+symbolColumns+` FROM code_symbols
+		WHERE `+cond+`
+		ORDER BY
+			CASE
+				WHEN qname = ?1 THEN 0
+				WHEN name = ?2 OR (container || '.' || name) = ?2 THEN 1
+				WHEN name = ?2 COLLATE NOCASE OR (container || '.' || name) = ?2 COLLATE NOCASE THEN 2
+				WHEN name LIKE ?3 ESCAPE '\' THEN 3
+				ELSE 4
+			END,
+			length(name), file_path, line_start
+		LIMIT ?5`,
+		query, query, prefix, like, limit)
+	if err != nil {
+		return nil, fmt.Errorf("find symbols: %w", err)
+	}
+	return scanSymbols(rows)
+}
+
+// GetSymbol returns the definition with the given qualified name, or nil.
+func (d *DB) GetSymbol(qname string) (*Symbol, error) {
+	rows, err := d.sql.Query(`SELECT `+symbolColumns+` FROM code_symbols WHERE qname = ? LIMIT 1`, qname)
+	if err != nil {
+		return nil, fmt.Errorf("get symbol: %w", err)
+	}
+	syms, err := scanSymbols(rows)
+	if err != nil || len(syms) == 0 {
+		return nil, err
+	}
+	return &syms[0], nil
+}
+
+const refColumns = `file_path, lang, from_qname, to_qname, to_name, kind, line, context`
+
+func scanRefs(rows *sql.Rows) ([]SymbolRef, error) {
+	defer rows.Close()
+	var out []SymbolRef
+	for rows.Next() {
+		var r SymbolRef
+		if err := rows.Scan(&r.FilePath, &r.Lang, &r.FromQName, &r.ToQName, &r.ToName,
+			&r.Kind, &r.Line, &r.Context); err != nil {
+			return nil, fmt.Errorf("scan symbol ref: %w", err)
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// FindReferences returns the uses of target: references resolved to its
+// qualified name first, then unresolved references in the same language
+// that only match by name. Calls sort before other references.
+func (d *DB) FindReferences(target Symbol, limit int) ([]SymbolRef, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := d.sql.Query(`
+		SELECT `+refColumns+` FROM code_refs
+		WHERE to_qname = ? OR (to_qname = '' AND to_name = ? AND lang = ?)
+		ORDER BY to_qname = '', kind != 'call', file_path, line
+		LIMIT ?`,
+		target.QName, target.Name, target.Lang, limit)
+	if err != nil {
+		return nil, fmt.Errorf("find references: %w", err)
+	}
+	return scanRefs(rows)
+}
+
+// FindCallees returns the references made from inside the symbol qname, in
+// source order.
+func (d *DB) FindCallees(qname string, limit int) ([]SymbolRef, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := d.sql.Query(`
+		SELECT `+refColumns+` FROM code_refs
+		WHERE from_qname = ?
+		ORDER BY kind != 'call', line
+		LIMIT ?`, qname, limit)
+	if err != nil {
+		return nil, fmt.Errorf("find callees: %w", err)
+	}
+	return scanRefs(rows)
+}
+
+// ListImporters returns the files importing importPath.
+func (d *DB) ListImporters(importPath string) ([]string, error) {
+	rows, err := d.sql.Query(`SELECT file_path FROM code_imports WHERE import_path = ? ORDER BY file_path`, importPath)
+	if err != nil {
+		return nil, fmt.Errorf("list importers: %w", err)
+	}
+	defer rows.Close()
+	var paths []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, rows.Err()
+}
+
+// GetSymbolIndexStats counts what the symbol index holds.
+func (d *DB) GetSymbolIndexStats() (SymbolIndexStats, error) {
+	var s SymbolIndexStats
+	var indexedAt sql.NullString
+	err := d.sql.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM symbol_files),
+			(SELECT COUNT(*) FROM code_symbols),
+			(SELECT COUNT(*) FROM code_refs),
+			(SELECT COUNT(*) FROM code_imports),
+			(SELECT MAX(indexed_at) FROM symbol_files)`).
+		Scan(&s.Files, &s.Symbols, &s.Refs, &s.Imports, &indexedAt)
+	if err != nil {
+		return s, fmt.Errorf("symbol index stats: %w", err)
+	}
+	s.IndexedAt = indexedAt.String
+	return s, nil
+}
+
+func escapeLike(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s)
+}
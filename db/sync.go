@@ -0,0 +1,302 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// SyncEntry is one shared item in the team sync journal. Version and Writer
+// order competing writes: the higher version wins, ties go to the greater
+// writer ID.
+type SyncEntry struct {
+	Kind      string          `json:"kind"`
+	UID       string          `json:"uid"`
+	Seq       int64           `json:"seq"`
+	Version   int64           `json:"version"`
+	Writer    string          `json:"writer"`
+	Hash      string          `json:"hash"`
+	LocalHash string          `json:"local_hash,omitempty"`
+	LocalID   string          `json:"local_id,omitempty"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// SyncKindCount counts journal entries of one kind by who wrote them.
+type SyncKindCount struct {
+	Kind   string `json:"kind"`
+	Local  int    `json:"local"`
+	Remote int    `json:"remote"`
+}
+
+// GetSyncMeta returns the value stored under key, or "" if none is.
+func (d *DB) GetSyncMeta(key string) (string, error) {
+	var v string
+	err := d.sql.QueryRow(`SELECT value FROM sync_meta WHERE key = ?`, key).Scan(&v)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get sync meta: %w", err)
+	}
+	return v, nil
+}
+
+// SetSyncMeta stores value under key.
+func (d *DB) SetSyncMeta(key, value string) error {
+	_, err := d.sql.Exec(`
+		INSERT INTO sync_meta (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`, key, value)
+	if err != nil {
+		return fmt.Errorf("set sync meta: %w", err)
+	}
+	return nil
+}
+
+// SyncInstanceID returns this database's sync identity, creating it on first
+// use. It survives restarts so peers can tell instances apart.
+func (d *DB) SyncInstanceID() (string, error) {
+	if _, err := d.sql.Exec(`INSERT OR IGNORE INTO sync_meta (key, value) VALUES ('instance_id', ?)`,
+		uuid.NewString()); err != nil {
+		return "", fmt.Errorf("create sync instance id: %w", err)
+	}
+	return d.GetSyncMeta("instance_id")
+}
+
+const syncEntryColumns = `kind, uid, seq, version_ms, writer, hash, local_hash, local_id, payload`
+
+func scanSyncEntries(rows *sql.Rows) ([]SyncEntry, error) {
+	var entries []SyncEntry
+	for rows.Next() {
+		var e SyncEntry
+		var payload string
+		if err := rows.Scan(&e.Kind, &e.UID, &e.Seq, &e.Version, &e.Writer,
+			&e.Hash, &e.LocalHash, &e.LocalID, &payload); err != nil {
+			return nil, err
+		}
+		e.Payload = json.RawMessage(payload)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (d *DB) getSyncEntry(where string, args ...any) (*SyncEntry, error) {
+	rows, err := d.sql.Query(`SELECT `+syncEntryColumns+` FROM sync_journal WHERE `+where+` LIMIT 1`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get sync entry: %w", err)
+	}
+	defer rows.Close()
+	entries, err := scanSyncEntries(rows)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// GetSyncEntry returns the journal entry for (kind, uid), or nil.
+func (d *DB) GetSyncEntry(kind, uid string) (*SyncEntry, error) {
+	return d.getSyncEntry(`kind = ? AND uid = ?`, kind, uid)
+}
+
+// GetSyncEntryByLocalID returns the journal entry mapped to a local row, or
+// nil.
+func (d *DB) GetSyncEntryByLocalID(kind, localID string) (*SyncEntry, error) {
+	return d.getSyncEntry(`kind = ? AND local_id = ?`, kind, localID)
+}
+
+// PutSyncEntry inserts or replaces the journal entry for (e.Kind, e.UID) and
+// moves it to the end of the change order. It returns the new seq.
+func (d *DB) PutSyncEntry(e SyncEntry) (int64, error) {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var seq int64
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(seq), 0) + 1 FROM sync_journal`).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("next sync seq: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO sync_journal (`+syncEntryColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(kind, uid) DO UPDATE SET
+			seq = excluded.seq, version_ms = excluded.version_ms, writer = excluded.writer,
+			hash = excluded.hash, local_hash = excluded.local_hash,
+			local_id = excluded.local_id, payload = excluded.payload`,
+		e.Kind, e.UID, seq, e.Version, e.Writer, e.Hash, e.LocalHash, e.LocalID, string(e.Payload),
+	); err != nil {
+		return 0, fmt.Errorf("put sync entry: %w", err)
+	}
+	return seq, tx.Commit()
+}
+
+// ListSyncEntries returns up to limit entries with seq greater than afterSeq
+// in change order. A non-empty writer restricts the result to that writer.
+func (d *DB) ListSyncEntries(afterSeq int64, writer string, limit int) ([]SyncEntry, error) {
+	if limit <= 0 {
+		limit = 500
+	}
+	rows, err := d.sql.Query(`
+		SELECT `+syncEntryColumns+` FROM sync_journal
+		WHERE seq > ? AND (? = '' OR writer = ?)
+		ORDER BY seq
+		LIMIT ?`, afterSeq, writer, writer, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list sync entries: %w", err)
+	}
+	defer rows.Close()
+	return scanSyncEntries(rows)
+}
+
+// SyncJournalCounts counts journal entries per kind, splitting those written
+// by self from those received from peers.
+func (d *DB) SyncJournalCounts(self string) ([]SyncKindCount, error) {
+	rows, err := d.sql.Query(`
+		SELECT kind,
+		       SUM(CASE WHEN writer = ? THEN 1 ELSE 0 END),
+		       SUM(CASE WHEN writer = ? THEN 0 ELSE 1 END)
+		FROM sync_journal GROUP BY kind ORDER BY kind`, self, self)
+	if err != nil {
+		return nil, fmt.Errorf("sync journal counts: %w", err)
+	}
+	defer rows.Close()
+	var counts []SyncKindCount
+	for rows.Next() {
+		var c SyncKindCount
+		if err := rows.Scan(&c.Kind, &c.Local, &c.Remote); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// UpdateSyncedEvent overwrites the content of event id with e, as received
+// from a peer. Local bookkeeping (importance decay, archiving) is kept. It
+// reports whether the event still exists.
+func (d *DB) UpdateSyncedEvent(id int64, e Event) (bool, error) {
+	var redactions int
+	e.Tags, e.Refs, redactions = redactEvent(&e.Title, &e.Text, e.Tags, e.Refs, nil)
+	tags, _ := json.Marshal(e.Tags)
+	refs, _ := json.Marshal(e.Refs)
+	if e.Tags == nil {
+		tags = []byte("[]")
+	}
+	if e.Refs == nil {
+		refs = []byte("{}")
+	}
+	res, err := d.sql.Exec(`
+		UPDATE events SET ts = ?, actor = ?, scope = ?, type = ?, text = ?, title = ?,
+		       tags = ?, refs = ?, ttl = ?, dedupe_key = ?, project = ?, redactions = ?
+		WHERE id = ?`,
+		e.Ts, e.Actor, e.Scope, e.Type, e.Text, e.Title,
+		string(tags), string(refs), e.TTL, e.DedupeKey, e.Project, redactions, id,
+	)
+	if err != nil {
+		return false, fmt.Errorf("update synced event: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// SyncedDocPrefix marks doc rows received from peers; they are never
+// re-published and never collide with locally indexed paths.
+const SyncedDocPrefix = "team://"
+
+// ListLocalDocs returns the locally indexed governance doc chunks ordered by
+// file and chunk.
+func (d *DB) ListLocalDocs() ([]Doc, error) {
+	rows, err := d.sql.Query(`
+		SELECT id, file_path, chunk_index, title, content, doc_type, file_hash, project, indexed_at
+		FROM docs
+		WHERE file_path NOT LIKE ? || '%'
+		ORDER BY file_path, chunk_index`, SyncedDocPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("list local docs: %w", err)
+	}
+	defer rows.Close()
+	var docs []Doc
+	for rows.Next() {
+		var doc Doc
+		if err := rows.Scan(&doc.ID, &doc.FilePath, &doc.ChunkIndex, &doc.Title,
+			&doc.Content, &doc.DocType, &doc.FileHash, &doc.Project, &doc.IndexedAt); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
+
+// ReplaceDocChunks replaces all chunks of filePath with chunks, numbered in
+// order.
+func (d *DB) ReplaceDocChunks(filePath, project, docType, fileHash string, chunks []Doc) error {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM docs WHERE file_path = ?`, filePath); err != nil {
+		return fmt.Errorf("replace doc chunks: %w", err)
+	}
+	for i, c := range chunks {
+		if _, err := tx.Exec(`
+			INSERT INTO docs (file_path, chunk_index, title, content, doc_type, file_hash, project)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			filePath, i, c.Title, c.Content, docType, fileHash, project,
+		); err != nil {
+			return fmt.Errorf("replace doc chunks: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// UpsertInsightProposal inserts p or overwrites the proposal with the same
+// ID, keeping p's timestamps.
+func (d *DB) UpsertInsightProposal(p *InsightProposal) error {
+	evidence, err := json.Marshal(p.Evidence)
+	if err != nil {
+		return fmt.Errorf("marshal evidence: %w", err)
+	}
+	recommendation, err := json.Marshal(p.Recommendation)
+	if err != nil {
+		return fmt.Errorf("marshal recommendation: %w", err)
+	}
+	if p.Evidence == nil {
+		evidence = []byte("{}")
+	}
+	if p.Recommendation == nil {
+		recommendation = []byte("{}")
+	}
+	var reason any
+	if p.DecisionReason != "" {
+		reason = p.DecisionReason
+	}
+	if p.CreatedAt == "" {
+		p.CreatedAt = now()
+	}
+	if p.UpdatedAt == "" {
+		p.UpdatedAt = p.CreatedAt
+	}
+	_, err = d.sql.Exec(`
+		INSERT INTO insight_proposals
+		(id, type, status, title, description, confidence, risk_level,
+		 source_pattern_id, evidence, recommendation, decision_reason, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			type = excluded.type, status = excluded.status, title = excluded.title,
+			description = excluded.description, confidence = excluded.confidence,
+			risk_level = excluded.risk_level, source_pattern_id = excluded.source_pattern_id,
+			evidence = excluded.evidence, recommendation = excluded.recommendation,
+			decision_reason = excluded.decision_reason, updated_at = excluded.updated_at`,
+		p.ID, p.Type, p.Status, p.Title, p.Description, p.Confidence, p.RiskLevel,
+		p.SourcePatternID, string(evidence), string(recommendation), reason,
+		p.CreatedAt, p.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("upsert insight proposal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// WorkflowCost is one entry of the workflow cost ledger.
+type WorkflowCost struct {
+	ID           int64   `json:"id"`
+	WorkflowID   string  `json:"workflow_id"`
+	Phase        string  `json:"phase"`
+	Agent        string  `json:"agent,omitempty"`
+	SessionID    string  `json:"session_id,omitempty"`
+	Source       string  `json:"source"` // session | agent
+	Model        string  `json:"model,omitempty"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+	Estimated    bool    `json:"estimated,omitempty"` // cost derived from list prices
+	CreatedAt    string  `json:"created_at"`
+}
+
+// WorkflowCostGroup sums a workflow's ledger entries sharing source, phase
+// and agent.
+type WorkflowCostGroup struct {
+	Source       string
+	Phase        string
+	Agent        string
+	InputTokens  int
+	OutputTokens int
+	CostUSD      float64
+	Estimated    bool // any entry of the group was estimated
+	Entries      int
+}
+
+// AddWorkflowCost appends an entry to the cost ledger.
+func (d *DB) AddWorkflowCost(c WorkflowCost) (int64, error) {
+	res, err := d.sql.Exec(`
+		INSERT INTO workflow_costs (workflow_id, phase, agent, session_id, source, model, input_tokens, output_tokens, cost_usd, estimated)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.WorkflowID, c.Phase, c.Agent, c.SessionID, c.Source, c.Model, c.InputTokens, c.OutputTokens, c.CostUSD, c.Estimated,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("insert workflow cost: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// WorkflowCostGroups returns a workflow's ledger summed by source, phase and
+// agent.
+func (d *DB) WorkflowCostGroups(workflowID string) ([]WorkflowCostGroup, error) {
+	rows, err := d.sql.Query(`
+		SELECT source, phase, agent, SUM(input_tokens), SUM(output_tokens), SUM(cost_usd), MAX(estimated), COUNT(*)
+		FROM workflow_costs WHERE workflow_id = ?
+		GROUP BY source, phase, agent ORDER BY source, phase, agent`, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("sum workflow costs: %w", err)
+	}
+	defer rows.Close()
+	var groups []WorkflowCostGroup
+	for rows.Next() {
+		var g WorkflowCostGroup
+		if err := rows.Scan(&g.Source, &g.Phase, &g.Agent, &g.InputTokens, &g.OutputTokens, &g.CostUSD, &g.Estimated, &g.Entries); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// ListWorkflowCosts returns a workflow's most recent ledger entries, newest
+// first.
+func (d *DB) ListWorkflowCosts(workflowID string, limit int) ([]WorkflowCost, error) {
+	rows, err := d.sql.Query(`
+		SELECT id, workflow_id, phase, agent, session_id, source, model, input_tokens, output_tokens, cost_usd, estimated, created_at
+		FROM workflow_costs WHERE workflow_id = ? ORDER BY id DESC LIMIT ?`, workflowID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list workflow costs: %w", err)
+	}
+	defer rows.Close()
+	costs := []WorkflowCost{}
+	for rows.Next() {
+		var c WorkflowCost
+		if err := rows.Scan(&c.ID, &c.WorkflowID, &c.Phase, &c.Agent, &c.SessionID, &c.Source, &c.Model,
+			&c.InputTokens, &c.OutputTokens, &c.CostUSD, &c.Estimated, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		costs = append(costs, c)
+	}
+	return costs, rows.Err()
+}
+
+// DeleteWorkflowCosts removes a workflow's ledger.
+func (d *DB) DeleteWorkflowCosts(workflowID string) error {
+	if _, err := d.sql.Exec(`DELETE FROM workflow_costs WHERE workflow_id = ?`, workflowID); err != nil {
+		return fmt.Errorf("delete workflow costs: %w", err)
+	}
+	return nil
+}
+
+// AdvanceSessionCost stores a session's cumulative cost and returns how much
+// it grew since the previous report for workflowID. The first report after
+// the session is mapped to workflowID only records the baseline and returns
+// 0, so spend from before the workflow, or charged to another one, is not
+// counted. A total lower than the stored one means the session restarted its
+// count, so the whole total is new.
+func (d *DB) AdvanceSessionCost(sessionID, workflowID string, totalUSD float64) (float64, error) {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var prev float64
+	var prevWorkflow string
+	err = tx.QueryRow(`SELECT workflow_id, cost_usd FROM session_usage WHERE session_id = ?`, sessionID).Scan(&prevWorkflow, &prev)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("get session usage: %w", err)
+	}
+	delta := totalUSD - prev
+	switch {
+	case err != nil || prevWorkflow != workflowID:
+		delta = 0
+	case delta < 0:
+		delta = totalUSD
+	}
+	_, err = tx.Exec(`
+		INSERT INTO session_usage (session_id, workflow_id, cost_usd, updated_at)
+		VALUES (?, ?, ?, strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+		ON CONFLICT(session_id) DO UPDATE SET workflow_id = excluded.workflow_id, cost_usd = excluded.cost_usd, updated_at = excluded.updated_at`,
+		sessionID, workflowID, totalUSD)
+	if err != nil {
+		return 0, fmt.Errorf("save session usage: %w", err)
+	}
+	return delta, tx.Commit()
+}
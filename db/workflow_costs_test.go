@@ -0,0 +1,62 @@
+package db
+
+import "testing"
+
+func TestAdvanceSessionCost(t *testing.T) {
+	d := openTestDB(t)
+	for _, step := range []struct {
+		workflow    string
+		total, want float64
+	}{
+		{"wf-1", 0.50, 0}, // baseline
+		{"wf-1", 0.75, 0.25},
+		{"wf-1", 0.75, 0},
+		{"wf-1", 0.10, 0.10}, // the session restarted its count
+		{"wf-2", 0.30, 0},    // mapped to another workflow: new baseline
+		{"wf-2", 0.40, 0.10},
+	} {
+		got, err := d.AdvanceSessionCost("sess-1", step.workflow, step.total)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := got - step.want; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%s total %.2f: delta = %.2f, want %.2f", step.workflow, step.total, got, step.want)
+		}
+	}
+}
+
+func TestWorkflowCostGroups(t *testing.T) {
+	d := openTestDB(t)
+	for _, c := range []WorkflowCost{
+		{WorkflowID: "wf-1", Phase: "implement", Agent: "delivery-backend-engineer", Source: "agent", InputTokens: 100, OutputTokens: 10, CostUSD: 0.2, Estimated: true},
+		{WorkflowID: "wf-1", Phase: "implement", Agent: "delivery-backend-engineer", Source: "agent", InputTokens: 50, OutputTokens: 5, CostUSD: 0.1},
+		{WorkflowID: "wf-1", Phase: "implement", Source: "session", CostUSD: 1},
+		{WorkflowID: "wf-2", Phase: "plan", Source: "session", CostUSD: 5},
+	} {
+		if _, err := d.AddWorkflowCost(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups, err := d.WorkflowCostGroups("wf-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("groups: %+v", groups)
+	}
+	agent := groups[0]
+	if agent.Source != "agent" || agent.InputTokens != 150 || agent.OutputTokens != 15 || !agent.Estimated || agent.Entries != 2 {
+		t.Errorf("agent group: %+v", agent)
+	}
+
+	if err := d.DeleteWorkflowCosts("wf-1"); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := d.ListWorkflowCosts("wf-1", 10); err != nil || len(entries) != 0 {
+		t.Errorf("after delete: %v %v", entries, err)
+	}
+	if entries, err := d.ListWorkflowCosts("wf-2", 10); err != nil || len(entries) != 1 {
+		t.Errorf("other workflow: %v %v", entries, err)
+	}
+}
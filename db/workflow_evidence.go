@@ -0,0 +1,52 @@
+package db
+
+import "fmt"
+
+// WorkflowEvidence is the recorded outcome of a phase check run when a
+// workflow tried to leave Phase for ToPhase.
+type WorkflowEvidence struct {
+	ID         int64  `json:"id"`
+	WorkflowID string `json:"workflow_id"`
+	Phase      string `json:"phase"`
+	ToPhase    string `json:"to_phase"`
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	Content    string `json:"content"`
+	Agent      string `json:"agent"`
+	Verdict    string `json:"verdict"`
+	DurationMs int64  `json:"duration_ms"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// CreateWorkflowEvidence inserts an evidence record and returns its ID.
+func (d *DB) CreateWorkflowEvidence(e WorkflowEvidence) (int64, error) {
+	res, err := d.sql.Exec(`
+		INSERT INTO workflow_evidence (workflow_id, phase, to_phase, type, name, content, agent, verdict, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.WorkflowID, e.Phase, e.ToPhase, e.Type, e.Name, e.Content, e.Agent, e.Verdict, e.DurationMs,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("insert workflow evidence: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// ListWorkflowEvidence returns a workflow's evidence, oldest first.
+func (d *DB) ListWorkflowEvidence(workflowID string) ([]WorkflowEvidence, error) {
+	rows, err := d.sql.Query(`
+		SELECT id, workflow_id, phase, to_phase, type, name, content, agent, verdict, duration_ms, created_at
+		FROM workflow_evidence WHERE workflow_id = ? ORDER BY id ASC`, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("list workflow evidence: %w", err)
+	}
+	defer rows.Close()
+	evidence := []WorkflowEvidence{}
+	for rows.Next() {
+		var e WorkflowEvidence
+		if err := rows.Scan(&e.ID, &e.WorkflowID, &e.Phase, &e.ToPhase, &e.Type, &e.Name, &e.Content, &e.Agent, &e.Verdict, &e.DurationMs, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		evidence = append(evidence, e)
+	}
+	return evidence, rows.Err()
+}
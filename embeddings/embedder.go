@@ -0,0 +1,145 @@
+// Package embeddings turns memory events into vectors for semantic search.
+// It talks to a local Ollama server or any OpenAI-compatible /embeddings
+// endpoint; the vectors themselves live in SQLite (see db.HybridSearchEvents).
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+)
+
+// Embedder converts texts into vectors. Implementations must return one
+// vector per input text, in order.
+type Embedder interface {
+	// Model identifies the embedding space; vectors from different models
+	// are never compared.
+	Model() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// New builds the embedder described by cfg.
+func New(cfg config.EmbeddingsConfig) (Embedder, error) {
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("embeddings: model is required")
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	c := &httpEmbedder{
+		model:   cfg.Model,
+		apiKey:  cfg.APIKey,
+		client:  &http.Client{Timeout: timeout},
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+	}
+	switch cfg.Provider {
+	case "", "ollama":
+		if c.baseURL == "" {
+			c.baseURL = "http://localhost:11434"
+		}
+		// Accept the OpenAI-compatible URL used by the LLM config too.
+		c.baseURL = strings.TrimSuffix(c.baseURL, "/v1")
+		c.ollama = true
+	case "openai":
+		if c.baseURL == "" {
+			c.baseURL = "https://api.openai.com/v1"
+		}
+	case "lmstudio":
+		if c.baseURL == "" {
+			c.baseURL = "http://localhost:1234/v1"
+		}
+	default:
+		if c.baseURL == "" {
+			return nil, fmt.Errorf("embeddings: base_url is required for provider %q", cfg.Provider)
+		}
+	}
+	if c.apiKey == "" && !c.ollama {
+		c.apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	return c, nil
+}
+
+// httpEmbedder calls Ollama's native /api/embed or an OpenAI-compatible
+// /embeddings endpoint.
+type httpEmbedder struct {
+	model   string
+	baseURL string
+	apiKey  string
+	ollama  bool
+	client  *http.Client
+}
+
+func (c *httpEmbedder) Model() string { return c.model }
+
+func (c *httpEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	url := c.baseURL + "/embeddings"
+	if c.ollama {
+		url = c.baseURL + "/api/embed"
+	}
+	body, _ := json.Marshal(map[string]any{"model": c.model, "input": texts})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("embeddings: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embeddings: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return nil, fmt.Errorf("embeddings: read response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("embeddings: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var vectors [][]float32
+	if c.ollama {
+		var out struct {
+			Embeddings [][]float32 `json:"embeddings"`
+		}
+		if err := json.Unmarshal(data, &out); err != nil {
+			return nil, fmt.Errorf("embeddings: decode response: %w", err)
+		}
+		vectors = out.Embeddings
+	} else {
+		var out struct {
+			Data []struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(data, &out); err != nil {
+			return nil, fmt.Errorf("embeddings: decode response: %w", err)
+		}
+		vectors = make([][]float32, len(out.Data))
+		for i, d := range out.Data {
+			idx := d.Index
+			if idx < 0 || idx >= len(vectors) {
+				idx = i
+			}
+			vectors[idx] = d.Embedding
+		}
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("embeddings: got %d vectors for %d inputs", len(vectors), len(texts))
+	}
+	return vectors, nil
+}
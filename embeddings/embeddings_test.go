@@ -0,0 +1,192 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+func TestEmbedder_Ollama(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("path = %s, want /api/embed", r.URL.Path)
+		}
+		var body struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Model != "nomic-embed-text" || len(body.Input) != 2 {
+			t.Errorf("body = %+v", body)
+		}
+		_, _ = w.Write([]byte(`{"embeddings":[[1,0],[0,1]]}`))
+	}))
+	defer srv.Close()
+
+	e, err := New(config.EmbeddingsConfig{Provider: "ollama", Model: "nomic-embed-text", BaseURL: srv.URL + "/v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vecs, err := e.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vecs) != 2 || vecs[1][1] != 1 {
+		t.Errorf("vecs = %v", vecs)
+	}
+}
+
+func TestEmbedder_OpenAICompatible(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("path = %s, want /v1/embeddings", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer k" {
+			t.Errorf("Authorization = %q", got)
+		}
+		// Out-of-order data must be placed by index.
+		_, _ = w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer srv.Close()
+
+	e, err := New(config.EmbeddingsConfig{Provider: "openai", Model: "text-embedding-3-small", BaseURL: srv.URL + "/v1", APIKey: "k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vecs, err := e.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vecs[0][0] != 1 || vecs[1][1] != 1 {
+		t.Errorf("vecs = %v", vecs)
+	}
+}
+
+// keywordEmbedder maps texts onto a tiny fixed vocabulary so tests can
+// reason about similarity.
+type keywordEmbedder struct{ calls int }
+
+func (k *keywordEmbedder) Model() string { return "keywords" }
+
+func (k *keywordEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	k.calls++
+	vocab := []string{"retry", "theme", "database"}
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		v := make([]float32, len(vocab))
+		for j, w := range vocab {
+			if strings.Contains(strings.ToLower(t), w) {
+				v[j] = 1
+			}
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func TestIndexer_BackfillAndSearch(t *testing.T) {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	for _, text := range []string{"retry with backoff", "dark theme colors", "database migrations", "retry budget per call"} {
+		if _, err := database.SaveEvent(db.SaveEventInput{Text: text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	emb := &keywordEmbedder{}
+	ix := NewIndexer(database, emb, 3)
+	n, err := ix.Backfill(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 || emb.calls != 2 {
+		t.Errorf("backfilled %d events in %d calls, want 4 in 2", n, emb.calls)
+	}
+	cov, _ := ix.Coverage()
+	if cov.Pending != 0 || cov.Embedded != 4 {
+		t.Errorf("coverage = %+v", cov)
+	}
+	if n, _ := ix.Backfill(context.Background(), 0); n != 0 {
+		t.Errorf("second backfill embedded %d events", n)
+	}
+
+	// "autoretry" is not a keyword match for "retry", but the vectors agree.
+	got, err := ix.Search(context.Background(), db.SearchEventsInput{Query: "autoretry"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d results, want the two retry events: %+v", len(got), got)
+	}
+	for _, e := range got {
+		if !strings.Contains(e.Text, "retry") {
+			t.Errorf("unexpected result %q", e.Text)
+		}
+	}
+}
+
+// rejectingEmbedder fails every request containing a text with reject.
+type rejectingEmbedder struct {
+	keywordEmbedder
+	reject string
+}
+
+func (r *rejectingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	for _, t := range texts {
+		if strings.Contains(t, r.reject) {
+			r.calls++
+			return nil, errors.New("input rejected")
+		}
+	}
+	return r.keywordEmbedder.Embed(ctx, texts)
+}
+
+func TestIndexer_BackfillSkipsFailingEvents(t *testing.T) {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	for _, text := range []string{"retry with backoff", "poison", "dark theme colors", "database migrations"} {
+		if _, err := database.SaveEvent(db.SaveEventInput{Text: text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ix := NewIndexer(database, &rejectingEmbedder{reject: "poison"}, 3)
+	n, err := ix.Backfill(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("backfilled %d events, want the 3 the provider accepts", n)
+	}
+	if cov, _ := ix.Coverage(); cov.Embedded != 3 || cov.Pending != 1 {
+		t.Errorf("coverage = %+v", cov)
+	}
+
+	// When nothing in a batch can be embedded, the backfill stops.
+	down := NewIndexer(database, &rejectingEmbedder{reject: ""}, 3)
+	if _, err := down.Backfill(context.Background(), 0); err == nil {
+		t.Error("expected an unavailable provider to fail the backfill")
+	}
+}
+
+func TestEventText(t *testing.T) {
+	got := EventText(db.Event{Title: "T", Text: strings.Repeat("x", maxTextRunes+10), Tags: []string{"a"}})
+	if !strings.HasPrefix(got, "T\n") || len([]rune(got)) != maxTextRunes {
+		t.Errorf("EventText length = %d", len([]rune(got)))
+	}
+}
@@ -0,0 +1,169 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+	"github.com/MartinNevlaha/stratus-v2/internal/scheduler"
+)
+
+// maxTextRunes caps the text sent per event; long plans would otherwise
+// overflow the context window of small local models.
+const maxTextRunes = 8000
+
+// Indexer keeps event embeddings up to date and serves hybrid search.
+type Indexer struct {
+	db        *db.DB
+	embedder  Embedder
+	batchSize int
+}
+
+// NewIndexer creates an Indexer. batchSize is the number of events embedded
+// per request during backfill.
+func NewIndexer(database *db.DB, embedder Embedder, batchSize int) *Indexer {
+	if batchSize <= 0 {
+		batchSize = 32
+	}
+	return &Indexer{db: database, embedder: embedder, batchSize: batchSize}
+}
+
+// Model returns the embedding model in use.
+func (ix *Indexer) Model() string { return ix.embedder.Model() }
+
+// EmbedEvent computes and stores the embedding of a single event.
+func (ix *Indexer) EmbedEvent(ctx context.Context, id int64) error {
+	events, err := ix.db.GetEventsByIDs([]int64{id})
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("event %d not found", id)
+	}
+	return ix.embed(ctx, events)
+}
+
+// Backfill embeds up to limit events that have no embedding from the current
+// model (limit <= 0 means all of them) and returns how many were embedded.
+//
+// When a batch fails, its events are embedded one at a time and those that
+// still fail are skipped until the next backfill, so one event the provider
+// rejects does not hold up the rest. A batch none of whose events can be
+// embedded stops the backfill with the error: the provider is likely down.
+func (ix *Indexer) Backfill(ctx context.Context, limit int) (int, error) {
+	done, seen := 0, 0
+	var after int64
+	for limit <= 0 || seen < limit {
+		n := ix.batchSize
+		if limit > 0 && limit-seen < n {
+			n = limit - seen
+		}
+		events, err := ix.db.EventsWithoutEmbedding(ix.Model(), after, n)
+		if err != nil {
+			return done, err
+		}
+		if len(events) == 0 {
+			break
+		}
+		after = events[len(events)-1].ID
+		seen += len(events)
+		if err := ix.embed(ctx, events); err == nil {
+			done += len(events)
+			continue
+		}
+
+		embedded := 0
+		var lastErr error
+		for _, e := range events {
+			if err := ix.embed(ctx, []db.Event{e}); err != nil {
+				log.Printf("embeddings: skipping event %d: %v", e.ID, err)
+				lastErr = err
+				continue
+			}
+			embedded++
+		}
+		if embedded == 0 {
+			return done, lastErr
+		}
+		done += embedded
+	}
+	return done, nil
+}
+
+// Coverage reports how many events are embedded with the current model.
+func (ix *Indexer) Coverage() (db.EmbeddingCoverage, error) {
+	return ix.db.GetEmbeddingCoverage(ix.Model())
+}
+
+// Search runs a hybrid search. If the query cannot be embedded the search
+// still runs on keywords, importance and recency.
+func (ix *Indexer) Search(ctx context.Context, in db.SearchEventsInput) ([]db.Event, error) {
+	hin := db.HybridSearchInput{SearchEventsInput: in, Model: ix.Model()}
+	vecs, err := ix.embedder.Embed(ctx, []string{in.Query})
+	if err != nil {
+		log.Printf("embeddings: embed query: %v (falling back to keyword ranking)", err)
+	} else {
+		hin.Vector = vecs[0]
+	}
+	return ix.db.HybridSearchEvents(hin)
+}
+
+// Run backfills missing embeddings every interval until ctx is cancelled.
+// A non-positive interval disables the loop.
+func (ix *Indexer) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	tick := func(ctx context.Context) {
+		n, err := ix.Backfill(ctx, 0)
+		if err != nil {
+			log.Printf("embeddings: backfill: %v", err)
+		}
+		if n > 0 {
+			log.Printf("embeddings: backfilled %d events", n)
+		}
+	}
+	tick(ctx)
+	if err := scheduler.New("embeddings", func() time.Duration { return interval }, tick).Run(ctx); err != nil && err != context.Canceled {
+		log.Printf("embeddings: scheduler: %v", err)
+	}
+}
+
+func (ix *Indexer) embed(ctx context.Context, events []db.Event) error {
+	texts := make([]string, len(events))
+	for i, e := range events {
+		texts[i] = EventText(e)
+	}
+	vecs, err := ix.embedder.Embed(ctx, texts)
+	if err != nil {
+		return err
+	}
+	for i, e := range events {
+		if err := ix.db.SaveEventEmbedding(e.ID, ix.Model(), vecs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EventText is the text embedded for an event: title, body and tags.
+func EventText(e db.Event) string {
+	var b strings.Builder
+	if e.Title != "" {
+		b.WriteString(e.Title)
+		b.WriteString("\n")
+	}
+	b.WriteString(e.Text)
+	if len(e.Tags) > 0 {
+		b.WriteString("\ntags: ")
+		b.WriteString(strings.Join(e.Tags, ", "))
+	}
+	s := b.String()
+	if r := []rune(s); len(r) > maxTextRunes {
+		s = string(r[:maxTextRunes])
+	}
+	return s
+}
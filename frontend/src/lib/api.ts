@@ -3,6 +3,15 @@ import type {
   Event,
   SearchResult,
   WorkflowState,
+  WorkflowDefinition,
+  WorkflowTemplate,
+  WorkflowEvidence,
+  WorkflowHistory,
+  WorkflowReplay,
+  Simulation,
+  CostSummary,
+  WorkflowCostEntry,
+  ApprovalDecision,
   ChangeSummary,
   VersionInfo,
   SwarmMission,
@@ -44,6 +53,7 @@ import type {
   CodeFinding,
   CodeQualityMetric,
   CodeAnalysisConfig,
+  RetrievalEvalRun,
 } from './types'
 
 const BASE = '/api'
@@ -120,15 +130,44 @@ export const getRetrieveStatus = () =>
 
 export const triggerReIndex = () => post<{ status: string }>('/retrieve/index')
 
+export const runRetrievalEval = (opts: { k?: number; offline?: boolean } = {}) =>
+  post<RetrievalEvalRun>('/retrieval/eval', { k: opts.k ?? 10, offline: opts.offline ?? false, save: true })
+
+export const listRetrievalEvalRuns = (limit = 20) =>
+  get<{ runs: RetrievalEvalRun[]; count: number }>('/retrieval/eval/runs', { limit: String(limit) })
+
+export const getRetrievalEvalRun = (id: number) => get<RetrievalEvalRun>(`/retrieval/eval/runs/${id}`)
+
+export const seedRetrievalGolden = () => post<{ added: number }>('/retrieval/golden/seed')
+
 // Workflows
 export const listWorkflows = () => get<WorkflowState[]>('/workflows')
+
+export const listWorkflowDefinitions = () =>
+  get<{ definitions: WorkflowDefinition[]; errors: string[] }>('/workflow-definitions')
+export const listWorkflowTemplates = () => get<{ templates: WorkflowTemplate[] }>('/workflow-templates')
+export const listWorkflowEvidence = (id: string) =>
+  get<{ evidence: WorkflowEvidence[] }>(`/workflows/${id}/evidence`)
+export const resolveApproval = (id: string, decision: ApprovalDecision['decision'], actor: string, comment = '') =>
+  post<WorkflowState>(`/workflows/${id}/approval`, { decision, actor, comment })
+export const getWorkflowCosts = (id: string) =>
+  get<{ summary: CostSummary; entries: WorkflowCostEntry[] }>(`/workflows/${id}/costs`, { limit: '20' })
+export const setWorkflowBudget = (id: string, budgetUsd: number) =>
+  put<WorkflowState>(`/workflows/${id}/budget`, { budget_usd: budgetUsd })
+export const getWorkflowHistory = (id: string) => get<WorkflowHistory>(`/workflows/${id}/history`)
+export const getWorkflowReplay = (id: string, step?: number) =>
+  get<WorkflowReplay>(`/workflows/${id}/replay`, step === undefined ? undefined : { step: String(step) })
+export const simulateWorkflow = (id: string, definition: unknown) =>
+  post<Simulation>(`/workflows/${id}/simulate`, { definition })
+export const rewindWorkflow = (id: string, phase: string, reason: string) =>
+  post<WorkflowState>(`/workflows/${id}/rewind`, { phase, reason, actor: 'dashboard' })
 export const deleteWorkflow = (id: string) => del<{ deleted: boolean }>(`/workflows/${id}`)
 export const listPastItems = (limit = 20, offset = 0) =>
   get<PastItemsResponse>('/past', { limit: String(limit), offset: String(offset) })
 export const analyzeWorkflow = (description: string, filesHint?: string[]) =>
   post<AnalysisResult>('/workflows/analyze', { description, files_hint: filesHint ?? [] })
 
-export const startWorkflow = (id: string, type: 'spec' | 'bug', title: string, complexity = 'simple') =>
+export const startWorkflow = (id: string, type: string, title: string, complexity = 'simple') =>
   post<WorkflowState>('/workflows', { id, type, title, complexity })
 
 export const getWorkflow = (id: string) => get<WorkflowState>(`/workflows/${id}`)
@@ -98,7 +98,7 @@ export function initStore() {
     appState.connected = false
   })
 
-  const updateTypes = ['workflow_updated', 'workflow_aborted', 'workflow_deleted', 'event_saved', 'governance_indexed']
+  const updateTypes = ['workflow_updated', 'workflow_aborted', 'workflow_deleted', 'event_saved', 'governance_indexed', 'approval_requested', 'approval_resolved', 'workflow_escalated']
   for (const type of updateTypes) {
     wsClient.on(type, () => { refreshDashboard() })
   }
@@ -32,7 +32,7 @@ export interface ChangeSummary {
 
 export interface WorkflowState {
   id: string
-  type: 'spec' | 'bug' | 'e2e'
+  type: string
   phase: string
   complexity: 'simple' | 'complex'
   delegated_agents: Record<string, string[]>
@@ -46,10 +46,239 @@ export interface WorkflowState {
   design_content?: string
   base_commit?: string
   change_summary?: ChangeSummary
+  loops?: Record<string, number>
+  approval?: Approval
+  parent_id?: string
+  children?: string[]
+  template?: string
+  escalations?: Escalation[]
+  phase_entered_at?: string
+  budget_usd?: number
   created_at: string
   updated_at: string
 }
 
+export interface ApprovalDecision {
+  actor: string
+  decision: 'approve' | 'reject' | 'comment'
+  comment?: string
+  at: string
+}
+
+export interface Approval {
+  from: string
+  to: string
+  status: 'pending' | 'approved' | 'rejected'
+  reviewers?: string[]
+  message?: string
+  requested_at: string
+  decisions: ApprovalDecision[]
+}
+
+export interface Escalation {
+  trigger: 'workflow_timeout' | 'phase_timeout' | 'loop_limit'
+  phase: string
+  action: 'delegate' | 'approval' | 'abort'
+  agent?: string
+  reason: string
+  message?: string
+  at: string
+}
+
+export interface CostTotals {
+  cost_usd: number
+  input_tokens: number
+  output_tokens: number
+}
+
+export interface CostSummary {
+  workflow_id: string
+  total: CostTotals
+  by_phase: Record<string, CostTotals>
+  by_agent: Record<string, CostTotals>
+  estimated: boolean
+  budget_usd?: number
+  over_budget: boolean
+}
+
+export interface WorkflowCostEntry {
+  id: number
+  workflow_id: string
+  phase: string
+  agent?: string
+  session_id?: string
+  source: 'session' | 'agent'
+  model?: string
+  input_tokens: number
+  output_tokens: number
+  cost_usd: number
+  estimated?: boolean
+  created_at: string
+}
+
+export interface EscalationPolicy {
+  action: 'delegate' | 'approval' | 'abort'
+  agent?: string
+  reviewers?: string[]
+  message?: string
+}
+
+export interface TimeBudget extends EscalationPolicy {
+  timeout: string
+}
+
+export interface ReadinessGate {
+  to?: string
+  check: 'tasks_defined' | 'tasks_done' | 'plan_set' | 'design_set' | 'delegated'
+  agent?: string
+  message?: string
+}
+
+export interface PhaseCheck {
+  name: string
+  to?: string
+  command?: string[]
+  alerts?: string
+  timeout_sec?: number
+  if_exists?: string
+}
+
+export interface WorkflowEvidence {
+  id: number
+  workflow_id: string
+  phase: string
+  to_phase: string
+  type: string
+  name: string
+  content: string
+  agent: string
+  verdict: 'pass' | 'fail'
+  duration_ms: number
+  created_at: string
+}
+
+export interface WorkflowEvent {
+  id: number
+  workflow_id: string
+  type: string
+  phase: string
+  actor?: string
+  data: Record<string, unknown>
+  created_at: string
+}
+
+export interface PhaseVisit {
+  phase: string
+  via: string
+  entered_at: string
+  left_at?: string
+  duration_ms: number
+}
+
+export interface WorkflowHistory {
+  workflow_id: string
+  events: WorkflowEvent[]
+  visits: PhaseVisit[]
+  time_in_phase_ms: Record<string, number>
+  loops: Record<string, number>
+  rewinds: number
+}
+
+export interface WorkflowLog {
+  id: number
+  workflow_id: string
+  session_id: string
+  ts: string
+  tool_name: string
+  summary: string
+  created_ms: number
+  redactions?: number
+}
+
+export type ReplayEntryKind = 'event' | 'tool_call' | 'trajectory_step' | 'alert' | 'evidence'
+
+export interface ReplayEntry {
+  seq: number
+  at: string
+  kind: ReplayEntryKind
+  phase: string
+  summary: string
+  event?: WorkflowEvent
+  tool_call?: WorkflowLog
+  step?: Record<string, unknown>
+  alert?: GuardianAlert
+  evidence?: WorkflowEvidence
+}
+
+export interface WorkflowReplay {
+  workflow_id: string
+  type: string
+  title: string
+  entries: ReplayEntry[]
+  visits: PhaseVisit[]
+  state?: WorkflowState
+}
+
+export type SimOutcome = 'allowed' | 'blocked' | 'awaiting_approval' | 'escalated' | 'unverified'
+
+export interface SimVerdict {
+  outcome: SimOutcome
+  reason?: string
+}
+
+export interface SimStep {
+  seq: number
+  at: string
+  kind: 'transition' | 'delegation' | 'write'
+  phase: string
+  subject: string
+  recorded?: SimOutcome
+  baseline: SimVerdict
+  simulated: SimVerdict
+  differs: boolean
+}
+
+export interface Simulation {
+  workflow_id: string
+  type: string
+  steps: SimStep[]
+  differences: number
+  first_difference?: number
+}
+
+export interface PhaseDefinition {
+  name: string
+  next?: string[]
+  agents: string[] | null
+  read_only?: boolean
+  complexity?: 'simple' | 'complex'
+  gates?: ReadinessGate[]
+  checks?: PhaseCheck[]
+  max_loops?: Record<string, number>
+  on_max_loops?: EscalationPolicy
+  time_budget?: TimeBudget
+}
+
+export interface WorkflowDefinition {
+  type: string
+  description?: string
+  initial_phase: string
+  phases: PhaseDefinition[]
+  time_budget?: TimeBudget
+  source?: string
+}
+
+export interface WorkflowTemplate {
+  name: string
+  type: string
+  description?: string
+  complexity?: 'simple' | 'complex'
+  tasks?: string[]
+  plan?: string
+  delegated?: Record<string, string[]>
+  source?: string
+}
+
 export interface Task {
   index: number
   title: string
@@ -65,6 +294,36 @@ export interface SearchResult {
   doc_type?: string
 }
 
+export interface RetrievalMetrics {
+  cases: number
+  recall: number
+  mrr: number
+  ndcg: number
+}
+
+export interface RetrievalCaseResult {
+  query: string
+  corpus: string
+  expected: string[]
+  hits: string[]
+  recall: number
+  rr: number
+  ndcg: number
+  skipped?: string
+}
+
+export interface RetrievalEvalRun {
+  id: number
+  k: number
+  offline: boolean
+  cases: number
+  skipped: number
+  metrics: Record<string, RetrievalMetrics>
+  regressions: string[]
+  results?: RetrievalCaseResult[]
+  created_at: string
+}
+
 export interface DashboardState {
   workflows: WorkflowState[]
   recent_events: Event[]
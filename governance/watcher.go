@@ -0,0 +1,226 @@
+// Package governance keeps the governance doc index in step with the
+// project: it turns config into index options and watches governance files
+// so edits are searchable without a manual reindex.
+package governance
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Options converts the governance config into index options.
+func Options(cfg config.GovernanceConfig) db.GovernanceOptions {
+	opts := db.GovernanceOptions{Exclude: cfg.Exclude}
+	for _, s := range cfg.Include {
+		if s.Pattern == "" {
+			continue
+		}
+		docType := s.DocType
+		if docType == "" {
+			docType = "custom"
+		}
+		opts.Sources = append(opts.Sources, db.DocSource{Pattern: s.Pattern, DocType: docType})
+	}
+	return opts
+}
+
+// Watcher reindexes governance files when they change on disk. It watches
+// the directories named by the sources (recursively below the fixed prefix
+// of a "**" pattern such as .claude/skills/**). Patterns that span the
+// whole tree, like **/CLAUDE.md, are only watched at the project root;
+// deeper matches are picked up by the regular reindex.
+type Watcher struct {
+	db       *db.DB
+	root     string
+	opts     db.GovernanceOptions
+	debounce time.Duration
+
+	// OnIndexed, if set, is called after a batch of changes touched the
+	// index.
+	OnIndexed func(db.GovernanceReport)
+
+	dirs      map[string]bool // exact directories to watch, with their ancestors
+	recursive []string        // directories watched with everything below them
+
+	mu      sync.Mutex
+	pending map[string]bool
+	timer   *time.Timer
+}
+
+// NewWatcher constructs a watcher for root. It does not start watching —
+// call Run.
+func NewWatcher(database *db.DB, root string, cfg config.GovernanceConfig) *Watcher {
+	w := &Watcher{
+		db:       database,
+		root:     root,
+		opts:     Options(cfg),
+		debounce: 500 * time.Millisecond,
+		dirs:     map[string]bool{".": true},
+		pending:  map[string]bool{},
+	}
+	for _, s := range append(db.DefaultDocSources(), w.opts.Sources...) {
+		dir := path.Dir(s.Pattern)
+		if i := strings.Index(s.Pattern, "**"); i >= 0 {
+			dir = path.Clean(s.Pattern[:i])
+			if dir != "." {
+				w.recursive = append(w.recursive, dir)
+			}
+		}
+		for ; dir != "."; dir = path.Dir(dir) {
+			w.dirs[dir] = true
+		}
+	}
+	return w
+}
+
+// Run blocks until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("governance watcher: new watcher: %w", err)
+	}
+	defer fw.Close()
+
+	for dir := range w.dirs {
+		w.watch(fw, filepath.Join(w.root, filepath.FromSlash(dir)))
+	}
+	slog.Info("governance watcher: running", "root", w.root)
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.mu.Lock()
+			if w.timer != nil {
+				w.timer.Stop()
+			}
+			w.mu.Unlock()
+			return ctx.Err()
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Create) {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					if w.wantDir(ev.Name) {
+						w.watch(fw, ev.Name)
+					}
+					continue
+				}
+			}
+			if _, ok := db.GovernanceDocType(w.root, w.opts, ev.Name); ok {
+				w.schedule(ev.Name)
+			}
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("governance watcher: error", "err", err)
+		}
+	}
+}
+
+// watch adds dir, and everything below it when it sits in a recursive
+// source, and queues any governance files already there. A directory that
+// appears after startup may have been populated before it was watched.
+func (w *Watcher) watch(fw *fsnotify.Watcher, dir string) {
+	if !w.underRecursive(dir) {
+		if err := fw.Add(dir); err == nil {
+			w.scheduleExisting(dir)
+		}
+		return
+	}
+	_ = filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if err := fw.Add(p); err != nil {
+			slog.Warn("governance watcher: add path", "path", p, "err", err)
+			return filepath.SkipDir
+		}
+		w.scheduleExisting(p)
+		return nil
+	})
+}
+
+func (w *Watcher) scheduleExisting(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		p := filepath.Join(dir, e.Name())
+		if _, ok := db.GovernanceDocType(w.root, w.opts, p); ok && !e.IsDir() {
+			w.schedule(p)
+		}
+	}
+}
+
+func (w *Watcher) wantDir(dir string) bool {
+	rel, err := filepath.Rel(w.root, dir)
+	if err != nil {
+		return false
+	}
+	return w.dirs[filepath.ToSlash(rel)] || w.underRecursive(dir)
+}
+
+func (w *Watcher) underRecursive(dir string) bool {
+	rel, err := filepath.Rel(w.root, dir)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, base := range w.recursive {
+		if rel == base || strings.HasPrefix(rel, base+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// schedule queues path and (re)starts the debounce timer, so a burst of
+// writes from an editor becomes one reindex.
+func (w *Watcher) schedule(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending[path] = true
+	if w.timer != nil {
+		w.timer.Reset(w.debounce)
+		return
+	}
+	w.timer = time.AfterFunc(w.debounce, w.flush)
+}
+
+func (w *Watcher) flush() {
+	w.mu.Lock()
+	paths := make([]string, 0, len(w.pending))
+	for p := range w.pending {
+		paths = append(paths, p)
+	}
+	w.pending = map[string]bool{}
+	w.timer = nil
+	w.mu.Unlock()
+
+	report, err := w.db.IndexGovernancePaths(w.root, w.opts, paths)
+	if err != nil {
+		slog.Warn("governance watcher: reindex failed", "err", err)
+		return
+	}
+	if report.Indexed == 0 && report.Removed == 0 {
+		return
+	}
+	slog.Info("governance watcher: reindexed", "indexed", report.Indexed, "removed", report.Removed)
+	if w.OnIndexed != nil {
+		w.OnIndexed(report)
+	}
+}
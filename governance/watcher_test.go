@@ -0,0 +1,88 @@
+package governance
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+func TestWatcher_ReindexesRuleEditsAndDeletes(t *testing.T) {
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".claude"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewWatcher(database, root, config.GovernanceConfig{Watch: true})
+	w.debounce = 50 * time.Millisecond
+	indexed := make(chan db.GovernanceReport, 10)
+	w.OnIndexed = func(r db.GovernanceReport) { indexed <- r }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = w.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	time.Sleep(100 * time.Millisecond)
+
+	wait := func(what string) db.GovernanceReport {
+		t.Helper()
+		select {
+		case r := <-indexed:
+			return r
+		case <-time.After(3 * time.Second):
+			t.Fatalf("no reindex after %s", what)
+		}
+		return db.GovernanceReport{}
+	}
+
+	// .claude/rules does not exist yet: the watcher picks the new directory
+	// up from its parent.
+	rule := filepath.Join(root, ".claude", "rules", "sql.md")
+	if err := os.MkdirAll(filepath.Dir(rule), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rule, []byte("# SQL\n\nAlways use parameterised queries."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if r := wait("create"); r.Indexed != 1 {
+		t.Fatalf("create report = %+v", r)
+	}
+	if docs, _ := database.SearchDocs("parameterised", "rule", "", 5); len(docs) != 1 {
+		t.Fatalf("new rule not searchable: %+v", docs)
+	}
+
+	if err := os.Remove(rule); err != nil {
+		t.Fatal(err)
+	}
+	if r := wait("delete"); r.Removed != 1 {
+		t.Fatalf("delete report = %+v", r)
+	}
+	if docs, _ := database.SearchDocs("parameterised", "rule", "", 5); len(docs) != 0 {
+		t.Errorf("deleted rule still searchable: %+v", docs)
+	}
+}
+
+func TestOptions_DefaultsDocType(t *testing.T) {
+	opts := Options(config.GovernanceConfig{
+		Include: []config.GovernanceSource{{Pattern: "policies/*.md"}, {Pattern: ""}},
+		Exclude: []string{"*.draft.md"},
+	})
+	if len(opts.Sources) != 1 || opts.Sources[0].DocType != "custom" || len(opts.Exclude) != 1 {
+		t.Fatalf("options = %+v", opts)
+	}
+}
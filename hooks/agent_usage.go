@@ -0,0 +1,66 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// AgentUsage reports the tokens a delegated agent used, read from the
+// Agent/Task tool response, to the Stratus API so they are charged to the
+// workflow and phase the agent worked in.
+// It always allows the tool call — this is a best-effort side effect.
+func AgentUsage(event HookEvent) Decision {
+	if !isDelegationTool(event.ToolName) {
+		return Decision{Continue: true}
+	}
+	report := agentUsageReport(event)
+	if report == nil {
+		return Decision{Continue: true}
+	}
+	// Prefer the workflow named in the task; otherwise the server charges the
+	// session's workflow.
+	if wf, err := fetchWorkflowForTaskStrict(event.ToolInput, event.SessionID); err == nil && wf != nil {
+		report["workflow_id"], _ = wf["id"].(string)
+	}
+
+	body, _ := json.Marshal(report)
+	client := &http.Client{Timeout: 1 * time.Second}
+	req, err := http.NewRequest("POST", "http://localhost:"+getPort()+"/api/usage", bytes.NewReader(body))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}
+	return Decision{Continue: true}
+}
+
+// agentUsageReport builds the usage report for a finished delegation, or nil
+// when the tool response carries no usage.
+func agentUsageReport(event HookEvent) map[string]any {
+	usage, _ := event.ToolResponse["usage"].(map[string]any)
+	if usage == nil {
+		return nil
+	}
+	tokens := func(key string) int {
+		n, _ := usage[key].(float64)
+		return int(n)
+	}
+	report := map[string]any{
+		"session_id":         event.SessionID,
+		"agent":              delegatedAgentType(event),
+		"input_tokens":       tokens("input_tokens"),
+		"output_tokens":      tokens("output_tokens"),
+		"cache_read_tokens":  tokens("cache_read_input_tokens"),
+		"cache_write_tokens": tokens("cache_creation_input_tokens"),
+	}
+	if report["input_tokens"] == 0 && report["output_tokens"] == 0 {
+		return nil
+	}
+	if model, _ := event.ToolInput["model"].(string); model != "" {
+		report["model"] = model
+	}
+	return report
+}
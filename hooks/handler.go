@@ -19,6 +19,8 @@ type HookEvent struct {
 	ToolInput     map[string]any `json:"tool_input,omitempty"`
 	AgentID       string         `json:"agent_id,omitempty"`
 	AgentType     string         `json:"agent_type,omitempty"`
+	// ToolResponse is the tool's result on PostToolUse events.
+	ToolResponse map[string]any `json:"tool_response,omitempty"`
 	// TranscriptPath points at the JSONL transcript of the agent the hook fired for.
 	TranscriptPath string `json:"transcript_path,omitempty"`
 	// TeammateName and TeamName are only set on Agent Teams events (TeammateIdle,
@@ -35,7 +37,11 @@ type Decision struct {
 	// Nudge redirects the agent instead of stopping it: Claude Code injects Reason
 	// into the agent's conversation and lets it keep working. Only meaningful for
 	// stop-like events (TeammateIdle); PreToolUse guards deny with Continue=false.
+	// On PreToolUse a nudge lets the tool run and passes Reason along as context.
 	Nudge bool `json:"-"`
+	// Ask hands a PreToolUse call to the user for approval, with Reason as the
+	// prompt. Elsewhere it blocks like Continue=false.
+	Ask bool `json:"-"`
 }
 
 // Handler is a function that processes a hook event.
@@ -94,6 +100,21 @@ func nudgePayload(reason string) []byte {
 	return data
 }
 
+// preToolUsePayload is a PreToolUse hookSpecificOutput: with decision "ask"
+// Claude Code asks the user to approve the call, with "" it only adds reason
+// to the agent's context.
+func preToolUsePayload(decision, reason string) []byte {
+	out := map[string]string{"hookEventName": "PreToolUse"}
+	if decision != "" {
+		out["permissionDecision"] = decision
+		out["permissionDecisionReason"] = reason
+	} else {
+		out["additionalContext"] = reason
+	}
+	data, _ := json.Marshal(map[string]any{"hookSpecificOutput": out})
+	return data
+}
+
 func writeDecision(d Decision) {
 	data, _ := json.Marshal(d)
 	fmt.Println(string(data))
@@ -116,7 +137,14 @@ func Run(name string, handlers map[string]Handler) {
 	}
 
 	decision := h(event)
+	preToolUse := event.HookEventName == "PreToolUse"
 	switch {
+	case decision.Ask && preToolUse:
+		fmt.Println(string(preToolUsePayload("ask", decision.Reason)))
+		os.Exit(0)
+	case decision.Nudge && preToolUse:
+		fmt.Println(string(preToolUsePayload("", decision.Reason)))
+		os.Exit(0)
 	case decision.Nudge:
 		Nudge(decision.Reason)
 	case decision.Continue:
@@ -10,6 +10,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/orchestration"
 )
 
 const noActiveWorkflowReason = "No active workflow registered. Use mcp__stratus__register_workflow first."
@@ -21,39 +23,25 @@ const unresolvedWorkflowReason = "No workflow could be resolved for this delegat
 // Task prompt. Mirrors the OpenCode plugin regex so both runtimes resolve identically.
 var workflowIDRe = regexp.MustCompile(`\b(?:bug|spec|e2e)-[a-z0-9][a-z0-9-]{0,120}\b`)
 
-// phaseAgentAllowlist defines which delivery agents are allowed in each phase per workflow type.
-var phaseAgentAllowlist = map[string]map[string][]string{
-	"bug": {
-		"analyze": {"delivery-debugger", "delivery-strategic-architect", "delivery-system-architect", "Plan", "Explore"},
-		"fix": {
-			"delivery-backend-engineer", "delivery-frontend-engineer", "delivery-database-engineer",
-			"delivery-devops-engineer", "delivery-mobile-engineer", "delivery-implementation-expert",
-			"delivery-ux-designer", "delivery-qa-engineer",
-		},
-		"review": {"delivery-code-reviewer"},
-	},
-	"spec": {
-		"plan":       {"delivery-strategic-architect", "delivery-system-architect", "Plan", "Explore"},
-		"discovery":  {"delivery-debugger", "delivery-strategic-architect", "Explore"},
-		"design":     {"delivery-strategic-architect", "delivery-system-architect", "delivery-ux-designer"},
-		"governance": {"delivery-code-reviewer", "delivery-governance-checker"},
-		"accept":     {},
-		"implement": {
-			"delivery-backend-engineer", "delivery-frontend-engineer", "delivery-database-engineer",
-			"delivery-devops-engineer", "delivery-mobile-engineer", "delivery-implementation-expert",
-			"delivery-ux-designer", "delivery-qa-engineer",
-		},
-		"verify":   {"delivery-code-reviewer"},
-		"learn":    {},
-		"complete": {},
-	},
-	"e2e": {
-		"setup":    {"delivery-qa-engineer"},
-		"plan":     {"delivery-strategic-architect", "Plan"},
-		"generate": {"delivery-qa-engineer", "delivery-frontend-engineer"},
-		"heal":     {"delivery-debugger", "delivery-qa-engineer"},
-		"complete": {},
-	},
+// findWorkflowID returns the first workflow ID in text, trying the built-in
+// prefixes first and then the types of any custom workflow definitions.
+func findWorkflowID(text string) string {
+	if id := workflowIDRe.FindString(text); id != "" {
+		return id
+	}
+	var custom []string
+	for _, t := range orchestration.WorkflowTypes() {
+		switch t {
+		case orchestration.WorkflowSpec, orchestration.WorkflowBug, orchestration.WorkflowE2E:
+		default:
+			custom = append(custom, regexp.QuoteMeta(string(t)))
+		}
+	}
+	if len(custom) == 0 {
+		return ""
+	}
+	re := regexp.MustCompile(`\b(?:` + strings.Join(custom, "|") + `)-[a-z0-9][a-z0-9-]{0,120}\b`)
+	return re.FindString(text)
 }
 
 // PhaseGuard blocks disallowed tools during certain workflow phases.
@@ -70,13 +58,14 @@ func PhaseGuard(event HookEvent) Decision {
 	phase, _ := state["phase"].(string)
 	wtype, _ := state["type"].(string)
 
-	// During verify/review phase: block write tools for delivery agents.
+	// During read-only phases (spec verify, bug review and any custom phase
+	// declared read_only): block write tools for delivery agents.
 	//
 	// Bash is judged by its COMMAND, not by its name. A reviewer that cannot run
 	// `git diff` or the test suite cannot verify what it reviews -- it can only assert
 	// that the code reads correctly, which is the failure mode this whole phase exists
 	// to prevent. isWriteBashCommand is the same split BashWriteGuard already applies.
-	if (phase == "verify" && wtype == "spec") || (phase == "review" && wtype == "bug") {
+	if orchestration.PhaseReadOnly(orchestration.WorkflowType(wtype), orchestration.Phase(phase)) {
 		if isWriteTool(event.ToolName) && isDeliveryAgent(event) {
 			if event.ToolName == "Bash" {
 				command, _ := event.ToolInput["command"].(string)
@@ -165,7 +154,7 @@ func DelegationGuard(event HookEvent) Decision {
 	phase, _ := wf["phase"].(string)
 	wtype, _ := wf["type"].(string)
 
-	if !isAgentAllowedInPhase(subagentType, wtype, phase) {
+	if !isAgentAllowedInPhase(subagentType, wtype, phase) && !isEscalatedAgent(wf, subagentType, phase) {
 		allowed := getAllowedAgentsForPhase(wtype, phase)
 		return Decision{
 			Continue: false,
@@ -174,17 +163,49 @@ func DelegationGuard(event HookEvent) Decision {
 		}
 	}
 
+	if id, _ := wf["id"].(string); id != "" {
+		if reason := workflowBudgetBlock(id); reason != "" {
+			return Decision{Continue: false, Reason: reason}
+		}
+	}
+
 	return Decision{Continue: true}
 }
 
-// isAgentAllowedInPhase checks if the agent is allowed in the current phase.
-func isAgentAllowedInPhase(agentID, wtype, phase string) bool {
-	workflowAgents, ok := phaseAgentAllowlist[wtype]
-	if !ok {
-		return true
+// workflowBudgetBlock returns why a workflow that has spent its cost budget
+// may not delegate further, or "". Unlike the workflow lookup it fails open:
+// a cost ledger that cannot be read must not stop delivery work.
+func workflowBudgetBlock(id string) string {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get("http://localhost:" + getPort() + "/api/workflows/" + url.PathEscape(id) + "/costs?limit=0")
+	if err != nil {
+		return ""
 	}
-	allowedAgents, ok := workflowAgents[phase]
-	if !ok {
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	var costs struct {
+		Summary struct {
+			Total struct {
+				CostUSD float64 `json:"cost_usd"`
+			} `json:"total"`
+			BudgetUSD  float64 `json:"budget_usd"`
+			OverBudget bool    `json:"over_budget"`
+		} `json:"summary"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&costs) != nil || !costs.Summary.OverBudget {
+		return ""
+	}
+	return fmt.Sprintf("Workflow %q has spent $%.2f of its $%.2f budget. Ask the user to raise the budget from the dashboard before delegating further.",
+		id, costs.Summary.Total.CostUSD, costs.Summary.BudgetUSD)
+}
+
+// isAgentAllowedInPhase checks the phase's agent list in the workflow
+// definition. Unknown workflow types and phases allow any agent.
+func isAgentAllowedInPhase(agentID, wtype, phase string) bool {
+	allowedAgents, restricted := orchestration.PhaseAgents(orchestration.WorkflowType(wtype), orchestration.Phase(phase))
+	if !restricted {
 		return true
 	}
 	for _, a := range allowedAgents {
@@ -195,12 +216,36 @@ func isAgentAllowedInPhase(agentID, wtype, phase string) bool {
 	return false
 }
 
+// isEscalatedAgent reports whether an escalation delegated the agent into the
+// workflow's current phase, which admits it even where the phase's agent
+// list does not.
+func isEscalatedAgent(wf map[string]any, agentID, phase string) bool {
+	escalations, _ := wf["escalations"].([]any)
+	escalated := false
+	for _, e := range escalations {
+		m, _ := e.(map[string]any)
+		if m["action"] == "delegate" && m["agent"] == agentID {
+			escalated = true
+			break
+		}
+	}
+	if !escalated {
+		return false
+	}
+	delegated, _ := wf["delegated_agents"].(map[string]any)
+	agents, _ := delegated[phase].([]any)
+	for _, a := range agents {
+		if a == agentID {
+			return true
+		}
+	}
+	return false
+}
+
 // getAllowedAgentsForPhase returns the list of allowed agents for a phase.
 func getAllowedAgentsForPhase(wtype, phase string) []string {
-	if workflowAgents, ok := phaseAgentAllowlist[wtype]; ok {
-		if agents, ok := workflowAgents[phase]; ok {
-			return agents
-		}
+	if agents, restricted := orchestration.PhaseAgents(orchestration.WorkflowType(wtype), orchestration.Phase(phase)); restricted {
+		return agents
 	}
 	return []string{"(any)"}
 }
@@ -429,7 +474,7 @@ func fetchWorkflowForSessionStrict(sessionID string) (map[string]any, error) {
 //
 // Resolution order:
 //  1. Workflow ID present in the task text (unique match, or the session-owned one).
-//  2. Prefix-form ID (spec-/bug-/e2e-) not yet in dashboard state, fetched by ID.
+//  2. Prefix-form ID (spec-/bug-/e2e- or a custom type) not yet in dashboard state, fetched by ID.
 //  3. Session ownership — only when it resolves to a single workflow.
 //
 // When several workflows match without a disambiguating ID, it returns nil rather than
@@ -470,7 +515,7 @@ func fetchWorkflowForTaskStrict(toolInput map[string]any, sessionID string) (map
 	}
 
 	// 2. Prefix-form ID not present in dashboard state.
-	if id := workflowIDRe.FindString(taskText); id != "" {
+	if id := findWorkflowID(taskText); id != "" {
 		wf, err := fetchWorkflowByID(id)
 		if err != nil {
 			return nil, err
@@ -8,7 +8,10 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/orchestration"
 )
 
 func TestWorkflowExistenceGuardBlocksWithoutSessionWorkflow(t *testing.T) {
@@ -286,6 +289,20 @@ func TestDelegationGuardPhaseAgentMatching(t *testing.T) {
 			subagent:    "delivery-backend-engineer",
 			shouldAllow: false,
 		},
+		{
+			name:        "spec implement blocks debugger",
+			workflow:    map[string]any{"id": "wf", "session_id": "sess", "type": "spec", "phase": "implement"},
+			subagent:    "delivery-debugger",
+			shouldAllow: false,
+		},
+		{
+			name: "spec implement allows escalated debugger",
+			workflow: map[string]any{"id": "wf", "session_id": "sess", "type": "spec", "phase": "implement",
+				"delegated_agents": map[string]any{"implement": []any{"delivery-debugger"}},
+				"escalations":      []any{map[string]any{"trigger": "loop_limit", "action": "delegate", "agent": "delivery-debugger"}}},
+			subagent:    "delivery-debugger",
+			shouldAllow: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -309,6 +326,54 @@ func TestDelegationGuardPhaseAgentMatching(t *testing.T) {
 	}
 }
 
+func TestDelegationGuardBlocksOverBudget(t *testing.T) {
+	overBudget := true
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/dashboard/state", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(dashboardState{Workflows: []map[string]any{
+			{"id": "spec-a", "session_id": "sess", "type": "spec", "phase": "implement"},
+		}})
+	})
+	mux.HandleFunc("/api/workflows/spec-a/costs", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"summary": map[string]any{
+			"total": map[string]any{"cost_usd": 5.2}, "budget_usd": 5, "over_budget": overBudget,
+		}})
+	})
+	serveAPI(t, mux)
+
+	event := HookEvent{
+		ToolName:  "Task",
+		SessionID: "sess",
+		ToolInput: map[string]any{"subagent_type": "delivery-backend-engineer"},
+	}
+	if d := DelegationGuard(event); d.Continue || !strings.Contains(d.Reason, "$5.20 of its $5.00 budget") {
+		t.Fatalf("expected a budget block, got %+v", d)
+	}
+	overBudget = false
+	if d := DelegationGuard(event); !d.Continue {
+		t.Fatalf("expected allow within budget, got %q", d.Reason)
+	}
+}
+
+func TestAgentUsageReport(t *testing.T) {
+	event := HookEvent{
+		ToolName:  "Task",
+		SessionID: "sess",
+		ToolInput: map[string]any{"subagent_type": "delivery-qa-engineer", "model": "haiku"},
+		ToolResponse: map[string]any{"usage": map[string]any{
+			"input_tokens": 12.0, "output_tokens": 340.0, "cache_read_input_tokens": 5000.0,
+		}},
+	}
+	report := agentUsageReport(event)
+	if report["agent"] != "delivery-qa-engineer" || report["model"] != "haiku" ||
+		report["output_tokens"] != 340 || report["cache_read_tokens"] != 5000 {
+		t.Errorf("report: %v", report)
+	}
+	if agentUsageReport(HookEvent{ToolName: "Task"}) != nil {
+		t.Error("a response without usage reports nothing")
+	}
+}
+
 func TestFetchActiveWorkflowExactSessionMatchAmongParallel(t *testing.T) {
 	// Two parallel workflows in different phases. The resolver must return the one
 	// owned by the querying session, not whichever is first in the list.
@@ -504,6 +569,49 @@ func TestPhaseGuardBlocksBashWithoutCommandInReviewPhase(t *testing.T) {
 	}
 }
 
+// Custom workflow definitions drive the same guards: agent lists per phase and
+// read-only phases come from .stratus/workflows/*.json, not a Go table.
+func TestGuardsFollowCustomWorkflowDefinition(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, orchestration.WorkflowDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	def := `{"type": "refactor", "phases": [
+	  {"name": "implement", "next": ["verify"], "agents": ["delivery-backend-engineer"]},
+	  {"name": "verify", "next": ["complete"], "read_only": true, "agents": ["delivery-code-reviewer"]},
+	  {"name": "complete"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "refactor.json"), []byte(def), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := orchestration.LoadWorkflowDefinitions(root); err != nil {
+		t.Fatal(err)
+	}
+	empty := t.TempDir()
+	t.Cleanup(func() { _ = orchestration.LoadWorkflowDefinitions(empty) })
+
+	if !isAgentAllowedInPhase("delivery-backend-engineer", "refactor", "implement") {
+		t.Fatal("expected backend engineer to be allowed in refactor implement")
+	}
+	if isAgentAllowedInPhase("delivery-frontend-engineer", "refactor", "implement") {
+		t.Fatal("expected frontend engineer to be blocked in refactor implement")
+	}
+
+	setDashboardState(t, dashboardState{
+		Workflows: []map[string]any{
+			{"id": "refactor-a", "session_id": "session-a", "type": "refactor", "phase": "verify"},
+		},
+	})
+	decision := PhaseGuard(HookEvent{
+		ToolName:  "Edit",
+		SessionID: "session-a",
+		AgentType: "delivery-code-reviewer",
+	})
+	if decision.Continue {
+		t.Fatal("expected Edit to be blocked in a read-only custom phase")
+	}
+}
+
 func TestIsWriteBashCommand(t *testing.T) {
 	tests := []struct {
 		cmd      string
@@ -671,14 +779,20 @@ func TestBashWriteGuard(t *testing.T) {
 
 func setDashboardState(t *testing.T, state dashboardState) {
 	t.Helper()
-
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	serveAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/api/dashboard/state" {
 			http.NotFound(w, r)
 			return
 		}
 		_ = json.NewEncoder(w).Encode(state)
 	}))
+}
+
+// serveAPI points the hooks at a fake Stratus API.
+func serveAPI(t *testing.T, handler http.Handler) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
 	t.Cleanup(server.Close)
 
 	u, err := url.Parse(server.URL)
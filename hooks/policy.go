@@ -0,0 +1,577 @@
+package hooks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/internal/redact"
+	"github.com/MartinNevlaha/stratus-v2/orchestration"
+)
+
+// PolicyFile is the project's hook policy, relative to the project root.
+const PolicyFile = ".stratus/policy.json"
+
+// PolicyEventLog is the file in the project's data directory where
+// policy_guard records the events it evaluates when the policy sets record,
+// for `stratus policy test`. Tool input is redacted before it is written.
+const PolicyEventLog = "policy-events.jsonl"
+
+// policyEventLogLimit caps PolicyEventLog; a full log is rotated to .1.
+const policyEventLogLimit = 4 << 20
+
+// PolicyOutcome is what a matching policy rule does with a tool call.
+type PolicyOutcome string
+
+const (
+	// PolicyAllow lets the call through and ends evaluation, so later rules
+	// can be carved out. The built-in guards still apply.
+	PolicyAllow PolicyOutcome = "allow"
+	// PolicyDeny blocks the call with the rule's message.
+	PolicyDeny PolicyOutcome = "deny"
+	// PolicyNudge lets the call through and hands the message to the agent.
+	PolicyNudge PolicyOutcome = "nudge"
+	// PolicyRequireApproval asks the human at the terminal to approve the
+	// call. Outside PreToolUse it blocks.
+	PolicyRequireApproval PolicyOutcome = "require_approval"
+)
+
+// Policy is a project's declarative hook policy. Rules are evaluated in
+// order and the first match decides; when none matches, the built-in guards
+// alone decide.
+type Policy struct {
+	// Record appends every evaluated event to PolicyEventLog.
+	Record bool         `json:"record,omitempty"`
+	Rules  []PolicyRule `json:"rules"`
+}
+
+// PolicyRule matches tool calls and decides their outcome. Every condition
+// that is set must hold; a list matches when any of its entries does. Tool,
+// agent and subagent entries are globs as in path.Match, path entries are
+// globs where ** spans directories and a pattern without a slash matches
+// the file name.
+type PolicyRule struct {
+	Name string `json:"name"`
+	// Events are hook event names such as PreToolUse; empty matches any.
+	Events []string `json:"events,omitempty"`
+	Tools  []string `json:"tools,omitempty"`
+	// Paths match the files a tool call touches, relative to the session's
+	// working directory.
+	Paths []string `json:"paths,omitempty"`
+	// Input maps tool input fields to regular expressions their value must
+	// match, e.g. {"command": "^npm publish"}.
+	Input map[string]string `json:"input,omitempty"`
+	// BashWrites matches Bash commands the built-in detector classes as
+	// writing (true) or read-only (false).
+	BashWrites *bool `json:"bash_writes,omitempty"`
+	// Workflow is "active" or "none": whether the session has a workflow.
+	Workflow      string   `json:"workflow,omitempty"`
+	WorkflowTypes []string `json:"workflow_types,omitempty"`
+	Phases        []string `json:"phases,omitempty"`
+	// ReadOnlyPhase matches phases the workflow definition declares
+	// read_only (true) or not (false).
+	ReadOnlyPhase *bool `json:"read_only_phase,omitempty"`
+	// Agents match the agent making the call; the main session has none.
+	Agents []string `json:"agents,omitempty"`
+	// Subagents match the agent an Agent or Task call delegates to.
+	Subagents []string      `json:"subagents,omitempty"`
+	Sessions  []string      `json:"sessions,omitempty"`
+	Outcome   PolicyOutcome `json:"outcome"`
+	// Message is shown to the agent, or to the human asked for approval.
+	// {tool}, {path}, {agent}, {subagent}, {workflow_type}, {phase} and
+	// {rule} are filled in.
+	Message string `json:"message,omitempty"`
+
+	input map[string]*regexp.Regexp
+	paths []*regexp.Regexp
+}
+
+// PolicyWorkflow is the workflow a tool call was made in.
+type PolicyWorkflow struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Phase string `json:"phase"`
+}
+
+// PolicyResult is the decision of the rule that matched an event.
+type PolicyResult struct {
+	Rule    string        `json:"rule"`
+	Outcome PolicyOutcome `json:"outcome"`
+	Message string        `json:"message,omitempty"`
+}
+
+// ReadPolicy parses and validates a policy file.
+func ReadPolicy(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if err := p.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return &p, nil
+}
+
+// compile validates the rules and prepares their patterns.
+func (p *Policy) compile() error {
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if err := r.compile(); err != nil {
+			return fmt.Errorf("%s: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *PolicyRule) compile() error {
+	switch r.Outcome {
+	case PolicyAllow, PolicyDeny, PolicyRequireApproval:
+	case PolicyNudge:
+		if r.Message == "" {
+			return errors.New("a nudge needs a message")
+		}
+	default:
+		return fmt.Errorf("unknown outcome %q", r.Outcome)
+	}
+	switch r.Workflow {
+	case "", "active", "none":
+	default:
+		return fmt.Errorf("workflow must be active or none, not %q", r.Workflow)
+	}
+	if r.Workflow == "none" && (len(r.WorkflowTypes) > 0 || len(r.Phases) > 0 || r.ReadOnlyPhase != nil) {
+		return errors.New("workflow none cannot be combined with workflow conditions")
+	}
+	for _, list := range [][]string{r.Tools, r.Agents, r.Subagents} {
+		for _, g := range list {
+			if _, err := path.Match(g, ""); err != nil {
+				return fmt.Errorf("glob %q: %w", g, err)
+			}
+		}
+	}
+	r.paths = nil
+	for _, g := range r.Paths {
+		re, err := globRegexp(g)
+		if err != nil {
+			return fmt.Errorf("path %q: %w", g, err)
+		}
+		r.paths = append(r.paths, re)
+	}
+	r.input = map[string]*regexp.Regexp{}
+	for field, expr := range r.Input {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("input %s: %w", field, err)
+		}
+		r.input[field] = re
+	}
+	return nil
+}
+
+// globRegexp turns a path glob into a regular expression: * and ? stay
+// within a directory, ** spans any number of them.
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case c == '*' && strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case c == '*' && strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// needsWorkflow reports whether any rule looks at the session's workflow.
+func (p *Policy) needsWorkflow() bool {
+	for _, r := range p.Rules {
+		if r.Workflow != "" || len(r.WorkflowTypes) > 0 || len(r.Phases) > 0 || r.ReadOnlyPhase != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate returns the decision of the first rule matching the event made
+// in workflow wf (nil when there is none), or nil when no rule matches.
+func (p *Policy) Evaluate(event HookEvent, wf *PolicyWorkflow) *PolicyResult {
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.matches(event, wf) {
+			return &PolicyResult{Rule: r.Name, Outcome: r.Outcome, Message: r.message(event, wf)}
+		}
+	}
+	return nil
+}
+
+func (r *PolicyRule) matches(event HookEvent, wf *PolicyWorkflow) bool {
+	if len(r.Events) > 0 && !containsString(r.Events, event.HookEventName) {
+		return false
+	}
+	if len(r.Tools) > 0 && !matchGlob(r.Tools, event.ToolName) {
+		return false
+	}
+	if len(r.Sessions) > 0 && !containsString(r.Sessions, event.SessionID) {
+		return false
+	}
+	if len(r.Agents) > 0 && (event.AgentType == "" || !matchGlob(r.Agents, event.AgentType)) {
+		return false
+	}
+	if len(r.Subagents) > 0 {
+		sub := ""
+		if isDelegationTool(event.ToolName) {
+			sub = delegatedAgentType(event)
+		}
+		if sub == "" || !matchGlob(r.Subagents, sub) {
+			return false
+		}
+	}
+	if r.BashWrites != nil {
+		command, _ := event.ToolInput["command"].(string)
+		if event.ToolName != "Bash" || command == "" || isWriteBashCommand(command) != *r.BashWrites {
+			return false
+		}
+	}
+	for field, re := range r.input {
+		v, ok := event.ToolInput[field]
+		if !ok || !re.MatchString(inputString(v)) {
+			return false
+		}
+	}
+	if len(r.paths) > 0 && !r.matchPaths(toolPaths(event)) {
+		return false
+	}
+
+	switch r.Workflow {
+	case "active":
+		if wf == nil {
+			return false
+		}
+	case "none":
+		return wf == nil
+	}
+	if len(r.WorkflowTypes) == 0 && len(r.Phases) == 0 && r.ReadOnlyPhase == nil {
+		return true
+	}
+	if wf == nil {
+		return false
+	}
+	if len(r.WorkflowTypes) > 0 && !containsString(r.WorkflowTypes, wf.Type) {
+		return false
+	}
+	if len(r.Phases) > 0 && !containsString(r.Phases, wf.Phase) {
+		return false
+	}
+	if r.ReadOnlyPhase != nil {
+		readOnly := orchestration.PhaseReadOnly(orchestration.WorkflowType(wf.Type), orchestration.Phase(wf.Phase))
+		if readOnly != *r.ReadOnlyPhase {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *PolicyRule) matchPaths(paths []string) bool {
+	for _, p := range paths {
+		for i, re := range r.paths {
+			name := p
+			if !strings.Contains(r.Paths[i], "/") {
+				name = path.Base(p)
+			}
+			if re.MatchString(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *PolicyRule) message(event HookEvent, wf *PolicyWorkflow) string {
+	msg := r.Message
+	if msg == "" {
+		switch r.Outcome {
+		case PolicyDeny:
+			msg = "Blocked by policy rule {rule}."
+		case PolicyRequireApproval:
+			msg = "Policy rule {rule} requires approval."
+		}
+	}
+	var wtype, phase, sub, file string
+	if wf != nil {
+		wtype, phase = wf.Type, wf.Phase
+	}
+	if isDelegationTool(event.ToolName) {
+		sub = delegatedAgentType(event)
+	}
+	if paths := toolPaths(event); len(paths) > 0 {
+		file = paths[0]
+	}
+	return strings.NewReplacer(
+		"{tool}", event.ToolName,
+		"{path}", file,
+		"{agent}", event.AgentType,
+		"{subagent}", sub,
+		"{workflow_type}", wtype,
+		"{phase}", phase,
+		"{rule}", r.Name,
+	).Replace(msg)
+}
+
+// Decision turns the result into a hook decision.
+func (res *PolicyResult) Decision() Decision {
+	switch res.Outcome {
+	case PolicyDeny:
+		return Decision{Continue: false, Reason: res.Message}
+	case PolicyNudge:
+		return Decision{Continue: true, Nudge: true, Reason: res.Message}
+	case PolicyRequireApproval:
+		return Decision{Continue: false, Ask: true, Reason: res.Message}
+	}
+	return Decision{Continue: true}
+}
+
+func matchGlob(globs []string, s string) bool {
+	for _, g := range globs {
+		if ok, _ := path.Match(g, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// inputString renders a tool input value for matching; non-strings are
+// matched as JSON.
+func inputString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// toolPaths returns the files a tool call names, relative to the session's
+// working directory where they lie inside it, with forward slashes.
+func toolPaths(event HookEvent) []string {
+	var paths []string
+	for _, key := range []string{"file_path", "notebook_path", "path"} {
+		if p, _ := event.ToolInput[key].(string); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	if edits, _ := event.ToolInput["edits"].([]any); len(edits) > 0 {
+		for _, e := range edits {
+			m, _ := e.(map[string]any)
+			if p, _ := m["file_path"].(string); p != "" {
+				paths = append(paths, p)
+			}
+		}
+	}
+	for i, p := range paths {
+		if event.Cwd != "" && filepath.IsAbs(p) {
+			if rel, err := filepath.Rel(event.Cwd, p); err == nil && !strings.HasPrefix(rel, "..") {
+				p = rel
+			}
+		}
+		paths[i] = filepath.ToSlash(p)
+	}
+	return paths
+}
+
+// activePolicy is the policy loaded by LoadPolicy.
+var activePolicy struct {
+	sync.RWMutex
+	policy *Policy
+	log    string
+}
+
+// LoadPolicy loads projectRoot's PolicyFile for PolicyGuard, which records
+// into PolicyEventLog under dataDir. A missing file clears the policy; an
+// invalid one clears it and is reported.
+func LoadPolicy(projectRoot, dataDir string) error {
+	p, err := ReadPolicy(filepath.Join(projectRoot, PolicyFile))
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	activePolicy.Lock()
+	activePolicy.policy, activePolicy.log = p, filepath.Join(dataDir, PolicyEventLog)
+	activePolicy.Unlock()
+	return err
+}
+
+// PolicyGuard applies the project's hook policy. It runs beside the
+// built-in guards and cannot loosen them. Workflow conditions fail open:
+// when the Stratus API is unreachable no workflow is known.
+func PolicyGuard(event HookEvent) Decision {
+	activePolicy.RLock()
+	p, logFile := activePolicy.policy, activePolicy.log
+	activePolicy.RUnlock()
+	if p == nil {
+		return Decision{Continue: true}
+	}
+
+	var wf *PolicyWorkflow
+	if p.Record || p.needsWorkflow() {
+		wf = policyWorkflow(event)
+	}
+	res := p.Evaluate(event, wf)
+	if p.Record {
+		rec := PolicyRecord{At: time.Now().UTC().Format(time.RFC3339Nano), Event: event, Workflow: wf}
+		if res != nil {
+			rec.Rule, rec.Outcome = res.Rule, res.Outcome
+		}
+		recordPolicyEvent(logFile, rec)
+	}
+	if res == nil {
+		return Decision{Continue: true}
+	}
+	return res.Decision()
+}
+
+// policyWorkflow resolves the workflow of a tool call the way the guards
+// do: delegations by the workflow named in the prompt, other calls by
+// session.
+func policyWorkflow(event HookEvent) *PolicyWorkflow {
+	var wf map[string]any
+	if isDelegationTool(event.ToolName) {
+		wf, _ = fetchWorkflowForTaskStrict(event.ToolInput, event.SessionID)
+	} else {
+		wf = fetchActiveWorkflow(event.SessionID)
+	}
+	if wf == nil {
+		return nil
+	}
+	id, _ := wf["id"].(string)
+	wtype, _ := wf["type"].(string)
+	phase, _ := wf["phase"].(string)
+	return &PolicyWorkflow{ID: id, Type: wtype, Phase: phase}
+}
+
+// PolicyRecord is an evaluated hook event, one line of PolicyEventLog.
+type PolicyRecord struct {
+	At       string          `json:"at,omitempty"`
+	Event    HookEvent       `json:"event"`
+	Workflow *PolicyWorkflow `json:"workflow,omitempty"`
+	// Rule and Outcome are the policy's decision when the event was
+	// recorded; empty when no rule matched.
+	Rule    string        `json:"rule,omitempty"`
+	Outcome PolicyOutcome `json:"outcome,omitempty"`
+	// Expect is the outcome a hand-written test event requires; "allow"
+	// is also met when no rule matches.
+	Expect PolicyOutcome `json:"expect,omitempty"`
+}
+
+// recordPolicyEvent appends rec to the log with secrets in the tool input
+// and response redacted, best-effort.
+func recordPolicyEvent(file string, rec PolicyRecord) {
+	if rec.Event.ToolInput != nil {
+		rec.Event.ToolInput = redact.Value(rec.Event.ToolInput).(map[string]any)
+	}
+	if rec.Event.ToolResponse != nil {
+		rec.Event.ToolResponse = redact.Value(rec.Event.ToolResponse).(map[string]any)
+	}
+	if info, err := os.Stat(file); err == nil && info.Size() > policyEventLogLimit {
+		_ = os.Rename(file, file+".1")
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+}
+
+// PolicyReplay is a recorded event evaluated against a policy.
+type PolicyReplay struct {
+	Line    int           `json:"line"`
+	Record  PolicyRecord  `json:"record"`
+	Summary string        `json:"summary"` // the tool call, as the streamer logs it
+	Result  *PolicyResult `json:"result,omitempty"`
+	// Changed is set when the policy decides a recorded event differently
+	// than it was; Failed when the record's Expect is not met.
+	Changed bool `json:"changed"`
+	Failed  bool `json:"failed"`
+}
+
+// ReplayPolicy evaluates every recorded event in r, one PolicyRecord per
+// line, against p. Blank lines are skipped.
+func ReplayPolicy(p *Policy, r io.Reader) ([]PolicyReplay, error) {
+	var out []PolicyReplay
+	scan := bufio.NewScanner(r)
+	scan.Buffer(make([]byte, 0, 64<<10), 8<<20)
+	for n := 1; scan.Scan(); n++ {
+		line := bytes.TrimSpace(scan.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var rec PolicyRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return out, fmt.Errorf("line %d: %w", n, err)
+		}
+		res := p.Evaluate(rec.Event, rec.Workflow)
+		got := PolicyOutcome("")
+		var rule string
+		if res != nil {
+			got, rule = res.Outcome, res.Rule
+		}
+		rp := PolicyReplay{
+			Line:    n,
+			Record:  rec,
+			Summary: rec.Event.ToolName + ": " + streamerSummary(rec.Event),
+			Result:  res,
+		}
+		// Hand-written events carry no recorded decision to compare with.
+		if rec.At != "" {
+			rp.Changed = got != rec.Outcome || rule != rec.Rule
+		}
+		if rec.Expect != "" {
+			rp.Failed = got != rec.Expect && !(rec.Expect == PolicyAllow && got == "")
+		}
+		out = append(out, rp)
+	}
+	return out, scan.Err()
+}
@@ -59,7 +59,7 @@ func streamerSummary(event HookEvent) string {
 				}
 			}
 		}
-	case "Task":
+	case "Task", "Agent":
 		if t, _ := in["subagent_type"].(string); t != "" {
 			return t
 		}
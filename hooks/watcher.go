@@ -8,7 +8,8 @@ import (
 )
 
 // Watcher extracts modified file paths from Write/Edit/MultiEdit/NotebookEdit
-// tool inputs and queues them for vexor reindexing via the Stratus API.
+// tool inputs and queues them for code and symbol reindexing via the Stratus
+// API.
 // It always allows the tool call — this is a best-effort side effect.
 func Watcher(event HookEvent) Decision {
 	paths := watcherExtractPaths(event)
@@ -3,6 +3,7 @@ package insight
 import (
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/MartinNevlaha/stratus-v2/db"
@@ -23,6 +24,9 @@ func (e *Engine) RunAnalysis() error {
 	if err := e.database.CollectDailyMetrics(7); err != nil {
 		log.Printf("insight: failed to collect daily metrics: %v", err)
 	}
+	if err := e.database.CollectMCPToolMetrics(7); err != nil {
+		log.Printf("insight: failed to collect mcp tool metrics: %v", err)
+	}
 
 	// Get recent daily metrics (7 days)
 	dailyMetrics, err := e.database.GetRecentDailyMetrics(7)
@@ -229,7 +233,14 @@ func (e *Engine) RunAnalysis() error {
 		}
 	}
 
-	// Pattern 5: Analyze agent performance (if available)
+	// Pattern 5: MCP tool reliability
+	if toolMetrics, err := e.database.GetMCPToolMetrics(7, ""); err != nil {
+		log.Printf("insight: failed to get mcp tool metrics: %v", err)
+	} else if len(toolMetrics) > 0 {
+		patterns = append(patterns, analyzeMCPTools(toolMetrics, findings)...)
+	}
+
+	// Pattern 6: Analyze agent performance (if available)
 	// TODO: Implement agent metrics when available
 	// agentMetrics, err := e.database.GetAgentMetrics(30)
 	// if err == nil && len(agentMetrics) > 0 {
@@ -283,6 +294,65 @@ func (e *Engine) RunAnalysis() error {
 	return nil
 }
 
+// mcpToolErrorThreshold is the 7-day error rate above which an MCP tool is
+// reported as unreliable; mcpToolMinCalls keeps one-off failures out.
+const (
+	mcpToolErrorThreshold = 0.2
+	mcpToolMinCalls       = 5
+)
+
+// analyzeMCPTools summarises the MCP tool-call rollup into findings and flags
+// tools that fail often.
+func analyzeMCPTools(metrics []db.MCPToolMetric, findings map[string]any) []*db.InsightPattern {
+	type toolTotals struct {
+		calls, errors int
+		p95           int64
+	}
+	totals := map[string]*toolTotals{}
+	for _, m := range metrics {
+		t := totals[m.Tool]
+		if t == nil {
+			t = &toolTotals{}
+			totals[m.Tool] = t
+		}
+		t.calls += m.Calls
+		t.errors += m.Errors
+		if m.P95DurationMs > t.p95 {
+			t.p95 = m.P95DurationMs
+		}
+	}
+
+	if all := totals["all"]; all != nil && all.calls > 0 {
+		findings["mcp_tool_calls"] = all.calls
+		findings["mcp_tool_error_rate"] = float64(all.errors) / float64(all.calls)
+	}
+
+	var patterns []*db.InsightPattern
+	var failing []string
+	for tool, t := range totals {
+		if tool == "all" || t.calls < mcpToolMinCalls {
+			continue
+		}
+		rate := float64(t.errors) / float64(t.calls)
+		if rate >= mcpToolErrorThreshold {
+			failing = append(failing, fmt.Sprintf("%s: %d/%d calls failed (p95 %dms)", tool, t.errors, t.calls, t.p95))
+		}
+	}
+	if len(failing) > 0 {
+		sort.Strings(failing)
+		patterns = append(patterns, &db.InsightPattern{
+			PatternType: "reliability",
+			PatternName: "mcp_tool_errors",
+			Description: fmt.Sprintf("%d MCP tool(s) fail in at least %.0f%% of calls", len(failing), mcpToolErrorThreshold*100),
+			Frequency:   len(failing),
+			Confidence:  0.8,
+			Examples:    failing,
+			Metadata:    map[string]interface{}{"threshold": mcpToolErrorThreshold},
+		})
+	}
+	return patterns
+}
+
 // Helper functions
 func calculateAverage(values []float64) float64 {
 	if len(values) == 0 {
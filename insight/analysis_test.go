@@ -233,3 +233,34 @@ func TestAnalyzeMetricsHighSuccessRate(t *testing.T) {
 		t.Error("Expected high_success_rate pattern to be detected")
 	}
 }
+
+func TestAnalyzeMetricsMCPToolErrors(t *testing.T) {
+	database := setupTestDBWithData(t)
+
+	for i := 0; i < 10; i++ {
+		call := &db.MCPToolCall{Tool: "search", DurationMs: 20, IsError: i < 4}
+		if _, err := database.SaveMCPToolCall(call); err != nil {
+			t.Fatalf("SaveMCPToolCall: %v", err)
+		}
+		call = &db.MCPToolCall{Tool: "get_workflow", DurationMs: 5}
+		if _, err := database.SaveMCPToolCall(call); err != nil {
+			t.Fatalf("SaveMCPToolCall: %v", err)
+		}
+	}
+
+	engine := NewEngine(database, config.InsightConfig{Enabled: true, Interval: 1})
+	if err := engine.RunAnalysis(); err != nil {
+		t.Fatalf("RunAnalysis failed: %v", err)
+	}
+
+	pattern, err := database.FindPatternByName("mcp_tool_errors")
+	if err != nil {
+		t.Fatalf("FindPatternByName failed: %v", err)
+	}
+	if pattern == nil {
+		t.Fatal("Expected mcp_tool_errors pattern to be detected")
+	}
+	if len(pattern.Examples) != 1 {
+		t.Errorf("Expected only search to be flagged, got %v", pattern.Examples)
+	}
+}
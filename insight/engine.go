@@ -207,6 +207,7 @@ func (e *Engine) initScorecardEngine() {
 	scorecardStore := scorecards.NewDBScorecardStore(e.database)
 	config := scorecards.DefaultScorecardConfig()
 	e.scorecardEngine = scorecards.NewEngine(eventQuery, scorecardStore, config)
+	e.scorecardEngine.SetToolCallQuery(scorecards.NewDBToolCallQuery(e.database))
 }
 
 func (e *Engine) initRoutingEngine() {
@@ -86,6 +86,11 @@ The new Overview must cover, in 2-6 short sentences each:
 4. Which success criteria were verified in this workflow.
 
 Preserve every other heading and its content verbatim: "## Tasks", "## Delegated Agents", "## Change Summary", "## Status". Do not reorder sections. Do not add new top-level headings. Return markdown only, no preamble, no code fences.`
+
+	MemoryConsolidation = `You maintain the long-term memory of a coding agent. You receive several related memory entries from the same project, each with an id, type, importance, title and text. Merge them into ONE memory that keeps every distinct fact, decision, file path and caveat, drops repetition, and prefers the newest entry when entries contradict each other.
+
+Return a strict JSON object, no preamble, no code fences:
+{"title":"<short title, max 80 chars>","text":"<consolidated memory, plain prose or bullets>","tags":["<tag>", ...]}`
 )
 
 // Compose concatenates prompt fragments with double-newline separators.
@@ -222,6 +222,42 @@ func CalculateWorkflowTrend(current, previous *WorkflowScorecard, threshold floa
 	return TrendStable
 }
 
+// CalculateToolTrend compares error rate (absolute) and p95 latency
+// (relative) against the previous scorecard for the tool.
+func CalculateToolTrend(current, previous *ToolScorecard, threshold float64) Trend {
+	if previous == nil || previous.TotalCalls == 0 {
+		return TrendStable
+	}
+
+	improvements := 0
+	degradations := 0
+
+	errorDiff := previous.ErrorRate - current.ErrorRate
+	if errorDiff >= threshold {
+		improvements++
+	} else if errorDiff <= -threshold {
+		degradations++
+	}
+
+	if previous.P95DurationMs > 0 {
+		latencyDiff := float64(previous.P95DurationMs-current.P95DurationMs) / float64(previous.P95DurationMs)
+		if latencyDiff >= threshold {
+			improvements++
+		} else if latencyDiff <= -threshold {
+			degradations++
+		}
+	}
+
+	if improvements > degradations {
+		return TrendImproving
+	}
+	if degradations > improvements {
+		return TrendDegrading
+	}
+
+	return TrendStable
+}
+
 type AgentEventStats struct {
 	AgentName      string
 	Spawned        int
@@ -532,3 +568,20 @@ func ComputeWorkflowScorecard(
 
 	return card
 }
+
+func ComputeToolScorecard(
+	stats ToolCallStats,
+	window Window,
+	windowStart, windowEnd time.Time,
+	config ScorecardConfig,
+) ToolScorecard {
+	card := NewToolScorecard(stats.Tool, window, windowStart, windowEnd)
+
+	card.TotalCalls = stats.Calls
+	card.ErrorRate = CalculateFailureRate(stats.Errors, stats.Calls)
+	card.AvgDurationMs = stats.AvgDurationMs
+	card.P95DurationMs = stats.P95DurationMs
+	card.ConfidenceScore = CalculateConfidenceScore(card.TotalCalls, config)
+
+	return card
+}
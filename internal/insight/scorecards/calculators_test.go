@@ -324,3 +324,69 @@ func TestComputeWorkflowScorecard(t *testing.T) {
 		t.Errorf("Expected CompletionRate between 0.5 and 0.8, got %f", card.CompletionRate)
 	}
 }
+
+func TestCalculateToolTrend(t *testing.T) {
+	threshold := 0.05
+
+	tests := []struct {
+		name     string
+		current  *ToolScorecard
+		previous *ToolScorecard
+		expected Trend
+	}{
+		{
+			name:     "no previous data",
+			current:  &ToolScorecard{ErrorRate: 0.1, TotalCalls: 10},
+			previous: nil,
+			expected: TrendStable,
+		},
+		{
+			name:     "fewer errors",
+			current:  &ToolScorecard{ErrorRate: 0.02, P95DurationMs: 100, TotalCalls: 40},
+			previous: &ToolScorecard{ErrorRate: 0.20, P95DurationMs: 100, TotalCalls: 30},
+			expected: TrendImproving,
+		},
+		{
+			name:     "slower p95",
+			current:  &ToolScorecard{ErrorRate: 0.10, P95DurationMs: 300, TotalCalls: 40},
+			previous: &ToolScorecard{ErrorRate: 0.10, P95DurationMs: 200, TotalCalls: 30},
+			expected: TrendDegrading,
+		},
+		{
+			name:     "mixed signals",
+			current:  &ToolScorecard{ErrorRate: 0.00, P95DurationMs: 300, TotalCalls: 40},
+			previous: &ToolScorecard{ErrorRate: 0.20, P95DurationMs: 200, TotalCalls: 30},
+			expected: TrendStable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CalculateToolTrend(tt.current, tt.previous, threshold)
+			if result != tt.expected {
+				t.Errorf("CalculateToolTrend() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestComputeToolScorecard(t *testing.T) {
+	now := time.Now().UTC()
+	config := DefaultScorecardConfig()
+
+	stats := ToolCallStats{Tool: "search", Calls: 20, Errors: 5, AvgDurationMs: 40, P95DurationMs: 120}
+	card := ComputeToolScorecard(stats, Window7d, now.Add(-7*24*time.Hour), now, config)
+
+	if card.ToolName != "search" || card.TotalCalls != 20 {
+		t.Errorf("Expected search with 20 calls, got %s with %d", card.ToolName, card.TotalCalls)
+	}
+	if card.ErrorRate != 0.25 {
+		t.Errorf("Expected ErrorRate = 0.25, got %f", card.ErrorRate)
+	}
+	if card.P95DurationMs != 120 {
+		t.Errorf("Expected P95DurationMs = 120, got %d", card.P95DurationMs)
+	}
+	if card.ConfidenceScore <= config.LowConfidenceScore {
+		t.Errorf("Expected confidence above the low-sample score, got %f", card.ConfidenceScore)
+	}
+}
@@ -12,8 +12,14 @@ type EventQuery interface {
 	GetEventsByTypesInTimeRange(ctx context.Context, eventTypes []string, start, end time.Time, limit int) ([]EventForScorecard, error)
 }
 
+// ToolCallQuery supplies per-tool MCP call aggregates for tool scorecards.
+type ToolCallQuery interface {
+	GetToolCallStats(ctx context.Context, start, end time.Time) ([]ToolCallStats, error)
+}
+
 type Engine struct {
 	eventQuery     EventQuery
+	toolQuery      ToolCallQuery
 	scorecardStore ScorecardStore
 	config         ScorecardConfig
 	mu             sync.Mutex
@@ -41,8 +47,11 @@ func (e *Engine) RunComputation(ctx context.Context) error {
 	windows := []Window{Window7d, Window30d}
 	totalAgentCards := 0
 	totalWorkflowCards := 0
+	totalToolCards := 0
 
 	for _, window := range windows {
+		totalToolCards += len(e.computeToolScorecards(ctx, window))
+
 		agentCards, workflowCards, err := e.computeForWindow(ctx, window)
 		if err != nil {
 			slog.Error("insight scorecards: window computation failed", "window", window, "error", err)
@@ -56,6 +65,7 @@ func (e *Engine) RunComputation(ctx context.Context) error {
 	slog.Info("insight scorecards: computation complete",
 		"agent_count", totalAgentCards,
 		"workflow_count", totalWorkflowCards,
+		"tool_count", totalToolCards,
 		"duration_ms", duration.Milliseconds())
 
 	return nil
@@ -162,6 +172,47 @@ func (e *Engine) computeWorkflowScorecards(
 	return cards
 }
 
+// computeToolScorecards rates every MCP tool called in the window. It is a
+// no-op until a ToolCallQuery is set.
+func (e *Engine) computeToolScorecards(ctx context.Context, window Window) []ToolScorecard {
+	if e.toolQuery == nil {
+		return nil
+	}
+
+	windowEnd := time.Now().UTC()
+	windowStart := windowEnd.Add(-e.config.WindowDuration(window))
+
+	stats, err := e.toolQuery.GetToolCallStats(ctx, windowStart, windowEnd)
+	if err != nil {
+		slog.Error("insight scorecards: load tool call stats failed", "window", window, "error", err)
+		return nil
+	}
+
+	var cards []ToolScorecard
+	for _, st := range stats {
+		if st.Calls == 0 {
+			continue
+		}
+		card := ComputeToolScorecard(st, window, windowStart, windowEnd, e.config)
+
+		prevCard, _ := e.scorecardStore.GetToolScorecardByName(ctx, st.Tool, window)
+		if prevCard != nil {
+			card.ID = prevCard.ID
+		}
+		card.Trend = CalculateToolTrend(&card, prevCard, e.config.TrendThreshold)
+
+		if err := e.scorecardStore.SaveToolScorecard(ctx, card); err != nil {
+			slog.Error("insight scorecards: failed to save tool scorecard",
+				"tool", st.Tool, "error", err)
+			continue
+		}
+		cards = append(cards, card)
+	}
+
+	slog.Debug("insight scorecards: computed tool scorecards", "count", len(cards))
+	return cards
+}
+
 func extractAgentNames(events []EventForScorecard) []string {
 	seen := make(map[string]bool)
 	var names []string
@@ -215,6 +266,13 @@ func (q *DBEventQuery) GetEventsByTypesInTimeRange(ctx context.Context, eventTyp
 	return q.queryFunc(ctx, eventTypes, start, end, limit)
 }
 
+// SetToolCallQuery enables MCP tool scorecards.
+func (e *Engine) SetToolCallQuery(q ToolCallQuery) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.toolQuery = q
+}
+
 func (e *Engine) SetConfig(config ScorecardConfig) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -243,6 +301,10 @@ func (e *Engine) GetWorkflowScorecardByType(ctx context.Context, workflowType st
 	return e.scorecardStore.GetWorkflowScorecardByType(ctx, workflowType, window)
 }
 
+func (e *Engine) GetToolScorecards(ctx context.Context, window Window, sortBy string, sortDir string, limit int) ([]ToolScorecard, error) {
+	return e.scorecardStore.GetToolScorecards(ctx, window, sortBy, sortDir, limit)
+}
+
 func (e *Engine) GetScorecardHighlights(ctx context.Context, window Window) (map[string]interface{}, error) {
 	return e.scorecardStore.GetScorecardHighlights(ctx, window)
 }
@@ -56,6 +56,21 @@ type WorkflowScorecard struct {
 	UpdatedAt           time.Time `json:"updated_at"`
 }
 
+type ToolScorecard struct {
+	ID              string    `json:"id"`
+	ToolName        string    `json:"tool_name"`
+	Window          Window    `json:"window"`
+	WindowStart     time.Time `json:"window_start"`
+	WindowEnd       time.Time `json:"window_end"`
+	TotalCalls      int       `json:"total_calls"`
+	ErrorRate       float64   `json:"error_rate"`
+	AvgDurationMs   int64     `json:"avg_duration_ms"`
+	P95DurationMs   int64     `json:"p95_duration_ms"`
+	ConfidenceScore float64   `json:"confidence_score"`
+	Trend           Trend     `json:"trend"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
 type ScorecardConfig struct {
 	MinSampleSize      int     `json:"min_sample_size"`
 	LowConfidenceScore float64 `json:"low_confidence_score"`
@@ -109,6 +124,27 @@ func NewWorkflowScorecard(workflowType string, window Window, windowStart, windo
 	}
 }
 
+func NewToolScorecard(toolName string, window Window, windowStart, windowEnd time.Time) ToolScorecard {
+	return ToolScorecard{
+		ID:          generateID(),
+		ToolName:    toolName,
+		Window:      window,
+		WindowStart: windowStart,
+		WindowEnd:   windowEnd,
+		Trend:       TrendStable,
+		UpdatedAt:   time.Now().UTC(),
+	}
+}
+
+// ToolCallStats is the per-tool aggregate of MCP tool calls in a window.
+type ToolCallStats struct {
+	Tool          string
+	Calls         int
+	Errors        int
+	AvgDurationMs int64
+	P95DurationMs int64
+}
+
 type EventForScorecard struct {
 	ID         string
 	Type       string
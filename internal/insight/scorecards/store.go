@@ -16,6 +16,9 @@ type ScorecardStore interface {
 	GetAgentScorecardByName(ctx context.Context, agentName string, window Window) (*AgentScorecard, error)
 	GetWorkflowScorecardByType(ctx context.Context, workflowType string, window Window) (*WorkflowScorecard, error)
 	GetScorecardHighlights(ctx context.Context, window Window) (map[string]interface{}, error)
+	SaveToolScorecard(ctx context.Context, scorecard ToolScorecard) error
+	GetToolScorecards(ctx context.Context, window Window, sortBy string, sortDir string, limit int) ([]ToolScorecard, error)
+	GetToolScorecardByName(ctx context.Context, toolName string, window Window) (*ToolScorecard, error)
 }
 
 type DBScorecardStore struct {
@@ -92,6 +95,63 @@ func (s *DBScorecardStore) GetScorecardHighlights(ctx context.Context, window Wi
 	return s.database.GetScorecardHighlights(string(window))
 }
 
+func (s *DBScorecardStore) SaveToolScorecard(ctx context.Context, scorecard ToolScorecard) error {
+	return s.database.SaveToolScorecard(toolScorecardToDB(scorecard))
+}
+
+func (s *DBScorecardStore) GetToolScorecards(ctx context.Context, window Window, sortBy string, sortDir string, limit int) ([]ToolScorecard, error) {
+	dbCards, err := s.database.ListToolScorecards(string(window), sortBy, sortDir, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list tool scorecards: %w", err)
+	}
+
+	cards := make([]ToolScorecard, len(dbCards))
+	for i, c := range dbCards {
+		cards[i] = dbToolScorecardToModel(c)
+	}
+	return cards, nil
+}
+
+func (s *DBScorecardStore) GetToolScorecardByName(ctx context.Context, toolName string, window Window) (*ToolScorecard, error) {
+	dbCard, err := s.database.GetToolScorecardByName(toolName, string(window))
+	if err != nil {
+		return nil, fmt.Errorf("get tool scorecard: %w", err)
+	}
+	if dbCard == nil {
+		return nil, nil
+	}
+
+	card := dbToolScorecardToModel(*dbCard)
+	return &card, nil
+}
+
+// DBToolCallQuery reads tool-call aggregates from the MCP audit log.
+type DBToolCallQuery struct {
+	database *db.DB
+}
+
+func NewDBToolCallQuery(database *db.DB) *DBToolCallQuery {
+	return &DBToolCallQuery{database: database}
+}
+
+func (q *DBToolCallQuery) GetToolCallStats(ctx context.Context, start, end time.Time) ([]ToolCallStats, error) {
+	rows, err := q.database.GetMCPToolStats(start, end)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]ToolCallStats, len(rows))
+	for i, r := range rows {
+		stats[i] = ToolCallStats{
+			Tool:          r.Tool,
+			Calls:         r.Calls,
+			Errors:        r.Errors,
+			AvgDurationMs: r.AvgDurationMs,
+			P95DurationMs: r.P95DurationMs,
+		}
+	}
+	return stats, nil
+}
+
 func agentScorecardToDB(c AgentScorecard) *db.AgentScorecard {
 	return &db.AgentScorecard{
 		ID:              c.ID,
@@ -191,3 +251,48 @@ func dbWorkflowScorecardToModel(c db.WorkflowScorecard) WorkflowScorecard {
 		UpdatedAt:           updatedAt,
 	}
 }
+
+func toolScorecardToDB(c ToolScorecard) *db.ToolScorecard {
+	return &db.ToolScorecard{
+		ID:              c.ID,
+		ToolName:        c.ToolName,
+		Window:          string(c.Window),
+		WindowStart:     c.WindowStart.Format(time.RFC3339Nano),
+		WindowEnd:       c.WindowEnd.Format(time.RFC3339Nano),
+		TotalCalls:      c.TotalCalls,
+		ErrorRate:       c.ErrorRate,
+		AvgDurationMs:   c.AvgDurationMs,
+		P95DurationMs:   c.P95DurationMs,
+		ConfidenceScore: c.ConfidenceScore,
+		Trend:           string(c.Trend),
+		UpdatedAt:       c.UpdatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+func dbToolScorecardToModel(c db.ToolScorecard) ToolScorecard {
+	var windowStart, windowEnd, updatedAt time.Time
+	if c.WindowStart != "" {
+		windowStart, _ = time.Parse(time.RFC3339Nano, c.WindowStart)
+	}
+	if c.WindowEnd != "" {
+		windowEnd, _ = time.Parse(time.RFC3339Nano, c.WindowEnd)
+	}
+	if c.UpdatedAt != "" {
+		updatedAt, _ = time.Parse(time.RFC3339Nano, c.UpdatedAt)
+	}
+
+	return ToolScorecard{
+		ID:              c.ID,
+		ToolName:        c.ToolName,
+		Window:          Window(c.Window),
+		WindowStart:     windowStart,
+		WindowEnd:       windowEnd,
+		TotalCalls:      c.TotalCalls,
+		ErrorRate:       c.ErrorRate,
+		AvgDurationMs:   c.AvgDurationMs,
+		P95DurationMs:   c.P95DurationMs,
+		ConfidenceScore: c.ConfidenceScore,
+		Trend:           Trend(c.Trend),
+		UpdatedAt:       updatedAt,
+	}
+}
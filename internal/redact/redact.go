@@ -0,0 +1,187 @@
+// Package redact finds credentials in free text and replaces them with a
+// placeholder before the text is persisted or sent to an LLM.
+package redact
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Placeholder replaces every detected secret.
+const Placeholder = "[REDACTED]"
+
+// Result is the outcome of redacting one string.
+type Result struct {
+	Text string
+	// Count is the number of secrets replaced; Kinds breaks it down by rule.
+	Count int
+	Kinds map[string]int
+}
+
+// rule detects one secret format. Text matched by the optional named groups
+// "pre" and "post" is kept around the placeholder, so "password=" or the
+// user part of a connection string survive. accept, when set, vetoes
+// matches whose secret part does not look like a credential.
+type rule struct {
+	kind   string
+	re     *regexp.Regexp
+	accept func(secret string) bool
+}
+
+// rules run in order; specific formats come before the generic ones so each
+// secret is counted under its most precise kind.
+var rules = []rule{
+	{kind: "private_key", re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(?:-----END [A-Z ]*PRIVATE KEY-----|$)`)},
+	{kind: "aws_access_key", re: regexp.MustCompile(`(?:AKIA|ASIA)[0-9A-Z]{16}`)},
+	{kind: "aws_secret_key", re: regexp.MustCompile(`(?i)(?P<pre>aws[_-]?secret[_-]?access[_-]?key["'\s:=]+)[A-Za-z0-9/+=]{40}`)},
+	{kind: "github_token", re: regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,}`)},
+	{kind: "gitlab_token", re: regexp.MustCompile(`glpat-[A-Za-z0-9_\-]{20,}`)},
+	{kind: "slack_token", re: regexp.MustCompile(`xox[abposr]-[A-Za-z0-9\-]{10,}`)},
+	{kind: "stripe_key", re: regexp.MustCompile(`[rs]k_(?:live|test)_[A-Za-z0-9]{16,}`)},
+	{kind: "google_api_key", re: regexp.MustCompile(`AIza[0-9A-Za-z_\-]{35}`)},
+	{kind: "api_key", re: regexp.MustCompile(`sk-(?:ant-|proj-)?[A-Za-z0-9_\-]{20,}`)},
+	{kind: "jwt", re: regexp.MustCompile(`eyJ[A-Za-z0-9_\-]{8,}\.eyJ[A-Za-z0-9_\-]{8,}\.[A-Za-z0-9_\-]{8,}`)},
+	{kind: "connection_string", re: regexp.MustCompile(`(?P<pre>\b[a-zA-Z][a-zA-Z0-9+.\-]*://[^\s:/@]+:)[^\s@/]+(?P<post>@)`)},
+	{kind: "bearer_token", re: regexp.MustCompile(`(?i)(?P<pre>bearer\s+)[A-Za-z0-9_\-\.=+/]{20,}`)},
+	{
+		kind:   "assignment",
+		re:     regexp.MustCompile(`(?i)(?P<pre>[A-Za-z0-9_\-]*(?:api[_-]?key|secret|token|passw(?:or)?d|pwd|access[_-]?key)["']?\s*[:=]\s*["']?)[^\s"'&,;\[][^\s"'&,;]{7,}`),
+		accept: looksLikeCredential,
+	},
+	// .env lines: an upper-case KEY with a long value, whatever the key is
+	// called. Values a specific rule already cleaned are left as they are.
+	{
+		kind:   "env_assignment",
+		re:     regexp.MustCompile(`(?m)(?P<pre>^(?:export\s+)?[A-Z_][A-Z0-9_]*\s*=\s*)\S{16,}$`),
+		accept: func(v string) bool { return !strings.Contains(v, Placeholder) },
+	},
+	// Passwords glued to -p on MySQL and MariaDB command lines.
+	{kind: "cli_password", re: regexp.MustCompile(`(?P<pre>\b(?:mysql|mysqldump|mysqladmin|mariadb|mariadb-dump|mariadb-admin)\b[^\n|;&]*?\s-p)(?:'[^']*'|"[^"]*"|[^\s'"]+)`)},
+	{kind: "high_entropy", re: regexp.MustCompile(`[A-Za-z0-9+/_\-=]{32,}`), accept: highEntropy},
+}
+
+// String redacts s and returns the cleaned text and the number of secrets
+// replaced.
+func String(s string) (string, int) {
+	r := Apply(s)
+	return r.Text, r.Count
+}
+
+// Apply redacts s and reports what was replaced.
+func Apply(s string) Result {
+	res := Result{Text: s}
+	for _, r := range rules {
+		res.Text = r.replace(res.Text, &res)
+	}
+	return res
+}
+
+// Value returns a copy of v, a decoded JSON value, with every string in it
+// redacted.
+func Value(v any) any {
+	switch val := v.(type) {
+	case string:
+		s, _ := String(val)
+		return s
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = Value(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = Value(item)
+		}
+		return out
+	}
+	return v
+}
+
+func (r rule) replace(s string, res *Result) string {
+	matches := r.re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	pre, post := r.re.SubexpIndex("pre"), r.re.SubexpIndex("post")
+	out := make([]byte, 0, len(s))
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		secretStart, secretEnd := start, end
+		if pre > 0 && m[2*pre] >= 0 {
+			secretStart = m[2*pre+1]
+		}
+		if post > 0 && m[2*post] >= 0 {
+			secretEnd = m[2*post]
+		}
+		if r.accept != nil && !r.accept(s[secretStart:secretEnd]) {
+			continue
+		}
+		out = append(out, s[last:secretStart]...)
+		out = append(out, Placeholder...)
+		out = append(out, s[secretEnd:end]...)
+		last = end
+		res.Count++
+		if res.Kinds == nil {
+			res.Kinds = map[string]int{}
+		}
+		res.Kinds[r.kind]++
+	}
+	return string(append(out, s[last:]...))
+}
+
+// looksLikeCredential keeps "password = configured via vault" readable: a
+// value must be long or contain a digit to be treated as a secret.
+func looksLikeCredential(v string) bool {
+	if len(v) >= 12 {
+		return true
+	}
+	for _, c := range v {
+		if unicode.IsDigit(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// highEntropy flags long tokens that mix character classes and look random,
+// such as base64 keys. Hex digests and UUIDs stay below the threshold, and
+// long CamelCase identifiers rarely carry more than one digit.
+func highEntropy(v string) bool {
+	var upper, lower bool
+	digits := 0
+	for _, c := range v {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digits++
+		}
+	}
+	if !upper || !lower || digits < 2 {
+		return false
+	}
+	return entropy(v) >= 4.5
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	n := 0
+	for _, c := range s {
+		counts[c]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -0,0 +1,153 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MartinNevlaha/stratus-v2/internal/redact"
+)
+
+const (
+	// auditMaxString caps recorded string arguments; plan and design documents
+	// passed to tools would otherwise dominate the audit table.
+	auditMaxString = 512
+	// auditPostTimeout bounds delivery of one call record to the API.
+	auditPostTimeout = 5 * time.Second
+)
+
+// CallRecord describes one tools/call for the audit log. Args are redacted
+// before the recorder sees them.
+type CallRecord struct {
+	SessionID  string         `json:"session_id,omitempty"`
+	WorkflowID string         `json:"workflow_id,omitempty"`
+	Tool       string         `json:"tool"`
+	Args       map[string]any `json:"args,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+	IsError    bool           `json:"is_error"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// SetCallRecorder installs fn to receive a record of every tools/call for a
+// registered tool, including calls rejected by argument validation. fn runs
+// on the request goroutine and must not block; pass nil to stop recording.
+func (s *Server) SetCallRecorder(fn func(CallRecord)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recorder = fn
+}
+
+func (s *Server) callRecorder() func(CallRecord) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.recorder
+}
+
+// record reports a finished tools/call to the installed recorder, if any.
+func (s *Server) record(p peer, tool string, args map[string]any, started time.Time, callErr error) {
+	fn := s.callRecorder()
+	if fn == nil {
+		return
+	}
+	rec := CallRecord{
+		Tool:       tool,
+		Args:       redactArgs(args),
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+	if p != nil {
+		rec.SessionID = p.sessionID()
+	}
+	if wf, ok := args["workflow_id"].(string); ok {
+		rec.WorkflowID = wf
+	}
+	if callErr != nil {
+		rec.IsError = true
+		rec.Error = callErr.Error()
+	}
+	fn(rec)
+}
+
+// RecordCalls ships every tool call on s to the API's audit log
+// (POST /api/mcp/calls). Delivery is asynchronous and best effort: a failed
+// post is logged and the tool result is unaffected.
+func RecordCalls(s *Server, apiBase string, httpClient *http.Client) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	url := strings.TrimRight(apiBase, "/") + "/api/mcp/calls"
+	s.SetCallRecorder(func(rec CallRecord) {
+		go func() {
+			body, _ := json.Marshal(rec)
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			if err != nil {
+				return
+			}
+			req.Header.Set("Content-Type", "application/json")
+			client := *httpClient
+			if client.Timeout == 0 || client.Timeout > auditPostTimeout {
+				client.Timeout = auditPostTimeout
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				log.Printf("mcp: record call %s: %v", rec.Tool, err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= 400 {
+				log.Printf("mcp: record call %s: HTTP %d", rec.Tool, resp.StatusCode)
+			}
+		}()
+	})
+}
+
+// secretKey matches argument names whose values are never recorded.
+var secretKey = regexp.MustCompile(`(?i)(secret|token|passw(or)?d|api[_-]?key|credential|^auth(orization)?$)`)
+
+const redacted = redact.Placeholder
+
+// redactArgs returns a copy of args that is safe to persist: values under
+// secret-looking keys are replaced, credentials in strings are masked and
+// long strings are truncated.
+func redactArgs(args map[string]any) map[string]any {
+	if len(args) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(args))
+	for k, v := range args {
+		if secretKey.MatchString(k) {
+			out[k] = redacted
+			continue
+		}
+		out[k] = redactValue(v)
+	}
+	return out
+}
+
+func redactValue(v any) any {
+	switch val := v.(type) {
+	case string:
+		val, _ = redact.String(val)
+		if len(val) > auditMaxString {
+			n := auditMaxString
+			for n > 0 && !utf8.RuneStart(val[n]) {
+				n--
+			}
+			val = val[:n] + "…"
+		}
+		return val
+	case map[string]any:
+		return redactArgs(val)
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = redactValue(item)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,172 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Change lists the resources affected by one internal change message.
+type Change struct {
+	// URIs are resources whose contents changed.
+	URIs []string
+	// ListChanged is set when resources appeared or disappeared.
+	ListChanged bool
+}
+
+// ChangeFor maps a WebSocket hub message type (workflow_updated, ticket_status,
+// signal_sent, …) or an event bus type (workflow.phase_transition, …) and its
+// payload to the resource URIs it touches. Payloads may be decoded JSON or the
+// Go values the API broadcasts; unknown kinds yield an empty Change.
+func ChangeFor(kind string, payload any) Change {
+	var c Change
+	switch kind {
+	case "workflow_updated", "phase_changed", "task_completed", "workflow_completed", "workflow_aborted",
+		"approval_requested", "approval_resolved", "workflow_escalated",
+		"workflow.phase_transition", "workflow.completed", "workflow.failed", "workflow.aborted":
+		c.URIs = workflowURIs(workflowID(normalize(payload)))
+
+	case "workflow_started", "workflow.started", "workflow_deleted":
+		c.URIs = workflowURIs(workflowID(normalize(payload)))
+		c.ListChanged = true
+
+	case "mission_status":
+		c.URIs = missionURIs(str(asMap(normalize(payload)), "id"))
+		c.ListChanged = true
+
+	case "worker_spawned", "worker_status":
+		m := asMap(normalize(payload))
+		c.URIs = append(missionURIs(str(m, "mission_id")), workerURI(str(m, "id"))...)
+		c.ListChanged = kind == "worker_spawned"
+
+	case "ticket_status", "tickets_created":
+		for _, t := range asList(normalize(payload)) {
+			c.URIs = append(c.URIs, ticketURIs(t)...)
+		}
+
+	case "tickets_dispatched":
+		m := asMap(normalize(payload))
+		mission := str(m, "mission_id")
+		c.URIs = append(missionURIs(mission), missionURI(mission, "tickets")...)
+		for _, a := range asList(m["assignments"]) {
+			c.URIs = append(c.URIs, workerURI(str(a, "worker_id"))...)
+		}
+
+	case "signal_sent":
+		m := asMap(normalize(payload))
+		c.URIs = missionURI(str(m, "mission_id"), "signals")
+		// Broadcast signals ("*") reach workers through the mission's signal
+		// resource; directed signals also update the recipient.
+		if to := str(m, "to_worker"); to != "*" {
+			c.URIs = append(c.URIs, workerURI(to)...)
+		}
+
+	case "forge_update", "forge_executed", "gate_result", "plan_drift", "evidence_recorded":
+		c.URIs = missionURIs(str(asMap(normalize(payload)), "mission_id"))
+
+	case "event_saved":
+		if id := str(asMap(normalize(payload)), "id"); id != "" {
+			c.URIs = []string{"stratus://memory/" + id}
+		}
+		c.ListChanged = true
+
+	case "governance_indexed":
+		c.ListChanged = true
+	}
+	return c
+}
+
+func workflowURIs(id string) []string {
+	if id == "" {
+		return nil
+	}
+	base := "stratus://workflow/" + id
+	return []string{base, base + "/plan", base + "/design", base + "/summary"}
+}
+
+func missionURIs(id string) []string {
+	return missionURI(id, "")
+}
+
+// missionURI returns the mission resource, or one of its sub-resources when
+// sub is set.
+func missionURI(id, sub string) []string {
+	if id == "" {
+		return nil
+	}
+	uri := "stratus://swarm/mission/" + id
+	if sub != "" {
+		uri += "/" + sub
+	}
+	return []string{uri}
+}
+
+func workerURI(id string) []string {
+	if id == "" {
+		return nil
+	}
+	return []string{"stratus://swarm/worker/" + id}
+}
+
+func ticketURIs(t map[string]any) []string {
+	mission := str(t, "mission_id")
+	uris := append(missionURIs(mission), missionURI(mission, "tickets")...)
+	return append(uris, workerURI(str(t, "worker_id"))...)
+}
+
+// workflowID reads the workflow ID from either a workflow state ("id") or a
+// workflow event payload ("workflow_id").
+func workflowID(payload any) string {
+	m := asMap(payload)
+	if id := str(m, "workflow_id"); id != "" {
+		return id
+	}
+	return str(m, "id")
+}
+
+// normalize turns a Go value broadcast in-process into the shape it has on
+// the wire, so both transports map changes identically.
+func normalize(payload any) any {
+	if payload == nil {
+		return nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil
+	}
+	var out any
+	_ = json.Unmarshal(data, &out)
+	return out
+}
+
+func asMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+// asList accepts a single object or an array of objects.
+func asList(v any) []map[string]any {
+	if m, ok := v.(map[string]any); ok {
+		return []map[string]any{m}
+	}
+	arr, _ := v.([]any)
+	out := make([]map[string]any, 0, len(arr))
+	for _, item := range arr {
+		if m, ok := item.(map[string]any); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// str reads a string (or numeric ID) field.
+func str(m map[string]any, key string) string {
+	switch v := m[key].(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%d", int64(v))
+	case int, int64:
+		return fmt.Sprintf("%d", v)
+	}
+	return ""
+}
@@ -0,0 +1,340 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// sessionHeader carries the session ID assigned on initialize.
+	sessionHeader = "Mcp-Session-Id"
+	// agentSessionHeader optionally carries the agent's own session ID on
+	// initialize (e.g. ${CLAUDE_SESSION_ID}), which the session's tool calls
+	// are audited under instead of the transport session.
+	agentSessionHeader = "X-Claude-Session-Id"
+	// sseKeepAlive is how often an idle SSE stream receives a comment line so
+	// proxies do not drop the connection.
+	sseKeepAlive = 30 * time.Second
+	// sessionOutboxSize bounds queued server→client messages per session.
+	sessionOutboxSize = 64
+	// sessionIdleTTL is how long a session may go without a request or an
+	// open stream before it is ended. Clients that exit without a DELETE
+	// would otherwise stay attached for the life of the process.
+	sessionIdleTTL = 30 * time.Minute
+	// sessionSweepInterval is the least time between two idle-session sweeps.
+	sessionSweepInterval = time.Minute
+	// maxPostBytes caps the size of a POSTed message or batch.
+	maxPostBytes = 4 << 20
+	// maxBatchSize caps the number of messages in one JSON-RPC batch.
+	maxBatchSize = 64
+)
+
+// httpSession is one client connected over the Streamable HTTP transport.
+type httpSession struct {
+	id      string
+	agent   string
+	outbox  chan []byte
+	closed  chan struct{}
+	closeMu sync.Once
+
+	lastSeen atomic.Int64 // unix nanoseconds of the latest request
+	streams  atomic.Int32 // open SSE streams
+}
+
+func (sess *httpSession) touch(now time.Time) { sess.lastSeen.Store(now.UnixNano()) }
+
+// idle reports whether the session has had no request within sessionIdleTTL
+// and no stream open.
+func (sess *httpSession) idle(now time.Time) bool {
+	return sess.streams.Load() == 0 && now.Sub(time.Unix(0, sess.lastSeen.Load())) > sessionIdleTTL
+}
+
+func (sess *httpSession) close() {
+	sess.closeMu.Do(func() { close(sess.closed) })
+}
+
+func (sess *httpSession) sessionID() string {
+	if sess.agent != "" {
+		return sess.agent
+	}
+	return sess.id
+}
+
+// notify queues a server→client message for the session's SSE stream. When
+// no stream is draining the outbox and it is full, the message is dropped
+// rather than blocking the notifier.
+func (sess *httpSession) notify(data []byte) {
+	select {
+	case <-sess.closed:
+	case sess.outbox <- data:
+	default:
+	}
+}
+
+// httpTransport implements the MCP Streamable HTTP transport on top of a
+// Server: POST carries client→server JSON-RPC messages, GET opens an SSE
+// stream for server→client messages, DELETE ends the session. Sessions idle
+// for sessionIdleTTL are ended too.
+type httpTransport struct {
+	server         *Server
+	allowedOrigins []string
+
+	mu        sync.RWMutex
+	sessions  map[string]*httpSession
+	lastSweep time.Time
+}
+
+// HTTPHandler returns an http.Handler exposing the server's tool registry over
+// the Streamable HTTP transport. Mount it at a single endpoint such as /mcp.
+//
+// Browser requests are accepted only from localhost and allowedOrigins
+// (e.g. "https://app.example.com"), so a web page cannot reach the local
+// server through DNS rebinding. Requests without an Origin header, which MCP
+// clients do not send, are always accepted.
+func (s *Server) HTTPHandler(allowedOrigins ...string) http.Handler {
+	return &httpTransport{server: s, allowedOrigins: allowedOrigins, sessions: make(map[string]*httpSession)}
+}
+
+func (t *httpTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !t.originAllowed(r.Header.Get("Origin")) {
+		writeHTTPError(w, http.StatusForbidden, -32600, "origin not allowed")
+		return
+	}
+	t.reapIdle(time.Now())
+	switch r.Method {
+	case http.MethodPost:
+		t.handlePost(w, r)
+	case http.MethodGet:
+		t.handleStream(w, r)
+	case http.MethodDelete:
+		t.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (t *httpTransport) originAllowed(origin string) bool {
+	if origin == "" || slices.Contains(t.allowedOrigins, origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// reapIdle ends the sessions that went idle. It rides on incoming requests
+// and sweeps at most once per sessionSweepInterval.
+func (t *httpTransport) reapIdle(now time.Time) {
+	t.mu.Lock()
+	if now.Sub(t.lastSweep) < sessionSweepInterval {
+		t.mu.Unlock()
+		return
+	}
+	t.lastSweep = now
+	var expired []*httpSession
+	for id, sess := range t.sessions {
+		if sess.idle(now) {
+			delete(t.sessions, id)
+			expired = append(expired, sess)
+		}
+	}
+	t.mu.Unlock()
+	for _, sess := range expired {
+		t.server.detach(sess)
+		sess.close()
+	}
+}
+
+func (t *httpTransport) session(id string) (*httpSession, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	sess, ok := t.sessions[id]
+	return sess, ok
+}
+
+func (t *httpTransport) newSession(r *http.Request) *httpSession {
+	sess := &httpSession{
+		id:     uuid.NewString(),
+		agent:  r.Header.Get(agentSessionHeader),
+		outbox: make(chan []byte, sessionOutboxSize),
+		closed: make(chan struct{}),
+	}
+	sess.touch(time.Now())
+	t.mu.Lock()
+	t.sessions[sess.id] = sess
+	t.mu.Unlock()
+	t.server.attach(sess)
+	return sess
+}
+
+// requireSession resolves the session named by the request header, writing
+// the appropriate HTTP error when it is missing or unknown.
+func (t *httpTransport) requireSession(w http.ResponseWriter, r *http.Request) (*httpSession, bool) {
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		writeHTTPError(w, http.StatusBadRequest, -32600, "missing "+sessionHeader+" header")
+		return nil, false
+	}
+	sess, ok := t.session(id)
+	if !ok {
+		writeHTTPError(w, http.StatusNotFound, -32001, "session not found")
+		return nil, false
+	}
+	sess.touch(time.Now())
+	return sess, true
+}
+
+func (t *httpTransport) handlePost(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	r.Body = http.MaxBytesReader(w, r.Body, maxPostBytes)
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeHTTPError(w, http.StatusRequestEntityTooLarge, -32600, "request body too large")
+			return
+		}
+		writeHTTPError(w, http.StatusBadRequest, -32700, "parse error")
+		return
+	}
+
+	// The body is either a single message or a JSON-RPC batch.
+	var reqs []jsonRPCRequest
+	batch := len(bytes.TrimSpace(raw)) > 0 && bytes.TrimSpace(raw)[0] == '['
+	if batch {
+		if err := json.Unmarshal(raw, &reqs); err != nil || len(reqs) == 0 {
+			writeHTTPError(w, http.StatusBadRequest, -32600, "invalid batch")
+			return
+		}
+		if len(reqs) > maxBatchSize {
+			writeHTTPError(w, http.StatusBadRequest, -32600, fmt.Sprintf("batch exceeds %d messages", maxBatchSize))
+			return
+		}
+	} else {
+		var req jsonRPCRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			writeHTTPError(w, http.StatusBadRequest, -32600, "invalid request")
+			return
+		}
+		reqs = []jsonRPCRequest{req}
+	}
+
+	var sess *httpSession
+	if !batch && reqs[0].Method == "initialize" {
+		sess = t.newSession(r)
+	} else {
+		var ok bool
+		if sess, ok = t.requireSession(w, r); !ok {
+			return
+		}
+	}
+
+	responses := make([]*jsonRPCResponse, 0, len(reqs))
+	for _, req := range reqs {
+		if resp := t.server.handle(sess, req); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+
+	w.Header().Set(sessionHeader, sess.id)
+	if len(responses) == 0 {
+		// Only notifications or responses were posted.
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if batch {
+		_ = json.NewEncoder(w).Encode(responses)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(responses[0])
+}
+
+// handleStream serves the long-lived SSE stream that carries server-initiated
+// messages for a session.
+func (t *httpTransport) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "GET requires Accept: text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+	sess, ok := t.requireSession(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set(sessionHeader, sess.id)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	sess.streams.Add(1)
+	defer func() {
+		sess.streams.Add(-1)
+		sess.touch(time.Now())
+	}()
+
+	ticker := time.NewTicker(sseKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sess.closed:
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case data := <-sess.outbox:
+			if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (t *httpTransport) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sess, ok := t.requireSession(w, r)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	delete(t.sessions, sess.id)
+	t.mu.Unlock()
+	t.server.detach(sess)
+	sess.close()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeHTTPError(w http.ResponseWriter, status, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse(nil, code, msg))
+}
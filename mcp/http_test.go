@@ -0,0 +1,260 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer() *Server {
+	s := New()
+	s.Register(Tool{
+		Name:        "echo",
+		Description: "Echo the arguments back",
+		InputSchema: obj(req("text", "string", "Text to echo")),
+		Handler: func(args map[string]any) (any, error) {
+			return map[string]any{"echo": args["text"]}, nil
+		},
+	})
+	return s
+}
+
+func postRPC(t *testing.T, h http.Handler, sessionID, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", "application/json, text/event-stream")
+	if sessionID != "" {
+		r.Header.Set(sessionHeader, sessionID)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func initSession(t *testing.T, h http.Handler) string {
+	t.Helper()
+	w := postRPC(t, h, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("initialize: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	id := w.Header().Get(sessionHeader)
+	if id == "" {
+		t.Fatal("initialize: expected session header")
+	}
+	var resp struct {
+		Result struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode initialize: %v", err)
+	}
+	if resp.Result.ProtocolVersion != "2025-03-26" {
+		t.Errorf("protocolVersion = %q, want 2025-03-26", resp.Result.ProtocolVersion)
+	}
+	return id
+}
+
+func TestHTTPTransport_ToolCall(t *testing.T) {
+	h := newTestServer().HTTPHandler()
+	sid := initSession(t, h)
+
+	w := postRPC(t, h, sid, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("tools/call: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Result struct {
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Result.Content) != 1 || !strings.Contains(resp.Result.Content[0].Text, `"echo":"hi"`) {
+		t.Errorf("unexpected content: %s", w.Body.String())
+	}
+}
+
+func TestHTTPTransport_NotificationAccepted(t *testing.T) {
+	h := newTestServer().HTTPHandler()
+	sid := initSession(t, h)
+
+	w := postRPC(t, h, sid, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected 202, got %d", w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected empty body, got %q", w.Body.String())
+	}
+}
+
+func TestHTTPTransport_Batch(t *testing.T) {
+	h := newTestServer().HTTPHandler()
+	sid := initSession(t, h)
+
+	w := postRPC(t, h, sid, `[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":2,"method":"tools/list"}]`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resps []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resps); err != nil {
+		t.Fatalf("decode batch: %v", err)
+	}
+	if len(resps) != 2 {
+		t.Errorf("expected 2 responses (notification dropped), got %d", len(resps))
+	}
+}
+
+func TestHTTPTransport_LimitsBatchAndBody(t *testing.T) {
+	h := newTestServer().HTTPHandler()
+	sid := initSession(t, h)
+
+	batch := "[" + strings.TrimSuffix(strings.Repeat(`{"jsonrpc":"2.0","id":1,"method":"ping"},`, maxBatchSize+1), ",") + "]"
+	if w := postRPC(t, h, sid, batch); w.Code != http.StatusBadRequest {
+		t.Errorf("oversized batch: expected 400, got %d", w.Code)
+	}
+	huge := `{"jsonrpc":"2.0","id":1,"method":"ping","params":{"pad":"` + strings.Repeat("x", maxPostBytes) + `"}}`
+	if w := postRPC(t, h, sid, huge); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body: expected 413, got %d", w.Code)
+	}
+}
+
+func TestHTTPTransport_SessionRequired(t *testing.T) {
+	h := newTestServer().HTTPHandler()
+
+	if w := postRPC(t, h, "", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("missing session: expected 400, got %d", w.Code)
+	}
+	if w := postRPC(t, h, "nope", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`); w.Code != http.StatusNotFound {
+		t.Errorf("unknown session: expected 404, got %d", w.Code)
+	}
+}
+
+func TestHTTPTransport_DeleteEndsSession(t *testing.T) {
+	h := newTestServer().HTTPHandler()
+	sid := initSession(t, h)
+
+	r := httptest.NewRequest(http.MethodDelete, "/mcp", nil)
+	r.Header.Set(sessionHeader, sid)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", w.Code)
+	}
+
+	if w := postRPC(t, h, sid, `{"jsonrpc":"2.0","id":1,"method":"ping"}`); w.Code != http.StatusNotFound {
+		t.Errorf("after delete: expected 404, got %d", w.Code)
+	}
+}
+
+func TestHTTPTransport_ReapsIdleSessions(t *testing.T) {
+	s := newTestServer()
+	transport := s.HTTPHandler().(*httpTransport)
+	idle := initSession(t, transport)
+	active := initSession(t, transport)
+
+	sess, _ := transport.session(idle)
+	sess.touch(time.Now().Add(-sessionIdleTTL - time.Minute))
+	streaming, _ := transport.session(active)
+	streaming.touch(time.Now().Add(-sessionIdleTTL - time.Minute))
+	streaming.streams.Add(1)
+
+	transport.reapIdle(time.Now().Add(sessionSweepInterval))
+	if _, ok := transport.session(idle); ok {
+		t.Error("expected the idle session to be ended")
+	}
+	select {
+	case <-sess.closed:
+	default:
+		t.Error("expected the idle session to be closed")
+	}
+	if _, ok := transport.session(active); !ok {
+		t.Error("a session with an open stream is not idle")
+	}
+	if len(s.peers) != 1 {
+		t.Errorf("expected the idle session to be detached, %d peers left", len(s.peers))
+	}
+	if w := postRPC(t, transport, idle, `{"jsonrpc":"2.0","id":1,"method":"ping"}`); w.Code != http.StatusNotFound {
+		t.Errorf("after reaping: expected 404, got %d", w.Code)
+	}
+}
+
+func TestHTTPTransport_ChecksOrigin(t *testing.T) {
+	h := newTestServer().HTTPHandler("https://app.example.com")
+	for origin, want := range map[string]int{
+		"":                        http.StatusOK,
+		"http://localhost:5173":   http.StatusOK,
+		"http://127.0.0.1:41777":  http.StatusOK,
+		"https://app.example.com": http.StatusOK,
+		"https://evil.example":    http.StatusForbidden,
+		"http://localhost.evil":   http.StatusForbidden,
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`))
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("origin %q: expected %d, got %d", origin, want, w.Code)
+		}
+	}
+}
+
+func TestHTTPTransport_StreamDeliversQueuedMessages(t *testing.T) {
+	transport := newTestServer().HTTPHandler().(*httpTransport)
+	srv := httptest.NewServer(transport)
+	defer srv.Close()
+	sid := initSession(t, transport)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	r.Header.Set("Accept", "text/event-stream")
+	r.Header.Set(sessionHeader, sid)
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	sess, _ := transport.session(sid)
+	sess.outbox <- []byte(`{"jsonrpc":"2.0","method":"notifications/message"}`)
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data: ") {
+			if !strings.Contains(line, "notifications/message") {
+				t.Errorf("unexpected data line %q", line)
+			}
+			return
+		}
+	}
+	t.Fatalf("stream ended without data: %v", scanner.Err())
+}
+
+func TestHTTPTransport_GetRequiresEventStream(t *testing.T) {
+	h := newTestServer().HTTPHandler()
+	sid := initSession(t, h)
+
+	r := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+	r.Header.Set(sessionHeader, sid)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNotAcceptable {
+		t.Errorf("expected 406, got %d", w.Code)
+	}
+}
@@ -0,0 +1,217 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// notifyCoalesce is how long change notifications are buffered before they
+// are flushed. A single API call usually fans out into several broadcasts
+// (workflow_updated + phase_changed + workflow.phase_transition), which this
+// collapses into one notification per URI.
+const notifyCoalesce = 50 * time.Millisecond
+
+// peer is a connected client that can receive server-initiated messages.
+type peer interface {
+	// sessionID identifies the client, e.g. in the tool-call audit log.
+	sessionID() string
+	notify(data []byte)
+}
+
+// stdioPeer delivers notifications on the stdio transport. The stdio client
+// has no protocol-level session; Serve uses the agent's session from
+// CLAUDE_SESSION_ID, or makes one up per connection when it is unset.
+type stdioPeer struct {
+	s       *Server
+	session string
+}
+
+func (p stdioPeer) sessionID() string { return p.session }
+
+func (p stdioPeer) notify(data []byte) {
+	p.s.writeMu.Lock()
+	defer p.s.writeMu.Unlock()
+	_, _ = fmt.Fprintf(p.s.writer, "%s\n", data)
+}
+
+// pendingChanges accumulates changes until the next flush.
+type pendingChanges struct {
+	uris         map[string]bool
+	resourceList bool
+	toolList     bool
+}
+
+// attach starts tracking a client so it receives list_changed notifications
+// and can subscribe to resources.
+func (s *Server) attach(p peer) {
+	s.peersMu.Lock()
+	defer s.peersMu.Unlock()
+	if _, ok := s.peers[p]; !ok {
+		s.peers[p] = make(map[string]bool)
+	}
+}
+
+// detach forgets a client and its subscriptions.
+func (s *Server) detach(p peer) {
+	s.peersMu.Lock()
+	defer s.peersMu.Unlock()
+	delete(s.peers, p)
+}
+
+func (s *Server) handleSubscribe(p peer, req jsonRPCRequest, subscribe bool) *jsonRPCResponse {
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+		return errorResponse(req.ID, -32602, "invalid params: uri is required")
+	}
+	if p == nil {
+		return errorResponse(req.ID, -32600, "subscriptions require a session")
+	}
+	if subscribe && !s.matchesResource(params.URI) {
+		return errorResponse(req.ID, -32002, fmt.Sprintf("resource %q not found", params.URI))
+	}
+
+	s.peersMu.Lock()
+	subs, ok := s.peers[p]
+	if !ok {
+		subs = make(map[string]bool)
+		s.peers[p] = subs
+	}
+	if subscribe {
+		subs[params.URI] = true
+	} else {
+		delete(subs, params.URI)
+	}
+	s.peersMu.Unlock()
+	return result(req.ID, map[string]any{})
+}
+
+// matchesResource reports whether uri belongs to a registered template. It
+// does not read the resource, so subscribing to something that does not exist
+// yet (a mission's signals before the first signal) is allowed.
+func (s *Server) matchesResource(uri string) bool {
+	for _, t := range s.resourceTemplates() {
+		if t.pattern.MatchString(uri) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResourceUpdated queues notifications/resources/updated for every client
+// subscribed to one of the given URIs.
+func (s *Server) ResourceUpdated(uris ...string) {
+	if len(uris) == 0 {
+		return
+	}
+	s.queue(func(p *pendingChanges) {
+		if p.uris == nil {
+			p.uris = make(map[string]bool)
+		}
+		for _, uri := range uris {
+			p.uris[uri] = true
+		}
+	})
+}
+
+// ResourceListChanged queues notifications/resources/list_changed for every
+// connected client.
+func (s *Server) ResourceListChanged() {
+	s.queue(func(p *pendingChanges) { p.resourceList = true })
+}
+
+// toolsChanged queues notifications/tools/list_changed for every connected
+// client.
+func (s *Server) toolsChanged() {
+	s.queue(func(p *pendingChanges) { p.toolList = true })
+}
+
+// WorkflowDefinitionsReloaded is the hub message broadcast after the API
+// reloads the workflow definitions. Its payload lists the workflow types and
+// phases now known: {"types": [...], "phases": [...]}.
+const WorkflowDefinitionsReloaded = "workflow_definitions_reloaded"
+
+// HandleChange translates an internal change message (a WebSocket hub
+// broadcast or an event bus event) into resource notifications. A reload of
+// the workflow definitions updates the workflow tool schemas instead.
+func (s *Server) HandleChange(kind string, payload any) {
+	if kind == WorkflowDefinitionsReloaded {
+		// Applied even without peers: the schemas outlive this moment.
+		m := asMap(normalize(payload))
+		s.SetWorkflowEnums(stringList(m["types"]), stringList(m["phases"]))
+		return
+	}
+	s.peersMu.Lock()
+	idle := len(s.peers) == 0
+	s.peersMu.Unlock()
+	if idle {
+		return
+	}
+	c := ChangeFor(kind, payload)
+	s.ResourceUpdated(c.URIs...)
+	if c.ListChanged {
+		s.ResourceListChanged()
+	}
+}
+
+func (s *Server) queue(apply func(*pendingChanges)) {
+	s.peersMu.Lock()
+	defer s.peersMu.Unlock()
+	if len(s.peers) == 0 {
+		// Nobody to tell; changes before the first client connects are moot.
+		return
+	}
+	apply(&s.pending)
+	if s.flush == nil {
+		s.flush = time.AfterFunc(notifyCoalesce, s.flushChanges)
+	}
+}
+
+// flushChanges sends the accumulated notifications.
+func (s *Server) flushChanges() {
+	s.peersMu.Lock()
+	pending := s.pending
+	s.pending = pendingChanges{}
+	s.flush = nil
+	type delivery struct {
+		p    peer
+		msgs [][]byte
+	}
+	var out []delivery
+	for p, subs := range s.peers {
+		var msgs [][]byte
+		if pending.toolList {
+			msgs = append(msgs, notification("notifications/tools/list_changed", nil))
+		}
+		if pending.resourceList {
+			msgs = append(msgs, notification("notifications/resources/list_changed", nil))
+		}
+		for uri := range pending.uris {
+			if subs[uri] {
+				msgs = append(msgs, notification("notifications/resources/updated", map[string]any{"uri": uri}))
+			}
+		}
+		if len(msgs) > 0 {
+			out = append(out, delivery{p, msgs})
+		}
+	}
+	s.peersMu.Unlock()
+
+	for _, d := range out {
+		for _, msg := range d.msgs {
+			d.p.notify(msg)
+		}
+	}
+}
+
+// notification encodes a JSON-RPC notification (a message without an id).
+func notification(method string, params any) []byte {
+	msg := map[string]any{"jsonrpc": "2.0", "method": method}
+	if params != nil {
+		msg["params"] = params
+	}
+	data, _ := json.Marshal(msg)
+	return data
+}
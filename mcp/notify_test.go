@@ -0,0 +1,164 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recvNotification waits for the next message queued on an HTTP session.
+func recvNotification(t *testing.T, sess *httpSession) map[string]any {
+	t.Helper()
+	select {
+	case data := <-sess.outbox:
+		var msg map[string]any
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("decode notification %s: %v", data, err)
+		}
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for notification")
+		return nil
+	}
+}
+
+func expectNoNotification(t *testing.T, sess *httpSession) {
+	t.Helper()
+	select {
+	case data := <-sess.outbox:
+		t.Fatalf("unexpected notification %s", data)
+	case <-time.After(3 * notifyCoalesce):
+	}
+}
+
+func TestResourceSubscriptions(t *testing.T) {
+	s := newTestServer()
+	s.RegisterResourceTemplate(ResourceTemplate{URITemplate: "stratus://workflow/{id}"})
+	transport := s.HTTPHandler().(*httpTransport)
+	sid := initSession(t, transport)
+	sess, _ := transport.session(sid)
+
+	w := postRPC(t, transport, sid, `{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"stratus://workflow/spec-auth"}}`)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), `"error"`) {
+		t.Fatalf("subscribe: %d %s", w.Code, w.Body.String())
+	}
+	w = postRPC(t, transport, sid, `{"jsonrpc":"2.0","id":3,"method":"resources/subscribe","params":{"uri":"file:///etc/passwd"}}`)
+	if !strings.Contains(w.Body.String(), "-32002") {
+		t.Errorf("subscribe to unknown resource: expected -32002, got %s", w.Body.String())
+	}
+
+	// One API call broadcasts several messages; the subscriber sees one update.
+	s.HandleChange("workflow_updated", map[string]any{"id": "spec-auth", "phase": "plan"})
+	s.HandleChange("phase_changed", map[string]any{"workflow_id": "spec-auth", "phase": "plan"})
+	s.HandleChange("workflow_updated", map[string]any{"id": "bug-other"})
+
+	msg := recvNotification(t, sess)
+	if msg["method"] != "notifications/resources/updated" {
+		t.Fatalf("method = %v", msg["method"])
+	}
+	if uri := msg["params"].(map[string]any)["uri"]; uri != "stratus://workflow/spec-auth" {
+		t.Errorf("uri = %v", uri)
+	}
+	expectNoNotification(t, sess)
+
+	postRPC(t, transport, sid, `{"jsonrpc":"2.0","id":4,"method":"resources/unsubscribe","params":{"uri":"stratus://workflow/spec-auth"}}`)
+	s.HandleChange("workflow_updated", map[string]any{"id": "spec-auth"})
+	expectNoNotification(t, sess)
+}
+
+func TestToolsListChanged(t *testing.T) {
+	s := newTestServer()
+	transport := s.HTTPHandler().(*httpTransport)
+	sid := initSession(t, transport)
+	sess, _ := transport.session(sid)
+
+	s.Register(Tool{Name: "extra", Handler: func(map[string]any) (any, error) { return nil, nil }})
+	if msg := recvNotification(t, sess); msg["method"] != "notifications/tools/list_changed" {
+		t.Errorf("after Register: method = %v", msg["method"])
+	}
+
+	s.Register(Tool{Name: "extra", Description: "replaced", Handler: func(map[string]any) (any, error) { return nil, nil }})
+	if msg := recvNotification(t, sess); msg["method"] != "notifications/tools/list_changed" {
+		t.Errorf("after replacing: method = %v", msg["method"])
+	}
+	if tools := s.toolList(); len(tools) != 2 || tools[1].Description != "replaced" {
+		t.Errorf("expected the tool to be replaced in place, got %+v", tools)
+	}
+}
+
+func TestWorkflowDefinitionsReloaded(t *testing.T) {
+	s := New()
+	RegisterTools(s, "http://unused", nil)
+	before := s.tools["register_workflow"].InputSchema
+	transport := s.HTTPHandler().(*httpTransport)
+	sid := initSession(t, transport)
+	sess, _ := transport.session(sid)
+
+	s.HandleChange(WorkflowDefinitionsReloaded, map[string]any{
+		"types":  []string{"spec", "bug", "e2e", "release"},
+		"phases": []string{"plan", "implement", "ship"},
+	})
+	if msg := recvNotification(t, sess); msg["method"] != "notifications/tools/list_changed" {
+		t.Errorf("after reload: method = %v", msg["method"])
+	}
+
+	register, _ := s.tool("register_workflow")
+	args, err := validateArgs(register.InputSchema, map[string]any{"id": "release-1", "type": "Release", "title": "1.0"})
+	if err != nil || args["type"] != "release" {
+		t.Errorf("expected the reloaded type to validate, got %v, %v", args, err)
+	}
+	transition, _ := s.tool("transition_phase")
+	if _, err := validateArgs(transition.InputSchema, map[string]any{"workflow_id": "release-1", "phase": "ship"}); err != nil {
+		t.Errorf("expected the reloaded phase to validate: %v", err)
+	}
+	if _, err := validateArgs(before, map[string]any{"id": "release-1", "type": "release", "title": "1.0"}); err == nil {
+		t.Error("the previous schema should not have been modified")
+	}
+}
+
+func TestSubscribeRequiresSession(t *testing.T) {
+	s := New()
+	s.RegisterResourceTemplate(ResourceTemplate{URITemplate: "stratus://workflow/{id}"})
+	resp := s.handle(nil, jsonRPCRequest{ID: 1, Method: "resources/subscribe", Params: json.RawMessage(`{"uri":"stratus://workflow/x"}`)})
+	if resp.Error == nil || resp.Error.Code != -32600 {
+		t.Errorf("expected -32600 without a session, got %+v", resp)
+	}
+}
+
+func TestChangeFor(t *testing.T) {
+	type ticket struct {
+		ID        string  `json:"id"`
+		MissionID string  `json:"mission_id"`
+		WorkerID  *string `json:"worker_id,omitempty"`
+	}
+	worker := "w1"
+	tests := []struct {
+		kind    string
+		payload any
+		want    []string
+		list    bool
+	}{
+		{"workflow.phase_transition", map[string]any{"workflow_id": "spec-a", "to_phase": "plan"},
+			[]string{"stratus://workflow/spec-a", "stratus://workflow/spec-a/plan", "stratus://workflow/spec-a/design", "stratus://workflow/spec-a/summary"}, false},
+		{"workflow_deleted", map[string]string{"id": "bug-b"},
+			[]string{"stratus://workflow/bug-b", "stratus://workflow/bug-b/plan", "stratus://workflow/bug-b/design", "stratus://workflow/bug-b/summary"}, true},
+		{"ticket_status", &ticket{ID: "t1", MissionID: "m1", WorkerID: &worker},
+			[]string{"stratus://swarm/mission/m1", "stratus://swarm/mission/m1/tickets", "stratus://swarm/worker/w1"}, false},
+		{"tickets_dispatched", map[string]any{"mission_id": "m1", "assignments": []map[string]string{{"ticket_id": "t1", "worker_id": "w2"}}},
+			[]string{"stratus://swarm/mission/m1", "stratus://swarm/mission/m1/tickets", "stratus://swarm/worker/w2"}, false},
+		{"signal_sent", map[string]any{"mission_id": "m1", "to_worker": "w1"},
+			[]string{"stratus://swarm/mission/m1/signals", "stratus://swarm/worker/w1"}, false},
+		{"signal_sent", map[string]any{"mission_id": "m1", "to_worker": "*"},
+			[]string{"stratus://swarm/mission/m1/signals"}, false},
+		{"event_saved", map[string]any{"id": int64(42)}, []string{"stratus://memory/42"}, true},
+		{"worker_heartbeat", map[string]string{"id": "w1"}, nil, false},
+	}
+	for _, tt := range tests {
+		got := ChangeFor(tt.kind, tt.payload)
+		if strings.Join(got.URIs, " ") != strings.Join(tt.want, " ") || got.ListChanged != tt.list {
+			t.Errorf("ChangeFor(%s) = %+v, want URIs %v list %v", tt.kind, got, tt.want, tt.list)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Prompt describes a prompt template returned by prompts/list.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument is a named argument a prompt accepts.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptMessage is one message of a rendered prompt.
+type PromptMessage struct {
+	Role    string         `json:"role"`
+	Content map[string]any `json:"content"`
+}
+
+// PromptResult is the rendered prompt returned by prompts/get.
+type PromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// PromptSource supplies prompts to the server. Prompts are resolved on every
+// request so edits on disk show up without restarting the server.
+type PromptSource struct {
+	List func() ([]Prompt, error)
+	Get  func(name string, args map[string]string) (*PromptResult, error)
+}
+
+var (
+	// errPromptNotFound is returned by PromptSource.Get for unknown prompt names.
+	errPromptNotFound = errors.New("prompt not found")
+	// errPromptArguments is returned by PromptSource.Get when required
+	// arguments are missing.
+	errPromptArguments = errors.New("invalid prompt arguments")
+)
+
+// SetPromptSource installs the provider used for prompts/list and prompts/get.
+func (s *Server) SetPromptSource(p PromptSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prompts = &p
+}
+
+func (s *Server) promptSource() *PromptSource {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.prompts
+}
+
+func (s *Server) handlePromptsList(req jsonRPCRequest) *jsonRPCResponse {
+	list := []Prompt{}
+	if src := s.promptSource(); src != nil {
+		prompts, err := src.List()
+		if err != nil {
+			log.Printf("mcp: list prompts: %v", err)
+		} else {
+			list = append(list, prompts...)
+		}
+	}
+	return result(req.ID, map[string]any{"prompts": list})
+}
+
+func (s *Server) handlePromptsGet(req jsonRPCRequest) *jsonRPCResponse {
+	var params struct {
+		Name      string            `json:"name"`
+		Arguments map[string]string `json:"arguments"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+		return errorResponse(req.ID, -32602, "invalid params: name is required")
+	}
+	src := s.promptSource()
+	if src == nil {
+		return errorResponse(req.ID, -32602, fmt.Sprintf("prompt %q not found", params.Name))
+	}
+	res, err := src.Get(params.Name, params.Arguments)
+	if errors.Is(err, errPromptNotFound) {
+		return errorResponse(req.ID, -32602, fmt.Sprintf("prompt %q not found", params.Name))
+	}
+	if errors.Is(err, errPromptArguments) {
+		return errorResponse(req.ID, -32602, err.Error())
+	}
+	if err != nil {
+		return errorResponse(req.ID, -32603, err.Error())
+	}
+	return result(req.ID, res)
+}
@@ -0,0 +1,135 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// Resource is a concrete, readable MCP resource returned by resources/list.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents is the body of a resource returned by resources/read.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// ResourceTemplate describes a family of resources sharing a URI template
+// (RFC 6570 level 1, e.g. "stratus://wiki/{id}"). List enumerates the
+// concrete resources currently available; Read resolves one URI whose
+// template variables have been extracted into vars.
+type ResourceTemplate struct {
+	URITemplate string                                                              `json:"uriTemplate"`
+	Name        string                                                              `json:"name"`
+	Description string                                                              `json:"description,omitempty"`
+	MimeType    string                                                              `json:"mimeType,omitempty"`
+	List        func() ([]Resource, error)                                          `json:"-"`
+	Read        func(uri string, vars map[string]string) (*ResourceContents, error) `json:"-"`
+
+	pattern *regexp.Regexp
+	vars    []string
+}
+
+// errResourceNotFound is returned by Read handlers for URIs that match a
+// template but do not resolve to an existing resource.
+var errResourceNotFound = errors.New("resource not found")
+
+// RegisterResourceTemplate adds a resource family to the server.
+func (s *Server) RegisterResourceTemplate(t ResourceTemplate) {
+	t.pattern, t.vars = compileURITemplate(t.URITemplate)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resources = append(s.resources, t)
+}
+
+func (s *Server) resourceTemplates() []ResourceTemplate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]ResourceTemplate(nil), s.resources...)
+}
+
+// listResources gathers concrete resources from every template. A failing
+// template is logged and skipped so one unavailable API does not hide the rest.
+func (s *Server) listResources() []Resource {
+	list := []Resource{}
+	for _, t := range s.resourceTemplates() {
+		if t.List == nil {
+			continue
+		}
+		items, err := t.List()
+		if err != nil {
+			log.Printf("mcp: list resources %s: %v", t.URITemplate, err)
+			continue
+		}
+		list = append(list, items...)
+	}
+	return list
+}
+
+// readResource dispatches uri to the first template that matches it.
+func (s *Server) readResource(uri string) (*ResourceContents, error) {
+	for _, t := range s.resourceTemplates() {
+		m := t.pattern.FindStringSubmatch(uri)
+		if m == nil {
+			continue
+		}
+		vars := make(map[string]string, len(t.vars))
+		for i, name := range t.vars {
+			vars[name] = m[i+1]
+		}
+		return t.Read(uri, vars)
+	}
+	return nil, errResourceNotFound
+}
+
+func (s *Server) handleResourcesRead(req jsonRPCRequest) *jsonRPCResponse {
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+		return errorResponse(req.ID, -32602, "invalid params: uri is required")
+	}
+	contents, err := s.readResource(params.URI)
+	if errors.Is(err, errResourceNotFound) {
+		return errorResponse(req.ID, -32002, fmt.Sprintf("resource %q not found", params.URI))
+	}
+	if err != nil {
+		return errorResponse(req.ID, -32603, err.Error())
+	}
+	return result(req.ID, map[string]any{"contents": []*ResourceContents{contents}})
+}
+
+var uriTemplateVar = regexp.MustCompile(`\{(\+?)([a-zA-Z0-9_]+)\}`)
+
+// compileURITemplate turns "stratus://workflow/{id}/plan" into an anchored
+// regexp. Simple variables match a single path segment; reserved expansions
+// ("{+path}") may span slashes, as in RFC 6570.
+func compileURITemplate(tmpl string) (*regexp.Regexp, []string) {
+	var b strings.Builder
+	var vars []string
+	b.WriteString("^")
+	last := 0
+	for _, loc := range uriTemplateVar.FindAllStringSubmatchIndex(tmpl, -1) {
+		b.WriteString(regexp.QuoteMeta(tmpl[last:loc[0]]))
+		vars = append(vars, tmpl[loc[4]:loc[5]])
+		if loc[3] > loc[2] {
+			b.WriteString("(.+)")
+		} else {
+			b.WriteString("([^/]+)")
+		}
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(tmpl[last:]))
+	b.WriteString("$")
+	return regexp.MustCompile(b.String()), vars
+}
@@ -0,0 +1,157 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompileURITemplate(t *testing.T) {
+	tests := []struct {
+		tmpl  string
+		uri   string
+		match bool
+		vars  map[string]string
+	}{
+		{"stratus://workflow/{id}/plan", "stratus://workflow/spec-auth/plan", true, map[string]string{"id": "spec-auth"}},
+		{"stratus://workflow/{id}/plan", "stratus://workflow/spec-auth/design", false, nil},
+		{"stratus://workflow/{id}", "stratus://workflow/spec-auth/plan", false, nil},
+		{"stratus://governance/{+path}", "stratus://governance/.claude/rules/go.md", true, map[string]string{"path": ".claude/rules/go.md"}},
+	}
+	for _, tt := range tests {
+		re, names := compileURITemplate(tt.tmpl)
+		m := re.FindStringSubmatch(tt.uri)
+		if (m != nil) != tt.match {
+			t.Errorf("%s vs %s: match=%v, want %v", tt.tmpl, tt.uri, m != nil, tt.match)
+			continue
+		}
+		for i, name := range names {
+			if m != nil && m[i+1] != tt.vars[name] {
+				t.Errorf("%s: var %s = %q, want %q", tt.tmpl, name, m[i+1], tt.vars[name])
+			}
+		}
+	}
+}
+
+func TestResourcesReadDispatch(t *testing.T) {
+	s := New()
+	s.RegisterResourceTemplate(ResourceTemplate{
+		URITemplate: "stratus://wiki/{id}",
+		List: func() ([]Resource, error) {
+			return []Resource{{URI: "stratus://wiki/p1", Name: "Page one"}}, nil
+		},
+		Read: func(uri string, vars map[string]string) (*ResourceContents, error) {
+			if vars["id"] != "p1" {
+				return nil, errResourceNotFound
+			}
+			return &ResourceContents{URI: uri, MimeType: mimeMarkdown, Text: "# Page one"}, nil
+		},
+	})
+	s.RegisterResourceTemplate(ResourceTemplate{
+		URITemplate: "stratus://broken/{id}",
+		List:        func() ([]Resource, error) { return nil, errors.New("api down") },
+		Read:        func(string, map[string]string) (*ResourceContents, error) { return nil, errors.New("api down") },
+	})
+
+	init := s.handle(nil, jsonRPCRequest{ID: 1, Method: "initialize", Params: json.RawMessage(`{}`)})
+	caps := init.Result.(map[string]any)["capabilities"].(map[string]any)
+	if _, ok := caps["resources"]; !ok {
+		t.Error("expected resources capability")
+	}
+	if _, ok := caps["prompts"]; ok {
+		t.Error("prompts capability advertised without a prompt source")
+	}
+
+	list := s.handle(nil, jsonRPCRequest{ID: 2, Method: "resources/list"})
+	resources := list.Result.(map[string]any)["resources"].([]Resource)
+	if len(resources) != 1 || resources[0].URI != "stratus://wiki/p1" {
+		t.Errorf("resources/list = %+v, want the single healthy resource", resources)
+	}
+
+	read := s.handle(nil, jsonRPCRequest{ID: 3, Method: "resources/read", Params: json.RawMessage(`{"uri":"stratus://wiki/p1"}`)})
+	if read.Error != nil {
+		t.Fatalf("resources/read error: %+v", read.Error)
+	}
+
+	missing := s.handle(nil, jsonRPCRequest{ID: 4, Method: "resources/read", Params: json.RawMessage(`{"uri":"stratus://wiki/nope"}`)})
+	if missing.Error == nil || missing.Error.Code != -32002 {
+		t.Errorf("expected -32002 for missing resource, got %+v", missing.Error)
+	}
+
+	unknown := s.handle(nil, jsonRPCRequest{ID: 5, Method: "resources/read", Params: json.RawMessage(`{"uri":"file:///etc/passwd"}`)})
+	if unknown.Error == nil || unknown.Error.Code != -32002 {
+		t.Errorf("expected -32002 for unmatched URI, got %+v", unknown.Error)
+	}
+}
+
+func TestPromptArguments(t *testing.T) {
+	tests := []struct {
+		hint string
+		body string
+		want []PromptArgument
+	}{
+		{"[workflow-id]", "", []PromptArgument{{Name: "workflow-id", Description: "workflow-id"}}},
+		{"<feature description>", "", []PromptArgument{{Name: "feature_description", Description: "feature description", Required: true}}},
+		{"", "Review: $ARGUMENTS", []PromptArgument{{Name: "arguments", Description: "Input passed to the skill as $ARGUMENTS"}}},
+		{"", "No placeholders", nil},
+	}
+	for _, tt := range tests {
+		got := promptArguments(tt.hint, tt.body)
+		if len(got) != len(tt.want) {
+			t.Errorf("promptArguments(%q) = %+v, want %+v", tt.hint, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("promptArguments(%q)[%d] = %+v, want %+v", tt.hint, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestRegisterPrompts_RendersSkillBody(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/skills":
+			_, _ = w.Write([]byte(`{"skills":[{"name":"spec","description":"Spec workflow","argument_hint":"<feature description>","body":"Build: $ARGUMENTS"}]}`))
+		case "/api/skills/spec":
+			_, _ = w.Write([]byte(`{"name":"spec","description":"Spec workflow","argument_hint":"<feature description>","body":"Build: $ARGUMENTS"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"skill not found"}`))
+		}
+	}))
+	defer api.Close()
+
+	s := New()
+	RegisterPrompts(s, api.URL, api.Client())
+
+	list := s.handle(nil, jsonRPCRequest{ID: 1, Method: "prompts/list"})
+	prompts := list.Result.(map[string]any)["prompts"].([]Prompt)
+	if len(prompts) != 1 || prompts[0].Name != "spec" || len(prompts[0].Arguments) != 1 {
+		t.Fatalf("prompts/list = %+v", prompts)
+	}
+
+	got := s.handle(nil, jsonRPCRequest{ID: 2, Method: "prompts/get", Params: json.RawMessage(`{"name":"spec","arguments":{"feature_description":"user login"}}`)})
+	if got.Error != nil {
+		t.Fatalf("prompts/get error: %+v", got.Error)
+	}
+	res := got.Result.(*PromptResult)
+	if text := res.Messages[0].Content["text"].(string); !strings.Contains(text, "Build: user login") {
+		t.Errorf("rendered prompt = %q", text)
+	}
+
+	missingArg := s.handle(nil, jsonRPCRequest{ID: 3, Method: "prompts/get", Params: json.RawMessage(`{"name":"spec"}`)})
+	if missingArg.Error == nil || missingArg.Error.Code != -32602 {
+		t.Errorf("expected -32602 for missing required argument, got %+v", missingArg.Error)
+	}
+
+	unknown := s.handle(nil, jsonRPCRequest{ID: 4, Method: "prompts/get", Params: json.RawMessage(`{"name":"nope"}`)})
+	if unknown.Error == nil || unknown.Error.Code != -32602 {
+		t.Errorf("expected -32602 for unknown prompt, got %+v", unknown.Error)
+	}
+}
@@ -0,0 +1,330 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema helpers. Tool input schemas are JSON Schema objects built from
+// fields:
+//
+//	obj(
+//		req("phase", "string", "Target phase", enum("plan", "implement")),
+//		opt("tags", "array", "Tags", items("string")),
+//	)
+
+// schemaOption refines a property schema (enum, item type, bounds).
+type schemaOption func(prop map[string]any)
+
+// enum restricts a string property (or array items) to a fixed set.
+func enum(values ...string) schemaOption {
+	return func(prop map[string]any) { prop["enum"] = values }
+}
+
+// withEnum returns a copy of an object schema with the named property
+// restricted to values. The original is left untouched, as calls in flight may
+// still be validated against it.
+func withEnum(schema map[string]any, name string, values []string) map[string]any {
+	props, _ := schema["properties"].(map[string]any)
+	prop, ok := props[name].(map[string]any)
+	if !ok {
+		return schema
+	}
+	prop = maps.Clone(prop)
+	prop["enum"] = slices.Clone(values)
+	props = maps.Clone(props)
+	props[name] = prop
+	out := maps.Clone(schema)
+	out["properties"] = props
+	return out
+}
+
+// items sets the schema of an array property's elements.
+func items(typ string, opts ...schemaOption) schemaOption {
+	return func(prop map[string]any) {
+		item := map[string]any{"type": typ}
+		for _, o := range opts {
+			o(item)
+		}
+		prop["items"] = item
+	}
+}
+
+// itemsOf sets a full schema (e.g. an object) for an array's elements.
+func itemsOf(schema map[string]any) schemaOption {
+	return func(prop map[string]any) { prop["items"] = schema }
+}
+
+// minimum sets the inclusive lower bound of a numeric property.
+func minimum(n float64) schemaOption {
+	return func(prop map[string]any) { prop["minimum"] = n }
+}
+
+// maximum sets the inclusive upper bound of a numeric property.
+func maximum(n float64) schemaOption {
+	return func(prop map[string]any) { prop["maximum"] = n }
+}
+
+// field is one property of an object schema.
+type field struct {
+	name     string
+	required bool
+	schema   map[string]any
+}
+
+func req(name, typ, desc string, opts ...schemaOption) field {
+	return newField(name, typ, desc, true, opts)
+}
+
+func opt(name, typ, desc string, opts ...schemaOption) field {
+	return newField(name, typ, desc, false, opts)
+}
+
+func newField(name, typ, desc string, required bool, opts []schemaOption) field {
+	prop := map[string]any{"type": typ, "description": desc}
+	if typ == "array" {
+		prop["items"] = map[string]any{"type": "string"}
+	}
+	for _, o := range opts {
+		o(prop)
+	}
+	return field{name: name, required: required, schema: prop}
+}
+
+// obj builds an object schema with a properties map and a required array.
+func obj(fields ...field) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	for _, f := range fields {
+		properties[f.name] = f.schema
+		if f.required {
+			required = append(required, f.name)
+		}
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// listOf is the output schema of a tool whose API endpoint returns a JSON
+// array; structuredContent wraps it as {"items": [...]}.
+func listOf(item map[string]any) map[string]any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"items": map[string]any{"type": "array", "items": item}},
+		"required":   []string{"items"},
+	}
+}
+
+// setKeys returns the members of a validity set (e.g. swarm.ValidTicketStatuses)
+// in a stable order for use as an enum.
+func setKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// argError is a tools/call argument that does not satisfy the tool's input
+// schema. It is reported as JSON-RPC -32602.
+type argError struct {
+	Field  string
+	Reason string
+}
+
+func (e *argError) Error() string {
+	return fmt.Sprintf("invalid argument %q: %s", e.Field, e.Reason)
+}
+
+// validateArgs checks args against an object schema and returns a copy with
+// values coerced to their declared types. Models regularly send numbers and
+// arrays as strings ("5", "[\"a\"]") and enum values in the wrong case;
+// those are accepted and normalized so handlers only see well-typed values.
+func validateArgs(schema map[string]any, args map[string]any) (map[string]any, error) {
+	props, _ := schema["properties"].(map[string]any)
+	out := make(map[string]any, len(args))
+	for name, v := range args {
+		prop, ok := props[name].(map[string]any)
+		if !ok || v == nil {
+			// Unknown properties pass through untouched; explicit nulls mean
+			// "not set".
+			if v != nil {
+				out[name] = v
+			}
+			continue
+		}
+		cv, err := checkValue(name, prop, v)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = cv
+	}
+	for _, name := range stringList(schema["required"]) {
+		v, ok := out[name]
+		if !ok {
+			return nil, &argError{Field: name, Reason: "is required"}
+		}
+		if s, isStr := v.(string); isStr && strings.TrimSpace(s) == "" {
+			return nil, &argError{Field: name, Reason: "must not be empty"}
+		}
+	}
+	return out, nil
+}
+
+func checkValue(path string, prop map[string]any, v any) (any, error) {
+	typ, _ := prop["type"].(string)
+	switch typ {
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return nil, &argError{Field: path, Reason: fmt.Sprintf("must be a string, got %s", jsonType(v))}
+		}
+		if allowed := stringList(prop["enum"]); len(allowed) > 0 {
+			for _, a := range allowed {
+				if strings.EqualFold(strings.TrimSpace(s), a) {
+					return a, nil
+				}
+			}
+			return nil, &argError{Field: path, Reason: fmt.Sprintf("must be one of %s, got %q", strings.Join(allowed, ", "), s)}
+		}
+		return s, nil
+
+	case "integer", "number":
+		n, ok := toNumber(v)
+		if !ok {
+			kind := "a number"
+			if typ == "integer" {
+				kind = "an integer"
+			}
+			return nil, &argError{Field: path, Reason: fmt.Sprintf("must be %s, got %s", kind, jsonType(v))}
+		}
+		if typ == "integer" && n != float64(int64(n)) {
+			return nil, &argError{Field: path, Reason: fmt.Sprintf("must be an integer, got %v", n)}
+		}
+		if lo, ok := prop["minimum"].(float64); ok && n < lo {
+			return nil, &argError{Field: path, Reason: fmt.Sprintf("must be >= %v, got %v", lo, n)}
+		}
+		if hi, ok := prop["maximum"].(float64); ok && n > hi {
+			return nil, &argError{Field: path, Reason: fmt.Sprintf("must be <= %v, got %v", hi, n)}
+		}
+		return n, nil
+
+	case "boolean":
+		switch b := v.(type) {
+		case bool:
+			return b, nil
+		case string:
+			if parsed, err := strconv.ParseBool(b); err == nil {
+				return parsed, nil
+			}
+		}
+		return nil, &argError{Field: path, Reason: fmt.Sprintf("must be a boolean, got %s", jsonType(v))}
+
+	case "array":
+		arr, ok := v.([]any)
+		if s, isStr := v.(string); isStr {
+			ok = json.Unmarshal([]byte(s), &arr) == nil
+		}
+		if !ok {
+			return nil, &argError{Field: path, Reason: fmt.Sprintf("must be an array, got %s", jsonType(v))}
+		}
+		itemSchema, _ := prop["items"].(map[string]any)
+		if itemSchema == nil {
+			return arr, nil
+		}
+		out := make([]any, len(arr))
+		for i, item := range arr {
+			cv, err := checkValue(fmt.Sprintf("%s[%d]", path, i), itemSchema, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = cv
+		}
+		return out, nil
+
+	case "object":
+		m, ok := v.(map[string]any)
+		if s, isStr := v.(string); isStr {
+			ok = json.Unmarshal([]byte(s), &m) == nil && m != nil
+		}
+		if !ok {
+			return nil, &argError{Field: path, Reason: fmt.Sprintf("must be an object, got %s", jsonType(v))}
+		}
+		return m, nil
+	}
+	return v, nil
+}
+
+func toNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// jsonType names the JSON type of a decoded value for error messages.
+func jsonType(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case float64, int, int64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// stringList reads a schema keyword holding strings ([]string when built in
+// Go, []any when decoded from JSON).
+func stringList(v any) []string {
+	switch l := v.(type) {
+	case []string:
+		return l
+	case []any:
+		out := make([]string, 0, len(l))
+		for _, item := range l {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// structuredContent returns the structured form of a tool result. MCP
+// requires an object, so arrays are wrapped as {"items": [...]}; scalars and
+// nil have no structured form.
+func structuredContent(res any) (map[string]any, bool) {
+	switch v := normalize(res).(type) {
+	case map[string]any:
+		return v, true
+	case []any:
+		return map[string]any{"items": v}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,138 @@
+package mcp
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidateArgs(t *testing.T) {
+	schema := obj(
+		req("workflow_id", "string", "Workflow ID"),
+		opt("phase", "string", "Target phase", enum("plan", "implement")),
+		opt("limit", "integer", "Max results", minimum(1), maximum(50)),
+		opt("importance", "number", "Importance", minimum(0), maximum(1)),
+		opt("persist", "boolean", "Persist"),
+		opt("ids", "array", "IDs", items("integer")),
+		opt("refs", "object", "References"),
+	)
+
+	tests := []struct {
+		name    string
+		args    string
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name: "coerces stringly-typed values",
+			args: `{"workflow_id":"spec-a","phase":" Implement","limit":"5","importance":"0.8","persist":"true","ids":"[1,2]","refs":"{\"pr\":12}"}`,
+			want: map[string]any{"workflow_id": "spec-a", "phase": "implement", "limit": 5.0, "importance": 0.8, "persist": true, "ids": []any{1.0, 2.0}, "refs": map[string]any{"pr": 12.0}},
+		},
+		{name: "null is unset", args: `{"workflow_id":"spec-a","limit":null}`, want: map[string]any{"workflow_id": "spec-a"}},
+		{name: "unknown properties pass through", args: `{"workflow_id":"spec-a","extra":1}`, want: map[string]any{"workflow_id": "spec-a", "extra": 1.0}},
+		{name: "missing required", args: `{}`, wantErr: `invalid argument "workflow_id": is required`},
+		{name: "empty required", args: `{"workflow_id":"  "}`, wantErr: `invalid argument "workflow_id": must not be empty`},
+		{name: "enum", args: `{"workflow_id":"a","phase":"deploy"}`, wantErr: `invalid argument "phase": must be one of plan, implement, got "deploy"`},
+		{name: "wrong type", args: `{"workflow_id":42}`, wantErr: `invalid argument "workflow_id": must be a string, got number`},
+		{name: "fractional integer", args: `{"workflow_id":"a","limit":2.5}`, wantErr: `invalid argument "limit": must be an integer, got 2.5`},
+		{name: "maximum", args: `{"workflow_id":"a","importance":3}`, wantErr: `invalid argument "importance": must be <= 1, got 3`},
+		{name: "array item type", args: `{"workflow_id":"a","ids":[1,"x"]}`, wantErr: `invalid argument "ids[1]": must be an integer, got string`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args map[string]any
+			if err := json.Unmarshal([]byte(tt.args), &args); err != nil {
+				t.Fatal(err)
+			}
+			got, err := validateArgs(schema, args)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolsCall_InvalidArgumentsReturn32602(t *testing.T) {
+	s := New()
+	called := false
+	s.Register(Tool{
+		Name:        "transition",
+		InputSchema: obj(req("phase", "string", "Target phase", enum("plan", "implement"))),
+		Handler: func(args map[string]any) (any, error) {
+			called = true
+			return map[string]any{"phase": args["phase"]}, nil
+		},
+	})
+
+	resp := s.handle(nil, jsonRPCRequest{ID: 1, Method: "tools/call", Params: json.RawMessage(`{"name":"transition","arguments":{"phase":"ship"}}`)})
+	if resp.Error == nil || resp.Error.Code != -32602 {
+		t.Fatalf("expected -32602, got %+v", resp)
+	}
+	if data, _ := resp.Error.Data.(map[string]any); data["argument"] != "phase" {
+		t.Errorf("error data = %+v, want argument=phase", resp.Error.Data)
+	}
+	if called {
+		t.Error("handler ran despite invalid arguments")
+	}
+
+	resp = s.handle(nil, jsonRPCRequest{ID: 2, Method: "tools/call", Params: json.RawMessage(`{"name":"transition","arguments":{"phase":"Plan"}}`)})
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %+v", resp.Error)
+	}
+	res := resp.Result.(map[string]any)
+	structured, _ := res["structuredContent"].(map[string]any)
+	if structured["phase"] != "plan" {
+		t.Errorf("structuredContent = %+v, want normalized phase", res["structuredContent"])
+	}
+}
+
+func TestStructuredContentWrapsArrays(t *testing.T) {
+	got, ok := structuredContent([]map[string]string{{"id": "s1"}})
+	if !ok {
+		t.Fatal("expected structured content for an array result")
+	}
+	if list, _ := got["items"].([]any); len(list) != 1 {
+		t.Errorf("got %+v, want one wrapped item", got)
+	}
+	if _, ok := structuredContent("plain"); ok {
+		t.Error("scalar results have no structured form")
+	}
+}
+
+// TestRegisterTools_SchemasAreWellFormed guards against typos in the tool
+// table: every required argument must be a declared property with a type.
+func TestRegisterTools_SchemasAreWellFormed(t *testing.T) {
+	s := New()
+	RegisterTools(s, "http://unused", nil)
+	for _, tool := range s.toolList() {
+		for _, schema := range []map[string]any{tool.InputSchema, tool.OutputSchema} {
+			if schema == nil {
+				continue
+			}
+			props, _ := schema["properties"].(map[string]any)
+			for name, p := range props {
+				if prop, _ := p.(map[string]any); prop["type"] == nil {
+					t.Errorf("%s: property %q has no type", tool.Name, name)
+				}
+			}
+			for _, name := range stringList(schema["required"]) {
+				if _, ok := props[name]; !ok {
+					t.Errorf("%s: required %q is not a property", tool.Name, name)
+				}
+			}
+		}
+	}
+	phase := s.tools["transition_phase"].InputSchema["properties"].(map[string]any)["phase"].(map[string]any)
+	if enum := stringList(phase["enum"]); len(enum) == 0 {
+		t.Error("transition_phase.phase has no enum")
+	}
+}
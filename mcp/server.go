@@ -1,4 +1,5 @@
-// Package mcp implements the Model Context Protocol over stdio.
+// Package mcp implements the Model Context Protocol over stdio and the
+// Streamable HTTP transport.
 // Spec: https://modelcontextprotocol.io/specification
 package mcp
 
@@ -8,21 +9,55 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
 )
 
-// Server handles MCP JSON-RPC communication over stdio.
+// latestProtocolVersion is returned when the client asks for a version this
+// server does not know about.
+const latestProtocolVersion = "2025-03-26"
+
+// supportedProtocolVersions lists the protocol revisions the server can speak.
+var supportedProtocolVersions = map[string]bool{
+	"2024-11-05": true,
+	"2025-03-26": true,
+	"2025-06-18": true,
+}
+
+// Server holds the tool registry and dispatches JSON-RPC requests. The same
+// Server can be driven by the stdio loop (Serve) and by the Streamable HTTP
+// transport (HTTPHandler) at the same time.
 type Server struct {
-	tools    map[string]Tool
-	reader   *bufio.Reader
-	writer   io.Writer
+	mu        sync.RWMutex
+	tools     map[string]Tool
+	order     []string
+	resources []ResourceTemplate
+	prompts   *PromptSource
+	recorder  func(CallRecord)
+	reader    *bufio.Reader
+
+	writeMu sync.Mutex
+	writer  io.Writer
+
+	// Connected clients and their resource subscriptions, plus the change
+	// set waiting to be flushed as notifications (see notify.go).
+	peersMu sync.Mutex
+	peers   map[peer]map[string]bool
+	pending pendingChanges
+	flush   *time.Timer
 }
 
-// Tool represents a callable MCP tool.
+// Tool represents a callable MCP tool. Arguments are validated against
+// InputSchema before Handler runs. Results are returned both as JSON text and
+// as structuredContent; OutputSchema, when set, describes the latter.
 type Tool struct {
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	InputSchema map[string]any `json:"inputSchema"`
-	Handler     func(args map[string]any) (any, error)
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	InputSchema  map[string]any `json:"inputSchema"`
+	OutputSchema map[string]any `json:"outputSchema,omitempty"`
+	Handler      func(args map[string]any) (any, error)
 }
 
 // New creates a new MCP server reading from stdin and writing to stdout.
@@ -31,16 +66,50 @@ func New() *Server {
 		tools:  make(map[string]Tool),
 		reader: bufio.NewReader(os.Stdin),
 		writer: os.Stdout,
+		peers:  make(map[peer]map[string]bool),
 	}
 }
 
-// Register adds a tool to the server.
+// Register adds a tool to the server, or replaces the one with the same name.
+// Connected clients are told to refetch the tool list.
 func (s *Server) Register(t Tool) {
+	s.mu.Lock()
+	if _, exists := s.tools[t.Name]; !exists {
+		s.order = append(s.order, t.Name)
+	}
 	s.tools[t.Name] = t
+	s.mu.Unlock()
+	s.toolsChanged()
+}
+
+// tool looks up a registered tool by name.
+func (s *Server) tool(name string) (Tool, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.tools[name]
+	return t, ok
+}
+
+// toolList returns the registered tools in registration order.
+func (s *Server) toolList() []Tool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]Tool, 0, len(s.order))
+	for _, name := range s.order {
+		list = append(list, s.tools[name])
+	}
+	return list
 }
 
-// Serve runs the MCP request/response loop until EOF.
+// Serve runs the MCP request/response loop over stdio until EOF.
 func (s *Server) Serve() error {
+	session := os.Getenv("CLAUDE_SESSION_ID")
+	if session == "" {
+		session = uuid.NewString()
+	}
+	stdio := stdioPeer{s: s, session: session}
+	s.attach(stdio)
+	defer s.detach(stdio)
 	for {
 		line, err := s.reader.ReadString('\n')
 		if err == io.EOF {
@@ -52,11 +121,13 @@ func (s *Server) Serve() error {
 
 		var req jsonRPCRequest
 		if err := json.Unmarshal([]byte(line), &req); err != nil {
-			s.sendError(nil, -32700, "parse error")
+			s.write(errorResponse(nil, -32700, "parse error"))
 			continue
 		}
 
-		s.handle(req)
+		if resp := s.handle(stdio, req); resp != nil {
+			s.write(resp)
+		}
 	}
 }
 
@@ -67,88 +138,167 @@ type jsonRPCRequest struct {
 	Params  json.RawMessage `json:"params"`
 }
 
+// isNotification reports whether the message expects no response.
+func (r jsonRPCRequest) isNotification() bool {
+	return r.ID == nil
+}
+
 type jsonRPCResponse struct {
-	JSONRPC string `json:"jsonrpc"`
-	ID      any    `json:"id,omitempty"`
-	Result  any    `json:"result,omitempty"`
+	JSONRPC string        `json:"jsonrpc"`
+	ID      any           `json:"id,omitempty"`
+	Result  any           `json:"result,omitempty"`
 	Error   *jsonRPCError `json:"error,omitempty"`
 }
 
 type jsonRPCError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
 }
 
-func (s *Server) handle(req jsonRPCRequest) {
+// handle dispatches a single JSON-RPC message received from p and returns the
+// response to send, or nil when the message is a notification. p is nil when
+// the caller cannot receive server-initiated messages.
+func (s *Server) handle(p peer, req jsonRPCRequest) *jsonRPCResponse {
 	switch req.Method {
 	case "initialize":
-		s.send(req.ID, map[string]any{
-			"protocolVersion": "2024-11-05",
-			"capabilities":    map[string]any{"tools": map[string]any{}},
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		version := params.ProtocolVersion
+		if !supportedProtocolVersions[version] {
+			version = latestProtocolVersion
+		}
+		return result(req.ID, map[string]any{
+			"protocolVersion": version,
+			"capabilities":    s.capabilities(),
 			"serverInfo":      map[string]any{"name": "stratus", "version": "2.0.0"},
 		})
 
+	case "ping":
+		return result(req.ID, map[string]any{})
+
 	case "tools/list":
-		list := make([]map[string]any, 0, len(s.tools))
-		for _, t := range s.tools {
-			list = append(list, map[string]any{
+		tools := s.toolList()
+		list := make([]map[string]any, 0, len(tools))
+		for _, t := range tools {
+			entry := map[string]any{
 				"name":        t.Name,
 				"description": t.Description,
 				"inputSchema": t.InputSchema,
-			})
+			}
+			if t.OutputSchema != nil {
+				entry["outputSchema"] = t.OutputSchema
+			}
+			list = append(list, entry)
 		}
-		s.send(req.ID, map[string]any{"tools": list})
+		return result(req.ID, map[string]any{"tools": list})
+
+	case "resources/list":
+		return result(req.ID, map[string]any{"resources": s.listResources()})
+
+	case "resources/templates/list":
+		return result(req.ID, map[string]any{"resourceTemplates": s.resourceTemplates()})
+
+	case "resources/read":
+		return s.handleResourcesRead(req)
+
+	case "resources/subscribe":
+		return s.handleSubscribe(p, req, true)
+
+	case "resources/unsubscribe":
+		return s.handleSubscribe(p, req, false)
+
+	case "prompts/list":
+		return s.handlePromptsList(req)
+
+	case "prompts/get":
+		return s.handlePromptsGet(req)
 
 	case "tools/call":
-		var params struct {
-			Name      string         `json:"name"`
-			Arguments map[string]any `json:"arguments"`
-		}
-		if err := json.Unmarshal(req.Params, &params); err != nil {
-			s.sendError(req.ID, -32602, "invalid params")
-			return
-		}
-		tool, ok := s.tools[params.Name]
-		if !ok {
-			s.sendError(req.ID, -32601, fmt.Sprintf("tool %q not found", params.Name))
-			return
-		}
-		result, err := tool.Handler(params.Arguments)
-		if err != nil {
-			s.send(req.ID, map[string]any{
-				"content": []map[string]any{{"type": "text", "text": "error: " + err.Error()}},
-				"isError": true,
-			})
-			return
+		return s.handleToolsCall(p, req)
+	}
+
+	if req.isNotification() {
+		// Notifications (notifications/initialized, notifications/cancelled, …)
+		// never get a response.
+		return nil
+	}
+	return errorResponse(req.ID, -32601, fmt.Sprintf("method %q not found", req.Method))
+}
+
+func (s *Server) handleToolsCall(p peer, req jsonRPCRequest) *jsonRPCResponse {
+	var params struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return errorResponse(req.ID, -32602, "invalid params")
+	}
+	tool, ok := s.tool(params.Name)
+	if !ok {
+		return errorResponse(req.ID, -32601, fmt.Sprintf("tool %q not found", params.Name))
+	}
+	started := time.Now()
+	args := params.Arguments
+	if tool.InputSchema != nil {
+		var err error
+		if args, err = validateArgs(tool.InputSchema, params.Arguments); err != nil {
+			s.record(p, tool.Name, params.Arguments, started, err)
+			resp := errorResponse(req.ID, -32602, err.Error())
+			if ae, ok := err.(*argError); ok {
+				resp.Error.Data = map[string]any{"tool": tool.Name, "argument": ae.Field, "reason": ae.Reason}
+			}
+			return resp
 		}
-		text, _ := json.Marshal(result)
-		s.send(req.ID, map[string]any{
-			"content": []map[string]any{{"type": "text", "text": string(text)}},
+	}
+	res, err := tool.Handler(args)
+	s.record(p, tool.Name, args, started, err)
+	if err != nil {
+		return result(req.ID, map[string]any{
+			"content": []map[string]any{{"type": "text", "text": "error: " + err.Error()}},
+			"isError": true,
 		})
+	}
+	text, _ := json.Marshal(res)
+	out := map[string]any{
+		"content": []map[string]any{{"type": "text", "text": string(text)}},
+	}
+	if structured, ok := structuredContent(res); ok {
+		out["structuredContent"] = structured
+	}
+	return result(req.ID, out)
+}
 
-	case "notifications/initialized":
-		// No response needed for notifications
-
-	default:
-		s.sendError(req.ID, -32601, fmt.Sprintf("method %q not found", req.Method))
+// capabilities advertises only the features that have something registered.
+func (s *Server) capabilities() map[string]any {
+	caps := map[string]any{"tools": map[string]any{"listChanged": true}}
+	if len(s.resourceTemplates()) > 0 {
+		caps["resources"] = map[string]any{"subscribe": true, "listChanged": true}
+	}
+	if s.promptSource() != nil {
+		caps["prompts"] = map[string]any{}
 	}
+	return caps
 }
 
-func (s *Server) send(id any, result any) {
-	resp := jsonRPCResponse{JSONRPC: "2.0", ID: id, Result: result}
-	s.write(resp)
+func result(id any, res any) *jsonRPCResponse {
+	return &jsonRPCResponse{JSONRPC: "2.0", ID: id, Result: res}
 }
 
-func (s *Server) sendError(id any, code int, msg string) {
-	resp := jsonRPCResponse{
+func errorResponse(id any, code int, msg string) *jsonRPCResponse {
+	return &jsonRPCResponse{
 		JSONRPC: "2.0",
 		ID:      id,
 		Error:   &jsonRPCError{Code: code, Message: msg},
 	}
-	s.write(resp)
 }
 
-func (s *Server) write(resp jsonRPCResponse) {
-	data, _ := json.Marshal(resp)
+// write emits one newline-delimited JSON-RPC message on the stdio transport.
+func (s *Server) write(msg any) {
+	data, _ := json.Marshal(msg)
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 	_, _ = fmt.Fprintf(s.writer, "%s\n", data)
 }
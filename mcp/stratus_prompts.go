@@ -0,0 +1,123 @@
+package mcp
+
+import (
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strings"
+)
+
+// argumentsPlaceholder is how skill bodies reference their invocation input.
+const argumentsPlaceholder = "$ARGUMENTS"
+
+// argumentHintToken matches "<required thing>" and "[optional-thing]" in a
+// skill's argument-hint frontmatter.
+var argumentHintToken = regexp.MustCompile(`<([^>]+)>|\[([^\]]+)\]`)
+
+// RegisterPrompts exposes the project's installed skills (.claude/skills,
+// written from the embedded set by `stratus init`) as MCP prompts. The
+// skill's argument-hint becomes the prompt's argument list and the rendered
+// prompt is the skill body with $ARGUMENTS substituted.
+func RegisterPrompts(s *Server, apiBase string, httpClient *http.Client) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	client := &apiClient{base: apiBase, http: httpClient}
+
+	s.SetPromptSource(PromptSource{
+		List: func() ([]Prompt, error) {
+			res, err := client.get("/api/skills", nil)
+			if err != nil {
+				return nil, err
+			}
+			var prompts []Prompt
+			for _, skill := range listField(res, "skills") {
+				prompts = append(prompts, skillPrompt(skill))
+			}
+			return prompts, nil
+		},
+		Get: func(name string, args map[string]string) (*PromptResult, error) {
+			res, err := client.get("/api/skills/"+neturl.PathEscape(name), nil)
+			if isNotFound(err) {
+				return nil, errPromptNotFound
+			}
+			if err != nil {
+				return nil, err
+			}
+			skill, _ := res.(map[string]any)
+			return renderSkillPrompt(skill, args)
+		},
+	})
+}
+
+// skillPrompt converts a decoded agents.SkillDef into a prompt descriptor.
+func skillPrompt(skill map[string]any) Prompt {
+	name, _ := skill["name"].(string)
+	desc, _ := skill["description"].(string)
+	hint, _ := skill["argument_hint"].(string)
+	body, _ := skill["body"].(string)
+	return Prompt{
+		Name:        name,
+		Description: desc,
+		Arguments:   promptArguments(hint, body),
+	}
+}
+
+// promptArguments derives prompt arguments from an argument hint such as
+// "<feature description>" (required) or "[workflow-id]" (optional). Skills
+// without a hint that still reference $ARGUMENTS get one optional
+// "arguments" argument.
+func promptArguments(hint, body string) []PromptArgument {
+	var args []PromptArgument
+	for _, m := range argumentHintToken.FindAllStringSubmatch(hint, -1) {
+		label, required := m[1], true
+		if label == "" {
+			label, required = m[2], false
+		}
+		name := argumentName(label)
+		if name == "" {
+			continue
+		}
+		args = append(args, PromptArgument{Name: name, Description: strings.TrimSpace(label), Required: required})
+	}
+	if len(args) == 0 && strings.Contains(body, argumentsPlaceholder) {
+		args = append(args, PromptArgument{Name: "arguments", Description: "Input passed to the skill as " + argumentsPlaceholder})
+	}
+	return args
+}
+
+// argumentName turns a hint label into an argument identifier:
+// "feature description" → "feature_description".
+func argumentName(label string) string {
+	fields := strings.Fields(strings.ToLower(label))
+	return strings.Join(fields, "_")
+}
+
+// renderSkillPrompt substitutes the supplied arguments into the skill body.
+// Argument values are joined in declaration order, mirroring how Claude Code
+// expands $ARGUMENTS for slash commands.
+func renderSkillPrompt(skill map[string]any, args map[string]string) (*PromptResult, error) {
+	p := skillPrompt(skill)
+	body, _ := skill["body"].(string)
+
+	var values []string
+	for _, a := range p.Arguments {
+		v := strings.TrimSpace(args[a.Name])
+		if v == "" && a.Required {
+			return nil, fmt.Errorf("%w: %q is required", errPromptArguments, a.Name)
+		}
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	text := strings.ReplaceAll(body, argumentsPlaceholder, strings.Join(values, " "))
+
+	return &PromptResult{
+		Description: p.Description,
+		Messages: []PromptMessage{{
+			Role:    "user",
+			Content: map[string]any{"type": "text", "text": strings.TrimSpace(text)},
+		}},
+	}, nil
+}
@@ -0,0 +1,480 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+const (
+	mimeMarkdown = "text/markdown"
+	mimeJSON     = "application/json"
+
+	// recentMemoryResources caps how many memory events resources/list
+	// enumerates; older events stay readable through the URI template.
+	recentMemoryResources = 20
+	// wikiResourceLimit caps how many published wiki pages are listed.
+	wikiResourceLimit = 100
+)
+
+// RegisterResources exposes Stratus context as MCP resources with stable URIs:
+//
+//	stratus://workflow/{id}               workflow state (JSON)
+//	stratus://workflow/{id}/plan          plan markdown
+//	stratus://workflow/{id}/design        design document markdown
+//	stratus://workflow/{id}/summary       change summary markdown
+//	stratus://wiki/{id}                   wiki page markdown
+//	stratus://governance/{+path}          governance doc (project-relative path)
+//	stratus://memory/{id}                 memory event (JSON)
+//	stratus://swarm/mission/{id}          mission with workers, tickets, forge (JSON)
+//	stratus://swarm/mission/{id}/tickets  mission tickets (JSON)
+//	stratus://swarm/mission/{id}/signals  mission signal log (JSON)
+//	stratus://swarm/worker/{id}           worker state, assigned tickets, signals (JSON)
+//
+// Like the tools, every read goes through the HTTP API. All of them can be
+// subscribed to; see ChangeFor for what triggers an update.
+func RegisterResources(s *Server, apiBase string, httpClient *http.Client) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	client := &apiClient{base: apiBase, http: httpClient}
+
+	getWorkflow := func(id string) (map[string]any, error) {
+		res, err := client.get("/api/workflows/"+neturl.PathEscape(id), nil)
+		if err != nil {
+			if isNotFound(err) {
+				return nil, errResourceNotFound
+			}
+			return nil, err
+		}
+		m, _ := res.(map[string]any)
+		return m, nil
+	}
+
+	s.RegisterResourceTemplate(ResourceTemplate{
+		URITemplate: "stratus://workflow/{id}/plan",
+		Name:        "Workflow plan",
+		Description: "Implementation plan markdown of a workflow",
+		MimeType:    mimeMarkdown,
+		List: func() ([]Resource, error) {
+			return listWorkflowResources(client, "plan_content", "plan", "Plan")
+		},
+		Read: func(uri string, vars map[string]string) (*ResourceContents, error) {
+			return readWorkflowField(getWorkflow, uri, vars["id"], "plan_content")
+		},
+	})
+
+	s.RegisterResourceTemplate(ResourceTemplate{
+		URITemplate: "stratus://workflow/{id}/design",
+		Name:        "Workflow design",
+		Description: "Design document markdown of a complex spec workflow",
+		MimeType:    mimeMarkdown,
+		List: func() ([]Resource, error) {
+			return listWorkflowResources(client, "design_content", "design", "Design")
+		},
+		Read: func(uri string, vars map[string]string) (*ResourceContents, error) {
+			return readWorkflowField(getWorkflow, uri, vars["id"], "design_content")
+		},
+	})
+
+	s.RegisterResourceTemplate(ResourceTemplate{
+		URITemplate: "stratus://workflow/{id}/summary",
+		Name:        "Workflow change summary",
+		Description: "Change summary of a completed workflow (capabilities, risks, governance compliance)",
+		MimeType:    mimeMarkdown,
+		List: func() ([]Resource, error) {
+			return listWorkflowResources(client, "change_summary", "summary", "Change summary")
+		},
+		Read: func(uri string, vars map[string]string) (*ResourceContents, error) {
+			text, err := client.getText(fmt.Sprintf("/api/workflows/%s/summary.md", neturl.PathEscape(vars["id"])))
+			if isNotFound(err) {
+				return nil, errResourceNotFound
+			}
+			if err != nil {
+				return nil, err
+			}
+			return &ResourceContents{URI: uri, MimeType: mimeMarkdown, Text: text}, nil
+		},
+	})
+
+	s.RegisterResourceTemplate(ResourceTemplate{
+		URITemplate: "stratus://workflow/{id}",
+		Name:        "Workflow state",
+		Description: "Full workflow state: phase, tasks, delegated agents",
+		MimeType:    mimeJSON,
+		List: func() ([]Resource, error) {
+			workflows, err := listWorkflows(client)
+			if err != nil {
+				return nil, err
+			}
+			list := make([]Resource, 0, len(workflows))
+			for _, w := range workflows {
+				id, _ := w["id"].(string)
+				list = append(list, Resource{
+					URI:         "stratus://workflow/" + id,
+					Name:        workflowLabel(w),
+					Description: fmt.Sprintf("%v workflow in phase %v", w["type"], w["phase"]),
+					MimeType:    mimeJSON,
+				})
+			}
+			return list, nil
+		},
+		Read: func(uri string, vars map[string]string) (*ResourceContents, error) {
+			w, err := getWorkflow(vars["id"])
+			if err != nil {
+				return nil, err
+			}
+			return jsonContents(uri, w)
+		},
+	})
+
+	s.RegisterResourceTemplate(ResourceTemplate{
+		URITemplate: "stratus://wiki/{id}",
+		Name:        "Wiki page",
+		Description: "Knowledge wiki page markdown",
+		MimeType:    mimeMarkdown,
+		List: func() ([]Resource, error) {
+			params := neturl.Values{"status": {"published"}, "limit": {fmt.Sprintf("%d", wikiResourceLimit)}}
+			res, err := client.get("/api/wiki/pages", params)
+			if err != nil {
+				return nil, err
+			}
+			var list []Resource
+			for _, p := range listField(res, "pages") {
+				id, _ := p["id"].(string)
+				title, _ := p["title"].(string)
+				list = append(list, Resource{
+					URI:         "stratus://wiki/" + id,
+					Name:        title,
+					Description: fmt.Sprintf("wiki %v page", p["page_type"]),
+					MimeType:    mimeMarkdown,
+				})
+			}
+			return list, nil
+		},
+		Read: func(uri string, vars map[string]string) (*ResourceContents, error) {
+			res, err := client.get("/api/wiki/pages/"+neturl.PathEscape(vars["id"]), nil)
+			if isNotFound(err) {
+				return nil, errResourceNotFound
+			}
+			if err != nil {
+				return nil, err
+			}
+			page, _ := mapField(res, "page")
+			title, _ := page["title"].(string)
+			content, _ := page["content"].(string)
+			if title != "" && !strings.HasPrefix(strings.TrimSpace(content), "# ") {
+				content = "# " + title + "\n\n" + content
+			}
+			return &ResourceContents{URI: uri, MimeType: mimeMarkdown, Text: content}, nil
+		},
+	})
+
+	s.RegisterResourceTemplate(ResourceTemplate{
+		URITemplate: "stratus://governance/{+path}",
+		Name:        "Governance document",
+		Description: "Indexed governance doc (rules, ADRs, architecture, CLAUDE.md) by project-relative path",
+		MimeType:    mimeMarkdown,
+		List: func() ([]Resource, error) {
+			res, err := client.get("/api/governance/docs", nil)
+			if err != nil {
+				return nil, err
+			}
+			var list []Resource
+			for _, d := range listField(res, "docs") {
+				path, _ := d["file_path"].(string)
+				title, _ := d["title"].(string)
+				if title == "" {
+					title = path
+				}
+				list = append(list, Resource{
+					URI:         "stratus://governance/" + path,
+					Name:        title,
+					Description: fmt.Sprintf("%v: %s", d["doc_type"], path),
+					MimeType:    mimeMarkdown,
+				})
+			}
+			return list, nil
+		},
+		Read: func(uri string, vars map[string]string) (*ResourceContents, error) {
+			res, err := client.get("/api/governance/doc", neturl.Values{"path": {vars["path"]}})
+			if isNotFound(err) {
+				return nil, errResourceNotFound
+			}
+			if err != nil {
+				return nil, err
+			}
+			m, _ := res.(map[string]any)
+			content, _ := m["content"].(string)
+			return &ResourceContents{URI: uri, MimeType: mimeMarkdown, Text: content}, nil
+		},
+	})
+
+	s.RegisterResourceTemplate(ResourceTemplate{
+		URITemplate: "stratus://memory/{id}",
+		Name:        "Memory event",
+		Description: "A saved memory event (decision, discovery, bugfix, …) by ID",
+		MimeType:    mimeJSON,
+		List: func() ([]Resource, error) {
+			res, err := client.get("/api/events/search", neturl.Values{"limit": {fmt.Sprintf("%d", recentMemoryResources)}})
+			if err != nil {
+				return nil, err
+			}
+			var list []Resource
+			for _, e := range listField(res, "results") {
+				id, _ := e["id"].(float64)
+				title, _ := e["title"].(string)
+				if title == "" {
+					text, _ := e["text"].(string)
+					title = truncateLabel(text, 60)
+				}
+				list = append(list, Resource{
+					URI:         fmt.Sprintf("stratus://memory/%d", int64(id)),
+					Name:        title,
+					Description: fmt.Sprintf("%v memory (%v)", e["type"], e["scope"]),
+					MimeType:    mimeJSON,
+				})
+			}
+			return list, nil
+		},
+		Read: func(uri string, vars map[string]string) (*ResourceContents, error) {
+			var id int64
+			if _, err := fmt.Sscanf(vars["id"], "%d", &id); err != nil {
+				return nil, errResourceNotFound
+			}
+			res, err := client.post("/api/events/batch", map[string]any{"ids": []int64{id}})
+			if err != nil {
+				return nil, err
+			}
+			events := listField(res, "results")
+			if len(events) == 0 {
+				return nil, errResourceNotFound
+			}
+			return jsonContents(uri, events[0])
+		},
+	})
+
+	registerSwarmResources(s, client)
+}
+
+// registerSwarmResources exposes missions and workers so a worker can
+// subscribe to its own resource instead of polling for signals and tickets.
+// Reads use the mission signal log, which, unlike the worker poll endpoint,
+// does not mark signals as read.
+func registerSwarmResources(s *Server, client *apiClient) {
+	getJSON := func(uri, path string) (*ResourceContents, error) {
+		res, err := client.get(path, nil)
+		if isNotFound(err) {
+			return nil, errResourceNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		return jsonContents(uri, res)
+	}
+
+	s.RegisterResourceTemplate(ResourceTemplate{
+		URITemplate: "stratus://swarm/mission/{id}/tickets",
+		Name:        "Mission tickets",
+		Description: "Tickets of a swarm mission with status and assigned worker",
+		MimeType:    mimeJSON,
+		Read: func(uri string, vars map[string]string) (*ResourceContents, error) {
+			return getJSON(uri, "/api/swarm/missions/"+neturl.PathEscape(vars["id"])+"/tickets")
+		},
+	})
+
+	s.RegisterResourceTemplate(ResourceTemplate{
+		URITemplate: "stratus://swarm/mission/{id}/signals",
+		Name:        "Mission signals",
+		Description: "Signals exchanged within a swarm mission, newest first",
+		MimeType:    mimeJSON,
+		Read: func(uri string, vars map[string]string) (*ResourceContents, error) {
+			return getJSON(uri, "/api/swarm/missions/"+neturl.PathEscape(vars["id"])+"/signals")
+		},
+	})
+
+	s.RegisterResourceTemplate(ResourceTemplate{
+		URITemplate: "stratus://swarm/mission/{id}",
+		Name:        "Swarm mission",
+		Description: "Mission state with its workers, tickets and merge queue",
+		MimeType:    mimeJSON,
+		List: func() ([]Resource, error) {
+			res, err := client.get("/api/swarm/missions", nil)
+			if err != nil {
+				return nil, err
+			}
+			arr, _ := res.([]any)
+			list := make([]Resource, 0, len(arr))
+			for _, item := range arr {
+				m, _ := item.(map[string]any)
+				id, _ := m["id"].(string)
+				title, _ := m["title"].(string)
+				list = append(list, Resource{
+					URI:         "stratus://swarm/mission/" + id,
+					Name:        title,
+					Description: fmt.Sprintf("%v mission for workflow %v", m["status"], m["workflow_id"]),
+					MimeType:    mimeJSON,
+				})
+			}
+			return list, nil
+		},
+		Read: func(uri string, vars map[string]string) (*ResourceContents, error) {
+			return getJSON(uri, "/api/swarm/missions/"+neturl.PathEscape(vars["id"]))
+		},
+	})
+
+	s.RegisterResourceTemplate(ResourceTemplate{
+		URITemplate: "stratus://swarm/worker/{id}",
+		Name:        "Swarm worker",
+		Description: "Worker state, its assigned tickets and the signals addressed to it",
+		MimeType:    mimeJSON,
+		Read: func(uri string, vars map[string]string) (*ResourceContents, error) {
+			res, err := client.get("/api/swarm/workers/"+neturl.PathEscape(vars["id"]), nil)
+			if isNotFound(err) {
+				return nil, errResourceNotFound
+			}
+			if err != nil {
+				return nil, err
+			}
+			worker, _ := res.(map[string]any)
+			workerID, _ := worker["id"].(string)
+			missionPath := "/api/swarm/missions/" + neturl.PathEscape(fmt.Sprint(worker["mission_id"]))
+
+			tickets, err := client.get(missionPath+"/tickets", nil)
+			if err != nil {
+				return nil, err
+			}
+			var assigned []any
+			for _, t := range anyList(tickets) {
+				if t["worker_id"] == workerID {
+					assigned = append(assigned, t)
+				}
+			}
+
+			signals, err := client.get(missionPath+"/signals", nil)
+			if err != nil {
+				return nil, err
+			}
+			var inbox []any
+			for _, sig := range anyList(signals) {
+				if to := sig["to_worker"]; to == workerID || to == "*" {
+					inbox = append(inbox, sig)
+				}
+			}
+
+			return jsonContents(uri, map[string]any{
+				"worker":  worker,
+				"tickets": nilAny(assigned),
+				"signals": nilAny(inbox),
+			})
+		},
+	})
+}
+
+// listWorkflows returns all workflows known to the API as decoded objects.
+func listWorkflows(client *apiClient) ([]map[string]any, error) {
+	res, err := client.get("/api/workflows", nil)
+	if err != nil {
+		return nil, err
+	}
+	arr, _ := res.([]any)
+	out := make([]map[string]any, 0, len(arr))
+	for _, item := range arr {
+		if m, ok := item.(map[string]any); ok {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// listWorkflowResources lists one resource per workflow whose field is set.
+func listWorkflowResources(client *apiClient, field, suffix, label string) ([]Resource, error) {
+	workflows, err := listWorkflows(client)
+	if err != nil {
+		return nil, err
+	}
+	var list []Resource
+	for _, w := range workflows {
+		if v, ok := w[field]; !ok || v == nil || v == "" {
+			continue
+		}
+		id, _ := w["id"].(string)
+		list = append(list, Resource{
+			URI:      fmt.Sprintf("stratus://workflow/%s/%s", id, suffix),
+			Name:     label + ": " + workflowLabel(w),
+			MimeType: mimeMarkdown,
+		})
+	}
+	return list, nil
+}
+
+func readWorkflowField(getWorkflow func(string) (map[string]any, error), uri, id, field string) (*ResourceContents, error) {
+	w, err := getWorkflow(id)
+	if err != nil {
+		return nil, err
+	}
+	text, _ := w[field].(string)
+	if text == "" {
+		return nil, errResourceNotFound
+	}
+	return &ResourceContents{URI: uri, MimeType: mimeMarkdown, Text: text}, nil
+}
+
+func workflowLabel(w map[string]any) string {
+	if title, _ := w["title"].(string); title != "" {
+		return title
+	}
+	id, _ := w["id"].(string)
+	return id
+}
+
+func jsonContents(uri string, v any) (*ResourceContents, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &ResourceContents{URI: uri, MimeType: mimeJSON, Text: string(data)}, nil
+}
+
+// listField extracts an array of objects from a decoded JSON object.
+func listField(res any, key string) []map[string]any {
+	m, _ := res.(map[string]any)
+	arr, _ := m[key].([]any)
+	out := make([]map[string]any, 0, len(arr))
+	for _, item := range arr {
+		if obj, ok := item.(map[string]any); ok {
+			out = append(out, obj)
+		}
+	}
+	return out
+}
+
+// anyList extracts the objects of a decoded top-level JSON array.
+func anyList(res any) []map[string]any {
+	return listField(map[string]any{"items": res}, "items")
+}
+
+// nilAny keeps empty lists encoding as [] rather than null.
+func nilAny(list []any) []any {
+	if list == nil {
+		return []any{}
+	}
+	return list
+}
+
+// mapField extracts a nested object from a decoded JSON object.
+func mapField(res any, key string) (map[string]any, bool) {
+	m, _ := res.(map[string]any)
+	obj, ok := m[key].(map[string]any)
+	return obj, ok
+}
+
+func truncateLabel(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len([]rune(s)) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
+}
@@ -3,13 +3,227 @@ package mcp
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	neturl "net/url"
-	"strconv"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/orchestration"
+	"github.com/MartinNevlaha/stratus-v2/swarm"
+)
+
+// Enumerations shared by several tool schemas.
+var (
+	memoryScopes           = []string{"repo", "global", "user"}
+	memoryActors           = []string{"user", "agent", "hook", "system"}
+	workflowComplexities   = []string{string(orchestration.ComplexitySimple), string(orchestration.ComplexityComplex)}
+	codeAnalysisCategories = []string{"anti_pattern", "duplication", "coverage_gap", "error_handling", "complexity", "dead_code", "security"}
 )
 
+// workflowTypes lists the registered workflow definitions: the built-in
+// spec, bug and e2e plus any loaded from the project.
+func workflowTypes() []string {
+	types := orchestration.WorkflowTypes()
+	out := make([]string, len(types))
+	for i, t := range types {
+		out[i] = string(t)
+	}
+	return out
+}
+
+// workflowPhases lists every phase a workflow can be in.
+func workflowPhases() []string {
+	phases := orchestration.KnownPhases()
+	out := make([]string, len(phases))
+	for i, p := range phases {
+		out[i] = string(p)
+	}
+	return out
+}
+
+// SetWorkflowEnums replaces the workflow types register_workflow accepts and
+// the phases transition_phase accepts, which change when the project's
+// workflow definitions are reloaded. Connected clients are told to refetch
+// the tool list.
+func (s *Server) SetWorkflowEnums(types, phases []string) {
+	s.setEnum("register_workflow", "type", types)
+	s.setEnum("transition_phase", "phase", phases)
+}
+
+func (s *Server) setEnum(toolName, property string, values []string) {
+	t, ok := s.tool(toolName)
+	if !ok || len(values) == 0 {
+		return
+	}
+	t.InputSchema = withEnum(t.InputSchema, property, values)
+	s.Register(t)
+}
+
+// workflowStateSchema describes orchestration.WorkflowState as returned by
+// the workflow endpoints.
+var workflowStateSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"id":         map[string]any{"type": "string"},
+		"type":       map[string]any{"type": "string"},
+		"phase":      map[string]any{"type": "string"},
+		"complexity": map[string]any{"type": "string"},
+		"title":      map[string]any{"type": "string"},
+		"aborted":    map[string]any{"type": "boolean"},
+		"tasks": map[string]any{"type": "array", "items": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"index":  map[string]any{"type": "integer"},
+				"title":  map[string]any{"type": "string"},
+				"status": map[string]any{"type": "string", "enum": []string{"pending", "in_progress", "done"}},
+			},
+		}},
+		"total_tasks":      map[string]any{"type": "integer"},
+		"current_task":     map[string]any{"type": "integer"},
+		"delegated_agents": map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "array", "items": map[string]any{"type": "string"}}},
+		"plan_content":     map[string]any{"type": "string"},
+		"design_content":   map[string]any{"type": "string"},
+		"approval":         approvalSchema,
+		"parent_id":        map[string]any{"type": "string"},
+		"children":         map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		"template":         map[string]any{"type": "string"},
+		"phase_entered_at": map[string]any{"type": "string"},
+		"escalations":      map[string]any{"type": "array", "items": escalationSchema},
+		"budget_usd":       map[string]any{"type": "number"},
+		"created_at":       map[string]any{"type": "string"},
+		"updated_at":       map[string]any{"type": "string"},
+	},
+	"required": []string{"id", "type", "phase"},
+}
+
+// approvalSchema describes orchestration.Approval.
+var approvalSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"from":      map[string]any{"type": "string"},
+		"to":        map[string]any{"type": "string"},
+		"status":    map[string]any{"type": "string", "enum": []string{"pending", "approved", "rejected"}},
+		"reviewers": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		"message":   map[string]any{"type": "string"},
+		"decisions": map[string]any{"type": "array", "items": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"actor":    map[string]any{"type": "string"},
+				"decision": map[string]any{"type": "string", "enum": []string{"approve", "reject", "comment"}},
+				"comment":  map[string]any{"type": "string"},
+				"at":       map[string]any{"type": "string"},
+			},
+		}},
+	},
+}
+
+// escalationSchema describes orchestration.Escalation.
+var escalationSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"trigger": map[string]any{"type": "string", "enum": []string{"workflow_timeout", "phase_timeout", "loop_limit"}},
+		"phase":   map[string]any{"type": "string"},
+		"action":  map[string]any{"type": "string", "enum": []string{"delegate", "approval", "abort"}},
+		"agent":   map[string]any{"type": "string"},
+		"reason":  map[string]any{"type": "string"},
+		"message": map[string]any{"type": "string"},
+		"at":      map[string]any{"type": "string"},
+	},
+}
+
+// costTotalsSchema describes orchestration.CostTotals.
+var costTotalsSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"cost_usd":      map[string]any{"type": "number"},
+		"input_tokens":  map[string]any{"type": "integer"},
+		"output_tokens": map[string]any{"type": "integer"},
+	},
+}
+
+// costSummarySchema describes orchestration.CostSummary.
+var costSummarySchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"workflow_id": map[string]any{"type": "string"},
+		"total":       costTotalsSchema,
+		"by_phase":    map[string]any{"type": "object", "additionalProperties": costTotalsSchema},
+		"by_agent":    map[string]any{"type": "object", "additionalProperties": costTotalsSchema},
+		"estimated":   map[string]any{"type": "boolean"},
+		"budget_usd":  map[string]any{"type": "number"},
+		"over_budget": map[string]any{"type": "boolean"},
+	},
+	"required": []string{"workflow_id", "total", "over_budget"},
+}
+
+// simVerdictSchema describes orchestration.SimVerdict.
+var simVerdictSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"outcome": map[string]any{"type": "string", "enum": []string{"allowed", "blocked", "awaiting_approval", "escalated", "unverified"}},
+		"reason":  map[string]any{"type": "string"},
+	},
+}
+
+// simulationSchema describes orchestration.Simulation.
+var simulationSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"workflow_id": map[string]any{"type": "string"},
+		"type":        map[string]any{"type": "string"},
+		"steps": map[string]any{"type": "array", "items": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"seq":       map[string]any{"type": "integer"},
+				"at":        map[string]any{"type": "string"},
+				"kind":      map[string]any{"type": "string", "enum": []string{"transition", "delegation", "write"}},
+				"phase":     map[string]any{"type": "string"},
+				"subject":   map[string]any{"type": "string"},
+				"recorded":  map[string]any{"type": "string"},
+				"baseline":  simVerdictSchema,
+				"simulated": simVerdictSchema,
+				"differs":   map[string]any{"type": "boolean"},
+			},
+		}},
+		"differences":      map[string]any{"type": "integer"},
+		"first_difference": map[string]any{"type": "integer"},
+	},
+	"required": []string{"workflow_id", "steps", "differences"},
+}
+
+// transitionResultSchema is the workflow state returned by transition_phase
+// and get_approval, with a status telling a completed transition apart
+// from one held for human approval.
+var transitionResultSchema = func() map[string]any {
+	props := map[string]any{
+		"status":  map[string]any{"type": "string", "enum": []string{"transitioned", "awaiting_approval", "approved", "rejected"}},
+		"message": map[string]any{"type": "string"},
+	}
+	for k, v := range workflowStateSchema["properties"].(map[string]any) {
+		props[k] = v
+	}
+	return map[string]any{"type": "object", "properties": props, "required": workflowStateSchema["required"]}
+}()
+
+// memoryEventSchema describes a memory event returned by search endpoints.
+var memoryEventSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"id":         map[string]any{"type": "integer"},
+		"type":       map[string]any{"type": "string"},
+		"scope":      map[string]any{"type": "string"},
+		"title":      map[string]any{"type": "string"},
+		"text":       map[string]any{"type": "string"},
+		"tags":       map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		"importance": map[string]any{"type": "number"},
+		"score":      map[string]any{"type": "number"},
+		"created_at": map[string]any{"type": "string"},
+	},
+	"required": []string{"id"},
+}
+
 // RegisterTools registers Stratus MCP tools on the server.
 func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 	if httpClient == nil {
@@ -19,23 +233,29 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 
 	s.Register(Tool{
 		Name:        "search",
-		Description: "Search memory events using full-text search. Returns index with IDs (~50-100 tokens/result).",
+		Description: "Search memory events. Uses hybrid keyword + semantic ranking when embeddings are enabled, full-text search otherwise. Returns index with IDs (~50-100 tokens/result).",
 		InputSchema: obj(
 			req("query", "string", "Full-text search query"),
-			opt("limit", "integer", "Max results (default: 20)"),
+			opt("limit", "integer", "Max results (default: 20)", minimum(1)),
 			opt("type", "string", "Filter by type (discovery, decision, bugfix, feature, etc.)"),
-			opt("scope", "string", "Filter by scope (repo, global, user)"),
+			opt("scope", "string", "Filter by scope", enum(memoryScopes...)),
 			opt("project", "string", "Filter by project name"),
 			opt("date_start", "string", "ISO 8601 start date"),
 			opt("date_end", "string", "ISO 8601 end date"),
-			opt("offset", "integer", "Pagination offset"),
+			opt("offset", "integer", "Pagination offset", minimum(0)),
+			opt("mode", "string", "Ranking: hybrid (default when embeddings are enabled) or keyword", enum("hybrid", "keyword")),
+		),
+		OutputSchema: obj(
+			req("results", "array", "Matching memory events", itemsOf(memoryEventSchema)),
+			opt("count", "integer", "Number of results"),
+			opt("mode", "string", "Ranking used: hybrid or keyword"),
 		),
 		Handler: func(args map[string]any) (any, error) {
 			params := neturl.Values{}
 			if q, ok := args["query"].(string); ok {
 				params.Set("q", q)
 			}
-			for _, k := range []string{"type", "scope", "project", "date_start", "date_end"} {
+			for _, k := range []string{"type", "scope", "project", "date_start", "date_end", "mode"} {
 				if v, ok := args[k].(string); ok && v != "" {
 					params.Set(k, v)
 				}
@@ -54,10 +274,10 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		Name:        "timeline",
 		Description: "Get chronological context around a memory event ID.",
 		InputSchema: obj(
-			opt("anchor_id", "integer", "Memory event ID to center on"),
+			opt("anchor_id", "integer", "Memory event ID to center on", minimum(1)),
 			opt("query", "string", "Find best match by query, then show timeline"),
-			opt("depth_before", "integer", "Events before anchor (default: 10)"),
-			opt("depth_after", "integer", "Events after anchor (default: 10)"),
+			opt("depth_before", "integer", "Events before anchor (default: 10)", minimum(0)),
+			opt("depth_after", "integer", "Events after anchor (default: 10)", minimum(0)),
 		),
 		Handler: func(args map[string]any) (any, error) {
 			anchorID := intArg(args, "anchor_id", 0)
@@ -97,7 +317,10 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		Name:        "get_observations",
 		Description: "Fetch full details for memory event IDs. ALWAYS batch for 2+ items.",
 		InputSchema: obj(
-			req("ids", "array", "Array of memory event IDs to fetch"),
+			req("ids", "array", "Array of memory event IDs to fetch", items("integer", minimum(1))),
+		),
+		OutputSchema: obj(
+			req("results", "array", "Memory events", itemsOf(memoryEventSchema)),
 		),
 		Handler: func(args map[string]any) (any, error) {
 			return client.post("/api/events/batch", args)
@@ -111,27 +334,31 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 			req("text", "string", "Content to remember"),
 			opt("title", "string", "Short title"),
 			opt("type", "string", "Type: discovery|decision|bugfix|feature|refactor|etc."),
-			opt("tags", "array", "Tags for categorization"),
-			opt("actor", "string", "Who created this: user|agent|hook|system"),
-			opt("scope", "string", "Scope: repo|global|user"),
-			opt("importance", "number", "0.0-1.0 importance score"),
+			opt("tags", "array", "Tags for categorization", items("string")),
+			opt("actor", "string", "Who created this", enum(memoryActors...)),
+			opt("scope", "string", "Scope", enum(memoryScopes...)),
+			opt("importance", "number", "0.0-1.0 importance score", minimum(0), maximum(1)),
 			opt("refs", "object", "References to other resources"),
 			opt("ttl", "string", "ISO 8601 expiration date"),
 			opt("dedupe_key", "string", "Unique key to prevent duplicate saves"),
 			opt("project", "string", "Project name"),
 		),
+		OutputSchema: obj(
+			req("id", "integer", "ID of the saved (or deduplicated) memory event"),
+		),
 		Handler: func(args map[string]any) (any, error) {
-			return client.post("/api/events", convertMemoryArgs(args))
+			return client.post("/api/events", args)
 		},
 	})
 
 	s.Register(Tool{
 		Name:        "retrieve",
-		Description: "Semantic search across code (Vexor), governance docs, and wiki knowledge pages. Auto-routes by query type.",
+		Description: "Semantic search across code (Vexor), governance docs, and wiki knowledge pages. Auto-routes by query type. The symbols, callers and callees corpora query the symbol index instead.",
 		InputSchema: obj(
-			req("query", "string", "Search query for code, governance docs, or wiki knowledge"),
-			opt("corpus", "string", "Force search corpus: 'code', 'governance', or 'wiki'. Omit for auto-routing across all sources."),
-			opt("top_k", "integer", "Max results (default: 10)"),
+			req("query", "string", "Search query for code, governance docs, or wiki knowledge; a symbol name for the symbol corpora"),
+			opt("corpus", "string", "Force search corpus. Omit for auto-routing across code, governance and wiki.", enum("code", "governance", "wiki", "symbols", "callers", "callees")),
+			opt("top_k", "integer", "Max results (default: 10)", minimum(1)),
+			opt("max_tokens", "integer", "Token budget for a packed, citation-tagged context block returned alongside the results. Merges adjacent chunks, prefers accepted rules and important memories, and trims excerpts to fit.", minimum(1)),
 		),
 		Handler: func(args map[string]any) (any, error) {
 			params := neturl.Values{}
@@ -144,10 +371,41 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 			if topK := intArg(args, "top_k", 0); topK > 0 {
 				params.Set("top_k", fmt.Sprintf("%d", topK))
 			}
+			if maxTokens := intArg(args, "max_tokens", 0); maxTokens > 0 {
+				params.Set("max_tokens", fmt.Sprintf("%d", maxTokens))
+			}
 			return client.get("/api/retrieve", params)
 		},
 	})
 
+	symbolTool := func(name, corpus, description, queryDesc string) {
+		s.Register(Tool{
+			Name:        name,
+			Description: description,
+			InputSchema: obj(
+				req("symbol", "string", queryDesc),
+				opt("limit", "integer", "Max results (default: 20)", minimum(1)),
+			),
+			Handler: func(args map[string]any) (any, error) {
+				params := neturl.Values{"corpus": {corpus}}
+				if q, ok := args["symbol"].(string); ok {
+					params.Set("q", q)
+				}
+				params.Set("top_k", fmt.Sprintf("%d", intArg(args, "limit", 20)))
+				return client.get("/api/retrieve", params)
+			},
+		})
+	}
+	symbolTool("find_symbol", "symbols",
+		"Find where a function, method, type, variable or constant is defined. Matches exact names first, then prefixes and substrings.",
+		"Symbol name: bare (SaveEvent), with receiver or class (DB.SaveEvent) or fully qualified")
+	symbolTool("find_callers", "callers",
+		"Find who calls or references a symbol, with the calling function and call site. Go references are resolved by type; other languages match by name.",
+		"Symbol name: bare, Type.Method or fully qualified")
+	symbolTool("find_callees", "callees",
+		"List what a function or method calls and references, each resolved to its definition when it is in the project.",
+		"Function or method name: bare, Type.Method or fully qualified")
+
 	s.Register(Tool{
 		Name:        "index_status",
 		Description: "Check index freshness and backend availability.",
@@ -186,7 +444,7 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 				return map[string]any{"workflows": []any{}, "message": "no active workflows"}, nil
 			}
 
-			result, err := client.get(fmt.Sprintf("/api/workflows/%s/dispatch", wfID), nil)
+			result, err := client.get(fmt.Sprintf("/api/workflows/%s/dispatch", neturl.PathEscape(wfID)), nil)
 			if err != nil {
 				return nil, err
 			}
@@ -199,14 +457,18 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 
 	s.Register(Tool{
 		Name:        "register_workflow",
-		Description: "Register a new workflow. REQUIRED before any Task delegation to delivery agents. Use this to start a spec, bug, or e2e workflow.",
+		Description: "Register a new workflow. REQUIRED before any Task delegation to delivery agents. Use this to start a spec, bug, or e2e workflow, or one of the project's custom workflow types from .stratus/workflows/.",
 		InputSchema: obj(
 			req("id", "string", "Unique workflow ID (use format: <type>-<slug>, e.g. 'bug-fix-login', 'spec-user-auth')"),
-			req("type", "string", "Workflow type: 'spec' | 'bug' | 'e2e'"),
+			req("type", "string", "Workflow type", enum(workflowTypes()...)),
 			req("title", "string", "Human-readable title for the workflow"),
 			opt("session_id", "string", "Claude session ID (use ${CLAUDE_SESSION_ID} for automatic tracking)"),
-			opt("complexity", "string", "For spec workflows: 'simple' | 'complex'"),
+			opt("complexity", "string", "For spec workflows", enum(workflowComplexities...)),
+			opt("template", "string", "Name of a template from .stratus/templates/ that pre-populates tasks, the plan and delegated agents; its type must match"),
+			opt("parent_id", "string", "ID of the workflow that spawns this one (e.g. a bug found in a spec's verify phase); the parent's phase must allow spawning this type"),
+			opt("budget_usd", "number", "Cost cap in USD; once spent, further delivery-agent delegation is refused until a human raises it"),
 		),
+		OutputSchema: workflowStateSchema,
 		Handler: func(args map[string]any) (any, error) {
 			return client.post("/api/workflows", args)
 		},
@@ -214,46 +476,72 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 
 	s.Register(Tool{
 		Name:        "transition_phase",
-		Description: "Transition a workflow to the next phase. Optionally set tasks and plan before transitioning. Validates against state machine rules.",
+		Description: "Transition a workflow to the next phase. Optionally set tasks and plan before transitioning. Validates against state machine rules and runs the phase's checks (e.g. build, tests); a failing check blocks the transition and its output is returned in the error. Some transitions need human approval: then status is \"awaiting_approval\", the workflow stays in its phase until a human decides in the dashboard; do not retry, check the outcome with get_approval.",
 		InputSchema: obj(
 			req("workflow_id", "string", "Workflow ID to transition"),
-			req("phase", "string", "Target phase (e.g. 'implement', 'verify', 'review', 'complete')"),
-			opt("tasks", "array", "Task titles to set before transitioning (for plan→implement)"),
+			req("phase", "string", "Target phase (e.g. 'implement', 'verify', 'review', 'complete')", enum(workflowPhases()...)),
+			opt("tasks", "array", "Task titles to set before transitioning (for plan→implement)", items("string")),
 			opt("plan_content", "string", "Full markdown plan content to set before transitioning"),
 		),
+		OutputSchema: transitionResultSchema,
 		Handler: func(args map[string]any) (any, error) {
 			id, _ := args["workflow_id"].(string)
-			if id == "" {
-				return nil, fmt.Errorf("workflow_id is required")
-			}
 			phase, _ := args["phase"].(string)
-			if phase == "" {
-				return nil, fmt.Errorf("phase is required")
-			}
 
-			if tasks, err := convertTasksArg(args["tasks"]); err != nil {
-				return nil, fmt.Errorf("invalid tasks: %w", err)
-			} else if len(tasks) > 0 {
-				if _, err := client.post(fmt.Sprintf("/api/workflows/%s/tasks", id), map[string]any{"tasks": tasks}); err != nil {
+			if tasks, _ := args["tasks"].([]any); len(tasks) > 0 {
+				if _, err := client.post(fmt.Sprintf("/api/workflows/%s/tasks", neturl.PathEscape(id)), map[string]any{"tasks": tasks}); err != nil {
 					return nil, fmt.Errorf("failed to set tasks: %w", err)
 				}
 			}
 
 			if plan, _ := args["plan_content"].(string); plan != "" {
-				if _, err := client.put(fmt.Sprintf("/api/workflows/%s/plan", id), map[string]any{"content": plan}); err != nil {
+				if _, err := client.put(fmt.Sprintf("/api/workflows/%s/plan", neturl.PathEscape(id)), map[string]any{"content": plan}); err != nil {
 					return nil, fmt.Errorf("failed to set plan: %w", err)
 				}
 			}
 
-			result, err := client.put(fmt.Sprintf("/api/workflows/%s/phase", id), map[string]any{"phase": phase})
+			// Phase checks such as `go test ./...` run inside this request.
+			result, err := client.withTimeout(phaseTransitionTimeout).put(fmt.Sprintf("/api/workflows/%s/phase", neturl.PathEscape(id)), map[string]any{"phase": phase})
 			if err != nil {
 				return nil, err
 			}
-
+			if m, ok := result.(map[string]any); ok {
+				if _, held := m["status"]; !held {
+					m["status"] = "transitioned"
+				}
+			}
 			return result, nil
 		},
 	})
 
+	s.Register(Tool{
+		Name:        "get_approval",
+		Description: "Get the state of a workflow's approval request without waiting. Returns status \"awaiting_approval\" while no human has decided, \"approved\" (the workflow has moved to the approved phase) or \"rejected\" (read the decision comments and revise). Do not poll: subscribe to the stratus://workflow/{id} resource, which is updated when the approval is resolved, or tell the user the workflow waits for their decision in the dashboard.",
+		InputSchema: obj(
+			req("workflow_id", "string", "Workflow ID"),
+		),
+		OutputSchema: transitionResultSchema,
+		Handler: func(args map[string]any) (any, error) {
+			id, _ := args["workflow_id"].(string)
+			result, err := client.get("/api/workflows/"+neturl.PathEscape(id), nil)
+			if err != nil {
+				return nil, err
+			}
+			m, _ := result.(map[string]any)
+			approval, _ := m["approval"].(map[string]any)
+			if approval == nil {
+				return nil, fmt.Errorf("workflow %q has no approval request", id)
+			}
+			switch status, _ := approval["status"].(string); status {
+			case "pending":
+				m["status"] = "awaiting_approval"
+			default:
+				m["status"] = status
+			}
+			return m, nil
+		},
+	})
+
 	s.Register(Tool{
 		Name:        "delegate_agent",
 		Description: "Record an agent delegation for the current workflow phase. Call this after delegating work via Task tool to track which agents worked on which phases.",
@@ -263,10 +551,7 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		),
 		Handler: func(args map[string]any) (any, error) {
 			id, _ := args["workflow_id"].(string)
-			if id == "" {
-				return nil, fmt.Errorf("workflow_id is required")
-			}
-			return client.post(fmt.Sprintf("/api/workflows/%s/delegate", id), args)
+			return client.post(fmt.Sprintf("/api/workflows/%s/delegate", neturl.PathEscape(id)), args)
 		},
 	})
 
@@ -275,18 +560,13 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		Description: "Mark a workflow task as in_progress. Call this before delegating a task to an agent.",
 		InputSchema: obj(
 			req("workflow_id", "string", "Workflow ID"),
-			req("task_index", "integer", "Zero-based task index"),
+			req("task_index", "integer", "Zero-based task index", minimum(0)),
 		),
+		OutputSchema: workflowStateSchema,
 		Handler: func(args map[string]any) (any, error) {
 			id, _ := args["workflow_id"].(string)
-			if id == "" {
-				return nil, fmt.Errorf("workflow_id is required")
-			}
-			index := intArg(args, "task_index", -1)
-			if index < 0 {
-				return nil, fmt.Errorf("task_index is required")
-			}
-			return client.post(fmt.Sprintf("/api/workflows/%s/tasks/%d/start", id, index), nil)
+			index := intArg(args, "task_index", 0)
+			return client.post(fmt.Sprintf("/api/workflows/%s/tasks/%d/start", neturl.PathEscape(id), index), nil)
 		},
 	})
 
@@ -295,18 +575,13 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		Description: "Mark a workflow task as done. Call this after an agent successfully completes a task.",
 		InputSchema: obj(
 			req("workflow_id", "string", "Workflow ID"),
-			req("task_index", "integer", "Zero-based task index"),
+			req("task_index", "integer", "Zero-based task index", minimum(0)),
 		),
+		OutputSchema: workflowStateSchema,
 		Handler: func(args map[string]any) (any, error) {
 			id, _ := args["workflow_id"].(string)
-			if id == "" {
-				return nil, fmt.Errorf("workflow_id is required")
-			}
-			index := intArg(args, "task_index", -1)
-			if index < 0 {
-				return nil, fmt.Errorf("task_index is required")
-			}
-			return client.post(fmt.Sprintf("/api/workflows/%s/tasks/%d/complete", id, index), nil)
+			index := intArg(args, "task_index", 0)
+			return client.post(fmt.Sprintf("/api/workflows/%s/tasks/%d/complete", neturl.PathEscape(id), index), nil)
 		},
 	})
 
@@ -316,12 +591,42 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		InputSchema: obj(
 			req("workflow_id", "string", "Workflow ID"),
 		),
+		OutputSchema: workflowStateSchema,
+		Handler: func(args map[string]any) (any, error) {
+			id, _ := args["workflow_id"].(string)
+			return client.get(fmt.Sprintf("/api/workflows/%s", neturl.PathEscape(id)), nil)
+		},
+	})
+
+	s.Register(Tool{
+		Name:        "get_workflow_costs",
+		Description: "Get what a workflow has spent so far: total cost and tokens, broken down per phase and per delegated agent, against its budget. When over_budget is true, delivery agents cannot be delegated until a human raises the budget.",
+		InputSchema: obj(
+			req("workflow_id", "string", "Workflow ID"),
+		),
+		OutputSchema: costSummarySchema,
 		Handler: func(args map[string]any) (any, error) {
 			id, _ := args["workflow_id"].(string)
-			if id == "" {
-				return nil, fmt.Errorf("workflow_id is required")
+			result, err := client.get(fmt.Sprintf("/api/workflows/%s/costs", neturl.PathEscape(id)), neturl.Values{"limit": {"0"}})
+			if err != nil {
+				return nil, err
 			}
-			return client.get(fmt.Sprintf("/api/workflows/%s", id), nil)
+			m, _ := result.(map[string]any)
+			return m["summary"], nil
+		},
+	})
+
+	s.Register(Tool{
+		Name:        "simulate_workflow",
+		Description: "Replay a recorded workflow against a modified workflow definition (phases, checks, approvals, loop limits, phase agents, read_only) and compare it with the registered one. Each transition, delivery-agent delegation and file write is judged by both; steps marked differs would have gone another way. Recorded check results are reused, not rerun. Use this to try a definition change before committing it to .stratus/workflows.",
+		InputSchema: obj(
+			req("workflow_id", "string", "Workflow ID of a past or active workflow"),
+			req("definition", "object", "Modified workflow definition, in the format of .stratus/workflows/*.json; type may be omitted"),
+		),
+		OutputSchema: simulationSchema,
+		Handler: func(args map[string]any) (any, error) {
+			id, _ := args["workflow_id"].(string)
+			return client.post(fmt.Sprintf("/api/workflows/%s/simulate", neturl.PathEscape(id)), map[string]any{"definition": args["definition"]})
 		},
 	})
 
@@ -344,10 +649,7 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		),
 		Handler: func(args map[string]any) (any, error) {
 			workerID, _ := args["worker_id"].(string)
-			if workerID == "" {
-				return nil, fmt.Errorf("worker_id is required")
-			}
-			return client.post(fmt.Sprintf("/api/swarm/workers/%s/heartbeat", workerID), nil)
+			return client.post(fmt.Sprintf("/api/swarm/workers/%s/heartbeat", neturl.PathEscape(workerID)), nil)
 		},
 	})
 
@@ -357,12 +659,17 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		InputSchema: obj(
 			req("worker_id", "string", "The worker's ID"),
 		),
+		OutputSchema: listOf(obj(
+			req("id", "string", "Signal ID"),
+			req("type", "string", "Signal type"),
+			opt("from_worker", "string", "Sender worker ID"),
+			opt("to_worker", "string", "Recipient worker ID, or * for broadcast"),
+			opt("payload", "string", "JSON payload"),
+			opt("created_at", "string", "When the signal was sent"),
+		)),
 		Handler: func(args map[string]any) (any, error) {
 			workerID, _ := args["worker_id"].(string)
-			if workerID == "" {
-				return nil, fmt.Errorf("worker_id is required")
-			}
-			return client.get(fmt.Sprintf("/api/swarm/workers/%s/signals", workerID), nil)
+			return client.get(fmt.Sprintf("/api/swarm/workers/%s/signals", neturl.PathEscape(workerID)), nil)
 		},
 	})
 
@@ -371,15 +678,12 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		Description: "Update a ticket's status. Use to start work (in_progress), complete it (done), or report failure (failed).",
 		InputSchema: obj(
 			req("ticket_id", "string", "Ticket ID"),
-			req("status", "string", "New status: in_progress | done | failed"),
+			req("status", "string", "New status: in_progress to start, done on completion, failed on failure", enum(setKeys(swarm.ValidTicketStatuses)...)),
 			opt("result", "string", "Completion summary or failure reason"),
 		),
 		Handler: func(args map[string]any) (any, error) {
 			ticketID, _ := args["ticket_id"].(string)
-			if ticketID == "" {
-				return nil, fmt.Errorf("ticket_id is required")
-			}
-			return client.put(fmt.Sprintf("/api/swarm/tickets/%s/status", ticketID), args)
+			return client.put(fmt.Sprintf("/api/swarm/tickets/%s/status", neturl.PathEscape(ticketID)), args)
 		},
 	})
 
@@ -391,9 +695,6 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		),
 		Handler: func(args map[string]any) (any, error) {
 			workerID, _ := args["worker_id"].(string)
-			if workerID == "" {
-				return nil, fmt.Errorf("worker_id is required")
-			}
 			return client.post("/api/swarm/forge/submit", map[string]any{"worker_id": workerID})
 		},
 	})
@@ -418,14 +719,10 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		Description: "Reserve file patterns for exclusive editing by a worker. Checks for conflicts with other workers' reservations before reserving.",
 		InputSchema: obj(
 			req("worker_id", "string", "Worker ID requesting the reservation"),
-			req("patterns", "array", "Array of glob patterns to reserve (e.g. [\"src/api/**\", \"db/schema.go\"])"),
+			req("patterns", "array", "Array of glob patterns to reserve (e.g. [\"src/api/**\", \"db/schema.go\"])", items("string")),
 			opt("reason", "string", "Why these files are needed"),
 		),
 		Handler: func(args map[string]any) (any, error) {
-			workerID, _ := args["worker_id"].(string)
-			if workerID == "" {
-				return nil, fmt.Errorf("worker_id is required")
-			}
 			return client.post("/api/swarm/files/reserve", args)
 		},
 	})
@@ -438,9 +735,6 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		),
 		Handler: func(args map[string]any) (any, error) {
 			workerID, _ := args["worker_id"].(string)
-			if workerID == "" {
-				return nil, fmt.Errorf("worker_id is required")
-			}
 			return client.post("/api/swarm/files/release", map[string]any{"worker_id": workerID})
 		},
 	})
@@ -450,14 +744,11 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		Description: "Save a coordinator checkpoint for mission recovery. Records progress percentage and context state.",
 		InputSchema: obj(
 			req("mission_id", "string", "Mission ID"),
-			req("progress", "integer", "Progress percentage 0-100"),
+			req("progress", "integer", "Progress percentage 0-100", minimum(0), maximum(100)),
 			opt("context", "string", "JSON string with coordinator state snapshot"),
 		),
 		Handler: func(args map[string]any) (any, error) {
 			missionID, _ := args["mission_id"].(string)
-			if missionID == "" {
-				return nil, fmt.Errorf("mission_id is required")
-			}
 			body := map[string]any{
 				"progress":   intArg(args, "progress", 0),
 				"state_json": "{}",
@@ -465,7 +756,7 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 			if ctx, ok := args["context"].(string); ok && ctx != "" {
 				body["state_json"] = ctx
 			}
-			return client.post(fmt.Sprintf("/api/swarm/missions/%s/checkpoint", missionID), body)
+			return client.post(fmt.Sprintf("/api/swarm/missions/%s/checkpoint", neturl.PathEscape(missionID)), body)
 		},
 	})
 
@@ -476,17 +767,14 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		Description: "Record structured evidence for a ticket. Use after completing meaningful actions (tests, builds, reviews) to create an audit trail that reviewers can inspect.",
 		InputSchema: obj(
 			req("ticket_id", "string", "Ticket ID this evidence belongs to"),
-			req("type", "string", "Evidence type: diff | test_result | review | build | note | gate"),
+			req("type", "string", "Evidence type", enum(setKeys(swarm.ValidEvidenceTypes)...)),
 			req("content", "string", "Evidence content (diff output, test results, review comments, etc.)"),
 			opt("agent", "string", "Agent that produced this evidence"),
-			opt("verdict", "string", "Verdict: pass | fail | info"),
+			opt("verdict", "string", "Verdict", enum("pass", "fail", "info")),
 		),
 		Handler: func(args map[string]any) (any, error) {
 			ticketID, _ := args["ticket_id"].(string)
-			if ticketID == "" {
-				return nil, fmt.Errorf("ticket_id is required")
-			}
-			return client.post(fmt.Sprintf("/api/swarm/tickets/%s/evidence", ticketID), args)
+			return client.post(fmt.Sprintf("/api/swarm/tickets/%s/evidence", neturl.PathEscape(ticketID)), args)
 		},
 	})
 
@@ -498,10 +786,7 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		),
 		Handler: func(args map[string]any) (any, error) {
 			ticketID, _ := args["ticket_id"].(string)
-			if ticketID == "" {
-				return nil, fmt.Errorf("ticket_id is required")
-			}
-			return client.get(fmt.Sprintf("/api/swarm/tickets/%s/evidence", ticketID), nil)
+			return client.get(fmt.Sprintf("/api/swarm/tickets/%s/evidence", neturl.PathEscape(ticketID)), nil)
 		},
 	})
 
@@ -516,10 +801,6 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 			opt("mission_id", "string", "Mission ID (auto-detected from worker if omitted)"),
 		),
 		Handler: func(args map[string]any) (any, error) {
-			workerID, _ := args["worker_id"].(string)
-			if workerID == "" {
-				return nil, fmt.Errorf("worker_id is required")
-			}
 			return client.post("/api/swarm/guardrails/track", args)
 		},
 	})
@@ -532,10 +813,7 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		),
 		Handler: func(args map[string]any) (any, error) {
 			missionID, _ := args["mission_id"].(string)
-			if missionID == "" {
-				return nil, fmt.Errorf("mission_id is required")
-			}
-			return client.post("/api/swarm/missions/"+missionID+"/forge/execute", map[string]any{})
+			return client.post("/api/swarm/missions/"+neturl.PathEscape(missionID)+"/forge/execute", map[string]any{})
 		},
 	})
 
@@ -546,8 +824,8 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		Description: "Search knowledge wiki pages using full-text search.",
 		InputSchema: obj(
 			req("query", "string", "Search query"),
-			opt("type", "string", "Filter by page type (summary, entity, concept, answer, index)"),
-			opt("limit", "integer", "Max results (default: 20)"),
+			opt("type", "string", "Filter by page type", enum("summary", "entity", "concept", "answer", "index")),
+			opt("limit", "integer", "Max results (default: 20)", minimum(1)),
 		),
 		Handler: func(args map[string]any) (any, error) {
 			params := neturl.Values{}
@@ -570,7 +848,7 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		InputSchema: obj(
 			req("query", "string", "Natural language question to answer from the wiki"),
 			opt("persist", "boolean", "Whether to persist the answer as a new wiki page"),
-			opt("max_sources", "integer", "Maximum number of source pages to use (default: 10)"),
+			opt("max_sources", "integer", "Maximum number of source pages to use (default: 10)", minimum(1)),
 		),
 		Handler: func(args map[string]any) (any, error) {
 			body := map[string]any{}
@@ -593,7 +871,7 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		Name:        "evolution_status",
 		Description: "Get recent agent evolution runs and their outcomes.",
 		InputSchema: obj(
-			opt("limit", "integer", "Max runs to return (default: 20)"),
+			opt("limit", "integer", "Max runs to return (default: 20)", minimum(1)),
 		),
 		Handler: func(args map[string]any) (any, error) {
 			params := neturl.Values{}
@@ -608,8 +886,8 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		Name:        "evolution_trigger",
 		Description: "Trigger an autonomous evolution cycle that tests hypotheses and applies improvements.",
 		InputSchema: obj(
-			opt("timeout_ms", "integer", "Timeout in milliseconds (default: 600000)"),
-			opt("categories", "array", "Hypothesis categories to test: prompt_tuning, workflow_routing, agent_selection, threshold_adjustment"),
+			opt("timeout_ms", "integer", "Timeout in milliseconds (default: 600000)", minimum(1)),
+			opt("categories", "array", "Hypothesis categories to test", items("string", enum("prompt_tuning", "workflow_routing", "agent_selection", "threshold_adjustment"))),
 		),
 		Handler: func(args map[string]any) (any, error) {
 			body := map[string]any{}
@@ -629,7 +907,7 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		Name:        "code_analysis_trigger",
 		Description: "Trigger a code quality analysis run on the host project. Analyzes top files by churn/risk score for anti-patterns, duplication, coverage gaps, error handling issues, complexity, dead code, and security concerns.",
 		InputSchema: obj(
-			opt("categories", "array", "Categories to analyze. Allowed: anti_pattern, duplication, coverage_gap, error_handling, complexity, dead_code, security. Empty = all."),
+			opt("categories", "array", "Categories to analyze. Empty = all.", items("string", enum(codeAnalysisCategories...))),
 		),
 		Handler: func(args map[string]any) (any, error) {
 			body := map[string]any{}
@@ -645,10 +923,10 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		Description: "Query code quality findings from the most recent analysis. Filter by file path, category, or severity to find specific issues.",
 		InputSchema: obj(
 			opt("file", "string", "Filter by file path (prefix match)"),
-			opt("category", "string", "Filter by category: anti_pattern, duplication, coverage_gap, error_handling, complexity, dead_code, security"),
-			opt("severity", "string", "Filter by severity: critical, warning, info"),
+			opt("category", "string", "Filter by category", enum(codeAnalysisCategories...)),
+			opt("severity", "string", "Filter by severity", enum("critical", "warning", "info")),
 			opt("q", "string", "Full-text search across finding titles and descriptions"),
-			opt("limit", "integer", "Max results to return (default: 20)"),
+			opt("limit", "integer", "Max results to return (default: 20)", minimum(1)),
 		),
 		Handler: func(args map[string]any) (any, error) {
 			params := neturl.Values{}
@@ -668,7 +946,7 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 		Name:        "code_quality_summary",
 		Description: "Get aggregated code quality metrics for the project over time. Shows trends in findings count, severity distribution, and coverage.",
 		InputSchema: obj(
-			opt("days", "integer", "Number of days of history to return (default: 30, max: 365)"),
+			opt("days", "integer", "Number of days of history to return (default: 30)", minimum(1), maximum(365)),
 		),
 		Handler: func(args map[string]any) (any, error) {
 			params := neturl.Values{}
@@ -682,34 +960,14 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 	s.Register(Tool{
 		Name:        "code_quality_finding_update",
 		Description: "Update the lifecycle status of a code quality finding (rejected or applied). Agents should call this with status='applied' at the end of a successful fix workflow initiated from the Code Quality tab.",
-		InputSchema: map[string]any{
-			"type": "object",
-			"properties": map[string]any{
-				"finding_id": map[string]any{
-					"type":        "string",
-					"description": "The ID of the code quality finding to update",
-				},
-				"status": map[string]any{
-					"type":        "string",
-					"description": "New lifecycle status for the finding",
-					"enum":        []string{"rejected", "applied"},
-				},
-			},
-			"required": []string{"finding_id", "status"},
-		},
+		InputSchema: obj(
+			req("finding_id", "string", "The ID of the code quality finding to update"),
+			req("status", "string", "New lifecycle status for the finding", enum("rejected", "applied")),
+		),
 		Handler: func(args map[string]any) (any, error) {
-			findingID, ok := args["finding_id"].(string)
-			if !ok || findingID == "" {
-				return nil, fmt.Errorf("finding_id is required")
-			}
-			status, ok := args["status"].(string)
-			if !ok || status == "" {
-				return nil, fmt.Errorf("status is required")
-			}
-			if status != "rejected" && status != "applied" {
-				return nil, fmt.Errorf("status must be one of: rejected, applied")
-			}
-			return client.put("/api/code-analysis/findings/"+findingID+"/status", map[string]any{
+			findingID, _ := args["finding_id"].(string)
+			status, _ := args["status"].(string)
+			return client.put("/api/code-analysis/findings/"+neturl.PathEscape(findingID)+"/status", map[string]any{
 				"status": status,
 			})
 		},
@@ -727,12 +985,23 @@ func RegisterTools(s *Server, apiBase string, httpClient *http.Client) {
 	})
 }
 
+// phaseTransitionTimeout bounds a transition_phase call, which waits for
+// the server to run the phase's checks.
+const phaseTransitionTimeout = 30 * time.Minute
+
 // apiClient is a minimal HTTP client for calling the Stratus API.
 type apiClient struct {
 	base string
 	http *http.Client
 }
 
+// withTimeout returns a copy of the client whose requests may take up to d.
+func (c *apiClient) withTimeout(d time.Duration) *apiClient {
+	hc := *c.http
+	hc.Timeout = d
+	return &apiClient{base: c.base, http: &hc}
+}
+
 func (c *apiClient) get(path string, params neturl.Values) (any, error) {
 	u := c.base + path
 	if len(params) > 0 {
@@ -777,24 +1046,64 @@ func (c *apiClient) put(path string, body any) (any, error) {
 	return c.decodeResponse(resp, "PUT", path)
 }
 
+// apiError is a non-2xx response from the Stratus API.
+type apiError struct {
+	Method  string
+	Path    string
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s %s: %s (HTTP %d)", e.Method, e.Path, e.Message, e.Status)
+	}
+	return fmt.Sprintf("%s %s: HTTP %d", e.Method, e.Path, e.Status)
+}
+
+// isNotFound reports whether err is an HTTP 404 from the Stratus API.
+func isNotFound(err error) bool {
+	var ae *apiError
+	return errors.As(err, &ae) && ae.Status == http.StatusNotFound
+}
+
 // decodeResponse checks the HTTP status code before decoding JSON.
 func (c *apiClient) decodeResponse(resp *http.Response, method, path string) (any, error) {
 	result, err := decodeJSON(resp.Body)
 	if err != nil {
+		if resp.StatusCode >= 400 {
+			return nil, &apiError{Method: method, Path: path, Status: resp.StatusCode}
+		}
 		return nil, fmt.Errorf("%s %s: failed to decode response: %w", method, path, err)
 	}
 	if resp.StatusCode >= 400 {
 		// Try to extract error message from response body
+		apiErr := &apiError{Method: method, Path: path, Status: resp.StatusCode}
 		if m, ok := result.(map[string]any); ok {
-			if msg, ok := m["error"].(string); ok {
-				return nil, fmt.Errorf("%s %s: %s (HTTP %d)", method, path, msg, resp.StatusCode)
-			}
+			apiErr.Message, _ = m["error"].(string)
 		}
-		return nil, fmt.Errorf("%s %s: HTTP %d", method, path, resp.StatusCode)
+		return nil, apiErr
 	}
 	return result, nil
 }
 
+// getText fetches a non-JSON endpoint (e.g. rendered markdown) as a string.
+func (c *apiClient) getText(path string) (string, error) {
+	resp, err := c.http.Get(c.base + path)
+	if err != nil {
+		return "", fmt.Errorf("GET %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("GET %s: %w", path, err)
+	}
+	if resp.StatusCode >= 400 {
+		return "", &apiError{Method: "GET", Path: path, Status: resp.StatusCode}
+	}
+	return string(body), nil
+}
+
 func decodeJSON(r io.Reader) (any, error) {
 	var result any
 	if err := json.NewDecoder(r).Decode(&result); err != nil {
@@ -803,36 +1112,8 @@ func decodeJSON(r io.Reader) (any, error) {
 	return result, nil
 }
 
-// Schema helpers
-func obj(fields ...map[string]any) map[string]any {
-	properties := map[string]any{}
-	required := []string{}
-	for _, f := range fields {
-		name := f["name"].(string)
-		prop := map[string]any{"type": f["type"], "description": f["description"]}
-		if f["type"] == "array" {
-			prop["items"] = map[string]any{"type": "string"}
-		}
-		properties[name] = prop
-		if r, ok := f["required"].(bool); ok && r {
-			required = append(required, name)
-		}
-	}
-	schema := map[string]any{"type": "object", "properties": properties}
-	if len(required) > 0 {
-		schema["required"] = required
-	}
-	return schema
-}
-
-func req(name, typ, desc string) map[string]any {
-	return map[string]any{"name": name, "type": typ, "description": desc, "required": true}
-}
-
-func opt(name, typ, desc string) map[string]any {
-	return map[string]any{"name": name, "type": typ, "description": desc}
-}
-
+// intArg reads an integer argument. Validation has already coerced numeric
+// values to float64.
 func intArg(args map[string]any, key string, def int) int {
 	v, ok := args[key]
 	if !ok {
@@ -846,54 +1127,3 @@ func intArg(args map[string]any, key string, def int) int {
 	}
 	return def
 }
-
-func convertMemoryArgs(args map[string]any) map[string]any {
-	result := make(map[string]any)
-	for k, v := range args {
-		switch k {
-		case "importance":
-			if s, ok := v.(string); ok {
-				if f, err := strconv.ParseFloat(s, 64); err == nil {
-					result[k] = f
-					continue
-				}
-			}
-		case "tags":
-			if s, ok := v.(string); ok {
-				var arr []string
-				if json.Unmarshal([]byte(s), &arr) == nil {
-					result[k] = arr
-					continue
-				}
-			}
-		}
-		result[k] = v
-	}
-	return result
-}
-
-func convertTasksArg(v any) ([]string, error) {
-	if v == nil {
-		return nil, nil
-	}
-	if arr, ok := v.([]any); ok {
-		result := make([]string, 0, len(arr))
-		for i, item := range arr {
-			if s, ok := item.(string); ok {
-				result = append(result, s)
-			} else {
-				return nil, fmt.Errorf("task at index %d is not a string (got %T)", i, item)
-			}
-		}
-		return result, nil
-	}
-	if s, ok := v.(string); ok && s != "" {
-		var arr []string
-		if err := json.Unmarshal([]byte(s), &arr); err != nil {
-			return nil, fmt.Errorf("invalid JSON array: %w", err)
-		}
-		return arr, nil
-	}
-	return nil, nil
-}
-
@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+const (
+	// watchReadLimit allows workflow_updated broadcasts carrying full plan and
+	// design documents.
+	watchReadLimit = 4 << 20
+	// watchMaxBackoff caps the delay between reconnect attempts.
+	watchMaxBackoff = 30 * time.Second
+)
+
+// WatchHub follows the API server's WebSocket hub (/api/ws) and turns its
+// broadcasts into MCP change notifications. `stratus mcp-serve` runs as a
+// separate process, so this is how stdio clients learn about changes; the
+// /mcp endpoint inside `stratus serve` listens to the hub directly. It
+// reconnects until ctx is cancelled.
+func WatchHub(ctx context.Context, s *Server, apiBase string) {
+	wsURL := "ws" + strings.TrimPrefix(strings.TrimRight(apiBase, "/"), "http") + "/api/ws"
+	backoff := time.Second
+	for {
+		err := watchOnce(ctx, s, wsURL, func() { backoff = time.Second })
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("mcp: hub watch: %v (retrying in %s)", err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > watchMaxBackoff {
+			backoff = watchMaxBackoff
+		}
+	}
+}
+
+func watchOnce(ctx context.Context, s *Server, wsURL string, connected func()) error {
+	conn, _, err := websocket.Dial(ctx, wsURL, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "bye")
+	conn.SetReadLimit(watchReadLimit)
+	connected()
+
+	for {
+		var msg struct {
+			Type    string          `json:"type"`
+			Payload json.RawMessage `json:"payload"`
+		}
+		if err := wsjson.Read(ctx, conn, &msg); err != nil {
+			return err
+		}
+		var payload any
+		_ = json.Unmarshal(msg.Payload, &payload)
+		s.HandleChange(msg.Type, payload)
+	}
+}
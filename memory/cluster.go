@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+type eventGroup struct {
+	project    string
+	scope      string
+	events     []db.Event
+	similarity float64
+}
+
+// clusterEvents groups similar events within each project and scope. Events
+// are visited newest first; each unassigned event seeds a cluster of the
+// unassigned events similar to it. Comparing against the seed rather than
+// any member keeps clusters from drifting through chains of loose matches.
+func clusterEvents(events []db.Event, vectors map[int64][]float32, cfg config.MemoryConsolidationConfig) []eventGroup {
+	type bucketKey struct{ project, scope string }
+	buckets := map[bucketKey][]db.Event{}
+	var keys []bucketKey
+	for _, e := range events {
+		k := bucketKey{scope: e.Scope}
+		if e.Project != nil {
+			k.project = *e.Project
+		}
+		if _, ok := buckets[k]; !ok {
+			keys = append(keys, k)
+		}
+		buckets[k] = append(buckets[k], e)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].project != keys[j].project {
+			return keys[i].project < keys[j].project
+		}
+		return keys[i].scope < keys[j].scope
+	})
+
+	var groups []eventGroup
+	for _, k := range keys {
+		members := buckets[k]
+		sort.Slice(members, func(i, j int) bool { return members[i].ID > members[j].ID })
+		tokens := make([]map[string]struct{}, len(members))
+		for i, e := range members {
+			tokens[i] = tokenSet(e.Title + " " + e.Text)
+		}
+		assigned := make([]bool, len(members))
+		for i := range members {
+			if assigned[i] {
+				continue
+			}
+			group := eventGroup{project: k.project, scope: k.scope, events: []db.Event{members[i]}, similarity: 1}
+			var picked []int
+			for j := i + 1; j < len(members) && len(group.events) < cfg.MaxClusterSize; j++ {
+				if assigned[j] {
+					continue
+				}
+				sim, ok := similar(members[i], members[j], vectors, tokens[i], tokens[j], cfg)
+				if !ok {
+					continue
+				}
+				group.events = append(group.events, members[j])
+				group.similarity = math.Min(group.similarity, sim)
+				picked = append(picked, j)
+			}
+			if len(group.events) < cfg.MinClusterSize {
+				continue
+			}
+			assigned[i] = true
+			for _, j := range picked {
+				assigned[j] = true
+			}
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// similar compares two events by embedding when both have one and by shared
+// words otherwise, returning the similarity and whether it clears the
+// matching threshold.
+func similar(a, b db.Event, vectors map[int64][]float32, ta, tb map[string]struct{}, cfg config.MemoryConsolidationConfig) (float64, bool) {
+	va, vb := vectors[a.ID], vectors[b.ID]
+	if len(va) > 0 && len(va) == len(vb) {
+		sim := cosine(va, vb)
+		return sim, sim >= cfg.SimilarityThreshold
+	}
+	sim := jaccard(ta, tb)
+	return sim, sim >= cfg.LexicalThreshold
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// tokenSet returns the distinct lower-cased words of s that are at least
+// three characters long.
+func tokenSet(s string) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		if len(w) >= 3 {
+			set[w] = struct{}{}
+		}
+	}
+	return set
+}
+
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	inter := 0
+	for w := range a {
+		if _, ok := b[w]; ok {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
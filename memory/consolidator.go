@@ -0,0 +1,406 @@
+// Package memory maintains the memory event store: it merges near-duplicate
+// events into consolidated memories, decays the importance of stale ones,
+// purges events whose TTL has passed, and moves memory between machines as
+// versioned JSONL.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+	"github.com/MartinNevlaha/stratus-v2/internal/insight/llm"
+	"github.com/MartinNevlaha/stratus-v2/internal/insight/prompts"
+	"github.com/MartinNevlaha/stratus-v2/internal/scheduler"
+)
+
+const (
+	defaultInterval = 24 * time.Hour
+	// minDecayStep skips importance changes too small to be worth a write.
+	minDecayStep = 0.001
+	// maxPromptChars caps the event text sent to the LLM per cluster.
+	maxPromptChars = 16000
+)
+
+// LLMClient is the subset of llm.Client used to write consolidated memories.
+type LLMClient interface {
+	Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error)
+}
+
+// Consolidator plans and applies memory maintenance passes.
+type Consolidator struct {
+	db  *db.DB
+	cfg func() config.MemoryConsolidationConfig
+	now func() time.Time
+
+	mu             sync.Mutex
+	llm            LLMClient
+	embeddingModel string
+	running        bool
+}
+
+// NewConsolidator creates a Consolidator. cfgFn is read on every pass so
+// config changes apply without a restart.
+func NewConsolidator(database *db.DB, cfgFn func() config.MemoryConsolidationConfig) *Consolidator {
+	return &Consolidator{db: database, cfg: cfgFn, now: time.Now}
+}
+
+// SetLLMClient sets the client that writes consolidated memories. Without
+// one, clusters are reported but never merged.
+func (c *Consolidator) SetLLMClient(client LLMClient) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.llm = client
+}
+
+// SetEmbeddingModel makes clustering compare stored embeddings from model
+// instead of only shared words.
+func (c *Consolidator) SetEmbeddingModel(model string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.embeddingModel = model
+}
+
+// Plan computes what a pass would do without changing anything.
+func (c *Consolidator) Plan(ctx context.Context) (*Report, error) {
+	return c.pass(ctx, true)
+}
+
+// Apply runs a pass: purge expired events, consolidate clusters, then decay.
+func (c *Consolidator) Apply(ctx context.Context) (*Report, error) {
+	return c.pass(ctx, false)
+}
+
+// Run applies a pass every interval_hours until ctx is cancelled. It returns
+// immediately when consolidation is disabled.
+func (c *Consolidator) Run(ctx context.Context) {
+	if !c.cfg().Enabled {
+		log.Println("memory: consolidation disabled, not starting")
+		return
+	}
+	intervalFn := func() time.Duration {
+		d := time.Duration(c.cfg().IntervalHours) * time.Hour
+		if d <= 0 {
+			return defaultInterval
+		}
+		return d
+	}
+	tick := func(ctx context.Context) {
+		if !c.cfg().Enabled {
+			return
+		}
+		report, err := c.Apply(ctx)
+		if err != nil {
+			log.Printf("memory: consolidation: %v", err)
+			return
+		}
+		s := report.Summary
+		log.Printf("memory: consolidation purged=%d consolidated=%d/%d decayed=%d archived=%d",
+			s.Expired, s.EventsConsolidated, s.Clusters, s.Decayed, s.Archived)
+	}
+	if err := scheduler.New("memory-consolidation", intervalFn, tick).Run(ctx); err != nil && err != context.Canceled {
+		log.Printf("memory: consolidation scheduler stopped: %v", err)
+	}
+}
+
+func (c *Consolidator) pass(ctx context.Context, dryRun bool) (*Report, error) {
+	c.mu.Lock()
+	if c.running {
+		c.mu.Unlock()
+		return nil, ErrRunning
+	}
+	c.running = true
+	client, model := c.llm, c.embeddingModel
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.running = false
+		c.mu.Unlock()
+	}()
+
+	cfg := withDefaults(c.cfg())
+	now := c.now()
+	report := &Report{DryRun: dryRun, GeneratedAt: now.UTC().Format(time.RFC3339)}
+
+	// 1. Expired TTL rows.
+	withTTL, err := c.db.ListEventsWithTTL()
+	if err != nil {
+		return nil, err
+	}
+	expired := map[int64]bool{}
+	for _, e := range withTTL {
+		if at, ok := parseTTL(*e.TTL); ok && !at.After(now) {
+			expired[e.ID] = true
+			report.Expired = append(report.Expired, ExpiredEvent{ID: e.ID, Title: e.Title, TTL: *e.TTL})
+		}
+	}
+	if !dryRun && len(report.Expired) > 0 {
+		ids := make([]int64, 0, len(expired))
+		for id := range expired {
+			ids = append(ids, id)
+		}
+		if _, err := c.db.DeleteEvents(ids); err != nil {
+			return nil, err
+		}
+	}
+
+	// 2. Clusters of similar events.
+	active, err := c.db.ListActiveEvents(cfg.MaxEvents)
+	if err != nil {
+		return nil, err
+	}
+	live := active[:0]
+	for _, e := range active {
+		if !expired[e.ID] {
+			live = append(live, e)
+		}
+	}
+	ids := make([]int64, len(live))
+	for i, e := range live {
+		ids[i] = e.ID
+	}
+	vectors, err := c.db.GetEventEmbeddings(ids, model)
+	if err != nil {
+		return nil, err
+	}
+	// merged holds events that are (or in a dry run would be) archived into a
+	// consolidated memory; they are left out of decay.
+	merged := map[int64]bool{}
+	for _, group := range clusterEvents(live, vectors, cfg) {
+		cl := Cluster{Project: group.project, Scope: group.scope, Similarity: round3(group.similarity)}
+		for _, e := range group.events {
+			cl.EventIDs = append(cl.EventIDs, e.ID)
+			cl.Titles = append(cl.Titles, displayTitle(e))
+		}
+		switch {
+		case client == nil:
+			cl.Skipped = "no LLM configured"
+		case dryRun:
+			report.Summary.EventsConsolidated += len(group.events)
+			for _, id := range cl.EventIDs {
+				merged[id] = true
+			}
+		default:
+			id, err := c.consolidate(ctx, client, group.events)
+			if err != nil {
+				cl.Skipped = err.Error()
+				report.Errors = append(report.Errors, fmt.Sprintf("cluster %v: %v", cl.EventIDs, err))
+				break
+			}
+			cl.ConsolidatedID = id
+			report.Summary.EventsConsolidated += len(group.events)
+			for _, id := range cl.EventIDs {
+				merged[id] = true
+			}
+		}
+		report.Clusters = append(report.Clusters, cl)
+	}
+
+	// 3. Importance decay.
+	grace := time.Duration(cfg.DecayGraceDays) * 24 * time.Hour
+	halfLife := time.Duration(cfg.DecayHalfLifeDays) * 24 * time.Hour
+	candidates, err := c.db.ListDecayCandidates(cfg.DecayMaxImportance, now.Add(-grace))
+	if err != nil {
+		return nil, err
+	}
+	var updates []db.ImportanceUpdate
+	for _, cand := range candidates {
+		if expired[cand.ID] || merged[cand.ID] {
+			continue
+		}
+		to, ok := decayImportance(cand, now, grace, halfLife)
+		if !ok {
+			continue
+		}
+		archive := to < cfg.ArchiveBelow
+		report.Decay = append(report.Decay, DecayChange{
+			ID: cand.ID, Title: cand.Title, From: round3(cand.Importance), To: round3(to), Archive: archive,
+		})
+		updates = append(updates, db.ImportanceUpdate{ID: cand.ID, Importance: to, Archive: archive})
+	}
+	if !dryRun {
+		if err := c.db.ApplyImportanceDecay(updates, now); err != nil {
+			return nil, err
+		}
+	}
+
+	report.Summary.Expired = len(report.Expired)
+	report.Summary.Clusters = len(report.Clusters)
+	report.Summary.Decayed = len(report.Decay)
+	for _, d := range report.Decay {
+		if d.Archive {
+			report.Summary.Archived++
+		}
+	}
+	return report, nil
+}
+
+// consolidate asks the LLM to merge events and stores the result, archiving
+// the originals.
+func (c *Consolidator) consolidate(ctx context.Context, client LLMClient, events []db.Event) (int64, error) {
+	resp, err := client.Complete(ctx, llm.CompletionRequest{
+		SystemPrompt:   prompts.MemoryConsolidation,
+		Messages:       []llm.Message{{Role: "user", Content: buildPrompt(events)}},
+		MaxTokens:      2048,
+		Temperature:    0.2,
+		ResponseFormat: "json",
+	})
+	if err != nil {
+		return 0, fmt.Errorf("llm: %w", err)
+	}
+	var out struct {
+		Title string   `json:"title"`
+		Text  string   `json:"text"`
+		Tags  []string `json:"tags"`
+	}
+	if err := llm.ParseJSONResponse(resp.Content, &out); err != nil {
+		return 0, fmt.Errorf("parse llm response: %w", err)
+	}
+	if strings.TrimSpace(out.Text) == "" {
+		return 0, fmt.Errorf("llm returned an empty memory")
+	}
+
+	ids := make([]int64, len(events))
+	importance := 0.0
+	tagSet := map[string]bool{"consolidated": true}
+	types := map[string]int{}
+	for i, e := range events {
+		ids[i] = e.ID
+		importance = math.Max(importance, e.Importance)
+		types[e.Type]++
+		for _, t := range e.Tags {
+			tagSet[t] = true
+		}
+	}
+	for _, t := range out.Tags {
+		if t = strings.TrimSpace(t); t != "" {
+			tagSet[t] = true
+		}
+	}
+	tags := make([]string, 0, len(tagSet))
+	for t := range tagSet {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+
+	first := events[0]
+	return c.db.ConsolidateEvents(db.SaveEventInput{
+		Actor:      "system",
+		Scope:      first.Scope,
+		Type:       dominantType(types, first.Type),
+		Title:      out.Title,
+		Text:       out.Text,
+		Tags:       tags,
+		Refs:       map[string]any{"consolidated_from": ids},
+		Importance: importance,
+		Project:    first.Project,
+	}, ids)
+}
+
+// decayImportance returns the importance of cand after the time elapsed since
+// it was last decayed (or since its grace period ended).
+func decayImportance(cand db.DecayCandidate, now time.Time, grace, halfLife time.Duration) (float64, bool) {
+	since := time.UnixMilli(cand.CreatedMs).Add(grace)
+	if cand.DecayedMs > 0 {
+		if last := time.UnixMilli(cand.DecayedMs); last.After(since) {
+			since = last
+		}
+	}
+	elapsed := now.Sub(since)
+	if elapsed <= 0 || halfLife <= 0 {
+		return cand.Importance, false
+	}
+	to := cand.Importance * math.Exp2(-float64(elapsed)/float64(halfLife))
+	if cand.Importance-to < minDecayStep {
+		return cand.Importance, false
+	}
+	return to, true
+}
+
+// ttlLayouts are the TTL formats accepted; save_memory documents ISO 8601.
+var ttlLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func parseTTL(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range ttlLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func withDefaults(cfg config.MemoryConsolidationConfig) config.MemoryConsolidationConfig {
+	def := config.Default().Memory.Consolidation
+	if cfg.MaxEvents <= 0 {
+		cfg.MaxEvents = def.MaxEvents
+	}
+	if cfg.SimilarityThreshold <= 0 {
+		cfg.SimilarityThreshold = def.SimilarityThreshold
+	}
+	if cfg.LexicalThreshold <= 0 {
+		cfg.LexicalThreshold = def.LexicalThreshold
+	}
+	if cfg.MinClusterSize < 2 {
+		cfg.MinClusterSize = 2
+	}
+	if cfg.MaxClusterSize < cfg.MinClusterSize {
+		cfg.MaxClusterSize = def.MaxClusterSize
+	}
+	if cfg.DecayHalfLifeDays <= 0 {
+		cfg.DecayHalfLifeDays = def.DecayHalfLifeDays
+	}
+	if cfg.DecayGraceDays < 0 {
+		cfg.DecayGraceDays = 0
+	}
+	return cfg
+}
+
+func buildPrompt(events []db.Event) string {
+	var b strings.Builder
+	for _, e := range events {
+		entry := fmt.Sprintf("[id=%d type=%s importance=%.2f ts=%s]\n%s\n%s\n\n", e.ID, e.Type, e.Importance, e.Ts, e.Title, e.Text)
+		if b.Len()+len(entry) > maxPromptChars {
+			break
+		}
+		b.WriteString(entry)
+	}
+	return b.String()
+}
+
+func dominantType(counts map[string]int, fallback string) string {
+	best, n := fallback, counts[fallback]
+	for t, c := range counts {
+		if c > n || (c == n && t < best) {
+			best, n = t, c
+		}
+	}
+	return best
+}
+
+func displayTitle(e db.Event) string {
+	if e.Title != "" {
+		return e.Title
+	}
+	r := []rune(e.Text)
+	if len(r) > 80 {
+		return string(r[:80]) + "…"
+	}
+	return string(r)
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
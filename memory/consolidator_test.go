@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+	"github.com/MartinNevlaha/stratus-v2/internal/insight/llm"
+)
+
+type fakeLLM struct {
+	calls   int
+	content string
+}
+
+func (f *fakeLLM) Complete(_ context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	f.calls++
+	return &llm.CompletionResponse{Content: f.content}, nil
+}
+
+func setup(t *testing.T) (*db.DB, *Consolidator) {
+	t.Helper()
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	cfg := config.Default().Memory.Consolidation
+	c := NewConsolidator(database, func() config.MemoryConsolidationConfig { return cfg })
+	return database, c
+}
+
+func save(t *testing.T, d *db.DB, in db.SaveEventInput) int64 {
+	t.Helper()
+	id, err := d.SaveEvent(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestConsolidator_PlanThenApply(t *testing.T) {
+	d, c := setup(t)
+	project := "api"
+	past := "2020-01-01"
+	a := save(t, d, db.SaveEventInput{Project: &project, Title: "Auth tokens expire", Text: "JWT access tokens expire after fifteen minutes and refresh tokens after seven days", Importance: 0.6})
+	b := save(t, d, db.SaveEventInput{Project: &project, Title: "Auth tokens expire", Text: "JWT access tokens expire after fifteen minutes; refresh tokens after seven days", Importance: 0.8, Tags: []string{"auth"}})
+	other := save(t, d, db.SaveEventInput{Project: &project, Text: "The dashboard is built with Svelte and Vite"})
+	expired := save(t, d, db.SaveEventInput{Text: "temporary note", TTL: &past})
+
+	plan, err := c.Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.DryRun || plan.Summary.Expired != 1 || plan.Expired[0].ID != expired {
+		t.Errorf("expired plan = %+v", plan.Expired)
+	}
+	if len(plan.Clusters) != 1 || len(plan.Clusters[0].EventIDs) != 2 || plan.Clusters[0].Skipped != "no LLM configured" {
+		t.Fatalf("clusters = %+v", plan.Clusters)
+	}
+	if got, _ := d.GetEventsByIDs([]int64{expired}); len(got) != 1 {
+		t.Fatal("dry run deleted an event")
+	}
+
+	fake := &fakeLLM{content: `{"title":"Auth token lifetimes","text":"Access tokens: 15 min. Refresh tokens: 7 days.","tags":["jwt"]}`}
+	c.SetLLMClient(fake)
+	report, err := c.Apply(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.calls != 1 || report.Summary.EventsConsolidated != 2 || report.Clusters[0].ConsolidatedID == 0 {
+		t.Fatalf("report = %+v", report)
+	}
+
+	merged, _ := d.GetEventsByIDs([]int64{report.Clusters[0].ConsolidatedID})
+	if len(merged) != 1 {
+		t.Fatal("consolidated event not saved")
+	}
+	m := merged[0]
+	if m.Importance != 0.8 || m.Project == nil || *m.Project != project || !strings.Contains(strings.Join(m.Tags, ","), "auth") {
+		t.Errorf("consolidated event = %+v", m)
+	}
+	if from, _ := m.Refs["consolidated_from"].([]any); len(from) != 2 {
+		t.Errorf("refs = %+v", m.Refs)
+	}
+
+	// Originals are archived: gone from search, still readable by ID.
+	results, _ := d.SearchEvents(db.SearchEventsInput{Query: "refresh tokens"})
+	if len(results) != 1 || results[0].ID != m.ID {
+		t.Errorf("search after consolidation = %+v", results)
+	}
+	if got, _ := d.GetEventsByIDs([]int64{a, b}); len(got) != 2 {
+		t.Errorf("archived originals not readable by id")
+	}
+	if got, _ := d.GetEventsByIDs([]int64{expired}); len(got) != 0 {
+		t.Errorf("expired event not purged")
+	}
+	if got, _ := d.GetEventsByIDs([]int64{other}); len(got) != 1 {
+		t.Errorf("unrelated event touched")
+	}
+}
+
+func TestConsolidator_Decay(t *testing.T) {
+	d, c := setup(t)
+	low := save(t, d, db.SaveEventInput{Text: "minor observation about logging", Importance: 0.3})
+	high := save(t, d, db.SaveEventInput{Text: "critical architectural decision", Importance: 0.9})
+
+	// Four half-lives past the grace period: 0.3 -> 0.01875, below archive_below.
+	c.now = func() time.Time { return time.Now().Add((14 + 4*90) * 24 * time.Hour) }
+	report, err := c.Apply(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Decay) != 1 || report.Decay[0].ID != low || !report.Decay[0].Archive {
+		t.Fatalf("decay = %+v", report.Decay)
+	}
+	if report.Summary.Archived != 1 {
+		t.Errorf("summary = %+v", report.Summary)
+	}
+	active, _ := d.ListActiveEvents(10)
+	if len(active) != 1 || active[0].ID != high {
+		t.Errorf("active after decay = %+v", active)
+	}
+
+	// A second pass at the same time has nothing left to decay.
+	again, _ := c.Apply(context.Background())
+	if len(again.Decay) != 0 {
+		t.Errorf("second pass decayed %+v", again.Decay)
+	}
+}
+
+func TestDecayImportance(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	cand := db.DecayCandidate{Importance: 0.5, CreatedMs: created.UnixMilli()}
+
+	if _, ok := decayImportance(cand, created.Add(10*day), 14*day, 90*day); ok {
+		t.Error("decayed inside the grace period")
+	}
+	to, ok := decayImportance(cand, created.Add(104*day), 14*day, 90*day)
+	if !ok || to < 0.2499 || to > 0.2501 {
+		t.Errorf("one half-life: %v, %v", to, ok)
+	}
+	// Decay resumes from the last decay, not from creation.
+	cand.DecayedMs = created.Add(104 * day).UnixMilli()
+	cand.Importance = 0.25
+	to, _ = decayImportance(cand, created.Add(194*day), 14*day, 90*day)
+	if to < 0.1249 || to > 0.1251 {
+		t.Errorf("second half-life: %v", to)
+	}
+}
+
+func TestClusterEvents_UsesEmbeddings(t *testing.T) {
+	cfg := config.Default().Memory.Consolidation
+	events := []db.Event{
+		{ID: 1, Scope: "repo", Text: "alpha"},
+		{ID: 2, Scope: "repo", Text: "beta"},
+		{ID: 3, Scope: "repo", Text: "gamma"},
+		{ID: 4, Scope: "global", Text: "alpha"},
+	}
+	vectors := map[int64][]float32{1: {1, 0}, 2: {0.99, 0.05}, 3: {0, 1}, 4: {1, 0}}
+	groups := clusterEvents(events, vectors, cfg)
+	if len(groups) != 1 || len(groups[0].events) != 2 {
+		t.Fatalf("groups = %+v", groups)
+	}
+	if groups[0].events[0].ID != 2 || groups[0].events[1].ID != 1 {
+		t.Errorf("cluster members = %+v", groups[0].events)
+	}
+}
@@ -0,0 +1,432 @@
+package memory
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+// SchemaVersion is the version of the JSONL memory format written by Export.
+// Import accepts this version and older ones.
+const SchemaVersion = 1
+
+const (
+	exportPageSize = 500
+	// maxRecordBytes bounds one JSONL line; long design notes fit comfortably.
+	maxRecordBytes = 16 << 20
+)
+
+// Record kinds. A file starts with one header followed by events and then
+// the sessions those events belong to.
+const (
+	KindHeader  = "header"
+	KindEvent   = "event"
+	KindSession = "session"
+)
+
+// Record is one line of an export file.
+type Record struct {
+	Kind          string           `json:"kind"`
+	SchemaVersion int              `json:"schema_version,omitempty"`
+	ExportedAt    string           `json:"exported_at,omitempty"`
+	Filter        *Filter          `json:"filter,omitempty"`
+	Event         *PortableEvent   `json:"event,omitempty"`
+	Session       *PortableSession `json:"session,omitempty"`
+}
+
+// PortableEvent is a memory event without its local row ID. SourceID is the
+// ID on the exporting machine, kept for tracing only.
+type PortableEvent struct {
+	SourceID   int64          `json:"source_id,omitempty"`
+	Ts         string         `json:"ts"`
+	Actor      string         `json:"actor"`
+	Scope      string         `json:"scope"`
+	Type       string         `json:"type"`
+	Title      string         `json:"title"`
+	Text       string         `json:"text"`
+	Tags       []string       `json:"tags"`
+	Refs       map[string]any `json:"refs"`
+	TTL        *string        `json:"ttl,omitempty"`
+	Importance float64        `json:"importance"`
+	DedupeKey  *string        `json:"dedupe_key,omitempty"`
+	Project    *string        `json:"project,omitempty"`
+	SessionID  *string        `json:"session_id,omitempty"`
+	CreatedMs  int64          `json:"created_ms"`
+}
+
+// PortableSession is a session without its local row ID.
+type PortableSession struct {
+	ContentSessionID string  `json:"content_session_id"`
+	Project          string  `json:"project"`
+	InitialPrompt    *string `json:"initial_prompt,omitempty"`
+	StartedAt        string  `json:"started_at"`
+}
+
+// Filter selects events on export and import. Since and Until compare
+// against the event timestamp; zero values match everything.
+type Filter struct {
+	Project         string `json:"project,omitempty"`
+	Scope           string `json:"scope,omitempty"`
+	Type            string `json:"type,omitempty"`
+	Since           string `json:"since,omitempty"`
+	Until           string `json:"until,omitempty"`
+	IncludeArchived bool   `json:"include_archived,omitempty"`
+}
+
+func (f Filter) matches(e *PortableEvent) bool {
+	project := ""
+	if e.Project != nil {
+		project = *e.Project
+	}
+	return (f.Project == "" || f.Project == project) &&
+		(f.Scope == "" || f.Scope == e.Scope) &&
+		(f.Type == "" || f.Type == e.Type) &&
+		(f.Since == "" || e.Ts >= f.Since) &&
+		(f.Until == "" || e.Ts <= f.Until)
+}
+
+// ExportSummary counts what Export wrote.
+type ExportSummary struct {
+	Events   int `json:"events"`
+	Sessions int `json:"sessions"`
+}
+
+// Export writes the events matching f, and the sessions they reference, to w
+// as JSONL.
+func Export(database *db.DB, w io.Writer, f Filter) (ExportSummary, error) {
+	var sum ExportSummary
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(Record{
+		Kind:          KindHeader,
+		SchemaVersion: SchemaVersion,
+		ExportedAt:    time.Now().UTC().Format(time.RFC3339),
+		Filter:        &f,
+	}); err != nil {
+		return sum, err
+	}
+
+	sessionIDs := map[string]bool{}
+	filter := db.EventFilter{
+		Project:         f.Project,
+		Scope:           f.Scope,
+		Type:            f.Type,
+		DateStart:       f.Since,
+		DateEnd:         f.Until,
+		IncludeArchived: f.IncludeArchived,
+	}
+	var after int64
+	for {
+		page, err := database.ListEventsAfter(filter, after, exportPageSize)
+		if err != nil {
+			return sum, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, e := range page {
+			if err := enc.Encode(Record{Kind: KindEvent, Event: toPortableEvent(e)}); err != nil {
+				return sum, err
+			}
+			sum.Events++
+			if e.SessionID != nil && *e.SessionID != "" {
+				sessionIDs[*e.SessionID] = true
+			}
+			after = e.ID
+		}
+	}
+
+	ids := make([]string, 0, len(sessionIDs))
+	for id := range sessionIDs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for start := 0; start < len(ids); start += exportPageSize {
+		end := min(start+exportPageSize, len(ids))
+		sessions, err := database.GetSessionsByContentIDs(ids[start:end])
+		if err != nil {
+			return sum, err
+		}
+		for _, s := range sessions {
+			if err := enc.Encode(Record{Kind: KindSession, Session: &PortableSession{
+				ContentSessionID: s.ContentSessionID,
+				Project:          s.Project,
+				InitialPrompt:    s.InitialPrompt,
+				StartedAt:        s.StartedAt,
+			}}); err != nil {
+				return sum, err
+			}
+			sum.Sessions++
+		}
+	}
+	return sum, nil
+}
+
+// ImportOptions controls Import.
+type ImportOptions struct {
+	// Filter limits which events are imported.
+	Filter Filter
+	// DryRun reports what would be imported without writing.
+	DryRun bool
+}
+
+// ImportReport summarises an import.
+type ImportReport struct {
+	SchemaVersion int              `json:"schema_version"`
+	DryRun        bool             `json:"dry_run"`
+	Events        ImportCounts     `json:"events"`
+	Sessions      ImportCounts     `json:"sessions"`
+	Conflicts     []ImportConflict `json:"conflicts"`
+	Errors        []ImportError    `json:"errors"`
+}
+
+// ImportCounts tallies records of one kind.
+type ImportCounts struct {
+	Imported int `json:"imported"`
+	// Duplicates already existed unchanged and were skipped.
+	Duplicates int `json:"duplicates"`
+	// Filtered did not match the import filter.
+	Filtered int `json:"filtered"`
+}
+
+// ImportConflict is an event whose dedupe_key is already held by a different
+// local event. The local event is kept.
+type ImportConflict struct {
+	Line       int    `json:"line"`
+	DedupeKey  string `json:"dedupe_key"`
+	ExistingID int64  `json:"existing_id"`
+	Reason     string `json:"reason"`
+}
+
+// ImportError is a line that could not be imported.
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// Import reads a JSONL export from r into the database. Events whose
+// dedupe_key already exists are skipped: as duplicates when the content is
+// the same, as conflicts when it differs. Events without a key are skipped
+// when an identical event exists, so importing a file twice is harmless.
+func Import(database *db.DB, r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	report := &ImportReport{DryRun: opts.DryRun, Conflicts: []ImportConflict{}, Errors: []ImportError{}}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxRecordBytes)
+	seenKeys := map[string]bool{}
+	line := 0
+	for sc.Scan() {
+		line++
+		raw := strings.TrimSpace(sc.Text())
+		if raw == "" {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+			if report.SchemaVersion == 0 {
+				return nil, fmt.Errorf("line %d: not a memory export: %w", line, err)
+			}
+			report.Errors = append(report.Errors, ImportError{Line: line, Error: err.Error()})
+			continue
+		}
+		if report.SchemaVersion == 0 {
+			if rec.Kind != KindHeader {
+				return nil, fmt.Errorf("line %d: missing header record", line)
+			}
+			if rec.SchemaVersion < 1 || rec.SchemaVersion > SchemaVersion {
+				return nil, fmt.Errorf("unsupported schema_version %d (this build reads up to %d)", rec.SchemaVersion, SchemaVersion)
+			}
+			report.SchemaVersion = rec.SchemaVersion
+			continue
+		}
+
+		switch rec.Kind {
+		case KindEvent:
+			if rec.Event == nil {
+				report.Errors = append(report.Errors, ImportError{Line: line, Error: "event record without event"})
+				continue
+			}
+			if err := importEvent(database, rec.Event, opts, line, seenKeys, report); err != nil {
+				report.Errors = append(report.Errors, ImportError{Line: line, Error: err.Error()})
+			}
+		case KindSession:
+			if rec.Session == nil || rec.Session.ContentSessionID == "" {
+				report.Errors = append(report.Errors, ImportError{Line: line, Error: "session record without content_session_id"})
+				continue
+			}
+			if err := importSession(database, rec.Session, opts, report); err != nil {
+				report.Errors = append(report.Errors, ImportError{Line: line, Error: err.Error()})
+			}
+		case KindHeader:
+			report.Errors = append(report.Errors, ImportError{Line: line, Error: "unexpected second header"})
+		default:
+			report.Errors = append(report.Errors, ImportError{Line: line, Error: fmt.Sprintf("unknown record kind %q", rec.Kind)})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return report, fmt.Errorf("read import: %w", err)
+	}
+	if report.SchemaVersion == 0 {
+		return nil, fmt.Errorf("empty import: no header record")
+	}
+	return report, nil
+}
+
+func importEvent(database *db.DB, pe *PortableEvent, opts ImportOptions, line int, seenKeys map[string]bool, report *ImportReport) error {
+	if strings.TrimSpace(pe.Text) == "" {
+		return fmt.Errorf("event has no text")
+	}
+	if !opts.Filter.matches(pe) {
+		report.Events.Filtered++
+		return nil
+	}
+	e := fromPortableEvent(pe)
+
+	if e.DedupeKey != nil && *e.DedupeKey != "" {
+		key := *e.DedupeKey
+		existing, err := database.FindEventByDedupeKey(key)
+		if err != nil {
+			return err
+		}
+		switch {
+		case existing != nil && sameContent(*existing, e):
+			report.Events.Duplicates++
+			return nil
+		case existing != nil:
+			report.Conflicts = append(report.Conflicts, ImportConflict{
+				Line: line, DedupeKey: key, ExistingID: existing.ID,
+				Reason: "dedupe_key exists with different content; kept local event",
+			})
+			return nil
+		case seenKeys[key]:
+			// Only reachable in a dry run, where earlier lines were not written.
+			report.Conflicts = append(report.Conflicts, ImportConflict{
+				Line: line, DedupeKey: key, Reason: "dedupe_key repeated within the import file",
+			})
+			return nil
+		}
+		seenKeys[key] = true
+	} else {
+		id, err := database.FindIdenticalEvent(e)
+		if err != nil {
+			return err
+		}
+		if id != 0 {
+			report.Events.Duplicates++
+			return nil
+		}
+	}
+
+	if !opts.DryRun {
+		if _, err := database.ImportEvent(e); err != nil {
+			return err
+		}
+	}
+	report.Events.Imported++
+	return nil
+}
+
+func importSession(database *db.DB, ps *PortableSession, opts ImportOptions, report *ImportReport) error {
+	if opts.Filter.Project != "" && ps.Project != opts.Filter.Project {
+		report.Sessions.Filtered++
+		return nil
+	}
+	if opts.DryRun {
+		existing, err := database.GetSessionsByContentIDs([]string{ps.ContentSessionID})
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			report.Sessions.Duplicates++
+		} else {
+			report.Sessions.Imported++
+		}
+		return nil
+	}
+	created, err := database.ImportSession(db.Session{
+		ContentSessionID: ps.ContentSessionID,
+		Project:          ps.Project,
+		InitialPrompt:    ps.InitialPrompt,
+		StartedAt:        ps.StartedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if created {
+		report.Sessions.Imported++
+	} else {
+		report.Sessions.Duplicates++
+	}
+	return nil
+}
+
+// sameContent reports whether two events carry the same memory, ignoring
+// local bookkeeping such as IDs and importance decay.
+func sameContent(a, b db.Event) bool {
+	return a.Scope == b.Scope && a.Type == b.Type && a.Title == b.Title && a.Text == b.Text &&
+		reflect.DeepEqual(normTags(a.Tags), normTags(b.Tags))
+}
+
+func normTags(tags []string) []string {
+	out := append([]string{}, tags...)
+	sort.Strings(out)
+	return out
+}
+
+func toPortableEvent(e db.Event) *PortableEvent {
+	return &PortableEvent{
+		SourceID:   e.ID,
+		Ts:         e.Ts,
+		Actor:      e.Actor,
+		Scope:      e.Scope,
+		Type:       e.Type,
+		Title:      e.Title,
+		Text:       e.Text,
+		Tags:       e.Tags,
+		Refs:       e.Refs,
+		TTL:        e.TTL,
+		Importance: e.Importance,
+		DedupeKey:  e.DedupeKey,
+		Project:    e.Project,
+		SessionID:  e.SessionID,
+		CreatedMs:  e.CreatedMs,
+	}
+}
+
+func fromPortableEvent(pe *PortableEvent) db.Event {
+	e := db.Event{
+		Ts:         pe.Ts,
+		Actor:      pe.Actor,
+		Scope:      pe.Scope,
+		Type:       pe.Type,
+		Title:      pe.Title,
+		Text:       pe.Text,
+		Tags:       pe.Tags,
+		Refs:       pe.Refs,
+		TTL:        pe.TTL,
+		Importance: pe.Importance,
+		DedupeKey:  pe.DedupeKey,
+		Project:    pe.Project,
+		SessionID:  pe.SessionID,
+		CreatedMs:  pe.CreatedMs,
+	}
+	if e.Actor == "" {
+		e.Actor = "agent"
+	}
+	if e.Scope == "" {
+		e.Scope = "repo"
+	}
+	if e.Type == "" {
+		e.Type = "discovery"
+	}
+	if e.Importance == 0 {
+		e.Importance = 0.5
+	}
+	return e
+}
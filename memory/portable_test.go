@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+func openDB(t *testing.T) *db.DB {
+	t.Helper()
+	database, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	src := openDB(t)
+	project, session, key := "api", "sess-1", "adr-7"
+	prompt := "add auth"
+	if _, err := src.SaveSession(session, project, &prompt); err != nil {
+		t.Fatal(err)
+	}
+	save(t, src, db.SaveEventInput{Scope: "global", Type: "decision", Title: "Use Postgres", Text: "We use Postgres 16", Tags: []string{"db"}, Refs: map[string]any{"adr": "7"}, DedupeKey: &key, Project: &project, SessionID: &session, Importance: 0.9})
+	save(t, src, db.SaveEventInput{Scope: "repo", Text: "local note", Project: &project})
+
+	var buf bytes.Buffer
+	sum, err := Export(src, &buf, Filter{Scope: "global"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Events != 1 || sum.Sessions != 1 {
+		t.Fatalf("export summary = %+v", sum)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], `"schema_version":1`) {
+		t.Fatalf("export = %s", buf.String())
+	}
+
+	dst := openDB(t)
+	report, err := Import(dst, bytes.NewReader(buf.Bytes()), ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Events.Imported != 1 || report.Sessions.Imported != 1 || len(report.Errors) != 0 {
+		t.Fatalf("import report = %+v", report)
+	}
+	got, _ := dst.FindEventByDedupeKey(key)
+	if got == nil || got.Title != "Use Postgres" || got.Importance != 0.9 || got.Refs["adr"] != "7" || *got.SessionID != session {
+		t.Errorf("imported event = %+v", got)
+	}
+	orig, _ := src.FindEventByDedupeKey(key)
+	if got.Ts != orig.Ts || got.CreatedMs != orig.CreatedMs {
+		t.Errorf("timestamps not preserved: %s/%d vs %s/%d", got.Ts, got.CreatedMs, orig.Ts, orig.CreatedMs)
+	}
+
+	// Importing the same file again changes nothing.
+	again, err := Import(dst, bytes.NewReader(buf.Bytes()), ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Events.Imported != 0 || again.Events.Duplicates != 1 || again.Sessions.Duplicates != 1 {
+		t.Errorf("re-import report = %+v", again)
+	}
+}
+
+func TestImport_ConflictsAndFilters(t *testing.T) {
+	dst := openDB(t)
+	key := "k1"
+	local := save(t, dst, db.SaveEventInput{Text: "local version", DedupeKey: &key})
+
+	input := strings.Join([]string{
+		`{"kind":"header","schema_version":1}`,
+		`{"kind":"event","event":{"ts":"2026-01-01T00:00:00.000Z","scope":"repo","type":"discovery","text":"remote version","dedupe_key":"k1"}}`,
+		`{"kind":"event","event":{"ts":"2026-01-02T00:00:00.000Z","scope":"global","type":"decision","text":"shared"}}`,
+		`{"kind":"event","event":{"ts":"2026-01-03T00:00:00.000Z","scope":"repo","type":"discovery","text":"repo only"}}`,
+		`{"kind":"event","event":{"ts":"2026-01-04T00:00:00.000Z","scope":"global","text":""}}`,
+		`not json`,
+	}, "\n")
+
+	dry, err := Import(dst, strings.NewReader(input), ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dry.Events.Imported != 2 || len(dry.Conflicts) != 1 || len(dry.Errors) != 2 {
+		t.Fatalf("dry run = %+v", dry)
+	}
+	if c := dry.Conflicts[0]; c.Line != 2 || c.ExistingID != local {
+		t.Errorf("conflict = %+v", c)
+	}
+	if events, _ := dst.SearchEvents(db.SearchEventsInput{}); len(events) != 1 {
+		t.Errorf("dry run wrote %d events", len(events)-1)
+	}
+
+	report, err := Import(dst, strings.NewReader(input), ImportOptions{Filter: Filter{Scope: "global"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Events.Imported != 1 || report.Events.Filtered != 2 {
+		t.Errorf("filtered import = %+v", report)
+	}
+	if e, _ := dst.FindEventByDedupeKey(key); e.Text != "local version" {
+		t.Errorf("conflict overwrote local event: %q", e.Text)
+	}
+}
+
+func TestImport_RejectsUnknownSchema(t *testing.T) {
+	dst := openDB(t)
+	for _, input := range []string{
+		`{"kind":"header","schema_version":99}`,
+		`{"kind":"event","event":{"text":"x"}}`,
+		``,
+	} {
+		if _, err := Import(dst, strings.NewReader(input), ImportOptions{}); err == nil {
+			t.Errorf("Import(%q) succeeded", input)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package memory
+
+import "errors"
+
+// ErrRunning is returned when a pass is requested while another is running.
+var ErrRunning = errors.New("memory consolidation: already running")
+
+// Report describes what a consolidation pass did, or would do when DryRun.
+type Report struct {
+	DryRun      bool           `json:"dry_run"`
+	GeneratedAt string         `json:"generated_at"`
+	Summary     ReportSummary  `json:"summary"`
+	Expired     []ExpiredEvent `json:"expired"`
+	Clusters    []Cluster      `json:"clusters"`
+	Decay       []DecayChange  `json:"decay"`
+	Errors      []string       `json:"errors,omitempty"`
+}
+
+// ReportSummary counts the changes in a Report.
+type ReportSummary struct {
+	Expired  int `json:"expired"`
+	Clusters int `json:"clusters"`
+	// EventsConsolidated counts originals archived into a consolidated memory.
+	EventsConsolidated int `json:"events_consolidated"`
+	Decayed            int `json:"decayed"`
+	Archived           int `json:"archived"`
+}
+
+// ExpiredEvent is an event purged because its TTL has passed.
+type ExpiredEvent struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	TTL   string `json:"ttl"`
+}
+
+// Cluster is a group of similar events from one project and scope.
+type Cluster struct {
+	Project  string   `json:"project"`
+	Scope    string   `json:"scope"`
+	EventIDs []int64  `json:"event_ids"`
+	Titles   []string `json:"titles"`
+	// Similarity is the lowest similarity between the seed event and a member.
+	Similarity     float64 `json:"similarity"`
+	ConsolidatedID int64   `json:"consolidated_id,omitempty"`
+	Skipped        string  `json:"skipped,omitempty"`
+}
+
+// DecayChange is one importance update. Archive is set when the new
+// importance falls below the archive threshold.
+type DecayChange struct {
+	ID      int64   `json:"id"`
+	Title   string  `json:"title"`
+	From    float64 `json:"from"`
+	To      float64 `json:"to"`
+	Archive bool    `json:"archive"`
+}
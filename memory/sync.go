@@ -0,0 +1,784 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+	"github.com/MartinNevlaha/stratus-v2/internal/scheduler"
+)
+
+// Kinds of items shared by team sync.
+const (
+	SyncKindEvent    = "event"
+	SyncKindDoc      = "doc"
+	SyncKindProposal = "proposal"
+)
+
+const (
+	defaultSyncInterval = 5 * time.Minute
+	syncPageSize        = 500
+)
+
+// ErrSyncRunning is returned when a sync is requested while another runs.
+var ErrSyncRunning = errors.New("team sync: already running")
+
+// SyncItem is one versioned item exchanged between instances. Hash covers
+// the payload's shared content; Version and Writer decide which of two
+// different payloads wins.
+type SyncItem struct {
+	Kind    string          `json:"kind"`
+	UID     string          `json:"uid"`
+	Version int64           `json:"version"`
+	Writer  string          `json:"writer"`
+	Hash    string          `json:"hash"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// SyncDoc is a governance doc as shared between instances: all chunks of one
+// file, addressed by project directory name and slash-separated path.
+type SyncDoc struct {
+	Project  string         `json:"project"`
+	Path     string         `json:"path"`
+	DocType  string         `json:"doc_type"`
+	FileHash string         `json:"file_hash"`
+	Chunks   []SyncDocChunk `json:"chunks"`
+}
+
+// SyncDocChunk is one section of a SyncDoc.
+type SyncDocChunk struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// SyncConflict records an item both sides changed. Winner is "local" or
+// "remote".
+type SyncConflict struct {
+	Kind         string `json:"kind"`
+	UID          string `json:"uid"`
+	LocalWriter  string `json:"local_writer"`
+	RemoteWriter string `json:"remote_writer"`
+	Winner       string `json:"winner"`
+}
+
+// MergeResult counts what happened to received items.
+type MergeResult struct {
+	Applied int `json:"applied"`
+	// Unchanged items carried content this instance already has.
+	Unchanged int `json:"unchanged"`
+	// Stale items lost to a newer local version.
+	Stale     int            `json:"stale"`
+	Filtered  int            `json:"filtered"`
+	Conflicts []SyncConflict `json:"conflicts,omitempty"`
+	Errors    []string       `json:"errors,omitempty"`
+}
+
+func (m *MergeResult) add(o MergeResult) {
+	m.Applied += o.Applied
+	m.Unchanged += o.Unchanged
+	m.Stale += o.Stale
+	m.Filtered += o.Filtered
+	m.Conflicts = append(m.Conflicts, o.Conflicts...)
+	m.Errors = append(m.Errors, o.Errors...)
+}
+
+// SyncReport describes one sync round.
+type SyncReport struct {
+	Instance   string      `json:"instance"`
+	Transport  string      `json:"transport,omitempty"`
+	StartedAt  string      `json:"started_at"`
+	DurationMs int64       `json:"duration_ms"`
+	Published  int         `json:"published"`
+	Pulled     int         `json:"pulled"`
+	Pushed     int         `json:"pushed"`
+	Merge      MergeResult `json:"merge"`
+	Errors     []string    `json:"errors,omitempty"`
+}
+
+// SyncStatus is the state reported by the sync status endpoint.
+type SyncStatus struct {
+	Instance  string             `json:"instance"`
+	Mode      string             `json:"mode"`
+	Transport string             `json:"transport,omitempty"`
+	Serving   bool               `json:"serving"`
+	Journal   []db.SyncKindCount `json:"journal"`
+	LastRun   *SyncReport        `json:"last_run,omitempty"`
+}
+
+// Syncer shares global and repo memory, governance docs and approved
+// proposals with other Stratus instances. Every shared item lives in the
+// sync journal with a last-writer-wins version; local changes are published
+// into the journal, pushed to the transport, and peers' entries pulled back
+// and applied when they are newer.
+type Syncer struct {
+	db       *db.DB
+	cfg      func() config.TeamSyncConfig
+	now      func() time.Time
+	instance string
+
+	// journalMu serializes journal writes from publishing, pulls and hub
+	// pushes.
+	journalMu sync.Mutex
+
+	mu        sync.Mutex
+	transport Transport
+	running   bool
+	last      *SyncReport
+}
+
+// NewSyncer creates a Syncer. cfgFn is read on every round so config
+// changes apply without a restart.
+func NewSyncer(database *db.DB, cfgFn func() config.TeamSyncConfig) (*Syncer, error) {
+	id, err := database.SyncInstanceID()
+	if err != nil {
+		return nil, err
+	}
+	return &Syncer{db: database, cfg: cfgFn, now: time.Now, instance: id}, nil
+}
+
+// InstanceID returns the ID this instance writes journal entries as.
+func (s *Syncer) InstanceID() string { return s.instance }
+
+// SetTransport sets how entries reach peers. Without one the syncer only
+// publishes into its journal, which is enough for a hub.
+func (s *Syncer) SetTransport(t Transport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transport = t
+}
+
+// Serving reports whether this instance accepts pushes and pulls from peers.
+func (s *Syncer) Serving() bool { return s.cfg().Serve }
+
+// Authorized checks an Authorization header against the configured token.
+func (s *Syncer) Authorized(header string) bool {
+	token := s.cfg().Token
+	if token == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(header), []byte("Bearer "+token)) == 1
+}
+
+// Status returns the instance identity, journal counts and the last round.
+func (s *Syncer) Status() (*SyncStatus, error) {
+	counts, err := s.db.SyncJournalCounts(s.instance)
+	if err != nil {
+		return nil, err
+	}
+	cfg := s.cfg()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := &SyncStatus{Instance: s.instance, Mode: cfg.Mode, Serving: cfg.Serve, Journal: counts, LastRun: s.last}
+	if s.transport != nil {
+		st.Transport = s.transport.Name()
+	}
+	if st.Journal == nil {
+		st.Journal = []db.SyncKindCount{}
+	}
+	return st, nil
+}
+
+// Run syncs once and then every interval_minutes until ctx is cancelled. It
+// returns immediately when team sync is disabled.
+func (s *Syncer) Run(ctx context.Context) {
+	if !s.cfg().Enabled {
+		log.Println("sync: team sync disabled, not starting")
+		return
+	}
+	intervalFn := func() time.Duration {
+		d := time.Duration(s.cfg().IntervalMinutes) * time.Minute
+		if d <= 0 {
+			return defaultSyncInterval
+		}
+		return d
+	}
+	tick := func(ctx context.Context) {
+		if !s.cfg().Enabled {
+			return
+		}
+		report, err := s.Sync(ctx)
+		if err != nil {
+			log.Printf("sync: %v", err)
+			return
+		}
+		for _, e := range report.Errors {
+			log.Printf("sync: %s", e)
+		}
+		if report.Published+report.Pulled+report.Pushed > 0 {
+			log.Printf("sync: published=%d pulled=%d applied=%d pushed=%d conflicts=%d",
+				report.Published, report.Pulled, report.Merge.Applied, report.Pushed, len(report.Merge.Conflicts))
+		}
+	}
+	tick(ctx)
+	if err := scheduler.New("team-sync", intervalFn, tick).Run(ctx); err != nil && err != context.Canceled {
+		log.Printf("sync: scheduler stopped: %v", err)
+	}
+}
+
+// Sync runs one round: publish local changes, pull and apply peers'
+// entries, then push this instance's own entries. Transport failures are
+// recorded in the report rather than returned.
+func (s *Syncer) Sync(ctx context.Context) (*SyncReport, error) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return nil, ErrSyncRunning
+	}
+	s.running = true
+	t := s.transport
+	s.mu.Unlock()
+
+	start := s.now()
+	report := &SyncReport{Instance: s.instance, StartedAt: start.UTC().Format(time.RFC3339)}
+	defer func() {
+		report.DurationMs = s.now().Sub(start).Milliseconds()
+		s.mu.Lock()
+		s.running = false
+		s.last = report
+		s.mu.Unlock()
+	}()
+
+	published, err := s.Publish()
+	if err != nil {
+		return nil, err
+	}
+	report.Published = published
+	if t == nil {
+		return report, nil
+	}
+	report.Transport = t.Name()
+	if err := s.pull(ctx, t, report); err != nil {
+		report.Errors = append(report.Errors, "pull: "+err.Error())
+	}
+	if err := s.push(ctx, t, report); err != nil {
+		report.Errors = append(report.Errors, "push: "+err.Error())
+	}
+	return report, nil
+}
+
+func (s *Syncer) pull(ctx context.Context, t Transport, report *SyncReport) error {
+	key := "pull:" + t.Name()
+	cursor, err := s.db.GetSyncMeta(key)
+	if err != nil {
+		return err
+	}
+	for {
+		items, next, err := t.Pull(ctx, cursor)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		var theirs []SyncItem
+		for _, it := range items {
+			if it.Writer != s.instance {
+				theirs = append(theirs, it)
+			}
+		}
+		report.Pulled += len(theirs)
+		report.Merge.add(s.Merge(theirs))
+		if err := s.db.SetSyncMeta(key, next); err != nil {
+			return err
+		}
+		if next == cursor {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+func (s *Syncer) push(ctx context.Context, t Transport, report *SyncReport) error {
+	key := "push:" + t.Name()
+	raw, err := s.db.GetSyncMeta(key)
+	if err != nil {
+		return err
+	}
+	after, _ := strconv.ParseInt(raw, 10, 64)
+	for {
+		entries, err := s.db.ListSyncEntries(after, s.instance, syncPageSize)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		items := make([]SyncItem, len(entries))
+		for i, e := range entries {
+			items[i] = itemFromEntry(e)
+		}
+		if err := t.Push(ctx, s.instance, items); err != nil {
+			return err
+		}
+		report.Pushed += len(items)
+		after = entries[len(entries)-1].Seq
+		if err := s.db.SetSyncMeta(key, strconv.FormatInt(after, 10)); err != nil {
+			return err
+		}
+	}
+}
+
+// Changes returns up to limit journal entries after seq, for peers pulling
+// from this instance, and the seq to continue from.
+func (s *Syncer) Changes(since int64, limit int) ([]SyncItem, int64, error) {
+	entries, err := s.db.ListSyncEntries(since, "", limit)
+	if err != nil {
+		return nil, since, err
+	}
+	items := make([]SyncItem, len(entries))
+	for i, e := range entries {
+		items[i] = itemFromEntry(e)
+		since = e.Seq
+	}
+	return items, since, nil
+}
+
+func itemFromEntry(e db.SyncEntry) SyncItem {
+	return SyncItem{Kind: e.Kind, UID: e.UID, Version: e.Version, Writer: e.Writer, Hash: e.Hash, Payload: e.Payload}
+}
+
+// newer reports whether version a written by aw supersedes b written by bw.
+func newer(a int64, aw string, b int64, bw string) bool {
+	if a != b {
+		return a > b
+	}
+	return aw > bw
+}
+
+// ---------------------------------------------------------------------------
+// Publishing local changes
+// ---------------------------------------------------------------------------
+
+// Publish records local changes to shared items in the journal. An item is
+// re-published only when its local content differs from what was last
+// published or applied, so content received from peers is not echoed back.
+func (s *Syncer) Publish() (int, error) {
+	s.journalMu.Lock()
+	defer s.journalMu.Unlock()
+	cfg := s.cfg()
+	n := 0
+	for _, scope := range cfg.Scopes {
+		var after int64
+		for {
+			events, err := s.db.ListEventsAfter(db.EventFilter{Scope: scope}, after, syncPageSize)
+			if err != nil {
+				return n, err
+			}
+			for _, e := range events {
+				ok, err := s.publishEvent(e)
+				if err != nil {
+					return n, err
+				}
+				if ok {
+					n++
+				}
+				after = e.ID
+			}
+			if len(events) < syncPageSize {
+				break
+			}
+		}
+	}
+	if cfg.Docs {
+		docs, err := s.db.ListLocalDocs()
+		if err != nil {
+			return n, err
+		}
+		for _, d := range groupDocs(docs) {
+			ok, err := s.publish(SyncKindDoc, d.Project+"/"+d.Path, d.localPath, d.SyncDoc)
+			if err != nil {
+				return n, err
+			}
+			if ok {
+				n++
+			}
+		}
+		// A removed file is published as a doc without chunks so peers drop
+		// their copy. Files that were never shared need no tombstone.
+		tombstones, err := s.db.ListDocTombstones()
+		if err != nil {
+			return n, err
+		}
+		for _, d := range tombstoneDocs(tombstones) {
+			uid := d.Project + "/" + d.Path
+			if entry, err := s.db.GetSyncEntry(SyncKindDoc, uid); err != nil {
+				return n, err
+			} else if entry == nil {
+				continue
+			}
+			ok, err := s.publish(SyncKindDoc, uid, d.localPath, d.SyncDoc)
+			if err != nil {
+				return n, err
+			}
+			if ok {
+				n++
+			}
+		}
+	}
+	if cfg.Proposals {
+		proposals, err := s.db.ListInsightProposals("", string(db.ProposalStatusApproved), "", 0, 10000, 0)
+		if err != nil {
+			return n, err
+		}
+		for _, p := range proposals {
+			ok, err := s.publish(SyncKindProposal, p.ID, p.ID, p)
+			if err != nil {
+				return n, err
+			}
+			if ok {
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+func (s *Syncer) publishEvent(e db.Event) (bool, error) {
+	localID := strconv.FormatInt(e.ID, 10)
+	uid := s.instance + "/" + localID
+	if e.DedupeKey != nil && *e.DedupeKey != "" {
+		// Events saved under the same dedupe key are the same memory on
+		// every instance.
+		uid = "key:" + *e.DedupeKey
+	}
+	if entry, err := s.db.GetSyncEntryByLocalID(SyncKindEvent, localID); err != nil {
+		return false, err
+	} else if entry != nil {
+		uid = entry.UID
+	}
+	return s.publish(SyncKindEvent, uid, localID, syncEvent(e))
+}
+
+func (s *Syncer) publish(kind, uid, localID string, payload any) (bool, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return false, err
+	}
+	hash, err := payloadHash(kind, raw)
+	if err != nil {
+		return false, err
+	}
+	existing, err := s.db.GetSyncEntry(kind, uid)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil && existing.LocalHash == hash {
+		return false, nil
+	}
+	version := s.now().UnixMilli()
+	if existing != nil && version <= existing.Version {
+		version = existing.Version + 1
+	}
+	_, err = s.db.PutSyncEntry(db.SyncEntry{
+		Kind: kind, UID: uid, Version: version, Writer: s.instance,
+		Hash: hash, LocalHash: hash, LocalID: localID, Payload: raw,
+	})
+	return err == nil, err
+}
+
+// syncEvent converts e to its shared form. Session IDs stay local because
+// sessions are not synced.
+func syncEvent(e db.Event) *PortableEvent {
+	pe := toPortableEvent(e)
+	pe.SourceID = 0
+	pe.SessionID = nil
+	return pe
+}
+
+type localDoc struct {
+	SyncDoc
+	localPath string
+}
+
+// groupDocs joins doc chunks into one SyncDoc per file. Paths are made
+// relative to the project root so the same repo matches across machines.
+func groupDocs(chunks []db.Doc) []localDoc {
+	var out []localDoc
+	for _, c := range chunks {
+		if len(out) == 0 || out[len(out)-1].localPath != c.FilePath {
+			rel, err := filepath.Rel(c.Project, c.FilePath)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				rel = filepath.Base(c.FilePath)
+			}
+			out = append(out, localDoc{
+				SyncDoc: SyncDoc{
+					Project:  filepath.Base(c.Project),
+					Path:     filepath.ToSlash(rel),
+					DocType:  c.DocType,
+					FileHash: c.FileHash,
+				},
+				localPath: c.FilePath,
+			})
+		}
+		d := &out[len(out)-1]
+		d.Chunks = append(d.Chunks, SyncDocChunk{Title: c.Title, Content: c.Content})
+	}
+	return out
+}
+
+// tombstoneDocs addresses removed files the same way groupDocs addresses
+// indexed ones.
+func tombstoneDocs(tombstones []db.DocTombstone) []localDoc {
+	out := make([]localDoc, 0, len(tombstones))
+	for _, t := range tombstones {
+		rel, err := filepath.Rel(t.Project, t.FilePath)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			rel = filepath.Base(t.FilePath)
+		}
+		out = append(out, localDoc{
+			SyncDoc: SyncDoc{
+				Project: filepath.Base(t.Project),
+				Path:    filepath.ToSlash(rel),
+				DocType: t.DocType,
+			},
+			localPath: t.FilePath,
+		})
+	}
+	return out
+}
+
+// payloadHash hashes the shared content of a payload. Event importance is
+// left out: it decays independently on every instance and must not cause
+// re-publishing.
+func payloadHash(kind string, raw json.RawMessage) (string, error) {
+	var v any
+	switch kind {
+	case SyncKindEvent:
+		var pe PortableEvent
+		if err := json.Unmarshal(raw, &pe); err != nil {
+			return "", err
+		}
+		pe.Importance = 0
+		v = pe
+	case SyncKindDoc:
+		var d SyncDoc
+		if err := json.Unmarshal(raw, &d); err != nil {
+			return "", err
+		}
+		v = d
+	case SyncKindProposal:
+		var p db.InsightProposal
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return "", err
+		}
+		v = p
+	default:
+		return "", fmt.Errorf("unknown sync kind %q", kind)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(kind+"\n"), b...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ---------------------------------------------------------------------------
+// Applying peers' entries
+// ---------------------------------------------------------------------------
+
+// Merge applies items received from peers. An item replaces the local
+// version when its (version, writer) is greater; items both sides changed
+// are reported as conflicts whichever side wins.
+func (s *Syncer) Merge(items []SyncItem) MergeResult {
+	s.journalMu.Lock()
+	defer s.journalMu.Unlock()
+	cfg := s.cfg()
+	var res MergeResult
+	for _, it := range items {
+		if err := s.mergeItem(cfg, it, &res); err != nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("%s %s: %v", it.Kind, it.UID, err))
+		}
+	}
+	return res
+}
+
+func (s *Syncer) mergeItem(cfg config.TeamSyncConfig, it SyncItem, res *MergeResult) error {
+	hash, err := payloadHash(it.Kind, it.Payload)
+	if err != nil {
+		return err
+	}
+	if hash != it.Hash {
+		return errors.New("payload does not match its hash")
+	}
+	if !s.shares(cfg, it) {
+		res.Filtered++
+		return nil
+	}
+
+	local, err := s.db.GetSyncEntry(it.Kind, it.UID)
+	if err != nil {
+		return err
+	}
+	entry := db.SyncEntry{Kind: it.Kind, UID: it.UID, Version: it.Version, Writer: it.Writer, Hash: it.Hash, Payload: it.Payload}
+	if local != nil {
+		entry.LocalHash, entry.LocalID = local.LocalHash, local.LocalID
+		remoteWins := newer(it.Version, it.Writer, local.Version, local.Writer)
+		if local.Hash != it.Hash && local.Writer != it.Writer {
+			c := SyncConflict{Kind: it.Kind, UID: it.UID, LocalWriter: local.Writer, RemoteWriter: it.Writer, Winner: "local"}
+			if remoteWins {
+				c.Winner = "remote"
+			}
+			res.Conflicts = append(res.Conflicts, c)
+		}
+		if !remoteWins {
+			if local.Hash == it.Hash {
+				res.Unchanged++
+			} else {
+				res.Stale++
+			}
+			return nil
+		}
+		if local.Hash == it.Hash {
+			// Same content under a newer version: adopt the version so
+			// every instance converges on one journal entry.
+			if _, err := s.db.PutSyncEntry(entry); err != nil {
+				return err
+			}
+			res.Unchanged++
+			return nil
+		}
+	}
+
+	switch it.Kind {
+	case SyncKindEvent:
+		err = s.applyEvent(&entry)
+	case SyncKindDoc:
+		err = s.applyDoc(&entry)
+	case SyncKindProposal:
+		err = s.applyProposal(&entry)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.PutSyncEntry(entry); err != nil {
+		return err
+	}
+	res.Applied++
+	return nil
+}
+
+// shares reports whether this instance's config accepts the item.
+func (s *Syncer) shares(cfg config.TeamSyncConfig, it SyncItem) bool {
+	switch it.Kind {
+	case SyncKindEvent:
+		var pe PortableEvent
+		if json.Unmarshal(it.Payload, &pe) != nil {
+			return false
+		}
+		for _, scope := range cfg.Scopes {
+			if pe.Scope == scope {
+				return true
+			}
+		}
+		return false
+	case SyncKindDoc:
+		return cfg.Docs
+	case SyncKindProposal:
+		return cfg.Proposals
+	}
+	return false
+}
+
+// applyEvent writes a received event into the local store: over the row it
+// was applied to before, over a local event with the same dedupe key, or as
+// a new event. The local row's hash is recorded so it is not re-published.
+func (s *Syncer) applyEvent(entry *db.SyncEntry) error {
+	var pe PortableEvent
+	if err := json.Unmarshal(entry.Payload, &pe); err != nil {
+		return err
+	}
+	e := fromPortableEvent(&pe)
+
+	id, _ := strconv.ParseInt(entry.LocalID, 10, 64)
+	if id == 0 && e.DedupeKey != nil {
+		existing, err := s.db.FindEventByDedupeKey(*e.DedupeKey)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			id = existing.ID
+		}
+	}
+	if id != 0 {
+		found, err := s.db.UpdateSyncedEvent(id, e)
+		if err != nil {
+			return err
+		}
+		if !found {
+			id = 0
+		}
+	}
+	if id == 0 {
+		var err error
+		if id, err = s.db.ImportEvent(e); err != nil {
+			return err
+		}
+	}
+
+	events, err := s.db.GetEventsByIDs([]int64{id})
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("event %d vanished after apply", id)
+	}
+	raw, _ := json.Marshal(syncEvent(events[0]))
+	entry.LocalID = strconv.FormatInt(id, 10)
+	entry.LocalHash, err = payloadHash(SyncKindEvent, raw)
+	return err
+}
+
+// applyDoc stores a received doc under the team:// namespace so it never
+// overwrites locally indexed files. When this instance has the same file
+// with the same content, any earlier team copy is dropped instead.
+func (s *Syncer) applyDoc(entry *db.SyncEntry) error {
+	var d SyncDoc
+	if err := json.Unmarshal(entry.Payload, &d); err != nil {
+		return err
+	}
+	path := db.SyncedDocPrefix + entry.UID
+	var chunks []db.Doc
+	if entry.LocalHash != entry.Hash {
+		for _, c := range d.Chunks {
+			chunks = append(chunks, db.Doc{Title: c.Title, Content: c.Content})
+		}
+	}
+	return s.db.ReplaceDocChunks(path, "team:"+d.Project, d.DocType, d.FileHash, chunks)
+}
+
+func (s *Syncer) applyProposal(entry *db.SyncEntry) error {
+	var p db.InsightProposal
+	if err := json.Unmarshal(entry.Payload, &p); err != nil {
+		return err
+	}
+	if p.ID == "" {
+		p.ID = entry.UID
+	}
+	if err := s.db.UpsertInsightProposal(&p); err != nil {
+		return err
+	}
+	stored, err := s.db.GetInsightProposalByID(p.ID)
+	if err != nil {
+		return err
+	}
+	if stored == nil {
+		return fmt.Errorf("proposal %s vanished after apply", p.ID)
+	}
+	raw, _ := json.Marshal(stored)
+	entry.LocalID = p.ID
+	entry.LocalHash, err = payloadHash(SyncKindProposal, raw)
+	return err
+}
@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+// newTestSyncer returns a syncer over a fresh database sharing dir, with a
+// clock the test advances by hand.
+func newTestSyncer(t *testing.T, dir string, clock *time.Time) (*Syncer, *db.DB) {
+	t.Helper()
+	database := openDB(t)
+	cfg := config.Default().TeamSync
+	cfg.Enabled = true
+	s, err := NewSyncer(database, func() config.TeamSyncConfig { return cfg })
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = func() time.Time { return *clock }
+	s.SetTransport(NewDirTransport(dir, s.InstanceID(), false))
+	return s, database
+}
+
+func syncOnce(t *testing.T, s *Syncer) *SyncReport {
+	t.Helper()
+	report, err := s.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) > 0 || len(report.Merge.Errors) > 0 {
+		t.Fatalf("sync errors: %v %v", report.Errors, report.Merge.Errors)
+	}
+	return report
+}
+
+func TestSync_DirTransportBetweenTwoInstances(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	a, dbA := newTestSyncer(t, dir, &clock)
+	b, dbB := newTestSyncer(t, dir, &clock)
+
+	key := "adr-12"
+	save(t, dbA, db.SaveEventInput{Scope: "global", Type: "decision", Text: "Use Postgres 16", DedupeKey: &key})
+	save(t, dbA, db.SaveEventInput{Scope: "repo", Text: "Integration tests need docker"})
+	save(t, dbA, db.SaveEventInput{Scope: "user", Text: "I prefer tabs"})
+	if err := dbA.SaveInsightProposal(&db.InsightProposal{ID: "p-1", Type: "rule", Status: "approved", Title: "Lint SQL", Description: "Add sqlfluff", Confidence: 0.8, RiskLevel: "low", SourcePatternID: "pat"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbA.SaveInsightProposal(&db.InsightProposal{ID: "p-2", Type: "rule", Status: "detected", Title: "Draft", Description: "not yet", Confidence: 0.5, RiskLevel: "low", SourcePatternID: "pat"}); err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(t.TempDir(), "shop")
+	if err := os.MkdirAll(filepath.Join(root, "docs", "decisions"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs", "decisions", "001-db.md"), []byte("# ADR 1\n\nPostgres everywhere."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbA.IndexGovernance(root); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := syncOnce(t, a); r.Published != 4 || r.Pushed != 4 {
+		t.Fatalf("first sync on A = %+v", r)
+	}
+	if r := syncOnce(t, b); r.Pulled != 4 || r.Merge.Applied != 4 {
+		t.Fatalf("first sync on B = %+v", r)
+	}
+
+	events, _ := dbB.SearchEvents(db.SearchEventsInput{})
+	if len(events) != 2 {
+		t.Fatalf("B has %d events, want global and repo only", len(events))
+	}
+	if p, _ := dbB.GetInsightProposalByID("p-1"); p == nil || p.Status != "approved" {
+		t.Errorf("approved proposal not synced: %+v", p)
+	}
+	if p, _ := dbB.GetInsightProposalByID("p-2"); p != nil {
+		t.Error("unapproved proposal was synced")
+	}
+	docs, _ := dbB.SearchDocs("Postgres", "", "team:shop", 5)
+	if len(docs) != 1 || docs[0].FilePath != db.SyncedDocPrefix+"shop/docs/decisions/001-db.md" {
+		t.Errorf("team docs = %+v", docs)
+	}
+
+	// Applied content is not echoed back, and re-syncing is a no-op.
+	if r := syncOnce(t, b); r.Published != 0 || r.Pushed != 0 || r.Pulled != 0 {
+		t.Errorf("second sync on B = %+v", r)
+	}
+	if r := syncOnce(t, a); r.Published != 0 || r.Pulled != 0 {
+		t.Errorf("second sync on A = %+v", r)
+	}
+}
+
+func TestSync_LastWriterWins(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	a, dbA := newTestSyncer(t, dir, &clock)
+	b, dbB := newTestSyncer(t, dir, &clock)
+
+	key := "ci-runner"
+	idA := save(t, dbA, db.SaveEventInput{Scope: "global", Text: "CI runs on GitHub Actions", DedupeKey: &key})
+	syncOnce(t, a)
+	syncOnce(t, b)
+	shared, _ := dbB.FindEventByDedupeKey(key)
+
+	edit := func(database *db.DB, id int64, text string) {
+		t.Helper()
+		e, _ := database.GetEventsByIDs([]int64{id})
+		e[0].Text = text
+		if _, err := database.UpdateSyncedEvent(id, e[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Both sides edit; B writes later.
+	clock = clock.Add(time.Minute)
+	edit(dbA, idA, "CI runs on Buildkite")
+	syncOnce(t, a)
+	clock = clock.Add(time.Minute)
+	edit(dbB, shared.ID, "CI runs on self-hosted runners")
+
+	rb := syncOnce(t, b)
+	if len(rb.Merge.Conflicts) != 1 || rb.Merge.Conflicts[0].Winner != "local" || rb.Merge.Stale != 1 {
+		t.Fatalf("B merge = %+v", rb.Merge)
+	}
+	ra := syncOnce(t, a)
+	if len(ra.Merge.Conflicts) != 1 || ra.Merge.Conflicts[0].Winner != "remote" || ra.Merge.Applied != 1 {
+		t.Fatalf("A merge = %+v", ra.Merge)
+	}
+	for name, database := range map[string]*db.DB{"A": dbA, "B": dbB} {
+		if e, _ := database.FindEventByDedupeKey(key); e.Text != "CI runs on self-hosted runners" {
+			t.Errorf("%s has %q", name, e.Text)
+		}
+	}
+	if r := syncOnce(t, a); r.Published != 0 {
+		t.Errorf("A re-published the winning version: %+v", r)
+	}
+}
+
+func TestSync_DeletedDocIsRemovedOnPeers(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	a, dbA := newTestSyncer(t, dir, &clock)
+	b, dbB := newTestSyncer(t, dir, &clock)
+
+	root := filepath.Join(t.TempDir(), "shop")
+	adr := filepath.Join(root, "docs", "decisions", "002-queue.md")
+	if err := os.MkdirAll(filepath.Dir(adr), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(adr, []byte("# ADR 2\n\nUse NATS for queues."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbA.IndexGovernance(root); err != nil {
+		t.Fatal(err)
+	}
+	syncOnce(t, a)
+	syncOnce(t, b)
+	if docs, _ := dbB.SearchDocs("NATS", "", "team:shop", 5); len(docs) != 1 {
+		t.Fatalf("doc not synced: %+v", docs)
+	}
+
+	if err := os.Remove(adr); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbA.IndexGovernance(root); err != nil {
+		t.Fatal(err)
+	}
+	clock = clock.Add(time.Minute)
+	if r := syncOnce(t, a); r.Published != 1 {
+		t.Fatalf("tombstone not published: %+v", r)
+	}
+	syncOnce(t, b)
+	if docs, _ := dbB.SearchDocs("NATS", "", "team:shop", 5); len(docs) != 0 {
+		t.Errorf("deleted doc still on B: %+v", docs)
+	}
+	if r := syncOnce(t, a); r.Published != 0 {
+		t.Errorf("tombstone re-published: %+v", r)
+	}
+}
+
+func TestMerge_RejectsTamperedPayload(t *testing.T) {
+	clock := time.Now()
+	s, _ := newTestSyncer(t, t.TempDir(), &clock)
+	res := s.Merge([]SyncItem{{
+		Kind: SyncKindEvent, UID: "x/1", Version: 1, Writer: "x", Hash: "deadbeef",
+		Payload: []byte(`{"scope":"global","text":"hi"}`),
+	}})
+	if len(res.Errors) != 1 || !strings.Contains(res.Errors[0], "hash") {
+		t.Errorf("merge = %+v", res)
+	}
+}
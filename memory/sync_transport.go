@@ -0,0 +1,266 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/config"
+)
+
+// Transport moves journal entries between this instance and its peers.
+// Pull returns entries after cursor and the cursor to continue from; an
+// empty result means the peer has nothing newer.
+type Transport interface {
+	Name() string
+	Push(ctx context.Context, instance string, items []SyncItem) error
+	Pull(ctx context.Context, cursor string) ([]SyncItem, string, error)
+}
+
+// NewTransport builds the transport for cfg.Mode. It returns nil when no
+// mode is set, which is valid for an instance that only serves as a hub.
+func NewTransport(cfg config.TeamSyncConfig, instance string) (Transport, error) {
+	switch cfg.Mode {
+	case "":
+		return nil, nil
+	case "hub":
+		if cfg.HubURL == "" {
+			return nil, fmt.Errorf("team_sync.hub_url is required for mode %q", cfg.Mode)
+		}
+		return NewHubTransport(cfg.HubURL, cfg.Token), nil
+	case "dir":
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("team_sync.dir is required for mode %q", cfg.Mode)
+		}
+		return NewDirTransport(cfg.Dir, instance, cfg.Git), nil
+	default:
+		return nil, fmt.Errorf("unknown team_sync.mode %q (want hub or dir)", cfg.Mode)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Hub: another Stratus instance with team_sync.serve enabled
+// ---------------------------------------------------------------------------
+
+// HubTransport exchanges entries with a hub instance over its sync API.
+type HubTransport struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewHubTransport creates a transport for the hub at baseURL.
+func NewHubTransport(baseURL, token string) *HubTransport {
+	return &HubTransport{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (h *HubTransport) Name() string { return "hub:" + h.baseURL }
+
+// HubPushRequest is the body of POST /api/sync/push.
+type HubPushRequest struct {
+	Instance string     `json:"instance"`
+	Items    []SyncItem `json:"items"`
+}
+
+// HubChanges is the response of GET /api/sync/changes.
+type HubChanges struct {
+	Items []SyncItem `json:"items"`
+	Next  int64      `json:"next"`
+}
+
+func (h *HubTransport) Push(ctx context.Context, instance string, items []SyncItem) error {
+	body, err := json.Marshal(HubPushRequest{Instance: instance, Items: items})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.baseURL+"/api/sync/push", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return h.do(req, nil)
+}
+
+func (h *HubTransport) Pull(ctx context.Context, cursor string) ([]SyncItem, string, error) {
+	since, _ := strconv.ParseInt(cursor, 10, 64)
+	q := neturl.Values{}
+	q.Set("since", strconv.FormatInt(since, 10))
+	q.Set("limit", strconv.Itoa(syncPageSize))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.baseURL+"/api/sync/changes?"+q.Encode(), nil)
+	if err != nil {
+		return nil, cursor, err
+	}
+	var out HubChanges
+	if err := h.do(req, &out); err != nil {
+		return nil, cursor, err
+	}
+	return out.Items, strconv.FormatInt(out.Next, 10), nil
+}
+
+func (h *HubTransport) do(req *http.Request, out any) error {
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: HTTP %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// ---------------------------------------------------------------------------
+// Shared directory, optionally a git checkout
+// ---------------------------------------------------------------------------
+
+// DirTransport exchanges entries through a shared directory. Each instance
+// appends to its own <instance>.jsonl and reads everyone else's, so files
+// never have two writers and git merges stay trivial.
+type DirTransport struct {
+	dir      string
+	instance string
+	git      bool
+}
+
+// NewDirTransport creates a transport over dir. With useGit, dir must be a
+// git checkout; it is pulled before reading and committed and pushed after
+// writing.
+func NewDirTransport(dir, instance string, useGit bool) *DirTransport {
+	return &DirTransport{dir: dir, instance: instance, git: useGit}
+}
+
+func (d *DirTransport) Name() string { return "dir:" + d.dir }
+
+func (d *DirTransport) Push(ctx context.Context, instance string, items []SyncItem) error {
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return err
+	}
+	name := instance + ".jsonl"
+	f, err := os.OpenFile(filepath.Join(d.dir, name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, it := range items {
+		if err := enc.Encode(it); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if !d.git {
+		return nil
+	}
+	if err := d.runGit(ctx, "add", name); err != nil {
+		return err
+	}
+	if err := d.runGit(ctx, "commit", "--quiet", "-m", "stratus sync: "+instance); err != nil {
+		return err
+	}
+	return d.runGit(ctx, "push", "--quiet")
+}
+
+// Pull reads lines appended to other instances' journals since cursor, a
+// JSON map of file name to byte offset. Partially written trailing lines
+// are left for the next pull.
+func (d *DirTransport) Pull(ctx context.Context, cursor string) ([]SyncItem, string, error) {
+	if d.git {
+		if err := d.runGit(ctx, "pull", "--rebase", "--quiet"); err != nil {
+			return nil, cursor, err
+		}
+	}
+	offsets := map[string]int64{}
+	if cursor != "" {
+		if err := json.Unmarshal([]byte(cursor), &offsets); err != nil {
+			return nil, cursor, fmt.Errorf("bad dir cursor: %w", err)
+		}
+	}
+	files, err := filepath.Glob(filepath.Join(d.dir, "*.jsonl"))
+	if err != nil {
+		return nil, cursor, err
+	}
+	sort.Strings(files)
+
+	var items []SyncItem
+	for _, path := range files {
+		name := filepath.Base(path)
+		if name == d.instance+".jsonl" {
+			continue
+		}
+		got, offset, err := readJournal(path, offsets[name])
+		if err != nil {
+			return nil, cursor, fmt.Errorf("%s: %w", name, err)
+		}
+		items = append(items, got...)
+		offsets[name] = offset
+	}
+	next, _ := json.Marshal(offsets)
+	return items, string(next), nil
+}
+
+// readJournal decodes complete lines of path from offset and returns the
+// offset after the last one. Undecodable lines are skipped.
+func readJournal(path string, offset int64) ([]SyncItem, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+	r := bufio.NewReader(f)
+	var items []SyncItem
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return items, offset, nil
+		}
+		if err != nil {
+			return nil, offset, err
+		}
+		offset += int64(len(line))
+		var it SyncItem
+		if json.Unmarshal(line, &it) == nil && it.Kind != "" {
+			items = append(items, it)
+		}
+	}
+}
+
+func (d *DirTransport) runGit(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", d.dir}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package orchestration
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ApprovalStatus is the state of a workflow's approval request.
+type ApprovalStatus string
+
+const (
+	ApprovalPending  ApprovalStatus = "pending"
+	ApprovalApproved ApprovalStatus = "approved"
+	ApprovalRejected ApprovalStatus = "rejected"
+)
+
+// Approval decisions a human can submit.
+const (
+	DecisionApprove = "approve"
+	DecisionReject  = "reject"
+	DecisionComment = "comment"
+)
+
+// Approval is the latest approval request of a workflow. It stays on the
+// state after it is resolved so agents waiting on it can see the outcome.
+// An escalation can hold a workflow with an approval that has no To; it is
+// released without a transition.
+type Approval struct {
+	From        Phase              `json:"from"`
+	To          Phase              `json:"to"`
+	Status      ApprovalStatus     `json:"status"`
+	Reviewers   []string           `json:"reviewers,omitempty"`
+	Message     string             `json:"message,omitempty"`
+	RequestedAt string             `json:"requested_at"`
+	Decisions   []ApprovalDecision `json:"decisions"`
+}
+
+// ApprovalDecision is one approval, rejection or comment on a request.
+type ApprovalDecision struct {
+	Actor    string `json:"actor"`
+	Decision string `json:"decision"`
+	Comment  string `json:"comment,omitempty"`
+	At       string `json:"at"`
+}
+
+// Awaiting reports whether the approval still blocks the workflow.
+func (a *Approval) Awaiting() bool {
+	return a != nil && a.Status == ApprovalPending
+}
+
+// Outstanding returns the required reviewers who have not approved yet. It
+// is empty once a request without named reviewers has one approval.
+func (a *Approval) Outstanding() []string {
+	approved := map[string]bool{}
+	for _, d := range a.Decisions {
+		if d.Decision == DecisionApprove {
+			approved[d.Actor] = true
+		}
+	}
+	if len(a.Reviewers) == 0 {
+		if len(approved) > 0 {
+			return nil
+		}
+		return []string{"any reviewer"}
+	}
+	var out []string
+	for _, r := range a.Reviewers {
+		if !approved[r] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// ApprovalPendingError reports a transition that is waiting for a human
+// decision. It is not a failure: the workflow stays in its phase until the
+// approval is resolved, then moves on by itself.
+type ApprovalPendingError struct {
+	WorkflowID string
+	Approval   *Approval
+	State      *WorkflowState
+}
+
+func (e *ApprovalPendingError) Error() string {
+	msg := fmt.Sprintf("transition %q → %q is awaiting human approval (waiting on %s)",
+		e.Approval.From, e.Approval.To, strings.Join(e.Approval.Outstanding(), ", "))
+	if e.Approval.To == "" {
+		msg = fmt.Sprintf("workflow is held in %q until a human approves it (waiting on %s)",
+			e.Approval.From, strings.Join(e.Approval.Outstanding(), ", "))
+	}
+	if e.Approval.Message != "" {
+		msg += ": " + e.Approval.Message
+	}
+	return msg
+}
+
+// approvalGate returns the gate the workflow definition attaches to the
+// current phase for a transition to to, or nil.
+func approvalGate(state *WorkflowState, to Phase) *ApprovalGate {
+	def, ok := Definition(state.Type)
+	if !ok {
+		return nil
+	}
+	phase, ok := def.Phase(state.Phase)
+	if !ok {
+		return nil
+	}
+	return phase.approvalGate(to)
+}
+
+// approvalGate returns the phase's gate for a transition to to, or nil. A
+// gate naming to wins over one that applies to every target.
+func (p PhaseDefinition) approvalGate(to Phase) *ApprovalGate {
+	var fallback *ApprovalGate
+	for i := range p.Approvals {
+		g := &p.Approvals[i]
+		switch g.To {
+		case to:
+			return g
+		case "":
+			fallback = g
+		}
+	}
+	return fallback
+}
+
+// ResolveApproval applies a human decision to a workflow's pending
+// approval. Once every required reviewer has approved, the held transition
+// is taken; a rejection leaves the workflow in its phase. The returned bool
+// reports whether the workflow transitioned.
+//
+// actor must identify a human; callers are responsible for keeping agents
+// away from this method.
+func (c *Coordinator) ResolveApproval(id, decision, actor, comment string) (*WorkflowState, bool, error) {
+	actor = strings.TrimSpace(actor)
+	if actor == "" {
+		return nil, false, fmt.Errorf("an approval decision needs an actor")
+	}
+	switch decision {
+	case DecisionApprove, DecisionReject, DecisionComment:
+	default:
+		return nil, false, fmt.Errorf("unknown approval decision %q", decision)
+	}
+	if decision == DecisionComment && strings.TrimSpace(comment) == "" {
+		return nil, false, fmt.Errorf("comment is empty")
+	}
+
+	state, err := c.Get(id)
+	if err != nil {
+		return nil, false, err
+	}
+	a := state.Approval
+	if !a.Awaiting() {
+		return nil, false, fmt.Errorf("workflow %q has no pending approval", id)
+	}
+	if decision != DecisionComment && len(a.Reviewers) > 0 && !containsString(a.Reviewers, actor) {
+		return nil, false, fmt.Errorf("%q is not a reviewer of this approval (reviewers: %s)", actor, strings.Join(a.Reviewers, ", "))
+	}
+
+	if err := c.record(state, WorkflowEventApprovalDecision, actor, EventData{Decision: decision, Comment: comment}); err != nil {
+		return nil, false, err
+	}
+	if decision != DecisionApprove || len(state.Approval.Outstanding()) > 0 || a.To == "" {
+		return state, false, nil
+	}
+
+	from, to := state.Approval.From, state.Approval.To
+	if err := c.record(state, WorkflowEventTransition, actor, EventData{From: from, To: to}); err != nil {
+		return nil, false, err
+	}
+	c.afterTransition(state, from, to)
+	return state, true, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
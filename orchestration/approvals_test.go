@@ -0,0 +1,79 @@
+package orchestration
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+func TestCoordinator_ApprovalGate(t *testing.T) {
+	resetDefinitions(t)
+	root := t.TempDir()
+	writeDefinition(t, root, "release.json", `{"type": "release", "phases": [
+	  {"name": "build", "next": ["ship"],
+	   "approvals": [{"to": "ship", "reviewers": ["alice", "bob"], "message": "release sign-off"}]},
+	  {"name": "ship", "next": ["complete"]},
+	  {"name": "complete"}]}`)
+	if err := LoadWorkflowDefinitions(root); err != nil {
+		t.Fatal(err)
+	}
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	coord := NewCoordinator(database)
+	if _, err := coord.Start("rel-1", "release", ComplexitySimple, "Release"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = coord.Transition("rel-1", "ship")
+	var pending *ApprovalPendingError
+	if !errors.As(err, &pending) || !strings.Contains(err.Error(), "alice, bob") || !strings.Contains(err.Error(), "release sign-off") {
+		t.Fatalf("expected ApprovalPendingError, got %v", err)
+	}
+	// Asking again reports the same pending approval; other moves are refused.
+	if _, err := coord.Transition("rel-1", "ship"); !errors.As(err, &pending) {
+		t.Fatalf("repeat transition: %v", err)
+	}
+	if _, _, err := coord.ResolveApproval("rel-1", DecisionApprove, "mallory", ""); err == nil {
+		t.Fatal("a non-reviewer must not approve")
+	}
+
+	// A rejection keeps the workflow in build; a new request starts over.
+	state, moved, err := coord.ResolveApproval("rel-1", DecisionReject, "alice", "changelog missing")
+	if err != nil || moved || state.Phase != "build" || state.Approval.Status != ApprovalRejected {
+		t.Fatalf("reject: %+v, %v, %v", state, moved, err)
+	}
+	if _, err := coord.Transition("rel-1", "ship"); !errors.As(err, &pending) {
+		t.Fatalf("re-request: %v", err)
+	}
+	if _, moved, err := coord.ResolveApproval("rel-1", DecisionApprove, "alice", ""); err != nil || moved {
+		t.Fatalf("first approval: %v, %v", moved, err)
+	}
+	if _, _, err := coord.ResolveApproval("rel-1", DecisionComment, "carol", "looks good"); err != nil {
+		t.Fatalf("comment: %v", err)
+	}
+	state, moved, err = coord.ResolveApproval("rel-1", DecisionApprove, "bob", "")
+	if err != nil || !moved || state.Phase != "ship" || state.Approval.Status != ApprovalApproved {
+		t.Fatalf("second approval: %+v, %v, %v", state, moved, err)
+	}
+	if n := len(state.Approval.Decisions); n != 3 {
+		t.Fatalf("decisions = %+v", state.Approval.Decisions)
+	}
+
+	events, err := coord.Events("rel-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	projected, err := Project(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projected.Phase != "ship" || projected.Approval.Status != ApprovalApproved || len(projected.Approval.Decisions) != 3 {
+		t.Fatalf("projected = %+v", projected)
+	}
+}
@@ -0,0 +1,215 @@
+package orchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+const (
+	// DefaultCheckTimeout bounds a check command that sets no timeout_sec.
+	DefaultCheckTimeout = 5 * time.Minute
+	// checkOutputLimit caps the command output kept as evidence. The tail is
+	// kept: compilers and test runners report failures last.
+	checkOutputLimit = 16 << 10
+	// checkErrorOutputLimit caps the output quoted in a transition error.
+	checkErrorOutputLimit = 2000
+	// checkAlertLimit caps the alert messages quoted by an alerts check.
+	checkAlertLimit = 10
+)
+
+// CheckResult is the outcome of one phase check.
+type CheckResult struct {
+	Name       string `json:"name"`
+	Command    string `json:"command,omitempty"`
+	Passed     bool   `json:"passed"`
+	Skipped    string `json:"skipped,omitempty"` // why the check did not run
+	Output     string `json:"output,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	EvidenceID int64  `json:"evidence_id,omitempty"`
+}
+
+// CheckFailedError reports a transition blocked by failing phase checks.
+// Results holds every check that applied, passing and skipped ones too.
+type CheckFailedError struct {
+	From    Phase
+	To      Phase
+	Results []CheckResult
+}
+
+func (e *CheckFailedError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "transition %q → %q blocked by failing checks:", e.From, e.To)
+	for _, r := range e.Results {
+		if r.Passed || r.Skipped != "" {
+			continue
+		}
+		sb.WriteString("\n- " + r.Name)
+		if r.Command != "" {
+			sb.WriteString(" (" + r.Command + ")")
+		}
+		if out := strings.TrimSpace(r.Output); out != "" {
+			if len(out) > checkErrorOutputLimit {
+				out = "…" + out[len(out)-checkErrorOutputLimit:]
+			}
+			sb.WriteString(":\n" + out)
+		}
+	}
+	return sb.String()
+}
+
+// SetProjectRoot sets the directory check commands run in. Until it is set,
+// command checks are skipped; alert checks still run.
+func (c *Coordinator) SetProjectRoot(root string) {
+	c.projectRoot = root
+}
+
+// runPhaseChecks runs the checks the workflow definition attaches to the
+// current phase for a transition to to, recording each executed check as
+// workflow evidence.
+func (c *Coordinator) runPhaseChecks(state *WorkflowState, to Phase) []CheckResult {
+	def, ok := Definition(state.Type)
+	if !ok {
+		return nil
+	}
+	phase, ok := def.Phase(state.Phase)
+	if !ok {
+		return nil
+	}
+	var results []CheckResult
+	for _, chk := range phase.Checks {
+		if chk.To != "" && chk.To != to {
+			continue
+		}
+		r := c.runCheck(state, chk)
+		if r.Skipped == "" {
+			r.EvidenceID = c.recordCheckEvidence(state, to, chk, r)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+func (c *Coordinator) runCheck(state *WorkflowState, chk PhaseCheck) (r CheckResult) {
+	r = CheckResult{Name: chk.Name, Command: strings.Join(chk.Command, " ")}
+	if chk.IfExists != "" {
+		if c.projectRoot == "" {
+			r.Skipped = "no project root"
+			return r
+		}
+		if _, err := os.Stat(filepath.Join(c.projectRoot, chk.IfExists)); err != nil {
+			r.Skipped = chk.IfExists + " not found"
+			return r
+		}
+	}
+
+	start := time.Now()
+	defer func() { r.DurationMs = time.Since(start).Milliseconds() }()
+
+	if chk.Children {
+		var open []string
+		for _, id := range state.Children {
+			child, err := c.Get(id)
+			if errors.Is(err, ErrWorkflowNotFound) {
+				continue // deleted children no longer hold the parent
+			}
+			if err != nil {
+				open = append(open, fmt.Sprintf("%s: %v", id, err))
+				continue
+			}
+			if !child.Aborted && child.Phase != PhaseComplete {
+				open = append(open, fmt.Sprintf("child workflow %s (%s) is in %s", child.ID, child.Type, child.Phase))
+			}
+		}
+		r.Passed = len(open) == 0
+		r.Output = strings.Join(open, "\n")
+		return r
+	}
+
+	if chk.Alerts != "" {
+		alerts, err := c.db.ListGuardianAlerts(chk.Alerts)
+		if err != nil {
+			r.Output = "list alerts: " + err.Error()
+			return r
+		}
+		r.Passed = len(alerts) == 0
+		var lines []string
+		for i, a := range alerts {
+			if i == checkAlertLimit {
+				lines = append(lines, fmt.Sprintf("… and %d more", len(alerts)-i))
+				break
+			}
+			lines = append(lines, fmt.Sprintf("alert #%d: %s", a.ID, a.Message))
+		}
+		r.Output = strings.Join(lines, "\n")
+		return r
+	}
+
+	if c.projectRoot == "" {
+		r.Skipped = "no project root"
+		return r
+	}
+	timeout := DefaultCheckTimeout
+	if chk.TimeoutSec > 0 {
+		timeout = time.Duration(chk.TimeoutSec) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, chk.Command[0], chk.Command[1:]...)
+	cmd.Dir = c.projectRoot
+	out, err := cmd.CombinedOutput()
+	r.Output = string(out)
+	if len(r.Output) > checkOutputLimit {
+		r.Output = "…" + r.Output[len(r.Output)-checkOutputLimit:]
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		r.Output += fmt.Sprintf("\ntimed out after %s", timeout)
+	case err != nil:
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			r.Output += "\n" + err.Error()
+		}
+	default:
+		r.Passed = true
+	}
+	return r
+}
+
+// recordCheckEvidence stores a check result in the style of swarm gate
+// evidence and returns its ID, or 0 when it could not be stored.
+func (c *Coordinator) recordCheckEvidence(state *WorkflowState, to Phase, chk PhaseCheck, r CheckResult) int64 {
+	label := r.Command
+	switch {
+	case chk.Children:
+		label = "child workflows"
+	case label == "":
+		label = "alerts " + chk.Alerts
+	}
+	content, verdict := label+": OK", "pass"
+	if !r.Passed {
+		content, verdict = label+" FAILED:\n"+r.Output, "fail"
+	}
+	id, err := c.db.CreateWorkflowEvidence(db.WorkflowEvidence{
+		WorkflowID: state.ID,
+		Phase:      string(state.Phase),
+		ToPhase:    string(to),
+		Type:       "gate",
+		Name:       chk.Name,
+		Content:    content,
+		Agent:      "phase-check",
+		Verdict:    verdict,
+		DurationMs: r.DurationMs,
+	})
+	if err != nil {
+		return 0
+	}
+	return id
+}
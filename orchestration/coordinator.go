@@ -16,6 +16,15 @@ import (
 // ErrWorkflowNotFound is returned when a workflow ID does not exist in the database.
 var ErrWorkflowNotFound = errors.New("workflow not found")
 
+// ErrConcurrentUpdate is returned when a workflow moved on between reading
+// it and recording a change decided on its phase, such as a transition.
+// Reading it again and retrying is safe.
+var ErrConcurrentUpdate = errors.New("workflow changed concurrently")
+
+// ErrInvalidStart is returned when a workflow cannot be started with the
+// requested template or parent.
+var ErrInvalidStart = errors.New("invalid workflow start")
+
 // ChangeSummary holds the structural and semantic summary of changes made during a workflow.
 type ChangeSummary struct {
 	CapabilitiesAdded    []string `json:"capabilities_added"`
@@ -49,8 +58,20 @@ type WorkflowState struct {
 	DesignContent string              `json:"design_content,omitempty"`
 	BaseCommit    string              `json:"base_commit,omitempty"`    // git HEAD at workflow creation
 	ChangeSummary *ChangeSummary      `json:"change_summary,omitempty"` // populated on complete
+	Loops         map[string]int      `json:"loops,omitempty"`          // "from->to" → times taken, for loop limits
+	Approval      *Approval           `json:"approval,omitempty"`       // latest human approval request
+	ParentID      string              `json:"parent_id,omitempty"`      // workflow this one was spawned from
+	Children      []string            `json:"children,omitempty"`       // workflows spawned from this one
+	Template      string              `json:"template,omitempty"`       // template the workflow was started from
+	Escalations   []Escalation        `json:"escalations,omitempty"`    // breached budgets and the actions taken
 	CreatedAt     string              `json:"created_at"`
 	UpdatedAt     string              `json:"updated_at"`
+
+	// PhaseEnteredAt is when the workflow entered its current phase; phase
+	// time budgets count from it.
+	PhaseEnteredAt string `json:"phase_entered_at,omitempty"`
+	// BudgetUSD caps the workflow's spend; 0 uses the definition's budget.
+	BudgetUSD float64 `json:"budget_usd,omitempty"`
 }
 
 // Task is a single work item within a workflow.
@@ -69,6 +90,8 @@ type Coordinator struct {
 	knowledgeEngine     LearnKnowledgeEngine
 	learnEventStore     LearnEventStore
 	learnPipelineTimeout time.Duration
+	projectRoot          string
+	hub                  Broadcaster
 }
 
 // NewCoordinator creates a new coordinator.
@@ -113,35 +136,132 @@ func (c *Coordinator) SetLearnPipelineTimeout(d time.Duration) {
 	}
 }
 
+// StartOptions holds the optional parts of starting a workflow.
+type StartOptions struct {
+	// Template names a registered template of the same workflow type whose
+	// tasks, plan skeleton and delegated agents the workflow starts with.
+	Template string
+	// ParentID links the workflow as a child of another one. The parent's
+	// current phase must list the child's type in its spawns.
+	ParentID string
+	// BudgetUSD caps the workflow's spend; 0 uses the definition's budget.
+	BudgetUSD float64
+}
+
 // Start creates a new workflow or returns an existing one with the same ID.
+// wtype must be a registered workflow definition.
 func (c *Coordinator) Start(id string, wtype WorkflowType, complexity Complexity, title string) (*WorkflowState, error) {
+	return c.StartWith(id, wtype, complexity, title, StartOptions{})
+}
+
+// StartWith is Start with a template and/or parent workflow. An empty
+// complexity falls back to the template's, then to simple.
+func (c *Coordinator) StartWith(id string, wtype WorkflowType, complexity Complexity, title string, opts StartOptions) (*WorkflowState, error) {
 	existing, err := c.Get(id)
 	if err == nil {
 		return existing, nil
 	}
+	if _, ok := Definition(wtype); !ok {
+		return nil, fmt.Errorf("unknown workflow type %q", wtype)
+	}
+
+	data := EventData{
+		WorkflowType: wtype,
+		Complexity:   complexity,
+		Title:        title,
+		To:           InitialPhase(wtype),
+		ParentID:     opts.ParentID,
+	}
+	if opts.BudgetUSD < 0 {
+		return nil, fmt.Errorf("%w: budget must not be negative", ErrInvalidStart)
+	}
+	if opts.BudgetUSD > 0 {
+		data.BudgetUSD = &opts.BudgetUSD
+	}
+	if opts.Template != "" {
+		t, ok := Template(opts.Template)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown template %q", ErrInvalidStart, opts.Template)
+		}
+		if t.Type != wtype {
+			return nil, fmt.Errorf("%w: template %q is for %q workflows, not %q", ErrInvalidStart, t.Name, t.Type, wtype)
+		}
+		if data.Complexity == "" {
+			data.Complexity = t.Complexity
+		}
+		data.Template = t.Name
+		data.Tasks = t.Tasks
+		data.Content = t.renderPlan(id, title)
+		if len(t.Delegated) > 0 {
+			data.Delegated = make(map[string][]string, len(t.Delegated))
+			for phase, agents := range t.Delegated {
+				data.Delegated[string(phase)] = agents
+			}
+		}
+	}
+	if data.Complexity == "" {
+		data.Complexity = ComplexitySimple
+	}
 
-	state := &WorkflowState{
-		ID:         id,
-		Type:       wtype,
-		Phase:      InitialPhase(wtype),
-		Complexity: complexity,
-		Delegated:  map[string][]string{},
-		Tasks:      []Task{},
-		Title:      title,
-		CreatedAt:  time.Now().UTC().Format(time.RFC3339Nano),
-		UpdatedAt:  time.Now().UTC().Format(time.RFC3339Nano),
+	var parent *WorkflowState
+	if opts.ParentID != "" {
+		if parent, err = c.Get(opts.ParentID); err != nil {
+			return nil, fmt.Errorf("parent %w", err)
+		}
+		if parent.Aborted || parent.Phase == PhaseComplete {
+			return nil, fmt.Errorf("%w: parent workflow %q is finished", ErrInvalidStart, parent.ID)
+		}
+		if !canSpawn(parent, wtype) {
+			return nil, fmt.Errorf("%w: phase %q of %s workflow %q cannot spawn %q workflows", ErrInvalidStart, parent.Phase, parent.Type, parent.ID, wtype)
+		}
 	}
-	if err := c.save(state); err != nil {
+
+	state := &WorkflowState{ID: id}
+	if err := c.record(state, WorkflowEventStart, "", data); err != nil {
 		return nil, err
 	}
+	if parent != nil {
+		if err := c.record(parent, WorkflowEventChildAdded, "", EventData{Child: id}); err != nil {
+			return nil, fmt.Errorf("link child workflow: %w", err)
+		}
+	}
 	return state, nil
 }
 
+// canSpawn reports whether the workflow's current phase may start a child
+// of type wtype.
+func canSpawn(state *WorkflowState, wtype WorkflowType) bool {
+	def, ok := Definition(state.Type)
+	if !ok {
+		return false
+	}
+	phase, ok := def.Phase(state.Phase)
+	if !ok {
+		return false
+	}
+	for _, t := range phase.Spawns {
+		if t == wtype {
+			return true
+		}
+	}
+	return false
+}
+
 // Get retrieves a workflow by ID.
 func (c *Coordinator) Get(id string) (*WorkflowState, error) {
+	return loadState(c.db.SQL(), id)
+}
+
+// rowQuerier is a *sql.DB or a *sql.Tx.
+type rowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// loadState reads a workflow's saved state through q.
+func loadState(q rowQuerier, id string) (*WorkflowState, error) {
 	var stateJSON, wtype, phase, complexity string
 	var createdAt, updatedAt string
-	err := c.db.SQL().QueryRow(`SELECT type, phase, complexity, state_json, created_at, updated_at FROM workflows WHERE id = ?`, id).
+	err := q.QueryRow(`SELECT type, phase, complexity, state_json, created_at, updated_at FROM workflows WHERE id = ?`, id).
 		Scan(&wtype, &phase, &complexity, &stateJSON, &createdAt, &updatedAt)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("workflow %q: %w", id, ErrWorkflowNotFound)
@@ -169,175 +289,88 @@ func (c *Coordinator) Get(id string) (*WorkflowState, error) {
 	return &state, nil
 }
 
+// validatePhaseReadiness runs the readiness gates the workflow definition
+// attaches to the current phase for a transition to to, returning a warning
+// per failing gate.
 func validatePhaseReadiness(state *WorkflowState, to Phase) []string {
+	def, ok := Definition(state.Type)
+	if !ok {
+		return nil
+	}
+	phase, ok := def.Phase(state.Phase)
+	if !ok {
+		return nil
+	}
 	var warnings []string
-
-	switch state.Type {
-	case WorkflowSpec:
-		warnings = append(warnings, validateSpecPhaseReadiness(state, to)...)
-	case WorkflowBug:
-		warnings = append(warnings, validateBugPhaseReadiness(state, to)...)
-	case WorkflowE2E:
-		warnings = append(warnings, validateE2EPhaseReadiness(state, to)...)
+	for _, g := range phase.Gates {
+		if g.To != "" && g.To != to {
+			continue
+		}
+		if w := checkReadinessGate(state, to, g); w != "" {
+			warnings = append(warnings, w)
+		}
 	}
-
 	return warnings
 }
 
-func validateSpecPhaseReadiness(state *WorkflowState, to Phase) []string {
-	var warnings []string
-
-	switch state.Phase {
-	case PhasePlan:
-		if to == PhaseImplement {
-			if len(state.Tasks) == 0 {
-				warnings = append(warnings,
-					"tasks not defined — use /api/workflows/<id>/tasks to set tasks before implementing")
-			}
-			if state.PlanContent == "" {
-				warnings = append(warnings,
-					"plan not defined — write plan to docs/plans/<slug>.md and set via /api/workflows/<id>/plan")
-			}
+// checkReadinessGate returns the warning for a failing gate, or "".
+func checkReadinessGate(state *WorkflowState, to Phase, g ReadinessGate) string {
+	msg := g.Message
+	switch g.Check {
+	case "tasks_defined":
+		if len(state.Tasks) > 0 {
+			return ""
 		}
-
-	case PhaseImplement:
-		if to == PhaseVerify {
-			var incompleteTasks []string
-			for i, task := range state.Tasks {
-				if task.Status != "done" {
-					incompleteTasks = append(incompleteTasks,
-						fmt.Sprintf("task %d: %s", i+1, task.Title))
-				}
-			}
-			if len(incompleteTasks) > 0 {
-				warnings = append(warnings,
-					fmt.Sprintf("transitioning to verify with incomplete tasks: %s",
-						strings.Join(incompleteTasks, ", ")))
-			}
+		if msg == "" {
+			msg = fmt.Sprintf("transitioning to %s without tasks", to)
 		}
-
-	case PhaseVerify:
-		if to == PhaseLearn {
-			phaseDelegations := state.Delegated[string(state.Phase)]
-			if phaseDelegations == nil {
-				phaseDelegations = []string{}
-			}
-			hasReviewer := false
-			for _, agent := range phaseDelegations {
-				if agent == "delivery-code-reviewer" {
-					hasReviewer = true
-					break
-				}
-			}
-			if !hasReviewer {
-				warnings = append(warnings,
-					"transitioning to learn without code review delegation")
+	case "tasks_done":
+		var incompleteTasks []string
+		for i, task := range state.Tasks {
+			if task.Status != "done" {
+				incompleteTasks = append(incompleteTasks,
+					fmt.Sprintf("task %d: %s", i+1, task.Title))
 			}
 		}
-
-	case PhaseLearn:
-		if to == PhaseComplete {
-			// TODO: Add validation for learning artifacts when implemented
+		if len(incompleteTasks) == 0 {
+			return ""
 		}
-	}
-
-	return warnings
-}
-
-func validateBugPhaseReadiness(state *WorkflowState, to Phase) []string {
-	var warnings []string
-
-	switch state.Phase {
-	case PhaseAnalyze:
-		if to == PhaseFix {
-			if len(state.Tasks) == 0 {
-				warnings = append(warnings,
-					"tasks not defined — use /api/workflows/<id>/tasks to set fix tasks before transitioning to fix")
-			}
+		if msg == "" {
+			msg = fmt.Sprintf("transitioning to %s with incomplete tasks", to)
 		}
-
-	case PhaseFix:
-		if to == PhaseReview {
-			var incompleteTasks []string
-			for i, task := range state.Tasks {
-				if task.Status != "done" {
-					incompleteTasks = append(incompleteTasks,
-						fmt.Sprintf("task %d: %s", i+1, task.Title))
-				}
-			}
-			if len(incompleteTasks) > 0 {
-				warnings = append(warnings,
-					fmt.Sprintf("transitioning to review with incomplete fixes: %s",
-						strings.Join(incompleteTasks, ", ")))
-			}
+		msg += ": " + strings.Join(incompleteTasks, ", ")
+	case "plan_set":
+		if state.PlanContent != "" {
+			return ""
 		}
-
-	case PhaseReview:
-		if to == PhaseComplete {
-			phaseDelegations := state.Delegated[string(state.Phase)]
-			if phaseDelegations == nil {
-				phaseDelegations = []string{}
-			}
-			hasReviewer := false
-			for _, agent := range phaseDelegations {
-				if agent == "delivery-code-reviewer" {
-					hasReviewer = true
-					break
-				}
-			}
-			if !hasReviewer {
-				warnings = append(warnings,
-					"transitioning to complete without code review delegation")
-			}
+		if msg == "" {
+			msg = fmt.Sprintf("transitioning to %s without a plan", to)
 		}
-	}
-
-	return warnings
-}
-
-func validateE2EPhaseReadiness(state *WorkflowState, to Phase) []string {
-	var warnings []string
-
-	switch state.Phase {
-	case PhaseSetup:
-		if to == PhasePlan {
-			// TODO: Validate setup completion
+	case "design_set":
+		if state.DesignContent != "" {
+			return ""
 		}
-
-	case PhasePlan:
-		if to == PhaseGenerate {
-			if state.PlanContent == "" {
-				warnings = append(warnings,
-					"transitioning to generate without test plan")
-			}
+		if msg == "" {
+			msg = fmt.Sprintf("transitioning to %s without a design", to)
 		}
-
-	case PhaseGenerate:
-		if to == PhaseHeal {
-			if len(state.Tasks) == 0 {
-				warnings = append(warnings,
-					"transitioning to heal with no tests generated")
+	case "delegated":
+		for _, agent := range state.Delegated[string(state.Phase)] {
+			if agent == g.Agent {
+				return ""
 			}
 		}
-
-	case PhaseHeal:
-		if to == PhaseComplete {
-			var incompleteTasks []string
-			for i, task := range state.Tasks {
-				if task.Status != "done" {
-					incompleteTasks = append(incompleteTasks,
-						fmt.Sprintf("test %d: %s", i+1, task.Title))
-				}
-			}
-			if len(incompleteTasks) > 0 {
-				warnings = append(warnings,
-					fmt.Sprintf("transitioning to complete with failing tests: %s",
-						strings.Join(incompleteTasks, ", ")))
-			}
+		if msg == "" {
+			msg = fmt.Sprintf("transitioning to %s without %s delegation", to, g.Agent)
 		}
+	default:
+		return ""
 	}
+	return msg
+}
 
-	return warnings
+// loopKey identifies a transition in WorkflowState.Loops.
+func loopKey(from, to Phase) string {
+	return string(from) + "->" + string(to)
 }
 
 // Transition moves a workflow to a new phase.
@@ -346,20 +379,86 @@ func (c *Coordinator) Transition(id string, to Phase) (*WorkflowState, error) {
 	if err != nil {
 		return nil, err
 	}
+	if a := state.Approval; a.Awaiting() {
+		// A hold without a target (from an escalation) blocks every move.
+		if a.To == to || a.To == "" {
+			return nil, &ApprovalPendingError{WorkflowID: id, Approval: a, State: state}
+		}
+		return nil, fmt.Errorf("workflow is awaiting approval of %q → %q", a.From, a.To)
+	}
 	if err := ValidateTransition(state.Type, state.Phase, to); err != nil {
 		return nil, err
 	}
+	var loopLimit string
+	var onLoopLimit *EscalationPolicy
+	key := loopKey(state.Phase, to)
+	if def, ok := Definition(state.Type); ok {
+		phase, _ := def.Phase(state.Phase)
+		if limit := phase.MaxLoops[to]; limit > 0 && state.Loops[key] >= limit {
+			loopLimit = fmt.Sprintf("loop limit reached: %q → %q already taken %d times (max %d)", state.Phase, to, state.Loops[key], limit)
+			if phase.OnMaxLoops == nil {
+				return nil, errors.New(loopLimit)
+			}
+			onLoopLimit = phase.OnMaxLoops
+		}
+	}
+
+	from := state.Phase
+	if results := c.runPhaseChecks(state, to); len(results) > 0 {
+		for _, r := range results {
+			if !r.Passed && r.Skipped == "" {
+				return nil, &CheckFailedError{From: from, To: to, Results: results}
+			}
+		}
+		// Checks can take minutes; pick up changes made meanwhile and make
+		// sure nobody moved the workflow on.
+		if state, err = c.Get(id); err != nil {
+			return nil, err
+		}
+		if state.Phase != from {
+			return nil, fmt.Errorf("workflow moved to %q while checks for %q → %q ran", state.Phase, from, to)
+		}
+	}
 
 	for _, w := range validatePhaseReadiness(state, to) {
 		log.Printf("warning: workflow %s phase transition: %s", id, w)
 	}
 
-	from := state.Phase
-	state.Phase = to
-	if err := c.save(state); err != nil {
+	// The loop limit escalates only once the transition is otherwise ready,
+	// so retrying after a failed check does not escalate again.
+	if onLoopLimit != nil {
+		if err := c.escalate(state, TriggerLoopLimit, *onLoopLimit, to, loopLimit); err != nil {
+			return nil, err
+		}
+		switch onLoopLimit.Action {
+		case EscalateAbort:
+			return nil, fmt.Errorf("%s; workflow aborted", loopLimit)
+		case EscalateApproval:
+			return nil, &ApprovalPendingError{WorkflowID: id, Approval: state.Approval, State: state}
+		}
+		// EscalateDelegate: the agent is brought in and the loop goes on.
+	}
+
+	// Checks have passed; a human now has to let the transition through.
+	if g := approvalGate(state, to); g != nil {
+		err := c.record(state, WorkflowEventApprovalRequested, "", EventData{
+			From: from, To: to, Reviewers: g.Reviewers, Reason: g.Message,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return nil, &ApprovalPendingError{WorkflowID: id, Approval: state.Approval, State: state}
+	}
+
+	if err := c.record(state, WorkflowEventTransition, "", EventData{From: from, To: to}); err != nil {
 		return nil, err
 	}
+	c.afterTransition(state, from, to)
+	return state, nil
+}
 
+// afterTransition starts the work that follows a transition.
+func (c *Coordinator) afterTransition(state *WorkflowState, from, to Phase) {
 	if to == PhaseComplete && from == PhaseLearn {
 		snapshot := *state
 		go RunLearnPipeline(context.Background(), LearnPipelineDeps{
@@ -372,11 +471,10 @@ func (c *Coordinator) Transition(id string, to Phase) (*WorkflowState, error) {
 			Timeout:         c.learnPipelineTimeout,
 		})
 	}
-
-	return state, nil
 }
 
-// RecordDelegation records an agent delegation for the current phase.
+// RecordDelegation records an agent delegation for the current phase. It
+// returns a *BudgetExceededError once the workflow has spent its budget.
 func (c *Coordinator) RecordDelegation(id, agentID string) (*WorkflowState, error) {
 	state, err := c.Get(id)
 	if err != nil {
@@ -388,8 +486,10 @@ func (c *Coordinator) RecordDelegation(id, agentID string) (*WorkflowState, erro
 			return state, nil // already recorded
 		}
 	}
-	state.Delegated[phase] = append(state.Delegated[phase], agentID)
-	if err := c.save(state); err != nil {
+	if err := c.checkBudget(state); err != nil {
+		return nil, err
+	}
+	if err := c.record(state, WorkflowEventDelegation, agentID, EventData{Agent: agentID}); err != nil {
 		return nil, err
 	}
 	return state, nil
@@ -401,12 +501,7 @@ func (c *Coordinator) SetTasks(id string, titles []string) (*WorkflowState, erro
 	if err != nil {
 		return nil, err
 	}
-	state.Tasks = make([]Task, len(titles))
-	for i, t := range titles {
-		state.Tasks[i] = Task{Index: i, Title: t, Status: "pending"}
-	}
-	state.TotalTasks = len(titles)
-	return state, c.save(state)
+	return state, c.record(state, WorkflowEventTasksSet, "", EventData{Tasks: titles})
 }
 
 // StartTask marks a task as in_progress.
@@ -418,9 +513,7 @@ func (c *Coordinator) StartTask(id string, index int) (*WorkflowState, error) {
 	if index < 0 || index >= len(state.Tasks) {
 		return nil, fmt.Errorf("task index %d out of range (total: %d)", index, len(state.Tasks))
 	}
-	state.Tasks[index].Status = "in_progress"
-	state.CurrentTask = &index
-	return state, c.save(state)
+	return state, c.record(state, WorkflowEventTaskStart, "", EventData{Index: &index})
 }
 
 // CompleteTask marks a task as done.
@@ -432,9 +525,7 @@ func (c *Coordinator) CompleteTask(id string, index int) (*WorkflowState, error)
 	if index < 0 || index >= len(state.Tasks) {
 		return nil, fmt.Errorf("task index %d out of range", index)
 	}
-	state.Tasks[index].Status = "done"
-	state.CurrentTask = nil
-	if err := c.save(state); err != nil {
+	if err := c.record(state, WorkflowEventTaskComplete, "", EventData{Index: &index}); err != nil {
 		return nil, err
 	}
 	return state, nil
@@ -446,8 +537,7 @@ func (c *Coordinator) Abort(id string) (*WorkflowState, error) {
 	if err != nil {
 		return nil, err
 	}
-	state.Aborted = true
-	return state, c.save(state)
+	return state, c.record(state, WorkflowEventAbort, "", EventData{})
 }
 
 // SetPlanContent stores the plan markdown content in the workflow state.
@@ -456,8 +546,7 @@ func (c *Coordinator) SetPlanContent(id, content string) (*WorkflowState, error)
 	if err != nil {
 		return nil, err
 	}
-	state.PlanContent = content
-	return state, c.save(state)
+	return state, c.record(state, WorkflowEventPlanSet, "", EventData{Content: content})
 }
 
 // SetDesignContent stores the design document markdown content in the workflow state.
@@ -466,8 +555,7 @@ func (c *Coordinator) SetDesignContent(id, content string) (*WorkflowState, erro
 	if err != nil {
 		return nil, err
 	}
-	state.DesignContent = content
-	return state, c.save(state)
+	return state, c.record(state, WorkflowEventDesignSet, "", EventData{Content: content})
 }
 
 // ListActive returns all non-completed, non-aborted workflows.
@@ -516,8 +604,7 @@ func (c *Coordinator) SetSessionID(id, sessionID string) error {
 	if state.SessionID != "" {
 		return nil // already captured — don't overwrite
 	}
-	state.SessionID = sessionID
-	return c.save(state)
+	return c.record(state, WorkflowEventSessionSet, "", EventData{SessionID: sessionID})
 }
 
 // UpdateSessionID sets (or replaces) the Claude Code session ID for a workflow.
@@ -528,8 +615,7 @@ func (c *Coordinator) UpdateSessionID(id, sessionID string) (*WorkflowState, err
 	if err != nil {
 		return nil, err
 	}
-	state.SessionID = sessionID
-	return state, c.save(state)
+	return state, c.record(state, WorkflowEventSessionSet, "", EventData{SessionID: sessionID})
 }
 
 // SetBaseCommit records the git HEAD SHA at workflow creation time.
@@ -541,8 +627,7 @@ func (c *Coordinator) SetBaseCommit(id, commit string) error {
 	if state.BaseCommit != "" {
 		return nil // already captured
 	}
-	state.BaseCommit = commit
-	return c.save(state)
+	return c.record(state, WorkflowEventBaseCommit, "", EventData{Commit: commit})
 }
 
 // SetChangeSummary stores (or replaces) the change summary for a workflow.
@@ -553,11 +638,10 @@ func (c *Coordinator) SetChangeSummary(id string, incoming *ChangeSummary) (*Wor
 	if err != nil {
 		return nil, err
 	}
-	if state.ChangeSummary == nil {
-		state.ChangeSummary = incoming
-	} else {
+	merged := incoming
+	if state.ChangeSummary != nil {
 		// Merge: preserve computed fields, overwrite semantic fields from incoming
-		existing := state.ChangeSummary
+		existing := *state.ChangeSummary
 		if incoming.FilesChanged != 0 {
 			existing.FilesChanged = incoming.FilesChanged
 		}
@@ -597,8 +681,9 @@ func (c *Coordinator) SetChangeSummary(id string, incoming *ChangeSummary) (*Wor
 		if incoming.TestCoverageDelta != "" {
 			existing.TestCoverageDelta = incoming.TestCoverageDelta
 		}
+		merged = &existing
 	}
-	return state, c.save(state)
+	return state, c.record(state, WorkflowEventSummarySet, "", EventData{Summary: merged})
 }
 
 // ListAll returns all workflows (including completed and aborted), newest first.
@@ -688,7 +773,14 @@ func (c *Coordinator) Delete(id string) error {
 	if n == 0 {
 		return fmt.Errorf("workflow %q: %w", id, ErrWorkflowNotFound)
 	}
-	return nil
+	if _, err := c.db.SQL().Exec(`DELETE FROM workflow_evidence WHERE workflow_id = ?`, id); err != nil {
+		return err
+	}
+	if err := c.db.DeleteWorkflowCosts(id); err != nil {
+		return err
+	}
+	_, err = c.db.SQL().Exec(`DELETE FROM workflow_events WHERE workflow_id = ?`, id)
+	return err
 }
 
 // WorkflowHistorySummary holds aggregated historical data used for risk scoring.
@@ -771,13 +863,14 @@ func (c *Coordinator) WorkflowHistory(wtype string) (*WorkflowHistorySummary, er
 	return &summary, nil
 }
 
-func (c *Coordinator) save(state *WorkflowState) error {
-	state.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+// saveState writes state as the workflow's current projection. Callers go
+// through record so every change also lands in the event log.
+func saveState(tx *sql.Tx, state *WorkflowState) error {
 	data, err := json.Marshal(state)
 	if err != nil {
 		return fmt.Errorf("encode workflow state: %w", err)
 	}
-	_, err = c.db.SQL().Exec(`
+	_, err = tx.Exec(`
 		INSERT INTO workflows (id, type, phase, complexity, state_json, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
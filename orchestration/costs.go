@@ -0,0 +1,302 @@
+package orchestration
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+// Cost ledger sources.
+const (
+	// CostSourceSession entries are deltas of a Claude Code session's total
+	// cost as reported by the statusline. They include delegated agents.
+	CostSourceSession = "session"
+	// CostSourceAgent entries are one delegated agent's usage, reported by
+	// the agent_usage hook when the agent returns.
+	CostSourceAgent = "agent"
+)
+
+// ErrInvalidUsage marks a usage report or budget the coordinator refuses.
+var ErrInvalidUsage = errors.New("invalid usage report")
+
+// modelPrice is a list price in USD per million tokens.
+type modelPrice struct {
+	family        string
+	input, output float64
+}
+
+// modelPrices are matched against the model name in order; unknown models
+// are priced like the first entry.
+var modelPrices = []modelPrice{
+	{"sonnet", 3, 15},
+	{"opus", 15, 75},
+	{"haiku", 1, 5},
+}
+
+// Cache reads and writes are billed relative to the input price.
+const (
+	cacheReadPriceFactor  = 0.1
+	cacheWritePriceFactor = 1.25
+)
+
+// EstimateCostUSD prices token usage at list prices for the model's family.
+func EstimateCostUSD(model string, input, output, cacheRead, cacheWrite int) float64 {
+	p := modelPrices[0]
+	lower := strings.ToLower(model)
+	for _, mp := range modelPrices {
+		if strings.Contains(lower, mp.family) {
+			p = mp
+			break
+		}
+	}
+	in := float64(input) + float64(cacheRead)*cacheReadPriceFactor + float64(cacheWrite)*cacheWritePriceFactor
+	return (in*p.input + float64(output)*p.output) / 1e6
+}
+
+// UsageReport is token spend reported for a session or a delegated agent.
+type UsageReport struct {
+	SessionID string `json:"session_id"`
+	// WorkflowID is optional; without it the session's active workflow is
+	// charged.
+	WorkflowID       string  `json:"workflow_id"`
+	Agent            string  `json:"agent"`
+	Model            string  `json:"model"`
+	InputTokens      int     `json:"input_tokens"`
+	OutputTokens     int     `json:"output_tokens"`
+	CacheReadTokens  int     `json:"cache_read_tokens"`
+	CacheWriteTokens int     `json:"cache_write_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	// Cumulative marks CostUSD as the session's running total (statusline)
+	// rather than the spend of one agent.
+	Cumulative bool `json:"cumulative"`
+}
+
+// UsageResult is what recording a usage report did.
+type UsageResult struct {
+	// Entry is nil when the report was not attributed to a workflow: no
+	// active workflow owns the session, the session total did not grow, or
+	// the report is the baseline of a session newly mapped to the workflow.
+	Entry   *db.WorkflowCost `json:"entry,omitempty"`
+	Summary *CostSummary     `json:"summary,omitempty"`
+	// BudgetExceeded is set by the report that pushed the workflow over its
+	// budget.
+	BudgetExceeded bool `json:"budget_exceeded,omitempty"`
+}
+
+// CostTotals sums ledger entries.
+type CostTotals struct {
+	CostUSD      float64 `json:"cost_usd"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+}
+
+// CostSummary is a workflow's spend, in total and per phase and delegated
+// agent. Session reports are authoritative for cost: when there are any,
+// the total and per-phase costs come from them and agent costs are a
+// breakdown of that total. Otherwise agent costs, estimated from list prices
+// where needed, are summed. Tokens are only known from agent reports.
+type CostSummary struct {
+	WorkflowID string                `json:"workflow_id"`
+	Total      CostTotals            `json:"total"`
+	ByPhase    map[Phase]CostTotals  `json:"by_phase"`
+	ByAgent    map[string]CostTotals `json:"by_agent"`
+	Estimated  bool                  `json:"estimated"` // the total includes estimated costs
+	BudgetUSD  float64               `json:"budget_usd,omitempty"`
+	OverBudget bool                  `json:"over_budget"`
+}
+
+// BudgetExceededError reports a workflow whose spend reached its budget.
+type BudgetExceededError struct {
+	WorkflowID string
+	SpentUSD   float64
+	BudgetUSD  float64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("workflow %q has spent $%.2f of its $%.2f budget; raise the budget to delegate further", e.WorkflowID, e.SpentUSD, e.BudgetUSD)
+}
+
+// budgetUSD returns the workflow's budget: its own, else its definition's.
+// Zero means unlimited.
+func budgetUSD(state *WorkflowState) float64 {
+	if state.BudgetUSD > 0 {
+		return state.BudgetUSD
+	}
+	if def, ok := Definition(state.Type); ok {
+		return def.BudgetUSD
+	}
+	return 0
+}
+
+// RecordUsage attributes a usage report to a workflow and phase and appends
+// it to the cost ledger.
+func (c *Coordinator) RecordUsage(r UsageReport) (*UsageResult, error) {
+	if r.WorkflowID == "" && r.SessionID == "" {
+		return nil, fmt.Errorf("%w: session_id or workflow_id is required", ErrInvalidUsage)
+	}
+	if r.CostUSD < 0 || r.InputTokens < 0 || r.OutputTokens < 0 || r.CacheReadTokens < 0 || r.CacheWriteTokens < 0 {
+		return nil, fmt.Errorf("%w: usage must not be negative", ErrInvalidUsage)
+	}
+	if r.Cumulative && r.SessionID == "" {
+		return nil, fmt.Errorf("%w: a cumulative report needs a session_id", ErrInvalidUsage)
+	}
+
+	entry := db.WorkflowCost{
+		Agent:        r.Agent,
+		SessionID:    r.SessionID,
+		Source:       CostSourceAgent,
+		Model:        r.Model,
+		InputTokens:  r.InputTokens + r.CacheReadTokens + r.CacheWriteTokens,
+		OutputTokens: r.OutputTokens,
+		CostUSD:      r.CostUSD,
+	}
+
+	id := r.WorkflowID
+	if id == "" {
+		var err error
+		if id, err = c.sessionWorkflow(r.SessionID); err != nil {
+			return nil, err
+		}
+		if id == "" {
+			return &UsageResult{}, nil
+		}
+	}
+	state, err := c.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Cumulative {
+		delta, err := c.db.AdvanceSessionCost(r.SessionID, state.ID, r.CostUSD)
+		if err != nil {
+			return nil, err
+		}
+		entry.Source = CostSourceSession
+		entry.CostUSD = delta
+		entry.InputTokens, entry.OutputTokens = 0, 0
+	} else if entry.CostUSD == 0 {
+		entry.CostUSD = EstimateCostUSD(r.Model, r.InputTokens, r.OutputTokens, r.CacheReadTokens, r.CacheWriteTokens)
+		entry.Estimated = true
+	}
+	if entry.CostUSD == 0 && entry.InputTokens == 0 && entry.OutputTokens == 0 {
+		return &UsageResult{}, nil
+	}
+	entry.WorkflowID = state.ID
+	entry.Phase = string(state.Phase)
+	if entry.ID, err = c.db.AddWorkflowCost(entry); err != nil {
+		return nil, err
+	}
+
+	summary, err := c.costSummary(state)
+	if err != nil {
+		return nil, err
+	}
+	before := summary.Total.CostUSD - entry.CostUSD
+	return &UsageResult{
+		Entry:          &entry,
+		Summary:        summary,
+		BudgetExceeded: summary.OverBudget && before < summary.BudgetUSD,
+	}, nil
+}
+
+// sessionWorkflow returns the most recently updated active workflow owned by
+// a Claude Code session, or "".
+func (c *Coordinator) sessionWorkflow(sessionID string) (string, error) {
+	var id string
+	err := c.db.SQL().QueryRow(`
+		SELECT id FROM workflows
+		WHERE JSON_EXTRACT(state_json, '$.session_id') = ?
+		  AND JSON_EXTRACT(state_json, '$.aborted') IS NOT 1 AND phase != 'complete'
+		ORDER BY updated_at DESC LIMIT 1`, sessionID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("find session workflow: %w", err)
+	}
+	return id, nil
+}
+
+// Costs returns a workflow's spend.
+func (c *Coordinator) Costs(id string) (*CostSummary, error) {
+	state, err := c.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return c.costSummary(state)
+}
+
+func (c *Coordinator) costSummary(state *WorkflowState) (*CostSummary, error) {
+	groups, err := c.db.WorkflowCostGroups(state.ID)
+	if err != nil {
+		return nil, err
+	}
+	s := &CostSummary{
+		WorkflowID: state.ID,
+		ByPhase:    map[Phase]CostTotals{},
+		ByAgent:    map[string]CostTotals{},
+		BudgetUSD:  budgetUSD(state),
+	}
+	sessionCosts := false
+	for _, g := range groups {
+		if g.Source == CostSourceSession && g.CostUSD > 0 {
+			sessionCosts = true
+		}
+	}
+	for _, g := range groups {
+		phase := s.ByPhase[Phase(g.Phase)]
+		phase.InputTokens += g.InputTokens
+		phase.OutputTokens += g.OutputTokens
+		s.Total.InputTokens += g.InputTokens
+		s.Total.OutputTokens += g.OutputTokens
+		// Only one source counts towards cost, so agent spend inside a
+		// reported session is not charged twice.
+		if (g.Source == CostSourceSession) == sessionCosts {
+			phase.CostUSD += g.CostUSD
+			s.Total.CostUSD += g.CostUSD
+			s.Estimated = s.Estimated || g.Estimated
+		}
+		s.ByPhase[Phase(g.Phase)] = phase
+		if g.Source == CostSourceAgent {
+			a := s.ByAgent[g.Agent]
+			a.CostUSD += g.CostUSD
+			a.InputTokens += g.InputTokens
+			a.OutputTokens += g.OutputTokens
+			s.ByAgent[g.Agent] = a
+		}
+	}
+	s.OverBudget = s.BudgetUSD > 0 && s.Total.CostUSD >= s.BudgetUSD
+	return s, nil
+}
+
+// checkBudget returns a *BudgetExceededError when the workflow has spent its
+// budget.
+func (c *Coordinator) checkBudget(state *WorkflowState) error {
+	if budgetUSD(state) == 0 {
+		return nil
+	}
+	s, err := c.costSummary(state)
+	if err != nil {
+		return err
+	}
+	if s.OverBudget {
+		return &BudgetExceededError{WorkflowID: state.ID, SpentUSD: s.Total.CostUSD, BudgetUSD: s.BudgetUSD}
+	}
+	return nil
+}
+
+// SetBudget sets the workflow's budget in USD; 0 falls back to the
+// definition's budget, if any.
+func (c *Coordinator) SetBudget(id string, usd float64) (*WorkflowState, error) {
+	if usd < 0 {
+		return nil, fmt.Errorf("%w: budget must not be negative", ErrInvalidUsage)
+	}
+	state, err := c.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return state, c.record(state, WorkflowEventBudgetSet, "", EventData{BudgetUSD: &usd})
+}
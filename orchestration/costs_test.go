@@ -0,0 +1,126 @@
+package orchestration
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+func TestEstimateCostUSD(t *testing.T) {
+	for _, tc := range []struct {
+		model string
+		want  float64
+	}{
+		{"claude-opus-4", 15 + 75},
+		{"claude-haiku", 1 + 5},
+		{"", 3 + 15}, // unknown models are priced like sonnet
+	} {
+		if got := EstimateCostUSD(tc.model, 1e6, 1e6, 0, 0); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%q: %v, want %v", tc.model, got, tc.want)
+		}
+	}
+	if got := EstimateCostUSD("sonnet", 0, 0, 1e6, 1e6); math.Abs(got-(0.3+3.75)) > 1e-9 {
+		t.Errorf("cache tokens: %v", got)
+	}
+}
+
+func TestCoordinator_RecordUsage(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	coord := NewCoordinator(database)
+	state, err := coord.Start("spec-a", WorkflowSpec, ComplexitySimple, "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := coord.SetSessionID("spec-a", "sess-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if res, err := coord.RecordUsage(UsageReport{SessionID: "sess-other", CostUSD: 3, Cumulative: true}); err != nil || res.Entry != nil {
+		t.Fatalf("session without a workflow: %+v %v", res, err)
+	}
+	if _, err := coord.RecordUsage(UsageReport{SessionID: "sess-1", CostUSD: -1}); !errors.Is(err, ErrInvalidUsage) {
+		t.Errorf("negative cost: %v", err)
+	}
+
+	// Spend from before the session was mapped to the workflow is not charged.
+	if res, err := coord.RecordUsage(UsageReport{SessionID: "sess-1", CostUSD: 0.2, Cumulative: true}); err != nil || res.Entry != nil {
+		t.Fatalf("baseline report: %+v %v", res, err)
+	}
+	res, err := coord.RecordUsage(UsageReport{SessionID: "sess-1", CostUSD: 0.7, Cumulative: true})
+	if err != nil || res.Entry == nil || math.Abs(res.Entry.CostUSD-0.5) > 1e-9 {
+		t.Fatalf("session report: %+v %v", res, err)
+	}
+	if res.Entry.WorkflowID != "spec-a" || res.Entry.Phase != string(state.Phase) || res.Entry.Source != CostSourceSession {
+		t.Errorf("entry: %+v", res.Entry)
+	}
+	res, err = coord.RecordUsage(UsageReport{SessionID: "sess-1", Agent: "delivery-backend-engineer", Model: "sonnet", InputTokens: 1000, OutputTokens: 100})
+	if err != nil || res.Entry == nil || !res.Entry.Estimated {
+		t.Fatalf("agent report: %+v %v", res, err)
+	}
+
+	summary, err := coord.Costs("spec-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The session total already includes the agent, so it is not added again.
+	if math.Abs(summary.Total.CostUSD-0.5) > 1e-9 || summary.Total.InputTokens != 1000 || summary.Estimated {
+		t.Errorf("total: %+v estimated=%v", summary.Total, summary.Estimated)
+	}
+	if a := summary.ByAgent["delivery-backend-engineer"]; a.OutputTokens != 100 || a.CostUSD <= 0 {
+		t.Errorf("by agent: %+v", summary.ByAgent)
+	}
+
+	if _, err := coord.SetBudget("spec-a", 0.75); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := coord.RecordDelegation("spec-a", "delivery-backend-engineer"); err != nil {
+		t.Fatalf("delegation within budget: %v", err)
+	}
+	res, err = coord.RecordUsage(UsageReport{SessionID: "sess-1", CostUSD: 1, Cumulative: true})
+	if err != nil || !res.BudgetExceeded || !res.Summary.OverBudget {
+		t.Fatalf("crossing the budget: %+v %v", res, err)
+	}
+	if res, err := coord.RecordUsage(UsageReport{SessionID: "sess-1", CostUSD: 1.2, Cumulative: true}); err != nil || res.BudgetExceeded {
+		t.Errorf("only the crossing report flags the budget: %+v %v", res, err)
+	}
+
+	_, err = coord.RecordDelegation("spec-a", "delivery-frontend-engineer")
+	var over *BudgetExceededError
+	if !errors.As(err, &over) || over.BudgetUSD != 0.75 {
+		t.Fatalf("delegation over budget: %v", err)
+	}
+	if _, err := coord.RecordDelegation("spec-a", "delivery-backend-engineer"); err != nil {
+		t.Errorf("an agent already delegated is not refused: %v", err)
+	}
+
+	state, err = coord.Get("spec-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := coord.Events("spec-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	projected, err := Project(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(projected, state) {
+		t.Errorf("projection differs:\n got %+v\nwant %+v", projected, state)
+	}
+
+	if err := coord.Delete("spec-a"); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := database.ListWorkflowCosts("spec-a", 10); err != nil || len(entries) != 0 {
+		t.Errorf("ledger after delete: %v %v", entries, err)
+	}
+}
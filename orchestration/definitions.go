@@ -0,0 +1,599 @@
+package orchestration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// WorkflowDir holds project-specific workflow definitions, relative to the
+// project root. Each *.json file defines one workflow type.
+const WorkflowDir = ".stratus/workflows"
+
+// WorkflowDefinition declares a workflow type: its phases, the transitions
+// between them and what each phase expects before it is left. The built-in
+// spec, bug and e2e types are definitions too, and a project file with the
+// same type replaces them.
+type WorkflowDefinition struct {
+	Type        WorkflowType `json:"type"`
+	Description string       `json:"description,omitempty"`
+	// InitialPhase defaults to the first phase.
+	InitialPhase Phase             `json:"initial_phase,omitempty"`
+	Phases       []PhaseDefinition `json:"phases"`
+	// TimeBudget bounds the age of a workflow of this type.
+	TimeBudget *TimeBudget `json:"time_budget,omitempty"`
+	// BudgetUSD caps what a workflow of this type may spend before further
+	// delegation is refused; a workflow's own budget overrides it.
+	BudgetUSD float64 `json:"budget_usd,omitempty"`
+	// Source is the file the definition was loaded from; empty for built-ins.
+	Source string `json:"source,omitempty"`
+}
+
+// PhaseDefinition describes one phase of a workflow. Phases are listed in
+// the order the dashboard draws them.
+type PhaseDefinition struct {
+	Name Phase   `json:"name"`
+	Next []Phase `json:"next,omitempty"`
+	// Agents lists the delivery agents that may be delegated in this phase.
+	// Omitted (null) allows any agent; an empty list allows none.
+	Agents []string `json:"agents"`
+	// ReadOnly blocks write tools for delivery agents during the phase.
+	ReadOnly bool `json:"read_only,omitempty"`
+	// Complexity limits the phase to workflows of that complexity when the
+	// dashboard draws the timeline; it does not affect transitions.
+	Complexity Complexity      `json:"complexity,omitempty"`
+	Gates      []ReadinessGate `json:"gates,omitempty"`
+	// Checks must pass before the workflow may leave the phase.
+	Checks []PhaseCheck `json:"checks,omitempty"`
+	// Approvals make transitions out of the phase wait for a human.
+	Approvals []ApprovalGate `json:"approvals,omitempty"`
+	// Spawns lists the workflow types that may be started as children of a
+	// workflow in this phase. Omitted allows none.
+	Spawns []WorkflowType `json:"spawns,omitempty"`
+	// MaxLoops caps how often a transition out of this phase may be taken in
+	// one workflow, e.g. {"implement": 3} on verify allows three fix loops.
+	MaxLoops map[Phase]int `json:"max_loops,omitempty"`
+	// OnMaxLoops escalates instead of refusing a transition over its
+	// MaxLoops limit.
+	OnMaxLoops *EscalationPolicy `json:"on_max_loops,omitempty"`
+	// TimeBudget bounds how long a workflow may stay in this phase.
+	TimeBudget *TimeBudget `json:"time_budget,omitempty"`
+}
+
+// ReadinessGate is a check run when a workflow leaves a phase. A failing
+// gate logs Message as a warning; it does not block the transition.
+type ReadinessGate struct {
+	// To restricts the gate to one target phase; empty applies to all.
+	To Phase `json:"to,omitempty"`
+	// Check is one of tasks_defined, tasks_done, plan_set, design_set or
+	// delegated (which needs Agent).
+	Check   string `json:"check"`
+	Agent   string `json:"agent,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// PhaseCheck is an executable gate: unlike a ReadinessGate, a failing
+// check blocks the transition. A check either runs Command in the project
+// root, requires that no undismissed guardian alerts of type Alerts exist,
+// or, with Children, requires every child workflow to be complete or
+// aborted.
+type PhaseCheck struct {
+	Name string `json:"name"`
+	// To restricts the check to one target phase; empty applies to all.
+	To Phase `json:"to,omitempty"`
+	// Command is run without a shell; it passes when it exits with status 0.
+	Command  []string `json:"command,omitempty"`
+	Alerts   string   `json:"alerts,omitempty"`
+	Children bool     `json:"children,omitempty"`
+	// TimeoutSec bounds Command; 0 uses DefaultCheckTimeout.
+	TimeoutSec int `json:"timeout_sec,omitempty"`
+	// IfExists skips the check unless this path exists under the project
+	// root, so built-in Go checks stay out of other projects.
+	IfExists string `json:"if_exists,omitempty"`
+}
+
+// ApprovalGate holds a transition until a human approves it through the
+// dashboard or API. Agents cannot resolve an approval.
+type ApprovalGate struct {
+	// To restricts the gate to one target phase; empty applies to all.
+	To Phase `json:"to,omitempty"`
+	// Reviewers must all approve; empty accepts any one human approval.
+	Reviewers []string `json:"reviewers,omitempty"`
+	Message   string   `json:"message,omitempty"`
+}
+
+var readinessChecks = map[string]bool{
+	"tasks_defined": true,
+	"tasks_done":    true,
+	"plan_set":      true,
+	"design_set":    true,
+	"delegated":     true,
+}
+
+var (
+	workflowTypeRe = regexp.MustCompile(`^[a-z][a-z0-9]*(?:-[a-z0-9]+)*$`)
+	phaseNameRe    = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+)
+
+// implementAgents may be delegated wherever code gets written.
+var implementAgents = []string{
+	"delivery-backend-engineer", "delivery-frontend-engineer", "delivery-database-engineer",
+	"delivery-devops-engineer", "delivery-mobile-engineer", "delivery-implementation-expert",
+	"delivery-ux-designer", "delivery-qa-engineer",
+}
+
+// builtinGoChecks enables the built-in workflows' Go build and test checks.
+var builtinGoChecks atomic.Bool
+
+// SetBuiltinGoChecks turns the `go build ./...` and `go test ./...` checks of
+// the built-in spec and bug workflows on or off. They run synchronously on
+// transition, so they are off unless workflows.go_checks is set. The change
+// applies from the next LoadWorkflowDefinitions.
+func SetBuiltinGoChecks(enabled bool) { builtinGoChecks.Store(enabled) }
+
+// goChecks returns checks when the built-in Go checks are enabled.
+func goChecks(checks ...PhaseCheck) []PhaseCheck {
+	if !builtinGoChecks.Load() {
+		return nil
+	}
+	return checks
+}
+
+// goBuild and goTest are the built-in checks for Go projects.
+func goBuild(to Phase) PhaseCheck {
+	return PhaseCheck{Name: "build", To: to, Command: []string{"go", "build", "./..."}, IfExists: "go.mod"}
+}
+
+func goTest(to Phase) PhaseCheck {
+	return PhaseCheck{Name: "test", To: to, Command: []string{"go", "test", "./..."}, TimeoutSec: 600, IfExists: "go.mod"}
+}
+
+func noGovernanceAlerts(to Phase) PhaseCheck {
+	return PhaseCheck{Name: "governance", To: to, Alerts: "governance_violation"}
+}
+
+func childrenDone(to Phase) PhaseCheck {
+	return PhaseCheck{Name: "children", To: to, Children: true}
+}
+
+func builtinDefinitions() []WorkflowDefinition {
+	return []WorkflowDefinition{
+		{
+			Type: WorkflowSpec,
+			// Simple: plan → implement → verify → learn → complete
+			// Complex: plan → discovery → design → governance → accept → implement → verify → learn → complete
+			Description:  "Feature specification, simple or complex",
+			InitialPhase: PhasePlan,
+			Phases: []PhaseDefinition{
+				{Name: PhasePlan, Next: []Phase{PhaseImplement, PhaseDiscovery, PhaseAccept},
+					Agents: []string{"delivery-strategic-architect", "delivery-system-architect", "Plan", "Explore"},
+					Gates: []ReadinessGate{
+						{To: PhaseImplement, Check: "tasks_defined", Message: "tasks not defined — use /api/workflows/<id>/tasks to set tasks before implementing"},
+						{To: PhaseImplement, Check: "plan_set", Message: "plan not defined — write plan to docs/plans/<slug>.md and set via /api/workflows/<id>/plan"},
+					}},
+				{Name: PhaseDiscovery, Next: []Phase{PhaseDesign}, Complexity: ComplexityComplex,
+					Agents: []string{"delivery-debugger", "delivery-strategic-architect", "Explore"}},
+				{Name: PhaseDesign, Next: []Phase{PhaseGovernance, PhasePlan}, Complexity: ComplexityComplex,
+					Agents: []string{"delivery-strategic-architect", "delivery-system-architect", "delivery-ux-designer"}},
+				{Name: PhaseGovernance, Next: []Phase{PhasePlan}, Complexity: ComplexityComplex,
+					Agents: []string{"delivery-code-reviewer", "delivery-governance-checker"},
+					Checks: []PhaseCheck{noGovernanceAlerts(PhasePlan)}},
+				{Name: PhaseAccept, Next: []Phase{PhaseImplement}, Complexity: ComplexityComplex, Agents: []string{},
+					Approvals: []ApprovalGate{
+						{To: PhaseImplement, Message: "plan and design need human acceptance before implementation"},
+					}},
+				{Name: PhaseImplement, Next: []Phase{PhaseVerify}, Agents: implementAgents,
+					Gates: []ReadinessGate{
+						{To: PhaseVerify, Check: "tasks_done", Message: "transitioning to verify with incomplete tasks"},
+					},
+					Checks: goChecks(goBuild(PhaseVerify))},
+				// IMPLEMENT = fix loop
+				{Name: PhaseVerify, Next: []Phase{PhaseImplement, PhaseLearn}, ReadOnly: true,
+					Agents: []string{"delivery-code-reviewer"},
+					Gates: []ReadinessGate{
+						{To: PhaseLearn, Check: "delegated", Agent: "delivery-code-reviewer", Message: "transitioning to learn without code review delegation"},
+					},
+					Checks: append(goChecks(goTest(PhaseLearn)), childrenDone(PhaseLearn)),
+					Spawns: []WorkflowType{WorkflowBug, WorkflowE2E}},
+				{Name: PhaseLearn, Next: []Phase{PhaseComplete}, Agents: []string{}},
+				{Name: PhaseComplete, Agents: []string{}},
+			},
+		},
+		{
+			Type:         WorkflowBug,
+			Description:  "Bug fix: analyze, fix, review",
+			InitialPhase: PhaseAnalyze,
+			Phases: []PhaseDefinition{
+				{Name: PhaseAnalyze, Next: []Phase{PhaseFix},
+					Agents: []string{"delivery-debugger", "delivery-strategic-architect", "delivery-system-architect", "Plan", "Explore"},
+					Gates: []ReadinessGate{
+						{To: PhaseFix, Check: "tasks_defined", Message: "tasks not defined — use /api/workflows/<id>/tasks to set fix tasks before transitioning to fix"},
+					}},
+				{Name: PhaseFix, Next: []Phase{PhaseReview}, Agents: implementAgents,
+					Gates: []ReadinessGate{
+						{To: PhaseReview, Check: "tasks_done", Message: "transitioning to review with incomplete fixes"},
+					},
+					Checks: goChecks(goBuild(PhaseReview))},
+				// FIX = another iteration
+				{Name: PhaseReview, Next: []Phase{PhaseFix, PhaseComplete}, ReadOnly: true,
+					Agents: []string{"delivery-code-reviewer"},
+					Gates: []ReadinessGate{
+						{To: PhaseComplete, Check: "delegated", Agent: "delivery-code-reviewer", Message: "transitioning to complete without code review delegation"},
+					},
+					Checks: goChecks(goTest(PhaseComplete))},
+				{Name: PhaseComplete},
+			},
+		},
+		{
+			Type:         WorkflowE2E,
+			Description:  "End-to-end test generation and healing",
+			InitialPhase: PhaseSetup,
+			Phases: []PhaseDefinition{
+				{Name: PhaseSetup, Next: []Phase{PhasePlan}, Agents: []string{"delivery-qa-engineer"}},
+				{Name: PhasePlan, Next: []Phase{PhaseGenerate}, Agents: []string{"delivery-strategic-architect", "Plan"},
+					Gates: []ReadinessGate{
+						{To: PhaseGenerate, Check: "plan_set", Message: "transitioning to generate without test plan"},
+					}},
+				{Name: PhaseGenerate, Next: []Phase{PhaseHeal}, Agents: []string{"delivery-qa-engineer", "delivery-frontend-engineer"},
+					Gates: []ReadinessGate{
+						{To: PhaseHeal, Check: "tasks_defined", Message: "transitioning to heal with no tests generated"},
+					}},
+				// GENERATE = regeneration loop
+				{Name: PhaseHeal, Next: []Phase{PhaseGenerate, PhaseComplete}, Agents: []string{"delivery-debugger", "delivery-qa-engineer"},
+					Gates: []ReadinessGate{
+						{To: PhaseComplete, Check: "tasks_done", Message: "transitioning to complete with failing tests"},
+					}},
+				{Name: PhaseComplete, Agents: []string{}},
+			},
+		},
+	}
+}
+
+// registry holds the workflow types in effect for this process.
+var registry = struct {
+	sync.RWMutex
+	defs   map[WorkflowType]WorkflowDefinition
+	errors []string
+}{defs: indexDefinitions(builtinDefinitions())}
+
+func indexDefinitions(defs []WorkflowDefinition) map[WorkflowType]WorkflowDefinition {
+	m := make(map[WorkflowType]WorkflowDefinition, len(defs))
+	for _, d := range defs {
+		if d.InitialPhase == "" && len(d.Phases) > 0 {
+			d.InitialPhase = d.Phases[0].Name
+		}
+		m[d.Type] = d
+	}
+	return m
+}
+
+// LoadWorkflowDefinitions registers the built-in workflow types plus every
+// valid definition under projectRoot's WorkflowDir, replacing whatever was
+// registered before, then the templates under TemplateDir. Invalid files
+// are skipped and reported together in the returned error; the valid ones
+// still take effect.
+func LoadWorkflowDefinitions(projectRoot string) error {
+	custom, err := ReadWorkflowDefinitions(projectRoot)
+	defs := builtinDefinitions()
+	for _, c := range custom {
+		replaced := false
+		for i := range defs {
+			if defs[i].Type == c.Type {
+				defs[i] = c
+				replaced = true
+			}
+		}
+		if !replaced {
+			defs = append(defs, c)
+		}
+	}
+
+	registry.Lock()
+	registry.defs = indexDefinitions(defs)
+	registry.Unlock()
+
+	err = errors.Join(err, loadTemplates(projectRoot))
+	var msgs []string
+	if err != nil {
+		msgs = strings.Split(err.Error(), "\n")
+	}
+	registry.Lock()
+	registry.errors = msgs
+	registry.Unlock()
+	return err
+}
+
+// ReadWorkflowDefinitions parses and validates the definition files under
+// projectRoot's WorkflowDir without registering them. A missing directory
+// is not an error. Definitions are JSON; YAML files are reported rather
+// than silently ignored.
+func ReadWorkflowDefinitions(projectRoot string) ([]WorkflowDefinition, error) {
+	var paths []string
+	for _, pattern := range []string{"*.json", "*.yaml", "*.yml"} {
+		matches, _ := filepath.Glob(filepath.Join(projectRoot, WorkflowDir, pattern))
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+
+	var defs []WorkflowDefinition
+	var errs []error
+	seen := map[WorkflowType]string{}
+	for _, path := range paths {
+		rel := filepath.ToSlash(filepath.Join(WorkflowDir, filepath.Base(path)))
+		if filepath.Ext(path) != ".json" {
+			errs = append(errs, fmt.Errorf("%s: YAML definitions are not supported, convert the file to JSON", rel))
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rel, err))
+			continue
+		}
+		var def WorkflowDefinition
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&def); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rel, err))
+			continue
+		}
+		if err := def.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rel, err))
+			continue
+		}
+		if prev, ok := seen[def.Type]; ok {
+			errs = append(errs, fmt.Errorf("%s: workflow type %q is already defined in %s", rel, def.Type, prev))
+			continue
+		}
+		seen[def.Type] = rel
+		def.Source = rel
+		defs = append(defs, def)
+	}
+	return defs, errors.Join(errs...)
+}
+
+// Validate checks that a definition describes a usable state machine: named
+// phases, known transition targets, a terminal complete phase reachable
+// from the initial one, and well-formed gates, checks, approvals and loop
+// limits.
+func (d WorkflowDefinition) Validate() error {
+	if !workflowTypeRe.MatchString(string(d.Type)) {
+		return fmt.Errorf("invalid workflow type %q: use lowercase letters, digits and dashes", d.Type)
+	}
+	if len(d.Phases) == 0 {
+		return errors.New("no phases defined")
+	}
+	byName := make(map[Phase]PhaseDefinition, len(d.Phases))
+	for _, p := range d.Phases {
+		if !phaseNameRe.MatchString(string(p.Name)) {
+			return fmt.Errorf("invalid phase name %q", p.Name)
+		}
+		if _, dup := byName[p.Name]; dup {
+			return fmt.Errorf("phase %q defined twice", p.Name)
+		}
+		byName[p.Name] = p
+	}
+	complete, ok := byName[PhaseComplete]
+	if !ok {
+		return fmt.Errorf("missing terminal phase %q", PhaseComplete)
+	}
+	if len(complete.Next) > 0 {
+		return fmt.Errorf("phase %q must not have transitions", PhaseComplete)
+	}
+	initial := d.InitialPhase
+	if initial == "" {
+		initial = d.Phases[0].Name
+	}
+	if _, ok := byName[initial]; !ok {
+		return fmt.Errorf("initial phase %q is not defined", initial)
+	}
+	if initial == PhaseComplete {
+		return fmt.Errorf("initial phase cannot be %q", PhaseComplete)
+	}
+	if d.TimeBudget != nil {
+		if err := d.TimeBudget.validate(); err != nil {
+			return fmt.Errorf("time budget: %w", err)
+		}
+	}
+	if d.BudgetUSD < 0 {
+		return fmt.Errorf("budget_usd must not be negative")
+	}
+
+	for _, p := range d.Phases {
+		if p.Name != PhaseComplete && len(p.Next) == 0 {
+			return fmt.Errorf("phase %q has no transitions; only %q may be terminal", p.Name, PhaseComplete)
+		}
+		next := make(map[Phase]bool, len(p.Next))
+		for _, to := range p.Next {
+			if _, ok := byName[to]; !ok {
+				return fmt.Errorf("phase %q transitions to undefined phase %q", p.Name, to)
+			}
+			next[to] = true
+		}
+		switch p.Complexity {
+		case "", ComplexitySimple, ComplexityComplex:
+		default:
+			return fmt.Errorf("phase %q: invalid complexity %q", p.Name, p.Complexity)
+		}
+		for _, g := range p.Gates {
+			if !readinessChecks[g.Check] {
+				return fmt.Errorf("phase %q: unknown gate check %q", p.Name, g.Check)
+			}
+			if g.Check == "delegated" && g.Agent == "" {
+				return fmt.Errorf("phase %q: delegated gate needs an agent", p.Name)
+			}
+			if g.To != "" && !next[g.To] {
+				return fmt.Errorf("phase %q: gate targets %q, which is not a transition", p.Name, g.To)
+			}
+		}
+		names := map[string]bool{}
+		for _, c := range p.Checks {
+			if c.Name == "" {
+				return fmt.Errorf("phase %q: check needs a name", p.Name)
+			}
+			if names[c.Name] {
+				return fmt.Errorf("phase %q: check %q defined twice", p.Name, c.Name)
+			}
+			names[c.Name] = true
+			kinds := 0
+			for _, set := range []bool{len(c.Command) > 0, c.Alerts != "", c.Children} {
+				if set {
+					kinds++
+				}
+			}
+			if kinds != 1 {
+				return fmt.Errorf("phase %q: check %q needs exactly one of command, alerts or children", p.Name, c.Name)
+			}
+			if c.To != "" && !next[c.To] {
+				return fmt.Errorf("phase %q: check %q targets %q, which is not a transition", p.Name, c.Name, c.To)
+			}
+			if c.TimeoutSec < 0 {
+				return fmt.Errorf("phase %q: check %q has a negative timeout", p.Name, c.Name)
+			}
+		}
+		gated := map[Phase]bool{}
+		for _, a := range p.Approvals {
+			if a.To != "" && !next[a.To] {
+				return fmt.Errorf("phase %q: approval targets %q, which is not a transition", p.Name, a.To)
+			}
+			if gated[a.To] {
+				return fmt.Errorf("phase %q: approval for %q defined twice", p.Name, a.To)
+			}
+			gated[a.To] = true
+			for _, r := range a.Reviewers {
+				if strings.TrimSpace(r) == "" {
+					return fmt.Errorf("phase %q: approval has an empty reviewer", p.Name)
+				}
+			}
+		}
+		for _, t := range p.Spawns {
+			if !workflowTypeRe.MatchString(string(t)) {
+				return fmt.Errorf("phase %q: invalid spawned workflow type %q", p.Name, t)
+			}
+		}
+		for to, limit := range p.MaxLoops {
+			if !next[to] {
+				return fmt.Errorf("phase %q: loop limit for %q, which is not a transition", p.Name, to)
+			}
+			if limit <= 0 {
+				return fmt.Errorf("phase %q: loop limit for %q must be positive", p.Name, to)
+			}
+		}
+		if p.OnMaxLoops != nil {
+			if len(p.MaxLoops) == 0 {
+				return fmt.Errorf("phase %q: on_max_loops without max_loops", p.Name)
+			}
+			if err := p.OnMaxLoops.validate(); err != nil {
+				return fmt.Errorf("phase %q: on_max_loops: %w", p.Name, err)
+			}
+		}
+		if p.TimeBudget != nil {
+			if p.Name == PhaseComplete {
+				return fmt.Errorf("phase %q cannot have a time budget", PhaseComplete)
+			}
+			if err := p.TimeBudget.validate(); err != nil {
+				return fmt.Errorf("phase %q: time budget: %w", p.Name, err)
+			}
+		}
+	}
+
+	reached := map[Phase]bool{initial: true}
+	queue := []Phase{initial}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, to := range byName[p].Next {
+			if !reached[to] {
+				reached[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+	for _, p := range d.Phases {
+		if !reached[p.Name] {
+			return fmt.Errorf("phase %q is unreachable from %q", p.Name, initial)
+		}
+	}
+	return nil
+}
+
+// Phase returns the named phase of the definition.
+func (d WorkflowDefinition) Phase(name Phase) (PhaseDefinition, bool) {
+	for _, p := range d.Phases {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return PhaseDefinition{}, false
+}
+
+// Definition returns the registered definition for a workflow type.
+func Definition(wtype WorkflowType) (WorkflowDefinition, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	d, ok := registry.defs[wtype]
+	return d, ok
+}
+
+// Definitions returns every registered workflow type, sorted by type.
+func Definitions() []WorkflowDefinition {
+	registry.RLock()
+	defer registry.RUnlock()
+	out := make([]WorkflowDefinition, 0, len(registry.defs))
+	for _, d := range registry.defs {
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Type < out[j].Type })
+	return out
+}
+
+// DefinitionErrors returns the problems found by the last
+// LoadWorkflowDefinitions, one per invalid file.
+func DefinitionErrors() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	return append([]string(nil), registry.errors...)
+}
+
+// WorkflowTypes returns the registered workflow types, sorted.
+func WorkflowTypes() []WorkflowType {
+	defs := Definitions()
+	types := make([]WorkflowType, len(defs))
+	for i, d := range defs {
+		types[i] = d.Type
+	}
+	return types
+}
+
+// PhaseAgents reports which agents may be delegated in a phase. restricted
+// is false when the workflow type or phase is unknown or the phase leaves
+// agents open, in which case any agent is allowed.
+func PhaseAgents(wtype WorkflowType, phase Phase) (agents []string, restricted bool) {
+	d, ok := Definition(wtype)
+	if !ok {
+		return nil, false
+	}
+	p, ok := d.Phase(phase)
+	if !ok || p.Agents == nil {
+		return nil, false
+	}
+	return p.Agents, true
+}
+
+// PhaseReadOnly reports whether delivery agents are barred from writing
+// during a phase.
+func PhaseReadOnly(wtype WorkflowType, phase Phase) bool {
+	d, ok := Definition(wtype)
+	if !ok {
+		return false
+	}
+	p, ok := d.Phase(phase)
+	return ok && p.ReadOnly
+}
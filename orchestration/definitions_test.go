@@ -0,0 +1,268 @@
+package orchestration
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+const refactorDefinition = `{
+  "type": "refactor",
+  "description": "Behaviour-preserving restructuring",
+  "phases": [
+    {"name": "plan", "next": ["implement"], "agents": ["delivery-system-architect"],
+     "gates": [{"to": "implement", "check": "tasks_defined"}]},
+    {"name": "implement", "next": ["verify"]},
+    {"name": "verify", "next": ["implement", "complete"], "read_only": true,
+     "agents": ["delivery-code-reviewer"], "max_loops": {"implement": 1}},
+    {"name": "complete"}
+  ]
+}`
+
+func writeDefinition(t *testing.T, root, name, content string) {
+	t.Helper()
+	dir := filepath.Join(root, WorkflowDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// resetDefinitions restores the built-in registry after a test loads
+// project definitions.
+func resetDefinitions(t *testing.T) {
+	empty := t.TempDir()
+	t.Cleanup(func() { _ = LoadWorkflowDefinitions(empty) })
+}
+
+func TestBuiltinDefinitionsValidate(t *testing.T) {
+	for _, d := range builtinDefinitions() {
+		if err := d.Validate(); err != nil {
+			t.Errorf("%s: %v", d.Type, err)
+		}
+	}
+	if err := ValidateTransition(WorkflowSpec, PhaseVerify, PhaseImplement); err != nil {
+		t.Errorf("spec verify → implement: %v", err)
+	}
+	if err := ValidateTransition(WorkflowBug, PhaseAnalyze, PhaseComplete); err == nil {
+		t.Error("bug analyze → complete should be rejected")
+	}
+	if InitialPhase(WorkflowE2E) != PhaseSetup || InitialPhase("unknown") != PhasePlan {
+		t.Error("initial phases")
+	}
+	if agents, restricted := PhaseAgents(WorkflowSpec, PhaseAccept); !restricted || len(agents) != 0 {
+		t.Errorf("spec accept agents = %v, %v", agents, restricted)
+	}
+	if _, restricted := PhaseAgents(WorkflowBug, PhaseComplete); restricted {
+		t.Error("bug complete should allow any agent")
+	}
+}
+
+func TestBuiltinGoChecksOptIn(t *testing.T) {
+	resetDefinitions(t)
+	implement := func() []PhaseCheck {
+		def, _ := Definition(WorkflowSpec)
+		phase, _ := def.Phase(PhaseImplement)
+		return phase.Checks
+	}
+	if checks := implement(); len(checks) != 0 {
+		t.Fatalf("Go checks should be off by default, got %+v", checks)
+	}
+
+	SetBuiltinGoChecks(true)
+	t.Cleanup(func() { SetBuiltinGoChecks(false) })
+	if err := LoadWorkflowDefinitions(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if checks := implement(); len(checks) != 1 || checks[0].Name != "build" || checks[0].IfExists != "go.mod" {
+		t.Fatalf("implement checks = %+v", checks)
+	}
+}
+
+func TestLoadWorkflowDefinitions(t *testing.T) {
+	resetDefinitions(t)
+	root := t.TempDir()
+	writeDefinition(t, root, "refactor.json", refactorDefinition)
+	writeDefinition(t, root, "broken.json", `{"type": "spike", "phases": [{"name": "explore", "next": ["report"]}, {"name": "complete"}]}`)
+	writeDefinition(t, root, "typo.json", `{"type": "release", "phasez": []}`)
+	writeDefinition(t, root, "hotfix.yaml", "type: hotfix\n")
+
+	err := LoadWorkflowDefinitions(root)
+	if err == nil {
+		t.Fatal("expected errors for invalid definitions")
+	}
+	if msg := err.Error(); !strings.Contains(msg, "broken.json") || !strings.Contains(msg, `undefined phase "report"`) || !strings.Contains(msg, "typo.json") || !strings.Contains(msg, "hotfix.yaml: YAML") {
+		t.Fatalf("error = %v", err)
+	}
+	if len(DefinitionErrors()) != 3 {
+		t.Fatalf("DefinitionErrors = %v", DefinitionErrors())
+	}
+
+	def, ok := Definition("refactor")
+	if !ok || def.InitialPhase != PhasePlan || def.Source != ".stratus/workflows/refactor.json" {
+		t.Fatalf("refactor = %+v, %v", def, ok)
+	}
+	if _, ok := Definition("spike"); ok {
+		t.Fatal("invalid definition should not be registered")
+	}
+	if _, ok := Definition(WorkflowSpec); !ok {
+		t.Fatal("built-ins stay registered")
+	}
+	if err := ValidateTransition("refactor", PhasePlan, PhaseImplement); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateTransition("refactor", PhasePlan, PhaseVerify); err == nil {
+		t.Fatal("plan → verify is not a refactor transition")
+	}
+	if !PhaseReadOnly("refactor", PhaseVerify) || PhaseReadOnly("refactor", PhaseImplement) {
+		t.Fatal("read-only phases")
+	}
+	if agents, restricted := PhaseAgents("refactor", PhaseImplement); restricted || agents != nil {
+		t.Fatal("phases without an agent list allow any agent")
+	}
+}
+
+func TestDefinitionValidate(t *testing.T) {
+	cases := map[string]string{
+		"no complete":      `{"type": "x", "phases": [{"name": "a", "next": ["a"]}]}`,
+		"dead end":         `{"type": "x", "phases": [{"name": "a", "next": ["b"]}, {"name": "b"}, {"name": "complete"}]}`,
+		"unreachable":      `{"type": "x", "phases": [{"name": "a", "next": ["complete"]}, {"name": "b", "next": ["complete"]}, {"name": "complete"}]}`,
+		"bad gate":         `{"type": "x", "phases": [{"name": "a", "next": ["complete"], "gates": [{"check": "vibes"}]}, {"name": "complete"}]}`,
+		"gate off-path":    `{"type": "x", "phases": [{"name": "a", "next": ["complete"], "gates": [{"to": "b", "check": "plan_set"}]}, {"name": "complete"}]}`,
+		"loop non-target":  `{"type": "x", "phases": [{"name": "a", "next": ["complete"], "max_loops": {"b": 2}}, {"name": "complete"}]}`,
+		"bad type":         `{"type": "Security Fix", "phases": [{"name": "a", "next": ["complete"]}, {"name": "complete"}]}`,
+		"check no action":  `{"type": "x", "phases": [{"name": "a", "next": ["complete"], "checks": [{"name": "c"}]}, {"name": "complete"}]}`,
+		"check off-path":   `{"type": "x", "phases": [{"name": "a", "next": ["complete"], "checks": [{"name": "c", "to": "b", "alerts": "governance_violation"}]}, {"name": "complete"}]}`,
+		"check two kinds":  `{"type": "x", "phases": [{"name": "a", "next": ["complete"], "checks": [{"name": "c", "children": true, "alerts": "governance_violation"}]}, {"name": "complete"}]}`,
+		"bad spawn":        `{"type": "x", "phases": [{"name": "a", "next": ["complete"], "spawns": ["Not A Type"]}, {"name": "complete"}]}`,
+		"bad budget":       `{"type": "x", "time_budget": {"timeout": "soon", "action": "abort"}, "phases": [{"name": "a", "next": ["complete"]}, {"name": "complete"}]}`,
+		"bad escalation":   `{"type": "x", "phases": [{"name": "a", "next": ["complete"], "time_budget": {"timeout": "1h", "action": "panic"}}, {"name": "complete"}]}`,
+		"policy no loops":  `{"type": "x", "phases": [{"name": "a", "next": ["complete"], "on_max_loops": {"action": "abort"}}, {"name": "complete"}]}`,
+		"approval stray":   `{"type": "x", "phases": [{"name": "a", "next": ["complete"], "approvals": [{"to": "b"}]}, {"name": "complete"}]}`,
+		"complete initial": `{"type": "x", "initial_phase": "complete", "phases": [{"name": "a", "next": ["complete"]}, {"name": "complete"}]}`,
+	}
+	for name, content := range cases {
+		root := t.TempDir()
+		writeDefinition(t, root, "x.json", content)
+		if defs, err := ReadWorkflowDefinitions(root); err == nil || len(defs) != 0 {
+			t.Errorf("%s: expected a validation error, got %v", name, defs)
+		}
+	}
+}
+
+func TestCoordinator_CustomWorkflowGatesAndLoopLimit(t *testing.T) {
+	resetDefinitions(t)
+	root := t.TempDir()
+	writeDefinition(t, root, "refactor.json", refactorDefinition)
+	if err := LoadWorkflowDefinitions(root); err != nil {
+		t.Fatal(err)
+	}
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	coord := NewCoordinator(database)
+
+	if _, err := coord.Start("release-1", "release", ComplexitySimple, "Unknown"); err == nil {
+		t.Fatal("starting an undefined workflow type should fail")
+	}
+	state, err := coord.Start("refactor-db", "refactor", ComplexitySimple, "Split DB package")
+	if err != nil || state.Phase != PhasePlan {
+		t.Fatalf("Start = %+v, %v", state, err)
+	}
+	if w := validatePhaseReadiness(state, PhaseImplement); len(w) != 1 || !strings.Contains(w[0], "without tasks") {
+		t.Fatalf("readiness warnings = %v", w)
+	}
+
+	for _, p := range []Phase{PhaseImplement, PhaseVerify, PhaseImplement, PhaseVerify} {
+		if _, err := coord.Transition("refactor-db", p); err != nil {
+			t.Fatalf("transition to %s: %v", p, err)
+		}
+	}
+	// verify → implement is capped at one loop.
+	if _, err := coord.Transition("refactor-db", PhaseImplement); err == nil || !strings.Contains(err.Error(), "loop limit") {
+		t.Fatalf("expected loop limit error, got %v", err)
+	}
+	state, err = coord.Transition("refactor-db", PhaseComplete)
+	if err != nil || state.Loops["verify->implement"] != 1 {
+		t.Fatalf("state = %+v, %v", state, err)
+	}
+}
+
+func TestCoordinator_PhaseChecksBlockTransition(t *testing.T) {
+	resetDefinitions(t)
+	root := t.TempDir()
+	writeDefinition(t, root, "release.json", `{"type": "release", "phases": [
+	  {"name": "build", "next": ["ship"], "checks": [
+	    {"name": "lint", "to": "ship", "command": ["sh", "-c", "echo lint ok"]},
+	    {"name": "test", "to": "ship", "command": ["sh", "-c", "test -f fixed || { echo 'FAIL: TestShip' >&2; exit 1; }"]},
+	    {"name": "docs", "command": ["sh", "-c", "exit 1"], "if_exists": "docs"}]},
+	  {"name": "ship", "next": ["complete"], "checks": [
+	    {"name": "governance", "alerts": "governance_violation"},
+	    {"name": "slow", "command": ["sleep", "5"], "timeout_sec": 1}]},
+	  {"name": "complete"}]}`)
+	if err := LoadWorkflowDefinitions(root); err != nil {
+		t.Fatal(err)
+	}
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	coord := NewCoordinator(database)
+	coord.SetProjectRoot(root)
+	if _, err := coord.Start("rel-1", "release", ComplexitySimple, "Release"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = coord.Transition("rel-1", "ship")
+	var checkErr *CheckFailedError
+	if !errors.As(err, &checkErr) {
+		t.Fatalf("expected CheckFailedError, got %v", err)
+	}
+	if len(checkErr.Results) != 3 || !checkErr.Results[0].Passed || checkErr.Results[1].Passed || checkErr.Results[2].Skipped != "docs not found" {
+		t.Fatalf("results = %+v", checkErr.Results)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "- test (sh -c") || !strings.Contains(msg, "FAIL: TestShip") || strings.Contains(msg, "lint") {
+		t.Fatalf("error = %v", err)
+	}
+	if state, _ := coord.Get("rel-1"); state.Phase != "build" {
+		t.Fatalf("phase = %s, want build", state.Phase)
+	}
+	evidence, err := database.ListWorkflowEvidence("rel-1")
+	if err != nil || len(evidence) != 2 {
+		t.Fatalf("evidence = %+v, %v", evidence, err)
+	}
+	if evidence[0].Verdict != "pass" || evidence[1].Verdict != "fail" || !strings.Contains(evidence[1].Content, "FAIL: TestShip") {
+		t.Fatalf("evidence = %+v", evidence)
+	}
+
+	// Fix the test, then hit an open governance alert and a timeout.
+	if err := os.WriteFile(filepath.Join(root, "fixed"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := coord.Transition("rel-1", "ship"); err != nil {
+		t.Fatal(err)
+	}
+	alertID, err := database.SaveGuardianAlert("governance_violation", "warning", "handler bypasses auth middleware", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = coord.Transition("rel-1", PhaseComplete)
+	if !errors.As(err, &checkErr) || !strings.Contains(err.Error(), "handler bypasses auth middleware") || !strings.Contains(err.Error(), "timed out after 1s") {
+		t.Fatalf("expected governance and timeout failures, got %v", err)
+	}
+	if err := database.DismissGuardianAlert(alertID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := coord.Transition("rel-1", PhaseComplete); err == nil || strings.Contains(err.Error(), "governance") {
+		t.Fatalf("expected only the timeout to fail, got %v", err)
+	}
+}
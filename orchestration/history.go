@@ -0,0 +1,558 @@
+package orchestration
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WorkflowEventType names an entry of the workflow event log.
+type WorkflowEventType string
+
+const (
+	WorkflowEventStart        WorkflowEventType = "start"
+	WorkflowEventTransition   WorkflowEventType = "transition"
+	WorkflowEventRewind       WorkflowEventType = "rewind"
+	WorkflowEventDelegation   WorkflowEventType = "delegation"
+	WorkflowEventTasksSet     WorkflowEventType = "tasks_set"
+	WorkflowEventTaskStart    WorkflowEventType = "task_start"
+	WorkflowEventTaskComplete WorkflowEventType = "task_complete"
+	WorkflowEventPlanSet      WorkflowEventType = "plan_set"
+	WorkflowEventDesignSet    WorkflowEventType = "design_set"
+	WorkflowEventSessionSet   WorkflowEventType = "session_set"
+	WorkflowEventBaseCommit   WorkflowEventType = "base_commit"
+	WorkflowEventSummarySet   WorkflowEventType = "summary_set"
+	WorkflowEventAbort        WorkflowEventType = "abort"
+	// Approval events hold and release a transition behind a human decision.
+	WorkflowEventApprovalRequested WorkflowEventType = "approval_requested"
+	WorkflowEventApprovalDecision  WorkflowEventType = "approval_decision"
+	// WorkflowEventChildAdded links a workflow spawned from this one.
+	WorkflowEventChildAdded WorkflowEventType = "child_added"
+	// WorkflowEventEscalation records a breached time budget or loop limit
+	// together with the action the coordinator took.
+	WorkflowEventEscalation WorkflowEventType = "escalation"
+	// WorkflowEventBudgetSet sets the workflow's cost budget.
+	WorkflowEventBudgetSet WorkflowEventType = "budget_set"
+	// WorkflowEventSnapshot seeds the log of a workflow created before
+	// events were recorded with its state at that point.
+	WorkflowEventSnapshot WorkflowEventType = "snapshot"
+)
+
+// WorkflowEvent is one entry of a workflow's append-only event log.
+type WorkflowEvent struct {
+	ID         int64             `json:"id"`
+	WorkflowID string            `json:"workflow_id"`
+	Type       WorkflowEventType `json:"type"`
+	Phase      Phase             `json:"phase"` // phase after the event
+	Actor      string            `json:"actor,omitempty"`
+	Data       EventData         `json:"data"`
+	CreatedAt  string            `json:"created_at"`
+}
+
+// EventData is the payload of a workflow event; each type sets only the
+// fields it needs.
+type EventData struct {
+	WorkflowType WorkflowType   `json:"workflow_type,omitempty"`
+	Complexity   Complexity     `json:"complexity,omitempty"`
+	Title        string         `json:"title,omitempty"`
+	From         Phase          `json:"from,omitempty"`
+	To           Phase          `json:"to,omitempty"`
+	Reason       string         `json:"reason,omitempty"`
+	Agent        string         `json:"agent,omitempty"`
+	Tasks        []string       `json:"tasks,omitempty"`
+	Index        *int           `json:"index,omitempty"`
+	Content      string         `json:"content,omitempty"`
+	SessionID    string         `json:"session_id,omitempty"`
+	Commit       string         `json:"commit,omitempty"`
+	Summary      *ChangeSummary `json:"summary,omitempty"`
+	State        *WorkflowState `json:"state,omitempty"`
+	Reviewers    []string       `json:"reviewers,omitempty"`
+	Decision     string         `json:"decision,omitempty"`
+	Comment      string         `json:"comment,omitempty"`
+	ParentID     string         `json:"parent_id,omitempty"`
+	Template     string         `json:"template,omitempty"`
+	// Delegated seeds delegated agents per phase from a template.
+	Delegated map[string][]string `json:"delegated,omitempty"`
+	Child     string              `json:"child,omitempty"`
+	Trigger   EscalationTrigger   `json:"trigger,omitempty"`
+	Action    EscalationAction    `json:"action,omitempty"`
+	BudgetUSD *float64            `json:"budget_usd,omitempty"`
+}
+
+// apply folds one event into state. It is the only place workflow state
+// changes, so the live state and a replayed Project always agree.
+func apply(state *WorkflowState, e WorkflowEvent) error {
+	d := e.Data
+	switch e.Type {
+	case WorkflowEventStart:
+		*state = WorkflowState{
+			ID:         e.WorkflowID,
+			Type:       d.WorkflowType,
+			Phase:      d.To,
+			Complexity: d.Complexity,
+			Delegated:  map[string][]string{},
+			Tasks:      []Task{},
+			Title:      d.Title,
+			ParentID:   d.ParentID,
+			Template:   d.Template,
+			CreatedAt:  e.CreatedAt,
+		}
+		state.PhaseEnteredAt = e.CreatedAt
+		if d.BudgetUSD != nil {
+			state.BudgetUSD = *d.BudgetUSD
+		}
+		for phase, agents := range d.Delegated {
+			state.Delegated[phase] = append([]string(nil), agents...)
+		}
+		if len(d.Tasks) > 0 {
+			state.Tasks = make([]Task, len(d.Tasks))
+			for i, t := range d.Tasks {
+				state.Tasks[i] = Task{Index: i, Title: t, Status: "pending"}
+			}
+			state.TotalTasks = len(d.Tasks)
+		}
+		state.PlanContent = d.Content
+	case WorkflowEventSnapshot:
+		if d.State == nil {
+			return errors.New("snapshot event without state")
+		}
+		*state = *d.State
+	case WorkflowEventTransition:
+		state.Phase = d.To
+		if state.Loops == nil {
+			state.Loops = map[string]int{}
+		}
+		state.Loops[loopKey(d.From, d.To)]++
+		state.PhaseEnteredAt = e.CreatedAt
+		closeApproval(state, ApprovalApproved)
+	case WorkflowEventRewind:
+		state.Phase = d.To
+		state.PhaseEnteredAt = e.CreatedAt
+		closeApproval(state, ApprovalRejected)
+	case WorkflowEventApprovalRequested:
+		state.Approval = &Approval{
+			From:        d.From,
+			To:          d.To,
+			Status:      ApprovalPending,
+			Reviewers:   d.Reviewers,
+			Message:     d.Reason,
+			RequestedAt: e.CreatedAt,
+			Decisions:   []ApprovalDecision{},
+		}
+	case WorkflowEventApprovalDecision:
+		if !state.Approval.Awaiting() {
+			return errors.New("approval decision without a pending approval")
+		}
+		a := *state.Approval
+		a.Decisions = append(append([]ApprovalDecision{}, a.Decisions...), ApprovalDecision{
+			Actor: e.Actor, Decision: d.Decision, Comment: d.Comment, At: e.CreatedAt,
+		})
+		switch {
+		case d.Decision == DecisionReject:
+			a.Status = ApprovalRejected
+		case a.To == "" && d.Decision == DecisionApprove && len(a.Outstanding()) == 0:
+			// A hold without a transition is released by the approval itself.
+			a.Status = ApprovalApproved
+		}
+		state.Approval = &a
+	case WorkflowEventDelegation:
+		state.Delegated[string(state.Phase)] = append(state.Delegated[string(state.Phase)], d.Agent)
+	case WorkflowEventTasksSet:
+		state.Tasks = make([]Task, len(d.Tasks))
+		for i, t := range d.Tasks {
+			state.Tasks[i] = Task{Index: i, Title: t, Status: "pending"}
+		}
+		state.TotalTasks = len(d.Tasks)
+	case WorkflowEventTaskStart, WorkflowEventTaskComplete:
+		if d.Index == nil || *d.Index < 0 || *d.Index >= len(state.Tasks) {
+			return fmt.Errorf("%s event: task index out of range", e.Type)
+		}
+		i := *d.Index
+		if e.Type == WorkflowEventTaskStart {
+			state.Tasks[i].Status = "in_progress"
+			state.CurrentTask = &i
+		} else {
+			state.Tasks[i].Status = "done"
+			state.CurrentTask = nil
+		}
+	case WorkflowEventPlanSet:
+		state.PlanContent = d.Content
+	case WorkflowEventDesignSet:
+		state.DesignContent = d.Content
+	case WorkflowEventSessionSet:
+		state.SessionID = d.SessionID
+	case WorkflowEventBaseCommit:
+		state.BaseCommit = d.Commit
+	case WorkflowEventSummarySet:
+		state.ChangeSummary = d.Summary
+	case WorkflowEventChildAdded:
+		state.Children = append(append([]string(nil), state.Children...), d.Child)
+	case WorkflowEventEscalation:
+		state.Escalations = append(append([]Escalation(nil), state.Escalations...), Escalation{
+			Trigger: d.Trigger, Phase: state.Phase, Action: d.Action, Agent: d.Agent,
+			Reason: d.Reason, Message: d.Comment, At: e.CreatedAt,
+		})
+		switch d.Action {
+		case EscalateDelegate:
+			phase := state.Phase
+			if d.To != "" {
+				phase = d.To
+			}
+			if !containsString(state.Delegated[string(phase)], d.Agent) {
+				state.Delegated[string(phase)] = append(state.Delegated[string(phase)], d.Agent)
+			}
+		case EscalateApproval:
+			if !state.Approval.Awaiting() {
+				msg := d.Comment
+				if msg == "" {
+					msg = d.Reason
+				}
+				state.Approval = &Approval{
+					From:        state.Phase,
+					To:          d.To,
+					Status:      ApprovalPending,
+					Reviewers:   d.Reviewers,
+					Message:     msg,
+					RequestedAt: e.CreatedAt,
+					Decisions:   []ApprovalDecision{},
+				}
+			}
+		case EscalateAbort:
+			state.Aborted = true
+			closeApproval(state, ApprovalRejected)
+		}
+	case WorkflowEventBudgetSet:
+		if d.BudgetUSD != nil {
+			state.BudgetUSD = *d.BudgetUSD
+		}
+	case WorkflowEventAbort:
+		state.Aborted = true
+		closeApproval(state, ApprovalRejected)
+	default:
+		return fmt.Errorf("unknown workflow event type %q", e.Type)
+	}
+	if state.Delegated == nil {
+		state.Delegated = map[string][]string{}
+	}
+	if state.Tasks == nil {
+		state.Tasks = []Task{}
+	}
+	state.UpdatedAt = e.CreatedAt
+	return nil
+}
+
+// closeApproval ends a pending approval with status, copying it so events
+// never share an Approval with an earlier state.
+func closeApproval(state *WorkflowState, status ApprovalStatus) {
+	if !state.Approval.Awaiting() {
+		return
+	}
+	a := *state.Approval
+	a.Status = status
+	state.Approval = &a
+}
+
+// Project rebuilds a workflow's state from its event log, which must begin
+// with a start or snapshot event.
+func Project(events []WorkflowEvent) (*WorkflowState, error) {
+	if len(events) == 0 {
+		return nil, errors.New("no events")
+	}
+	if t := events[0].Type; t != WorkflowEventStart && t != WorkflowEventSnapshot {
+		return nil, fmt.Errorf("event log starts with %q", t)
+	}
+	state := &WorkflowState{}
+	for _, e := range events {
+		if err := apply(state, e); err != nil {
+			return nil, fmt.Errorf("event %d: %w", e.ID, err)
+		}
+	}
+	return state, nil
+}
+
+// record appends an event to the workflow's log and saves the resulting
+// state as its projection, atomically. The event is applied to the saved
+// state, not to the caller's copy, so concurrent writers never drop each
+// other's changes; state is replaced with the result. Events decided on the
+// workflow's phase fail with ErrConcurrentUpdate when it moved on since the
+// caller read it.
+func (c *Coordinator) record(state *WorkflowState, typ WorkflowEventType, actor string, data EventData) error {
+	tx, err := c.db.SQL().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if typ != WorkflowEventStart {
+		// Write first, so concurrent writers queue on the database lock
+		// instead of reading the same row.
+		if _, err := tx.Exec(`UPDATE workflows SET updated_at = updated_at WHERE id = ?`, state.ID); err != nil {
+			return err
+		}
+		current, err := loadState(tx, state.ID)
+		if err != nil {
+			return err
+		}
+		if phaseEvents[typ] && !samePosition(current, state) {
+			return fmt.Errorf("workflow %q: %w", state.ID, ErrConcurrentUpdate)
+		}
+		*state = *current
+
+		var n int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM workflow_events WHERE workflow_id = ?`, state.ID).Scan(&n); err != nil {
+			return err
+		}
+		if n == 0 {
+			snapshot := *state
+			if err := insertEvent(tx, WorkflowEvent{
+				WorkflowID: state.ID,
+				Type:       WorkflowEventSnapshot,
+				Phase:      state.Phase,
+				Data:       EventData{State: &snapshot},
+				CreatedAt:  state.UpdatedAt,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	e := WorkflowEvent{
+		WorkflowID: state.ID,
+		Type:       typ,
+		Actor:      actor,
+		Data:       data,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if err := apply(state, e); err != nil {
+		return err
+	}
+	e.Phase = state.Phase
+	if err := insertEvent(tx, e); err != nil {
+		return err
+	}
+	if err := saveState(tx, state); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// phaseEvents are validated against the workflow's phase, approval and
+// abort state before they are recorded.
+var phaseEvents = map[WorkflowEventType]bool{
+	WorkflowEventTransition:        true,
+	WorkflowEventRewind:            true,
+	WorkflowEventApprovalRequested: true,
+	WorkflowEventApprovalDecision:  true,
+	WorkflowEventEscalation:        true,
+}
+
+// samePosition reports whether a and b agree on what phase events are
+// decided on.
+func samePosition(a, b *WorkflowState) bool {
+	var ra, rb string
+	if a.Approval.Awaiting() {
+		ra = a.Approval.RequestedAt
+	}
+	if b.Approval.Awaiting() {
+		rb = b.Approval.RequestedAt
+	}
+	return a.Phase == b.Phase && a.Aborted == b.Aborted && ra == rb
+}
+
+func insertEvent(tx *sql.Tx, e WorkflowEvent) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return fmt.Errorf("encode workflow event: %w", err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO workflow_events (workflow_id, type, phase, actor, data, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		e.WorkflowID, e.Type, e.Phase, e.Actor, string(data), e.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert workflow event: %w", err)
+	}
+	return nil
+}
+
+// Events returns a workflow's event log, oldest first.
+func (c *Coordinator) Events(id string) ([]WorkflowEvent, error) {
+	rows, err := c.db.SQL().Query(`
+		SELECT id, workflow_id, type, phase, actor, data, created_at
+		FROM workflow_events WHERE workflow_id = ? ORDER BY id ASC`, id)
+	if err != nil {
+		return nil, fmt.Errorf("list workflow events: %w", err)
+	}
+	defer rows.Close()
+	var events []WorkflowEvent
+	for rows.Next() {
+		var e WorkflowEvent
+		var data string
+		if err := rows.Scan(&e.ID, &e.WorkflowID, &e.Type, &e.Phase, &e.Actor, &data, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &e.Data); err != nil {
+			return nil, fmt.Errorf("decode workflow event %d: %w", e.ID, err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// PhaseVisit is one stay in a phase. LeftAt is empty while the workflow is
+// still in the phase.
+type PhaseVisit struct {
+	Phase      Phase             `json:"phase"`
+	Via        WorkflowEventType `json:"via"` // start, snapshot, transition or rewind
+	EnteredAt  string            `json:"entered_at"`
+	LeftAt     string            `json:"left_at,omitempty"`
+	DurationMs int64             `json:"duration_ms"`
+}
+
+// History is a workflow's event log with the phase timeline derived from it.
+type History struct {
+	WorkflowID string          `json:"workflow_id"`
+	Events     []WorkflowEvent `json:"events"`
+	Visits     []PhaseVisit    `json:"visits"`
+	// TimeInPhaseMs sums the visits per phase; the open visit counts up to
+	// now unless the workflow is complete or aborted.
+	TimeInPhaseMs map[Phase]int64 `json:"time_in_phase_ms"`
+	// Loops counts transitions back into a phase already visited, e.g.
+	// "verify->implement", the way fix loops are usually discussed.
+	Loops   map[string]int `json:"loops"`
+	Rewinds int            `json:"rewinds"`
+}
+
+// History returns the event log of a workflow and its phase timeline. A
+// workflow that predates the event log gets a single synthetic snapshot.
+func (c *Coordinator) History(id string) (*History, error) {
+	state, err := c.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	events, err := c.Events(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		events = []WorkflowEvent{{
+			WorkflowID: id,
+			Type:       WorkflowEventSnapshot,
+			Phase:      state.Phase,
+			Data:       EventData{State: state},
+			CreatedAt:  state.UpdatedAt,
+		}}
+	}
+	return buildHistory(id, events, time.Now().UTC()), nil
+}
+
+func buildHistory(id string, events []WorkflowEvent, now time.Time) *History {
+	h := &History{
+		WorkflowID:    id,
+		Events:        events,
+		Visits:        []PhaseVisit{},
+		TimeInPhaseMs: map[Phase]int64{},
+		Loops:         map[string]int{},
+	}
+	seen := map[Phase]bool{}
+	closeVisit := func(at string) {
+		if len(h.Visits) == 0 {
+			return
+		}
+		v := &h.Visits[len(h.Visits)-1]
+		if v.LeftAt != "" {
+			return
+		}
+		v.LeftAt = at
+		v.DurationMs = elapsedMs(v.EnteredAt, at)
+		h.TimeInPhaseMs[v.Phase] += v.DurationMs
+	}
+	enter := func(p Phase, via WorkflowEventType, at string) {
+		closeVisit(at)
+		h.Visits = append(h.Visits, PhaseVisit{Phase: p, Via: via, EnteredAt: at})
+		seen[p] = true
+	}
+
+	finished := false
+	for _, e := range events {
+		switch e.Type {
+		case WorkflowEventStart, WorkflowEventSnapshot:
+			enter(e.Phase, e.Type, e.CreatedAt)
+			if s := e.Data.State; s != nil && s.Aborted {
+				finished = true
+			}
+		case WorkflowEventTransition:
+			if seen[e.Data.To] {
+				h.Loops[loopKey(e.Data.From, e.Data.To)]++
+			}
+			enter(e.Data.To, e.Type, e.CreatedAt)
+			finished = false
+		case WorkflowEventRewind:
+			h.Rewinds++
+			enter(e.Data.To, e.Type, e.CreatedAt)
+			finished = false
+		case WorkflowEventAbort:
+			closeVisit(e.CreatedAt)
+			finished = true
+		}
+	}
+	if last := len(h.Visits) - 1; last >= 0 && !finished {
+		v := &h.Visits[last]
+		if v.Phase == PhaseComplete {
+			v.LeftAt = v.EnteredAt
+		} else if v.LeftAt == "" {
+			v.DurationMs = elapsedMs(v.EnteredAt, now.Format(time.RFC3339Nano))
+			h.TimeInPhaseMs[v.Phase] += v.DurationMs
+		}
+	}
+	return h
+}
+
+func elapsedMs(from, to string) int64 {
+	a, err1 := time.Parse(time.RFC3339Nano, from)
+	b, err2 := time.Parse(time.RFC3339Nano, to)
+	if err1 != nil || err2 != nil || b.Before(a) {
+		return 0
+	}
+	return b.Sub(a).Milliseconds()
+}
+
+// Rewind moves a workflow back to a phase it has already been in, bypassing
+// the transition table. The rewind, its reason and actor are kept in the
+// event log. Loop counters are left alone: transitions taken before the
+// rewind still count toward loop limits.
+func (c *Coordinator) Rewind(id string, to Phase, reason, actor string) (*WorkflowState, error) {
+	state, err := c.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if state.Aborted {
+		return nil, fmt.Errorf("workflow %q is aborted", id)
+	}
+	if to == state.Phase {
+		return nil, fmt.Errorf("workflow is already in phase %q", to)
+	}
+	if to == PhaseComplete {
+		return nil, fmt.Errorf("cannot rewind to %q", PhaseComplete)
+	}
+	h, err := c.History(id)
+	if err != nil {
+		return nil, err
+	}
+	visited := false
+	for _, v := range h.Visits {
+		if v.Phase == to {
+			visited = true
+			break
+		}
+	}
+	if !visited {
+		return nil, fmt.Errorf("workflow %q was never in phase %q", id, to)
+	}
+	if err := c.record(state, WorkflowEventRewind, actor, EventData{From: state.Phase, To: to, Reason: reason}); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
@@ -0,0 +1,246 @@
+package orchestration
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+func newHistoryCoordinator(t *testing.T) *Coordinator {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("db.Open: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return NewCoordinator(database)
+}
+
+func TestHistory_ProjectionMatchesStoredState(t *testing.T) {
+	coord := newHistoryCoordinator(t)
+	id := "bug-history"
+	if _, err := coord.Start(id, WorkflowBug, ComplexitySimple, "History"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	steps := []func() error{
+		func() error { _, err := coord.RecordDelegation(id, "delivery-debugger"); return err },
+		func() error { _, err := coord.Transition(id, PhaseFix); return err },
+		func() error { _, err := coord.SetTasks(id, []string{"a", "b"}); return err },
+		func() error { _, err := coord.StartTask(id, 0); return err },
+		func() error { _, err := coord.CompleteTask(id, 0); return err },
+		func() error { _, err := coord.SetPlanContent(id, "plan"); return err },
+		func() error { return coord.SetSessionID(id, "sess-1") },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+
+	stored, err := coord.Get(id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	events, err := coord.Events(id)
+	if err != nil {
+		t.Fatalf("Events: %v", err)
+	}
+	if len(events) != len(steps)+1 {
+		t.Fatalf("events: got %d want %d", len(events), len(steps)+1)
+	}
+	if events[2].Actor != "" || events[1].Actor != "delivery-debugger" {
+		t.Errorf("delegation actor not recorded: %+v", events[1])
+	}
+	projected, err := Project(events)
+	if err != nil {
+		t.Fatalf("Project: %v", err)
+	}
+	if !reflect.DeepEqual(projected, stored) {
+		t.Errorf("projection differs from stored state:\n got %+v\nwant %+v", projected, stored)
+	}
+}
+
+func TestHistory_LoopsAndRewind(t *testing.T) {
+	coord := newHistoryCoordinator(t)
+	id := "spec-history"
+	if _, err := coord.Start(id, WorkflowSpec, ComplexitySimple, "Loops"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	for _, p := range []Phase{PhaseImplement, PhaseVerify, PhaseImplement, PhaseVerify} {
+		if _, err := coord.Transition(id, p); err != nil {
+			t.Fatalf("Transition to %s: %v", p, err)
+		}
+	}
+
+	if _, err := coord.Rewind(id, PhaseLearn, "never visited", "me"); err == nil {
+		t.Error("rewind to an unvisited phase must fail")
+	}
+	state, err := coord.Rewind(id, PhasePlan, "requirements changed", "me")
+	if err != nil {
+		t.Fatalf("Rewind: %v", err)
+	}
+	if state.Phase != PhasePlan {
+		t.Fatalf("phase after rewind: got %s", state.Phase)
+	}
+
+	h, err := coord.History(id)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if got := h.Loops["verify->implement"]; got != 1 {
+		t.Errorf("verify->implement loops: got %d want 1", got)
+	}
+	if got := h.Loops["implement->verify"]; got != 1 {
+		t.Errorf("implement->verify loops: got %d want 1", got)
+	}
+	if h.Rewinds != 1 {
+		t.Errorf("rewinds: got %d want 1", h.Rewinds)
+	}
+	if len(h.Visits) != 6 {
+		t.Fatalf("visits: got %d want 6", len(h.Visits))
+	}
+	last := h.Events[len(h.Events)-1]
+	if last.Type != WorkflowEventRewind || last.Actor != "me" || last.Data.Reason != "requirements changed" {
+		t.Errorf("rewind audit record: %+v", last)
+	}
+}
+
+func TestBuildHistory_TimeInPhase(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(min int) string { return t0.Add(time.Duration(min) * time.Minute).Format(time.RFC3339Nano) }
+	events := []WorkflowEvent{
+		{Type: WorkflowEventStart, Phase: PhasePlan, Data: EventData{To: PhasePlan}, CreatedAt: at(0)},
+		{Type: WorkflowEventTransition, Phase: PhaseImplement, Data: EventData{From: PhasePlan, To: PhaseImplement}, CreatedAt: at(10)},
+		{Type: WorkflowEventTransition, Phase: PhaseVerify, Data: EventData{From: PhaseImplement, To: PhaseVerify}, CreatedAt: at(40)},
+		{Type: WorkflowEventTransition, Phase: PhaseImplement, Data: EventData{From: PhaseVerify, To: PhaseImplement}, CreatedAt: at(45)},
+	}
+	h := buildHistory("wf", events, t0.Add(60*time.Minute))
+
+	want := map[Phase]int64{
+		PhasePlan:      10 * 60_000,
+		PhaseImplement: (30 + 15) * 60_000,
+		PhaseVerify:    5 * 60_000,
+	}
+	if !reflect.DeepEqual(h.TimeInPhaseMs, want) {
+		t.Errorf("time in phase: got %v want %v", h.TimeInPhaseMs, want)
+	}
+	if h.Visits[len(h.Visits)-1].LeftAt != "" {
+		t.Error("current visit must stay open")
+	}
+}
+
+func TestHistory_LegacyWorkflowGetsSnapshot(t *testing.T) {
+	coord := newHistoryCoordinator(t)
+	_, err := coord.db.SQL().Exec(`
+		INSERT INTO workflows (id, type, phase, complexity, state_json, created_at, updated_at)
+		VALUES ('legacy', 'spec', 'implement', 'simple', '{"title":"Old"}', '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')`)
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if _, err := coord.Transition("legacy", PhaseVerify); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	events, err := coord.Events("legacy")
+	if err != nil {
+		t.Fatalf("Events: %v", err)
+	}
+	if len(events) != 2 || events[0].Type != WorkflowEventSnapshot || events[1].Type != WorkflowEventTransition {
+		t.Fatalf("events: %+v", events)
+	}
+	projected, err := Project(events)
+	if err != nil {
+		t.Fatalf("Project: %v", err)
+	}
+	if projected.Phase != PhaseVerify || projected.Title != "Old" {
+		t.Errorf("projected: %+v", projected)
+	}
+}
+
+func TestHistory_StaleWriterKeepsOtherChanges(t *testing.T) {
+	coord := newHistoryCoordinator(t)
+	id := "bug-stale"
+	if _, err := coord.Start(id, WorkflowBug, ComplexitySimple, "Stale"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	stale, err := coord.Get(id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if _, err := coord.SetTasks(id, []string{"a"}); err != nil {
+		t.Fatalf("SetTasks: %v", err)
+	}
+	if err := coord.record(stale, WorkflowEventDelegation, "", EventData{Agent: "delivery-debugger"}); err != nil {
+		t.Fatalf("record on a stale copy: %v", err)
+	}
+	if len(stale.Tasks) != 1 {
+		t.Errorf("the caller's copy should be brought up to date, got tasks %+v", stale.Tasks)
+	}
+
+	// A transition decided on a phase the workflow has left is refused.
+	stale, _ = coord.Get(id)
+	if _, err := coord.Transition(id, PhaseFix); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	err = coord.record(stale, WorkflowEventTransition, "", EventData{From: PhaseAnalyze, To: PhaseFix})
+	if !errors.Is(err, ErrConcurrentUpdate) {
+		t.Fatalf("expected ErrConcurrentUpdate, got %v", err)
+	}
+
+	stored, _ := coord.Get(id)
+	events, _ := coord.Events(id)
+	projected, err := Project(events)
+	if err != nil {
+		t.Fatalf("Project: %v", err)
+	}
+	if !reflect.DeepEqual(projected, stored) {
+		t.Errorf("projection differs from stored state:\n got %+v\nwant %+v", projected, stored)
+	}
+	if len(stored.Tasks) != 1 || len(stored.Delegated[string(PhaseAnalyze)]) != 1 || stored.Loops["analyze->fix"] != 1 {
+		t.Errorf("stored: tasks %+v, delegated %v, loops %v", stored.Tasks, stored.Delegated, stored.Loops)
+	}
+}
+
+func TestHistory_ConcurrentWritersLoseNothing(t *testing.T) {
+	coord := newHistoryCoordinator(t)
+	id := "bug-concurrent"
+	if _, err := coord.Start(id, WorkflowBug, ComplexitySimple, "Concurrent"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := coord.RecordDelegation(id, fmt.Sprintf("agent-%d", i))
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("RecordDelegation: %v", err)
+		}
+	}
+
+	stored, _ := coord.Get(id)
+	if n := len(stored.Delegated[string(PhaseAnalyze)]); n != writers {
+		t.Errorf("delegations: got %d want %d", n, writers)
+	}
+	events, _ := coord.Events(id)
+	projected, err := Project(events)
+	if err != nil {
+		t.Fatalf("Project: %v", err)
+	}
+	if !reflect.DeepEqual(projected, stored) {
+		t.Errorf("projection differs from stored state:\n got %+v\nwant %+v", projected, stored)
+	}
+}
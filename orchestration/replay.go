@@ -0,0 +1,211 @@
+package orchestration
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+// replayLogLimit caps the tool calls loaded into a replay.
+const replayLogLimit = 5000
+
+// ReplayEntryKind names the source of a replay timeline entry.
+type ReplayEntryKind string
+
+const (
+	// ReplayEvent is an entry of the workflow event log.
+	ReplayEvent ReplayEntryKind = "event"
+	// ReplayToolCall is a tool call logged by the Streamer hook.
+	ReplayToolCall ReplayEntryKind = "tool_call"
+	// ReplayTrajectory is a step of the workflow's recorded trajectory.
+	ReplayTrajectory ReplayEntryKind = "trajectory_step"
+	// ReplayAlert is a guardian alert raised about the workflow.
+	ReplayAlert ReplayEntryKind = "alert"
+	// ReplayEvidence is the result of a phase check.
+	ReplayEvidence ReplayEntryKind = "evidence"
+)
+
+// ReplayEntry is one step of a workflow replay. Exactly one of the source
+// fields is set, matching Kind.
+type ReplayEntry struct {
+	Seq     int             `json:"seq"`
+	At      string          `json:"at"`
+	Kind    ReplayEntryKind `json:"kind"`
+	Phase   Phase           `json:"phase"` // phase the workflow was in afterwards
+	Summary string          `json:"summary"`
+
+	Event    *WorkflowEvent       `json:"event,omitempty"`
+	ToolCall *db.WorkflowLog      `json:"tool_call,omitempty"`
+	Step     *db.TrajectoryStep   `json:"step,omitempty"`
+	Alert    *db.GuardianAlert    `json:"alert,omitempty"`
+	Evidence *db.WorkflowEvidence `json:"evidence,omitempty"`
+}
+
+// Replay is a workflow's recorded timeline: its events merged with the tool
+// calls, trajectory steps, guardian alerts and check results around them,
+// oldest first.
+type Replay struct {
+	WorkflowID string        `json:"workflow_id"`
+	Type       WorkflowType  `json:"type"`
+	Title      string        `json:"title"`
+	Entries    []ReplayEntry `json:"entries"`
+	Visits     []PhaseVisit  `json:"visits"`
+}
+
+// Replay assembles the timeline of a workflow, finished or not.
+func (c *Coordinator) Replay(id string) (*Replay, error) {
+	h, err := c.History(id)
+	if err != nil {
+		return nil, err
+	}
+	evidence, err := c.db.ListWorkflowEvidence(id)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := c.db.GetWorkflowLogs(id, replayLogLimit)
+	if err != nil {
+		return nil, fmt.Errorf("list workflow logs: %w", err)
+	}
+	trajectory, err := c.db.GetTrajectoryByWorkflowID(id)
+	if err != nil {
+		return nil, err
+	}
+	alerts, err := c.db.ListWorkflowGuardianAlerts(id)
+	if err != nil {
+		return nil, fmt.Errorf("list workflow alerts: %w", err)
+	}
+
+	var entries []ReplayEntry
+	for i := range h.Events {
+		e := &h.Events[i]
+		entries = append(entries, ReplayEntry{At: e.CreatedAt, Kind: ReplayEvent, Summary: describeEvent(e), Event: e})
+	}
+	for i := range evidence {
+		ev := &evidence[i]
+		entries = append(entries, ReplayEntry{
+			At: ev.CreatedAt, Kind: ReplayEvidence, Evidence: ev,
+			Summary: fmt.Sprintf("check %s %s (%s → %s)", ev.Name, ev.Verdict, ev.Phase, ev.ToPhase),
+		})
+	}
+	for i := range logs {
+		l := &logs[i]
+		entries = append(entries, ReplayEntry{At: l.Ts, Kind: ReplayToolCall, Summary: l.ToolName + ": " + l.Summary, ToolCall: l})
+	}
+	if trajectory != nil {
+		for i := range trajectory.Steps {
+			s := &trajectory.Steps[i]
+			summary := s.AgentName + " " + s.ActionType
+			if s.OutputSummary != "" {
+				summary += ": " + s.OutputSummary
+			}
+			entries = append(entries, ReplayEntry{At: s.Timestamp.UTC().Format(time.RFC3339Nano), Kind: ReplayTrajectory, Summary: summary, Step: s})
+		}
+	}
+	for i := range alerts {
+		a := &alerts[i]
+		entries = append(entries, ReplayEntry{At: a.CreatedAt, Kind: ReplayAlert, Summary: fmt.Sprintf("[%s] %s", a.Severity, a.Message), Alert: a})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return parseTime(entries[i].At).Before(parseTime(entries[j].At))
+	})
+	var phase Phase
+	if len(h.Events) > 0 {
+		phase = h.Events[0].Phase
+	}
+	for i := range entries {
+		entries[i].Seq = i
+		if e := entries[i].Event; e != nil {
+			phase = e.Phase
+		}
+		entries[i].Phase = phase
+	}
+
+	state, err := c.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return &Replay{
+		WorkflowID: id,
+		Type:       state.Type,
+		Title:      state.Title,
+		Entries:    entries,
+		Visits:     h.Visits,
+	}, nil
+}
+
+// StateAt projects the workflow state as it was after entry seq.
+func (r *Replay) StateAt(seq int) (*WorkflowState, error) {
+	if seq < 0 || seq >= len(r.Entries) {
+		return nil, fmt.Errorf("step %d out of range (%d entries)", seq, len(r.Entries))
+	}
+	var events []WorkflowEvent
+	for _, e := range r.Entries[:seq+1] {
+		if e.Event != nil {
+			events = append(events, *e.Event)
+		}
+	}
+	return Project(events)
+}
+
+// parseTime parses the timestamps of the replay sources; unparsable ones
+// sort first.
+func parseTime(ts string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, ts)
+	return t
+}
+
+// describeEvent is a one-line, human-readable account of an event.
+func describeEvent(e *WorkflowEvent) string {
+	d := e.Data
+	switch e.Type {
+	case WorkflowEventStart:
+		if d.Template != "" {
+			return fmt.Sprintf("started in %s from template %s", e.Phase, d.Template)
+		}
+		return fmt.Sprintf("started in %s", e.Phase)
+	case WorkflowEventSnapshot:
+		return fmt.Sprintf("recorded state in %s", e.Phase)
+	case WorkflowEventTransition:
+		if e.Actor != "" {
+			return fmt.Sprintf("%s → %s (approved by %s)", d.From, d.To, e.Actor)
+		}
+		return fmt.Sprintf("%s → %s", d.From, d.To)
+	case WorkflowEventRewind:
+		return fmt.Sprintf("rewound %s → %s: %s", d.From, d.To, d.Reason)
+	case WorkflowEventDelegation:
+		return "delegated " + d.Agent
+	case WorkflowEventTasksSet:
+		return fmt.Sprintf("%d tasks set", len(d.Tasks))
+	case WorkflowEventTaskStart, WorkflowEventTaskComplete:
+		verb := "started"
+		if e.Type == WorkflowEventTaskComplete {
+			verb = "completed"
+		}
+		if d.Index != nil {
+			return fmt.Sprintf("task %d %s", *d.Index, verb)
+		}
+		return "task " + verb
+	case WorkflowEventPlanSet:
+		return "plan updated"
+	case WorkflowEventDesignSet:
+		return "design updated"
+	case WorkflowEventApprovalRequested:
+		return fmt.Sprintf("approval requested for %s → %s", d.From, d.To)
+	case WorkflowEventApprovalDecision:
+		return fmt.Sprintf("%s: %s", e.Actor, d.Decision)
+	case WorkflowEventChildAdded:
+		return "spawned " + d.Child
+	case WorkflowEventEscalation:
+		return fmt.Sprintf("escalated (%s → %s): %s", d.Trigger, d.Action, d.Reason)
+	case WorkflowEventBudgetSet:
+		if d.BudgetUSD != nil {
+			return fmt.Sprintf("budget set to $%.2f", *d.BudgetUSD)
+		}
+	case WorkflowEventAbort:
+		return "aborted"
+	}
+	return string(e.Type)
+}
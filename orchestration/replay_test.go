@@ -0,0 +1,175 @@
+package orchestration
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+const replayDefinition = `{"type": "release", "phases": [
+  {"name": "build", "next": ["verify"], "checks": [{"name": "drift", "alerts": "drift"}]},
+  {"name": "verify", "next": ["build", "complete"], "read_only": true},
+  {"name": "complete"}]}`
+
+// recordReplayWorkflow runs rel-1 through a blocked and a passing attempt
+// at build → verify, an edit and a delegation in verify, and completion.
+func recordReplayWorkflow(t *testing.T) *Coordinator {
+	t.Helper()
+	resetDefinitions(t)
+	root := t.TempDir()
+	writeDefinition(t, root, "release.json", replayDefinition)
+	if err := LoadWorkflowDefinitions(root); err != nil {
+		t.Fatal(err)
+	}
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	coord := NewCoordinator(database)
+
+	// Log timestamps have millisecond precision; keep the steps apart.
+	pause := func() { time.Sleep(3 * time.Millisecond) }
+	if _, err := coord.Start("rel-1", "release", ComplexitySimple, "Release"); err != nil {
+		t.Fatal(err)
+	}
+	pause()
+	alertID, err := database.SaveGuardianAlert("drift", "warning", "docs drifted", map[string]interface{}{"workflow_id": "rel-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pause()
+	if _, err := coord.Transition("rel-1", "verify"); err == nil {
+		t.Fatal("the drift alert should block the transition")
+	}
+	pause()
+	if err := database.DismissGuardianAlert(alertID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := coord.Transition("rel-1", "verify"); err != nil {
+		t.Fatal(err)
+	}
+	pause()
+	if _, err := database.SaveWorkflowLog("rel-1", "sess-1", "Edit", "main.go"); err != nil {
+		t.Fatal(err)
+	}
+	pause()
+	if _, err := coord.RecordDelegation("rel-1", "delivery-qa-engineer"); err != nil {
+		t.Fatal(err)
+	}
+	pause()
+	if _, err := coord.Transition("rel-1", "complete"); err != nil {
+		t.Fatal(err)
+	}
+	return coord
+}
+
+func TestCoordinator_Replay(t *testing.T) {
+	coord := recordReplayWorkflow(t)
+	r, err := coord.Replay("rel-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var kinds []ReplayEntryKind
+	for i, e := range r.Entries {
+		if e.Seq != i {
+			t.Errorf("entry %d has seq %d", i, e.Seq)
+		}
+		kinds = append(kinds, e.Kind)
+	}
+	want := []ReplayEntryKind{ReplayEvent, ReplayAlert, ReplayEvidence, ReplayEvidence, ReplayEvent, ReplayToolCall, ReplayEvent, ReplayEvent}
+	if len(kinds) != len(want) {
+		t.Fatalf("kinds: got %v want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("kinds: got %v want %v", kinds, want)
+		}
+	}
+
+	edit := r.Entries[5]
+	if edit.Phase != "verify" || edit.Summary != "Edit: main.go" {
+		t.Errorf("tool call entry: %+v", edit)
+	}
+	state, err := r.StateAt(edit.Seq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Phase != "verify" || len(state.Delegated["verify"]) != 0 {
+		t.Errorf("state at the edit: phase %s, delegated %v", state.Phase, state.Delegated)
+	}
+	if _, err := r.StateAt(len(r.Entries)); err == nil {
+		t.Error("a step past the end should be refused")
+	}
+	if len(r.Visits) != 3 {
+		t.Errorf("visits: %+v", r.Visits)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	coord := recordReplayWorkflow(t)
+	r, err := coord.Replay("rel-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := Definition("release")
+
+	same, err := Simulate(r, base, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same.Differences != 0 || same.FirstDifference != nil {
+		t.Fatalf("the registered definition should agree with itself: %+v", same)
+	}
+
+	// No drift check, a writable verify phase limited to the code reviewer,
+	// and a sign-off before completion.
+	var modified WorkflowDefinition
+	if err := json.Unmarshal([]byte(`{"type": "release", "phases": [
+	  {"name": "build", "next": ["verify"]},
+	  {"name": "verify", "next": ["build", "complete"], "agents": ["delivery-code-reviewer"],
+	   "approvals": [{"to": "complete", "message": "sign-off"}]},
+	  {"name": "complete"}]}`), &modified); err != nil {
+		t.Fatal(err)
+	}
+	sim, err := Simulate(r, base, modified)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type row struct {
+		kind                          SimStepKind
+		recorded, baseline, simulated SimOutcome
+	}
+	want := []row{
+		{SimTransition, SimBlocked, SimBlocked, SimAllowed},
+		{SimTransition, SimAllowed, SimAllowed, SimAllowed},
+		{SimWrite, "", SimBlocked, SimAllowed},
+		{SimDelegation, SimAllowed, SimAllowed, SimBlocked},
+		{SimTransition, SimAllowed, SimAllowed, SimAwaitingApproval},
+	}
+	if len(sim.Steps) != len(want) {
+		t.Fatalf("steps: %+v", sim.Steps)
+	}
+	for i, w := range want {
+		s := sim.Steps[i]
+		got := row{s.Kind, s.Recorded, s.Baseline.Outcome, s.Simulated.Outcome}
+		if got != w {
+			t.Errorf("step %d (%s): got %+v want %+v", i, s.Subject, got, w)
+		}
+	}
+	if sim.Differences != 4 || sim.FirstDifference == nil || *sim.FirstDifference != sim.Steps[0].Seq {
+		t.Errorf("differences: %d, first %v", sim.Differences, sim.FirstDifference)
+	}
+
+	if _, err := coord.Simulate("rel-1", WorkflowDefinition{Phases: modified.Phases}); err != nil {
+		t.Errorf("a definition without a type takes the workflow's: %v", err)
+	}
+	if _, err := coord.Simulate("rel-1", WorkflowDefinition{Type: "release"}); err == nil {
+		t.Error("an invalid definition should be refused")
+	}
+}
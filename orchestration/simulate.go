@@ -0,0 +1,330 @@
+package orchestration
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidSimulation marks a definition a workflow cannot be simulated
+// against.
+var ErrInvalidSimulation = errors.New("invalid simulation")
+
+// SimOutcome is what a workflow definition makes of one recorded step.
+type SimOutcome string
+
+const (
+	SimAllowed SimOutcome = "allowed"
+	SimBlocked SimOutcome = "blocked"
+	// SimAwaitingApproval holds the step until a human approves it.
+	SimAwaitingApproval SimOutcome = "awaiting_approval"
+	// SimEscalated lets the step through after a delegate escalation.
+	SimEscalated SimOutcome = "escalated"
+	// SimUnverified depends on a check that never ran in the recorded
+	// workflow, so its result is unknown.
+	SimUnverified SimOutcome = "unverified"
+)
+
+// SimStepKind names what a simulated step judges.
+type SimStepKind string
+
+const (
+	SimTransition SimStepKind = "transition"
+	SimDelegation SimStepKind = "delegation"
+	SimWrite      SimStepKind = "write"
+)
+
+// SimVerdict is one definition's judgement of a step.
+type SimVerdict struct {
+	Outcome SimOutcome `json:"outcome"`
+	Reason  string     `json:"reason,omitempty"`
+}
+
+// SimStep is a recorded step judged by the baseline and the modified
+// definition. Seq points at the replay entry it was taken from.
+type SimStep struct {
+	Seq     int         `json:"seq"`
+	At      string      `json:"at"`
+	Kind    SimStepKind `json:"kind"`
+	Phase   Phase       `json:"phase"`
+	Subject string      `json:"subject"` // "verify → implement", an agent or a tool call
+	// Recorded is what actually happened, when the log shows it.
+	Recorded  SimOutcome `json:"recorded,omitempty"`
+	Baseline  SimVerdict `json:"baseline"`
+	Simulated SimVerdict `json:"simulated"`
+	Differs   bool       `json:"differs"`
+
+	ctx simContext // transitions only
+}
+
+// Simulation compares how two definitions judge a recorded workflow.
+type Simulation struct {
+	WorkflowID      string       `json:"workflow_id"`
+	Type            WorkflowType `json:"type"`
+	Steps           []SimStep    `json:"steps"`
+	Differences     int          `json:"differences"`
+	FirstDifference *int         `json:"first_difference,omitempty"` // Seq of the first differing step
+}
+
+// simWriteTools are the file-writing tools a read-only phase blocks. Bash is
+// left out: whether a command writes is decided by the hook from the full
+// command, which the log truncates.
+var simWriteTools = map[string]bool{"Write": true, "Edit": true, "MultiEdit": true, "NotebookEdit": true}
+
+// Simulate replays a workflow's recorded steps against base and modified and
+// reports how each definition judges them: transitions (taken, held for
+// approval, stopped by a loop limit or failing checks), delegations of
+// delivery agents and file writes in read-only phases.
+//
+// The simulation does not branch. Every step is judged in the state the
+// workflow was actually in, so a difference means that step would have gone
+// another way, and later steps still assume it went as recorded. Checks are
+// not run again: their recorded results are reused, and a check no recorded
+// attempt ran leaves the transition unverified.
+func Simulate(r *Replay, base, modified WorkflowDefinition) (*Simulation, error) {
+	sim := &Simulation{WorkflowID: r.WorkflowID, Type: r.Type, Steps: []SimStep{}}
+	add := func(s SimStep) {
+		s.Baseline = s.judge(base)
+		s.Simulated = s.judge(modified)
+		s.Differs = s.Baseline != s.Simulated
+		if s.Differs {
+			if sim.FirstDifference == nil {
+				seq := s.Seq
+				sim.FirstDifference = &seq
+			}
+			sim.Differences++
+		}
+		sim.Steps = append(sim.Steps, s)
+	}
+
+	var (
+		state   *WorkflowState
+		batch   *checkBatch
+		results = map[string]map[string]string{} // check verdicts per loopKey, for the current phase
+	)
+	// flush ends a batch of check results no transition followed. If a check
+	// failed, the batch was a blocked attempt.
+	flush := func() {
+		if batch == nil {
+			return
+		}
+		b := batch
+		batch = nil
+		results[loopKey(b.from, b.to)] = b.verdicts
+		if b.failed() && state != nil {
+			add(newSimTransition(b.entry, state, b.from, b.to, b.verdicts, false, SimBlocked))
+		}
+	}
+	attempt := func(entry ReplayEntry, from, to Phase, approved bool, recorded SimOutcome) {
+		if batch != nil && batch.from == from && batch.to == to {
+			results[loopKey(from, to)] = batch.verdicts
+			batch = nil
+		}
+		flush()
+		add(newSimTransition(entry, state, from, to, results[loopKey(from, to)], approved, recorded))
+	}
+
+	for _, entry := range r.Entries {
+		switch entry.Kind {
+		case ReplayEvidence:
+			ev := entry.Evidence
+			from, to := Phase(ev.Phase), Phase(ev.ToPhase)
+			if batch != nil && (batch.from != from || batch.to != to || batch.verdicts[ev.Name] != "") {
+				flush()
+			}
+			if batch == nil {
+				batch = &checkBatch{entry: entry, from: from, to: to, verdicts: map[string]string{}}
+			}
+			batch.verdicts[ev.Name] = ev.Verdict
+			continue
+		case ReplayToolCall:
+			if state != nil && simWriteTools[entry.ToolCall.ToolName] {
+				add(SimStep{Seq: entry.Seq, At: entry.At, Kind: SimWrite, Phase: state.Phase, Subject: entry.Summary})
+			}
+			continue
+		case ReplayEvent:
+		default:
+			continue
+		}
+
+		e := entry.Event
+		d := e.Data
+		if state != nil {
+			switch e.Type {
+			case WorkflowEventTransition:
+				attempt(entry, d.From, d.To, e.Actor != "", SimAllowed)
+			case WorkflowEventApprovalRequested:
+				attempt(entry, d.From, d.To, false, SimAwaitingApproval)
+			case WorkflowEventEscalation:
+				// A delegate escalation lets the transition go on; the
+				// transition event that follows is judged instead.
+				if d.Trigger == TriggerLoopLimit && d.Action != EscalateDelegate {
+					recorded := SimBlocked
+					if d.Action == EscalateApproval {
+						recorded = SimAwaitingApproval
+					}
+					attempt(entry, state.Phase, d.To, false, recorded)
+				}
+			case WorkflowEventDelegation:
+				flush()
+				if strings.HasPrefix(d.Agent, "delivery-") {
+					add(SimStep{Seq: entry.Seq, At: entry.At, Kind: SimDelegation, Phase: state.Phase, Subject: d.Agent, Recorded: SimAllowed})
+				}
+			default:
+				flush()
+			}
+		}
+
+		if state == nil {
+			state = &WorkflowState{}
+		}
+		before := state.Phase
+		if err := apply(state, *e); err != nil {
+			return nil, fmt.Errorf("replay event %d: %w", e.ID, err)
+		}
+		if state.Phase != before {
+			results = map[string]map[string]string{}
+		}
+	}
+	flush()
+	return sim, nil
+}
+
+// Simulate replays workflow id against def, compared with the registered
+// definition of its type. def may omit its type.
+func (c *Coordinator) Simulate(id string, def WorkflowDefinition) (*Simulation, error) {
+	r, err := c.Replay(id)
+	if err != nil {
+		return nil, err
+	}
+	base, ok := Definition(r.Type)
+	if !ok {
+		return nil, fmt.Errorf("%w: workflow type %q is not registered", ErrInvalidSimulation, r.Type)
+	}
+	if def.Type == "" {
+		def.Type = r.Type
+	}
+	if def.Type != r.Type {
+		return nil, fmt.Errorf("%w: definition is for %q, workflow is %q", ErrInvalidSimulation, def.Type, r.Type)
+	}
+	if err := def.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSimulation, err)
+	}
+	return Simulate(r, base, def)
+}
+
+// checkBatch collects the check results of one transition attempt.
+type checkBatch struct {
+	entry    ReplayEntry // first result
+	from, to Phase
+	verdicts map[string]string // check name → pass | fail
+}
+
+func (b *checkBatch) failed() bool {
+	for _, v := range b.verdicts {
+		if v == "fail" {
+			return true
+		}
+	}
+	return false
+}
+
+// simContext is the recorded state a step is judged in.
+type simContext struct {
+	from, to Phase
+	loops    int
+	checks   map[string]string
+	approved bool
+}
+
+func newSimTransition(entry ReplayEntry, state *WorkflowState, from, to Phase, checks map[string]string, approved bool, recorded SimOutcome) SimStep {
+	return SimStep{
+		Seq:      entry.Seq,
+		At:       entry.At,
+		Kind:     SimTransition,
+		Phase:    from,
+		Subject:  fmt.Sprintf("%s → %s", from, to),
+		Recorded: recorded,
+		ctx:      simContext{from: from, to: to, loops: state.Loops[loopKey(from, to)], checks: checks, approved: approved},
+	}
+}
+
+// judge applies def to the step.
+func (s *SimStep) judge(def WorkflowDefinition) SimVerdict {
+	phase, ok := def.Phase(s.Phase)
+	if !ok {
+		return SimVerdict{SimBlocked, fmt.Sprintf("phase %q is not defined", s.Phase)}
+	}
+	switch s.Kind {
+	case SimDelegation:
+		if phase.Agents != nil && !containsString(phase.Agents, s.Subject) {
+			return SimVerdict{SimBlocked, fmt.Sprintf("%s may not be delegated during %s", s.Subject, s.Phase)}
+		}
+	case SimWrite:
+		if phase.ReadOnly {
+			return SimVerdict{SimBlocked, fmt.Sprintf("%s is read-only for delivery agents", s.Phase)}
+		}
+	case SimTransition:
+		return s.ctx.judge(phase)
+	}
+	return SimVerdict{Outcome: SimAllowed}
+}
+
+// judge follows Coordinator.Transition: the transition must be declared,
+// within its loop limit, past its checks and approved where a gate asks.
+func (c simContext) judge(phase PhaseDefinition) SimVerdict {
+	declared := false
+	for _, p := range phase.Next {
+		declared = declared || p == c.to
+	}
+	if !declared {
+		return SimVerdict{SimBlocked, fmt.Sprintf("invalid transition %q → %q", c.from, c.to)}
+	}
+
+	var loopLimit string
+	onLoopLimit := phase.OnMaxLoops
+	if limit := phase.MaxLoops[c.to]; limit > 0 && c.loops >= limit {
+		loopLimit = fmt.Sprintf("loop limit reached: %q → %q already taken %d times (max %d)", c.from, c.to, c.loops, limit)
+		if onLoopLimit == nil {
+			return SimVerdict{SimBlocked, loopLimit}
+		}
+	}
+
+	var unverified []string
+	for _, chk := range phase.Checks {
+		if chk.To != "" && chk.To != c.to {
+			continue
+		}
+		switch c.checks[chk.Name] {
+		case "pass":
+		case "fail":
+			return SimVerdict{SimBlocked, fmt.Sprintf("check %s failed", chk.Name)}
+		default:
+			unverified = append(unverified, chk.Name)
+		}
+	}
+	if len(unverified) > 0 {
+		return SimVerdict{SimUnverified, "never ran: " + strings.Join(unverified, ", ")}
+	}
+
+	escalated := ""
+	if loopLimit != "" {
+		switch onLoopLimit.Action {
+		case EscalateAbort:
+			return SimVerdict{SimBlocked, loopLimit + "; workflow aborted"}
+		case EscalateApproval:
+			if !c.approved {
+				return SimVerdict{SimAwaitingApproval, loopLimit}
+			}
+		case EscalateDelegate:
+			escalated = loopLimit
+		}
+	}
+	if g := phase.approvalGate(c.to); g != nil && !c.approved {
+		return SimVerdict{SimAwaitingApproval, g.Message}
+	}
+	if escalated != "" {
+		return SimVerdict{SimEscalated, escalated}
+	}
+	return SimVerdict{Outcome: SimAllowed}
+}
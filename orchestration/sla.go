@@ -0,0 +1,266 @@
+package orchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/internal/scheduler"
+)
+
+// slaCheckInterval is how often RunSLAMonitor looks for workflows over
+// their time budgets.
+const slaCheckInterval = time.Minute
+
+// DefaultEscalationAgent is delegated by a delegate escalation that does not
+// name an agent.
+const DefaultEscalationAgent = "delivery-debugger"
+
+// EscalationAction is what the coordinator does when a budget is breached.
+type EscalationAction string
+
+const (
+	// EscalateDelegate records an agent, by default delivery-debugger, as
+	// delegated so the coordinating session brings it in.
+	EscalateDelegate EscalationAction = "delegate"
+	// EscalateApproval holds the workflow until a human approves it.
+	EscalateApproval EscalationAction = "approval"
+	// EscalateAbort aborts the workflow.
+	EscalateAbort EscalationAction = "abort"
+)
+
+// EscalationTrigger names the budget an escalation was taken for.
+type EscalationTrigger string
+
+const (
+	TriggerWorkflowTimeout EscalationTrigger = "workflow_timeout"
+	TriggerPhaseTimeout    EscalationTrigger = "phase_timeout"
+	TriggerLoopLimit       EscalationTrigger = "loop_limit"
+)
+
+// EscalationPolicy is the action taken when a workflow breaches a budget.
+type EscalationPolicy struct {
+	Action EscalationAction `json:"action"`
+	// Agent is delegated by EscalateDelegate; empty uses
+	// DefaultEscalationAgent.
+	Agent string `json:"agent,omitempty"`
+	// Reviewers must all approve an EscalateApproval hold; empty accepts any
+	// one human approval.
+	Reviewers []string `json:"reviewers,omitempty"`
+	Message   string   `json:"message,omitempty"`
+}
+
+// TimeBudget bounds how long a workflow, or a phase of it, may run before
+// its escalation policy is applied.
+type TimeBudget struct {
+	// Timeout is a Go duration such as "4h" or "90m".
+	Timeout string `json:"timeout"`
+	EscalationPolicy
+}
+
+// duration returns the parsed timeout; Validate has rejected bad values.
+func (b *TimeBudget) duration() time.Duration {
+	d, _ := time.ParseDuration(b.Timeout)
+	return d
+}
+
+func (p EscalationPolicy) validate() error {
+	switch p.Action {
+	case EscalateDelegate, EscalateApproval, EscalateAbort:
+	default:
+		return fmt.Errorf("unknown escalation action %q", p.Action)
+	}
+	if p.Agent != "" && p.Action != EscalateDelegate {
+		return fmt.Errorf("agent is only used by the %s action", EscalateDelegate)
+	}
+	for _, r := range p.Reviewers {
+		if strings.TrimSpace(r) == "" {
+			return fmt.Errorf("empty reviewer name")
+		}
+	}
+	return nil
+}
+
+func (b *TimeBudget) validate() error {
+	d, err := time.ParseDuration(b.Timeout)
+	if err != nil {
+		return fmt.Errorf("timeout: %w", err)
+	}
+	if d <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+	return b.EscalationPolicy.validate()
+}
+
+// Escalation records one breached budget and the action taken for it.
+type Escalation struct {
+	Trigger EscalationTrigger `json:"trigger"`
+	Phase   Phase             `json:"phase"` // phase the workflow was in
+	Action  EscalationAction  `json:"action"`
+	Agent   string            `json:"agent,omitempty"`
+	Reason  string            `json:"reason"`
+	Message string            `json:"message,omitempty"`
+	At      string            `json:"at"`
+}
+
+// Broadcaster is the subset of api.Hub the coordinator uses to announce
+// escalations it takes on its own.
+type Broadcaster interface {
+	BroadcastJSON(msgType string, payload any)
+}
+
+// SetBroadcaster injects the hub escalations are announced on. If never
+// called, escalations are only recorded.
+func (c *Coordinator) SetBroadcaster(b Broadcaster) {
+	c.hub = b
+}
+
+// escalate applies policy to the workflow and records it in the history.
+// to is the target of the transition that breached a loop limit; a delegate
+// escalation delegates the agent there, an approval holds exactly that
+// transition.
+func (c *Coordinator) escalate(state *WorkflowState, trigger EscalationTrigger, p EscalationPolicy, to Phase, reason string) error {
+	data := EventData{
+		Trigger:   trigger,
+		Action:    p.Action,
+		To:        to,
+		Reviewers: p.Reviewers,
+		Reason:    reason,
+		Comment:   p.Message,
+	}
+	if p.Action == EscalateDelegate {
+		data.Agent = p.Agent
+		if data.Agent == "" {
+			data.Agent = DefaultEscalationAgent
+		}
+	}
+	if err := c.record(state, WorkflowEventEscalation, "", data); err != nil {
+		return err
+	}
+	log.Printf("workflow %s: escalated (%s → %s): %s", state.ID, trigger, p.Action, reason)
+	if c.hub != nil {
+		c.hub.BroadcastJSON("workflow_updated", state)
+		c.hub.BroadcastJSON("workflow_escalated", map[string]any{
+			"workflow_id": state.ID,
+			"title":       state.Title,
+			"escalation":  state.Escalations[len(state.Escalations)-1],
+		})
+		if p.Action == EscalateAbort {
+			c.hub.BroadcastJSON("workflow_aborted", map[string]string{"id": state.ID})
+		}
+	}
+	return nil
+}
+
+// CheckTimeBudgets escalates every active workflow that has run past its
+// workflow or current-phase time budget at now. Each budget escalates once:
+// the workflow budget once per workflow, a phase budget once per visit.
+func (c *Coordinator) CheckTimeBudgets(now time.Time) ([]Escalation, error) {
+	rows, err := c.db.SQL().Query(`SELECT id FROM workflows WHERE JSON_EXTRACT(state_json, '$.aborted') IS NOT 1 AND phase != 'complete'`)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var taken []Escalation
+	for _, id := range ids {
+		state, err := c.Get(id)
+		if err != nil {
+			continue
+		}
+		def, ok := Definition(state.Type)
+		if !ok {
+			continue
+		}
+		if b := def.TimeBudget; b != nil && !state.escalated(TriggerWorkflowTimeout, "", "") {
+			if age := elapsed(state.CreatedAt, now); age > b.duration() {
+				reason := fmt.Sprintf("workflow has run %s, over its %s budget", age.Round(time.Minute), b.Timeout)
+				if err := c.escalate(state, TriggerWorkflowTimeout, b.EscalationPolicy, "", reason); err != nil {
+					if errors.Is(err, ErrConcurrentUpdate) {
+						continue // moved on meanwhile; the next check sees where
+					}
+					return taken, err
+				}
+				taken = append(taken, state.Escalations[len(state.Escalations)-1])
+				if state.Aborted {
+					continue
+				}
+			}
+		}
+		phase, ok := def.Phase(state.Phase)
+		if !ok || phase.TimeBudget == nil {
+			continue
+		}
+		entered := state.PhaseEnteredAt
+		if entered == "" {
+			entered = state.UpdatedAt // workflows from before phase_entered_at
+		}
+		if state.escalated(TriggerPhaseTimeout, state.Phase, entered) {
+			continue
+		}
+		if in := elapsed(entered, now); in > phase.TimeBudget.duration() {
+			reason := fmt.Sprintf("%s phase has run %s, over its %s budget", state.Phase, in.Round(time.Minute), phase.TimeBudget.Timeout)
+			if err := c.escalate(state, TriggerPhaseTimeout, phase.TimeBudget.EscalationPolicy, "", reason); err != nil {
+				if errors.Is(err, ErrConcurrentUpdate) {
+					continue
+				}
+				return taken, err
+			}
+			taken = append(taken, state.Escalations[len(state.Escalations)-1])
+		}
+	}
+	return taken, nil
+}
+
+// escalated reports whether the workflow already escalated for trigger in
+// phase (any phase when empty) at or after since (any time when empty).
+func (s *WorkflowState) escalated(trigger EscalationTrigger, phase Phase, since string) bool {
+	from, _ := time.Parse(time.RFC3339Nano, since)
+	for _, e := range s.Escalations {
+		if e.Trigger != trigger || (phase != "" && e.Phase != phase) {
+			continue
+		}
+		if at, err := time.Parse(time.RFC3339Nano, e.At); err != nil || !at.Before(from) {
+			return true
+		}
+	}
+	return false
+}
+
+// elapsed returns the time from an RFC 3339 timestamp to now, or 0 when the
+// timestamp does not parse.
+func elapsed(ts string, now time.Time) time.Duration {
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return 0
+	}
+	return now.Sub(t)
+}
+
+// RunSLAMonitor checks time budgets every minute until ctx is cancelled.
+func (c *Coordinator) RunSLAMonitor(ctx context.Context) {
+	tick := func(ctx context.Context) {
+		if _, err := c.CheckTimeBudgets(time.Now().UTC()); err != nil {
+			log.Printf("workflow sla: %v", err)
+		}
+	}
+	interval := func() time.Duration { return slaCheckInterval }
+	if err := scheduler.New("workflow-sla", interval, tick).Run(ctx); err != nil && err != context.Canceled {
+		log.Printf("workflow sla: scheduler stopped: %v", err)
+	}
+}
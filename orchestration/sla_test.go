@@ -0,0 +1,219 @@
+package orchestration
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+type recordingHub struct{ types []string }
+
+func (h *recordingHub) BroadcastJSON(msgType string, _ any) { h.types = append(h.types, msgType) }
+
+const slaDefinition = `{"type": "release", "time_budget": {"timeout": "48h", "action": "abort"},
+  "phases": [
+    {"name": "build", "next": ["verify"], "time_budget": {"timeout": "4h", "action": "approval", "reviewers": ["alice"]}},
+    {"name": "verify", "next": ["build", "complete"], "max_loops": {"build": 1},
+     "on_max_loops": {"action": "%s"}},
+    {"name": "complete"}]}`
+
+func newSLACoordinator(t *testing.T, loopAction EscalationAction) (*Coordinator, *recordingHub) {
+	t.Helper()
+	resetDefinitions(t)
+	root := t.TempDir()
+	writeDefinition(t, root, "release.json", strings.Replace(slaDefinition, "%s", string(loopAction), 1))
+	if err := LoadWorkflowDefinitions(root); err != nil {
+		t.Fatal(err)
+	}
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	coord := NewCoordinator(database)
+	hub := &recordingHub{}
+	coord.SetBroadcaster(hub)
+	if _, err := coord.Start("rel-1", "release", ComplexitySimple, "Release"); err != nil {
+		t.Fatal(err)
+	}
+	return coord, hub
+}
+
+// loopOnce takes build → verify → build → verify, using up the loop limit.
+func loopOnce(t *testing.T, coord *Coordinator) {
+	t.Helper()
+	for _, p := range []Phase{"verify", "build", "verify"} {
+		if _, err := coord.Transition("rel-1", p); err != nil {
+			t.Fatalf("to %s: %v", p, err)
+		}
+	}
+}
+
+func TestLoopLimitEscalation_Delegate(t *testing.T) {
+	coord, hub := newSLACoordinator(t, EscalateDelegate)
+	loopOnce(t, coord)
+
+	state, err := coord.Transition("rel-1", "build")
+	if err != nil {
+		t.Fatalf("delegate escalation lets the loop go on: %v", err)
+	}
+	if !reflect.DeepEqual(state.Delegated["build"], []string{DefaultEscalationAgent}) {
+		t.Errorf("delegated: %v", state.Delegated)
+	}
+	if len(state.Escalations) != 1 || state.Escalations[0].Trigger != TriggerLoopLimit || state.Escalations[0].Phase != "verify" {
+		t.Errorf("escalations: %+v", state.Escalations)
+	}
+	if !containsString(hub.types, "workflow_escalated") {
+		t.Errorf("escalation not broadcast: %v", hub.types)
+	}
+
+	events, err := coord.Events("rel-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	projected, err := Project(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(projected, state) {
+		t.Errorf("projection differs:\n got %+v\nwant %+v", projected, state)
+	}
+}
+
+func TestLoopLimitEscalation_Approval(t *testing.T) {
+	coord, _ := newSLACoordinator(t, EscalateApproval)
+	loopOnce(t, coord)
+
+	_, err := coord.Transition("rel-1", "build")
+	var pending *ApprovalPendingError
+	if !errors.As(err, &pending) || pending.Approval.To != "build" {
+		t.Fatalf("expected the looping transition to wait for approval, got %v", err)
+	}
+	state, moved, err := coord.ResolveApproval("rel-1", DecisionApprove, "bob", "one more try")
+	if err != nil || !moved || state.Phase != "build" {
+		t.Fatalf("approval: moved=%v phase=%v err=%v", moved, state, err)
+	}
+}
+
+func TestLoopLimitEscalation_Abort(t *testing.T) {
+	coord, _ := newSLACoordinator(t, EscalateAbort)
+	loopOnce(t, coord)
+
+	if _, err := coord.Transition("rel-1", "build"); err == nil || !strings.Contains(err.Error(), "aborted") {
+		t.Fatalf("expected abort, got %v", err)
+	}
+	state, err := coord.Get("rel-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Aborted {
+		t.Error("workflow not aborted")
+	}
+}
+
+func TestLoopLimitEscalation_AfterChecks(t *testing.T) {
+	resetDefinitions(t)
+	root := t.TempDir()
+	writeDefinition(t, root, "release.json", `{"type": "release", "phases": [
+	  {"name": "build", "next": ["verify"]},
+	  {"name": "verify", "next": ["build", "complete"], "max_loops": {"build": 1},
+	   "on_max_loops": {"action": "delegate"},
+	   "checks": [{"name": "ready", "to": "build", "command": ["sh", "-c", "test -f ready"]}]},
+	  {"name": "complete"}]}`)
+	if err := LoadWorkflowDefinitions(root); err != nil {
+		t.Fatal(err)
+	}
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	coord := NewCoordinator(database)
+	coord.SetProjectRoot(root)
+	if _, err := coord.Start("rel-1", "release", ComplexitySimple, "Release"); err != nil {
+		t.Fatal(err)
+	}
+	ready := filepath.Join(root, "ready")
+	if err := os.WriteFile(ready, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loopOnce(t, coord)
+	if err := os.Remove(ready); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failing check refuses the looping transition without escalating,
+	// however often it is retried.
+	for i := 0; i < 2; i++ {
+		var checkErr *CheckFailedError
+		if _, err := coord.Transition("rel-1", "build"); !errors.As(err, &checkErr) {
+			t.Fatalf("expected CheckFailedError, got %v", err)
+		}
+	}
+	if state, _ := coord.Get("rel-1"); len(state.Escalations) != 0 {
+		t.Fatalf("escalated before the checks passed: %+v", state.Escalations)
+	}
+
+	if err := os.WriteFile(ready, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	state, err := coord.Transition("rel-1", "build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Escalations) != 1 || state.Phase != "build" {
+		t.Fatalf("state = %+v", state)
+	}
+}
+
+func TestCheckTimeBudgets(t *testing.T) {
+	coord, _ := newSLACoordinator(t, EscalateAbort)
+	now := time.Now().UTC()
+
+	if taken, err := coord.CheckTimeBudgets(now.Add(time.Hour)); err != nil || len(taken) != 0 {
+		t.Fatalf("within budget: %v %v", taken, err)
+	}
+	taken, err := coord.CheckTimeBudgets(now.Add(5 * time.Hour))
+	if err != nil || len(taken) != 1 || taken[0].Trigger != TriggerPhaseTimeout || taken[0].Action != EscalateApproval {
+		t.Fatalf("phase budget: %+v %v", taken, err)
+	}
+	// Each phase visit escalates once.
+	if taken, err := coord.CheckTimeBudgets(now.Add(6 * time.Hour)); err != nil || len(taken) != 0 {
+		t.Fatalf("repeat check: %+v %v", taken, err)
+	}
+
+	// The hold blocks every transition until a reviewer releases it.
+	_, err = coord.Transition("rel-1", "verify")
+	var pending *ApprovalPendingError
+	if !errors.As(err, &pending) || !strings.Contains(err.Error(), "held in") {
+		t.Fatalf("expected a hold, got %v", err)
+	}
+	if _, _, err := coord.ResolveApproval("rel-1", DecisionApprove, "bob", ""); err == nil {
+		t.Error("only the listed reviewer may release the hold")
+	}
+	state, moved, err := coord.ResolveApproval("rel-1", DecisionApprove, "alice", "")
+	if err != nil || moved || state.Approval.Status != ApprovalApproved {
+		t.Fatalf("release: moved=%v approval=%+v err=%v", moved, state.Approval, err)
+	}
+	if _, err := coord.Transition("rel-1", "verify"); err != nil {
+		t.Fatalf("transition after release: %v", err)
+	}
+
+	taken, err = coord.CheckTimeBudgets(now.Add(49 * time.Hour))
+	if err != nil || len(taken) != 1 || taken[0].Trigger != TriggerWorkflowTimeout {
+		t.Fatalf("workflow budget: %+v %v", taken, err)
+	}
+	state, err = coord.Get("rel-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Aborted {
+		t.Error("workflow over its budget should be aborted")
+	}
+}
@@ -1,6 +1,9 @@
 package orchestration
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 // Phase represents a workflow phase.
 type Phase string
@@ -30,7 +33,8 @@ const (
 	PhaseHeal     Phase = "heal"
 )
 
-// WorkflowType distinguishes spec vs bug workflows.
+// WorkflowType names a workflow definition. spec, bug and e2e are built in;
+// projects add their own under WorkflowDir.
 type WorkflowType string
 
 const (
@@ -47,43 +51,18 @@ const (
 	ComplexityComplex Complexity = "complex"
 )
 
-// validTransitions defines allowed phase transitions per workflow type.
-var validTransitions = map[WorkflowType]map[Phase][]Phase{
-	WorkflowSpec: {
-		// Simple: plan → implement → verify → learn → complete
-		// Complex: plan → discovery → design → governance → accept → implement → verify → learn → complete
-		PhasePlan:      {PhaseImplement, PhaseDiscovery, PhaseAccept},
-		PhaseDiscovery:  {PhaseDesign},
-		PhaseDesign:     {PhaseGovernance, PhasePlan},
-		PhaseGovernance: {PhasePlan},
-		PhaseAccept:     {PhaseImplement},
-		PhaseImplement:  {PhaseVerify},
-		PhaseVerify:     {PhaseImplement, PhaseLearn}, // IMPLEMENT = fix loop
-		PhaseLearn:      {PhaseComplete},
-		PhaseComplete:   {},
-	},
-	WorkflowBug: {
-		PhaseAnalyze: {PhaseFix},
-		PhaseFix:     {PhaseReview},
-		PhaseReview:  {PhaseFix, PhaseComplete}, // FIX = another iteration
-		PhaseComplete: {},
-	},
-	WorkflowE2E: {
-		PhaseSetup:    {PhasePlan},
-		PhasePlan:     {PhaseGenerate},
-		PhaseGenerate: {PhaseHeal},
-		PhaseHeal:     {PhaseGenerate, PhaseComplete}, // GENERATE = regeneration loop
-		PhaseComplete: {},
-	},
-}
-
-// ValidateTransition checks if transitioning from → to is allowed.
+// ValidateTransition checks if transitioning from → to is allowed by the
+// registered definition of the workflow type.
 func ValidateTransition(wtype WorkflowType, from, to Phase) error {
-	allowed, ok := validTransitions[wtype][from]
+	def, ok := Definition(wtype)
+	if !ok {
+		return fmt.Errorf("unknown workflow type %q", wtype)
+	}
+	phase, ok := def.Phase(from)
 	if !ok {
 		return fmt.Errorf("unknown phase %q for workflow type %q", from, wtype)
 	}
-	for _, p := range allowed {
+	for _, p := range phase.Next {
 		if p == to {
 			return nil
 		}
@@ -91,14 +70,27 @@ func ValidateTransition(wtype WorkflowType, from, to Phase) error {
 	return fmt.Errorf("invalid transition %q → %q for workflow type %q", from, to, wtype)
 }
 
-// InitialPhase returns the starting phase for a workflow type.
+// InitialPhase returns the starting phase for a workflow type. Unknown
+// types start in plan, like spec workflows.
 func InitialPhase(wtype WorkflowType) Phase {
-	switch wtype {
-	case WorkflowBug:
-		return PhaseAnalyze
-	case WorkflowE2E:
-		return PhaseSetup
-	default:
-		return PhasePlan
+	if def, ok := Definition(wtype); ok {
+		return def.InitialPhase
+	}
+	return PhasePlan
+}
+
+// KnownPhases returns every phase used by any registered workflow type, sorted.
+func KnownPhases() []Phase {
+	seen := map[Phase]bool{}
+	for _, def := range Definitions() {
+		for _, p := range def.Phases {
+			seen[p.Name] = true
+		}
+	}
+	phases := make([]Phase, 0, len(seen))
+	for p := range seen {
+		phases = append(phases, p)
 	}
+	sort.Slice(phases, func(i, j int) bool { return phases[i] < phases[j] })
+	return phases
 }
@@ -0,0 +1,154 @@
+package orchestration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TemplateDir holds reusable workflow templates, relative to the project
+// root. Each *.json file defines one template.
+const TemplateDir = ".stratus/templates"
+
+// WorkflowTemplate pre-populates a new workflow of Type with tasks, a plan
+// skeleton and delegated agents, so recurring kinds of work do not start
+// from scratch.
+type WorkflowTemplate struct {
+	Name        string       `json:"name"`
+	Type        WorkflowType `json:"type"`
+	Description string       `json:"description,omitempty"`
+	// Complexity is used when the caller does not choose one.
+	Complexity Complexity `json:"complexity,omitempty"`
+	Tasks      []string   `json:"tasks,omitempty"`
+	// Plan is markdown; {{id}} and {{title}} are replaced with the
+	// workflow's ID and title.
+	Plan string `json:"plan,omitempty"`
+	// Delegated maps phases to the agents recorded as delegated in them.
+	Delegated map[Phase][]string `json:"delegated,omitempty"`
+	// Source is the file the template was loaded from.
+	Source string `json:"source,omitempty"`
+}
+
+// templates holds the templates loaded with the workflow definitions.
+var templates = struct {
+	sync.RWMutex
+	byName map[string]WorkflowTemplate
+}{byName: map[string]WorkflowTemplate{}}
+
+// loadTemplates replaces the registered templates with the valid ones under
+// projectRoot's TemplateDir. Templates are checked against the registered
+// definitions, so it runs after they are loaded.
+func loadTemplates(projectRoot string) error {
+	loaded, err := ReadWorkflowTemplates(projectRoot)
+	byName := make(map[string]WorkflowTemplate, len(loaded))
+	for _, t := range loaded {
+		byName[t.Name] = t
+	}
+	templates.Lock()
+	templates.byName = byName
+	templates.Unlock()
+	return err
+}
+
+// ReadWorkflowTemplates parses and validates the template files under
+// projectRoot's TemplateDir without registering them. A missing directory
+// is not an error.
+func ReadWorkflowTemplates(projectRoot string) ([]WorkflowTemplate, error) {
+	paths, _ := filepath.Glob(filepath.Join(projectRoot, TemplateDir, "*.json"))
+	sort.Strings(paths)
+
+	var out []WorkflowTemplate
+	var errs []error
+	seen := map[string]string{}
+	for _, path := range paths {
+		rel := filepath.ToSlash(filepath.Join(TemplateDir, filepath.Base(path)))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rel, err))
+			continue
+		}
+		var t WorkflowTemplate
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&t); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rel, err))
+			continue
+		}
+		if err := t.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rel, err))
+			continue
+		}
+		if prev, ok := seen[t.Name]; ok {
+			errs = append(errs, fmt.Errorf("%s: template %q is already defined in %s", rel, t.Name, prev))
+			continue
+		}
+		seen[t.Name] = rel
+		t.Source = rel
+		out = append(out, t)
+	}
+	return out, errors.Join(errs...)
+}
+
+// Validate checks that a template names a registered workflow type and only
+// delegates in phases that type has.
+func (t WorkflowTemplate) Validate() error {
+	if !workflowTypeRe.MatchString(t.Name) {
+		return fmt.Errorf("invalid template name %q: use lowercase letters, digits and dashes", t.Name)
+	}
+	def, ok := Definition(t.Type)
+	if !ok {
+		return fmt.Errorf("unknown workflow type %q", t.Type)
+	}
+	switch t.Complexity {
+	case "", ComplexitySimple, ComplexityComplex:
+	default:
+		return fmt.Errorf("invalid complexity %q", t.Complexity)
+	}
+	for i, task := range t.Tasks {
+		if strings.TrimSpace(task) == "" {
+			return fmt.Errorf("task %d is empty", i+1)
+		}
+	}
+	for phase, agents := range t.Delegated {
+		if _, ok := def.Phase(phase); !ok {
+			return fmt.Errorf("delegated agents for phase %q, which %q does not have", phase, t.Type)
+		}
+		for _, a := range agents {
+			if strings.TrimSpace(a) == "" {
+				return fmt.Errorf("phase %q: empty agent name", phase)
+			}
+		}
+	}
+	return nil
+}
+
+// Template returns the registered template with the given name.
+func Template(name string) (WorkflowTemplate, bool) {
+	templates.RLock()
+	defer templates.RUnlock()
+	t, ok := templates.byName[name]
+	return t, ok
+}
+
+// Templates returns the registered templates, sorted by name.
+func Templates() []WorkflowTemplate {
+	templates.RLock()
+	defer templates.RUnlock()
+	out := make([]WorkflowTemplate, 0, len(templates.byName))
+	for _, t := range templates.byName {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// renderPlan fills the template's plan skeleton for one workflow.
+func (t WorkflowTemplate) renderPlan(id, title string) string {
+	return strings.NewReplacer("{{id}}", id, "{{title}}", title).Replace(t.Plan)
+}
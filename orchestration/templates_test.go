@@ -0,0 +1,145 @@
+package orchestration
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/db"
+)
+
+func writeTemplate(t *testing.T, root, name, content string) {
+	t.Helper()
+	dir := filepath.Join(root, TemplateDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadWorkflowTemplates(t *testing.T) {
+	resetDefinitions(t)
+	root := t.TempDir()
+	writeTemplate(t, root, "endpoint.json", `{"name": "api-endpoint", "type": "spec", "complexity": "complex",
+	  "tasks": ["Add handler", "Register route"], "plan": "# {{title}} ({{id}})",
+	  "delegated": {"implement": ["delivery-backend-engineer"]}}`)
+	writeTemplate(t, root, "bad-type.json", `{"name": "nope", "type": "missing"}`)
+	writeTemplate(t, root, "bad-phase.json", `{"name": "nope2", "type": "bug", "delegated": {"design": ["x"]}}`)
+
+	err := LoadWorkflowDefinitions(root)
+	if err == nil || !strings.Contains(err.Error(), "bad-type.json") || !strings.Contains(err.Error(), "bad-phase.json") {
+		t.Fatalf("expected errors for invalid templates, got %v", err)
+	}
+	if got := Templates(); len(got) != 1 || got[0].Name != "api-endpoint" || got[0].Source != ".stratus/templates/endpoint.json" {
+		t.Fatalf("templates: %+v", got)
+	}
+
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	coord := NewCoordinator(database)
+
+	if _, err := coord.StartWith("bug-1", WorkflowBug, "", "Wrong type", StartOptions{Template: "api-endpoint"}); !errors.Is(err, ErrInvalidStart) {
+		t.Errorf("template for another type: %v", err)
+	}
+	state, err := coord.StartWith("spec-users", WorkflowSpec, "", "Users API", StartOptions{Template: "api-endpoint"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Complexity != ComplexityComplex || state.Template != "api-endpoint" {
+		t.Errorf("complexity/template: %s %q", state.Complexity, state.Template)
+	}
+	if state.TotalTasks != 2 || state.Tasks[1].Title != "Register route" || state.Tasks[1].Status != "pending" {
+		t.Errorf("tasks: %+v", state.Tasks)
+	}
+	if state.PlanContent != "# Users API (spec-users)" {
+		t.Errorf("plan: %q", state.PlanContent)
+	}
+	if got := state.Delegated["implement"]; !reflect.DeepEqual(got, []string{"delivery-backend-engineer"}) {
+		t.Errorf("delegated: %v", state.Delegated)
+	}
+
+	// The event log alone reproduces the templated state.
+	events, err := coord.Events("spec-users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	projected, err := Project(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(projected, state) {
+		t.Errorf("projection differs:\n got %+v\nwant %+v", projected, state)
+	}
+}
+
+func TestCoordinator_ChildWorkflows(t *testing.T) {
+	resetDefinitions(t)
+	root := t.TempDir()
+	writeDefinition(t, root, "release.json", `{"type": "release", "phases": [
+	  {"name": "build", "next": ["verify"]},
+	  {"name": "verify", "next": ["complete"], "spawns": ["bug"],
+	   "checks": [{"name": "children", "children": true}]},
+	  {"name": "complete"}]}`)
+	if err := LoadWorkflowDefinitions(root); err != nil {
+		t.Fatal(err)
+	}
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	coord := NewCoordinator(database)
+	if _, err := coord.Start("rel-1", "release", ComplexitySimple, "Release"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := coord.StartWith("bug-early", WorkflowBug, ComplexitySimple, "Too early", StartOptions{ParentID: "rel-1"}); !errors.Is(err, ErrInvalidStart) {
+		t.Errorf("build cannot spawn: %v", err)
+	}
+	if _, err := coord.StartWith("bug-orphan", WorkflowBug, ComplexitySimple, "Orphan", StartOptions{ParentID: "missing"}); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Errorf("missing parent: %v", err)
+	}
+	if _, err := coord.Transition("rel-1", "verify"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := coord.StartWith("e2e-1", WorkflowE2E, ComplexitySimple, "Not allowed", StartOptions{ParentID: "rel-1"}); !errors.Is(err, ErrInvalidStart) {
+		t.Errorf("verify cannot spawn e2e: %v", err)
+	}
+	child, err := coord.StartWith("bug-1", WorkflowBug, ComplexitySimple, "Found in verify", StartOptions{ParentID: "rel-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if child.ParentID != "rel-1" {
+		t.Errorf("child parent: %q", child.ParentID)
+	}
+	parent, err := coord.Get("rel-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parent.Children, []string{"bug-1"}) {
+		t.Errorf("parent children: %v", parent.Children)
+	}
+
+	_, err = coord.Transition("rel-1", PhaseComplete)
+	var failed *CheckFailedError
+	if !errors.As(err, &failed) || !strings.Contains(err.Error(), "bug-1") {
+		t.Fatalf("open child must block the parent, got %v", err)
+	}
+
+	for _, p := range []Phase{PhaseFix, PhaseReview, PhaseComplete} {
+		if _, err := coord.Transition("bug-1", p); err != nil {
+			t.Fatalf("child to %s: %v", p, err)
+		}
+	}
+	if _, err := coord.Transition("rel-1", PhaseComplete); err != nil {
+		t.Fatalf("parent after child completed: %v", err)
+	}
+}
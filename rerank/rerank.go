@@ -0,0 +1,182 @@
+// Package rerank rescores retrieval candidates against a query, either with
+// a cross-encoder server or by asking an LLM. Scores are in [0, 1], higher is
+// more relevant, one per candidate in input order.
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MartinNevlaha/stratus-v2/internal/insight/llm"
+)
+
+// Reranker scores docs by relevance to query.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, docs []string) ([]float64, error)
+}
+
+// maxDocRunes caps each candidate's text; rerankers only need the gist.
+const maxDocRunes = 1500
+
+// HTTP calls a cross-encoder rerank endpoint. The request carries both the
+// TEI ("texts") and Jina/Cohere ("documents") field names, and both response
+// shapes are understood.
+type HTTP struct {
+	endpoint string
+	model    string
+	apiKey   string
+	client   *http.Client
+}
+
+// NewHTTP creates a cross-encoder reranker posting to endpoint.
+func NewHTTP(endpoint, model, apiKey string, timeout time.Duration) *HTTP {
+	if timeout <= 0 {
+		timeout = 20 * time.Second
+	}
+	return &HTTP{endpoint: endpoint, model: model, apiKey: apiKey, client: &http.Client{Timeout: timeout}}
+}
+
+// Rerank implements Reranker.
+func (h *HTTP) Rerank(ctx context.Context, query string, docs []string) ([]float64, error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+	texts := make([]string, len(docs))
+	for i, d := range docs {
+		texts[i] = truncate(d)
+	}
+	body := map[string]any{"query": query, "texts": texts, "documents": texts, "top_n": len(texts)}
+	if h.model != "" {
+		body["model"] = h.model
+	}
+	payload, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("rerank: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.apiKey)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rerank: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return nil, fmt.Errorf("rerank: read response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("rerank: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return parseScores(data, len(docs))
+}
+
+type scoredIndex struct {
+	Index          int      `json:"index"`
+	Score          *float64 `json:"score"`
+	RelevanceScore *float64 `json:"relevance_score"`
+}
+
+// parseScores reads a TEI array or a {"results": [...]} object into one score
+// per doc. Logit-style scores outside [0, 1] are squashed with a sigmoid.
+func parseScores(data []byte, n int) ([]float64, error) {
+	var items []scoredIndex
+	if err := json.Unmarshal(data, &items); err != nil {
+		var wrapped struct {
+			Results []scoredIndex `json:"results"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, fmt.Errorf("rerank: decode response: %w", err)
+		}
+		items = wrapped.Results
+	}
+	scores := make([]float64, n)
+	for _, it := range items {
+		if it.Index < 0 || it.Index >= n {
+			continue
+		}
+		switch {
+		case it.RelevanceScore != nil:
+			scores[it.Index] = *it.RelevanceScore
+		case it.Score != nil:
+			scores[it.Index] = *it.Score
+		}
+	}
+	for i, s := range scores {
+		if s < 0 || s > 1 {
+			scores[i] = sigmoid(s)
+		}
+	}
+	return scores, nil
+}
+
+// LLM asks a chat model to grade each candidate from 0 to 10.
+type LLM struct {
+	client llm.Client
+}
+
+// NewLLM creates an LLM-backed reranker.
+func NewLLM(client llm.Client) *LLM {
+	return &LLM{client: client}
+}
+
+const llmSystemPrompt = `You grade search results for relevance to a developer's query.
+Score every passage from 0 (irrelevant) to 10 (directly answers the query).
+Reply with JSON only: {"scores": [<one number per passage, in order>]}`
+
+// Rerank implements Reranker.
+func (l *LLM) Rerank(ctx context.Context, query string, docs []string) ([]float64, error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Query: %s\n", query)
+	for i, d := range docs {
+		fmt.Fprintf(&sb, "\n[%d]\n%s\n", i+1, truncate(d))
+	}
+	resp, err := l.client.Complete(ctx, llm.CompletionRequest{
+		SystemPrompt:   llmSystemPrompt,
+		Messages:       []llm.Message{{Role: "user", Content: sb.String()}},
+		MaxTokens:      16 + 8*len(docs),
+		Temperature:    0,
+		ResponseFormat: "json",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rerank: %w", err)
+	}
+	var out struct {
+		Scores []float64 `json:"scores"`
+	}
+	if err := llm.ParseJSONResponse(resp.Content, &out); err != nil {
+		return nil, fmt.Errorf("rerank: %w", err)
+	}
+	if len(out.Scores) != len(docs) {
+		return nil, fmt.Errorf("rerank: got %d scores for %d passages", len(out.Scores), len(docs))
+	}
+	scores := make([]float64, len(docs))
+	for i, s := range out.Scores {
+		scores[i] = min(max(s/10, 0), 1)
+	}
+	return scores, nil
+}
+
+func truncate(s string) string {
+	r := []rune(s)
+	if len(r) <= maxDocRunes {
+		return s
+	}
+	return string(r[:maxDocRunes])
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
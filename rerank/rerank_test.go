@@ -0,0 +1,59 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MartinNevlaha/stratus-v2/internal/insight/llm"
+)
+
+func TestHTTP_ParsesTEIAndCohereResponses(t *testing.T) {
+	for name, body := range map[string]string{
+		"tei":    `[{"index":1,"score":0.9},{"index":0,"score":0.2}]`,
+		"cohere": `{"results":[{"index":1,"relevance_score":0.9},{"index":0,"relevance_score":0.2}]}`,
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Query string   `json:"query"`
+				Texts []string `json:"texts"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req.Query != "auth" || len(req.Texts) != 2 {
+				t.Errorf("%s: request = %+v", name, req)
+			}
+			w.Write([]byte(body))
+		}))
+		scores, err := NewHTTP(srv.URL, "", "", 0).Rerank(context.Background(), "auth", []string{"a", "b"})
+		srv.Close()
+		if err != nil || len(scores) != 2 || scores[0] != 0.2 || scores[1] != 0.9 {
+			t.Errorf("%s: scores = %v, %v", name, scores, err)
+		}
+	}
+
+	// Logits are squashed into [0, 1] without changing their order.
+	scores, _ := parseScores([]byte(`[{"index":0,"score":-3.5},{"index":1,"score":4.2}]`), 2)
+	if scores[0] <= 0 || scores[0] >= scores[1] || scores[1] >= 1 {
+		t.Errorf("logit scores = %v", scores)
+	}
+}
+
+type fakeLLM struct{ content string }
+
+func (f fakeLLM) Complete(context.Context, llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	return &llm.CompletionResponse{Content: f.content}, nil
+}
+func (fakeLLM) Provider() string { return "fake" }
+func (fakeLLM) Model() string    { return "fake" }
+
+func TestLLM_GradesPassages(t *testing.T) {
+	scores, err := NewLLM(fakeLLM{"```json\n{\"scores\": [3, 10, 12]}\n```"}).Rerank(context.Background(), "q", []string{"a", "b", "c"})
+	if err != nil || scores[0] != 0.3 || scores[1] != 1 || scores[2] != 1 {
+		t.Errorf("scores = %v, %v", scores, err)
+	}
+	if _, err := NewLLM(fakeLLM{`{"scores": [1]}`}).Rerank(context.Background(), "q", []string{"a", "b"}); err == nil {
+		t.Error("short score list accepted")
+	}
+}